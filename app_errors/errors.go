@@ -8,21 +8,81 @@ const (
 	ErrInternalError  ErrorCode = "INTERNAL_ERROR"
 	ErrNotImplemented ErrorCode = "NOT_IMPLEMENTED"
 	ErrNotFound       ErrorCode = "NOT_FOUND"
+	ErrInvalidFilter  ErrorCode = "INVALID_FILTER"
+	ErrInvalidSort    ErrorCode = "INVALID_SORT"
+	ErrInvalidCursor  ErrorCode = "INVALID_CURSOR"
+	ErrUnauthorized   ErrorCode = "UNAUTHORIZED"
+	ErrUnavailable    ErrorCode = "SERVICE_UNAVAILABLE"
+
+	ErrUpgradeRequired    ErrorCode = "UPGRADE_REQUIRED"
+	ErrOriginNotAllowed   ErrorCode = "ORIGIN_NOT_ALLOWED"
+	ErrInvalidMessageType ErrorCode = "INVALID_MESSAGE_TYPE"
 
 	ErrInvalidTotalWagerValue   ErrorCode = "INVALID_TOTAL_WAGER_VALUE"
 	ErrInvalidOdds              ErrorCode = "INVALID_ODDS"
 	ErrInvalidSellingPercentage ErrorCode = "INVALID_SELLING_PERCENTAGE"
 	ErrInvalidSellingPrice      ErrorCode = "INVALID_SELLING_PRICE"
+	ErrInvalidPricing           ErrorCode = "INVALID_PRICING"
+	ErrInvalidFloorPrice        ErrorCode = "INVALID_FLOOR_PRICE"
+	ErrInvalidDecaySeconds      ErrorCode = "INVALID_DECAY_SECONDS"
+	ErrInvalidStepPercentage    ErrorCode = "INVALID_STEP_PERCENTAGE"
 
 	ErrInvalidWagerID     ErrorCode = "INVALID_WAGER_ID"
 	ErrInvalidBuyingPrice ErrorCode = "INVALID_BUYING_PRICE"
 	ErrWagerSoldOut       ErrorCode = "WAGER_SOLD_OUT"
+	ErrInvalidQuantity    ErrorCode = "INVALID_QUANTITY"
+	// ErrTotalValueSold and ErrSellOutLimitReached reject purchases like ErrWagerSoldOut, by other sell out rules
+	ErrTotalValueSold      ErrorCode = "TOTAL_VALUE_SOLD"
+	ErrSellOutLimitReached ErrorCode = "SELL_OUT_LIMIT_REACHED"
+
+	ErrInvalidOrderSide  ErrorCode = "INVALID_ORDER_SIDE"
+	ErrInvalidOrderPrice ErrorCode = "INVALID_ORDER_PRICE"
+	ErrNotWagerSeller    ErrorCode = "NOT_WAGER_SELLER"
+	ErrOrderNotOpen      ErrorCode = "ORDER_NOT_OPEN"
+
+	ErrInvalidAuction       ErrorCode = "INVALID_AUCTION"
+	ErrInvalidAuctionEndsAt ErrorCode = "INVALID_AUCTION_ENDS_AT"
+	ErrInvalidBidPrice      ErrorCode = "INVALID_BID_PRICE"
+	ErrSellerCannotBid      ErrorCode = "SELLER_CANNOT_BID"
+	// ErrAuctionOpen rejects purchases and orders of wager until its auction is closed
+	ErrAuctionOpen   ErrorCode = "AUCTION_OPEN"
+	ErrAuctionClosed ErrorCode = "AUCTION_CLOSED"
+
+	ErrInvalidOutcome  ErrorCode = "INVALID_OUTCOME"
+	ErrWagerSettled    ErrorCode = "WAGER_SETTLED"
+	ErrWagerNotSettled ErrorCode = "WAGER_NOT_SETTLED"
+	ErrNotOperator     ErrorCode = "NOT_OPERATOR"
+
+	ErrInvalidUsername    ErrorCode = "INVALID_USERNAME"
+	ErrInvalidPassword    ErrorCode = "INVALID_PASSWORD"
+	ErrUsernameTaken      ErrorCode = "USERNAME_TAKEN"
+	ErrInvalidCredentials ErrorCode = "INVALID_CREDENTIALS"
+
+	ErrInvalidAmount     ErrorCode = "INVALID_AMOUNT"
+	ErrInsufficientFunds ErrorCode = "INSUFFICIENT_FUNDS"
+
+	ErrInvalidIdempotencyKey    ErrorCode = "INVALID_IDEMPOTENCY_KEY"
+	ErrIdempotencyKeyReused     ErrorCode = "IDEMPOTENCY_KEY_REUSED"
+	ErrIdempotencyKeyInProgress ErrorCode = "IDEMPOTENCY_KEY_IN_PROGRESS"
 )
 
 // ErrorResponse is response object for errors
 type ErrorResponse struct {
 	Status int       `json:"-"`
 	Code   ErrorCode `json:"error"`
+	// Details are all violations of request validation, Code is code of the first one
+	Details []FieldError `json:"details,omitempty"`
+}
+
+// FieldError is violation of single validation rule by request field
+type FieldError struct {
+	// Field is json name of request field, empty for violations of whole body
+	Field string `json:"field,omitempty"`
+	// Rule is name of violated rule, ex. min, max, oneof
+	Rule    string `json:"rule"`
+	Message string `json:"message"`
+	// Limits are parameters of violated rule by name, ex. {"min": "1"}
+	Limits map[string]string `json:"limits,omitempty"`
 }
 
 // Error returns error string message
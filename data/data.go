@@ -0,0 +1,11 @@
+// Package data embeds database resources into the app binary
+package data
+
+import (
+	"embed"
+)
+
+// Migrations contains versioned schema migrations per sql dialect, ex. migrations/postgres/0001_init.up.sql
+//
+//go:embed migrations
+var Migrations embed.FS
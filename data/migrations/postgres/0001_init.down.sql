@@ -0,0 +1,3 @@
+drop table if exists purchases;
+
+drop table if exists wager;
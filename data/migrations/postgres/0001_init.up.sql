@@ -1,3 +1,5 @@
+-- Initial schema. Uses 'if not exists' so databases created by former data/init_database.sql adopt it.
+
 create table if not exists wager (
     id bigserial not null constraint wager_pk primary key,
     total_wager_value integer not null default 0,
@@ -21,4 +23,4 @@ create table if not exists purchases (
         foreign key (wager_id)
             references wager (id)
             on update cascade on delete cascade
-);
\ No newline at end of file
+);
@@ -0,0 +1,5 @@
+drop table if exists settlements;
+
+alter table wager drop column if exists settled_at;
+alter table wager drop column if exists outcome;
+alter table wager drop column if exists status;
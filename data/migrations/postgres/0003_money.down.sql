@@ -0,0 +1,9 @@
+alter table settlements
+    alter column payout type real using payout::real;
+
+alter table purchases
+    alter column buying_price type real using buying_price::real;
+
+alter table wager
+    alter column selling_price type real using selling_price::real,
+    alter column current_selling_price type real using current_selling_price::real;
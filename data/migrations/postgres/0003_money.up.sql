@@ -0,0 +1,11 @@
+-- Store money as exact decimals instead of floats. Existing amounts are rounded to cents.
+
+alter table wager
+    alter column selling_price type numeric(14, 2) using round(selling_price::numeric, 2),
+    alter column current_selling_price type numeric(14, 2) using round(current_selling_price::numeric, 2);
+
+alter table purchases
+    alter column buying_price type numeric(14, 2) using round(buying_price::numeric, 2);
+
+alter table settlements
+    alter column payout type numeric(14, 2) using round(payout::numeric, 2);
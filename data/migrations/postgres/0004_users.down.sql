@@ -0,0 +1,5 @@
+alter table purchases drop column if exists buyer_id;
+
+alter table wager drop column if exists seller_id;
+
+drop table if exists users;
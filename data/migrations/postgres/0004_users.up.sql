@@ -0,0 +1,15 @@
+-- User accounts with sellers of wagers and buyers of purchases. Existing records stay anonymous.
+
+create table users (
+    id bigserial not null constraint users_pk primary key,
+    username varchar(32) not null constraint users_username_uq unique,
+    password_hash varchar(255) not null,
+    created_at timestamp default now(),
+    updated_at timestamp default null
+);
+
+alter table wager add column seller_id bigint default null
+    constraint wager_seller_fk references users (id) on update cascade on delete set null;
+
+alter table purchases add column buyer_id bigint default null
+    constraint purchases_buyer_fk references users (id) on update cascade on delete set null;
@@ -0,0 +1,48 @@
+-- Wallets of users backed by double-entry ledger. Entries of every ledger transaction sum to zero,
+-- external account is counterparty of deposits and withdrawals. Existing users get empty wallets.
+
+create table accounts (
+    id bigserial not null constraint accounts_pk primary key,
+    user_id bigint default null constraint accounts_user_uq unique,
+    kind varchar(16) not null,
+    balance numeric(14, 2) not null default 0,
+    created_at timestamp default now(),
+    updated_at timestamp default null,
+    constraint accounts_user_fk
+        foreign key (user_id)
+            references users (id)
+            on update cascade on delete restrict
+);
+
+insert into accounts (kind) values ('external');
+
+insert into accounts (user_id, kind) select id, 'user' from users order by id;
+
+create table ledger_transactions (
+    id bigserial not null constraint ledger_transactions_pk primary key,
+    kind varchar(16) not null,
+    purchase_id bigint default null,
+    created_at timestamp default now(),
+    constraint ledger_transactions_purchase_fk
+        foreign key (purchase_id)
+            references purchases (id)
+            on update cascade on delete set null
+);
+
+create table ledger_entries (
+    id bigserial not null constraint ledger_entries_pk primary key,
+    transaction_id bigint not null,
+    account_id bigint not null,
+    amount numeric(14, 2) not null,
+    created_at timestamp default now(),
+    constraint ledger_entries_transaction_fk
+        foreign key (transaction_id)
+            references ledger_transactions (id)
+            on update cascade on delete restrict,
+    constraint ledger_entries_account_fk
+        foreign key (account_id)
+            references accounts (id)
+            on update cascade on delete restrict
+);
+
+create index ledger_entries_account_idx on ledger_entries (account_id, id);
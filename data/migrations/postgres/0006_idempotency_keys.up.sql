@@ -0,0 +1,20 @@
+-- Idempotency keys of retried POST requests with recorded response replayed to retries.
+-- Key is unique per user, expired key can be reused by next request.
+
+create table idempotency_keys (
+    id bigserial not null constraint idempotency_keys_pk primary key,
+    user_id bigint not null,
+    idempotency_key varchar(255) not null,
+    request_hash varchar(64) not null,
+    response_status integer default null,
+    response_body text default null,
+    created_at timestamp default now(),
+    expires_at timestamp not null,
+    constraint idempotency_keys_user_key_uq unique (user_id, idempotency_key),
+    constraint idempotency_keys_user_fk
+        foreign key (user_id)
+            references users (id)
+            on update cascade on delete cascade
+);
+
+create index idempotency_keys_expires_at_idx on idempotency_keys (expires_at);
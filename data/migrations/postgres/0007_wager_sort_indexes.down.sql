@@ -0,0 +1,4 @@
+drop index if exists wager_created_at_idx;
+drop index if exists wager_percentage_sold_idx;
+drop index if exists wager_odds_idx;
+drop index if exists wager_current_selling_price_idx;
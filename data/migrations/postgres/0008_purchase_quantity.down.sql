@@ -0,0 +1,3 @@
+alter table purchases
+    drop column total_price,
+    drop column quantity;
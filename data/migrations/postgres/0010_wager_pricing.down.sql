@@ -0,0 +1,6 @@
+alter table wager
+    drop column priced_at,
+    drop column step_percentage,
+    drop column decay_seconds,
+    drop column floor_price,
+    drop column pricing;
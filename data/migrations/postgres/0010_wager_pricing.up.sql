@@ -0,0 +1,9 @@
+-- Pricing strategy of wager current selling price with its parameters. Existing wagers keep pricing
+-- current selling price at last buying price. priced_at is time current selling price was last set.
+
+alter table wager
+    add column pricing varchar(16) not null default 'last_trade',
+    add column floor_price numeric(14, 2) not null default 0,
+    add column decay_seconds integer not null default 0,
+    add column step_percentage real not null default 0,
+    add column priced_at timestamp default null;
@@ -0,0 +1,25 @@
+-- Order book of wagers. Users bid for units of wager, seller of wager asks for its units. Orders are matched
+-- by price-time priority into purchases, open orders of wager are listed by side and price.
+
+create table orders (
+    id bigserial not null constraint orders_pk primary key,
+    wager_id bigint not null,
+    user_id bigint not null,
+    side varchar(3) not null,
+    price numeric(14, 2) not null,
+    quantity integer not null,
+    filled integer not null default 0,
+    status varchar(16) not null default 'open',
+    created_at timestamp default now(),
+    updated_at timestamp default null,
+    constraint orders_wager_fk
+        foreign key (wager_id)
+            references wager (id)
+            on update cascade on delete cascade,
+    constraint orders_user_fk
+        foreign key (user_id)
+            references users (id)
+            on update cascade on delete cascade
+);
+
+create index orders_book_idx on orders (wager_id, side, status, price, id);
@@ -0,0 +1,8 @@
+drop table if exists auction_bids;
+
+drop index if exists wager_auction_due_idx;
+
+alter table wager
+    drop column auction_closed_at,
+    drop column auction_ends_at,
+    drop column auction;
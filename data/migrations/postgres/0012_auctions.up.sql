@@ -0,0 +1,30 @@
+-- Sealed-bid auction mode of wagers. auction is empty for wagers sold first-come-first-served, otherwise
+-- first_price or second_price. Bids are collected until auction_ends_at and awarded when auction is closed.
+
+alter table wager
+    add column auction varchar(16) not null default '',
+    add column auction_ends_at timestamp default null,
+    add column auction_closed_at timestamp default null;
+
+create index wager_auction_due_idx on wager (auction_ends_at) where auction_closed_at is null;
+
+create table auction_bids (
+    id bigserial not null constraint auction_bids_pk primary key,
+    wager_id bigint not null,
+    user_id bigint not null,
+    price numeric(14, 2) not null,
+    quantity integer not null,
+    awarded integer not null default 0,
+    created_at timestamp default now(),
+    updated_at timestamp default null,
+    constraint auction_bids_wager_fk
+        foreign key (wager_id)
+            references wager (id)
+            on update cascade on delete cascade,
+    constraint auction_bids_user_fk
+        foreign key (user_id)
+            references users (id)
+            on update cascade on delete cascade
+);
+
+create index auction_bids_wager_idx on auction_bids (wager_id, price, id);
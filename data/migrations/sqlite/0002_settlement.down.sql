@@ -0,0 +1,5 @@
+drop table if exists settlements;
+
+alter table wager drop column settled_at;
+alter table wager drop column outcome;
+alter table wager drop column status;
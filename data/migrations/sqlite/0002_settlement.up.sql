@@ -0,0 +1,24 @@
+-- Wager status and outcome with per purchase settlement records.
+
+alter table wager add column status varchar(16) not null default 'open';
+alter table wager add column outcome varchar(16) default null;
+alter table wager add column settled_at timestamp default null;
+
+update wager set status = 'sold_out' where amount_sold >= total_wager_value;
+
+create table settlements (
+    id integer not null constraint settlements_pk primary key autoincrement,
+    wager_id bigint not null,
+    purchase_id bigint not null constraint settlements_purchase_uq unique,
+    outcome varchar(16) not null,
+    payout real not null default 0,
+    created_at timestamp default current_timestamp,
+    constraint settlements_wager_fk
+        foreign key (wager_id)
+            references wager (id)
+            on update cascade on delete cascade,
+    constraint settlements_purchase_fk
+        foreign key (purchase_id)
+            references purchases (id)
+            on update cascade on delete cascade
+);
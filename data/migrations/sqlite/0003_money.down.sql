@@ -0,0 +1,2 @@
+-- Nothing to revert, see 0003_money.up.sql
+select 1;
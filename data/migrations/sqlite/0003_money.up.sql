@@ -0,0 +1,10 @@
+-- Store money as exact decimals instead of floats.
+-- SQLite keeps numeric and real values alike as 8-byte floats and column types can not be altered in place,
+-- so schema is kept. Amounts of 2 decimal places are restored exactly by rounding on read (see money.Money.Scan).
+-- Existing amounts are rounded to cents.
+
+update wager set selling_price = round(selling_price, 2), current_selling_price = round(current_selling_price, 2);
+
+update purchases set buying_price = round(buying_price, 2);
+
+update settlements set payout = round(payout, 2);
@@ -0,0 +1,5 @@
+alter table purchases drop column buyer_id;
+
+alter table wager drop column seller_id;
+
+drop table if exists users;
@@ -0,0 +1,5 @@
+drop table if exists ledger_entries;
+
+drop table if exists ledger_transactions;
+
+drop table if exists accounts;
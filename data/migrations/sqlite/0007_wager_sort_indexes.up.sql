@@ -0,0 +1,7 @@
+-- Indexes of sortable wager listing fields, id breaks ties so keyset pages are stable.
+-- Wagers without purchases have null percentage_sold and are sorted as 0 sold.
+
+create index wager_current_selling_price_idx on wager (current_selling_price, id);
+create index wager_odds_idx on wager (odds, id);
+create index wager_percentage_sold_idx on wager (coalesce(percentage_sold, 0), id);
+create index wager_created_at_idx on wager (created_at, id);
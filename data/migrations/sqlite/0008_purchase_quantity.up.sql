@@ -0,0 +1,8 @@
+-- Purchases of several units of wager. Buying price stays price of one unit, total price is paid for all units.
+-- Existing purchases are of one unit.
+
+alter table purchases add column quantity integer not null default 1;
+
+alter table purchases add column total_price numeric(14, 2) not null default 0;
+
+update purchases set total_price = buying_price;
@@ -0,0 +1,5 @@
+-- Status of wagers sold out at total wager value is restored, wagers sold out by percentage stay as they are.
+
+update wager set status = 'open'
+where status = 'sold_out'
+  and coalesce(amount_sold, 0) < total_wager_value;
@@ -0,0 +1,12 @@
+-- Wagers are sold out at selling_percentage of total_wager_value (rounded down, at least 1 unit) by default
+-- sell out policy, former versions marked them sold out at total_wager_value only. Status of unsettled wagers
+-- is recomputed, wagers of other configured policies follow it on their next purchase.
+-- Casting positive real to integer rounds it down.
+
+update wager set status = 'sold_out'
+where status = 'open'
+  and coalesce(amount_sold, 0) >= max(cast(total_wager_value * selling_percentage / 100 + 0.000001 as integer), 1);
+
+update wager set status = 'open'
+where status = 'sold_out'
+  and coalesce(amount_sold, 0) < max(cast(total_wager_value * selling_percentage / 100 + 0.000001 as integer), 1);
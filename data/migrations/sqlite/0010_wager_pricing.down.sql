@@ -0,0 +1,9 @@
+alter table wager drop column priced_at;
+
+alter table wager drop column step_percentage;
+
+alter table wager drop column decay_seconds;
+
+alter table wager drop column floor_price;
+
+alter table wager drop column pricing;
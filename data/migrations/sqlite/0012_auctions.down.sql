@@ -0,0 +1,9 @@
+drop table if exists auction_bids;
+
+drop index if exists wager_auction_due_idx;
+
+alter table wager drop column auction_closed_at;
+
+alter table wager drop column auction_ends_at;
+
+alter table wager drop column auction;
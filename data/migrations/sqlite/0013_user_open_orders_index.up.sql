@@ -0,0 +1,3 @@
+-- Open bids of user across wagers are summed when user places bid, so they are not over user balance.
+
+create index orders_user_idx on orders (user_id, side, status);
@@ -0,0 +1,33 @@
+package dto
+
+import (
+	"time"
+
+	"github.com/vitthalaa/wager-app/money"
+)
+
+// PlaceBidRequest is sealed bid for units of auctioned wager
+type PlaceBidRequest struct {
+	UserID  uint32 `json:"-"`
+	WagerID uint32 `json:"-"`
+	// Price is price of one unit bidder pays at most, winners of second price auction may pay less
+	Price    money.Money `json:"price" validate:"min=1.00,max=1000000000.00" error:"INVALID_BID_PRICE"`
+	Quantity uint32      `json:"quantity" validate:"min=1,max=1000000" error:"INVALID_QUANTITY"`
+}
+
+// AuctionBidsRequest is request of bids of user for auctioned wager
+type AuctionBidsRequest struct {
+	UserID  uint32
+	WagerID uint32
+}
+
+// AuctionBid is bid visible to its bidder only
+type AuctionBid struct {
+	ID       uint32      `json:"id"`
+	WagerID  uint32      `json:"wager_id"`
+	Price    money.Money `json:"price"`
+	Quantity uint32      `json:"quantity"`
+	// Awarded is number of units bought by bid when auction was closed
+	Awarded  uint32     `json:"awarded"`
+	PlacedAt *time.Time `json:"placed_at"`
+}
@@ -0,0 +1,19 @@
+package dto
+
+// Health is result of liveness or readiness probe with status of each check
+type Health struct {
+	// Status is "ok" when all checks pass, "failing" otherwise
+	Status string            `json:"status"`
+	Checks map[string]string `json:"checks,omitempty"`
+}
+
+// OK returns true when all checks pass
+func (h Health) OK() bool {
+	return h.Status == HealthOK
+}
+
+// Health and check statuses
+const (
+	HealthOK      = "ok"
+	HealthFailing = "failing"
+)
@@ -0,0 +1,15 @@
+package dto
+
+// IdempotentRequest is request sent with Idempotency-Key header
+type IdempotentRequest struct {
+	UserID uint32
+	Key    string
+	// RequestHash is fingerprint of request, retries with same key must send same request
+	RequestHash string
+}
+
+// IdempotentResponse is recorded response of request with Idempotency-Key, replayed to its retries
+type IdempotentResponse struct {
+	Status int
+	Body   []byte
+}
@@ -0,0 +1,60 @@
+package dto
+
+import (
+	"time"
+
+	"github.com/vitthalaa/wager-app/money"
+)
+
+// PlaceOrderRequest ...
+type PlaceOrderRequest struct {
+	UserID  uint32 `json:"-"`
+	WagerID uint32 `json:"wager_id" validate:"min=1" error:"INVALID_WAGER_ID"`
+	// Side is bid to buy units of wager, or ask to sell them by seller of wager
+	Side string `json:"side" validate:"oneof=bid ask" error:"INVALID_ORDER_SIDE"`
+	// Price is limit price of one unit, bids buy at or below it and asks sell at or above it
+	Price    money.Money `json:"price" validate:"min=1.00,max=1000000000.00" error:"INVALID_ORDER_PRICE"`
+	Quantity uint32      `json:"quantity" validate:"min=1,max=1000000" error:"INVALID_QUANTITY"`
+}
+
+// OrderRequest is request of single order by its owner
+type OrderRequest struct {
+	UserID  uint32
+	OrderID uint32
+}
+
+// Order ...
+type Order struct {
+	ID       uint32      `json:"id"`
+	WagerID  uint32      `json:"wager_id"`
+	UserID   uint32      `json:"user_id"`
+	Side     string      `json:"side"`
+	Price    money.Money `json:"price"`
+	Quantity uint32      `json:"quantity"`
+	Filled   uint32      `json:"filled"`
+	Status   string      `json:"status"`
+	PlacedAt *time.Time  `json:"placed_at"`
+	// Purchases are fills of order made when it was placed
+	Purchases []WagerPurchase `json:"purchases,omitempty"`
+}
+
+// GetOrderBookRequest ...
+type GetOrderBookRequest struct {
+	WagerID uint32
+	// Levels is number of best prices of each side, defaults to 10
+	Levels uint32
+}
+
+// OrderBook is depth of open orders of wager, best prices first
+type OrderBook struct {
+	WagerID uint32           `json:"wager_id"`
+	Bids    []OrderBookLevel `json:"bids"`
+	Asks    []OrderBookLevel `json:"asks"`
+}
+
+// OrderBookLevel is open orders at same price
+type OrderBookLevel struct {
+	Price    money.Money `json:"price"`
+	Quantity uint32      `json:"quantity"`
+	Orders   uint32      `json:"orders"`
+}
@@ -0,0 +1,35 @@
+package dto
+
+import (
+	"time"
+
+	"github.com/vitthalaa/wager-app/money"
+)
+
+// SettleWagerRequest ...
+type SettleWagerRequest struct {
+	WagerID uint32 `json:"-"`
+	// UserID is user settling wager, only Operator can settle it
+	UserID   uint32 `json:"-"`
+	Operator bool   `json:"-"`
+	// Outcome is one of won, lost or void
+	Outcome string `json:"outcome" validate:"oneof=won lost void" error:"INVALID_OUTCOME"`
+}
+
+// WagerSettlement is settled wager result with payout of each purchase
+type WagerSettlement struct {
+	WagerID     uint32               `json:"wager_id"`
+	Status      string               `json:"status"`
+	Outcome     string               `json:"outcome"`
+	SettledAt   *time.Time           `json:"settled_at"`
+	TotalPayout money.Money          `json:"total_payout"`
+	Settlements []PurchaseSettlement `json:"settlements"`
+}
+
+// PurchaseSettlement ...
+type PurchaseSettlement struct {
+	ID         uint32      `json:"id"`
+	PurchaseID uint32      `json:"purchase_id"`
+	Outcome    string      `json:"outcome"`
+	Payout     money.Money `json:"payout"`
+}
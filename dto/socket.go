@@ -0,0 +1,44 @@
+package dto
+
+import (
+	"github.com/vitthalaa/wager-app/app_errors"
+	"github.com/vitthalaa/wager-app/money"
+)
+
+// Message types of WebSocket API
+const (
+	MessageSubscribe   = "subscribe"
+	MessageUnsubscribe = "unsubscribe"
+	MessageBuy         = "buy"
+	MessageAck         = "ack"
+	MessageError       = "error"
+	MessageEvent       = "event"
+)
+
+// ClientMessage is message sent by client over WebSocket
+type ClientMessage struct {
+	Type string `json:"type" validate:"oneof=subscribe unsubscribe buy" error:"INVALID_MESSAGE_TYPE"`
+	// ID is chosen by client, ack or error of the message carries it back
+	ID string `json:"id,omitempty"`
+	// WagerID of subscription or purchase, 0 subscribes to all wagers
+	WagerID uint32 `json:"wager_id,omitempty"`
+	// LastEventID resumes subscription after the event, like Last-Event-ID of wager streams
+	LastEventID uint64      `json:"last_event_id,omitempty"`
+	BuyingPrice money.Money `json:"buying_price,omitempty"`
+	Quantity    uint32      `json:"quantity,omitempty"`
+}
+
+// ServerMessage is ack, error or event sent to client over WebSocket
+type ServerMessage struct {
+	Type string `json:"type"`
+	// ID is id of client message acked or failed
+	ID string `json:"id,omitempty"`
+	// Purchase is set in ack of buy
+	Purchase *WagerPurchase          `json:"purchase,omitempty"`
+	Error    app_errors.ErrorCode    `json:"error,omitempty"`
+	Details  []app_errors.FieldError `json:"details,omitempty"`
+	// Event is type of event, ex. price_changed
+	Event   string `json:"event,omitempty"`
+	EventID uint64 `json:"event_id,omitempty"`
+	Wager   *Wager `json:"wager,omitempty"`
+}
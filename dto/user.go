@@ -0,0 +1,33 @@
+package dto
+
+import (
+	"time"
+)
+
+// RegisterRequest ...
+type RegisterRequest struct {
+	// Username is validated in lower case, usernames are case-insensitive
+	Username string `json:"username" validate:"minlen=3,maxlen=32,pattern=^[a-z0-9_.-]*$" error:"INVALID_USERNAME"`
+	// Password is limited to 72 bytes, bcrypt ignores the rest
+	Password string `json:"password" validate:"minlen=8,maxlen=72" error:"INVALID_PASSWORD"`
+}
+
+// LoginRequest ...
+type LoginRequest struct {
+	Username string `json:"username"`
+	Password string `json:"password"`
+}
+
+// User ...
+type User struct {
+	ID           uint32     `json:"id"`
+	Username     string     `json:"username"`
+	RegisteredAt *time.Time `json:"registered_at"`
+}
+
+// Token is bearer token to be sent in Authorization header
+type Token struct {
+	AccessToken string    `json:"access_token"`
+	TokenType   string    `json:"token_type"`
+	ExpiresAt   time.Time `json:"expires_at"`
+}
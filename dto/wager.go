@@ -2,45 +2,129 @@ package dto
 
 import (
 	"time"
+
+	"github.com/vitthalaa/wager-app/money"
 )
 
 // PlaceWagerRequest ...
 type PlaceWagerRequest struct {
-	TotalWagerValue   uint32  `json:"total_wager_value"`
-	Odds              uint32  `json:"odds"`
-	SellingPercentage float32 `json:"selling_percentage"`
-	SellingPrice      float32 `json:"selling_price"`
+	SellerID          uint32      `json:"-"`
+	TotalWagerValue   uint32      `json:"total_wager_value" validate:"min=1" error:"INVALID_TOTAL_WAGER_VALUE"`
+	Odds              uint32      `json:"odds" validate:"min=1,max=100" error:"INVALID_ODDS"`
+	SellingPercentage float32     `json:"selling_percentage" validate:"min=1,max=100" error:"INVALID_SELLING_PERCENTAGE"`
+	SellingPrice      money.Money `json:"selling_price" validate:"gt=0.00,max=1000000000.00" error:"INVALID_SELLING_PRICE"`
+	// Pricing is strategy of current selling price, last_trade when empty
+	Pricing string `json:"pricing,omitempty"`
+	// FloorPrice and DecaySeconds are required by time_decay and dutch_auction pricing
+	FloorPrice   money.Money `json:"floor_price,omitempty" validate:"min=0.00,max=1000000000.00" error:"INVALID_FLOOR_PRICE"`
+	DecaySeconds uint32      `json:"decay_seconds,omitempty"`
+	// StepPercentage is required by demand_step_up pricing
+	StepPercentage float32 `json:"step_percentage,omitempty" validate:"min=0,max=100" error:"INVALID_STEP_PERCENTAGE"`
+	// Auction is first_price or second_price to sell units by sealed bids until AuctionEndsAt, none when empty
+	Auction       string     `json:"auction,omitempty"`
+	AuctionEndsAt *time.Time `json:"auction_ends_at,omitempty"`
 }
 
 // Wager ...
 type Wager struct {
-	ID                  uint32     `json:"id"`
-	TotalWagerValue     uint32     `json:"total_wager_value"`
-	Odds                uint32     `json:"odds"`
-	SellingPercentage   float32    `json:"selling_percentage"`
-	SellingPrice        float32    `json:"selling_price"`
-	CurrentSellingPrice float32    `json:"current_selling_price"`
-	PercentageSold      float32    `json:"percentage_sold"`
-	AmountSold          uint32     `json:"amount_sold"`
-	PlacedAt            *time.Time `json:"placed_at"`
+	ID                  uint32      `json:"id"`
+	TotalWagerValue     uint32      `json:"total_wager_value"`
+	Odds                uint32      `json:"odds"`
+	SellingPercentage   float32     `json:"selling_percentage"`
+	SellingPrice        money.Money `json:"selling_price"`
+	CurrentSellingPrice money.Money `json:"current_selling_price"`
+	PercentageSold      float32     `json:"percentage_sold"`
+	AmountSold          uint32      `json:"amount_sold"`
+	PlacedAt            *time.Time  `json:"placed_at"`
+	Status              string      `json:"status"`
+	Outcome             string      `json:"outcome,omitempty"`
+	SettledAt           *time.Time  `json:"settled_at,omitempty"`
+	SellerID            uint32      `json:"seller_id,omitempty"`
+	Pricing             string      `json:"pricing"`
+	FloorPrice          money.Money `json:"floor_price,omitempty"`
+	DecaySeconds        uint32      `json:"decay_seconds,omitempty"`
+	StepPercentage      float32     `json:"step_percentage,omitempty"`
+	Auction             string      `json:"auction,omitempty"`
+	AuctionEndsAt       *time.Time  `json:"auction_ends_at,omitempty"`
+	AuctionClosedAt     *time.Time  `json:"auction_closed_at,omitempty"`
 }
 
 // BuyWagerRequest ...
 type BuyWagerRequest struct {
-	WagerID     uint32  `json:"-"`
-	BuyingPrice float32 `json:"buying_price"`
+	WagerID uint32 `json:"-"`
+	BuyerID uint32 `json:"-"`
+	// BuyingPrice is price of one unit of wager
+	BuyingPrice money.Money `json:"buying_price" validate:"min=1.00,max=1000000000.00" error:"INVALID_BUYING_PRICE"`
+	// Quantity is number of units to buy, defaults to 1
+	Quantity uint32 `json:"quantity,omitempty" validate:"max=1000000" error:"INVALID_QUANTITY"`
 }
 
-// ListWagerRequest ...
+// ListWagerRequest is filter and page of wager listing, zero values are not applied
 type ListWagerRequest struct {
+	MinOdds  uint32
+	MaxOdds  uint32
+	MinPrice money.Money
+	MaxPrice money.Money
+	SoldOut  *bool
+	From     *time.Time
+	To       *time.Time
+	// Sort is field name to sort by, prefixed with - for descending order. Ex. current_selling_price
+	Sort string
+	// Cursor is next_cursor of previous page, empty for first page
+	Cursor string
+	Limit  uint32
+}
+
+// WagerList is page of wagers in requested sort order
+type WagerList struct {
+	Items []Wager `json:"items"`
+	// NextCursor is cursor of next page, empty on last page
+	NextCursor string `json:"next_cursor,omitempty"`
+	HasMore    bool   `json:"has_more"`
+}
+
+// GetWagerRequest ...
+type GetWagerRequest struct {
+	WagerID uint32
+	Page    uint32
+	Limit   uint32
+}
+
+// WagerDetails is wager with page of its purchases
+type WagerDetails struct {
+	Wager
+	Purchases WagerPurchaseList `json:"purchases"`
+}
+
+// ListPurchaseRequest is filter, sort and page of purchases listing. Zero value filters are not applied.
+type ListPurchaseRequest struct {
+	WagerID  uint32
+	MinPrice money.Money
+	MaxPrice money.Money
+	From     *time.Time
+	To       *time.Time
+	// Sort is field name to sort by, prefixed with - for descending order. Ex. -bought_at
+	Sort  string
 	Page  uint32
 	Limit uint32
 }
 
+// WagerPurchaseList is page of wager purchases
+type WagerPurchaseList struct {
+	Items []WagerPurchase `json:"items"`
+	Page  uint32          `json:"page"`
+	Limit uint32          `json:"limit"`
+	Total uint32          `json:"total"`
+}
+
 // WagerPurchase ...
 type WagerPurchase struct {
-	ID          uint32     `json:"id"`
-	WagerID     uint32     `json:"wager_id"`
-	BuyingPrice float32    `json:"buying_Price"`
-	BoughtAt    *time.Time `json:"bought_at"`
+	ID      uint32 `json:"id"`
+	WagerID uint32 `json:"wager_id"`
+	// BuyingPrice is price of one unit, its key buying_Price is kept as clients read it
+	BuyingPrice money.Money `json:"buying_Price"`
+	Quantity    uint32      `json:"quantity"`
+	TotalPrice  money.Money `json:"total_price"`
+	BoughtAt    *time.Time  `json:"bought_at"`
+	BuyerID     uint32      `json:"buyer_id,omitempty"`
 }
@@ -0,0 +1,50 @@
+package dto
+
+import (
+	"time"
+
+	"github.com/vitthalaa/wager-app/money"
+)
+
+// DepositRequest ...
+type DepositRequest struct {
+	UserID uint32      `json:"-"`
+	Amount money.Money `json:"amount" validate:"gt=0.00" error:"INVALID_AMOUNT"`
+}
+
+// WithdrawRequest ...
+type WithdrawRequest struct {
+	UserID uint32      `json:"-"`
+	Amount money.Money `json:"amount" validate:"gt=0.00" error:"INVALID_AMOUNT"`
+}
+
+// ListLedgerEntriesRequest ...
+type ListLedgerEntriesRequest struct {
+	UserID uint32
+	Page   uint32
+	Limit  uint32
+}
+
+// Wallet is balance of user
+type Wallet struct {
+	Balance   money.Money `json:"balance"`
+	UpdatedAt *time.Time  `json:"updated_at,omitempty"`
+}
+
+// LedgerEntryList is page of wallet ledger entries
+type LedgerEntryList struct {
+	Items []LedgerEntry `json:"items"`
+	Page  uint32        `json:"page"`
+	Limit uint32        `json:"limit"`
+	Total uint32        `json:"total"`
+}
+
+// LedgerEntry is wallet balance change, amount is positive for credits and negative for debits
+type LedgerEntry struct {
+	ID            uint32      `json:"id"`
+	TransactionID uint32      `json:"transaction_id"`
+	Kind          string      `json:"kind"`
+	PurchaseID    uint32      `json:"purchase_id,omitempty"`
+	Amount        money.Money `json:"amount"`
+	CreatedAt     *time.Time  `json:"created_at"`
+}
@@ -0,0 +1,159 @@
+//go:build integration
+// +build integration
+
+package integration_tests
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/vitthalaa/wager-app/dto"
+	"github.com/vitthalaa/wager-app/internal/auth"
+	"github.com/vitthalaa/wager-app/internal/events"
+	"github.com/vitthalaa/wager-app/internal/handlers"
+	"github.com/vitthalaa/wager-app/internal/logger"
+	"github.com/vitthalaa/wager-app/internal/metrics"
+	"github.com/vitthalaa/wager-app/internal/services"
+	"github.com/vitthalaa/wager-app/money"
+)
+
+func Test_Auction(t *testing.T) {
+	ctx := context.Background()
+	repos := openStorage(t)
+	_, sellerToken := authenticate(t, repos)
+	alice, aliceToken := authenticate(t, repos)
+	bob, bobToken := authenticate(t, repos)
+	_, carolToken := authenticate(t, repos)
+
+	appMetrics := metrics.New()
+	sellOut := services.NewSellOutPolicy(services.SellingPercentageRule{})
+	wagerService := services.NewWagerService(repos.Wager, repos.Purchase, logger.Discard(), appMetrics, events.NewBroker(1))
+	settlementService := services.NewSettlementService(
+		repos.Transactor, repos.Wager, repos.Purchase, repos.Settlement, repos.Wallet, repos.Order, logger.Discard())
+	walletService := services.NewWalletService(repos.Transactor, repos.Wallet, logger.Discard())
+	purchaseService := services.NewPurchaseService(repos.Transactor, repos.Purchase, repos.Wager, repos.Wallet, repos.Order,
+		logger.Discard(), appMetrics, events.NewBroker(1), sellOut)
+	auctionService := services.NewAuctionService(repos.Transactor, repos.AuctionBid, repos.Purchase, repos.Wager,
+		repos.Wallet, logger.Discard(), appMetrics, events.NewBroker(1), sellOut)
+
+	for _, userID := range []uint32{alice.ID, bob.ID} {
+		_, err := walletService.Deposit(ctx, &dto.DepositRequest{UserID: userID, Amount: money.MustParse("100")})
+		require.Nil(t, err)
+	}
+
+	wagersHandler := handlers.NewWagersHandler(wagerService, settlementService, auctionService, nil, logger.Discard(), nil)
+	mux := http.NewServeMux()
+	mux.HandleFunc("/wagers", wagersHandler.Handle)
+	mux.HandleFunc("/wagers/", wagersHandler.Handle)
+	mux.HandleFunc("/buy/", handlers.NewPurchasesHandler(purchaseService, nil, logger.Discard()).Handle)
+	mux.HandleFunc("/wallet", newWalletHandler(repos).Handle)
+	handler := auth.Middleware(newTokens(), logger.Discard(), mux)
+
+	// 1. Auctioned wager of 3 units, bidding ends shortly
+	endsAt := time.Now().Add(2 * time.Second).UTC().Truncate(time.Millisecond)
+	rr := sendJSON(handler, "POST", "/wagers", sellerToken, dto.PlaceWagerRequest{
+		TotalWagerValue:   10,
+		Odds:              2,
+		SellingPercentage: 30,
+		SellingPrice:      money.MustParse("100"),
+		Auction:           "second_price",
+		AuctionEndsAt:     &endsAt,
+	})
+	require.Equal(t, http.StatusOK, rr.Code, rr.Body.String())
+
+	var wager dto.Wager
+	require.Nil(t, json.Unmarshal(rr.Body.Bytes(), &wager))
+	require.Equal(t, "second_price", wager.Auction)
+	require.Nil(t, wager.AuctionClosedAt)
+
+	bidsPath := fmt.Sprintf("/wagers/%d/bids", wager.ID)
+	placeBid := func(token, price string, quantity uint32) *httptest.ResponseRecorder {
+		return sendJSON(handler, "POST", bidsPath, token, dto.PlaceBidRequest{Price: money.MustParse(price), Quantity: quantity})
+	}
+
+	// 2. Units are not sold while auction is open
+	rr = sendJSON(handler, "POST", fmt.Sprintf("/buy/%d", wager.ID), aliceToken, dto.BuyWagerRequest{
+		BuyingPrice: money.MustParse("30"),
+	})
+	require.Equal(t, http.StatusConflict, rr.Code, rr.Body.String())
+	require.JSONEq(t, `{"error":"AUCTION_OPEN"}`, rr.Body.String())
+
+	// 3. Sealed bids
+	rr = placeBid(sellerToken, "30", 1)
+	require.Equal(t, http.StatusForbidden, rr.Code, rr.Body.String())
+	require.JSONEq(t, `{"error":"SELLER_CANNOT_BID"}`, rr.Body.String())
+
+	rr = placeBid(carolToken, "30", 1)
+	require.Equal(t, http.StatusPaymentRequired, rr.Code, rr.Body.String())
+
+	rr = placeBid(aliceToken, "40", 2)
+	require.Equal(t, http.StatusOK, rr.Code, rr.Body.String())
+	rr = placeBid(bobToken, "35", 2)
+	require.Equal(t, http.StatusOK, rr.Code, rr.Body.String())
+	rr = placeBid(bobToken, "20", 1)
+	require.Equal(t, http.StatusOK, rr.Code, rr.Body.String())
+
+	getBids := func(token string) []dto.AuctionBid {
+		rr := sendJSON(handler, "GET", bidsPath, token, nil)
+		require.Equal(t, http.StatusOK, rr.Code, rr.Body.String())
+
+		var bids []dto.AuctionBid
+		require.Nil(t, json.Unmarshal(rr.Body.Bytes(), &bids))
+		return bids
+	}
+
+	// bids of other users are not disclosed
+	require.Len(t, getBids(aliceToken), 1)
+	require.Len(t, getBids(bobToken), 2)
+	require.Empty(t, getBids(sellerToken))
+
+	// 4. Bidding ends and auction closes
+	time.Sleep(time.Until(endsAt) + 10*time.Millisecond)
+
+	rr = placeBid(aliceToken, "50", 1)
+	require.Equal(t, http.StatusConflict, rr.Code, rr.Body.String())
+	require.JSONEq(t, `{"error":"AUCTION_CLOSED"}`, rr.Body.String())
+
+	closed, err := auctionService.CloseDueAuctions(ctx)
+	require.Nil(t, err)
+	require.Equal(t, 1, closed)
+
+	// closed auction is not closed again
+	closed, err = auctionService.CloseDueAuctions(ctx)
+	require.Nil(t, err)
+	require.Zero(t, closed)
+
+	// alice wins 2 units, bob 1 unit of partly awarded bid, all at price of bob's bid
+	aliceBids := getBids(aliceToken)
+	require.Equal(t, uint32(2), aliceBids[0].Awarded)
+	bobBids := getBids(bobToken)
+	require.Equal(t, uint32(1), bobBids[0].Awarded)
+	require.Zero(t, bobBids[1].Awarded)
+
+	require.Equal(t, money.MustParse("30"), getWallet(t, handler, aliceToken).Balance)
+	require.Equal(t, money.MustParse("65"), getWallet(t, handler, bobToken).Balance)
+	require.Equal(t, money.MustParse("105"), getWallet(t, handler, sellerToken).Balance)
+
+	rr = sendJSON(handler, "GET", fmt.Sprintf("/wagers/%d", wager.ID), aliceToken, nil)
+	require.Equal(t, http.StatusOK, rr.Code, rr.Body.String())
+
+	var details dto.WagerDetails
+	require.Nil(t, json.Unmarshal(rr.Body.Bytes(), &details))
+	require.NotNil(t, details.AuctionClosedAt)
+	require.Equal(t, uint32(3), details.AmountSold)
+	require.Equal(t, "sold_out", details.Status)
+	require.Equal(t, uint32(2), details.Purchases.Total)
+	for _, p := range details.Purchases.Items {
+		require.Equal(t, money.MustParse("35"), p.BuyingPrice)
+	}
+
+	// 5. Ledger stays balanced
+	require.Nil(t, walletService.CheckLedger(ctx))
+}
@@ -0,0 +1,121 @@
+//go:build integration
+// +build integration
+
+package integration_tests
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/vitthalaa/wager-app/dto"
+	"github.com/vitthalaa/wager-app/internal/auth"
+	"github.com/vitthalaa/wager-app/internal/config"
+	"github.com/vitthalaa/wager-app/internal/handlers"
+	"github.com/vitthalaa/wager-app/internal/logger"
+	"github.com/vitthalaa/wager-app/internal/services"
+	"github.com/vitthalaa/wager-app/internal/storage"
+)
+
+func Test_AuthHandler(t *testing.T) {
+	repos := openStorage(t)
+	handler := http.HandlerFunc(newAuthHandler(repos).Handle)
+	username := uniqueUsername()
+
+	// 1. Register
+	rr := postJSON(handler, "/auth/register", dto.RegisterRequest{Username: username, Password: "secret123"})
+	require.Equal(t, http.StatusCreated, rr.Code, rr.Body.String())
+
+	var user dto.User
+	require.Nil(t, json.Unmarshal(rr.Body.Bytes(), &user))
+	require.NotEmpty(t, user.ID)
+	require.Equal(t, username, user.Username)
+	require.NotNil(t, user.RegisteredAt)
+
+	// 2. Username is taken
+	rr = postJSON(handler, "/auth/register", dto.RegisterRequest{Username: username, Password: "secret123"})
+	require.Equal(t, http.StatusConflict, rr.Code, rr.Body.String())
+
+	// 3. Login
+	rr = postJSON(handler, "/auth/login", dto.LoginRequest{Username: username, Password: "secret123"})
+	require.Equal(t, http.StatusOK, rr.Code, rr.Body.String())
+
+	var token dto.Token
+	require.Nil(t, json.Unmarshal(rr.Body.Bytes(), &token))
+	require.Equal(t, "Bearer", token.TokenType)
+
+	loggedIn, err := newTokens().Verify(token.AccessToken)
+	require.Nil(t, err)
+	require.Equal(t, user.ID, loggedIn.ID)
+
+	// 4. Wrong password
+	rr = postJSON(handler, "/auth/login", dto.LoginRequest{Username: username, Password: "secret1234"})
+	require.Equal(t, http.StatusUnauthorized, rr.Code, rr.Body.String())
+}
+
+// authenticate registers new user and returns its bearer token
+func authenticate(t *testing.T, repos *storage.Repositories) (dto.User, string) {
+	handler := http.HandlerFunc(newAuthHandler(repos).Handle)
+	username := uniqueUsername()
+
+	rr := postJSON(handler, "/auth/register", dto.RegisterRequest{Username: username, Password: "secret123"})
+	require.Equal(t, http.StatusCreated, rr.Code, rr.Body.String())
+
+	var user dto.User
+	require.Nil(t, json.Unmarshal(rr.Body.Bytes(), &user))
+
+	rr = postJSON(handler, "/auth/login", dto.LoginRequest{Username: username, Password: "secret123"})
+	require.Equal(t, http.StatusOK, rr.Code, rr.Body.String())
+
+	var token dto.Token
+	require.Nil(t, json.Unmarshal(rr.Body.Bytes(), &token))
+
+	return user, token.AccessToken
+}
+
+// authenticateOperator registers new user and returns its bearer token of operator role, as given on login to users
+// listed in OPERATOR_USERNAMES
+func authenticateOperator(t *testing.T, repos *storage.Repositories) (dto.User, string) {
+	user, _ := authenticate(t, repos)
+
+	token, _, err := newTokens().Issue(auth.User{ID: user.ID, Username: user.Username, Role: auth.RoleOperator})
+	require.Nil(t, err)
+
+	return user, token
+}
+
+// testTokenSecret signs tokens of tests when AUTH_TOKEN_SECRET is not set, .env does not keep it
+const testTokenSecret = "integration-tests-token-secret-0123456789"
+
+// newTokens returns tokens configured in environment, openStorage loads .env
+func newTokens() *auth.Tokens {
+	conf := config.GetAuthConfig()
+	if conf.TokenSecret == "" {
+		conf.TokenSecret = testTokenSecret
+	}
+
+	return auth.NewTokens([]byte(conf.TokenSecret), conf.TokenTTL)
+}
+
+func newAuthHandler(repos *storage.Repositories) *handlers.AuthHandler {
+	return handlers.NewAuthHandler(services.NewUserService(repos.Transactor, repos.User, repos.Wallet, newTokens(), nil, logger.Discard()), logger.Discard())
+}
+
+// uniqueUsername returns username not used by previous runs against persistent storage
+func uniqueUsername() string {
+	return fmt.Sprintf("user_%d", time.Now().UnixNano())
+}
+
+func postJSON(handler http.Handler, path string, body interface{}) *httptest.ResponseRecorder {
+	b, _ := json.Marshal(body)
+	req := httptest.NewRequest("POST", path, bytes.NewReader(b))
+	rr := httptest.NewRecorder()
+	handler.ServeHTTP(rr, req)
+	return rr
+}
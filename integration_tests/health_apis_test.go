@@ -0,0 +1,63 @@
+//go:build integration
+// +build integration
+
+package integration_tests
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/vitthalaa/wager-app/dto"
+	"github.com/vitthalaa/wager-app/internal/handlers"
+	"github.com/vitthalaa/wager-app/internal/logger"
+	"github.com/vitthalaa/wager-app/internal/services"
+)
+
+func Test_HealthHandler(t *testing.T) {
+	repos := openStorage(t)
+
+	var migrator services.IMigrator
+	if repos.Migrator != nil {
+		migrator = repos.Migrator
+	}
+
+	healthService := services.NewHealthService(repos.Health, migrator, time.Second, logger.Discard())
+	handler := http.HandlerFunc(handlers.NewHealthHandler(healthService, logger.Discard()).Handle)
+
+	probe := func(path string) (int, dto.Health) {
+		req, err := http.NewRequest("GET", path, nil)
+		require.Nil(t, err)
+
+		rr := httptest.NewRecorder()
+		handler.ServeHTTP(rr, req)
+
+		var health dto.Health
+		require.Nil(t, json.Unmarshal(rr.Body.Bytes(), &health), rr.Body.String())
+		return rr.Code, health
+	}
+
+	code, health := probe("/healthz")
+	assert.Equal(t, http.StatusOK, code)
+	assert.Equal(t, "ok", health.Status)
+
+	code, health = probe("/readyz")
+	require.Equal(t, http.StatusOK, code, health)
+	assert.Equal(t, "ok", health.Checks["database"])
+	assert.Equal(t, "ok", health.Checks["migrations"])
+
+	// readiness fails as soon as shutdown starts, liveness does not
+	healthService.ShutDown()
+
+	code, health = probe("/readyz")
+	assert.Equal(t, http.StatusServiceUnavailable, code)
+	assert.Equal(t, "failing", health.Checks["shutdown"])
+
+	code, _ = probe("/healthz")
+	assert.Equal(t, http.StatusOK, code)
+}
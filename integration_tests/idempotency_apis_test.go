@@ -0,0 +1,108 @@
+//go:build integration
+// +build integration
+
+package integration_tests
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/vitthalaa/wager-app/dto"
+	"github.com/vitthalaa/wager-app/internal/auth"
+	"github.com/vitthalaa/wager-app/internal/events"
+	"github.com/vitthalaa/wager-app/internal/handlers"
+	"github.com/vitthalaa/wager-app/internal/logger"
+	"github.com/vitthalaa/wager-app/internal/metrics"
+	"github.com/vitthalaa/wager-app/internal/services"
+	"github.com/vitthalaa/wager-app/internal/storage"
+	"github.com/vitthalaa/wager-app/money"
+)
+
+func Test_IdempotencyKey(t *testing.T) {
+	repos := openStorage(t)
+	_, sellerToken := authenticate(t, repos)
+	_, buyerToken := authenticate(t, repos)
+	tokens := newTokens()
+	idempotencyService := newIdempotencyService(repos)
+
+	wagerHandle := auth.Middleware(tokens, logger.Discard(), http.HandlerFunc(handlers.NewWagersHandler(
+		services.NewWagerService(repos.Wager, repos.Purchase, logger.Discard(), metrics.New(), events.NewBroker(1)),
+		services.NewSettlementService(
+			repos.Transactor, repos.Wager, repos.Purchase, repos.Settlement, repos.Wallet, repos.Order, logger.Discard()),
+		nil, idempotencyService, logger.Discard(), nil,
+	).Handle))
+	purchaseHandle := auth.Middleware(tokens, logger.Discard(), http.HandlerFunc(handlers.NewPurchasesHandler(
+		services.NewPurchaseService(repos.Transactor, repos.Purchase, repos.Wager, repos.Wallet, repos.Order, logger.Discard(),
+			metrics.New(), events.NewBroker(1), services.NewSellOutPolicy(services.SellingPercentageRule{})),
+		idempotencyService, logger.Discard(),
+	).Handle))
+	walletHandle := auth.Middleware(tokens, logger.Discard(), http.HandlerFunc(newWalletHandler(repos).Handle))
+
+	// 1. Retried wager placement returns first wager
+	placeKey := fmt.Sprintf("place-%d", time.Now().UnixNano())
+	placeWagerReq := dto.PlaceWagerRequest{
+		TotalWagerValue:   100,
+		Odds:              2,
+		SellingPercentage: 20,
+		SellingPrice:      money.MustParse("21"),
+	}
+
+	rr := sendIdempotent(wagerHandle, "/wagers", sellerToken, placeKey, placeWagerReq)
+	require.Equal(t, http.StatusOK, rr.Code, rr.Body.String())
+
+	var wager dto.Wager
+	require.Nil(t, json.Unmarshal(rr.Body.Bytes(), &wager))
+
+	retry := sendIdempotent(wagerHandle, "/wagers", sellerToken, placeKey, placeWagerReq)
+	require.Equal(t, http.StatusOK, retry.Code, retry.Body.String())
+	require.Equal(t, "true", retry.Header().Get("Idempotent-Replayed"))
+	require.Equal(t, rr.Body.String(), retry.Body.String())
+
+	// 2. Same key with different body is rejected
+	placeWagerReq.Odds = 3
+	rr = sendIdempotent(wagerHandle, "/wagers", sellerToken, placeKey, placeWagerReq)
+	require.Equal(t, http.StatusUnprocessableEntity, rr.Code, rr.Body.String())
+
+	// 3. Retried purchase is paid once
+	rr = sendJSON(walletHandle, "POST", "/wallet/deposit", buyerToken, dto.DepositRequest{Amount: money.MustParse("30")})
+	require.Equal(t, http.StatusOK, rr.Code, rr.Body.String())
+
+	buyKey := fmt.Sprintf("buy-%d", time.Now().UnixNano())
+	buyPath := fmt.Sprintf("/buy/%d", wager.ID)
+	buyWagerReq := dto.BuyWagerRequest{BuyingPrice: money.MustParse("20.5")}
+
+	rr = sendIdempotent(purchaseHandle, buyPath, buyerToken, buyKey, buyWagerReq)
+	require.Equal(t, http.StatusOK, rr.Code, rr.Body.String())
+
+	retry = sendIdempotent(purchaseHandle, buyPath, buyerToken, buyKey, buyWagerReq)
+	require.Equal(t, http.StatusOK, retry.Code, retry.Body.String())
+	require.Equal(t, rr.Body.String(), retry.Body.String())
+
+	require.Equal(t, money.MustParse("9.5"), getWallet(t, walletHandle, buyerToken).Balance)
+
+	// 4. Keys are scoped by user, buyer can use same key
+	rr = sendIdempotent(wagerHandle, "/wagers", buyerToken, placeKey, placeWagerReq)
+	require.Equal(t, http.StatusOK, rr.Code, rr.Body.String())
+}
+
+func newIdempotencyService(repos *storage.Repositories) *services.IdempotencyService {
+	return services.NewIdempotencyService(repos.Idempotency, time.Hour, logger.Discard())
+}
+
+// sendIdempotent sends POST request of token user with Idempotency-Key header
+func sendIdempotent(handler http.Handler, path, token, key string, body interface{}) *httptest.ResponseRecorder {
+	b, _ := json.Marshal(body)
+	req := httptest.NewRequest("POST", path, bytes.NewReader(b))
+	req.Header.Set("Authorization", "Bearer "+token)
+	req.Header.Set("Idempotency-Key", key)
+	rr := httptest.NewRecorder()
+	handler.ServeHTTP(rr, req)
+	return rr
+}
@@ -0,0 +1,69 @@
+//go:build integration
+// +build integration
+
+package integration_tests
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/vitthalaa/wager-app/dto"
+	"github.com/vitthalaa/wager-app/internal/auth"
+	"github.com/vitthalaa/wager-app/internal/events"
+	"github.com/vitthalaa/wager-app/internal/handlers"
+	"github.com/vitthalaa/wager-app/internal/logger"
+	"github.com/vitthalaa/wager-app/internal/metrics"
+	"github.com/vitthalaa/wager-app/internal/services"
+	"github.com/vitthalaa/wager-app/money"
+)
+
+func Test_Metrics(t *testing.T) {
+	appMetrics := metrics.New()
+	repos := openStorageWithMetrics(t, appMetrics)
+	_, token := authenticate(t, repos)
+
+	wagerHandler := handlers.NewWagersHandler(
+		services.NewWagerService(repos.Wager, repos.Purchase, logger.Discard(), appMetrics, events.NewBroker(1)),
+		services.NewSettlementService(
+			repos.Transactor, repos.Wager, repos.Purchase, repos.Settlement, repos.Wallet, repos.Order, logger.Discard()),
+		nil, nil, logger.Discard(), nil,
+	)
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/wagers", wagerHandler.Handle)
+	mux.HandleFunc("/wagers/", wagerHandler.Handle)
+	mux.Handle("/metrics", appMetrics.Registry)
+	handler := metrics.Middleware(appMetrics, []string{"/wagers", "/wagers/{id}", "/metrics"},
+		auth.Middleware(newTokens(), logger.Discard(), mux))
+
+	rr := sendJSON(handler, "POST", "/wagers", token, dto.PlaceWagerRequest{
+		TotalWagerValue:   100,
+		Odds:              2,
+		SellingPercentage: 20,
+		SellingPrice:      money.MustParse("21"),
+	})
+	require.Equal(t, http.StatusOK, rr.Code, rr.Body.String())
+
+	rr = sendJSON(handler, "GET", "/wagers/999999999", token, nil)
+	require.Equal(t, http.StatusNotFound, rr.Code, rr.Body.String())
+
+	req, err := http.NewRequest("GET", "/metrics", nil)
+	require.Nil(t, err)
+
+	rr = httptest.NewRecorder()
+	handler.ServeHTTP(rr, req)
+	require.Equal(t, http.StatusOK, rr.Code)
+
+	body := rr.Body.String()
+	assert.Contains(t, body, "wagers_placed_total 1\n")
+	assert.Contains(t, body, `http_requests_total{route="/wagers",method="POST",status="200"} 1`+"\n")
+	assert.Contains(t, body, `http_requests_total{route="/wagers/{id}",method="GET",status="404"} 1`+"\n")
+	assert.Contains(t, body, `http_request_duration_seconds_count{route="/wagers",method="POST"} 1`+"\n")
+	if repos.Migrator != nil {
+		assert.Contains(t, body, "# TYPE db_open_connections gauge\n")
+	}
+}
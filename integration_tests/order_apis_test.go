@@ -0,0 +1,149 @@
+//go:build integration
+// +build integration
+
+package integration_tests
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/vitthalaa/wager-app/dto"
+	"github.com/vitthalaa/wager-app/internal/auth"
+	"github.com/vitthalaa/wager-app/internal/events"
+	"github.com/vitthalaa/wager-app/internal/handlers"
+	"github.com/vitthalaa/wager-app/internal/logger"
+	"github.com/vitthalaa/wager-app/internal/metrics"
+	"github.com/vitthalaa/wager-app/internal/services"
+	"github.com/vitthalaa/wager-app/money"
+)
+
+func Test_OrderBook(t *testing.T) {
+	ctx := context.Background()
+	repos := openStorage(t)
+	seller, sellerToken := authenticate(t, repos)
+	buyer, buyerToken := authenticate(t, repos)
+
+	wagerService := services.NewWagerService(repos.Wager, repos.Purchase, logger.Discard(), metrics.New(), events.NewBroker(1))
+	walletService := services.NewWalletService(repos.Transactor, repos.Wallet, logger.Discard())
+	orderService := services.NewOrderService(repos.Transactor, repos.Order, repos.Purchase, repos.Wager, repos.Wallet,
+		logger.Discard(), metrics.New(), events.NewBroker(1), services.NewSellOutPolicy(services.SellingPercentageRule{}))
+
+	_, err := walletService.Deposit(ctx, &dto.DepositRequest{UserID: buyer.ID, Amount: money.MustParse("100")})
+	require.Nil(t, err)
+
+	wager, err := wagerService.PlaceWager(ctx, &dto.PlaceWagerRequest{
+		SellerID:          seller.ID,
+		TotalWagerValue:   100,
+		Odds:              2,
+		SellingPercentage: 20,
+		SellingPrice:      money.MustParse("100"),
+	})
+	require.Nil(t, err)
+
+	orderHandler := handlers.NewOrderHandler(orderService, nil, logger.Discard())
+	mux := http.NewServeMux()
+	mux.HandleFunc("/orders", orderHandler.Handle)
+	mux.HandleFunc("/orders/", orderHandler.Handle)
+	mux.HandleFunc("/books/", orderHandler.Handle)
+	mux.HandleFunc("/wallet", newWalletHandler(repos).Handle)
+	handler := auth.Middleware(newTokens(), logger.Discard(), mux)
+
+	placeOrder := func(token, side, price string, quantity uint32) dto.Order {
+		rr := sendJSON(handler, "POST", "/orders", token, dto.PlaceOrderRequest{
+			WagerID: wager.ID, Side: side, Price: money.MustParse(price), Quantity: quantity,
+		})
+		require.Equal(t, http.StatusOK, rr.Code, rr.Body.String())
+
+		var order dto.Order
+		require.Nil(t, json.Unmarshal(rr.Body.Bytes(), &order))
+		return order
+	}
+
+	getBook := func() dto.OrderBook {
+		rr := sendJSON(handler, "GET", fmt.Sprintf("/books/%d", wager.ID), buyerToken, nil)
+		require.Equal(t, http.StatusOK, rr.Code, rr.Body.String())
+
+		var book dto.OrderBook
+		require.Nil(t, json.Unmarshal(rr.Body.Bytes(), &book))
+		return book
+	}
+
+	// 1. Bids rest in the book without asks
+	first := placeOrder(buyerToken, "bid", "20", 2)
+	require.Equal(t, "open", first.Status)
+	require.Empty(t, first.Purchases)
+	placeOrder(buyerToken, "bid", "22", 1)
+
+	book := getBook()
+	require.Equal(t, []dto.OrderBookLevel{
+		{Price: money.MustParse("22"), Quantity: 1, Orders: 1},
+		{Price: money.MustParse("20"), Quantity: 2, Orders: 1},
+	}, book.Bids)
+	require.Empty(t, book.Asks)
+
+	// 2. Only seller asks
+	rr := sendJSON(handler, "POST", "/orders", buyerToken, dto.PlaceOrderRequest{
+		WagerID: wager.ID, Side: "ask", Price: money.MustParse("20"), Quantity: 1,
+	})
+	require.Equal(t, http.StatusForbidden, rr.Code, rr.Body.String())
+	require.JSONEq(t, `{"error":"NOT_WAGER_SELLER"}`, rr.Body.String())
+
+	// 3. Ask trades with best bids at their prices
+	ask := placeOrder(sellerToken, "ask", "19", 2)
+	require.Equal(t, "filled", ask.Status)
+	require.Len(t, ask.Purchases, 2)
+	require.Equal(t, money.MustParse("22"), ask.Purchases[0].BuyingPrice)
+	require.Equal(t, money.MustParse("20"), ask.Purchases[1].BuyingPrice)
+	require.Equal(t, buyer.ID, ask.Purchases[0].BuyerID)
+
+	book = getBook()
+	require.Equal(t, []dto.OrderBookLevel{{Price: money.MustParse("20"), Quantity: 1, Orders: 1}}, book.Bids)
+
+	require.Equal(t, money.MustParse("58"), getWallet(t, handler, buyerToken).Balance)
+	require.Equal(t, money.MustParse("42"), getWallet(t, handler, sellerToken).Balance)
+
+	updated, err := repos.Wager.GetWagerByID(ctx, wager.ID)
+	require.Nil(t, err)
+	require.Equal(t, int32(2), updated.AmountSold.Int32)
+	require.Equal(t, money.MustParse("20"), updated.CurrentSellingPrice)
+
+	// 4. Orders are visible to their owner only
+	orderPath := fmt.Sprintf("/orders/%d", first.ID)
+	rr = sendJSON(handler, "GET", orderPath, sellerToken, nil)
+	require.Equal(t, http.StatusNotFound, rr.Code, rr.Body.String())
+
+	rr = sendJSON(handler, "GET", orderPath, buyerToken, nil)
+	require.Equal(t, http.StatusOK, rr.Code, rr.Body.String())
+
+	var order dto.Order
+	require.Nil(t, json.Unmarshal(rr.Body.Bytes(), &order))
+	require.Equal(t, uint32(1), order.Filled)
+	require.Equal(t, "open", order.Status)
+
+	// 5. Cancel removes order from the book once
+	rr = sendJSON(handler, "DELETE", orderPath, buyerToken, nil)
+	require.Equal(t, http.StatusOK, rr.Code, rr.Body.String())
+	require.Nil(t, json.Unmarshal(rr.Body.Bytes(), &order))
+	require.Equal(t, "cancelled", order.Status)
+	require.Empty(t, getBook().Bids)
+
+	rr = sendJSON(handler, "DELETE", orderPath, buyerToken, nil)
+	require.Equal(t, http.StatusConflict, rr.Code, rr.Body.String())
+	require.JSONEq(t, `{"error":"ORDER_NOT_OPEN"}`, rr.Body.String())
+
+	// 6. Open bids of user together are limited by wallet balance
+	placeOrder(buyerToken, "bid", "20", 2)
+	rr = sendJSON(handler, "POST", "/orders", buyerToken, dto.PlaceOrderRequest{
+		WagerID: wager.ID, Side: "bid", Price: money.MustParse("20"), Quantity: 1,
+	})
+	require.Equal(t, http.StatusPaymentRequired, rr.Code, rr.Body.String())
+	require.JSONEq(t, `{"error":"INSUFFICIENT_FUNDS"}`, rr.Body.String())
+
+	// 7. Ledger stays balanced
+	require.Nil(t, walletService.CheckLedger(ctx))
+}
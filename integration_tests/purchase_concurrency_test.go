@@ -0,0 +1,102 @@
+//go:build integration
+// +build integration
+
+package integration_tests
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/vitthalaa/wager-app/dto"
+	"github.com/vitthalaa/wager-app/internal/auth"
+	"github.com/vitthalaa/wager-app/internal/events"
+	"github.com/vitthalaa/wager-app/internal/handlers"
+	"github.com/vitthalaa/wager-app/internal/logger"
+	"github.com/vitthalaa/wager-app/internal/metrics"
+	"github.com/vitthalaa/wager-app/internal/services"
+	"github.com/vitthalaa/wager-app/money"
+)
+
+func Test_PurchaseWager_Concurrent_NoOversell(t *testing.T) {
+	const (
+		totalWagerValue = 10
+		buyers          = 50
+	)
+
+	repos := openStorage(t)
+	buyer, token := authenticate(t, repos)
+
+	wagerRepo := repos.Wager
+	purchaseRepo := repos.Purchase
+	wagerService := services.NewWagerService(wagerRepo, repos.Purchase, logger.Discard(), metrics.New(), events.NewBroker(1))
+	purchaseService := services.NewPurchaseService(repos.Transactor, purchaseRepo, wagerRepo, repos.Wallet, repos.Order,
+		logger.Discard(), metrics.New(), events.NewBroker(1), services.NewSellOutPolicy(services.TotalValueRule{}))
+	walletService := services.NewWalletService(repos.Transactor, repos.Wallet, logger.Discard())
+
+	// Buyer can afford every attempt, so only sold out purchases fail
+	_, err := walletService.Deposit(context.Background(), &dto.DepositRequest{UserID: buyer.ID, Amount: money.MustParse("2500")})
+	require.Nil(t, err)
+
+	wager, err := wagerService.PlaceWager(context.Background(), &dto.PlaceWagerRequest{
+		TotalWagerValue:   totalWagerValue,
+		Odds:              2,
+		SellingPercentage: 20,
+		SellingPrice:      money.MustParse("100"),
+	})
+	require.Nil(t, err)
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/buy/", handlers.NewPurchasesHandler(purchaseService, nil, logger.Discard()).Handle)
+	server := httptest.NewServer(auth.Middleware(newTokens(), logger.Discard(), mux))
+	defer server.Close()
+
+	body, err := json.Marshal(dto.BuyWagerRequest{BuyingPrice: money.MustParse("50")})
+	require.Nil(t, err)
+
+	var wg sync.WaitGroup
+	statuses := make(chan int, buyers)
+	for i := 0; i < buyers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			req, _ := http.NewRequest("POST", fmt.Sprintf("%s/buy/%d", server.URL, wager.ID), bytes.NewReader(body))
+			req.Header.Set("Authorization", "Bearer "+token)
+			res, err := http.DefaultClient.Do(req)
+			if err != nil {
+				statuses <- 0
+				return
+			}
+
+			res.Body.Close()
+			statuses <- res.StatusCode
+		}()
+	}
+
+	wg.Wait()
+	close(statuses)
+
+	counts := map[int]int{}
+	for status := range statuses {
+		counts[status]++
+	}
+
+	require.Equal(t, totalWagerValue, counts[http.StatusOK], counts)
+	require.Equal(t, buyers-totalWagerValue, counts[http.StatusNotAcceptable], counts)
+
+	updated, err := wagerRepo.GetWagerByID(context.Background(), wager.ID)
+	require.Nil(t, err)
+	require.Equal(t, int32(totalWagerValue), updated.AmountSold.Int32)
+
+	wallet, err := walletService.GetWallet(context.Background(), buyer.ID)
+	require.Nil(t, err)
+	require.Equal(t, money.MustParse("2000"), wallet.Balance)
+	require.Nil(t, walletService.CheckLedger(context.Background()))
+}
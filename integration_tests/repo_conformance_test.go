@@ -0,0 +1,54 @@
+//go:build integration
+// +build integration
+
+package integration_tests
+
+import (
+	"context"
+	"testing"
+
+	env "github.com/joho/godotenv"
+	"github.com/stretchr/testify/require"
+
+	"github.com/vitthalaa/wager-app/internal/config"
+	"github.com/vitthalaa/wager-app/internal/db"
+	"github.com/vitthalaa/wager-app/internal/logger"
+	"github.com/vitthalaa/wager-app/internal/metrics"
+	"github.com/vitthalaa/wager-app/internal/migrate"
+	"github.com/vitthalaa/wager-app/internal/repo"
+	"github.com/vitthalaa/wager-app/internal/repo/repotest"
+)
+
+func Test_PostgresRepo_Conformance(t *testing.T) {
+	var loadEnv = env.Overload
+	err := loadEnv("../.env")
+	require.Nil(t, err)
+
+	conf := config.GetAppConfig()
+	if conf.DataBaseConfig.DBDriver != config.DriverPostgres {
+		t.Skipf("postgres is not configured, DB_DRIVER is %s", conf.DataBaseConfig.DBDriver)
+	}
+
+	conn, err := db.OpenConnection(&conf.DataBaseConfig, logger.Discard())
+	require.Nil(t, err)
+
+	migrator, err := migrate.New(conn, repo.DialectPostgres, logger.Discard())
+	require.Nil(t, err)
+	require.Nil(t, migrator.Up(context.Background()))
+
+	repotest.RunConformance(t, func(t *testing.T) repotest.Repos {
+		transactor := repo.NewTransactor(conn, logger.Discard(), metrics.New())
+		return repotest.Repos{
+			Transactor:  transactor,
+			Wager:       repo.NewWagerRepo(conn, repo.DialectPostgres),
+			Purchase:    repo.NewPurchaseRepo(conn),
+			Settlement:  repo.NewSettlementRepo(conn),
+			User:        repo.NewUserRepo(conn),
+			Wallet:      repo.NewWalletRepo(conn, repo.DialectPostgres, transactor),
+			Idempotency: repo.NewIdempotencyRepo(conn),
+			Health:      repo.NewHealthRepo(conn),
+			Order:       repo.NewOrderRepo(conn),
+			AuctionBid:  repo.NewAuctionBidRepo(conn),
+		}
+	})
+}
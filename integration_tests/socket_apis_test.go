@@ -0,0 +1,123 @@
+//go:build integration
+// +build integration
+
+package integration_tests
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/vitthalaa/wager-app/app_errors"
+	"github.com/vitthalaa/wager-app/dto"
+	"github.com/vitthalaa/wager-app/internal/auth"
+	"github.com/vitthalaa/wager-app/internal/events"
+	"github.com/vitthalaa/wager-app/internal/handlers"
+	"github.com/vitthalaa/wager-app/internal/logger"
+	"github.com/vitthalaa/wager-app/internal/metrics"
+	"github.com/vitthalaa/wager-app/internal/services"
+	"github.com/vitthalaa/wager-app/internal/websocket"
+	"github.com/vitthalaa/wager-app/money"
+)
+
+func Test_SocketTrading(t *testing.T) {
+	appMetrics := metrics.New()
+	repos := openStorage(t)
+	seller, _ := authenticate(t, repos)
+	buyer, token := authenticate(t, repos)
+
+	broker := events.NewBroker(100)
+	wagerService := services.NewWagerService(repos.Wager, repos.Purchase, logger.Discard(), appMetrics, broker)
+	purchaseService := services.NewPurchaseService(
+		repos.Transactor, repos.Purchase, repos.Wager, repos.Wallet, repos.Order, logger.Discard(), appMetrics, broker,
+		services.NewSellOutPolicy(services.SellingPercentageRule{}))
+	walletService := services.NewWalletService(repos.Transactor, repos.Wallet, logger.Discard())
+
+	_, err := walletService.Deposit(context.Background(), &dto.DepositRequest{UserID: buyer.ID, Amount: money.MustParse("100")})
+	require.Nil(t, err)
+
+	wager, err := wagerService.PlaceWager(context.Background(), &dto.PlaceWagerRequest{
+		SellerID:          seller.ID,
+		TotalWagerValue:   1,
+		Odds:              2,
+		SellingPercentage: 20,
+		SellingPrice:      money.MustParse("30"),
+	})
+	require.Nil(t, err)
+
+	socket := handlers.NewSocketHandler(wagerService, purchaseService, broker, 10, time.Minute, nil, logger.Discard())
+	mux := http.NewServeMux()
+	mux.HandleFunc("/ws", socket.Handle)
+	// connections are hijacked through middlewares of app
+	server := httptest.NewServer(logger.Middleware(logger.Discard(), metrics.Middleware(appMetrics, []string{"/ws"},
+		auth.Middleware(newTokens(), logger.Discard(), mux))))
+	t.Cleanup(server.Close)
+	t.Cleanup(func() { _ = socket.Shutdown(context.Background()) })
+
+	url := "ws" + strings.TrimPrefix(server.URL, "http") + "/ws"
+	conn, _, err := websocket.Dial(context.Background(), url, http.Header{"Authorization": {"Bearer " + token}})
+	require.Nil(t, err)
+	t.Cleanup(func() { conn.Close() })
+
+	send := func(msg dto.ClientMessage) {
+		b, err := json.Marshal(msg)
+		require.Nil(t, err)
+		require.Nil(t, conn.WriteMessage(websocket.TextMessage, b))
+	}
+	read := func() dto.ServerMessage {
+		require.Nil(t, conn.SetReadDeadline(time.Now().Add(5*time.Second)))
+		_, data, err := conn.ReadMessage()
+		require.Nil(t, err)
+
+		var msg dto.ServerMessage
+		require.Nil(t, json.Unmarshal(data, &msg))
+		return msg
+	}
+
+	send(dto.ClientMessage{Type: dto.MessageSubscribe, ID: "sub", WagerID: wager.ID})
+	assert.Equal(t, dto.ServerMessage{Type: dto.MessageAck, ID: "sub"}, read())
+
+	send(dto.ClientMessage{Type: dto.MessageBuy, ID: "buy", WagerID: wager.ID, BuyingPrice: money.MustParse("25")})
+
+	// ack and events of the purchase are sent by different goroutines, so their order is not fixed
+	var ack dto.ServerMessage
+	var received []string
+	for len(received) < 2 || ack.Type == "" {
+		msg := read()
+		switch msg.Type {
+		case dto.MessageAck:
+			ack = msg
+		case dto.MessageEvent:
+			received = append(received, msg.Event)
+			assert.Equal(t, wager.ID, msg.Wager.ID)
+			assert.Equal(t, money.MustParse("25"), msg.Wager.CurrentSellingPrice)
+		default:
+			require.Fail(t, "unexpected message", "%+v", msg)
+		}
+	}
+
+	assert.Equal(t, []string{events.TypePriceChanged, events.TypeSoldOut}, received)
+	assert.Equal(t, "buy", ack.ID)
+	require.NotNil(t, ack.Purchase)
+	assert.Equal(t, wager.ID, ack.Purchase.WagerID)
+	assert.Equal(t, buyer.ID, ack.Purchase.BuyerID)
+
+	send(dto.ClientMessage{Type: dto.MessageBuy, ID: "again", WagerID: wager.ID, BuyingPrice: money.MustParse("20")})
+	assert.Equal(t, dto.ServerMessage{Type: dto.MessageError, ID: "again", Error: app_errors.ErrWagerSoldOut}, read())
+
+	require.Nil(t, socket.Shutdown(context.Background()))
+	_, _, err = conn.ReadMessage()
+	assert.Equal(t, &websocket.CloseError{Code: websocket.CloseGoingAway, Reason: "server shutting down"}, err)
+
+	var body strings.Builder
+	_, err = appMetrics.Registry.WriteTo(&body)
+	require.Nil(t, err)
+	assert.Contains(t, body.String(), `http_requests_total{route="/ws",method="GET",status="101"} 1`+"\n")
+}
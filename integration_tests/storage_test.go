@@ -0,0 +1,40 @@
+//go:build integration
+// +build integration
+
+package integration_tests
+
+import (
+	"context"
+	"testing"
+
+	env "github.com/joho/godotenv"
+	"github.com/stretchr/testify/require"
+
+	"github.com/vitthalaa/wager-app/internal/config"
+	"github.com/vitthalaa/wager-app/internal/logger"
+	"github.com/vitthalaa/wager-app/internal/metrics"
+	"github.com/vitthalaa/wager-app/internal/storage"
+)
+
+// openStorage opens storage backend configured in .env and applies migrations
+func openStorage(t *testing.T) *storage.Repositories {
+	return openStorageWithMetrics(t, metrics.New())
+}
+
+// openStorageWithMetrics opens storage like openStorage, registering connection pool stats to appMetrics
+func openStorageWithMetrics(t *testing.T, appMetrics *metrics.Metrics) *storage.Repositories {
+	var loadEnv = env.Overload
+	err := loadEnv("../.env")
+	require.Nil(t, err)
+
+	conf := config.GetAppConfig()
+
+	repos, err := storage.Open(&conf.DataBaseConfig, logger.Discard(), appMetrics)
+	require.Nil(t, err)
+
+	if repos.Migrator != nil {
+		require.Nil(t, repos.Migrator.Up(context.Background()))
+	}
+
+	return repos
+}
@@ -0,0 +1,158 @@
+//go:build integration
+// +build integration
+
+package integration_tests
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/vitthalaa/wager-app/dto"
+	"github.com/vitthalaa/wager-app/internal/auth"
+	"github.com/vitthalaa/wager-app/internal/events"
+	"github.com/vitthalaa/wager-app/internal/handlers"
+	"github.com/vitthalaa/wager-app/internal/logger"
+	"github.com/vitthalaa/wager-app/internal/metrics"
+	"github.com/vitthalaa/wager-app/internal/services"
+	"github.com/vitthalaa/wager-app/money"
+)
+
+// streamEvent is event frame read from stream
+type streamEvent struct {
+	id        uint64
+	eventType string
+	wager     dto.Wager
+}
+
+func Test_WagerStream(t *testing.T) {
+	repos := openStorage(t)
+	seller, _ := authenticate(t, repos)
+	buyer, token := authenticate(t, repos)
+
+	broker := events.NewBroker(100)
+	wagerService := services.NewWagerService(repos.Wager, repos.Purchase, logger.Discard(), metrics.New(), broker)
+	purchaseService := services.NewPurchaseService(
+		repos.Transactor, repos.Purchase, repos.Wager, repos.Wallet, repos.Order, logger.Discard(), metrics.New(), broker,
+		services.NewSellOutPolicy(services.SellingPercentageRule{}))
+	walletService := services.NewWalletService(repos.Transactor, repos.Wallet, logger.Discard())
+	settlementService := services.NewSettlementService(
+		repos.Transactor, repos.Wager, repos.Purchase, repos.Settlement, repos.Wallet, repos.Order, logger.Discard())
+
+	_, err := walletService.Deposit(context.Background(), &dto.DepositRequest{UserID: buyer.ID, Amount: money.MustParse("100")})
+	require.Nil(t, err)
+
+	stream := handlers.NewWagerStreamHandler(broker, time.Hour, logger.Discard())
+	wagersHandler := handlers.NewWagersHandler(wagerService, settlementService, nil, nil, logger.Discard(), stream)
+	mux := http.NewServeMux()
+	mux.HandleFunc("/wagers/", wagersHandler.Handle)
+	mux.HandleFunc("/buy/", handlers.NewPurchasesHandler(purchaseService, nil, logger.Discard()).Handle)
+	server := httptest.NewServer(auth.Middleware(newTokens(), logger.Discard(), mux))
+	t.Cleanup(server.Close)
+	// ends open streams before server waits for them on close
+	t.Cleanup(broker.Close)
+
+	all := openWagerStream(t, server.URL+"/wagers/stream", 0)
+
+	wager, err := wagerService.PlaceWager(context.Background(), &dto.PlaceWagerRequest{
+		SellerID:          seller.ID,
+		TotalWagerValue:   2,
+		Odds:              2,
+		SellingPercentage: 100,
+		SellingPrice:      money.MustParse("30"),
+	})
+	require.Nil(t, err)
+
+	created := readWagerEvent(t, all)
+	assert.Equal(t, events.TypeWagerCreated, created.eventType)
+	assert.Equal(t, wager.ID, created.wager.ID)
+
+	one := openWagerStream(t, fmt.Sprintf("%s/wagers/%d/stream", server.URL, wager.ID), 0)
+
+	buy := func(price string) {
+		rr := sendJSON(server.Config.Handler, "POST", fmt.Sprintf("/buy/%d", wager.ID), token,
+			dto.BuyWagerRequest{BuyingPrice: money.MustParse(price)})
+		require.Equal(t, http.StatusOK, rr.Code, rr.Body.String())
+	}
+
+	// every purchase sells one unit of wager at buying price
+	buy("20")
+	buy("10")
+
+	var received []streamEvent
+	for _, s := range []*bufio.Reader{all, one} {
+		changed := readWagerEvent(t, s)
+		assert.Equal(t, events.TypePriceChanged, changed.eventType)
+		assert.Equal(t, money.MustParse("20"), changed.wager.CurrentSellingPrice)
+
+		lastChanged := readWagerEvent(t, s)
+		assert.Equal(t, events.TypePriceChanged, lastChanged.eventType)
+		assert.Equal(t, money.MustParse("10"), lastChanged.wager.CurrentSellingPrice)
+
+		soldOut := readWagerEvent(t, s)
+		assert.Equal(t, events.TypeSoldOut, soldOut.eventType)
+		assert.Equal(t, "sold_out", soldOut.wager.Status)
+		assert.Equal(t, wager.ID, soldOut.wager.ID)
+
+		received = []streamEvent{changed, lastChanged, soldOut}
+	}
+
+	// reconnecting client gets events it missed
+	resumed := openWagerStream(t, fmt.Sprintf("%s/wagers/%d/stream", server.URL, wager.ID), received[0].id)
+	assert.Equal(t, received[1], readWagerEvent(t, resumed))
+	assert.Equal(t, received[2], readWagerEvent(t, resumed))
+
+	rr := sendJSON(server.Config.Handler, "GET", "/wagers/999999999/stream", token, nil)
+	assert.Equal(t, http.StatusNotFound, rr.Code)
+}
+
+// openWagerStream opens stream resuming after lastEventID, subscription is registered once response headers are received
+func openWagerStream(t *testing.T, url string, lastEventID uint64) *bufio.Reader {
+	req, err := http.NewRequest("GET", url, nil)
+	require.Nil(t, err)
+	if lastEventID > 0 {
+		req.Header.Set("Last-Event-ID", strconv.FormatUint(lastEventID, 10))
+	}
+
+	res, err := http.DefaultClient.Do(req)
+	require.Nil(t, err)
+	t.Cleanup(func() { res.Body.Close() })
+	require.Equal(t, http.StatusOK, res.StatusCode)
+	require.Equal(t, "text/event-stream", res.Header.Get("Content-Type"))
+
+	return bufio.NewReader(res.Body)
+}
+
+// readWagerEvent reads next event frame of stream, heartbeat comments are skipped
+func readWagerEvent(t *testing.T, stream *bufio.Reader) streamEvent {
+	var event streamEvent
+	for {
+		line, err := stream.ReadString('\n')
+		require.Nil(t, err)
+
+		field, value, _ := strings.Cut(strings.TrimSuffix(line, "\n"), ": ")
+		switch field {
+		case "id":
+			event.id, err = strconv.ParseUint(value, 10, 64)
+			require.Nil(t, err)
+		case "event":
+			event.eventType = value
+		case "data":
+			require.Nil(t, json.Unmarshal([]byte(value), &event.wager))
+		case "":
+			if event.eventType != "" {
+				return event
+			}
+		}
+	}
+}
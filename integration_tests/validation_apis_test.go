@@ -0,0 +1,98 @@
+//go:build integration
+// +build integration
+
+package integration_tests
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/vitthalaa/wager-app/app_errors"
+	"github.com/vitthalaa/wager-app/dto"
+	"github.com/vitthalaa/wager-app/internal/auth"
+	"github.com/vitthalaa/wager-app/internal/events"
+	"github.com/vitthalaa/wager-app/internal/handlers"
+	"github.com/vitthalaa/wager-app/internal/logger"
+	"github.com/vitthalaa/wager-app/internal/metrics"
+	"github.com/vitthalaa/wager-app/internal/services"
+	"github.com/vitthalaa/wager-app/money"
+)
+
+func Test_ValidationDetails(t *testing.T) {
+	repos := openStorage(t)
+	_, token := authenticate(t, repos)
+	tokens := newTokens()
+
+	wagerService := services.NewWagerService(repos.Wager, repos.Purchase, logger.Discard(), metrics.New(), events.NewBroker(1))
+	settlementService := services.NewSettlementService(
+		repos.Transactor, repos.Wager, repos.Purchase, repos.Settlement, repos.Wallet, repos.Order, logger.Discard())
+	wagerHandle := auth.Middleware(tokens, logger.Discard(), http.HandlerFunc(
+		handlers.NewWagersHandler(wagerService, settlementService, nil, nil, logger.Discard(), nil).Handle))
+
+	purchaseService := services.NewPurchaseService(
+		repos.Transactor, repos.Purchase, repos.Wager, repos.Wallet, repos.Order, logger.Discard(), metrics.New(),
+		events.NewBroker(1), services.NewSellOutPolicy(services.SellingPercentageRule{}))
+	purchaseHandle := auth.Middleware(tokens, logger.Discard(), http.HandlerFunc(
+		handlers.NewPurchasesHandler(purchaseService, nil, logger.Discard()).Handle))
+
+	errorOf := func(body []byte) app_errors.ErrorResponse {
+		var errRes app_errors.ErrorResponse
+		require.Nil(t, json.Unmarshal(body, &errRes), string(body))
+		return errRes
+	}
+
+	// Every violation is reported at once
+	rr := sendJSON(wagerHandle, "POST", "/wagers", token, dto.PlaceWagerRequest{
+		TotalWagerValue:   0,
+		Odds:              0,
+		SellingPercentage: 150,
+		SellingPrice:      money.MustParse("10"),
+	})
+	require.Equal(t, http.StatusBadRequest, rr.Code, rr.Body.String())
+
+	errRes := errorOf(rr.Body.Bytes())
+	assert.Equal(t, app_errors.ErrInvalidTotalWagerValue, errRes.Code)
+	assert.Equal(t, []app_errors.FieldError{
+		{Field: "total_wager_value", Rule: "min", Message: "must be at least 1", Limits: map[string]string{"min": "1"}},
+		{Field: "odds", Rule: "min", Message: "must be at least 1", Limits: map[string]string{"min": "1"}},
+		{Field: "selling_percentage", Rule: "max", Message: "must be at most 100", Limits: map[string]string{"max": "100"}},
+	}, errRes.Details)
+
+	// Unknown fields are rejected
+	rr = sendJSON(wagerHandle, "POST", "/wagers", token, map[string]interface{}{
+		"total_wager_value": 100, "odds": 2, "selling_percentage": 20, "selling_price": "21", "seller_id": 1,
+	})
+	require.Equal(t, http.StatusBadRequest, rr.Code, rr.Body.String())
+	assert.Equal(t, app_errors.ErrorResponse{
+		Code:    app_errors.ErrInvalidBody,
+		Details: []app_errors.FieldError{{Field: "seller_id", Rule: "unknown", Message: "is not allowed"}},
+	}, errorOf(rr.Body.Bytes()))
+
+	rr = sendJSON(wagerHandle, "POST", "/wagers", token, dto.PlaceWagerRequest{
+		TotalWagerValue:   100,
+		Odds:              2,
+		SellingPercentage: 20,
+		SellingPrice:      money.MustParse("21"),
+	})
+	require.Equal(t, http.StatusOK, rr.Code, rr.Body.String())
+
+	var wager dto.Wager
+	require.Nil(t, json.Unmarshal(rr.Body.Bytes(), &wager))
+
+	// Buying price is limited by current selling price of wager
+	rr = sendJSON(purchaseHandle, "POST", fmt.Sprintf("/buy/%d", wager.ID), token, dto.BuyWagerRequest{
+		BuyingPrice: money.MustParse("21.01"),
+	})
+	require.Equal(t, http.StatusBadRequest, rr.Code, rr.Body.String())
+	assert.Equal(t, app_errors.ErrorResponse{
+		Code: app_errors.ErrInvalidBuyingPrice,
+		Details: []app_errors.FieldError{
+			{Field: "buying_price", Rule: "max", Message: "must be at most 21.00", Limits: map[string]string{"max": "21.00"}},
+		},
+	}, errorOf(rr.Body.Bytes()))
+}
@@ -10,16 +10,19 @@ import (
 	"net/http"
 	"net/http/httptest"
 	"testing"
+	"time"
 
-	env "github.com/joho/godotenv"
+	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 
 	"github.com/vitthalaa/wager-app/dto"
-	"github.com/vitthalaa/wager-app/internal/config"
-	"github.com/vitthalaa/wager-app/internal/db"
+	"github.com/vitthalaa/wager-app/internal/auth"
+	"github.com/vitthalaa/wager-app/internal/events"
 	"github.com/vitthalaa/wager-app/internal/handlers"
-	"github.com/vitthalaa/wager-app/internal/repo"
+	"github.com/vitthalaa/wager-app/internal/logger"
+	"github.com/vitthalaa/wager-app/internal/metrics"
 	"github.com/vitthalaa/wager-app/internal/services"
+	"github.com/vitthalaa/wager-app/money"
 )
 
 func Test_WagerHandler(t *testing.T) {
@@ -28,34 +31,42 @@ func Test_WagerHandler(t *testing.T) {
 		TotalWagerValue:   100,
 		Odds:              2,
 		SellingPercentage: 20,
-		SellingPrice:      21,
+		SellingPrice:      money.MustParse("21"),
 	}
 
-	var loadEnv = env.Overload
-	err := loadEnv("../.env")
+	repos := openStorage(t)
+	seller, sellerToken := authenticate(t, repos)
+	buyer, buyerToken := authenticate(t, repos)
+	_, operatorToken := authenticateOperator(t, repos)
+	tokens := newTokens()
 
-	require.Nil(t, err)
-
-	conf := config.GetAppConfig()
+	wagerRepo := repos.Wager
 
-	conn, err := db.OpenConnection(&conf.DataBaseConfig)
-	require.Nil(t, err)
+	wagerService := services.NewWagerService(wagerRepo, repos.Purchase, logger.Discard(), metrics.New(), events.NewBroker(1))
 
-	wagerRepo := repo.NewWagerRepo(conn)
+	settlementService := services.NewSettlementService(
+		repos.Transactor, wagerRepo, repos.Purchase, repos.Settlement, repos.Wallet, repos.Order, logger.Discard())
 
-	wagerService := services.NewWagerService(wagerRepo)
-
-	wagerHandler := handlers.NewWagersHandler(wagerService)
+	wagerHandler := handlers.NewWagersHandler(wagerService, settlementService, nil, newIdempotencyService(repos), logger.Discard(), nil)
 
 	rr := httptest.NewRecorder()
-	handler := http.HandlerFunc(wagerHandler.Handle)
+	handler := auth.Middleware(tokens, logger.Discard(), http.HandlerFunc(wagerHandler.Handle))
 
 	body, err := json.Marshal(placeWagerReq)
 	require.Nil(t, err)
 
+	// Anonymous user can not place wager
 	req, err := http.NewRequest("POST", "/wagers", bytes.NewReader(body))
 	require.Nil(t, err)
 
+	handler.ServeHTTP(rr, req)
+	require.Equal(t, http.StatusUnauthorized, rr.Code, rr.Body.String())
+
+	req, err = http.NewRequest("POST", "/wagers", bytes.NewReader(body))
+	require.Nil(t, err)
+	req.Header.Set("Authorization", "Bearer "+sellerToken)
+
+	rr = httptest.NewRecorder()
 	handler.ServeHTTP(rr, req)
 
 	require.Equal(t, http.StatusOK, rr.Code, rr.Body.String())
@@ -65,9 +76,10 @@ func Test_WagerHandler(t *testing.T) {
 	require.Nil(t, err)
 	require.NotNil(t, wager)
 	require.NotEmpty(t, wager.ID)
+	require.Equal(t, seller.ID, wager.SellerID)
 
 	// 2. List wager
-	req, err = http.NewRequest("GET", "/wagers?page=1&limit=20", nil)
+	req, err = http.NewRequest("GET", "/wagers?limit=20", nil)
 	require.Nil(t, err)
 
 	rr = httptest.NewRecorder()
@@ -75,14 +87,14 @@ func Test_WagerHandler(t *testing.T) {
 
 	require.Equal(t, http.StatusOK, rr.Code, rr.Body.String())
 
-	var wagerList []dto.Wager
+	var wagerList dto.WagerList
 	err = json.Unmarshal(rr.Body.Bytes(), &wagerList)
 
 	require.Nil(t, err)
-	require.NotEmpty(t, wagerList)
+	require.NotEmpty(t, wagerList.Items)
 
 	idsMap := map[uint32]bool{}
-	for _, w := range wagerList {
+	for _, w := range wagerList.Items {
 		idsMap[w.ID] = true
 	}
 
@@ -90,18 +102,45 @@ func Test_WagerHandler(t *testing.T) {
 
 	// 3. Buy Wager
 	buyWagerReq := dto.BuyWagerRequest{
-		BuyingPrice: 20.5,
+		BuyingPrice: money.MustParse("20.5"),
+		Quantity:    2,
 	}
 	body, err = json.Marshal(buyWagerReq)
 	require.Nil(t, err)
 
-	purchaseRepo := repo.NewPurchaseRepo(conn)
-	purchaseService := services.NewPurchaseService(purchaseRepo, wagerRepo)
-	purchaseHandler := handlers.NewPurchasesHandler(purchaseService)
-	purchaseHandle := http.HandlerFunc(purchaseHandler.Handle)
+	purchaseRepo := repos.Purchase
+	purchaseService := services.NewPurchaseService(repos.Transactor, purchaseRepo, wagerRepo, repos.Wallet, repos.Order,
+		logger.Discard(), metrics.New(), events.NewBroker(1), services.NewSellOutPolicy(services.SellingPercentageRule{}))
+	purchaseHandler := handlers.NewPurchasesHandler(purchaseService, newIdempotencyService(repos), logger.Discard())
+	purchaseHandle := auth.Middleware(tokens, logger.Discard(), http.HandlerFunc(purchaseHandler.Handle))
+	walletHandle := auth.Middleware(tokens, logger.Discard(), http.HandlerFunc(newWalletHandler(repos).Handle))
+
+	// Buyer can not pay total price of units with wallet of single unit price
+	req, err = http.NewRequest("POST", fmt.Sprintf("/buy/%d", wager.ID), bytes.NewReader(body))
+	require.Nil(t, err)
+	req.Header.Set("Authorization", "Bearer "+buyerToken)
+
+	rr = httptest.NewRecorder()
+	purchaseHandle.ServeHTTP(rr, req)
+	require.Equal(t, http.StatusPaymentRequired, rr.Code, rr.Body.String())
+
+	rr = sendJSON(walletHandle, "POST", "/wallet/deposit", buyerToken, dto.DepositRequest{Amount: money.MustParse("30")})
+	require.Equal(t, http.StatusOK, rr.Code, rr.Body.String())
 
 	req, err = http.NewRequest("POST", fmt.Sprintf("/buy/%d", wager.ID), bytes.NewReader(body))
 	require.Nil(t, err)
+	req.Header.Set("Authorization", "Bearer "+buyerToken)
+
+	rr = httptest.NewRecorder()
+	purchaseHandle.ServeHTTP(rr, req)
+	require.Equal(t, http.StatusPaymentRequired, rr.Code, rr.Body.String())
+
+	rr = sendJSON(walletHandle, "POST", "/wallet/deposit", buyerToken, dto.DepositRequest{Amount: money.MustParse("20")})
+	require.Equal(t, http.StatusOK, rr.Code, rr.Body.String())
+
+	req, err = http.NewRequest("POST", fmt.Sprintf("/buy/%d", wager.ID), bytes.NewReader(body))
+	require.Nil(t, err)
+	req.Header.Set("Authorization", "Bearer "+buyerToken)
 
 	rr = httptest.NewRecorder()
 	purchaseHandle.ServeHTTP(rr, req)
@@ -113,4 +152,313 @@ func Test_WagerHandler(t *testing.T) {
 	require.NotNil(t, wagerPurchase)
 	require.NotEmpty(t, wagerPurchase.ID)
 	require.Equal(t, wager.ID, wagerPurchase.WagerID)
+	require.Equal(t, buyer.ID, wagerPurchase.BuyerID)
+	require.Equal(t, uint32(2), wagerPurchase.Quantity)
+	require.Equal(t, money.MustParse("20.5"), wagerPurchase.BuyingPrice)
+	require.Equal(t, money.MustParse("41"), wagerPurchase.TotalPrice)
+
+	// Total price moved from buyer to seller wallet
+	require.Equal(t, money.MustParse("9"), getWallet(t, walletHandle, buyerToken).Balance)
+	require.Equal(t, money.MustParse("41"), getWallet(t, walletHandle, sellerToken).Balance)
+
+	// Wager sells 20 units, 18 are left
+	rr = sendJSON(purchaseHandle, "POST", fmt.Sprintf("/buy/%d", wager.ID), buyerToken,
+		dto.BuyWagerRequest{BuyingPrice: money.MustParse("1"), Quantity: 19})
+	require.Equal(t, http.StatusBadRequest, rr.Code, rr.Body.String())
+	require.JSONEq(t, `{"error":"INVALID_QUANTITY","details":[{"field":"quantity","rule":"max","message":"must be at most 18","limits":{"max":"18"}}]}`,
+		rr.Body.String())
+
+	// 4. Get wager with purchases
+	req, err = http.NewRequest("GET", fmt.Sprintf("/wagers/%d", wager.ID), nil)
+	require.Nil(t, err)
+
+	rr = httptest.NewRecorder()
+	handler.ServeHTTP(rr, req)
+
+	require.Equal(t, http.StatusOK, rr.Code, rr.Body.String())
+	var wagerDetails dto.WagerDetails
+	err = json.Unmarshal(rr.Body.Bytes(), &wagerDetails)
+	require.Nil(t, err)
+	require.Equal(t, wager.ID, wagerDetails.ID)
+	require.Equal(t, uint32(2), wagerDetails.AmountSold)
+	require.Equal(t, float32(2), wagerDetails.PercentageSold)
+	require.Equal(t, uint32(1), wagerDetails.Purchases.Total)
+	require.Len(t, wagerDetails.Purchases.Items, 1)
+	require.Equal(t, wagerPurchase.ID, wagerDetails.Purchases.Items[0].ID)
+
+	// 5. List purchases of wager
+	req, err = http.NewRequest("GET", fmt.Sprintf("/purchases?wager_id=%d&min_price=20&sort=-buying_price", wager.ID), nil)
+	require.Nil(t, err)
+
+	rr = httptest.NewRecorder()
+	purchaseHandle.ServeHTTP(rr, req)
+
+	require.Equal(t, http.StatusOK, rr.Code, rr.Body.String())
+	var purchaseList dto.WagerPurchaseList
+	err = json.Unmarshal(rr.Body.Bytes(), &purchaseList)
+	require.Nil(t, err)
+	require.Equal(t, uint32(1), purchaseList.Total)
+	require.Len(t, purchaseList.Items, 1)
+	require.Equal(t, wagerPurchase.ID, purchaseList.Items[0].ID)
+
+	// 6. Get purchase
+	req, err = http.NewRequest("GET", fmt.Sprintf("/purchases/%d", wagerPurchase.ID), nil)
+	require.Nil(t, err)
+
+	rr = httptest.NewRecorder()
+	purchaseHandle.ServeHTTP(rr, req)
+
+	require.Equal(t, http.StatusOK, rr.Code, rr.Body.String())
+	var purchase dto.WagerPurchase
+	err = json.Unmarshal(rr.Body.Bytes(), &purchase)
+	require.Nil(t, err)
+	require.Equal(t, wagerPurchase.ID, purchase.ID)
+	require.Equal(t, wager.ID, purchase.WagerID)
+
+	// 7. Settle wager, only operators can, not even its seller
+	for _, token := range []string{buyerToken, sellerToken} {
+		req, err = http.NewRequest("POST", fmt.Sprintf("/wagers/%d/settle", wager.ID), bytes.NewReader([]byte(`{"outcome":"won"}`)))
+		require.Nil(t, err)
+		req.Header.Set("Authorization", "Bearer "+token)
+
+		rr = httptest.NewRecorder()
+		handler.ServeHTTP(rr, req)
+		require.Equal(t, http.StatusForbidden, rr.Code, rr.Body.String())
+		require.JSONEq(t, `{"error":"NOT_OPERATOR"}`, rr.Body.String())
+	}
+
+	req, err = http.NewRequest("POST", fmt.Sprintf("/wagers/%d/settle", wager.ID), bytes.NewReader([]byte(`{"outcome":"won"}`)))
+	require.Nil(t, err)
+	req.Header.Set("Authorization", "Bearer "+operatorToken)
+
+	rr = httptest.NewRecorder()
+	handler.ServeHTTP(rr, req)
+
+	require.Equal(t, http.StatusOK, rr.Code, rr.Body.String())
+	var settlement dto.WagerSettlement
+	err = json.Unmarshal(rr.Body.Bytes(), &settlement)
+	require.Nil(t, err)
+	require.Equal(t, "settled", settlement.Status)
+	require.Equal(t, "won", settlement.Outcome)
+	require.NotNil(t, settlement.SettledAt)
+	require.Len(t, settlement.Settlements, 1)
+	require.Equal(t, wagerPurchase.ID, settlement.Settlements[0].PurchaseID)
+	require.Equal(t, money.MustParse("4"), settlement.Settlements[0].Payout)
+
+	// Payout of odds per unit credited to buyer wallet, seller keeps proceeds
+	require.Equal(t, money.MustParse("13"), getWallet(t, walletHandle, buyerToken).Balance)
+	require.Equal(t, money.MustParse("41"), getWallet(t, walletHandle, sellerToken).Balance)
+
+	// 8. Settled wager can not be settled or bought again
+	req, err = http.NewRequest("POST", fmt.Sprintf("/wagers/%d/settle", wager.ID), bytes.NewReader([]byte(`{"outcome":"void"}`)))
+	require.Nil(t, err)
+	req.Header.Set("Authorization", "Bearer "+operatorToken)
+
+	rr = httptest.NewRecorder()
+	handler.ServeHTTP(rr, req)
+	require.Equal(t, http.StatusConflict, rr.Code, rr.Body.String())
+
+	req, err = http.NewRequest("POST", fmt.Sprintf("/buy/%d", wager.ID), bytes.NewReader(body))
+	require.Nil(t, err)
+	req.Header.Set("Authorization", "Bearer "+buyerToken)
+
+	rr = httptest.NewRecorder()
+	purchaseHandle.ServeHTTP(rr, req)
+	require.Equal(t, http.StatusConflict, rr.Code, rr.Body.String())
+
+	// 9. Get settlement
+	req, err = http.NewRequest("GET", fmt.Sprintf("/wagers/%d/settlement", wager.ID), nil)
+	require.Nil(t, err)
+
+	rr = httptest.NewRecorder()
+	handler.ServeHTTP(rr, req)
+
+	require.Equal(t, http.StatusOK, rr.Code, rr.Body.String())
+	var storedSettlement dto.WagerSettlement
+	err = json.Unmarshal(rr.Body.Bytes(), &storedSettlement)
+	require.Nil(t, err)
+	require.Equal(t, settlement.Settlements, storedSettlement.Settlements)
+	require.Equal(t, settlement.TotalPayout, storedSettlement.TotalPayout)
+}
+
+func Test_ListWagersCursorAndSort(t *testing.T) {
+	repos := openStorage(t)
+	_, token := authenticate(t, repos)
+
+	wagerService := services.NewWagerService(repos.Wager, repos.Purchase, logger.Discard(), metrics.New(), events.NewBroker(1))
+	settlementService := services.NewSettlementService(
+		repos.Transactor, repos.Wager, repos.Purchase, repos.Settlement, repos.Wallet, repos.Order, logger.Discard())
+	handler := auth.Middleware(newTokens(), logger.Discard(), http.HandlerFunc(
+		handlers.NewWagersHandler(wagerService, settlementService, nil, nil, logger.Discard(), nil).Handle))
+
+	// wagers placed from next second on keep wagers of other tests out of listing on shared database
+	from := time.Now().Truncate(time.Second).Add(time.Second)
+	time.Sleep(time.Until(from))
+	place := func(price string) dto.Wager {
+		rr := sendJSON(handler, "POST", "/wagers", token, dto.PlaceWagerRequest{
+			TotalWagerValue:   100,
+			Odds:              2,
+			SellingPercentage: 20,
+			SellingPrice:      money.MustParse(price),
+		})
+		require.Equal(t, http.StatusOK, rr.Code, rr.Body.String())
+
+		var wager dto.Wager
+		require.Nil(t, json.Unmarshal(rr.Body.Bytes(), &wager))
+		return wager
+	}
+	list := func(query string) dto.WagerList {
+		rr := sendJSON(handler, "GET", fmt.Sprintf("/wagers?from=%s&%s", from.UTC().Format(time.RFC3339), query), token, nil)
+		require.Equal(t, http.StatusOK, rr.Code, rr.Body.String())
+
+		var wagerList dto.WagerList
+		require.Nil(t, json.Unmarshal(rr.Body.Bytes(), &wagerList))
+		return wagerList
+	}
+	ids := func(wagerList dto.WagerList) []uint32 {
+		res := make([]uint32, 0, len(wagerList.Items))
+		for _, w := range wagerList.Items {
+			res = append(res, w.ID)
+		}
+
+		return res
+	}
+
+	first, second, third := place("21"), place("30"), place("40")
+
+	page := list("limit=2")
+	assert.Equal(t, []uint32{third.ID, second.ID}, ids(page))
+	require.True(t, page.HasMore)
+	require.NotEmpty(t, page.NextCursor)
+
+	// wager placed between pages does not shift next page
+	fourth := place("50")
+
+	page = list("limit=2&cursor=" + page.NextCursor)
+	assert.Equal(t, []uint32{first.ID}, ids(page))
+	assert.False(t, page.HasMore)
+	assert.Empty(t, page.NextCursor)
+
+	assert.Equal(t, []uint32{third.ID, second.ID}, ids(list("min_price=30&max_price=40")))
+	assert.Len(t, list("sold_out=false").Items, 4)
+	assert.Empty(t, list("sold_out=true").Items)
+
+	// sorted by price with cursor of same sort
+	page = list("sort=-current_selling_price&limit=3")
+	assert.Equal(t, []uint32{fourth.ID, third.ID, second.ID}, ids(page))
+	require.True(t, page.HasMore)
+
+	cursor := page.NextCursor
+	page = list("sort=-current_selling_price&limit=3&cursor=" + cursor)
+	assert.Equal(t, []uint32{first.ID}, ids(page))
+	assert.False(t, page.HasMore)
+
+	rr := sendJSON(handler, "GET", "/wagers?cursor="+cursor, token, nil)
+	require.Equal(t, http.StatusBadRequest, rr.Code, rr.Body.String())
+	assert.JSONEq(t, `{"error":"INVALID_CURSOR"}`, rr.Body.String())
+
+	rr = sendJSON(handler, "GET", "/wagers?sort=seller_id", token, nil)
+	require.Equal(t, http.StatusBadRequest, rr.Code, rr.Body.String())
+	assert.JSONEq(t, `{"error":"INVALID_SORT"}`, rr.Body.String())
+
+	rr = sendJSON(handler, "GET", "/wagers?cursor=abc", token, nil)
+	require.Equal(t, http.StatusBadRequest, rr.Code, rr.Body.String())
+	assert.JSONEq(t, `{"error":"INVALID_CURSOR"}`, rr.Body.String())
+
+	rr = sendJSON(handler, "GET", "/wagers?limit=1000", token, nil)
+	require.Equal(t, http.StatusBadRequest, rr.Code, rr.Body.String())
+	assert.JSONEq(t, `{"error":"INVALID_FILTER"}`, rr.Body.String())
+}
+
+func Test_WagerPricing(t *testing.T) {
+	repos := openStorage(t)
+	_, sellerToken := authenticate(t, repos)
+	_, buyerToken := authenticate(t, repos)
+	tokens := newTokens()
+
+	wagerService := services.NewWagerService(repos.Wager, repos.Purchase, logger.Discard(), metrics.New(), events.NewBroker(1))
+	settlementService := services.NewSettlementService(
+		repos.Transactor, repos.Wager, repos.Purchase, repos.Settlement, repos.Wallet, repos.Order, logger.Discard())
+	wagerHandle := auth.Middleware(tokens, logger.Discard(), http.HandlerFunc(
+		handlers.NewWagersHandler(wagerService, settlementService, nil, nil, logger.Discard(), nil).Handle))
+	purchaseService := services.NewPurchaseService(repos.Transactor, repos.Purchase, repos.Wager, repos.Wallet, repos.Order,
+		logger.Discard(), metrics.New(), events.NewBroker(1), services.NewSellOutPolicy(services.SellingPercentageRule{}))
+	purchaseHandle := auth.Middleware(tokens, logger.Discard(), http.HandlerFunc(
+		handlers.NewPurchasesHandler(purchaseService, nil, logger.Discard()).Handle))
+	walletHandle := auth.Middleware(tokens, logger.Discard(), http.HandlerFunc(newWalletHandler(repos).Handle))
+
+	getWager := func(id uint32) dto.Wager {
+		rr := sendJSON(wagerHandle, "GET", fmt.Sprintf("/wagers/%d", id), sellerToken, nil)
+		require.Equal(t, http.StatusOK, rr.Code, rr.Body.String())
+
+		var details dto.WagerDetails
+		require.Nil(t, json.Unmarshal(rr.Body.Bytes(), &details))
+		return details.Wager
+	}
+
+	// Decaying pricing requires floor price and decay seconds
+	rr := sendJSON(wagerHandle, "POST", "/wagers", sellerToken, dto.PlaceWagerRequest{
+		TotalWagerValue:   100,
+		Odds:              2,
+		SellingPercentage: 20,
+		SellingPrice:      money.MustParse("21"),
+		Pricing:           "time_decay",
+	})
+	require.Equal(t, http.StatusBadRequest, rr.Code, rr.Body.String())
+	require.JSONEq(t, `{"error":"INVALID_FLOOR_PRICE","details":[
+		{"field":"floor_price","rule":"required","message":"is required"},
+		{"field":"decay_seconds","rule":"required","message":"is required"}]}`, rr.Body.String())
+
+	// Demand step up raises price by step percentage for every unit bought
+	rr = sendJSON(wagerHandle, "POST", "/wagers", sellerToken, dto.PlaceWagerRequest{
+		TotalWagerValue:   100,
+		Odds:              2,
+		SellingPercentage: 20,
+		SellingPrice:      money.MustParse("21"),
+		Pricing:           "demand_step_up",
+		StepPercentage:    10,
+	})
+	require.Equal(t, http.StatusOK, rr.Code, rr.Body.String())
+
+	var stepUp dto.Wager
+	require.Nil(t, json.Unmarshal(rr.Body.Bytes(), &stepUp))
+	require.Equal(t, "demand_step_up", stepUp.Pricing)
+	require.Equal(t, float32(10), stepUp.StepPercentage)
+
+	rr = sendJSON(walletHandle, "POST", "/wallet/deposit", buyerToken, dto.DepositRequest{Amount: money.MustParse("100")})
+	require.Equal(t, http.StatusOK, rr.Code, rr.Body.String())
+
+	rr = sendJSON(purchaseHandle, "POST", fmt.Sprintf("/buy/%d", stepUp.ID), buyerToken,
+		dto.BuyWagerRequest{BuyingPrice: money.MustParse("20"), Quantity: 2})
+	require.Equal(t, http.StatusOK, rr.Code, rr.Body.String())
+
+	stepUp = getWager(stepUp.ID)
+	assert.Equal(t, "demand_step_up", stepUp.Pricing)
+	assert.Equal(t, money.MustParse("25.2"), stepUp.CurrentSellingPrice)
+
+	// Dutch auction price decays from selling price on read, buying over it fails
+	rr = sendJSON(wagerHandle, "POST", "/wagers", sellerToken, dto.PlaceWagerRequest{
+		TotalWagerValue:   100,
+		Odds:              2,
+		SellingPercentage: 20,
+		SellingPrice:      money.MustParse("21"),
+		Pricing:           "dutch_auction",
+		FloorPrice:        money.MustParse("1"),
+		DecaySeconds:      1,
+	})
+	require.Equal(t, http.StatusOK, rr.Code, rr.Body.String())
+
+	var auction dto.Wager
+	require.Nil(t, json.Unmarshal(rr.Body.Bytes(), &auction))
+	require.Equal(t, money.MustParse("1"), auction.FloorPrice)
+	require.Equal(t, uint32(1), auction.DecaySeconds)
+
+	time.Sleep(1100 * time.Millisecond)
+	assert.Equal(t, money.MustParse("1"), getWager(auction.ID).CurrentSellingPrice)
+
+	rr = sendJSON(purchaseHandle, "POST", fmt.Sprintf("/buy/%d", auction.ID), buyerToken,
+		dto.BuyWagerRequest{BuyingPrice: money.MustParse("2")})
+	require.Equal(t, http.StatusBadRequest, rr.Code, rr.Body.String())
+	require.JSONEq(t, `{"error":"INVALID_BUYING_PRICE","details":[
+		{"field":"buying_price","rule":"max","message":"must be at most 1.00","limits":{"max":"1.00"}}]}`, rr.Body.String())
 }
@@ -0,0 +1,104 @@
+//go:build integration
+// +build integration
+
+package integration_tests
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/vitthalaa/wager-app/dto"
+	"github.com/vitthalaa/wager-app/internal/auth"
+	"github.com/vitthalaa/wager-app/internal/handlers"
+	"github.com/vitthalaa/wager-app/internal/logger"
+	"github.com/vitthalaa/wager-app/internal/services"
+	"github.com/vitthalaa/wager-app/internal/storage"
+	"github.com/vitthalaa/wager-app/money"
+)
+
+func Test_WalletHandler(t *testing.T) {
+	repos := openStorage(t)
+	_, token := authenticate(t, repos)
+	handler := auth.Middleware(newTokens(), logger.Discard(), http.HandlerFunc(newWalletHandler(repos).Handle))
+
+	// 1. New user has empty wallet
+	require.Equal(t, money.Money(0), getWallet(t, handler, token).Balance)
+
+	// 2. Deposit
+	rr := sendJSON(handler, "POST", "/wallet/deposit", token, dto.DepositRequest{Amount: money.MustParse("25.75")})
+	require.Equal(t, http.StatusOK, rr.Code, rr.Body.String())
+
+	var wallet dto.Wallet
+	require.Nil(t, json.Unmarshal(rr.Body.Bytes(), &wallet))
+	require.Equal(t, money.MustParse("25.75"), wallet.Balance)
+
+	// 3. Withdraw more than balance
+	rr = sendJSON(handler, "POST", "/wallet/withdraw", token, dto.WithdrawRequest{Amount: money.MustParse("25.76")})
+	require.Equal(t, http.StatusPaymentRequired, rr.Code, rr.Body.String())
+
+	// 4. Withdraw
+	rr = sendJSON(handler, "POST", "/wallet/withdraw", token, dto.WithdrawRequest{Amount: money.MustParse("5.25")})
+	require.Equal(t, http.StatusOK, rr.Code, rr.Body.String())
+	require.Equal(t, money.MustParse("20.5"), getWallet(t, handler, token).Balance)
+
+	// 5. List entries, newest first
+	req, err := http.NewRequest("GET", "/wallet/entries?page=1&limit=20", nil)
+	require.Nil(t, err)
+	req.Header.Set("Authorization", "Bearer "+token)
+
+	rr = httptest.NewRecorder()
+	handler.ServeHTTP(rr, req)
+	require.Equal(t, http.StatusOK, rr.Code, rr.Body.String())
+
+	var entries dto.LedgerEntryList
+	require.Nil(t, json.Unmarshal(rr.Body.Bytes(), &entries))
+	require.Equal(t, uint32(2), entries.Total)
+	require.Len(t, entries.Items, 2)
+	require.Equal(t, "withdrawal", entries.Items[0].Kind)
+	require.Equal(t, money.MustParse("-5.25"), entries.Items[0].Amount)
+	require.Equal(t, "deposit", entries.Items[1].Kind)
+	require.Equal(t, money.MustParse("25.75"), entries.Items[1].Amount)
+
+	// 6. Page size over 100 is rejected like on wager listing
+	rr = sendJSON(handler, "GET", "/wallet/entries?limit=1000", token, nil)
+	require.Equal(t, http.StatusBadRequest, rr.Code, rr.Body.String())
+	require.JSONEq(t, `{"error":"INVALID_FILTER"}`, rr.Body.String())
+
+	// 7. Ledger stays balanced
+	require.Nil(t, services.NewWalletService(repos.Transactor, repos.Wallet, logger.Discard()).CheckLedger(context.Background()))
+}
+
+func newWalletHandler(repos *storage.Repositories) *handlers.WalletHandler {
+	return handlers.NewWalletHandler(services.NewWalletService(repos.Transactor, repos.Wallet, logger.Discard()), logger.Discard())
+}
+
+// getWallet returns wallet of token user
+func getWallet(t *testing.T, handler http.Handler, token string) dto.Wallet {
+	req, err := http.NewRequest("GET", "/wallet", nil)
+	require.Nil(t, err)
+	req.Header.Set("Authorization", "Bearer "+token)
+
+	rr := httptest.NewRecorder()
+	handler.ServeHTTP(rr, req)
+	require.Equal(t, http.StatusOK, rr.Code, rr.Body.String())
+
+	var wallet dto.Wallet
+	require.Nil(t, json.Unmarshal(rr.Body.Bytes(), &wallet))
+	return wallet
+}
+
+// sendJSON sends body as authenticated request of token user
+func sendJSON(handler http.Handler, method, path, token string, body interface{}) *httptest.ResponseRecorder {
+	b, _ := json.Marshal(body)
+	req := httptest.NewRequest(method, path, bytes.NewReader(b))
+	req.Header.Set("Authorization", "Bearer "+token)
+	rr := httptest.NewRecorder()
+	handler.ServeHTTP(rr, req)
+	return rr
+}
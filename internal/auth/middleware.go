@@ -0,0 +1,76 @@
+package auth
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"strings"
+
+	"github.com/vitthalaa/wager-app/app_errors"
+	"github.com/vitthalaa/wager-app/internal/logger"
+)
+
+// RoleOperator is role of users allowed to settle wagers
+const RoleOperator = "operator"
+
+// User is authenticated user of request
+type User struct {
+	ID       uint32
+	Username string
+	// Role is RoleOperator for operators, empty for other users
+	Role string
+}
+
+// IsOperator returns true when user has operator role
+func (u User) IsOperator() bool {
+	return u.Role == RoleOperator
+}
+
+type userKey struct{}
+
+// WithUser returns copy of ctx carrying authenticated user
+func WithUser(ctx context.Context, user User) context.Context {
+	return context.WithValue(ctx, userKey{}, user)
+}
+
+// UserFromContext returns authenticated user of ctx, false for anonymous requests
+func UserFromContext(ctx context.Context) (User, bool) {
+	user, ok := ctx.Value(userKey{}).(User)
+	return user, ok
+}
+
+// Middleware authenticates requests carrying "Authorization: Bearer <token>" header and
+// puts user to request context. Requests without header pass as anonymous,
+// handlers decide which routes require user. Invalid tokens are rejected with 401.
+func Middleware(tokens *Tokens, log *logger.Logger, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		header := req.Header.Get("Authorization")
+		if header == "" {
+			next.ServeHTTP(w, req)
+			return
+		}
+
+		scheme, token, _ := strings.Cut(header, " ")
+		if !strings.EqualFold(scheme, "Bearer") {
+			Unauthorized(w)
+			return
+		}
+
+		user, err := tokens.Verify(strings.TrimSpace(token))
+		if err != nil {
+			log.Warn(req.Context(), "authentication failed", "error", err)
+			Unauthorized(w)
+			return
+		}
+
+		next.ServeHTTP(w, req.WithContext(WithUser(req.Context(), user)))
+	})
+}
+
+// Unauthorized writes 401 response asking for bearer token
+func Unauthorized(w http.ResponseWriter) {
+	w.Header().Set("WWW-Authenticate", "Bearer")
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusUnauthorized)
+	_ = json.NewEncoder(w).Encode(app_errors.ErrorResponse{Code: app_errors.ErrUnauthorized})
+}
@@ -0,0 +1,77 @@
+package auth
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/vitthalaa/wager-app/internal/logger"
+)
+
+func TestMiddleware(t *testing.T) {
+	tokens := NewTokens([]byte("secret"), time.Hour)
+	token, _, err := tokens.Issue(User{ID: 7, Username: "alice"})
+	require.Nil(t, err)
+
+	for _, tc := range []struct {
+		name   string
+		header string
+
+		expectedCode int
+		expectedUser *User
+	}{
+		{
+			name:         "anonymous",
+			expectedCode: http.StatusOK,
+		},
+		{
+			name:         "bearer token",
+			header:       "Bearer " + token,
+			expectedCode: http.StatusOK,
+			expectedUser: &User{ID: 7, Username: "alice"},
+		},
+		{
+			name:         "invalid token",
+			header:       "Bearer abc",
+			expectedCode: http.StatusUnauthorized,
+		},
+		{
+			name:         "other scheme",
+			header:       "Basic YWxpY2U6c2VjcmV0",
+			expectedCode: http.StatusUnauthorized,
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			var called bool
+			next := http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+				called = true
+				user, ok := UserFromContext(req.Context())
+				if tc.expectedUser == nil {
+					assert.False(t, ok)
+					return
+				}
+
+				assert.True(t, ok)
+				assert.Equal(t, *tc.expectedUser, user)
+			})
+
+			request, err := http.NewRequest("GET", "http://domain.co/wagers", nil)
+			require.Nil(t, err)
+			if tc.header != "" {
+				request.Header.Set("Authorization", tc.header)
+			}
+
+			resRecorder := httptest.NewRecorder()
+			Middleware(tokens, logger.Discard(), next).ServeHTTP(resRecorder, request)
+
+			require.Equal(t, tc.expectedCode, resRecorder.Code)
+			assert.Equal(t, tc.expectedCode == http.StatusOK, called)
+			if tc.expectedCode == http.StatusUnauthorized {
+				assert.JSONEq(t, `{"error":"UNAUTHORIZED"}`, resRecorder.Body.String())
+			}
+		})
+	}
+}
@@ -0,0 +1,109 @@
+// Package auth issues and verifies bearer tokens and authenticates requests.
+package auth
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ErrInvalidToken is returned for malformed, tampered or expired tokens
+var ErrInvalidToken = errors.New("invalid token")
+
+// tokenHeader is base64url encoded {"alg":"HS256","typ":"JWT"}, it is the only header accepted
+var tokenHeader = base64.RawURLEncoding.EncodeToString([]byte(`{"alg":"HS256","typ":"JWT"}`))
+
+// Claims is payload of token
+type Claims struct {
+	// Subject is user id
+	Subject   string `json:"sub"`
+	Username  string `json:"username"`
+	Role      string `json:"role,omitempty"`
+	IssuedAt  int64  `json:"iat"`
+	ExpiresAt int64  `json:"exp"`
+}
+
+// NewTokens ...
+func NewTokens(secret []byte, ttl time.Duration) *Tokens {
+	return &Tokens{
+		secret: secret,
+		ttl:    ttl,
+		now:    time.Now,
+	}
+}
+
+// Tokens issues and verifies JWT signed with HMAC-SHA256
+type Tokens struct {
+	secret []byte
+	ttl    time.Duration
+	now    func() time.Time
+}
+
+// Issue returns signed token of user and its expiry time
+func (t *Tokens) Issue(user User) (string, time.Time, error) {
+	issuedAt := t.now()
+	expiresAt := issuedAt.Add(t.ttl)
+	payload, err := json.Marshal(Claims{
+		Subject:   strconv.FormatUint(uint64(user.ID), 10),
+		Username:  user.Username,
+		Role:      user.Role,
+		IssuedAt:  issuedAt.Unix(),
+		ExpiresAt: expiresAt.Unix(),
+	})
+	if err != nil {
+		return "", time.Time{}, err
+	}
+
+	unsigned := tokenHeader + "." + base64.RawURLEncoding.EncodeToString(payload)
+	return unsigned + "." + t.sign(unsigned), expiresAt, nil
+}
+
+// Verify checks token signature and expiry and returns user of token
+func (t *Tokens) Verify(token string) (User, error) {
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 || parts[0] != tokenHeader {
+		return User{}, ErrInvalidToken
+	}
+
+	signature, err := base64.RawURLEncoding.DecodeString(parts[2])
+	if err != nil {
+		return User{}, ErrInvalidToken
+	}
+
+	expected, _ := base64.RawURLEncoding.DecodeString(t.sign(parts[0] + "." + parts[1]))
+	if !hmac.Equal(signature, expected) {
+		return User{}, ErrInvalidToken
+	}
+
+	payload, err := base64.RawURLEncoding.DecodeString(parts[1])
+	if err != nil {
+		return User{}, ErrInvalidToken
+	}
+
+	var claims Claims
+	if err = json.Unmarshal(payload, &claims); err != nil {
+		return User{}, ErrInvalidToken
+	}
+
+	if t.now().Unix() >= claims.ExpiresAt {
+		return User{}, ErrInvalidToken
+	}
+
+	id, err := strconv.ParseUint(claims.Subject, 10, 32)
+	if err != nil || id == 0 {
+		return User{}, ErrInvalidToken
+	}
+
+	return User{ID: uint32(id), Username: claims.Username, Role: claims.Role}, nil
+}
+
+func (t *Tokens) sign(unsigned string) string {
+	mac := hmac.New(sha256.New, t.secret)
+	mac.Write([]byte(unsigned))
+	return base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
@@ -0,0 +1,88 @@
+package auth
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTokens_IssueVerify(t *testing.T) {
+	now := time.Unix(1700000000, 0)
+	tokens := NewTokens([]byte("secret"), time.Hour)
+	tokens.now = func() time.Time { return now }
+
+	token, expiresAt, err := tokens.Issue(User{ID: 7, Username: "alice"})
+	require.Nil(t, err)
+	assert.Equal(t, now.Add(time.Hour), expiresAt)
+
+	user, err := tokens.Verify(token)
+	require.Nil(t, err)
+	assert.Equal(t, User{ID: 7, Username: "alice"}, user)
+
+	operatorToken, _, err := tokens.Issue(User{ID: 8, Username: "bob", Role: RoleOperator})
+	require.Nil(t, err)
+
+	user, err = tokens.Verify(operatorToken)
+	require.Nil(t, err)
+	assert.True(t, user.IsOperator())
+
+	parts := strings.Split(token, ".")
+	for _, tc := range []struct {
+		name  string
+		token string
+		now   time.Time
+	}{
+		{
+			name:  "malformed",
+			token: "abc",
+			now:   now,
+		},
+		{
+			name:  "tampered payload",
+			token: parts[0] + "." + parts[1] + "e30." + parts[2],
+			now:   now,
+		},
+		{
+			name:  "tampered signature",
+			token: parts[0] + "." + parts[1] + "." + strings.Repeat("A", len(parts[2])),
+			now:   now,
+		},
+		{
+			name:  "other header",
+			token: "eyJhbGciOiJub25lIn0." + parts[1] + "." + parts[2],
+			now:   now,
+		},
+		{
+			name:  "expired",
+			token: token,
+			now:   expiresAt,
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			tokens.now = func() time.Time { return tc.now }
+
+			_, err := tokens.Verify(tc.token)
+			assert.Equal(t, ErrInvalidToken, err)
+		})
+	}
+
+	t.Run("other secret", func(t *testing.T) {
+		other := NewTokens([]byte("other"), time.Hour)
+		other.now = func() time.Time { return now }
+
+		_, err := other.Verify(token)
+		assert.Equal(t, ErrInvalidToken, err)
+	})
+}
+
+func TestCheckPassword(t *testing.T) {
+	hash, err := HashPassword("secret123")
+	require.Nil(t, err)
+
+	assert.True(t, CheckPassword(hash, "secret123"))
+	assert.False(t, CheckPassword(hash, "secret124"))
+	assert.False(t, CheckPassword("", "secret123"))
+}
@@ -1,17 +1,45 @@
 package config
 
 import (
+	"errors"
+	"fmt"
 	"os"
 	"strconv"
 	"strings"
+	"time"
+)
+
+// Supported storage drivers
+const (
+	DriverPostgres = "postgres"
+	DriverMemory   = "memory"
+	DriverSQLite   = "sqlite"
 )
 
 type AppConfig struct {
 	Port           int
 	DataBaseConfig DataBaseConfig
+	// MigrateOnStart applies pending schema migrations when app starts
+	MigrateOnStart bool
+	AuthConfig     AuthConfig
+	// IdempotencyKeyTTL is how long responses of requests with Idempotency-Key are replayed
+	IdempotencyKeyTTL time.Duration
+	LogConfig         LogConfig
+	// ReadinessTimeout limits checks of readiness probe
+	ReadinessTimeout time.Duration
+	// ShutdownDrainDelay is how long app keeps serving with failing readiness before server shuts down,
+	// so that load balancers stop sending new requests
+	ShutdownDrainDelay time.Duration
+	StreamConfig       StreamConfig
+	SocketConfig       SocketConfig
+	// SellOutRules are rules of sell out policy, wager is sold out when any of them is reached
+	SellOutRules []string
+	// AuctionCloseInterval is how often auctions whose bidding ended are closed and their units awarded
+	AuctionCloseInterval time.Duration
 }
 
 type DataBaseConfig struct {
+	DBDriver      string
 	DBName        string
 	DBHost        string
 	DBPort        int
@@ -19,17 +47,111 @@ type DataBaseConfig struct {
 	DBPass        string
 	DBMaxOpenConn int
 	DBMaxIdleConn int
+	// DBPath is database file path for sqlite driver
+	DBPath string
+}
+
+type LogConfig struct {
+	// Level is minimal level of written lines: debug, info, warn or error
+	Level string
+	// Format is json or text
+	Format string
+}
+
+type StreamConfig struct {
+	// HeartbeatInterval is how often idle event streams get heartbeat comment
+	HeartbeatInterval time.Duration
+	// ReplayBuffer is number of latest events replayed to clients resuming stream with Last-Event-ID
+	ReplayBuffer int
+}
+
+type SocketConfig struct {
+	// SendBuffer is number of messages queued for WebSocket client, reading of its messages pauses while queue is full
+	SendBuffer int
+	// PingInterval is how often clients are pinged, connections not answering within two intervals are closed
+	PingInterval time.Duration
+	// AllowedOrigins are origins, ex. https://app.example.com, of browser pages allowed to connect besides host of API
+	AllowedOrigins []string
+}
+
+type AuthConfig struct {
+	// TokenSecret is HMAC key signing access tokens
+	TokenSecret string
+	TokenTTL    time.Duration
+	// OperatorUsernames are users given operator role on login
+	OperatorUsernames []string
+}
+
+// MinTokenSecretLength is minimal length of token secret in bytes
+const MinTokenSecretLength = 32
+
+// devTokenSecret is secret former .env shipped with, it is public so tokens signed by it can be forged
+const devTokenSecret = "wagerAppDevSecret"
+
+// Validate returns error when token secret is missing, public or too short
+func (c AuthConfig) Validate() error {
+	switch {
+	case c.TokenSecret == "":
+		return errors.New("no auth token secret specified")
+	case c.TokenSecret == devTokenSecret:
+		return errors.New("auth token secret is public development value, set a random one")
+	case len(c.TokenSecret) < MinTokenSecretLength:
+		return fmt.Errorf("auth token secret must be at least %d bytes long", MinTokenSecretLength)
+	}
+
+	return nil
 }
 
 func GetAppConfig() AppConfig {
 	return AppConfig{
-		Port:           osValToInt("PORT", 8080),
-		DataBaseConfig: GetDatabaseConfig(),
+		Port:                 osValToInt("PORT", 8080),
+		DataBaseConfig:       GetDatabaseConfig(),
+		MigrateOnStart:       osValToBool("MIGRATE_ON_START", true),
+		AuthConfig:           GetAuthConfig(),
+		IdempotencyKeyTTL:    osValToDuration("IDEMPOTENCY_KEY_TTL", 24*time.Hour),
+		LogConfig:            GetLogConfig(),
+		ReadinessTimeout:     osValToDuration("READINESS_TIMEOUT", 2*time.Second),
+		ShutdownDrainDelay:   osValToDuration("SHUTDOWN_DRAIN_DELAY", 5*time.Second),
+		StreamConfig:         GetStreamConfig(),
+		SocketConfig:         GetSocketConfig(),
+		SellOutRules:         osValToArray("SELL_OUT_RULES", ",", []string{"percentage"}),
+		AuctionCloseInterval: osValToDuration("AUCTION_CLOSE_INTERVAL", 10*time.Second),
+	}
+}
+
+func GetStreamConfig() StreamConfig {
+	return StreamConfig{
+		HeartbeatInterval: osValToDuration("STREAM_HEARTBEAT_INTERVAL", 15*time.Second),
+		ReplayBuffer:      osValToInt("STREAM_REPLAY_BUFFER", 1000),
+	}
+}
+
+func GetSocketConfig() SocketConfig {
+	return SocketConfig{
+		SendBuffer:     osValToInt("WS_SEND_BUFFER", 32),
+		PingInterval:   osValToDuration("WS_PING_INTERVAL", 30*time.Second),
+		AllowedOrigins: osValToArray("WS_ALLOWED_ORIGINS", ",", nil),
+	}
+}
+
+func GetLogConfig() LogConfig {
+	return LogConfig{
+		Level:  strings.ToLower(osVal("LOG_LEVEL", "info")),
+		Format: strings.ToLower(osVal("LOG_FORMAT", "json")),
+	}
+}
+
+func GetAuthConfig() AuthConfig {
+	return AuthConfig{
+		TokenSecret:       osVal("AUTH_TOKEN_SECRET", ""),
+		TokenTTL:          osValToDuration("AUTH_TOKEN_TTL", 24*time.Hour),
+		OperatorUsernames: osValToArray("OPERATOR_USERNAMES", ",", nil),
 	}
 }
 
 func GetDatabaseConfig() DataBaseConfig {
 	return DataBaseConfig{
+		DBDriver:      strings.ToLower(osVal("DB_DRIVER", DriverPostgres)),
 		DBName:        osVal("POSTGRES_DB", ""),
 		DBHost:        osVal("POSTGRES_HOST", "localhost"),
 		DBPort:        osValToInt("POSTGRES_PORT", 5432),
@@ -37,6 +159,7 @@ func GetDatabaseConfig() DataBaseConfig {
 		DBPass:        osVal("POSTGRES_PASSWORD", ""),
 		DBMaxOpenConn: osValToInt("POSTGRES_MAX_OPEN_CONN", 20),
 		DBMaxIdleConn: osValToInt("POSTGRES_MAX_IDLE_CONN", 5),
+		DBPath:        osVal("SQLITE_PATH", "wager_app.db"),
 	}
 }
 
@@ -56,7 +179,7 @@ func osValToInt(key string, defaultVal int) int {
 	}
 
 	i64, err := strconv.ParseInt(val, 10, 32)
-	if err != nil {
+	if err != nil || i64 < 0 {
 		return defaultVal
 	}
 
@@ -72,6 +195,20 @@ func osValToBool(key string, defaultVal bool) bool {
 	return strings.ToUpper(val) == "TRUE"
 }
 
+func osValToDuration(key string, defaultVal time.Duration) time.Duration {
+	val := osVal(key, "")
+	if val == "" {
+		return defaultVal
+	}
+
+	d, err := time.ParseDuration(val)
+	if err != nil || d <= 0 {
+		return defaultVal
+	}
+
+	return d
+}
+
 func osValToArray(key, sep string, defaultVal []string) []string {
 	val := osVal(key, "")
 	if val == "" {
@@ -3,6 +3,7 @@ package config
 import (
 	"os"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 )
@@ -24,3 +25,229 @@ func TestGetDatabaseConfigConfig(t *testing.T) {
 	assert.Equal(t, "test_db_env_name", conf.DBName)
 	assert.Equal(t, "test_db_env_user", conf.DBUser)
 }
+
+func TestGetDatabaseConfig_Driver(t *testing.T) {
+	driver, exist := os.LookupEnv("DB_DRIVER")
+	defer func() {
+		if exist {
+			os.Setenv("DB_DRIVER", driver)
+		} else {
+			os.Unsetenv("DB_DRIVER")
+		}
+	}()
+
+	os.Unsetenv("DB_DRIVER")
+	assert.Equal(t, DriverPostgres, GetDatabaseConfig().DBDriver)
+
+	os.Setenv("DB_DRIVER", " Memory ")
+	assert.Equal(t, DriverMemory, GetDatabaseConfig().DBDriver)
+}
+
+func TestGetAuthConfig(t *testing.T) {
+	secret, secretExist := os.LookupEnv("AUTH_TOKEN_SECRET")
+	ttl, ttlExist := os.LookupEnv("AUTH_TOKEN_TTL")
+	defer func() {
+		if secretExist {
+			os.Setenv("AUTH_TOKEN_SECRET", secret)
+		} else {
+			os.Unsetenv("AUTH_TOKEN_SECRET")
+		}
+
+		if ttlExist {
+			os.Setenv("AUTH_TOKEN_TTL", ttl)
+		} else {
+			os.Unsetenv("AUTH_TOKEN_TTL")
+		}
+	}()
+
+	os.Setenv("AUTH_TOKEN_SECRET", "test_secret")
+	os.Unsetenv("AUTH_TOKEN_TTL")
+	conf := GetAuthConfig()
+	assert.Equal(t, "test_secret", conf.TokenSecret)
+	assert.Equal(t, 24*time.Hour, conf.TokenTTL)
+
+	os.Setenv("AUTH_TOKEN_TTL", "30m")
+	assert.Equal(t, 30*time.Minute, GetAuthConfig().TokenTTL)
+
+	os.Setenv("AUTH_TOKEN_TTL", "invalid")
+	assert.Equal(t, 24*time.Hour, GetAuthConfig().TokenTTL)
+}
+
+func TestAuthConfig_Validate(t *testing.T) {
+	for _, tc := range []struct {
+		name        string
+		secret      string
+		expectedErr string
+	}{
+		{name: "random secret", secret: "6f1c0e9a4b7d2e8f3a5c9b1d7e4f2a6c"},
+		{name: "missing", secret: "", expectedErr: "no auth token secret specified"},
+		{name: "development value", secret: "wagerAppDevSecret", expectedErr: "auth token secret is public development value, set a random one"},
+		{name: "short", secret: "6f1c0e9a4b7d2e8f3a5c9b1d7e4f2a6", expectedErr: "auth token secret must be at least 32 bytes long"},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			err := AuthConfig{TokenSecret: tc.secret}.Validate()
+			if tc.expectedErr == "" {
+				assert.Nil(t, err)
+				return
+			}
+
+			assert.EqualError(t, err, tc.expectedErr)
+		})
+	}
+}
+
+func TestGetAppConfig_IdempotencyKeyTTL(t *testing.T) {
+	ttl, exist := os.LookupEnv("IDEMPOTENCY_KEY_TTL")
+	defer func() {
+		if exist {
+			os.Setenv("IDEMPOTENCY_KEY_TTL", ttl)
+		} else {
+			os.Unsetenv("IDEMPOTENCY_KEY_TTL")
+		}
+	}()
+
+	os.Unsetenv("IDEMPOTENCY_KEY_TTL")
+	assert.Equal(t, 24*time.Hour, GetAppConfig().IdempotencyKeyTTL)
+
+	os.Setenv("IDEMPOTENCY_KEY_TTL", "1h")
+	assert.Equal(t, time.Hour, GetAppConfig().IdempotencyKeyTTL)
+}
+
+func TestGetAppConfig_SellOutRules(t *testing.T) {
+	rules, exist := os.LookupEnv("SELL_OUT_RULES")
+	defer func() {
+		if exist {
+			os.Setenv("SELL_OUT_RULES", rules)
+		} else {
+			os.Unsetenv("SELL_OUT_RULES")
+		}
+	}()
+
+	os.Unsetenv("SELL_OUT_RULES")
+	assert.Equal(t, []string{"percentage"}, GetAppConfig().SellOutRules)
+
+	os.Setenv("SELL_OUT_RULES", "percentage, max_units:50")
+	assert.Equal(t, []string{"percentage", "max_units:50"}, GetAppConfig().SellOutRules)
+}
+
+func TestGetLogConfig(t *testing.T) {
+	level, levelExist := os.LookupEnv("LOG_LEVEL")
+	format, formatExist := os.LookupEnv("LOG_FORMAT")
+	defer func() {
+		if levelExist {
+			os.Setenv("LOG_LEVEL", level)
+		} else {
+			os.Unsetenv("LOG_LEVEL")
+		}
+
+		if formatExist {
+			os.Setenv("LOG_FORMAT", format)
+		} else {
+			os.Unsetenv("LOG_FORMAT")
+		}
+	}()
+
+	os.Unsetenv("LOG_LEVEL")
+	os.Unsetenv("LOG_FORMAT")
+	assert.Equal(t, LogConfig{Level: "info", Format: "json"}, GetLogConfig())
+
+	os.Setenv("LOG_LEVEL", "DEBUG")
+	os.Setenv("LOG_FORMAT", "text")
+	assert.Equal(t, LogConfig{Level: "debug", Format: "text"}, GetLogConfig())
+}
+
+func TestGetAppConfig_Health(t *testing.T) {
+	timeout, timeoutExist := os.LookupEnv("READINESS_TIMEOUT")
+	delay, delayExist := os.LookupEnv("SHUTDOWN_DRAIN_DELAY")
+	defer func() {
+		if timeoutExist {
+			os.Setenv("READINESS_TIMEOUT", timeout)
+		} else {
+			os.Unsetenv("READINESS_TIMEOUT")
+		}
+
+		if delayExist {
+			os.Setenv("SHUTDOWN_DRAIN_DELAY", delay)
+		} else {
+			os.Unsetenv("SHUTDOWN_DRAIN_DELAY")
+		}
+	}()
+
+	os.Unsetenv("READINESS_TIMEOUT")
+	os.Unsetenv("SHUTDOWN_DRAIN_DELAY")
+	conf := GetAppConfig()
+	assert.Equal(t, 2*time.Second, conf.ReadinessTimeout)
+	assert.Equal(t, 5*time.Second, conf.ShutdownDrainDelay)
+
+	os.Setenv("READINESS_TIMEOUT", "500ms")
+	os.Setenv("SHUTDOWN_DRAIN_DELAY", "1s")
+	conf = GetAppConfig()
+	assert.Equal(t, 500*time.Millisecond, conf.ReadinessTimeout)
+	assert.Equal(t, time.Second, conf.ShutdownDrainDelay)
+}
+
+func TestGetStreamConfig(t *testing.T) {
+	heartbeat, heartbeatExist := os.LookupEnv("STREAM_HEARTBEAT_INTERVAL")
+	buffer, bufferExist := os.LookupEnv("STREAM_REPLAY_BUFFER")
+	defer func() {
+		if heartbeatExist {
+			os.Setenv("STREAM_HEARTBEAT_INTERVAL", heartbeat)
+		} else {
+			os.Unsetenv("STREAM_HEARTBEAT_INTERVAL")
+		}
+
+		if bufferExist {
+			os.Setenv("STREAM_REPLAY_BUFFER", buffer)
+		} else {
+			os.Unsetenv("STREAM_REPLAY_BUFFER")
+		}
+	}()
+
+	os.Unsetenv("STREAM_HEARTBEAT_INTERVAL")
+	os.Unsetenv("STREAM_REPLAY_BUFFER")
+	assert.Equal(t, StreamConfig{HeartbeatInterval: 15 * time.Second, ReplayBuffer: 1000}, GetStreamConfig())
+
+	os.Setenv("STREAM_HEARTBEAT_INTERVAL", "1s")
+	os.Setenv("STREAM_REPLAY_BUFFER", "50")
+	assert.Equal(t, StreamConfig{HeartbeatInterval: time.Second, ReplayBuffer: 50}, GetStreamConfig())
+}
+
+func TestGetSocketConfig(t *testing.T) {
+	buffer, bufferExist := os.LookupEnv("WS_SEND_BUFFER")
+	ping, pingExist := os.LookupEnv("WS_PING_INTERVAL")
+	origins, originsExist := os.LookupEnv("WS_ALLOWED_ORIGINS")
+	defer func() {
+		if originsExist {
+			os.Setenv("WS_ALLOWED_ORIGINS", origins)
+		} else {
+			os.Unsetenv("WS_ALLOWED_ORIGINS")
+		}
+
+		if bufferExist {
+			os.Setenv("WS_SEND_BUFFER", buffer)
+		} else {
+			os.Unsetenv("WS_SEND_BUFFER")
+		}
+
+		if pingExist {
+			os.Setenv("WS_PING_INTERVAL", ping)
+		} else {
+			os.Unsetenv("WS_PING_INTERVAL")
+		}
+	}()
+
+	os.Unsetenv("WS_SEND_BUFFER")
+	os.Unsetenv("WS_PING_INTERVAL")
+	os.Unsetenv("WS_ALLOWED_ORIGINS")
+	assert.Equal(t, SocketConfig{SendBuffer: 32, PingInterval: 30 * time.Second}, GetSocketConfig())
+
+	os.Setenv("WS_SEND_BUFFER", "4")
+	os.Setenv("WS_PING_INTERVAL", "1s")
+	assert.Equal(t, SocketConfig{SendBuffer: 4, PingInterval: time.Second}, GetSocketConfig())
+
+	os.Setenv("WS_SEND_BUFFER", "-1")
+	assert.Equal(t, 32, GetSocketConfig().SendBuffer)
+
+	os.Setenv("WS_ALLOWED_ORIGINS", "https://app.example.com, http://localhost:3000")
+	assert.Equal(t, []string{"https://app.example.com", "http://localhost:3000"}, GetSocketConfig().AllowedOrigins)
+}
@@ -1,25 +1,32 @@
 package db
 
 import (
+	"context"
 	"database/sql"
 	"fmt"
-	"log"
 
 	_ "github.com/lib/pq"
+	_ "modernc.org/sqlite"
 
 	"github.com/vitthalaa/wager-app/internal/config"
+	"github.com/vitthalaa/wager-app/internal/logger"
 )
 
-func OpenConnection(conf *config.DataBaseConfig) (dbConn *sql.DB, err error) {
+// OpenConnection opens connection to database of configured driver
+func OpenConnection(conf *config.DataBaseConfig, log *logger.Logger) (dbConn *sql.DB, err error) {
+	if conf.DBDriver == config.DriverSQLite {
+		return openSQLite(conf, log)
+	}
+
 	connStr := fmt.Sprintf(
 		"port=%d host=%s user=%s password=%s dbname=%s sslmode=disable",
 		conf.DBPort, conf.DBHost, conf.DBUser, conf.DBPass, conf.DBName)
 
 	dbConn, err = sql.Open("postgres", connStr)
 	if err == nil {
-		log.Print("Connection opened to DB: " + conf.DBName)
+		log.Info(context.Background(), "connection opened to DB", "db_name", conf.DBName)
 	} else {
-		log.Printf("DB connection failed: %v", err)
+		log.Error(context.Background(), "DB connection failed", "error", err)
 		return
 	}
 
@@ -28,3 +35,25 @@ func OpenConnection(conf *config.DataBaseConfig) (dbConn *sql.DB, err error) {
 
 	return
 }
+
+// openSQLite opens sqlite database file.
+// Transactions take write lock on begin (_txlock=immediate), which serializes them
+// the same way as row locks do for wager purchase in postgres.
+func openSQLite(conf *config.DataBaseConfig, log *logger.Logger) (*sql.DB, error) {
+	dsn := fmt.Sprintf(
+		"file:%s?_txlock=immediate&_pragma=foreign_keys(1)&_pragma=busy_timeout(5000)&_pragma=journal_mode(WAL)",
+		conf.DBPath)
+
+	dbConn, err := sql.Open("sqlite", dsn)
+	if err != nil {
+		log.Error(context.Background(), "DB connection failed", "error", err)
+		return nil, err
+	}
+
+	// SQLite allows single writer only, one connection avoids busy errors
+	dbConn.SetMaxOpenConns(1)
+
+	log.Info(context.Background(), "connection opened to SQLite DB", "path", conf.DBPath)
+
+	return dbConn, nil
+}
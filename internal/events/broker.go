@@ -0,0 +1,187 @@
+// Package events is in-process pub/sub of wager changes streamed to clients.
+package events
+
+import (
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/vitthalaa/wager-app/dto"
+)
+
+// Event types
+const (
+	TypeWagerCreated = "wager_created"
+	TypePriceChanged = "price_changed"
+	TypeSoldOut      = "sold_out"
+	// TypeReset is replayed instead of events evicted from replay buffer, client has to reload wagers
+	TypeReset = "reset"
+)
+
+// subscriptionBuffer is number of published events subscriber can lag behind before it is dropped
+const subscriptionBuffer = 64
+
+var (
+	// ErrClosed is returned by Subscribe after broker is closed
+	ErrClosed = errors.New("events: broker closed")
+	// ErrDropped is error of subscription dropped as too slow to receive published events
+	ErrDropped = errors.New("events: subscriber too slow")
+)
+
+// Event is change of wager published to subscribers
+type Event struct {
+	// ID increases by one with every published event, clients resume after it
+	ID    uint64
+	Type  string
+	Wager dto.Wager
+}
+
+// NewBroker returns broker keeping last bufferSize events for replay.
+// Event ids start at current unix time in nanoseconds, so ids of restarted app are greater than ids seen before.
+func NewBroker(bufferSize int) *Broker {
+	if bufferSize < 1 {
+		bufferSize = 1
+	}
+
+	return &Broker{
+		lastID:      uint64(time.Now().UnixNano()),
+		buffer:      make([]Event, 0, bufferSize),
+		size:        bufferSize,
+		subscribers: map[*Subscription]struct{}{},
+	}
+}
+
+// Broker fans out published events to subscribers and keeps bounded buffer of latest events for replay
+type Broker struct {
+	mu     sync.Mutex
+	lastID uint64
+	// buffer is ring of latest events, start is index of the oldest one once buffer is full
+	buffer      []Event
+	start       int
+	size        int
+	subscribers map[*Subscription]struct{}
+	closed      bool
+}
+
+// Publish assigns next id to event of wager and delivers it to subscribers.
+// Subscribers too slow to receive it are dropped, they resume from replay buffer on reconnect.
+func (b *Broker) Publish(eventType string, wager dto.Wager) Event {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.lastID++
+	event := Event{ID: b.lastID, Type: eventType, Wager: wager}
+	if len(b.buffer) < b.size {
+		b.buffer = append(b.buffer, event)
+	} else {
+		b.buffer[b.start] = event
+		b.start = (b.start + 1) % b.size
+	}
+
+	for sub := range b.subscribers {
+		if !sub.matches(event) {
+			continue
+		}
+
+		select {
+		case sub.events <- event:
+		default:
+			sub.err = ErrDropped
+			b.remove(sub)
+		}
+	}
+
+	return event
+}
+
+// Subscribe returns subscription to events of wager, or of all wagers when wagerID is 0,
+// with buffered events after lastEventID to replay. lastEventID 0 replays nothing.
+// When some of the events were already evicted from buffer or lastEventID is unknown,
+// single reset event with id of latest event is replayed instead.
+func (b *Broker) Subscribe(lastEventID uint64, wagerID uint32) (*Subscription, []Event, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.closed {
+		return nil, nil, ErrClosed
+	}
+
+	sub := &Subscription{broker: b, wagerID: wagerID, events: make(chan Event, subscriptionBuffer)}
+	b.subscribers[sub] = struct{}{}
+
+	if lastEventID == 0 || lastEventID == b.lastID {
+		return sub, nil, nil
+	}
+
+	oldest := b.lastID - uint64(len(b.buffer)) + 1
+	if lastEventID > b.lastID || lastEventID+1 < oldest {
+		return sub, []Event{{ID: b.lastID, Type: TypeReset}}, nil
+	}
+
+	var replay []Event
+	for i := 0; i < len(b.buffer); i++ {
+		event := b.buffer[(b.start+i)%len(b.buffer)]
+		if event.ID > lastEventID && sub.matches(event) {
+			replay = append(replay, event)
+		}
+	}
+
+	return sub, replay, nil
+}
+
+// Close ends all subscriptions and rejects new ones, so that streams end on shutdown
+func (b *Broker) Close() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.closed = true
+	for sub := range b.subscribers {
+		sub.err = ErrClosed
+		b.remove(sub)
+	}
+}
+
+// remove closes channel of subscription, must be called holding broker lock
+func (b *Broker) remove(sub *Subscription) {
+	if _, ok := b.subscribers[sub]; !ok {
+		return
+	}
+
+	delete(b.subscribers, sub)
+	close(sub.events)
+}
+
+// Subscription receives published events until it is closed
+type Subscription struct {
+	broker  *Broker
+	wagerID uint32
+	events  chan Event
+	// err is reason of subscription ended by broker, guarded by broker lock
+	err error
+}
+
+// Events returns channel of published events, it is closed when subscription is dropped or broker is closed
+func (s *Subscription) Events() <-chan Event {
+	return s.events
+}
+
+// Err returns ErrDropped or ErrClosed when subscription was ended by broker, nil otherwise.
+// It is meant to be called once events channel is closed.
+func (s *Subscription) Err() error {
+	s.broker.mu.Lock()
+	defer s.broker.mu.Unlock()
+
+	return s.err
+}
+
+// Close unsubscribes from broker
+func (s *Subscription) Close() {
+	s.broker.mu.Lock()
+	defer s.broker.mu.Unlock()
+
+	s.broker.remove(s)
+}
+
+func (s *Subscription) matches(event Event) bool {
+	return s.wagerID == 0 || s.wagerID == event.Wager.ID
+}
@@ -0,0 +1,130 @@
+package events
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/vitthalaa/wager-app/dto"
+)
+
+func TestBroker_PublishToSubscribers(t *testing.T) {
+	broker := NewBroker(10)
+
+	all, _, err := broker.Subscribe(0, 0)
+	require.Nil(t, err)
+	one, _, err := broker.Subscribe(0, 2)
+	require.Nil(t, err)
+
+	first := broker.Publish(TypeWagerCreated, dto.Wager{ID: 1})
+	second := broker.Publish(TypePriceChanged, dto.Wager{ID: 2})
+	assert.Equal(t, first.ID+1, second.ID)
+
+	assert.Equal(t, first, <-all.Events())
+	assert.Equal(t, second, <-all.Events())
+	assert.Equal(t, second, <-one.Events())
+	assert.Empty(t, one.Events())
+
+	one.Close()
+	one.Close()
+	_, ok := <-one.Events()
+	assert.False(t, ok)
+	assert.Nil(t, one.Err())
+
+	broker.Publish(TypeSoldOut, dto.Wager{ID: 2})
+	assert.Len(t, all.Events(), 1)
+}
+
+func TestBroker_SubscribeReplay(t *testing.T) {
+	broker := NewBroker(3)
+
+	var published []Event
+	for id := uint32(1); id <= 5; id++ {
+		published = append(published, broker.Publish(TypeWagerCreated, dto.Wager{ID: id}))
+	}
+
+	reset := []Event{{ID: published[4].ID, Type: TypeReset}}
+	for _, tc := range []struct {
+		name        string
+		lastEventID uint64
+		wagerID     uint32
+
+		expectedReplay []Event
+	}{
+		{
+			name: "new subscriber",
+		},
+		{
+			name:        "up to date",
+			lastEventID: published[4].ID,
+		},
+		{
+			name:           "buffered events after last event",
+			lastEventID:    published[2].ID,
+			expectedReplay: published[3:],
+		},
+		{
+			name:           "last event right before buffer",
+			lastEventID:    published[1].ID,
+			expectedReplay: published[2:],
+		},
+		{
+			name:           "events of wager",
+			lastEventID:    published[1].ID,
+			wagerID:        4,
+			expectedReplay: published[3:4],
+		},
+		{
+			name:           "evicted events",
+			lastEventID:    published[0].ID,
+			expectedReplay: reset,
+		},
+		{
+			name:           "unknown future event",
+			lastEventID:    published[4].ID + 1,
+			expectedReplay: reset,
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			sub, replay, err := broker.Subscribe(tc.lastEventID, tc.wagerID)
+			require.Nil(t, err)
+			defer sub.Close()
+
+			assert.Equal(t, tc.expectedReplay, replay)
+		})
+	}
+}
+
+func TestBroker_DropsSlowSubscriber(t *testing.T) {
+	broker := NewBroker(1)
+	sub, _, err := broker.Subscribe(0, 0)
+	require.Nil(t, err)
+
+	for i := 0; i <= subscriptionBuffer; i++ {
+		broker.Publish(TypePriceChanged, dto.Wager{ID: 1})
+	}
+
+	received := 0
+	for range sub.Events() {
+		received++
+	}
+
+	assert.Equal(t, subscriptionBuffer, received)
+	assert.Equal(t, ErrDropped, sub.Err())
+}
+
+func TestBroker_Close(t *testing.T) {
+	broker := NewBroker(1)
+	sub, _, err := broker.Subscribe(0, 0)
+	require.Nil(t, err)
+
+	broker.Close()
+
+	_, ok := <-sub.Events()
+	assert.False(t, ok)
+	assert.Equal(t, ErrClosed, sub.Err())
+
+	_, _, err = broker.Subscribe(0, 0)
+	assert.Equal(t, ErrClosed, err)
+}
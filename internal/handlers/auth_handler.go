@@ -0,0 +1,90 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/vitthalaa/wager-app/app_errors"
+	"github.com/vitthalaa/wager-app/dto"
+	"github.com/vitthalaa/wager-app/internal/auth"
+	"github.com/vitthalaa/wager-app/internal/logger"
+	"github.com/vitthalaa/wager-app/internal/services"
+)
+
+// AuthHandler is handler for all /auth routes
+type AuthHandler struct {
+	userService services.IUserService
+	log         *logger.Logger
+}
+
+// NewAuthHandler ...
+func NewAuthHandler(userService services.IUserService, log *logger.Logger) *AuthHandler {
+	return &AuthHandler{
+		userService: userService,
+		log:         log,
+	}
+}
+
+// Handle is method to handle requests to routes
+func (h *AuthHandler) Handle(w http.ResponseWriter, req *http.Request) {
+	var err error
+	switch {
+	case req.Method == http.MethodPost && req.URL.Path == "/auth/register":
+		err = h.doRegister(w, req)
+	case req.Method == http.MethodPost && req.URL.Path == "/auth/login":
+		err = h.doLogin(w, req)
+	default:
+		h.log.Debug(req.Context(), "route not found", "method", req.Method, "path", req.URL.Path)
+		writeResponse(w, http.StatusNotFound, app_errors.ErrorResponse{Code: app_errors.ErrNotFound})
+	}
+
+	if err != nil {
+		h.log.Error(req.Context(), "handle request failed", "error", err)
+		writeResponse(w, http.StatusInternalServerError, app_errors.ErrorResponse{Code: app_errors.ErrInternalError})
+	}
+}
+
+// doRegister registers new user
+func (h *AuthHandler) doRegister(w http.ResponseWriter, req *http.Request) error {
+	var request dto.RegisterRequest
+	if errRes := decodeJSON(req, &request); errRes != nil {
+		writeResponse(w, errRes.Status, errRes)
+		return nil
+	}
+
+	user, err := h.userService.Register(req.Context(), &request)
+	if err != nil {
+		writeErrorResponse(w, err)
+		return nil
+	}
+
+	writeResponse(w, http.StatusCreated, user)
+	return nil
+}
+
+// doLogin issues bearer token for user credentials
+func (h *AuthHandler) doLogin(w http.ResponseWriter, req *http.Request) error {
+	var request dto.LoginRequest
+	if errRes := decodeJSON(req, &request); errRes != nil {
+		writeResponse(w, errRes.Status, errRes)
+		return nil
+	}
+
+	token, err := h.userService.Login(req.Context(), &request)
+	if err != nil {
+		writeErrorResponse(w, err)
+		return nil
+	}
+
+	writeResponse(w, http.StatusOK, token)
+	return nil
+}
+
+// requireUser returns authenticated user of request, writes unauthorized response for anonymous request
+func requireUser(w http.ResponseWriter, req *http.Request) (auth.User, bool) {
+	user, ok := auth.UserFromContext(req.Context())
+	if !ok {
+		auth.Unauthorized(w)
+	}
+
+	return user, ok
+}
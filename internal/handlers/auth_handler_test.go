@@ -0,0 +1,172 @@
+package handlers
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	"github.com/vitthalaa/wager-app/app_errors"
+	"github.com/vitthalaa/wager-app/dto"
+	"github.com/vitthalaa/wager-app/internal/auth"
+	"github.com/vitthalaa/wager-app/internal/logger"
+)
+
+var testUser = auth.User{ID: 7, Username: "alice"}
+
+// withUser returns request authenticated as testUser
+func withUser(req *http.Request) *http.Request {
+	return req.WithContext(auth.WithUser(req.Context(), testUser))
+}
+
+func TestAuthHandler_Handle_Register(t *testing.T) {
+	now := time.Now()
+	for _, tc := range []struct {
+		name string
+		body string
+
+		serviceResp  *dto.User
+		serviceError error
+
+		expectedCode int
+		expectedBody interface{}
+	}{
+		{
+			name:         "happy path",
+			body:         `{"username":"alice","password":"secret123"}`,
+			serviceResp:  &dto.User{ID: 7, Username: "alice", RegisteredAt: &now},
+			expectedCode: http.StatusCreated,
+			expectedBody: &dto.User{ID: 7, Username: "alice", RegisteredAt: &now},
+		},
+		{
+			name:         "username taken",
+			body:         `{"username":"alice","password":"secret123"}`,
+			serviceError: &app_errors.ErrorResponse{Status: http.StatusConflict, Code: app_errors.ErrUsernameTaken},
+			expectedCode: http.StatusConflict,
+			expectedBody: app_errors.ErrorResponse{Code: app_errors.ErrUsernameTaken},
+		},
+		{
+			name:         "invalid body",
+			body:         `{"username":`,
+			expectedCode: http.StatusBadRequest,
+			expectedBody: app_errors.ErrorResponse{Code: app_errors.ErrInvalidBody},
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			request, err := http.NewRequest("POST", "http://domain.co/auth/register", bytes.NewReader([]byte(tc.body)))
+			require.Nil(t, err)
+
+			mockUserService := new(MockUserService)
+			mockUserService.On("Register", mock.Anything, &dto.RegisterRequest{Username: "alice", Password: "secret123"}).
+				Return(tc.serviceResp, tc.serviceError)
+
+			resRecorder := httptest.NewRecorder()
+			handler := NewAuthHandler(mockUserService, logger.Discard())
+			handler.Handle(resRecorder, request)
+
+			expected, err := json.Marshal(tc.expectedBody)
+			require.Nil(t, err)
+
+			require.Equal(t, tc.expectedCode, resRecorder.Code)
+			assert.JSONEq(t, string(expected), resRecorder.Body.String())
+		})
+	}
+}
+
+func TestAuthHandler_Handle_Login(t *testing.T) {
+	expiresAt := time.Now().Add(time.Hour)
+	for _, tc := range []struct {
+		name string
+
+		serviceResp  *dto.Token
+		serviceError error
+
+		expectedCode int
+		expectedBody interface{}
+	}{
+		{
+			name:         "happy path",
+			serviceResp:  &dto.Token{AccessToken: "a.b.c", TokenType: "Bearer", ExpiresAt: expiresAt},
+			expectedCode: http.StatusOK,
+			expectedBody: &dto.Token{AccessToken: "a.b.c", TokenType: "Bearer", ExpiresAt: expiresAt},
+		},
+		{
+			name:         "invalid credentials",
+			serviceError: &app_errors.ErrorResponse{Status: http.StatusUnauthorized, Code: app_errors.ErrInvalidCredentials},
+			expectedCode: http.StatusUnauthorized,
+			expectedBody: app_errors.ErrorResponse{Code: app_errors.ErrInvalidCredentials},
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			body := []byte(`{"username":"alice","password":"secret123"}`)
+			request, err := http.NewRequest("POST", "http://domain.co/auth/login", bytes.NewReader(body))
+			require.Nil(t, err)
+
+			mockUserService := new(MockUserService)
+			mockUserService.On("Login", mock.Anything, &dto.LoginRequest{Username: "alice", Password: "secret123"}).
+				Return(tc.serviceResp, tc.serviceError)
+
+			resRecorder := httptest.NewRecorder()
+			handler := NewAuthHandler(mockUserService, logger.Discard())
+			handler.Handle(resRecorder, request)
+
+			expected, err := json.Marshal(tc.expectedBody)
+			require.Nil(t, err)
+
+			require.Equal(t, tc.expectedCode, resRecorder.Code)
+			assert.JSONEq(t, string(expected), resRecorder.Body.String())
+		})
+	}
+}
+
+func TestHandlers_Unauthorized(t *testing.T) {
+	for _, tc := range []struct {
+		name    string
+		url     string
+		handler http.HandlerFunc
+	}{
+		{
+			name:    "place wager",
+			url:     "http://domain.co/wagers",
+			handler: NewWagersHandler(new(MockWagerService), new(MockSettlementService), new(MockAuctionService), nil, logger.Discard(), nil).Handle,
+		},
+		{
+			name:    "settle wager",
+			url:     "http://domain.co/wagers/111/settle",
+			handler: NewWagersHandler(new(MockWagerService), new(MockSettlementService), new(MockAuctionService), nil, logger.Discard(), nil).Handle,
+		},
+		{
+			name:    "buy wager",
+			url:     "http://domain.co/buy/111",
+			handler: NewPurchasesHandler(new(MockPurchaseService), nil, logger.Discard()).Handle,
+		},
+		{
+			name:    "deposit",
+			url:     "http://domain.co/wallet/deposit",
+			handler: NewWalletHandler(new(MockWalletService), logger.Discard()).Handle,
+		},
+		{
+			name:    "withdraw",
+			url:     "http://domain.co/wallet/withdraw",
+			handler: NewWalletHandler(new(MockWalletService), logger.Discard()).Handle,
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			request, err := http.NewRequest("POST", tc.url, bytes.NewReader([]byte(`{}`)))
+			require.Nil(t, err)
+
+			resRecorder := httptest.NewRecorder()
+			tc.handler(resRecorder, request)
+
+			require.Equal(t, http.StatusUnauthorized, resRecorder.Code)
+			assert.Equal(t, "Bearer", resRecorder.Header().Get("WWW-Authenticate"))
+			assert.JSONEq(t, `{"error":"UNAUTHORIZED"}`, resRecorder.Body.String())
+		})
+	}
+}
@@ -0,0 +1,79 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"reflect"
+	"strconv"
+	"strings"
+
+	"github.com/vitthalaa/wager-app/app_errors"
+)
+
+// unknownFieldPrefix is prefix of encoding/json error for field not present in destination struct
+const unknownFieldPrefix = "json: unknown field "
+
+// decodeJSON decodes request body into dst strictly: unknown fields and data after JSON value are rejected.
+// It returns invalid body error response with detail of offending field when known.
+func decodeJSON(req *http.Request, dst interface{}) *app_errors.ErrorResponse {
+	return decodeStrict(req.Body, dst)
+}
+
+// decodeStrict decodes single JSON value of r into dst like decodeJSON
+func decodeStrict(r io.Reader, dst interface{}) *app_errors.ErrorResponse {
+	decoder := json.NewDecoder(r)
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(dst); err != nil {
+		return invalidBody(err)
+	}
+
+	if _, err := decoder.Token(); err != io.EOF {
+		return &app_errors.ErrorResponse{
+			Status: http.StatusBadRequest,
+			Code:   app_errors.ErrInvalidBody,
+			Details: []app_errors.FieldError{
+				{Rule: "trailing", Message: "must contain single JSON value"},
+			},
+		}
+	}
+
+	return nil
+}
+
+// invalidBody returns invalid body error response of decode err
+func invalidBody(err error) *app_errors.ErrorResponse {
+	errRes := &app_errors.ErrorResponse{Status: http.StatusBadRequest, Code: app_errors.ErrInvalidBody}
+
+	var typeErr *json.UnmarshalTypeError
+	switch {
+	case errors.As(err, &typeErr) && typeErr.Field != "":
+		errRes.Details = []app_errors.FieldError{
+			{Field: typeErr.Field, Rule: "type", Message: "must be " + jsonType(typeErr.Type)},
+		}
+	case strings.HasPrefix(err.Error(), unknownFieldPrefix):
+		field, unquoteErr := strconv.Unquote(strings.TrimPrefix(err.Error(), unknownFieldPrefix))
+		if unquoteErr == nil {
+			errRes.Details = []app_errors.FieldError{{Field: field, Rule: "unknown", Message: "is not allowed"}}
+		}
+	}
+
+	return errRes
+}
+
+// jsonType returns JSON type of values of Go type t
+func jsonType(t reflect.Type) string {
+	switch t.Kind() {
+	case reflect.Bool:
+		return "boolean"
+	case reflect.String:
+		return "string"
+	case reflect.Slice, reflect.Array:
+		return "array"
+	case reflect.Map, reflect.Struct:
+		return "object"
+	}
+
+	return "number"
+}
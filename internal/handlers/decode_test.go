@@ -0,0 +1,95 @@
+package handlers
+
+import (
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/vitthalaa/wager-app/app_errors"
+	"github.com/vitthalaa/wager-app/dto"
+	"github.com/vitthalaa/wager-app/money"
+)
+
+func Test_decodeJSON(t *testing.T) {
+	for _, tc := range []struct {
+		name string
+		body string
+
+		expectedReq   dto.BuyWagerRequest
+		expectedError *app_errors.ErrorResponse
+	}{
+		{
+			name:        "valid",
+			body:        `{"buying_price": "10.50"}`,
+			expectedReq: dto.BuyWagerRequest{BuyingPrice: money.MustParse("10.50")},
+		},
+		{
+			name:        "trailing whitespace",
+			body:        "{\"buying_price\": 10}\n",
+			expectedReq: dto.BuyWagerRequest{BuyingPrice: money.MustParse("10")},
+		},
+		{
+			name: "unknown field",
+			body: `{"buying_price": "10.50", "buyingPrice": "11"}`,
+			expectedError: &app_errors.ErrorResponse{
+				Status: http.StatusBadRequest, Code: app_errors.ErrInvalidBody,
+				Details: []app_errors.FieldError{{Field: "buyingPrice", Rule: "unknown", Message: "is not allowed"}},
+			},
+		},
+		{
+			name: "field of ignored struct field",
+			body: `{"buying_price": "10.50", "WagerID": 5}`,
+			expectedError: &app_errors.ErrorResponse{
+				Status: http.StatusBadRequest, Code: app_errors.ErrInvalidBody,
+				Details: []app_errors.FieldError{{Field: "WagerID", Rule: "unknown", Message: "is not allowed"}},
+			},
+		},
+		{
+			name: "trailing data",
+			body: `{"buying_price": "10.50"} {"buying_price": "11"}`,
+			expectedError: &app_errors.ErrorResponse{
+				Status: http.StatusBadRequest, Code: app_errors.ErrInvalidBody,
+				Details: []app_errors.FieldError{{Rule: "trailing", Message: "must contain single JSON value"}},
+			},
+		},
+		{
+			name:          "invalid money",
+			body:          `{"buying_price": "ten"}`,
+			expectedError: &app_errors.ErrorResponse{Status: http.StatusBadRequest, Code: app_errors.ErrInvalidBody},
+		},
+		{
+			name:          "malformed",
+			body:          `{"buying_price": `,
+			expectedError: &app_errors.ErrorResponse{Status: http.StatusBadRequest, Code: app_errors.ErrInvalidBody},
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			request, err := http.NewRequest("POST", "/buy/1", strings.NewReader(tc.body))
+			require.Nil(t, err)
+
+			var req dto.BuyWagerRequest
+			errRes := decodeJSON(request, &req)
+
+			assert.Equal(t, tc.expectedError, errRes)
+			if tc.expectedError == nil {
+				assert.Equal(t, tc.expectedReq, req)
+			}
+		})
+	}
+}
+
+func Test_decodeJSON_TypeError(t *testing.T) {
+	request, err := http.NewRequest("POST", "/wagers", strings.NewReader(`{"odds": "two"}`))
+	require.Nil(t, err)
+
+	var req dto.PlaceWagerRequest
+	errRes := decodeJSON(request, &req)
+
+	assert.Equal(t, &app_errors.ErrorResponse{
+		Status: http.StatusBadRequest, Code: app_errors.ErrInvalidBody,
+		Details: []app_errors.FieldError{{Field: "odds", Rule: "type", Message: "must be number"}},
+	}, errRes)
+}
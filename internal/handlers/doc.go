@@ -3,3 +3,10 @@ package handlers
 // Generate dependencies mocks for handlers
 //go:generate mockery --name=IWagerService --structname=MockWagerService --dir ../services --filename generated_mock_wager_service_test.go --testonly --output . --outpkg handlers
 //go:generate mockery --name=IPurchaseService --structname=MockPurchaseService --dir ../services --filename generated_mock_purchase_service_test.go --testonly --output . --outpkg handlers
+//go:generate mockery --name=ISettlementService --structname=MockSettlementService --dir ../services --filename generated_mock_settlement_service_test.go --testonly --output . --outpkg handlers
+//go:generate mockery --name=IUserService --structname=MockUserService --dir ../services --filename generated_mock_user_service_test.go --testonly --output . --outpkg handlers
+//go:generate mockery --name=IWalletService --structname=MockWalletService --dir ../services --filename generated_mock_wallet_service_test.go --testonly --output . --outpkg handlers
+//go:generate mockery --name=IIdempotencyService --structname=MockIdempotencyService --dir ../services --filename generated_mock_idempotency_service_test.go --testonly --output . --outpkg handlers
+//go:generate mockery --name=IHealthService --structname=MockHealthService --dir ../services --filename generated_mock_health_service_test.go --testonly --output . --outpkg handlers
+//go:generate mockery --name=IOrderService --structname=MockOrderService --dir ../services --filename generated_mock_order_service_test.go --testonly --output . --outpkg handlers
+//go:generate mockery --name=IAuctionService --structname=MockAuctionService --dir ../services --filename generated_mock_auction_service_test.go --testonly --output . --outpkg handlers
@@ -0,0 +1,37 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/vitthalaa/wager-app/app_errors"
+	"github.com/vitthalaa/wager-app/internal/logger"
+	"github.com/vitthalaa/wager-app/internal/openapi"
+)
+
+// DocsHandler is handler for /openapi.json specification of API and /docs viewer of it
+type DocsHandler struct {
+	spec *openapi.Document
+	log  *logger.Logger
+}
+
+// NewDocsHandler ...
+func NewDocsHandler(spec *openapi.Document, log *logger.Logger) *DocsHandler {
+	return &DocsHandler{
+		spec: spec,
+		log:  log,
+	}
+}
+
+// Handle is method to handle requests to routes
+func (h *DocsHandler) Handle(w http.ResponseWriter, req *http.Request) {
+	switch {
+	case req.Method == http.MethodGet && req.URL.Path == "/openapi.json":
+		writeResponse(w, http.StatusOK, h.spec)
+	case req.Method == http.MethodGet && req.URL.Path == "/docs":
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		_, _ = w.Write(openapi.ViewerHTML)
+	default:
+		h.log.Debug(req.Context(), "route not found", "method", req.Method, "path", req.URL.Path)
+		writeResponse(w, http.StatusNotFound, app_errors.ErrorResponse{Code: app_errors.ErrNotFound})
+	}
+}
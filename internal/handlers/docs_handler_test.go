@@ -0,0 +1,63 @@
+package handlers
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/vitthalaa/wager-app/internal/logger"
+	"github.com/vitthalaa/wager-app/internal/openapi"
+)
+
+func TestDocsHandler_Handle(t *testing.T) {
+	for _, tc := range []struct {
+		name   string
+		method string
+		path   string
+
+		expectedCode        int
+		expectedContentType string
+		expectedBody        string
+	}{
+		{
+			name:                "spec",
+			method:              "GET",
+			path:                "/openapi.json",
+			expectedCode:        http.StatusOK,
+			expectedContentType: "application/json",
+			expectedBody:        `"openapi":"3.0.3"`,
+		},
+		{
+			name:                "viewer",
+			method:              "GET",
+			path:                "/docs",
+			expectedCode:        http.StatusOK,
+			expectedContentType: "text/html; charset=utf-8",
+			expectedBody:        "fetch('openapi.json')",
+		},
+		{
+			name:                "post to spec",
+			method:              "POST",
+			path:                "/openapi.json",
+			expectedCode:        http.StatusNotFound,
+			expectedContentType: "application/json",
+			expectedBody:        `{"error":"NOT_FOUND"}`,
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			request, err := http.NewRequest(tc.method, tc.path, nil)
+			require.Nil(t, err)
+
+			resRecorder := httptest.NewRecorder()
+			handler := NewDocsHandler(openapi.Spec(), logger.Discard())
+			handler.Handle(resRecorder, request)
+
+			require.Equal(t, tc.expectedCode, resRecorder.Code)
+			assert.Equal(t, tc.expectedContentType, resRecorder.Header().Get("Content-Type"))
+			assert.Contains(t, resRecorder.Body.String(), tc.expectedBody)
+		})
+	}
+}
@@ -0,0 +1,95 @@
+// Code generated by mockery v2.12.2. DO NOT EDIT.
+
+package handlers
+
+import (
+	context "context"
+
+	mock "github.com/stretchr/testify/mock"
+
+	dto "github.com/vitthalaa/wager-app/dto"
+
+	testing "testing"
+)
+
+// MockAuctionService is an autogenerated mock type for the IAuctionService type
+type MockAuctionService struct {
+	mock.Mock
+}
+
+// CloseDueAuctions provides a mock function with given fields: ctx
+func (_m *MockAuctionService) CloseDueAuctions(ctx context.Context) (int, error) {
+	ret := _m.Called(ctx)
+
+	var r0 int
+	if rf, ok := ret.Get(0).(func(context.Context) int); ok {
+		r0 = rf(ctx)
+	} else {
+		r0 = ret.Get(0).(int)
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context) error); ok {
+		r1 = rf(ctx)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// ListBids provides a mock function with given fields: ctx, req
+func (_m *MockAuctionService) ListBids(ctx context.Context, req *dto.AuctionBidsRequest) ([]dto.AuctionBid, error) {
+	ret := _m.Called(ctx, req)
+
+	var r0 []dto.AuctionBid
+	if rf, ok := ret.Get(0).(func(context.Context, *dto.AuctionBidsRequest) []dto.AuctionBid); ok {
+		r0 = rf(ctx, req)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]dto.AuctionBid)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, *dto.AuctionBidsRequest) error); ok {
+		r1 = rf(ctx, req)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// PlaceBid provides a mock function with given fields: ctx, req
+func (_m *MockAuctionService) PlaceBid(ctx context.Context, req *dto.PlaceBidRequest) (*dto.AuctionBid, error) {
+	ret := _m.Called(ctx, req)
+
+	var r0 *dto.AuctionBid
+	if rf, ok := ret.Get(0).(func(context.Context, *dto.PlaceBidRequest) *dto.AuctionBid); ok {
+		r0 = rf(ctx, req)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*dto.AuctionBid)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, *dto.PlaceBidRequest) error); ok {
+		r1 = rf(ctx, req)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// NewMockAuctionService creates a new instance of MockAuctionService. It also registers the testing.TB interface on the mock and a cleanup function to assert the mocks expectations.
+func NewMockAuctionService(t testing.TB) *MockAuctionService {
+	mock := &MockAuctionService{}
+	mock.Mock.Test(t)
+
+	t.Cleanup(func() { mock.AssertExpectations(t) })
+
+	return mock
+}
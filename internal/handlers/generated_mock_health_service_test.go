@@ -0,0 +1,65 @@
+// Code generated by mockery v2.12.2. DO NOT EDIT.
+
+package handlers
+
+import (
+	context "context"
+
+	mock "github.com/stretchr/testify/mock"
+
+	dto "github.com/vitthalaa/wager-app/dto"
+
+	testing "testing"
+)
+
+// MockHealthService is an autogenerated mock type for the IHealthService type
+type MockHealthService struct {
+	mock.Mock
+}
+
+// Live provides a mock function with given fields: ctx
+func (_m *MockHealthService) Live(ctx context.Context) *dto.Health {
+	ret := _m.Called(ctx)
+
+	var r0 *dto.Health
+	if rf, ok := ret.Get(0).(func(context.Context) *dto.Health); ok {
+		r0 = rf(ctx)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*dto.Health)
+		}
+	}
+
+	return r0
+}
+
+// Ready provides a mock function with given fields: ctx
+func (_m *MockHealthService) Ready(ctx context.Context) *dto.Health {
+	ret := _m.Called(ctx)
+
+	var r0 *dto.Health
+	if rf, ok := ret.Get(0).(func(context.Context) *dto.Health); ok {
+		r0 = rf(ctx)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*dto.Health)
+		}
+	}
+
+	return r0
+}
+
+// ShutDown provides a mock function with given fields:
+func (_m *MockHealthService) ShutDown() {
+	_m.Called()
+}
+
+// NewMockHealthService creates a new instance of MockHealthService. It also registers the testing.TB interface on the mock and a cleanup function to assert the mocks expectations.
+func NewMockHealthService(t testing.TB) *MockHealthService {
+	mock := &MockHealthService{}
+	mock.Mock.Test(t)
+
+	t.Cleanup(func() { mock.AssertExpectations(t) })
+
+	return mock
+}
@@ -0,0 +1,100 @@
+// Code generated by mockery v2.12.2. DO NOT EDIT.
+
+package handlers
+
+import (
+	context "context"
+
+	mock "github.com/stretchr/testify/mock"
+
+	dto "github.com/vitthalaa/wager-app/dto"
+
+	testing "testing"
+)
+
+// MockIdempotencyService is an autogenerated mock type for the IIdempotencyService type
+type MockIdempotencyService struct {
+	mock.Mock
+}
+
+// Begin provides a mock function with given fields: ctx, req
+func (_m *MockIdempotencyService) Begin(ctx context.Context, req *dto.IdempotentRequest) (*dto.IdempotentResponse, error) {
+	ret := _m.Called(ctx, req)
+
+	var r0 *dto.IdempotentResponse
+	if rf, ok := ret.Get(0).(func(context.Context, *dto.IdempotentRequest) *dto.IdempotentResponse); ok {
+		r0 = rf(ctx, req)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*dto.IdempotentResponse)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, *dto.IdempotentRequest) error); ok {
+		r1 = rf(ctx, req)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// Complete provides a mock function with given fields: ctx, req, res
+func (_m *MockIdempotencyService) Complete(ctx context.Context, req *dto.IdempotentRequest, res *dto.IdempotentResponse) error {
+	ret := _m.Called(ctx, req, res)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, *dto.IdempotentRequest, *dto.IdempotentResponse) error); ok {
+		r0 = rf(ctx, req, res)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// DeleteExpired provides a mock function with given fields: ctx
+func (_m *MockIdempotencyService) DeleteExpired(ctx context.Context) (int64, error) {
+	ret := _m.Called(ctx)
+
+	var r0 int64
+	if rf, ok := ret.Get(0).(func(context.Context) int64); ok {
+		r0 = rf(ctx)
+	} else {
+		r0 = ret.Get(0).(int64)
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context) error); ok {
+		r1 = rf(ctx)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// Release provides a mock function with given fields: ctx, req
+func (_m *MockIdempotencyService) Release(ctx context.Context, req *dto.IdempotentRequest) error {
+	ret := _m.Called(ctx, req)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, *dto.IdempotentRequest) error); ok {
+		r0 = rf(ctx, req)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// NewMockIdempotencyService creates a new instance of MockIdempotencyService. It also registers the testing.TB interface on the mock and a cleanup function to assert the mocks expectations.
+func NewMockIdempotencyService(t testing.TB) *MockIdempotencyService {
+	mock := &MockIdempotencyService{}
+	mock.Mock.Test(t)
+
+	t.Cleanup(func() { mock.AssertExpectations(t) })
+
+	return mock
+}
@@ -0,0 +1,120 @@
+// Code generated by mockery v2.12.2. DO NOT EDIT.
+
+package handlers
+
+import (
+	context "context"
+
+	mock "github.com/stretchr/testify/mock"
+
+	dto "github.com/vitthalaa/wager-app/dto"
+
+	testing "testing"
+)
+
+// MockOrderService is an autogenerated mock type for the IOrderService type
+type MockOrderService struct {
+	mock.Mock
+}
+
+// CancelOrder provides a mock function with given fields: ctx, req
+func (_m *MockOrderService) CancelOrder(ctx context.Context, req *dto.OrderRequest) (*dto.Order, error) {
+	ret := _m.Called(ctx, req)
+
+	var r0 *dto.Order
+	if rf, ok := ret.Get(0).(func(context.Context, *dto.OrderRequest) *dto.Order); ok {
+		r0 = rf(ctx, req)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*dto.Order)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, *dto.OrderRequest) error); ok {
+		r1 = rf(ctx, req)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetOrder provides a mock function with given fields: ctx, req
+func (_m *MockOrderService) GetOrder(ctx context.Context, req *dto.OrderRequest) (*dto.Order, error) {
+	ret := _m.Called(ctx, req)
+
+	var r0 *dto.Order
+	if rf, ok := ret.Get(0).(func(context.Context, *dto.OrderRequest) *dto.Order); ok {
+		r0 = rf(ctx, req)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*dto.Order)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, *dto.OrderRequest) error); ok {
+		r1 = rf(ctx, req)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetOrderBook provides a mock function with given fields: ctx, req
+func (_m *MockOrderService) GetOrderBook(ctx context.Context, req *dto.GetOrderBookRequest) (*dto.OrderBook, error) {
+	ret := _m.Called(ctx, req)
+
+	var r0 *dto.OrderBook
+	if rf, ok := ret.Get(0).(func(context.Context, *dto.GetOrderBookRequest) *dto.OrderBook); ok {
+		r0 = rf(ctx, req)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*dto.OrderBook)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, *dto.GetOrderBookRequest) error); ok {
+		r1 = rf(ctx, req)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// PlaceOrder provides a mock function with given fields: ctx, req
+func (_m *MockOrderService) PlaceOrder(ctx context.Context, req *dto.PlaceOrderRequest) (*dto.Order, error) {
+	ret := _m.Called(ctx, req)
+
+	var r0 *dto.Order
+	if rf, ok := ret.Get(0).(func(context.Context, *dto.PlaceOrderRequest) *dto.Order); ok {
+		r0 = rf(ctx, req)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*dto.Order)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, *dto.PlaceOrderRequest) error); ok {
+		r1 = rf(ctx, req)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// NewMockOrderService creates a new instance of MockOrderService. It also registers the testing.TB interface on the mock and a cleanup function to assert the mocks expectations.
+func NewMockOrderService(t testing.TB) *MockOrderService {
+	mock := &MockOrderService{}
+	mock.Mock.Test(t)
+
+	t.Cleanup(func() { mock.AssertExpectations(t) })
+
+	return mock
+}
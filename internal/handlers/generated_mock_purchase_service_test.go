@@ -17,6 +17,52 @@ type MockPurchaseService struct {
 	mock.Mock
 }
 
+// GetPurchase provides a mock function with given fields: ctx, id
+func (_m *MockPurchaseService) GetPurchase(ctx context.Context, id uint32) (*dto.WagerPurchase, error) {
+	ret := _m.Called(ctx, id)
+
+	var r0 *dto.WagerPurchase
+	if rf, ok := ret.Get(0).(func(context.Context, uint32) *dto.WagerPurchase); ok {
+		r0 = rf(ctx, id)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*dto.WagerPurchase)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, uint32) error); ok {
+		r1 = rf(ctx, id)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// ListPurchases provides a mock function with given fields: ctx, req
+func (_m *MockPurchaseService) ListPurchases(ctx context.Context, req *dto.ListPurchaseRequest) (*dto.WagerPurchaseList, error) {
+	ret := _m.Called(ctx, req)
+
+	var r0 *dto.WagerPurchaseList
+	if rf, ok := ret.Get(0).(func(context.Context, *dto.ListPurchaseRequest) *dto.WagerPurchaseList); ok {
+		r0 = rf(ctx, req)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*dto.WagerPurchaseList)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, *dto.ListPurchaseRequest) error); ok {
+		r1 = rf(ctx, req)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
 // PurchaseWager provides a mock function with given fields: ctx, req
 func (_m *MockPurchaseService) PurchaseWager(ctx context.Context, req *dto.BuyWagerRequest) (*dto.WagerPurchase, error) {
 	ret := _m.Called(ctx, req)
@@ -0,0 +1,74 @@
+// Code generated by mockery v2.12.2. DO NOT EDIT.
+
+package handlers
+
+import (
+	context "context"
+
+	mock "github.com/stretchr/testify/mock"
+
+	dto "github.com/vitthalaa/wager-app/dto"
+
+	testing "testing"
+)
+
+// MockSettlementService is an autogenerated mock type for the ISettlementService type
+type MockSettlementService struct {
+	mock.Mock
+}
+
+// GetSettlement provides a mock function with given fields: ctx, wagerID
+func (_m *MockSettlementService) GetSettlement(ctx context.Context, wagerID uint32) (*dto.WagerSettlement, error) {
+	ret := _m.Called(ctx, wagerID)
+
+	var r0 *dto.WagerSettlement
+	if rf, ok := ret.Get(0).(func(context.Context, uint32) *dto.WagerSettlement); ok {
+		r0 = rf(ctx, wagerID)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*dto.WagerSettlement)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, uint32) error); ok {
+		r1 = rf(ctx, wagerID)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// SettleWager provides a mock function with given fields: ctx, req
+func (_m *MockSettlementService) SettleWager(ctx context.Context, req *dto.SettleWagerRequest) (*dto.WagerSettlement, error) {
+	ret := _m.Called(ctx, req)
+
+	var r0 *dto.WagerSettlement
+	if rf, ok := ret.Get(0).(func(context.Context, *dto.SettleWagerRequest) *dto.WagerSettlement); ok {
+		r0 = rf(ctx, req)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*dto.WagerSettlement)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, *dto.SettleWagerRequest) error); ok {
+		r1 = rf(ctx, req)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// NewMockSettlementService creates a new instance of MockSettlementService. It also registers the testing.TB interface on the mock and a cleanup function to assert the mocks expectations.
+func NewMockSettlementService(t testing.TB) *MockSettlementService {
+	mock := &MockSettlementService{}
+	mock.Mock.Test(t)
+
+	t.Cleanup(func() { mock.AssertExpectations(t) })
+
+	return mock
+}
@@ -0,0 +1,74 @@
+// Code generated by mockery v2.12.2. DO NOT EDIT.
+
+package handlers
+
+import (
+	context "context"
+
+	mock "github.com/stretchr/testify/mock"
+
+	dto "github.com/vitthalaa/wager-app/dto"
+
+	testing "testing"
+)
+
+// MockUserService is an autogenerated mock type for the IUserService type
+type MockUserService struct {
+	mock.Mock
+}
+
+// Login provides a mock function with given fields: ctx, req
+func (_m *MockUserService) Login(ctx context.Context, req *dto.LoginRequest) (*dto.Token, error) {
+	ret := _m.Called(ctx, req)
+
+	var r0 *dto.Token
+	if rf, ok := ret.Get(0).(func(context.Context, *dto.LoginRequest) *dto.Token); ok {
+		r0 = rf(ctx, req)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*dto.Token)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, *dto.LoginRequest) error); ok {
+		r1 = rf(ctx, req)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// Register provides a mock function with given fields: ctx, req
+func (_m *MockUserService) Register(ctx context.Context, req *dto.RegisterRequest) (*dto.User, error) {
+	ret := _m.Called(ctx, req)
+
+	var r0 *dto.User
+	if rf, ok := ret.Get(0).(func(context.Context, *dto.RegisterRequest) *dto.User); ok {
+		r0 = rf(ctx, req)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*dto.User)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, *dto.RegisterRequest) error); ok {
+		r1 = rf(ctx, req)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// NewMockUserService creates a new instance of MockUserService. It also registers the testing.TB interface on the mock and a cleanup function to assert the mocks expectations.
+func NewMockUserService(t testing.TB) *MockUserService {
+	mock := &MockUserService{}
+	mock.Mock.Test(t)
+
+	t.Cleanup(func() { mock.AssertExpectations(t) })
+
+	return mock
+}
@@ -17,16 +17,39 @@ type MockWagerService struct {
 	mock.Mock
 }
 
+// GetWager provides a mock function with given fields: ctx, req
+func (_m *MockWagerService) GetWager(ctx context.Context, req *dto.GetWagerRequest) (*dto.WagerDetails, error) {
+	ret := _m.Called(ctx, req)
+
+	var r0 *dto.WagerDetails
+	if rf, ok := ret.Get(0).(func(context.Context, *dto.GetWagerRequest) *dto.WagerDetails); ok {
+		r0 = rf(ctx, req)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*dto.WagerDetails)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, *dto.GetWagerRequest) error); ok {
+		r1 = rf(ctx, req)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
 // ListWager provides a mock function with given fields: ctx, req
-func (_m *MockWagerService) ListWager(ctx context.Context, req *dto.ListWagerRequest) ([]dto.Wager, error) {
+func (_m *MockWagerService) ListWager(ctx context.Context, req *dto.ListWagerRequest) (*dto.WagerList, error) {
 	ret := _m.Called(ctx, req)
 
-	var r0 []dto.Wager
-	if rf, ok := ret.Get(0).(func(context.Context, *dto.ListWagerRequest) []dto.Wager); ok {
+	var r0 *dto.WagerList
+	if rf, ok := ret.Get(0).(func(context.Context, *dto.ListWagerRequest) *dto.WagerList); ok {
 		r0 = rf(ctx, req)
 	} else {
 		if ret.Get(0) != nil {
-			r0 = ret.Get(0).([]dto.Wager)
+			r0 = ret.Get(0).(*dto.WagerList)
 		}
 	}
 
@@ -0,0 +1,134 @@
+// Code generated by mockery v2.12.2. DO NOT EDIT.
+
+package handlers
+
+import (
+	context "context"
+
+	mock "github.com/stretchr/testify/mock"
+
+	dto "github.com/vitthalaa/wager-app/dto"
+
+	testing "testing"
+)
+
+// MockWalletService is an autogenerated mock type for the IWalletService type
+type MockWalletService struct {
+	mock.Mock
+}
+
+// CheckLedger provides a mock function with given fields: ctx
+func (_m *MockWalletService) CheckLedger(ctx context.Context) error {
+	ret := _m.Called(ctx)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context) error); ok {
+		r0 = rf(ctx)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// Deposit provides a mock function with given fields: ctx, req
+func (_m *MockWalletService) Deposit(ctx context.Context, req *dto.DepositRequest) (*dto.Wallet, error) {
+	ret := _m.Called(ctx, req)
+
+	var r0 *dto.Wallet
+	if rf, ok := ret.Get(0).(func(context.Context, *dto.DepositRequest) *dto.Wallet); ok {
+		r0 = rf(ctx, req)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*dto.Wallet)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, *dto.DepositRequest) error); ok {
+		r1 = rf(ctx, req)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetWallet provides a mock function with given fields: ctx, userID
+func (_m *MockWalletService) GetWallet(ctx context.Context, userID uint32) (*dto.Wallet, error) {
+	ret := _m.Called(ctx, userID)
+
+	var r0 *dto.Wallet
+	if rf, ok := ret.Get(0).(func(context.Context, uint32) *dto.Wallet); ok {
+		r0 = rf(ctx, userID)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*dto.Wallet)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, uint32) error); ok {
+		r1 = rf(ctx, userID)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// ListLedgerEntries provides a mock function with given fields: ctx, req
+func (_m *MockWalletService) ListLedgerEntries(ctx context.Context, req *dto.ListLedgerEntriesRequest) (*dto.LedgerEntryList, error) {
+	ret := _m.Called(ctx, req)
+
+	var r0 *dto.LedgerEntryList
+	if rf, ok := ret.Get(0).(func(context.Context, *dto.ListLedgerEntriesRequest) *dto.LedgerEntryList); ok {
+		r0 = rf(ctx, req)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*dto.LedgerEntryList)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, *dto.ListLedgerEntriesRequest) error); ok {
+		r1 = rf(ctx, req)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// Withdraw provides a mock function with given fields: ctx, req
+func (_m *MockWalletService) Withdraw(ctx context.Context, req *dto.WithdrawRequest) (*dto.Wallet, error) {
+	ret := _m.Called(ctx, req)
+
+	var r0 *dto.Wallet
+	if rf, ok := ret.Get(0).(func(context.Context, *dto.WithdrawRequest) *dto.Wallet); ok {
+		r0 = rf(ctx, req)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*dto.Wallet)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, *dto.WithdrawRequest) error); ok {
+		r1 = rf(ctx, req)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// NewMockWalletService creates a new instance of MockWalletService. It also registers the testing.TB interface on the mock and a cleanup function to assert the mocks expectations.
+func NewMockWalletService(t testing.TB) *MockWalletService {
+	mock := &MockWalletService{}
+	mock.Mock.Test(t)
+
+	t.Cleanup(func() { mock.AssertExpectations(t) })
+
+	return mock
+}
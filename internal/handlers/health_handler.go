@@ -0,0 +1,47 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/vitthalaa/wager-app/app_errors"
+	"github.com/vitthalaa/wager-app/internal/logger"
+	"github.com/vitthalaa/wager-app/internal/services"
+)
+
+// HealthHandler is handler for /healthz liveness and /readyz readiness probes
+type HealthHandler struct {
+	healthService services.IHealthService
+	log           *logger.Logger
+}
+
+// NewHealthHandler ...
+func NewHealthHandler(healthService services.IHealthService, log *logger.Logger) *HealthHandler {
+	return &HealthHandler{
+		healthService: healthService,
+		log:           log,
+	}
+}
+
+// Handle is method to handle requests to routes
+func (h *HealthHandler) Handle(w http.ResponseWriter, req *http.Request) {
+	switch {
+	case req.Method == http.MethodGet && req.URL.Path == "/healthz":
+		writeResponse(w, http.StatusOK, h.healthService.Live(req.Context()))
+	case req.Method == http.MethodGet && req.URL.Path == "/readyz":
+		h.doReady(w, req)
+	default:
+		h.log.Debug(req.Context(), "route not found", "method", req.Method, "path", req.URL.Path)
+		writeResponse(w, http.StatusNotFound, app_errors.ErrorResponse{Code: app_errors.ErrNotFound})
+	}
+}
+
+// doReady responds 503 unless app is ready to take traffic
+func (h *HealthHandler) doReady(w http.ResponseWriter, req *http.Request) {
+	health := h.healthService.Ready(req.Context())
+	if !health.OK() {
+		writeResponse(w, http.StatusServiceUnavailable, health)
+		return
+	}
+
+	writeResponse(w, http.StatusOK, health)
+}
@@ -0,0 +1,78 @@
+package handlers
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	"github.com/vitthalaa/wager-app/dto"
+	"github.com/vitthalaa/wager-app/internal/logger"
+)
+
+func TestHealthHandler_Handle(t *testing.T) {
+	for _, tc := range []struct {
+		name   string
+		method string
+		path   string
+
+		readyResp *dto.Health
+
+		expectedCode int
+		expectedBody string
+	}{
+		{
+			name:         "live",
+			method:       "GET",
+			path:         "/healthz",
+			expectedCode: http.StatusOK,
+			expectedBody: `{"status":"ok"}`,
+		},
+		{
+			name:   "ready",
+			method: "GET",
+			path:   "/readyz",
+			readyResp: &dto.Health{Status: "ok", Checks: map[string]string{
+				"database": "ok", "migrations": "ok", "shutdown": "ok",
+			}},
+			expectedCode: http.StatusOK,
+			expectedBody: `{"status":"ok","checks":{"database":"ok","migrations":"ok","shutdown":"ok"}}`,
+		},
+		{
+			name:   "not ready",
+			method: "GET",
+			path:   "/readyz",
+			readyResp: &dto.Health{Status: "failing", Checks: map[string]string{
+				"database": "ok", "migrations": "ok", "shutdown": "failing",
+			}},
+			expectedCode: http.StatusServiceUnavailable,
+			expectedBody: `{"status":"failing","checks":{"database":"ok","migrations":"ok","shutdown":"failing"}}`,
+		},
+		{
+			name:         "post to probe",
+			method:       "POST",
+			path:         "/readyz",
+			expectedCode: http.StatusNotFound,
+			expectedBody: `{"error":"NOT_FOUND"}`,
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			request, err := http.NewRequest(tc.method, tc.path, nil)
+			require.Nil(t, err)
+
+			mockHealthService := new(MockHealthService)
+			mockHealthService.On("Live", mock.Anything).Return(&dto.Health{Status: "ok"})
+			mockHealthService.On("Ready", mock.Anything).Return(tc.readyResp)
+
+			resRecorder := httptest.NewRecorder()
+			handler := NewHealthHandler(mockHealthService, logger.Discard())
+			handler.Handle(resRecorder, request)
+
+			require.Equal(t, tc.expectedCode, resRecorder.Code)
+			assert.JSONEq(t, tc.expectedBody, resRecorder.Body.String())
+		})
+	}
+}
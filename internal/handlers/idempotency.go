@@ -0,0 +1,105 @@
+package handlers
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"io"
+	"net/http"
+	"strings"
+
+	"github.com/vitthalaa/wager-app/app_errors"
+	"github.com/vitthalaa/wager-app/dto"
+	"github.com/vitthalaa/wager-app/internal/httpwriter"
+	"github.com/vitthalaa/wager-app/internal/logger"
+	"github.com/vitthalaa/wager-app/internal/services"
+)
+
+const (
+	idempotencyKeyHeader = "Idempotency-Key"
+	// idempotentReplayedHeader is set on responses replayed for retried request
+	idempotentReplayedHeader = "Idempotent-Replayed"
+	// maxIdempotentBodyBytes limits body read in memory to be hashed, requests of this API are far smaller
+	maxIdempotentBodyBytes = 1 << 20
+)
+
+// idempotent runs handle once per Idempotency-Key header of authenticated user. Retries with same key get
+// response of first request replayed, retries with different request body are rejected.
+// Requests without header or without idempotencyService are handled as usual.
+// Responses with 5xx status or failing to be recorded are not kept, so request can be retried with same key.
+// Bodies over maxIdempotentBodyBytes are rejected as invalid.
+func idempotent(
+	w http.ResponseWriter, req *http.Request, idempotencyService services.IIdempotencyService, log *logger.Logger,
+	handle func(w http.ResponseWriter, req *http.Request) error,
+) error {
+	key := strings.TrimSpace(req.Header.Get(idempotencyKeyHeader))
+	if idempotencyService == nil || key == "" {
+		return handle(w, req)
+	}
+
+	user, ok := requireUser(w, req)
+	if !ok {
+		return nil
+	}
+
+	body, err := io.ReadAll(http.MaxBytesReader(w, req.Body, maxIdempotentBodyBytes))
+	if err != nil {
+		writeResponse(w, http.StatusBadRequest, &app_errors.ErrorResponse{Code: app_errors.ErrInvalidBody})
+		return nil
+	}
+
+	req.Body = io.NopCloser(bytes.NewReader(body))
+	request := &dto.IdempotentRequest{
+		UserID:      user.ID,
+		Key:         key,
+		RequestHash: requestHash(req, body),
+	}
+
+	recorded, err := idempotencyService.Begin(req.Context(), request)
+	if err != nil {
+		writeErrorResponse(w, err)
+		return nil
+	}
+
+	if recorded != nil {
+		w.Header().Set("Content-Type", "application/json")
+		w.Header().Set(idempotentReplayedHeader, "true")
+		w.WriteHeader(recorded.Status)
+		_, _ = w.Write(recorded.Body)
+		return nil
+	}
+
+	recorder := httpwriter.NewRecorder(w)
+	err = handle(recorder, req)
+
+	// request context may be cancelled by client already, key must not stay reserved
+	ctx := logger.Detach(req.Context())
+	if err != nil || recorder.Status() >= http.StatusInternalServerError {
+		if releaseErr := idempotencyService.Release(ctx, request); releaseErr != nil {
+			log.Error(ctx, "release idempotency key failed", "idempotency_key", key, "error", releaseErr)
+		}
+
+		return err
+	}
+
+	err = idempotencyService.Complete(ctx, request, &dto.IdempotentResponse{
+		Status: recorder.Status(),
+		Body:   recorder.Body(),
+	})
+	if err != nil {
+		log.Error(ctx, "record idempotent response failed", "idempotency_key", key, "error", err)
+		if releaseErr := idempotencyService.Release(ctx, request); releaseErr != nil {
+			log.Error(ctx, "release idempotency key failed", "idempotency_key", key, "error", releaseErr)
+		}
+	}
+
+	return nil
+}
+
+// requestHash returns fingerprint of request method, path and body
+func requestHash(req *http.Request, body []byte) string {
+	hash := sha256.New()
+	hash.Write([]byte(req.Method + " " + req.URL.Path + "\n"))
+	hash.Write(body)
+	return hex.EncodeToString(hash.Sum(nil))
+}
@@ -0,0 +1,177 @@
+package handlers
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	"github.com/vitthalaa/wager-app/app_errors"
+	"github.com/vitthalaa/wager-app/dto"
+	"github.com/vitthalaa/wager-app/internal/logger"
+)
+
+func TestIdempotent_PlaceWager(t *testing.T) {
+	body := `{"total_wager_value":100,"odds":2,"selling_percentage":20,"selling_price":"21"}`
+	for _, tc := range []struct {
+		name string
+
+		beginResp     *dto.IdempotentResponse
+		beginError    error
+		placeError    error
+		completeError error
+
+		expectPlaced   bool
+		expectComplete bool
+		expectRelease  bool
+
+		expectedCode     int
+		expectedBody     string
+		expectedReplayed string
+	}{
+		{
+			name:           "first request",
+			expectPlaced:   true,
+			expectComplete: true,
+			expectedCode:   http.StatusOK,
+		},
+		{
+			name:             "retry replays response",
+			beginResp:        &dto.IdempotentResponse{Status: http.StatusOK, Body: []byte(`{"id":1}`)},
+			expectedCode:     http.StatusOK,
+			expectedBody:     `{"id":1}`,
+			expectedReplayed: "true",
+		},
+		{
+			name:         "key reused",
+			beginError:   &app_errors.ErrorResponse{Status: http.StatusUnprocessableEntity, Code: app_errors.ErrIdempotencyKeyReused},
+			expectedCode: http.StatusUnprocessableEntity,
+			expectedBody: `{"error":"IDEMPOTENCY_KEY_REUSED"}`,
+		},
+		{
+			name:          "internal error releases key",
+			placeError:    errors.New("some service error"),
+			expectPlaced:  true,
+			expectRelease: true,
+			expectedCode:  http.StatusInternalServerError,
+			expectedBody:  `{"error":"INTERNAL_ERROR"}`,
+		},
+		{
+			name:           "failed recording releases key",
+			completeError:  errors.New("some complete error"),
+			expectPlaced:   true,
+			expectComplete: true,
+			expectRelease:  true,
+			expectedCode:   http.StatusOK,
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			request, err := http.NewRequest("POST", "/wagers", bytes.NewReader([]byte(body)))
+			require.Nil(t, err)
+			request.Header.Set("Idempotency-Key", "key-1")
+			request = withUser(request)
+
+			expectedReq := &dto.IdempotentRequest{
+				UserID:      testUser.ID,
+				Key:         "key-1",
+				RequestHash: requestHash(request, []byte(body)),
+			}
+
+			var placeResp *dto.Wager
+			if tc.placeError == nil {
+				placeResp = &dto.Wager{ID: 1}
+			}
+
+			mockWagerService := new(MockWagerService)
+			mockWagerService.On("PlaceWager", mock.Anything, mock.Anything).
+				Return(placeResp, tc.placeError)
+
+			mockIdempotencyService := new(MockIdempotencyService)
+			mockIdempotencyService.On("Begin", mock.Anything, expectedReq).
+				Return(tc.beginResp, tc.beginError)
+			mockIdempotencyService.On("Complete", mock.Anything, expectedReq, mock.Anything).
+				Return(tc.completeError)
+			mockIdempotencyService.On("Release", mock.Anything, expectedReq).
+				Return(nil)
+
+			resRecorder := httptest.NewRecorder()
+			handler := NewWagersHandler(mockWagerService, new(MockSettlementService), new(MockAuctionService), mockIdempotencyService, logger.Discard(), nil)
+			handler.Handle(resRecorder, request)
+
+			expectedBody := tc.expectedBody
+			if expectedBody == "" {
+				placed, err := json.Marshal(placeResp)
+				require.Nil(t, err)
+				expectedBody = string(placed)
+			}
+
+			require.Equal(t, tc.expectedCode, resRecorder.Code)
+			assert.JSONEq(t, expectedBody, resRecorder.Body.String())
+			assert.Equal(t, tc.expectedReplayed, resRecorder.Header().Get("Idempotent-Replayed"))
+
+			if tc.expectPlaced {
+				mockWagerService.AssertCalled(t, "PlaceWager", mock.Anything, mock.Anything)
+			} else {
+				mockWagerService.AssertNotCalled(t, "PlaceWager", mock.Anything, mock.Anything)
+			}
+
+			if tc.expectComplete {
+				mockIdempotencyService.AssertCalled(t, "Complete", mock.Anything, expectedReq,
+					&dto.IdempotentResponse{Status: tc.expectedCode, Body: resRecorder.Body.Bytes()})
+			} else {
+				mockIdempotencyService.AssertNotCalled(t, "Complete", mock.Anything, mock.Anything, mock.Anything)
+			}
+
+			if tc.expectRelease {
+				mockIdempotencyService.AssertCalled(t, "Release", mock.Anything, expectedReq)
+			} else {
+				mockIdempotencyService.AssertNotCalled(t, "Release", mock.Anything, mock.Anything)
+			}
+		})
+	}
+}
+
+func TestIdempotent_WithoutKey(t *testing.T) {
+	request, err := http.NewRequest("POST", "/buy/1", bytes.NewReader([]byte(`{"buying_price":"20"}`)))
+	require.Nil(t, err)
+	request = withUser(request)
+
+	mockPurchaseService := new(MockPurchaseService)
+	mockPurchaseService.On("PurchaseWager", mock.Anything, mock.Anything).
+		Return(&dto.WagerPurchase{ID: 3}, nil)
+
+	mockIdempotencyService := new(MockIdempotencyService)
+
+	resRecorder := httptest.NewRecorder()
+	handler := NewPurchasesHandler(mockPurchaseService, mockIdempotencyService, logger.Discard())
+	handler.Handle(resRecorder, request)
+
+	require.Equal(t, http.StatusOK, resRecorder.Code)
+	mockIdempotencyService.AssertNotCalled(t, "Begin", mock.Anything, mock.Anything)
+}
+
+func TestIdempotent_BodyTooLarge(t *testing.T) {
+	body := bytes.Repeat([]byte(" "), maxIdempotentBodyBytes+1)
+	request, err := http.NewRequest("POST", "/buy/1", bytes.NewReader(body))
+	require.Nil(t, err)
+	request.Header.Set("Idempotency-Key", "key-1")
+	request = withUser(request)
+
+	mockPurchaseService := new(MockPurchaseService)
+	mockIdempotencyService := new(MockIdempotencyService)
+
+	resRecorder := httptest.NewRecorder()
+	handler := NewPurchasesHandler(mockPurchaseService, mockIdempotencyService, logger.Discard())
+	handler.Handle(resRecorder, request)
+
+	require.Equal(t, http.StatusBadRequest, resRecorder.Code)
+	assert.JSONEq(t, `{"error":"INVALID_BODY"}`, resRecorder.Body.String())
+	mockIdempotencyService.AssertNotCalled(t, "Begin", mock.Anything, mock.Anything)
+	mockPurchaseService.AssertNotCalled(t, "PurchaseWager", mock.Anything, mock.Anything)
+}
@@ -0,0 +1,146 @@
+package handlers
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/vitthalaa/wager-app/app_errors"
+	"github.com/vitthalaa/wager-app/dto"
+	"github.com/vitthalaa/wager-app/internal/logger"
+	"github.com/vitthalaa/wager-app/internal/services"
+)
+
+// OrderHandler is handler for all order book(/orders, /books) routes
+type OrderHandler struct {
+	orderService       services.IOrderService
+	idempotencyService services.IIdempotencyService
+	log                *logger.Logger
+}
+
+// NewOrderHandler ...
+func NewOrderHandler(
+	orderService services.IOrderService, idempotencyService services.IIdempotencyService, log *logger.Logger,
+) *OrderHandler {
+	return &OrderHandler{
+		orderService:       orderService,
+		idempotencyService: idempotencyService,
+		log:                log,
+	}
+}
+
+// Handle is method to handle requests to routes
+func (h *OrderHandler) Handle(w http.ResponseWriter, req *http.Request) {
+	var err error
+	isBooks := strings.HasPrefix(req.URL.Path, "/books/")
+	hasID := orderIDPath(req) != ""
+	switch {
+	case req.Method == http.MethodGet && isBooks:
+		err = h.doGetOrderBook(w, req)
+	case req.Method == http.MethodPost && !isBooks && !hasID:
+		err = idempotent(w, req, h.idempotencyService, h.log, h.doPlaceOrder)
+	case req.Method == http.MethodGet && !isBooks && hasID:
+		err = h.doOrder(w, req, h.orderService.GetOrder)
+	case req.Method == http.MethodDelete && !isBooks && hasID:
+		err = h.doOrder(w, req, h.orderService.CancelOrder)
+	default:
+		h.log.Debug(req.Context(), "route not found", "method", req.Method, "path", req.URL.Path)
+		writeResponse(w, http.StatusNotFound, app_errors.ErrorResponse{Code: app_errors.ErrNotFound})
+	}
+
+	if err != nil {
+		h.log.Error(req.Context(), "handle request failed", "error", err)
+		writeResponse(w, http.StatusInternalServerError, app_errors.ErrorResponse{Code: app_errors.ErrInternalError})
+	}
+}
+
+// doPlaceOrder places order of user and returns it with its fills
+func (h *OrderHandler) doPlaceOrder(w http.ResponseWriter, req *http.Request) error {
+	user, ok := requireUser(w, req)
+	if !ok {
+		return nil
+	}
+
+	var request dto.PlaceOrderRequest
+	if errRes := decodeJSON(req, &request); errRes != nil {
+		writeResponse(w, errRes.Status, errRes)
+		return nil
+	}
+
+	request.UserID = user.ID
+
+	res, err := h.orderService.PlaceOrder(req.Context(), &request)
+	if err != nil {
+		writeErrorResponse(w, err)
+		return nil
+	}
+
+	writeResponse(w, http.StatusOK, res)
+	return nil
+}
+
+// doOrder runs get or cancel of order of user by id
+func (h *OrderHandler) doOrder(
+	w http.ResponseWriter, req *http.Request,
+	fn func(ctx context.Context, req *dto.OrderRequest) (*dto.Order, error),
+) error {
+	user, ok := requireUser(w, req)
+	if !ok {
+		return nil
+	}
+
+	id := orderIDPath(req)
+	orderID, err := strconv.ParseUint(id, 10, 32)
+	if err != nil || orderID < 1 {
+		h.log.Debug(req.Context(), "invalid order id", "order_id", id)
+		writeResponse(w, http.StatusNotFound, app_errors.ErrorResponse{Code: app_errors.ErrNotFound})
+		return nil
+	}
+
+	res, err := fn(req.Context(), &dto.OrderRequest{UserID: user.ID, OrderID: uint32(orderID)})
+	if err != nil {
+		writeErrorResponse(w, err)
+		return nil
+	}
+
+	writeResponse(w, http.StatusOK, res)
+	return nil
+}
+
+// doGetOrderBook returns depth of order book of wager
+func (h *OrderHandler) doGetOrderBook(w http.ResponseWriter, req *http.Request) error {
+	id := strings.TrimPrefix(req.URL.Path, "/books/")
+	wagerID, err := strconv.ParseUint(id, 10, 32)
+	if err != nil || wagerID < 1 {
+		h.log.Debug(req.Context(), "invalid wager id", "wager_id", id)
+		writeResponse(w, http.StatusNotFound, app_errors.ErrorResponse{Code: app_errors.ErrNotFound})
+		return nil
+	}
+
+	request := &dto.GetOrderBookRequest{WagerID: uint32(wagerID)}
+	if v := strings.TrimSpace(req.URL.Query().Get("levels")); v != "" {
+		levels, err := strconv.ParseUint(v, 10, 32)
+		if err != nil {
+			h.log.Debug(req.Context(), "invalid order book levels", "levels", v)
+			writeResponse(w, http.StatusBadRequest, &app_errors.ErrorResponse{Code: app_errors.ErrInvalidFilter})
+			return nil
+		}
+
+		request.Levels = uint32(levels)
+	}
+
+	res, err := h.orderService.GetOrderBook(req.Context(), request)
+	if err != nil {
+		writeErrorResponse(w, err)
+		return nil
+	}
+
+	writeResponse(w, http.StatusOK, res)
+	return nil
+}
+
+// orderIDPath returns {id} part of /orders/{id} path, empty for /orders
+func orderIDPath(req *http.Request) string {
+	return strings.Trim(strings.TrimPrefix(req.URL.Path, "/orders"), "/")
+}
@@ -0,0 +1,259 @@
+package handlers
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	"github.com/vitthalaa/wager-app/app_errors"
+	"github.com/vitthalaa/wager-app/dto"
+	"github.com/vitthalaa/wager-app/internal/logger"
+	"github.com/vitthalaa/wager-app/money"
+)
+
+func TestOrderHandler_Handle_PlaceOrder(t *testing.T) {
+	placeOrderReq := dto.PlaceOrderRequest{
+		WagerID:  111,
+		Side:     "bid",
+		Price:    money.MustParse("25"),
+		Quantity: 2,
+	}
+
+	now := time.Now()
+	orderRes := &dto.Order{
+		ID:       1,
+		WagerID:  111,
+		UserID:   testUser.ID,
+		Side:     "bid",
+		Price:    money.MustParse("25"),
+		Quantity: 2,
+		Filled:   1,
+		Status:   "open",
+		PlacedAt: &now,
+		Purchases: []dto.WagerPurchase{
+			{ID: 1, WagerID: 111, BuyingPrice: money.MustParse("24"), Quantity: 1, TotalPrice: money.MustParse("24"), BoughtAt: &now},
+		},
+	}
+
+	body, err := json.Marshal(placeOrderReq)
+	require.Nil(t, err)
+
+	request, err := http.NewRequest("POST", "http://domain.co/orders", bytes.NewReader(body))
+	require.Nil(t, err)
+	request = withUser(request)
+
+	expectedReq := placeOrderReq
+	expectedReq.UserID = testUser.ID
+
+	mockOrderService := new(MockOrderService)
+	mockOrderService.On("PlaceOrder", mock.Anything, &expectedReq).
+		Return(orderRes, nil)
+
+	resRecorder := httptest.NewRecorder()
+	handler := NewOrderHandler(mockOrderService, nil, logger.Discard())
+	handler.Handle(resRecorder, request)
+
+	expected, err := json.Marshal(orderRes)
+	require.Nil(t, err)
+
+	require.Equal(t, http.StatusOK, resRecorder.Code)
+	assert.Equal(t, string(expected), resRecorder.Body.String())
+}
+
+func TestOrderHandler_Handle_Order(t *testing.T) {
+	orderRes := &dto.Order{
+		ID:       1,
+		WagerID:  111,
+		UserID:   testUser.ID,
+		Side:     "ask",
+		Price:    money.MustParse("25"),
+		Quantity: 2,
+		Status:   "cancelled",
+	}
+
+	for _, tc := range []struct {
+		name   string
+		method string
+		url    string
+		// serviceMethod is expected method of service, none when empty
+		serviceMethod string
+		serviceError  error
+
+		expectedCode int
+		expectedBody string
+	}{
+		{
+			name:          "get order",
+			method:        "GET",
+			url:           "http://domain.co/orders/1",
+			serviceMethod: "GetOrder",
+			expectedCode:  http.StatusOK,
+		},
+		{
+			name:          "cancel order",
+			method:        "DELETE",
+			url:           "http://domain.co/orders/1",
+			serviceMethod: "CancelOrder",
+			expectedCode:  http.StatusOK,
+		},
+		{
+			name:          "cancel not open order",
+			method:        "DELETE",
+			url:           "http://domain.co/orders/1",
+			serviceMethod: "CancelOrder",
+			serviceError:  &app_errors.ErrorResponse{Status: http.StatusConflict, Code: app_errors.ErrOrderNotOpen},
+			expectedCode:  http.StatusConflict,
+			expectedBody:  `{"error":"ORDER_NOT_OPEN"}`,
+		},
+		{
+			name:         "invalid id",
+			method:       "GET",
+			url:          "http://domain.co/orders/abc",
+			expectedCode: http.StatusNotFound,
+			expectedBody: `{"error":"NOT_FOUND"}`,
+		},
+		{
+			name:         "zero id",
+			method:       "DELETE",
+			url:          "http://domain.co/orders/0",
+			expectedCode: http.StatusNotFound,
+			expectedBody: `{"error":"NOT_FOUND"}`,
+		},
+		{
+			name:         "post to order",
+			method:       "POST",
+			url:          "http://domain.co/orders/1",
+			expectedCode: http.StatusNotFound,
+			expectedBody: `{"error":"NOT_FOUND"}`,
+		},
+		{
+			name:         "list orders",
+			method:       "GET",
+			url:          "http://domain.co/orders",
+			expectedCode: http.StatusNotFound,
+			expectedBody: `{"error":"NOT_FOUND"}`,
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			request, err := http.NewRequest(tc.method, tc.url, nil)
+			require.Nil(t, err)
+			request = withUser(request)
+
+			mockOrderService := new(MockOrderService)
+			if tc.serviceMethod != "" {
+				var res *dto.Order
+				if tc.serviceError == nil {
+					res = orderRes
+				}
+
+				mockOrderService.On(tc.serviceMethod, mock.Anything, &dto.OrderRequest{UserID: testUser.ID, OrderID: 1}).
+					Return(res, tc.serviceError)
+			}
+
+			resRecorder := httptest.NewRecorder()
+			handler := NewOrderHandler(mockOrderService, nil, logger.Discard())
+			handler.Handle(resRecorder, request)
+
+			expectedBody := tc.expectedBody
+			if expectedBody == "" {
+				expected, err := json.Marshal(orderRes)
+				require.Nil(t, err)
+				expectedBody = string(expected)
+			}
+
+			require.Equal(t, tc.expectedCode, resRecorder.Code)
+			assert.JSONEq(t, expectedBody, resRecorder.Body.String())
+			mockOrderService.AssertExpectations(t)
+		})
+	}
+}
+
+func TestOrderHandler_Handle_Order_Unauthorized(t *testing.T) {
+	request, err := http.NewRequest("DELETE", "http://domain.co/orders/1", nil)
+	require.Nil(t, err)
+
+	mockOrderService := new(MockOrderService)
+
+	resRecorder := httptest.NewRecorder()
+	handler := NewOrderHandler(mockOrderService, nil, logger.Discard())
+	handler.Handle(resRecorder, request)
+
+	require.Equal(t, http.StatusUnauthorized, resRecorder.Code)
+	assert.JSONEq(t, `{"error":"UNAUTHORIZED"}`, resRecorder.Body.String())
+	mockOrderService.AssertExpectations(t)
+}
+
+func TestOrderHandler_Handle_GetOrderBook(t *testing.T) {
+	book := &dto.OrderBook{
+		WagerID: 111,
+		Bids:    []dto.OrderBookLevel{{Price: money.MustParse("24"), Quantity: 3, Orders: 2}},
+		Asks:    []dto.OrderBookLevel{{Price: money.MustParse("26"), Quantity: 1, Orders: 1}},
+	}
+
+	for _, tc := range []struct {
+		name string
+		url  string
+		// expectedReq is expected request of service, service is not called when nil
+		expectedReq *dto.GetOrderBookRequest
+
+		expectedCode int
+		expectedBody string
+	}{
+		{
+			name:         "default levels",
+			url:          "http://domain.co/books/111",
+			expectedReq:  &dto.GetOrderBookRequest{WagerID: 111},
+			expectedCode: http.StatusOK,
+		},
+		{
+			name:         "levels",
+			url:          "http://domain.co/books/111?levels=5",
+			expectedReq:  &dto.GetOrderBookRequest{WagerID: 111, Levels: 5},
+			expectedCode: http.StatusOK,
+		},
+		{
+			name:         "invalid levels",
+			url:          "http://domain.co/books/111?levels=many",
+			expectedCode: http.StatusBadRequest,
+			expectedBody: `{"error":"INVALID_FILTER"}`,
+		},
+		{
+			name:         "invalid wager id",
+			url:          "http://domain.co/books/abc",
+			expectedCode: http.StatusNotFound,
+			expectedBody: `{"error":"NOT_FOUND"}`,
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			request, err := http.NewRequest("GET", tc.url, nil)
+			require.Nil(t, err)
+
+			mockOrderService := new(MockOrderService)
+			if tc.expectedReq != nil {
+				mockOrderService.On("GetOrderBook", mock.Anything, tc.expectedReq).Return(book, nil)
+			}
+
+			resRecorder := httptest.NewRecorder()
+			handler := NewOrderHandler(mockOrderService, nil, logger.Discard())
+			handler.Handle(resRecorder, request)
+
+			expectedBody := tc.expectedBody
+			if expectedBody == "" {
+				expected, err := json.Marshal(book)
+				require.Nil(t, err)
+				expectedBody = string(expected)
+			}
+
+			require.Equal(t, tc.expectedCode, resRecorder.Code)
+			assert.JSONEq(t, expectedBody, resRecorder.Body.String())
+			mockOrderService.AssertExpectations(t)
+		})
+	}
+}
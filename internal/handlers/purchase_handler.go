@@ -1,70 +1,87 @@
 package handlers
 
 import (
-	"encoding/json"
-	"log"
 	"net/http"
 	"strconv"
 	"strings"
+	"time"
 
 	"github.com/vitthalaa/wager-app/app_errors"
 	"github.com/vitthalaa/wager-app/dto"
+	"github.com/vitthalaa/wager-app/internal/logger"
 	"github.com/vitthalaa/wager-app/internal/services"
+	"github.com/vitthalaa/wager-app/money"
 )
 
-// PurchaseHandler is handler for all purchase(/buy) routes
+// PurchaseHandler is handler for all purchase(/buy, /purchases) routes
 type PurchaseHandler struct {
-	purchaseService services.IPurchaseService
+	purchaseService    services.IPurchaseService
+	idempotencyService services.IIdempotencyService
+	log                *logger.Logger
 }
 
 // NewPurchasesHandler ...
-func NewPurchasesHandler(purchaseService services.IPurchaseService) *PurchaseHandler {
+func NewPurchasesHandler(
+	purchaseService services.IPurchaseService, idempotencyService services.IIdempotencyService, log *logger.Logger,
+) *PurchaseHandler {
 	return &PurchaseHandler{
-		purchaseService: purchaseService,
+		purchaseService:    purchaseService,
+		idempotencyService: idempotencyService,
+		log:                log,
 	}
 }
 
 // Handle is method to handle requests to routes
 func (h *PurchaseHandler) Handle(w http.ResponseWriter, req *http.Request) {
 	var err error
-	switch req.Method {
-	case http.MethodPost:
-		err = h.doPurchaseWager(w, req)
+	isPurchases := req.URL.Path == "/purchases" || strings.HasPrefix(req.URL.Path, "/purchases/")
+	hasID := purchaseIDPath(req) != ""
+	switch {
+	case req.Method == http.MethodPost && !isPurchases:
+		err = idempotent(w, req, h.idempotencyService, h.log, h.doPurchaseWager)
+	case req.Method == http.MethodGet && isPurchases && !hasID:
+		err = h.doListPurchases(w, req)
+	case req.Method == http.MethodGet && isPurchases:
+		err = h.doGetPurchase(w, req)
 	default:
-		log.Println("error no 404")
+		h.log.Debug(req.Context(), "route not found", "method", req.Method, "path", req.URL.Path)
 		writeResponse(w, http.StatusNotFound, app_errors.ErrorResponse{Code: app_errors.ErrNotFound})
 	}
 
 	if err != nil {
-		log.Println("error {}", err)
+		h.log.Error(req.Context(), "handle request failed", "error", err)
 		writeResponse(w, http.StatusInternalServerError, app_errors.ErrorResponse{Code: app_errors.ErrInternalError})
 	}
 }
 
 func (h *PurchaseHandler) doPurchaseWager(w http.ResponseWriter, req *http.Request) error {
+	user, ok := requireUser(w, req)
+	if !ok {
+		return nil
+	}
+
 	id := strings.TrimPrefix(req.URL.Path, "/buy/")
 	if id == "" {
-		log.Println("empty wager id")
+		h.log.Debug(req.Context(), "empty wager id")
 		writeResponse(w, http.StatusNotFound, app_errors.ErrorResponse{Code: app_errors.ErrNotFound})
 		return nil
 	}
 
 	wagerID, err := strconv.Atoi(id)
 	if err != nil {
-		log.Println("invalid wager id {}", id)
+		h.log.Debug(req.Context(), "invalid wager id", "wager_id", id)
 		writeResponse(w, http.StatusNotFound, app_errors.ErrorResponse{Code: app_errors.ErrNotFound})
 		return nil
 	}
 
-	decoder := json.NewDecoder(req.Body)
 	var request dto.BuyWagerRequest
-	err = decoder.Decode(&request)
-	if err != nil {
-		writeResponse(w, http.StatusBadRequest, &app_errors.ErrorResponse{Code: app_errors.ErrInvalidBody})
+	if errRes := decodeJSON(req, &request); errRes != nil {
+		writeResponse(w, errRes.Status, errRes)
 		return nil
 	}
 
 	request.WagerID = uint32(wagerID)
+	request.BuyerID = user.ID
 
 	res, err := h.purchaseService.PurchaseWager(req.Context(), &request)
 	if err != nil {
@@ -75,3 +92,101 @@ func (h *PurchaseHandler) doPurchaseWager(w http.ResponseWriter, req *http.Reque
 	writeResponse(w, http.StatusOK, res)
 	return nil
 }
+
+// doListPurchases lists purchases matching query filters
+func (h *PurchaseHandler) doListPurchases(w http.ResponseWriter, req *http.Request) error {
+	request, err := listPurchaseRequest(req)
+	if err != nil {
+		h.log.Debug(req.Context(), "invalid purchase filter", "error", err)
+		writeResponse(w, http.StatusBadRequest, &app_errors.ErrorResponse{Code: app_errors.ErrInvalidFilter})
+		return nil
+	}
+
+	purchaseList, err := h.purchaseService.ListPurchases(req.Context(), request)
+	if err != nil {
+		writeErrorResponse(w, err)
+		return nil
+	}
+
+	writeResponse(w, http.StatusOK, purchaseList)
+	return nil
+}
+
+// doGetPurchase returns purchase by id
+func (h *PurchaseHandler) doGetPurchase(w http.ResponseWriter, req *http.Request) error {
+	id := purchaseIDPath(req)
+	purchaseID, err := strconv.ParseUint(id, 10, 32)
+	if err != nil || purchaseID < 1 {
+		h.log.Debug(req.Context(), "invalid purchase id", "purchase_id", id)
+		writeResponse(w, http.StatusNotFound, app_errors.ErrorResponse{Code: app_errors.ErrNotFound})
+		return nil
+	}
+
+	res, err := h.purchaseService.GetPurchase(req.Context(), uint32(purchaseID))
+	if err != nil {
+		writeErrorResponse(w, err)
+		return nil
+	}
+
+	writeResponse(w, http.StatusOK, res)
+	return nil
+}
+
+// purchaseIDPath returns {id} part of /purchases/{id} path, empty for /purchases
+func purchaseIDPath(req *http.Request) string {
+	return strings.Trim(strings.TrimPrefix(req.URL.Path, "/purchases"), "/")
+}
+
+// listPurchaseRequest parses purchase list filters from query params
+func listPurchaseRequest(req *http.Request) (*dto.ListPurchaseRequest, error) {
+	query := req.URL.Query()
+	page, limit, err := pagination(req)
+	if err != nil {
+		return nil, err
+	}
+
+	request := &dto.ListPurchaseRequest{
+		Sort:  strings.TrimSpace(query.Get("sort")),
+		Page:  page,
+		Limit: limit,
+	}
+
+	if v := strings.TrimSpace(query.Get("wager_id")); v != "" {
+		wagerID, err := strconv.ParseUint(v, 10, 32)
+		if err != nil {
+			return nil, err
+		}
+
+		request.WagerID = uint32(wagerID)
+	}
+
+	for param, dst := range map[string]*money.Money{
+		"min_price": &request.MinPrice,
+		"max_price": &request.MaxPrice,
+	} {
+		if v := strings.TrimSpace(query.Get(param)); v != "" {
+			price, err := money.Parse(v)
+			if err != nil {
+				return nil, err
+			}
+
+			*dst = price
+		}
+	}
+
+	for param, dst := range map[string]**time.Time{
+		"from": &request.From,
+		"to":   &request.To,
+	} {
+		if v := strings.TrimSpace(query.Get(param)); v != "" {
+			t, err := time.Parse(time.RFC3339, v)
+			if err != nil {
+				return nil, err
+			}
+
+			*dst = &t
+		}
+	}
+
+	return request, nil
+}
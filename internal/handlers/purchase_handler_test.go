@@ -13,18 +13,23 @@ import (
 	"github.com/stretchr/testify/require"
 
 	"github.com/vitthalaa/wager-app/dto"
+	"github.com/vitthalaa/wager-app/internal/logger"
+	"github.com/vitthalaa/wager-app/money"
 )
 
 func TestPurchaseHandler_Handle(t *testing.T) {
 	buyWagerReq := dto.BuyWagerRequest{
-		BuyingPrice: 25,
+		BuyingPrice: money.MustParse("25"),
+		Quantity:    2,
 	}
 
 	now := time.Now()
 	purchaseRes := dto.WagerPurchase{
 		ID:          1,
 		WagerID:     111,
-		BuyingPrice: 25,
+		BuyingPrice: money.MustParse("25"),
+		Quantity:    2,
+		TotalPrice:  money.MustParse("50"),
 		BoughtAt:    &now,
 	}
 
@@ -33,10 +38,13 @@ func TestPurchaseHandler_Handle(t *testing.T) {
 
 	request, err := http.NewRequest("POST", "http://domain.co/buy/111", bytes.NewReader(body))
 	require.Nil(t, err)
+	request = withUser(request)
 
 	expectedReq := &dto.BuyWagerRequest{
 		WagerID:     111,
-		BuyingPrice: 25,
+		BuyerID:     testUser.ID,
+		BuyingPrice: money.MustParse("25"),
+		Quantity:    2,
 	}
 
 	mockPurchaseService := new(MockPurchaseService)
@@ -44,7 +52,7 @@ func TestPurchaseHandler_Handle(t *testing.T) {
 		Return(&purchaseRes, nil)
 
 	resRecorder := httptest.NewRecorder()
-	handler := NewPurchasesHandler(mockPurchaseService)
+	handler := NewPurchasesHandler(mockPurchaseService, nil, logger.Discard())
 	handler.Handle(resRecorder, request)
 
 	expected, err := json.Marshal(purchaseRes)
@@ -53,3 +61,167 @@ func TestPurchaseHandler_Handle(t *testing.T) {
 	require.Equal(t, http.StatusOK, resRecorder.Code)
 	assert.Equal(t, string(expected), resRecorder.Body.String())
 }
+
+func TestPurchaseHandler_Handle_ListPurchases_HappyPath(t *testing.T) {
+	now := time.Now()
+	purchaseList := &dto.WagerPurchaseList{
+		Items: []dto.WagerPurchase{
+			{
+				ID:          1,
+				WagerID:     111,
+				BuyingPrice: money.MustParse("25"),
+				BoughtAt:    &now,
+			},
+		},
+		Page:  2,
+		Limit: 5,
+		Total: 6,
+	}
+
+	request, err := http.NewRequest("GET",
+		"http://domain.co/purchases?wager_id=111&min_price=10.5&max_price=30&from=2022-01-02T10:00:00Z&sort=-bought_at&page=2&limit=5", nil)
+	require.Nil(t, err)
+
+	from := time.Date(2022, 1, 2, 10, 0, 0, 0, time.UTC)
+	expectedRequest := &dto.ListPurchaseRequest{
+		WagerID:  111,
+		MinPrice: money.MustParse("10.5"),
+		MaxPrice: money.MustParse("30"),
+		From:     &from,
+		Sort:     "-bought_at",
+		Page:     2,
+		Limit:    5,
+	}
+
+	mockPurchaseService := new(MockPurchaseService)
+	mockPurchaseService.On("ListPurchases", mock.Anything, expectedRequest).
+		Return(purchaseList, nil)
+
+	resRecorder := httptest.NewRecorder()
+	handler := NewPurchasesHandler(mockPurchaseService, nil, logger.Discard())
+	handler.Handle(resRecorder, request)
+
+	expected, err := json.Marshal(purchaseList)
+	require.Nil(t, err)
+
+	require.Equal(t, http.StatusOK, resRecorder.Code)
+	assert.Equal(t, string(expected), resRecorder.Body.String())
+}
+
+func TestPurchaseHandler_Handle_ListPurchases_InvalidFilter(t *testing.T) {
+	for _, tc := range []struct {
+		name string
+		url  string
+	}{
+		{
+			name: "invalid wager id",
+			url:  "http://domain.co/purchases?wager_id=abc",
+		},
+		{
+			name: "invalid price",
+			url:  "http://domain.co/purchases?min_price=cheap",
+		},
+		{
+			name: "price with more than 2 decimals",
+			url:  "http://domain.co/purchases?max_price=10.005",
+		},
+		{
+			name: "invalid time",
+			url:  "http://domain.co/purchases?to=yesterday",
+		},
+		{
+			name: "invalid page",
+			url:  "http://domain.co/purchases?page=first",
+		},
+		{
+			name: "negative limit",
+			url:  "http://domain.co/purchases?limit=-5",
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			request, err := http.NewRequest("GET", tc.url, nil)
+			require.Nil(t, err)
+
+			mockPurchaseService := new(MockPurchaseService)
+
+			resRecorder := httptest.NewRecorder()
+			handler := NewPurchasesHandler(mockPurchaseService, nil, logger.Discard())
+			handler.Handle(resRecorder, request)
+
+			require.Equal(t, http.StatusBadRequest, resRecorder.Code)
+			assert.JSONEq(t, `{"error":"INVALID_FILTER"}`, resRecorder.Body.String())
+			mockPurchaseService.AssertExpectations(t)
+		})
+	}
+}
+
+func TestPurchaseHandler_Handle_GetPurchase_HappyPath(t *testing.T) {
+	now := time.Now()
+	purchaseRes := &dto.WagerPurchase{
+		ID:          1,
+		WagerID:     111,
+		BuyingPrice: money.MustParse("25"),
+		BoughtAt:    &now,
+	}
+
+	request, err := http.NewRequest("GET", "http://domain.co/purchases/1", nil)
+	require.Nil(t, err)
+
+	mockPurchaseService := new(MockPurchaseService)
+	mockPurchaseService.On("GetPurchase", mock.Anything, uint32(1)).
+		Return(purchaseRes, nil)
+
+	resRecorder := httptest.NewRecorder()
+	handler := NewPurchasesHandler(mockPurchaseService, nil, logger.Discard())
+	handler.Handle(resRecorder, request)
+
+	expected, err := json.Marshal(purchaseRes)
+	require.Nil(t, err)
+
+	require.Equal(t, http.StatusOK, resRecorder.Code)
+	assert.Equal(t, string(expected), resRecorder.Body.String())
+}
+
+func TestPurchaseHandler_Handle_GetPurchase_NotFound(t *testing.T) {
+	for _, tc := range []struct {
+		name   string
+		method string
+		url    string
+	}{
+		{
+			name:   "invalid id",
+			method: "GET",
+			url:    "http://domain.co/purchases/abc",
+		},
+		{
+			name:   "zero id",
+			method: "GET",
+			url:    "http://domain.co/purchases/0",
+		},
+		{
+			name:   "post to purchases",
+			method: "POST",
+			url:    "http://domain.co/purchases",
+		},
+		{
+			name:   "get buy route",
+			method: "GET",
+			url:    "http://domain.co/buy/111",
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			request, err := http.NewRequest(tc.method, tc.url, nil)
+			require.Nil(t, err)
+
+			mockPurchaseService := new(MockPurchaseService)
+
+			resRecorder := httptest.NewRecorder()
+			handler := NewPurchasesHandler(mockPurchaseService, nil, logger.Discard())
+			handler.Handle(resRecorder, request)
+
+			require.Equal(t, http.StatusNotFound, resRecorder.Code)
+			assert.JSONEq(t, `{"error":"NOT_FOUND"}`, resRecorder.Body.String())
+			mockPurchaseService.AssertExpectations(t)
+		})
+	}
+}
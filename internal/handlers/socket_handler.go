@@ -0,0 +1,443 @@
+package handlers
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/vitthalaa/wager-app/app_errors"
+	"github.com/vitthalaa/wager-app/dto"
+	"github.com/vitthalaa/wager-app/internal/auth"
+	"github.com/vitthalaa/wager-app/internal/events"
+	"github.com/vitthalaa/wager-app/internal/logger"
+	"github.com/vitthalaa/wager-app/internal/services"
+	"github.com/vitthalaa/wager-app/internal/validation"
+	"github.com/vitthalaa/wager-app/internal/websocket"
+)
+
+const (
+	// socketMaxMessageSize is max length of client message in bytes
+	socketMaxMessageSize = 4 << 10
+	// socketWriteTimeout is how long writing single message to client may take
+	socketWriteTimeout = 10 * time.Second
+	// socketCloseTimeout is how long client has to answer close frame before connection is dropped
+	socketCloseTimeout = 2 * time.Second
+)
+
+// SocketHandler serves WebSocket API of /ws, clients subscribe to wager events and buy wagers over single connection
+type SocketHandler struct {
+	wagerService    services.IWagerService
+	purchaseService services.IPurchaseService
+	broker          *events.Broker
+	sendBuffer      int
+	pingInterval    time.Duration
+	allowedOrigins  []string
+	log             *logger.Logger
+
+	mu       sync.Mutex
+	sessions map[*socketSession]struct{}
+	closing  bool
+	wg       sync.WaitGroup
+}
+
+// NewSocketHandler returns handler of /ws queueing up to sendBuffer messages for each client
+// and pinging clients every pingInterval
+func NewSocketHandler(
+	wagerService services.IWagerService, purchaseService services.IPurchaseService, broker *events.Broker,
+	sendBuffer int, pingInterval time.Duration, allowedOrigins []string, log *logger.Logger,
+) *SocketHandler {
+	return &SocketHandler{
+		wagerService:    wagerService,
+		purchaseService: purchaseService,
+		broker:          broker,
+		sendBuffer:      sendBuffer,
+		pingInterval:    pingInterval,
+		allowedOrigins:  allowedOrigins,
+		log:             log,
+		sessions:        map[*socketSession]struct{}{},
+	}
+}
+
+// Handle upgrades request to WebSocket and serves messages of client until connection is closed
+func (h *SocketHandler) Handle(w http.ResponseWriter, req *http.Request) {
+	if req.Method != http.MethodGet {
+		h.log.Debug(req.Context(), "route not found", "method", req.Method, "path", req.URL.Path)
+		writeResponse(w, http.StatusNotFound, app_errors.ErrorResponse{Code: app_errors.ErrNotFound})
+		return
+	}
+
+	if !h.originAllowed(req) {
+		h.log.Debug(req.Context(), "websocket origin not allowed", "origin", req.Header.Get("Origin"))
+		writeResponse(w, http.StatusForbidden, app_errors.ErrorResponse{Code: app_errors.ErrOriginNotAllowed})
+		return
+	}
+
+	h.mu.Lock()
+	closing := h.closing
+	if !closing {
+		h.wg.Add(1)
+	}
+	h.mu.Unlock()
+
+	if closing {
+		writeResponse(w, http.StatusServiceUnavailable, app_errors.ErrorResponse{Code: app_errors.ErrUnavailable})
+		return
+	}
+
+	defer h.wg.Done()
+
+	conn, err := websocket.Upgrade(w, req)
+	if err == websocket.ErrBadHandshake {
+		h.log.Debug(req.Context(), "not websocket handshake")
+		w.Header().Set("Upgrade", "websocket")
+		w.Header().Set("Sec-WebSocket-Version", "13")
+		writeResponse(w, http.StatusUpgradeRequired, app_errors.ErrorResponse{Code: app_errors.ErrUpgradeRequired})
+		return
+	}
+
+	if err != nil {
+		h.log.Error(req.Context(), "websocket upgrade failed", "error", err)
+		writeResponse(w, http.StatusInternalServerError, app_errors.ErrorResponse{Code: app_errors.ErrInternalError})
+		return
+	}
+
+	s := &socketSession{
+		h:    h,
+		conn: conn,
+		send: make(chan dto.ServerMessage, h.sendBuffer),
+		done: make(chan struct{}),
+		subs: map[uint32]*events.Subscription{},
+	}
+	s.user, s.authenticated = auth.UserFromContext(req.Context())
+
+	h.track(s)
+	defer h.untrack(s)
+
+	h.log.Debug(req.Context(), "websocket opened", "authenticated", s.authenticated)
+	s.run(req.Context())
+	h.log.Debug(req.Context(), "websocket closed", "code", s.closeCode)
+}
+
+// originAllowed returns true for handshakes without Origin header, sent by clients other than browsers, and
+// for browser pages of API host or of allowedOrigins. Browsers let pages open WebSocket to any site, so pages of
+// other sites could trade over connections of their visitors once credentials are sent by browser.
+func (h *SocketHandler) originAllowed(req *http.Request) bool {
+	origin := req.Header.Get("Origin")
+	if origin == "" {
+		return true
+	}
+
+	for _, allowed := range h.allowedOrigins {
+		if strings.EqualFold(origin, allowed) {
+			return true
+		}
+	}
+
+	u, err := url.Parse(origin)
+	return err == nil && strings.EqualFold(u.Host, req.Host)
+}
+
+// Shutdown closes connections with going away code and rejects new ones, then waits for connections to end.
+// Hijacked connections are not tracked by http.Server, so it has to be called on server shutdown.
+func (h *SocketHandler) Shutdown(ctx context.Context) error {
+	h.mu.Lock()
+	h.closing = true
+	for s := range h.sessions {
+		s.end(websocket.CloseGoingAway, "server shutting down")
+	}
+	h.mu.Unlock()
+
+	done := make(chan struct{})
+	go func() {
+		h.wg.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func (h *SocketHandler) track(s *socketSession) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	h.sessions[s] = struct{}{}
+	// shutdown started while connection was being upgraded
+	if h.closing {
+		s.end(websocket.CloseGoingAway, "server shutting down")
+	}
+}
+
+func (h *SocketHandler) untrack(s *socketSession) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	delete(h.sessions, s)
+}
+
+// socketSession is single WebSocket connection. Its reader goroutine handles client messages and owns subscriptions,
+// writer goroutine writes queued messages and pings, forwarder goroutine of each subscription queues its events.
+type socketSession struct {
+	h             *SocketHandler
+	conn          *websocket.Conn
+	user          auth.User
+	authenticated bool
+
+	// send is bounded queue of messages to client. Reading of client messages pauses while it is full,
+	// subscriptions lagging behind are dropped by broker and connection is closed then.
+	send chan dto.ServerMessage
+	// done is closed when session ends, closeCode and closeReason are set before
+	done        chan struct{}
+	endOnce     sync.Once
+	closeCode   int
+	closeReason string
+	// deadlineMu orders extending read deadline with shortening it on end
+	deadlineMu sync.Mutex
+
+	subs       map[uint32]*events.Subscription
+	forwarders sync.WaitGroup
+}
+
+// run serves connection until client closes it or session ends
+func (s *socketSession) run(ctx context.Context) {
+	s.conn.SetReadLimit(socketMaxMessageSize)
+	s.conn.SetPongHandler(s.extendReadDeadline)
+	s.extendReadDeadline()
+
+	writerDone := make(chan struct{})
+	go func() {
+		defer close(writerDone)
+		s.writeLoop(ctx)
+	}()
+
+	s.readLoop(ctx)
+	s.end(websocket.CloseNormal, "")
+	<-writerDone
+
+	for _, sub := range s.subs {
+		sub.Close()
+	}
+
+	s.forwarders.Wait()
+	s.conn.Close()
+}
+
+// readLoop handles client messages until connection fails or closing handshake completes
+func (s *socketSession) readLoop(ctx context.Context) {
+	for {
+		_, data, err := s.conn.ReadMessage()
+		if err != nil {
+			var closeErr *websocket.CloseError
+			if errors.As(err, &closeErr) {
+				s.end(closeErr.Code, "")
+				return
+			}
+
+			s.h.log.Debug(ctx, "websocket read failed", "error", err)
+			return
+		}
+
+		s.extendReadDeadline()
+		if !s.handle(ctx, data) {
+			return
+		}
+	}
+}
+
+// writeLoop writes queued messages and pings until session ends, then it starts closing handshake
+func (s *socketSession) writeLoop(ctx context.Context) {
+	ping := time.NewTicker(s.h.pingInterval)
+	defer ping.Stop()
+
+	for {
+		var err error
+		select {
+		case msg := <-s.send:
+			var b []byte
+			if b, err = json.Marshal(msg); err == nil {
+				_ = s.conn.SetWriteDeadline(time.Now().Add(socketWriteTimeout))
+				err = s.conn.WriteMessage(websocket.TextMessage, b)
+			}
+		case <-ping.C:
+			_ = s.conn.SetWriteDeadline(time.Now().Add(socketWriteTimeout))
+			err = s.conn.WritePing()
+		case <-s.done:
+			_ = s.conn.SetWriteDeadline(time.Now().Add(socketWriteTimeout))
+			_ = s.conn.WriteClose(s.closeCode, s.closeReason)
+			return
+		}
+
+		if err != nil && err != websocket.ErrCloseSent {
+			s.h.log.Debug(ctx, "websocket write failed", "error", err)
+			s.end(websocket.CloseInternalError, "")
+			return
+		}
+	}
+}
+
+// handle executes client message, it returns false when session ended meanwhile
+func (s *socketSession) handle(ctx context.Context, data []byte) bool {
+	var msg dto.ClientMessage
+	if errRes := decodeStrict(bytes.NewReader(data), &msg); errRes != nil {
+		return s.replyError(ctx, msg.ID, errRes)
+	}
+
+	if errRes := validation.Struct(&msg).Err(); errRes != nil {
+		return s.replyError(ctx, msg.ID, errRes)
+	}
+
+	switch msg.Type {
+	case dto.MessageSubscribe:
+		return s.subscribe(ctx, msg)
+	case dto.MessageUnsubscribe:
+		if sub, ok := s.subs[msg.WagerID]; ok {
+			sub.Close()
+			delete(s.subs, msg.WagerID)
+		}
+
+		return s.enqueue(dto.ServerMessage{Type: dto.MessageAck, ID: msg.ID})
+	}
+
+	return s.buy(ctx, msg)
+}
+
+// subscribe subscribes to events of wager, or of all wagers for wager id 0, replaying events after last event id
+func (s *socketSession) subscribe(ctx context.Context, msg dto.ClientMessage) bool {
+	if _, ok := s.subs[msg.WagerID]; ok {
+		return s.enqueue(dto.ServerMessage{Type: dto.MessageAck, ID: msg.ID})
+	}
+
+	if msg.WagerID > 0 {
+		_, err := s.h.wagerService.GetWager(ctx, &dto.GetWagerRequest{WagerID: msg.WagerID, Limit: 1})
+		if err != nil {
+			return s.replyError(ctx, msg.ID, err)
+		}
+	}
+
+	sub, replay, err := s.h.broker.Subscribe(msg.LastEventID, msg.WagerID)
+	if err == events.ErrClosed {
+		return s.replyError(ctx, msg.ID,
+			&app_errors.ErrorResponse{Status: http.StatusServiceUnavailable, Code: app_errors.ErrUnavailable})
+	}
+
+	if err != nil {
+		return s.replyError(ctx, msg.ID, err)
+	}
+
+	s.subs[msg.WagerID] = sub
+	s.forwarders.Add(1)
+	// replay is queued before forwarder starts, so events reach client in order
+	ok := s.enqueue(dto.ServerMessage{Type: dto.MessageAck, ID: msg.ID})
+	for i := 0; ok && i < len(replay); i++ {
+		ok = s.enqueue(eventMessage(replay[i]))
+	}
+
+	go s.forward(sub)
+	return ok
+}
+
+// forward queues events of subscription until it is closed
+func (s *socketSession) forward(sub *events.Subscription) {
+	defer s.forwarders.Done()
+
+	for event := range sub.Events() {
+		if !s.enqueue(eventMessage(event)) {
+			return
+		}
+	}
+
+	switch sub.Err() {
+	case events.ErrDropped:
+		s.end(websocket.ClosePolicyViolation, "too slow, resubscribe with last_event_id")
+	case events.ErrClosed:
+		s.end(websocket.CloseGoingAway, "server shutting down")
+	}
+}
+
+// buy buys wager for authenticated user
+func (s *socketSession) buy(ctx context.Context, msg dto.ClientMessage) bool {
+	if !s.authenticated {
+		return s.replyError(ctx, msg.ID,
+			&app_errors.ErrorResponse{Status: http.StatusUnauthorized, Code: app_errors.ErrUnauthorized})
+	}
+
+	purchase, err := s.h.purchaseService.PurchaseWager(ctx, &dto.BuyWagerRequest{
+		WagerID:     msg.WagerID,
+		BuyerID:     s.user.ID,
+		BuyingPrice: msg.BuyingPrice,
+		Quantity:    msg.Quantity,
+	})
+	if err != nil {
+		return s.replyError(ctx, msg.ID, err)
+	}
+
+	return s.enqueue(dto.ServerMessage{Type: dto.MessageAck, ID: msg.ID, Purchase: purchase})
+}
+
+// replyError queues error message of client message with id, errors other than app errors are reported as internal
+func (s *socketSession) replyError(ctx context.Context, id string, err error) bool {
+	errRes, ok := err.(*app_errors.ErrorResponse)
+	if !ok {
+		s.h.log.Error(ctx, "handle websocket message failed", "error", err)
+		errRes = &app_errors.ErrorResponse{Code: app_errors.ErrInternalError}
+	}
+
+	return s.enqueue(dto.ServerMessage{Type: dto.MessageError, ID: id, Error: errRes.Code, Details: errRes.Details})
+}
+
+// enqueue queues message for client, blocking while queue is full. It returns false when session ended.
+func (s *socketSession) enqueue(msg dto.ServerMessage) bool {
+	select {
+	case s.send <- msg:
+		return true
+	case <-s.done:
+		return false
+	}
+}
+
+// end ends session with close code and reason, only first call has effect.
+// Client has socketCloseTimeout to answer close frame.
+func (s *socketSession) end(code int, reason string) {
+	s.endOnce.Do(func() {
+		s.deadlineMu.Lock()
+		defer s.deadlineMu.Unlock()
+
+		s.closeCode = code
+		s.closeReason = reason
+		close(s.done)
+		_ = s.conn.SetReadDeadline(time.Now().Add(socketCloseTimeout))
+	})
+}
+
+// extendReadDeadline gives client two ping intervals to send next frame, clients answer every ping with pong
+func (s *socketSession) extendReadDeadline() {
+	s.deadlineMu.Lock()
+	defer s.deadlineMu.Unlock()
+
+	select {
+	case <-s.done:
+	default:
+		_ = s.conn.SetReadDeadline(time.Now().Add(2 * s.h.pingInterval))
+	}
+}
+
+// eventMessage returns message of wager event
+func eventMessage(event events.Event) dto.ServerMessage {
+	msg := dto.ServerMessage{Type: dto.MessageEvent, Event: event.Type, EventID: event.ID}
+	if event.Type != events.TypeReset {
+		wager := event.Wager
+		msg.Wager = &wager
+	}
+
+	return msg
+}
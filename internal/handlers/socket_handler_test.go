@@ -0,0 +1,277 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	"github.com/vitthalaa/wager-app/app_errors"
+	"github.com/vitthalaa/wager-app/dto"
+	"github.com/vitthalaa/wager-app/internal/events"
+	"github.com/vitthalaa/wager-app/internal/logger"
+	"github.com/vitthalaa/wager-app/internal/websocket"
+	"github.com/vitthalaa/wager-app/money"
+)
+
+func TestSocketHandler_Subscribe(t *testing.T) {
+	broker := events.NewBroker(10)
+	earlier := broker.Publish(events.TypeWagerCreated, dto.Wager{ID: 2})
+
+	mockWagerService := new(MockWagerService)
+	mockWagerService.On("GetWager", mock.Anything, &dto.GetWagerRequest{WagerID: 2, Limit: 1}).
+		Return(&dto.WagerDetails{}, nil)
+
+	handler := NewSocketHandler(mockWagerService, new(MockPurchaseService), broker, 10, time.Hour, nil, logger.Discard())
+	conn := dialSocket(t, newSocketServer(t, handler, false))
+
+	sendMessage(t, conn, dto.ClientMessage{Type: dto.MessageSubscribe, ID: "all"})
+	assert.Equal(t, dto.ServerMessage{Type: dto.MessageAck, ID: "all"}, readMessage(t, conn))
+
+	// resumed subscription replays events after last event id
+	sendMessage(t, conn, dto.ClientMessage{Type: dto.MessageSubscribe, ID: "one", WagerID: 2, LastEventID: earlier.ID - 1})
+	assert.Equal(t, dto.ServerMessage{Type: dto.MessageAck, ID: "one"}, readMessage(t, conn))
+	assert.Equal(t, eventMessage(earlier), readMessage(t, conn))
+
+	created := broker.Publish(events.TypeWagerCreated, dto.Wager{ID: 1})
+	assert.Equal(t, eventMessage(created), readMessage(t, conn))
+
+	sendMessage(t, conn, dto.ClientMessage{Type: dto.MessageUnsubscribe, ID: "off"})
+	assert.Equal(t, dto.ServerMessage{Type: dto.MessageAck, ID: "off"}, readMessage(t, conn))
+
+	broker.Publish(events.TypeWagerCreated, dto.Wager{ID: 3})
+	changed := broker.Publish(events.TypePriceChanged, dto.Wager{ID: 2, CurrentSellingPrice: money.MustParse("5")})
+	assert.Equal(t, eventMessage(changed), readMessage(t, conn))
+}
+
+func TestSocketHandler_Messages(t *testing.T) {
+	purchase := &dto.WagerPurchase{ID: 7, WagerID: 1, BuyingPrice: money.MustParse("10")}
+	for _, tc := range []struct {
+		name          string
+		message       string
+		authenticated bool
+		mock          func(wagerService *MockWagerService, purchaseService *MockPurchaseService)
+
+		expected dto.ServerMessage
+	}{
+		{
+			name:          "buy",
+			message:       `{"type":"buy","id":"b1","wager_id":1,"buying_price":"10.00"}`,
+			authenticated: true,
+			mock: func(wagerService *MockWagerService, purchaseService *MockPurchaseService) {
+				purchaseService.On("PurchaseWager", mock.Anything, &dto.BuyWagerRequest{
+					WagerID: 1, BuyerID: testUser.ID, BuyingPrice: money.MustParse("10"),
+				}).Return(purchase, nil)
+			},
+			expected: dto.ServerMessage{Type: dto.MessageAck, ID: "b1", Purchase: purchase},
+		},
+		{
+			name:          "buy sold out wager",
+			message:       `{"type":"buy","id":"b2","wager_id":1,"buying_price":"10.00"}`,
+			authenticated: true,
+			mock: func(wagerService *MockWagerService, purchaseService *MockPurchaseService) {
+				purchaseService.On("PurchaseWager", mock.Anything, mock.Anything).
+					Return(nil, &app_errors.ErrorResponse{Status: http.StatusNotAcceptable, Code: app_errors.ErrWagerSoldOut})
+			},
+			expected: dto.ServerMessage{Type: dto.MessageError, ID: "b2", Error: app_errors.ErrWagerSoldOut},
+		},
+		{
+			name:     "buy anonymously",
+			message:  `{"type":"buy","id":"b3","wager_id":1,"buying_price":"10.00"}`,
+			expected: dto.ServerMessage{Type: dto.MessageError, ID: "b3", Error: app_errors.ErrUnauthorized},
+		},
+		{
+			name:    "subscribe to unknown wager",
+			message: `{"type":"subscribe","id":"s1","wager_id":5}`,
+			mock: func(wagerService *MockWagerService, purchaseService *MockPurchaseService) {
+				wagerService.On("GetWager", mock.Anything, &dto.GetWagerRequest{WagerID: 5, Limit: 1}).
+					Return(nil, &app_errors.ErrorResponse{Status: http.StatusNotFound, Code: app_errors.ErrNotFound})
+			},
+			expected: dto.ServerMessage{Type: dto.MessageError, ID: "s1", Error: app_errors.ErrNotFound},
+		},
+		{
+			name:     "malformed JSON",
+			message:  `{"type":`,
+			expected: dto.ServerMessage{Type: dto.MessageError, Error: app_errors.ErrInvalidBody},
+		},
+		{
+			name:    "unknown field",
+			message: `{"id":"u1","type":"subscribe","wager":1}`,
+			expected: dto.ServerMessage{
+				Type: dto.MessageError, ID: "u1", Error: app_errors.ErrInvalidBody,
+				Details: []app_errors.FieldError{{Field: "wager", Rule: "unknown", Message: "is not allowed"}},
+			},
+		},
+		{
+			name:    "unknown type",
+			message: `{"type":"sell","id":"x1"}`,
+			expected: dto.ServerMessage{
+				Type: dto.MessageError, ID: "x1", Error: app_errors.ErrInvalidMessageType,
+				Details: []app_errors.FieldError{{
+					Field: "type", Rule: "oneof", Message: "must be one of subscribe, unsubscribe, buy",
+					Limits: map[string]string{"oneof": "subscribe unsubscribe buy"},
+				}},
+			},
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			mockWagerService := new(MockWagerService)
+			mockPurchaseService := new(MockPurchaseService)
+			if tc.mock != nil {
+				tc.mock(mockWagerService, mockPurchaseService)
+			}
+
+			handler := NewSocketHandler(
+				mockWagerService, mockPurchaseService, events.NewBroker(1), 10, time.Hour, nil, logger.Discard())
+			conn := dialSocket(t, newSocketServer(t, handler, tc.authenticated))
+
+			require.Nil(t, conn.WriteMessage(websocket.TextMessage, []byte(tc.message)))
+			assert.Equal(t, tc.expected, readMessage(t, conn))
+			mockWagerService.AssertExpectations(t)
+			mockPurchaseService.AssertExpectations(t)
+		})
+	}
+}
+
+func TestSocketHandler_ClosesSlowClient(t *testing.T) {
+	broker := events.NewBroker(1)
+	handler := NewSocketHandler(new(MockWagerService), new(MockPurchaseService), broker, 1, time.Hour, nil, logger.Discard())
+	conn := dialSocket(t, newSocketServer(t, handler, false))
+
+	sendMessage(t, conn, dto.ClientMessage{Type: dto.MessageSubscribe, ID: "all"})
+	assert.Equal(t, dto.ServerMessage{Type: dto.MessageAck, ID: "all"}, readMessage(t, conn))
+
+	// client does not read until connection buffers, send queue and subscription are full
+	for i := 0; i < 100000; i++ {
+		broker.Publish(events.TypePriceChanged, dto.Wager{ID: 1})
+	}
+
+	assert.Equal(t, websocket.ClosePolicyViolation, readClose(t, conn))
+}
+
+func TestSocketHandler_Shutdown(t *testing.T) {
+	handler := NewSocketHandler(
+		new(MockWagerService), new(MockPurchaseService), events.NewBroker(1), 10, time.Hour, nil, logger.Discard())
+	url := newSocketServer(t, handler, false)
+	conn := dialSocket(t, url)
+
+	shutdown := make(chan error)
+	go func() {
+		shutdown <- handler.Shutdown(context.Background())
+	}()
+
+	assert.Equal(t, websocket.CloseGoingAway, readClose(t, conn))
+	require.Nil(t, <-shutdown)
+
+	_, res, err := websocket.Dial(context.Background(), url, nil)
+	assert.Equal(t, websocket.ErrBadHandshake, err)
+	assert.Equal(t, http.StatusServiceUnavailable, res.StatusCode)
+}
+
+func TestSocketHandler_NotWebSocket(t *testing.T) {
+	handler := NewSocketHandler(
+		new(MockWagerService), new(MockPurchaseService), events.NewBroker(1), 10, time.Hour, nil, logger.Discard())
+
+	resRecorder := httptest.NewRecorder()
+	handler.Handle(resRecorder, httptest.NewRequest("GET", "/ws", nil))
+
+	require.Equal(t, http.StatusUpgradeRequired, resRecorder.Code)
+	assert.Equal(t, "websocket", resRecorder.Header().Get("Upgrade"))
+	assert.JSONEq(t, `{"error":"UPGRADE_REQUIRED"}`, resRecorder.Body.String())
+}
+
+func TestSocketHandler_Origin(t *testing.T) {
+	for _, tc := range []struct {
+		name   string
+		origin string
+
+		expectedCode int
+	}{
+		{name: "no origin", expectedCode: http.StatusUpgradeRequired},
+		{name: "same host", origin: "http://example.com", expectedCode: http.StatusUpgradeRequired},
+		{name: "allowed origin", origin: "https://APP.example.org", expectedCode: http.StatusUpgradeRequired},
+		{name: "other origin", origin: "https://evil.example.net", expectedCode: http.StatusForbidden},
+		{name: "other port", origin: "http://example.com:8080", expectedCode: http.StatusForbidden},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			handler := NewSocketHandler(new(MockWagerService), new(MockPurchaseService), events.NewBroker(1), 10, time.Hour,
+				[]string{"https://app.example.org"}, logger.Discard())
+
+			// request is not handshake, so that allowed origins get upgrade required
+			request := httptest.NewRequest("GET", "http://example.com/ws", nil)
+			if tc.origin != "" {
+				request.Header.Set("Origin", tc.origin)
+			}
+
+			resRecorder := httptest.NewRecorder()
+			handler.Handle(resRecorder, request)
+
+			require.Equal(t, tc.expectedCode, resRecorder.Code)
+			if tc.expectedCode == http.StatusForbidden {
+				assert.JSONEq(t, `{"error":"ORIGIN_NOT_ALLOWED"}`, resRecorder.Body.String())
+			}
+		})
+	}
+}
+
+// newSocketServer serves handler, requests are of test user when authenticated. It returns ws:// url of /ws.
+func newSocketServer(t *testing.T, handler *SocketHandler, authenticated bool) string {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		if authenticated {
+			req = withUser(req)
+		}
+
+		handler.Handle(w, req)
+	}))
+	t.Cleanup(server.Close)
+	// connections are hijacked, server does not close them
+	t.Cleanup(func() { _ = handler.Shutdown(context.Background()) })
+
+	return "ws" + strings.TrimPrefix(server.URL, "http") + "/ws"
+}
+
+func dialSocket(t *testing.T, url string) *websocket.Conn {
+	conn, _, err := websocket.Dial(context.Background(), url, nil)
+	require.Nil(t, err)
+	t.Cleanup(func() { conn.Close() })
+
+	return conn
+}
+
+func sendMessage(t *testing.T, conn *websocket.Conn, msg dto.ClientMessage) {
+	b, err := json.Marshal(msg)
+	require.Nil(t, err)
+	require.Nil(t, conn.WriteMessage(websocket.TextMessage, b))
+}
+
+func readMessage(t *testing.T, conn *websocket.Conn) dto.ServerMessage {
+	require.Nil(t, conn.SetReadDeadline(time.Now().Add(5*time.Second)))
+	_, data, err := conn.ReadMessage()
+	require.Nil(t, err)
+
+	var msg dto.ServerMessage
+	require.Nil(t, json.Unmarshal(data, &msg))
+	return msg
+}
+
+// readClose reads messages until server closes connection and returns close code
+func readClose(t *testing.T, conn *websocket.Conn) int {
+	require.Nil(t, conn.SetReadDeadline(time.Now().Add(10*time.Second)))
+	for {
+		_, _, err := conn.ReadMessage()
+		if err == nil {
+			continue
+		}
+
+		closeErr, ok := err.(*websocket.CloseError)
+		require.True(t, ok, "expected close, got %v", err)
+		return closeErr.Code
+	}
+}
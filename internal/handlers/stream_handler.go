@@ -0,0 +1,135 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/vitthalaa/wager-app/app_errors"
+	"github.com/vitthalaa/wager-app/internal/events"
+	"github.com/vitthalaa/wager-app/internal/logger"
+)
+
+// lastEventIDHeader is sent by EventSource on reconnect with id of last received event
+const lastEventIDHeader = "Last-Event-ID"
+
+// WagerStreamHandler streams wager events as Server-Sent Events
+type WagerStreamHandler struct {
+	broker    *events.Broker
+	heartbeat time.Duration
+	log       *logger.Logger
+}
+
+// NewWagerStreamHandler returns handler of streams writing heartbeat comment every heartbeat interval,
+// so that proxies do not close idle streams
+func NewWagerStreamHandler(broker *events.Broker, heartbeat time.Duration, log *logger.Logger) *WagerStreamHandler {
+	return &WagerStreamHandler{
+		broker:    broker,
+		heartbeat: heartbeat,
+		log:       log,
+	}
+}
+
+// serve streams events of wager, or of all wagers when wagerID is 0, until client disconnects or broker is closed.
+// Events after Last-Event-ID header, or last_event_id query param of clients not able to set headers, are replayed first.
+func (h *WagerStreamHandler) serve(w http.ResponseWriter, req *http.Request, wagerID uint32) error {
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		return errors.New("response writer does not support flushing")
+	}
+
+	sub, replay, err := h.broker.Subscribe(lastEventID(req), wagerID)
+	if err == events.ErrClosed {
+		writeResponse(w, http.StatusServiceUnavailable, app_errors.ErrorResponse{Code: app_errors.ErrUnavailable})
+		return nil
+	}
+
+	if err != nil {
+		return err
+	}
+
+	defer sub.Close()
+
+	h.log.Debug(req.Context(), "wager stream opened", "wager_id", wagerID, "replayed", len(replay))
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("X-Accel-Buffering", "no")
+	w.WriteHeader(http.StatusOK)
+
+	for _, event := range replay {
+		if err := writeEvent(w, event); err != nil {
+			return nil
+		}
+	}
+
+	flusher.Flush()
+
+	heartbeat := time.NewTicker(h.heartbeat)
+	defer heartbeat.Stop()
+
+	for {
+		select {
+		case <-req.Context().Done():
+			h.log.Debug(req.Context(), "wager stream closed by client", "wager_id", wagerID)
+			return nil
+		case event, ok := <-sub.Events():
+			if !ok {
+				// dropped as too slow or server is shutting down, client reconnects with Last-Event-ID
+				h.log.Debug(req.Context(), "wager stream ended", "wager_id", wagerID)
+				return nil
+			}
+
+			if err := writeEvent(w, event); err != nil {
+				return nil
+			}
+		case <-heartbeat.C:
+			if _, err := fmt.Fprint(w, ": heartbeat\n\n"); err != nil {
+				return nil
+			}
+		}
+
+		flusher.Flush()
+	}
+}
+
+// lastEventID returns id of last event received by client, 0 for new clients.
+// Malformed ids are returned as unknown id, so client gets reset event.
+func lastEventID(req *http.Request) uint64 {
+	v := strings.TrimSpace(req.Header.Get(lastEventIDHeader))
+	if v == "" {
+		v = strings.TrimSpace(req.URL.Query().Get("last_event_id"))
+	}
+
+	if v == "" {
+		return 0
+	}
+
+	id, err := strconv.ParseUint(v, 10, 64)
+	if err != nil {
+		return math.MaxUint64
+	}
+
+	return id
+}
+
+// writeEvent writes event frame with wager as JSON data, reset event has empty object as data
+func writeEvent(w http.ResponseWriter, event events.Event) error {
+	var data interface{} = event.Wager
+	if event.Type == events.TypeReset {
+		data = struct{}{}
+	}
+
+	b, err := json.Marshal(data)
+	if err != nil {
+		return err
+	}
+
+	_, err = fmt.Fprintf(w, "id: %d\nevent: %s\ndata: %s\n\n", event.ID, event.Type, b)
+	return err
+}
@@ -0,0 +1,230 @@
+package handlers
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	"github.com/vitthalaa/wager-app/app_errors"
+	"github.com/vitthalaa/wager-app/dto"
+	"github.com/vitthalaa/wager-app/internal/events"
+	"github.com/vitthalaa/wager-app/internal/logger"
+	"github.com/vitthalaa/wager-app/money"
+)
+
+func TestWagersHandler_Handle_Stream_Replay(t *testing.T) {
+	broker := events.NewBroker(10)
+	first := broker.Publish(events.TypeWagerCreated, dto.Wager{ID: 1, CurrentSellingPrice: money.MustParse("10")})
+	second := broker.Publish(events.TypeWagerCreated, dto.Wager{ID: 2, CurrentSellingPrice: money.MustParse("20")})
+	third := broker.Publish(events.TypePriceChanged, dto.Wager{ID: 1, CurrentSellingPrice: money.MustParse("5")})
+
+	for _, tc := range []struct {
+		name        string
+		url         string
+		lastEventID string
+
+		expectedBody string
+	}{
+		{
+			name: "new client",
+			url:  "/wagers/stream",
+		},
+		{
+			name:         "events after Last-Event-ID",
+			url:          "/wagers/stream",
+			lastEventID:  fmt.Sprint(first.ID),
+			expectedBody: frame(second) + frame(third),
+		},
+		{
+			name:         "events after last_event_id query param",
+			url:          fmt.Sprintf("/wagers/stream?last_event_id=%d", second.ID),
+			expectedBody: frame(third),
+		},
+		{
+			name:         "events of wager",
+			url:          "/wagers/1/stream",
+			lastEventID:  fmt.Sprint(first.ID),
+			expectedBody: frame(third),
+		},
+		{
+			name:         "malformed Last-Event-ID",
+			url:          "/wagers/stream",
+			lastEventID:  "abc",
+			expectedBody: fmt.Sprintf("id: %d\nevent: reset\ndata: {}\n\n", third.ID),
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			// canceled request ends stream once replay is written
+			ctx, cancel := context.WithCancel(context.Background())
+			cancel()
+
+			request := httptest.NewRequest("GET", tc.url, nil).WithContext(ctx)
+			if tc.lastEventID != "" {
+				request.Header.Set("Last-Event-ID", tc.lastEventID)
+			}
+
+			mockWagerService := new(MockWagerService)
+			mockWagerService.On("GetWager", mock.Anything, &dto.GetWagerRequest{WagerID: 1, Limit: 1}).
+				Return(&dto.WagerDetails{}, nil).Maybe()
+
+			resRecorder := httptest.NewRecorder()
+			handler := NewWagersHandler(mockWagerService, new(MockSettlementService), new(MockAuctionService), nil, logger.Discard(),
+				NewWagerStreamHandler(broker, time.Hour, logger.Discard()))
+			handler.Handle(resRecorder, request)
+
+			require.Equal(t, http.StatusOK, resRecorder.Code)
+			assert.Equal(t, "text/event-stream", resRecorder.Header().Get("Content-Type"))
+			assert.Equal(t, tc.expectedBody, resRecorder.Body.String())
+		})
+	}
+}
+
+func TestWagersHandler_Handle_Stream_Live(t *testing.T) {
+	broker := events.NewBroker(10)
+	mockWagerService := new(MockWagerService)
+	mockWagerService.On("GetWager", mock.Anything, &dto.GetWagerRequest{WagerID: 2, Limit: 1}).
+		Return(&dto.WagerDetails{}, nil)
+
+	handler := NewWagersHandler(mockWagerService, new(MockSettlementService), new(MockAuctionService), nil, logger.Discard(),
+		NewWagerStreamHandler(broker, time.Hour, logger.Discard()))
+	server := httptest.NewServer(http.HandlerFunc(handler.Handle))
+	t.Cleanup(server.Close)
+
+	all := openStream(t, server.URL+"/wagers/stream")
+	one := openStream(t, server.URL+"/wagers/2/stream")
+
+	created := broker.Publish(events.TypeWagerCreated, dto.Wager{ID: 1})
+	changed := broker.Publish(events.TypePriceChanged, dto.Wager{ID: 2, CurrentSellingPrice: money.MustParse("1.50")})
+	soldOut := broker.Publish(events.TypeSoldOut, dto.Wager{ID: 2, Status: "sold_out"})
+
+	assert.Equal(t, frame(created), readFrame(t, all))
+	assert.Equal(t, frame(changed), readFrame(t, all))
+	assert.Equal(t, frame(soldOut), readFrame(t, all))
+	assert.Equal(t, frame(changed), readFrame(t, one))
+	assert.Equal(t, frame(soldOut), readFrame(t, one))
+
+	broker.Close()
+	_, err := all.ReadString('\n')
+	assert.NotNil(t, err, "stream must end when broker is closed")
+}
+
+func TestWagersHandler_Handle_Stream_Heartbeat(t *testing.T) {
+	handler := NewWagersHandler(new(MockWagerService), new(MockSettlementService), new(MockAuctionService), nil, logger.Discard(),
+		NewWagerStreamHandler(events.NewBroker(1), 10*time.Millisecond, logger.Discard()))
+	server := httptest.NewServer(http.HandlerFunc(handler.Handle))
+	t.Cleanup(server.Close)
+
+	stream := openStream(t, server.URL+"/wagers/stream")
+	assert.Equal(t, ": heartbeat\n\n", readFrame(t, stream))
+}
+
+func TestWagersHandler_Handle_Stream_Errors(t *testing.T) {
+	closed := events.NewBroker(1)
+	closed.Close()
+
+	for _, tc := range []struct {
+		name   string
+		url    string
+		broker *events.Broker
+		stream bool
+		getErr error
+
+		expectedStatus int
+		expectedBody   string
+	}{
+		{
+			name:           "stream not enabled",
+			url:            "/wagers/stream",
+			expectedStatus: http.StatusNotFound,
+			expectedBody:   `{"error":"NOT_FOUND"}`,
+		},
+		{
+			name:           "invalid wager id",
+			url:            "/wagers/abc/stream",
+			broker:         events.NewBroker(1),
+			stream:         true,
+			expectedStatus: http.StatusNotFound,
+			expectedBody:   `{"error":"NOT_FOUND"}`,
+		},
+		{
+			name:           "unknown wager",
+			url:            "/wagers/3/stream",
+			broker:         events.NewBroker(1),
+			stream:         true,
+			getErr:         &app_errors.ErrorResponse{Status: http.StatusNotFound, Code: app_errors.ErrNotFound},
+			expectedStatus: http.StatusNotFound,
+			expectedBody:   `{"error":"NOT_FOUND"}`,
+		},
+		{
+			name:           "shutting down",
+			url:            "/wagers/stream",
+			broker:         closed,
+			stream:         true,
+			expectedStatus: http.StatusServiceUnavailable,
+			expectedBody:   `{"error":"SERVICE_UNAVAILABLE"}`,
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			request := httptest.NewRequest("GET", tc.url, nil)
+
+			mockWagerService := new(MockWagerService)
+			if tc.getErr != nil {
+				mockWagerService.On("GetWager", mock.Anything, mock.Anything).Return(nil, tc.getErr)
+			}
+
+			var stream *WagerStreamHandler
+			if tc.stream {
+				stream = NewWagerStreamHandler(tc.broker, time.Hour, logger.Discard())
+			}
+
+			resRecorder := httptest.NewRecorder()
+			handler := NewWagersHandler(mockWagerService, new(MockSettlementService), new(MockAuctionService), nil, logger.Discard(), stream)
+			handler.Handle(resRecorder, request)
+
+			require.Equal(t, tc.expectedStatus, resRecorder.Code)
+			assert.JSONEq(t, tc.expectedBody, resRecorder.Body.String())
+			mockWagerService.AssertExpectations(t)
+		})
+	}
+}
+
+// frame returns event stream frame of event
+func frame(event events.Event) string {
+	rec := httptest.NewRecorder()
+	_ = writeEvent(rec, event)
+	return rec.Body.String()
+}
+
+// openStream opens stream of url, subscription is registered once response headers are received
+func openStream(t *testing.T, url string) *bufio.Reader {
+	t.Helper()
+	res, err := http.Get(url)
+	require.Nil(t, err)
+	t.Cleanup(func() { res.Body.Close() })
+	require.Equal(t, http.StatusOK, res.StatusCode)
+
+	return bufio.NewReader(res.Body)
+}
+
+// readFrame reads lines of stream up to blank line ending a frame
+func readFrame(t *testing.T, stream *bufio.Reader) string {
+	t.Helper()
+	var b strings.Builder
+	for {
+		line, err := stream.ReadString('\n')
+		require.Nil(t, err)
+		b.WriteString(line)
+		if line == "\n" {
+			return b.String()
+		}
+	}
+}
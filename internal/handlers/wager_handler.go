@@ -3,57 +3,95 @@ package handlers
 import (
 	"encoding/json"
 	"fmt"
-	"log"
 	"net/http"
 	"strconv"
 	"strings"
+	"time"
 
 	"github.com/vitthalaa/wager-app/app_errors"
 	"github.com/vitthalaa/wager-app/dto"
+	"github.com/vitthalaa/wager-app/internal/logger"
 	"github.com/vitthalaa/wager-app/internal/services"
+	"github.com/vitthalaa/wager-app/money"
 )
 
 // WagersHandler is handler for all /wagers routes
 type WagersHandler struct {
-	wagerService services.IWagerService
+	wagerService       services.IWagerService
+	settlementService  services.ISettlementService
+	auctionService     services.IAuctionService
+	idempotencyService services.IIdempotencyService
+	log                *logger.Logger
+	stream             *WagerStreamHandler
 }
 
-// NewWagersHandler ...
-func NewWagersHandler(wagerService services.IWagerService) *WagersHandler {
+// NewWagersHandler returns handler of /wagers routes, stream routes are not found when stream is nil
+func NewWagersHandler(
+	wagerService services.IWagerService, settlementService services.ISettlementService,
+	auctionService services.IAuctionService, idempotencyService services.IIdempotencyService, log *logger.Logger,
+	stream *WagerStreamHandler,
+) *WagersHandler {
 	return &WagersHandler{
-		wagerService: wagerService,
+		wagerService:       wagerService,
+		settlementService:  settlementService,
+		auctionService:     auctionService,
+		idempotencyService: idempotencyService,
+		log:                log,
+		stream:             stream,
 	}
 }
 
 // Handle is method to handle requests to routes
 func (h *WagersHandler) Handle(w http.ResponseWriter, req *http.Request) {
 	var err error
-	switch req.Method {
-	case http.MethodPost:
-		err = h.doPlaceWager(w, req)
-	case http.MethodGet:
+	id, action := wagerPath(req)
+	switch {
+	case req.Method == http.MethodPost && id == "":
+		err = idempotent(w, req, h.idempotencyService, h.log, h.doPlaceWager)
+	case req.Method == http.MethodGet && id == "":
 		err = h.doListWager(w, req)
+	case req.Method == http.MethodGet && id == "stream" && action == "" && h.stream != nil:
+		err = h.stream.serve(w, req, 0)
+	case req.Method == http.MethodGet && action == "stream" && h.stream != nil:
+		err = h.doStreamWager(w, req, id)
+	case req.Method == http.MethodGet && action == "":
+		err = h.doGetWager(w, req, id)
+	case req.Method == http.MethodPost && action == "settle":
+		err = h.doSettleWager(w, req, id)
+	case req.Method == http.MethodGet && action == "settlement":
+		err = h.doGetSettlement(w, req, id)
+	case req.Method == http.MethodPost && action == "bids":
+		err = idempotent(w, req, h.idempotencyService, h.log, func(w http.ResponseWriter, req *http.Request) error {
+			return h.doPlaceBid(w, req, id)
+		})
+	case req.Method == http.MethodGet && action == "bids":
+		err = h.doListBids(w, req, id)
 	default:
-		log.Println("error no 404")
+		h.log.Debug(req.Context(), "route not found", "method", req.Method, "path", req.URL.Path)
 		writeResponse(w, http.StatusNotFound, app_errors.ErrorResponse{Code: app_errors.ErrNotFound})
 	}
 
 	if err != nil {
-		log.Println("error {}", err)
+		h.log.Error(req.Context(), "handle request failed", "error", err)
 		writeResponse(w, http.StatusInternalServerError, app_errors.ErrorResponse{Code: app_errors.ErrInternalError})
 	}
 }
 
 // doPlaceWager places wager
 func (h *WagersHandler) doPlaceWager(w http.ResponseWriter, req *http.Request) error {
-	decoder := json.NewDecoder(req.Body)
+	user, ok := requireUser(w, req)
+	if !ok {
+		return nil
+	}
+
 	var request dto.PlaceWagerRequest
-	err := decoder.Decode(&request)
-	if err != nil {
-		writeResponse(w, http.StatusBadRequest, &app_errors.ErrorResponse{Code: app_errors.ErrInvalidBody})
+	if errRes := decodeJSON(req, &request); errRes != nil {
+		writeResponse(w, errRes.Status, errRes)
 		return nil
 	}
 
+	request.SellerID = user.ID
+
 	wager, err := h.wagerService.PlaceWager(req.Context(), &request)
 	if err != nil {
 		writeErrorResponse(w, err)
@@ -64,55 +102,286 @@ func (h *WagersHandler) doPlaceWager(w http.ResponseWriter, req *http.Request) e
 	return nil
 }
 
-// doListWager list wager
+// doListWager lists page of wagers matching query filters
 func (h *WagersHandler) doListWager(w http.ResponseWriter, req *http.Request) error {
-	pageStr := strings.TrimSpace(req.URL.Query().Get("page"))
-	limitStr := strings.TrimSpace(req.URL.Query().Get("limit"))
-	page, limit := 0, 0
-	var err error
-	if pageStr != "" {
-		page, err = strconv.Atoi(pageStr)
+	request, err := listWagerRequest(req)
+	if err != nil {
+		h.log.Debug(req.Context(), "invalid wager filter", "error", err)
+		writeResponse(w, http.StatusBadRequest, &app_errors.ErrorResponse{Code: app_errors.ErrInvalidFilter})
+		return nil
 	}
 
-	if limitStr != "" {
-		limit, err = strconv.Atoi(limitStr)
+	wagerList, err := h.wagerService.ListWager(req.Context(), request)
+	if err != nil {
+		writeErrorResponse(w, err)
+		return nil
+	}
+
+	writeResponse(w, http.StatusOK, wagerList)
+	return nil
+}
+
+// doGetWager returns wager by id with page of its purchases
+func (h *WagersHandler) doGetWager(w http.ResponseWriter, req *http.Request, id string) error {
+	wagerID, ok := h.parseWagerID(w, req, id)
+	if !ok {
+		return nil
 	}
 
+	page, limit, err := pagination(req)
 	if err != nil {
-		log.Printf("get query params err %s", err)
+		h.log.Debug(req.Context(), "invalid pagination", "error", err)
+		writeResponse(w, http.StatusBadRequest, &app_errors.ErrorResponse{Code: app_errors.ErrInvalidFilter})
+		return nil
 	}
 
-	request := &dto.ListWagerRequest{
-		Page:  uint32(page),
-		Limit: uint32(limit),
+	request := &dto.GetWagerRequest{
+		WagerID: wagerID,
+		Page:    page,
+		Limit:   limit,
 	}
 
-	wagerList, err := h.wagerService.ListWager(req.Context(), request)
+	wager, err := h.wagerService.GetWager(req.Context(), request)
 	if err != nil {
 		writeErrorResponse(w, err)
 		return nil
 	}
 
-	writeResponse(w, http.StatusOK, wagerList)
+	writeResponse(w, http.StatusOK, wager)
+	return nil
+}
+
+// doStreamWager streams events of existing wager
+func (h *WagersHandler) doStreamWager(w http.ResponseWriter, req *http.Request, id string) error {
+	wagerID, ok := h.parseWagerID(w, req, id)
+	if !ok {
+		return nil
+	}
+
+	if _, err := h.wagerService.GetWager(req.Context(), &dto.GetWagerRequest{WagerID: wagerID, Limit: 1}); err != nil {
+		writeErrorResponse(w, err)
+		return nil
+	}
+
+	return h.stream.serve(w, req, wagerID)
+}
+
+// doSettleWager records wager outcome and settles its purchases
+func (h *WagersHandler) doSettleWager(w http.ResponseWriter, req *http.Request, id string) error {
+	user, ok := requireUser(w, req)
+	if !ok {
+		return nil
+	}
+
+	wagerID, ok := h.parseWagerID(w, req, id)
+	if !ok {
+		return nil
+	}
+
+	var request dto.SettleWagerRequest
+	if errRes := decodeJSON(req, &request); errRes != nil {
+		writeResponse(w, errRes.Status, errRes)
+		return nil
+	}
+
+	request.WagerID = wagerID
+	request.UserID = user.ID
+	request.Operator = user.IsOperator()
+
+	settlement, err := h.settlementService.SettleWager(req.Context(), &request)
+	if err != nil {
+		writeErrorResponse(w, err)
+		return nil
+	}
+
+	writeResponse(w, http.StatusOK, settlement)
+	return nil
+}
+
+// doGetSettlement returns settlement of wager
+func (h *WagersHandler) doGetSettlement(w http.ResponseWriter, req *http.Request, id string) error {
+	wagerID, ok := h.parseWagerID(w, req, id)
+	if !ok {
+		return nil
+	}
+
+	settlement, err := h.settlementService.GetSettlement(req.Context(), wagerID)
+	if err != nil {
+		writeErrorResponse(w, err)
+		return nil
+	}
+
+	writeResponse(w, http.StatusOK, settlement)
+	return nil
+}
+
+// doPlaceBid places sealed bid of user for units of auctioned wager
+func (h *WagersHandler) doPlaceBid(w http.ResponseWriter, req *http.Request, id string) error {
+	user, ok := requireUser(w, req)
+	if !ok {
+		return nil
+	}
+
+	wagerID, ok := h.parseWagerID(w, req, id)
+	if !ok {
+		return nil
+	}
+
+	var request dto.PlaceBidRequest
+	if errRes := decodeJSON(req, &request); errRes != nil {
+		writeResponse(w, errRes.Status, errRes)
+		return nil
+	}
+
+	request.UserID = user.ID
+	request.WagerID = wagerID
+
+	bid, err := h.auctionService.PlaceBid(req.Context(), &request)
+	if err != nil {
+		writeErrorResponse(w, err)
+		return nil
+	}
+
+	writeResponse(w, http.StatusOK, bid)
 	return nil
 }
 
+// doListBids returns bids of user for wager, bids of other users are never disclosed
+func (h *WagersHandler) doListBids(w http.ResponseWriter, req *http.Request, id string) error {
+	user, ok := requireUser(w, req)
+	if !ok {
+		return nil
+	}
+
+	wagerID, ok := h.parseWagerID(w, req, id)
+	if !ok {
+		return nil
+	}
+
+	bids, err := h.auctionService.ListBids(req.Context(), &dto.AuctionBidsRequest{UserID: user.ID, WagerID: wagerID})
+	if err != nil {
+		writeErrorResponse(w, err)
+		return nil
+	}
+
+	writeResponse(w, http.StatusOK, bids)
+	return nil
+}
+
+// listWagerRequest parses wager listing query, values that can not be parsed are rejected
+func listWagerRequest(req *http.Request) (*dto.ListWagerRequest, error) {
+	query := req.URL.Query()
+	request := &dto.ListWagerRequest{
+		Sort:   strings.TrimSpace(query.Get("sort")),
+		Cursor: strings.TrimSpace(query.Get("cursor")),
+	}
+
+	for param, dst := range map[string]*uint32{
+		"limit":    &request.Limit,
+		"min_odds": &request.MinOdds,
+		"max_odds": &request.MaxOdds,
+	} {
+		if v := strings.TrimSpace(query.Get(param)); v != "" {
+			n, err := strconv.ParseUint(v, 10, 32)
+			if err != nil {
+				return nil, err
+			}
+
+			*dst = uint32(n)
+		}
+	}
+
+	for param, dst := range map[string]*money.Money{
+		"min_price": &request.MinPrice,
+		"max_price": &request.MaxPrice,
+	} {
+		if v := strings.TrimSpace(query.Get(param)); v != "" {
+			price, err := money.Parse(v)
+			if err != nil {
+				return nil, err
+			}
+
+			*dst = price
+		}
+	}
+
+	if v := strings.TrimSpace(query.Get("sold_out")); v != "" {
+		soldOut, err := strconv.ParseBool(v)
+		if err != nil {
+			return nil, err
+		}
+
+		request.SoldOut = &soldOut
+	}
+
+	for param, dst := range map[string]**time.Time{
+		"from": &request.From,
+		"to":   &request.To,
+	} {
+		if v := strings.TrimSpace(query.Get(param)); v != "" {
+			t, err := time.Parse(time.RFC3339, v)
+			if err != nil {
+				return nil, err
+			}
+
+			*dst = &t
+		}
+	}
+
+	return request, nil
+}
+
+// wagerPath returns {id} and {action} parts of /wagers/{id}/{action} path, both empty for /wagers
+func wagerPath(req *http.Request) (id, action string) {
+	path := strings.Trim(strings.TrimPrefix(req.URL.Path, "/wagers"), "/")
+	id, action, _ = strings.Cut(path, "/")
+	return id, action
+}
+
+// parseWagerID parses wager id path param, writes not found response if it is invalid
+func (h *WagersHandler) parseWagerID(w http.ResponseWriter, req *http.Request, id string) (uint32, bool) {
+	wagerID, err := strconv.ParseUint(id, 10, 32)
+	if err != nil || wagerID < 1 {
+		h.log.Debug(req.Context(), "invalid wager id", "wager_id", id)
+		writeResponse(w, http.StatusNotFound, app_errors.ErrorResponse{Code: app_errors.ErrNotFound})
+		return 0, false
+	}
+
+	return uint32(wagerID), true
+}
+
+// pagination returns page and limit query params, values that can not be parsed are rejected
+func pagination(req *http.Request) (page, limit uint32, err error) {
+	for param, dst := range map[string]*uint32{
+		"page":  &page,
+		"limit": &limit,
+	} {
+		if v := strings.TrimSpace(req.URL.Query().Get(param)); v != "" {
+			n, err := strconv.ParseUint(v, 10, 32)
+			if err != nil {
+				return 0, 0, err
+			}
+
+			*dst = uint32(n)
+		}
+	}
+
+	return page, limit, nil
+}
+
+// writeResponse writes res as JSON body. Write errors mean client is gone, status of response
+// is logged by request middleware.
 func writeResponse(w http.ResponseWriter, status int, res interface{}) {
 	resBody, err := json.Marshal(res)
 	if err != nil {
-		log.Println("marshal response body error {}", err)
 		w.WriteHeader(http.StatusInternalServerError)
 		fmt.Fprint(w, "internal error")
+		return
 	}
 
 	w.Header().Set("Content-Type", "application/json")
 	w.WriteHeader(status)
-	_, err = w.Write(resBody)
-	if err != nil {
-		log.Println("write response body error {}", err)
-		w.WriteHeader(http.StatusInternalServerError)
-		fmt.Fprint(w, "internal error")
-	}
+	_, _ = w.Write(resBody)
 }
 
 func writeErrorResponse(w http.ResponseWriter, err error) {
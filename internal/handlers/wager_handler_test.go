@@ -12,7 +12,11 @@ import (
 	"github.com/stretchr/testify/mock"
 	"github.com/stretchr/testify/require"
 
+	"github.com/vitthalaa/wager-app/app_errors"
 	"github.com/vitthalaa/wager-app/dto"
+	"github.com/vitthalaa/wager-app/internal/auth"
+	"github.com/vitthalaa/wager-app/internal/logger"
+	"github.com/vitthalaa/wager-app/money"
 )
 
 func TestWagersHandler_Handle_PlaceWager_HappyPath(t *testing.T) {
@@ -20,8 +24,10 @@ func TestWagersHandler_Handle_PlaceWager_HappyPath(t *testing.T) {
 		TotalWagerValue:   1000,
 		Odds:              2,
 		SellingPercentage: 20,
-		SellingPrice:      201,
+		SellingPrice:      money.MustParse("201"),
 	}
+	expectedReq := placeWagerReq
+	expectedReq.SellerID = testUser.ID
 
 	now := time.Now()
 	placeWagerRes := &dto.Wager{
@@ -29,8 +35,8 @@ func TestWagersHandler_Handle_PlaceWager_HappyPath(t *testing.T) {
 		TotalWagerValue:     1000,
 		Odds:                2,
 		SellingPercentage:   20,
-		SellingPrice:        201,
-		CurrentSellingPrice: 201,
+		SellingPrice:        money.MustParse("201"),
+		CurrentSellingPrice: money.MustParse("201"),
 		PercentageSold:      0,
 		AmountSold:          0,
 		PlacedAt:            &now,
@@ -41,13 +47,14 @@ func TestWagersHandler_Handle_PlaceWager_HappyPath(t *testing.T) {
 
 	request, err := http.NewRequest("POST", "/wagers", bytes.NewReader(body))
 	require.Nil(t, err)
+	request = withUser(request)
 
 	mockWagerService := new(MockWagerService)
-	mockWagerService.On("PlaceWager", mock.Anything, &placeWagerReq).
+	mockWagerService.On("PlaceWager", mock.Anything, &expectedReq).
 		Return(placeWagerRes, nil)
 
 	resRecorder := httptest.NewRecorder()
-	handler := NewWagersHandler(mockWagerService)
+	handler := NewWagersHandler(mockWagerService, new(MockSettlementService), new(MockAuctionService), nil, logger.Discard(), nil)
 	handler.Handle(resRecorder, request)
 
 	expected, err := json.Marshal(placeWagerRes)
@@ -59,14 +66,14 @@ func TestWagersHandler_Handle_PlaceWager_HappyPath(t *testing.T) {
 
 func TestWagersHandler_Handle_ListWager_HappyPath(t *testing.T) {
 	now := time.Now()
-	wagerListResp := []dto.Wager{
+	wagerListResp := &dto.WagerList{Items: []dto.Wager{
 		{
 			ID:                  111,
 			TotalWagerValue:     1000,
 			Odds:                2,
 			SellingPercentage:   20,
-			SellingPrice:        201,
-			CurrentSellingPrice: 201,
+			SellingPrice:        money.MustParse("201"),
+			CurrentSellingPrice: money.MustParse("201"),
 			PercentageSold:      0,
 			AmountSold:          0,
 			PlacedAt:            &now,
@@ -76,20 +83,30 @@ func TestWagersHandler_Handle_ListWager_HappyPath(t *testing.T) {
 			TotalWagerValue:     100,
 			Odds:                2,
 			SellingPercentage:   20.5,
-			SellingPrice:        20.6,
-			CurrentSellingPrice: 40.6,
+			SellingPrice:        money.MustParse("20.6"),
+			CurrentSellingPrice: money.MustParse("40.6"),
 			PercentageSold:      50.1,
 			AmountSold:          50,
 			PlacedAt:            &now,
 		},
-	}
+	}, NextCursor: "eyJpZCI6MjIyfQ", HasMore: true}
 
-	request, err := http.NewRequest("GET", "http://domain.co/wagers?page=1&limit=20", nil)
+	request, err := http.NewRequest("GET", "http://domain.co/wagers?cursor=eyJpZCI6MzMzfQ&limit=20"+
+		"&min_odds=2&max_odds=5&min_price=10.5&max_price=300&sold_out=false&from=2021-01-01T00:00:00Z&sort=-odds", nil)
 	require.Nil(t, err)
 
+	soldOut := false
+	from := time.Date(2021, 1, 1, 0, 0, 0, 0, time.UTC)
 	expectedRequest := &dto.ListWagerRequest{
-		Page:  uint32(1),
-		Limit: uint32(20),
+		MinOdds:  2,
+		MaxOdds:  5,
+		MinPrice: money.MustParse("10.5"),
+		MaxPrice: money.MustParse("300"),
+		SoldOut:  &soldOut,
+		From:     &from,
+		Sort:     "-odds",
+		Cursor:   "eyJpZCI6MzMzfQ",
+		Limit:    20,
 	}
 
 	mockWagerService := new(MockWagerService)
@@ -97,7 +114,7 @@ func TestWagersHandler_Handle_ListWager_HappyPath(t *testing.T) {
 		Return(wagerListResp, nil)
 
 	resRecorder := httptest.NewRecorder()
-	handler := NewWagersHandler(mockWagerService)
+	handler := NewWagersHandler(mockWagerService, new(MockSettlementService), new(MockAuctionService), nil, logger.Discard(), nil)
 	handler.Handle(resRecorder, request)
 
 	expected, err := json.Marshal(wagerListResp)
@@ -106,3 +123,428 @@ func TestWagersHandler_Handle_ListWager_HappyPath(t *testing.T) {
 	require.Equal(t, http.StatusOK, resRecorder.Code)
 	assert.Equal(t, string(expected), resRecorder.Body.String())
 }
+
+func TestWagersHandler_Handle_ListWager_InvalidFilter(t *testing.T) {
+	for _, tc := range []struct {
+		name string
+		url  string
+	}{
+		{
+			name: "invalid limit",
+			url:  "http://domain.co/wagers?limit=ten",
+		},
+		{
+			name: "negative odds",
+			url:  "http://domain.co/wagers?min_odds=-1",
+		},
+		{
+			name: "invalid price",
+			url:  "http://domain.co/wagers?max_price=cheap",
+		},
+		{
+			name: "invalid sold out",
+			url:  "http://domain.co/wagers?sold_out=maybe",
+		},
+		{
+			name: "invalid time",
+			url:  "http://domain.co/wagers?from=yesterday",
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			request, err := http.NewRequest("GET", tc.url, nil)
+			require.Nil(t, err)
+
+			resRecorder := httptest.NewRecorder()
+			handler := NewWagersHandler(new(MockWagerService), new(MockSettlementService), new(MockAuctionService), nil, logger.Discard(), nil)
+			handler.Handle(resRecorder, request)
+
+			require.Equal(t, http.StatusBadRequest, resRecorder.Code)
+			assert.JSONEq(t, `{"error":"INVALID_FILTER"}`, resRecorder.Body.String())
+		})
+	}
+}
+
+func TestWagersHandler_Handle_GetWager_HappyPath(t *testing.T) {
+	now := time.Now()
+	wagerResp := &dto.WagerDetails{
+		Wager: dto.Wager{
+			ID:                  111,
+			TotalWagerValue:     100,
+			Odds:                2,
+			SellingPercentage:   20,
+			SellingPrice:        money.MustParse("21"),
+			CurrentSellingPrice: money.MustParse("20.5"),
+			PercentageSold:      1,
+			AmountSold:          1,
+			PlacedAt:            &now,
+		},
+		Purchases: dto.WagerPurchaseList{
+			Items: []dto.WagerPurchase{
+				{
+					ID:          1,
+					WagerID:     111,
+					BuyingPrice: money.MustParse("20.5"),
+					BoughtAt:    &now,
+				},
+			},
+			Page:  2,
+			Limit: 5,
+			Total: 6,
+		},
+	}
+
+	request, err := http.NewRequest("GET", "http://domain.co/wagers/111?page=2&limit=5", nil)
+	require.Nil(t, err)
+
+	expectedRequest := &dto.GetWagerRequest{
+		WagerID: 111,
+		Page:    2,
+		Limit:   5,
+	}
+
+	mockWagerService := new(MockWagerService)
+	mockWagerService.On("GetWager", mock.Anything, expectedRequest).
+		Return(wagerResp, nil)
+
+	resRecorder := httptest.NewRecorder()
+	handler := NewWagersHandler(mockWagerService, new(MockSettlementService), new(MockAuctionService), nil, logger.Discard(), nil)
+	handler.Handle(resRecorder, request)
+
+	expected, err := json.Marshal(wagerResp)
+	require.Nil(t, err)
+
+	require.Equal(t, http.StatusOK, resRecorder.Code)
+	assert.Equal(t, string(expected), resRecorder.Body.String())
+}
+
+func TestWagersHandler_Handle_GetWager_InvalidPagination(t *testing.T) {
+	for _, url := range []string{
+		"http://domain.co/wagers/111?page=two",
+		"http://domain.co/wagers/111?limit=99999999999",
+	} {
+		t.Run(url, func(t *testing.T) {
+			request, err := http.NewRequest("GET", url, nil)
+			require.Nil(t, err)
+
+			mockWagerService := new(MockWagerService)
+
+			resRecorder := httptest.NewRecorder()
+			handler := NewWagersHandler(mockWagerService, new(MockSettlementService), new(MockAuctionService), nil, logger.Discard(), nil)
+			handler.Handle(resRecorder, request)
+
+			require.Equal(t, http.StatusBadRequest, resRecorder.Code)
+			assert.JSONEq(t, `{"error":"INVALID_FILTER"}`, resRecorder.Body.String())
+			mockWagerService.AssertExpectations(t)
+		})
+	}
+}
+
+func TestWagersHandler_Handle_GetWager_NotFound(t *testing.T) {
+	for _, tc := range []struct {
+		name   string
+		method string
+		url    string
+	}{
+		{
+			name:   "invalid id",
+			method: "GET",
+			url:    "http://domain.co/wagers/abc",
+		},
+		{
+			name:   "zero id",
+			method: "GET",
+			url:    "http://domain.co/wagers/0",
+		},
+		{
+			name:   "post to wager id",
+			method: "POST",
+			url:    "http://domain.co/wagers/111",
+		},
+		{
+			name:   "unknown wager action",
+			method: "POST",
+			url:    "http://domain.co/wagers/111/cancel",
+		},
+		{
+			name:   "get settle action",
+			method: "GET",
+			url:    "http://domain.co/wagers/111/settle",
+		},
+		{
+			name:   "settle invalid id",
+			method: "POST",
+			url:    "http://domain.co/wagers/abc/settle",
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			request, err := http.NewRequest(tc.method, tc.url, nil)
+			require.Nil(t, err)
+			request = withUser(request)
+
+			mockWagerService := new(MockWagerService)
+
+			resRecorder := httptest.NewRecorder()
+			handler := NewWagersHandler(mockWagerService, new(MockSettlementService), new(MockAuctionService), nil, logger.Discard(), nil)
+			handler.Handle(resRecorder, request)
+
+			require.Equal(t, http.StatusNotFound, resRecorder.Code)
+			assert.JSONEq(t, `{"error":"NOT_FOUND"}`, resRecorder.Body.String())
+			mockWagerService.AssertExpectations(t)
+		})
+	}
+}
+
+func TestWagersHandler_Handle_SettleWager_HappyPath(t *testing.T) {
+	now := time.Now()
+	settlementResp := &dto.WagerSettlement{
+		WagerID:     111,
+		Status:      "settled",
+		Outcome:     "won",
+		SettledAt:   &now,
+		TotalPayout: money.MustParse("2"),
+		Settlements: []dto.PurchaseSettlement{
+			{ID: 1, PurchaseID: 5, Outcome: "won", Payout: money.MustParse("2")},
+		},
+	}
+
+	request, err := http.NewRequest("POST", "http://domain.co/wagers/111/settle", bytes.NewReader([]byte(`{"outcome":"won"}`)))
+	require.Nil(t, err)
+	request = withUser(request)
+
+	expectedRequest := &dto.SettleWagerRequest{
+		WagerID: 111,
+		UserID:  testUser.ID,
+		Outcome: "won",
+	}
+
+	mockSettlementService := new(MockSettlementService)
+	mockSettlementService.On("SettleWager", mock.Anything, expectedRequest).
+		Return(settlementResp, nil)
+
+	resRecorder := httptest.NewRecorder()
+	handler := NewWagersHandler(new(MockWagerService), mockSettlementService, new(MockAuctionService), nil, logger.Discard(), nil)
+	handler.Handle(resRecorder, request)
+
+	expected, err := json.Marshal(settlementResp)
+	require.Nil(t, err)
+
+	require.Equal(t, http.StatusOK, resRecorder.Code)
+	assert.Equal(t, string(expected), resRecorder.Body.String())
+}
+
+func TestWagersHandler_Handle_SettleWager_Forbidden(t *testing.T) {
+	for _, tc := range []struct {
+		name string
+		user auth.User
+		// serviceError is error of service, settlement is returned when nil
+		serviceError error
+
+		expectedCode int
+		expectedBody string
+	}{
+		{
+			name:         "not operator",
+			user:         testUser,
+			serviceError: &app_errors.ErrorResponse{Status: http.StatusForbidden, Code: app_errors.ErrNotOperator},
+			expectedCode: http.StatusForbidden,
+			expectedBody: `{"error":"NOT_OPERATOR"}`,
+		},
+		{
+			name:         "operator",
+			user:         auth.User{ID: 8, Username: "bob", Role: auth.RoleOperator},
+			expectedCode: http.StatusOK,
+			expectedBody: `{"wager_id":111,"status":"settled","outcome":"won","settled_at":null,"total_payout":"0.00","settlements":[]}`,
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			request, err := http.NewRequest("POST", "http://domain.co/wagers/111/settle", bytes.NewReader([]byte(`{"outcome":"won"}`)))
+			require.Nil(t, err)
+			request = request.WithContext(auth.WithUser(request.Context(), tc.user))
+
+			expectedRequest := &dto.SettleWagerRequest{
+				WagerID:  111,
+				UserID:   tc.user.ID,
+				Operator: tc.user.IsOperator(),
+				Outcome:  "won",
+			}
+
+			var settlement *dto.WagerSettlement
+			if tc.serviceError == nil {
+				settlement = &dto.WagerSettlement{WagerID: 111, Status: "settled", Outcome: "won", Settlements: []dto.PurchaseSettlement{}}
+			}
+
+			mockSettlementService := new(MockSettlementService)
+			mockSettlementService.On("SettleWager", mock.Anything, expectedRequest).
+				Return(settlement, tc.serviceError)
+
+			resRecorder := httptest.NewRecorder()
+			handler := NewWagersHandler(new(MockWagerService), mockSettlementService, new(MockAuctionService), nil, logger.Discard(), nil)
+			handler.Handle(resRecorder, request)
+
+			require.Equal(t, tc.expectedCode, resRecorder.Code)
+			assert.JSONEq(t, tc.expectedBody, resRecorder.Body.String())
+			mockSettlementService.AssertExpectations(t)
+		})
+	}
+}
+
+func TestWagersHandler_Handle_SettleWager_InvalidBody(t *testing.T) {
+	request, err := http.NewRequest("POST", "http://domain.co/wagers/111/settle", bytes.NewReader([]byte(`{"outcome":`)))
+	require.Nil(t, err)
+	request = withUser(request)
+
+	mockSettlementService := new(MockSettlementService)
+
+	resRecorder := httptest.NewRecorder()
+	handler := NewWagersHandler(new(MockWagerService), mockSettlementService, new(MockAuctionService), nil, logger.Discard(), nil)
+	handler.Handle(resRecorder, request)
+
+	require.Equal(t, http.StatusBadRequest, resRecorder.Code)
+	assert.JSONEq(t, `{"error":"INVALID_BODY"}`, resRecorder.Body.String())
+	mockSettlementService.AssertExpectations(t)
+}
+
+func TestWagersHandler_Handle_GetSettlement_HappyPath(t *testing.T) {
+	settlementResp := &dto.WagerSettlement{
+		WagerID:     111,
+		Status:      "voided",
+		Outcome:     "void",
+		TotalPayout: money.MustParse("20"),
+		Settlements: []dto.PurchaseSettlement{
+			{ID: 1, PurchaseID: 5, Outcome: "void", Payout: money.MustParse("20")},
+		},
+	}
+
+	request, err := http.NewRequest("GET", "http://domain.co/wagers/111/settlement", nil)
+	require.Nil(t, err)
+
+	mockSettlementService := new(MockSettlementService)
+	mockSettlementService.On("GetSettlement", mock.Anything, uint32(111)).
+		Return(settlementResp, nil)
+
+	resRecorder := httptest.NewRecorder()
+	handler := NewWagersHandler(new(MockWagerService), mockSettlementService, new(MockAuctionService), nil, logger.Discard(), nil)
+	handler.Handle(resRecorder, request)
+
+	expected, err := json.Marshal(settlementResp)
+	require.Nil(t, err)
+
+	require.Equal(t, http.StatusOK, resRecorder.Code)
+	assert.Equal(t, string(expected), resRecorder.Body.String())
+}
+
+func TestWagersHandler_Handle_PlaceBid(t *testing.T) {
+	now := time.Now()
+	bidRes := &dto.AuctionBid{ID: 1, WagerID: 111, Price: money.MustParse("30"), Quantity: 2, PlacedAt: &now}
+
+	for _, tc := range []struct {
+		name string
+		url  string
+		body string
+		// expectedReq is expected request of service, service is not called when nil
+		expectedReq  *dto.PlaceBidRequest
+		serviceError error
+
+		expectedCode int
+		expectedBody string
+	}{
+		{
+			name:         "happy path",
+			url:          "http://domain.co/wagers/111/bids",
+			body:         `{"price":30,"quantity":2}`,
+			expectedReq:  &dto.PlaceBidRequest{UserID: testUser.ID, WagerID: 111, Price: money.MustParse("30"), Quantity: 2},
+			expectedCode: http.StatusOK,
+		},
+		{
+			name:         "auction closed",
+			url:          "http://domain.co/wagers/111/bids",
+			body:         `{"price":30,"quantity":2}`,
+			expectedReq:  &dto.PlaceBidRequest{UserID: testUser.ID, WagerID: 111, Price: money.MustParse("30"), Quantity: 2},
+			serviceError: &app_errors.ErrorResponse{Status: http.StatusConflict, Code: app_errors.ErrAuctionClosed},
+			expectedCode: http.StatusConflict,
+			expectedBody: `{"error":"AUCTION_CLOSED"}`,
+		},
+		{
+			name:         "invalid body",
+			url:          "http://domain.co/wagers/111/bids",
+			body:         `{"price":`,
+			expectedCode: http.StatusBadRequest,
+			expectedBody: `{"error":"INVALID_BODY"}`,
+		},
+		{
+			name:         "invalid wager id",
+			url:          "http://domain.co/wagers/abc/bids",
+			body:         `{"price":30,"quantity":2}`,
+			expectedCode: http.StatusNotFound,
+			expectedBody: `{"error":"NOT_FOUND"}`,
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			request, err := http.NewRequest("POST", tc.url, bytes.NewReader([]byte(tc.body)))
+			require.Nil(t, err)
+			request = withUser(request)
+
+			mockAuctionService := new(MockAuctionService)
+			if tc.expectedReq != nil {
+				var res *dto.AuctionBid
+				if tc.serviceError == nil {
+					res = bidRes
+				}
+
+				mockAuctionService.On("PlaceBid", mock.Anything, tc.expectedReq).Return(res, tc.serviceError)
+			}
+
+			resRecorder := httptest.NewRecorder()
+			handler := NewWagersHandler(new(MockWagerService), new(MockSettlementService), mockAuctionService, nil, logger.Discard(), nil)
+			handler.Handle(resRecorder, request)
+
+			expectedBody := tc.expectedBody
+			if expectedBody == "" {
+				expected, err := json.Marshal(bidRes)
+				require.Nil(t, err)
+				expectedBody = string(expected)
+			}
+
+			require.Equal(t, tc.expectedCode, resRecorder.Code)
+			assert.JSONEq(t, expectedBody, resRecorder.Body.String())
+			mockAuctionService.AssertExpectations(t)
+		})
+	}
+}
+
+func TestWagersHandler_Handle_ListBids(t *testing.T) {
+	bids := []dto.AuctionBid{{ID: 1, WagerID: 111, Price: money.MustParse("30"), Quantity: 2, Awarded: 1}}
+
+	request, err := http.NewRequest("GET", "http://domain.co/wagers/111/bids", nil)
+	require.Nil(t, err)
+	request = withUser(request)
+
+	mockAuctionService := new(MockAuctionService)
+	mockAuctionService.On("ListBids", mock.Anything, &dto.AuctionBidsRequest{UserID: testUser.ID, WagerID: 111}).
+		Return(bids, nil)
+
+	resRecorder := httptest.NewRecorder()
+	handler := NewWagersHandler(new(MockWagerService), new(MockSettlementService), mockAuctionService, nil, logger.Discard(), nil)
+	handler.Handle(resRecorder, request)
+
+	expected, err := json.Marshal(bids)
+	require.Nil(t, err)
+
+	require.Equal(t, http.StatusOK, resRecorder.Code)
+	assert.Equal(t, string(expected), resRecorder.Body.String())
+}
+
+func TestWagersHandler_Handle_ListBids_Unauthorized(t *testing.T) {
+	request, err := http.NewRequest("GET", "http://domain.co/wagers/111/bids", nil)
+	require.Nil(t, err)
+
+	mockAuctionService := new(MockAuctionService)
+
+	resRecorder := httptest.NewRecorder()
+	handler := NewWagersHandler(new(MockWagerService), new(MockSettlementService), mockAuctionService, nil, logger.Discard(), nil)
+	handler.Handle(resRecorder, request)
+
+	require.Equal(t, http.StatusUnauthorized, resRecorder.Code)
+	assert.JSONEq(t, `{"error":"UNAUTHORIZED"}`, resRecorder.Body.String())
+	mockAuctionService.AssertExpectations(t)
+}
@@ -0,0 +1,142 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/vitthalaa/wager-app/app_errors"
+	"github.com/vitthalaa/wager-app/dto"
+	"github.com/vitthalaa/wager-app/internal/logger"
+	"github.com/vitthalaa/wager-app/internal/services"
+)
+
+// WalletHandler is handler for all /wallet routes, wallet is of authenticated user
+type WalletHandler struct {
+	walletService services.IWalletService
+	log           *logger.Logger
+}
+
+// NewWalletHandler ...
+func NewWalletHandler(walletService services.IWalletService, log *logger.Logger) *WalletHandler {
+	return &WalletHandler{
+		walletService: walletService,
+		log:           log,
+	}
+}
+
+// Handle is method to handle requests to routes
+func (h *WalletHandler) Handle(w http.ResponseWriter, req *http.Request) {
+	var err error
+	switch {
+	case req.Method == http.MethodGet && req.URL.Path == "/wallet":
+		err = h.doGetWallet(w, req)
+	case req.Method == http.MethodPost && req.URL.Path == "/wallet/deposit":
+		err = h.doDeposit(w, req)
+	case req.Method == http.MethodPost && req.URL.Path == "/wallet/withdraw":
+		err = h.doWithdraw(w, req)
+	case req.Method == http.MethodGet && req.URL.Path == "/wallet/entries":
+		err = h.doListEntries(w, req)
+	default:
+		h.log.Debug(req.Context(), "route not found", "method", req.Method, "path", req.URL.Path)
+		writeResponse(w, http.StatusNotFound, app_errors.ErrorResponse{Code: app_errors.ErrNotFound})
+	}
+
+	if err != nil {
+		h.log.Error(req.Context(), "handle request failed", "error", err)
+		writeResponse(w, http.StatusInternalServerError, app_errors.ErrorResponse{Code: app_errors.ErrInternalError})
+	}
+}
+
+// doGetWallet returns wallet balance
+func (h *WalletHandler) doGetWallet(w http.ResponseWriter, req *http.Request) error {
+	user, ok := requireUser(w, req)
+	if !ok {
+		return nil
+	}
+
+	res, err := h.walletService.GetWallet(req.Context(), user.ID)
+	if err != nil {
+		writeErrorResponse(w, err)
+		return nil
+	}
+
+	writeResponse(w, http.StatusOK, res)
+	return nil
+}
+
+// doDeposit credits amount to wallet
+func (h *WalletHandler) doDeposit(w http.ResponseWriter, req *http.Request) error {
+	user, ok := requireUser(w, req)
+	if !ok {
+		return nil
+	}
+
+	var request dto.DepositRequest
+	if errRes := decodeJSON(req, &request); errRes != nil {
+		writeResponse(w, errRes.Status, errRes)
+		return nil
+	}
+
+	request.UserID = user.ID
+
+	res, err := h.walletService.Deposit(req.Context(), &request)
+	if err != nil {
+		writeErrorResponse(w, err)
+		return nil
+	}
+
+	writeResponse(w, http.StatusOK, res)
+	return nil
+}
+
+// doWithdraw debits amount from wallet
+func (h *WalletHandler) doWithdraw(w http.ResponseWriter, req *http.Request) error {
+	user, ok := requireUser(w, req)
+	if !ok {
+		return nil
+	}
+
+	var request dto.WithdrawRequest
+	if errRes := decodeJSON(req, &request); errRes != nil {
+		writeResponse(w, errRes.Status, errRes)
+		return nil
+	}
+
+	request.UserID = user.ID
+
+	res, err := h.walletService.Withdraw(req.Context(), &request)
+	if err != nil {
+		writeErrorResponse(w, err)
+		return nil
+	}
+
+	writeResponse(w, http.StatusOK, res)
+	return nil
+}
+
+// doListEntries returns page of wallet ledger entries
+func (h *WalletHandler) doListEntries(w http.ResponseWriter, req *http.Request) error {
+	user, ok := requireUser(w, req)
+	if !ok {
+		return nil
+	}
+
+	page, limit, err := pagination(req)
+	if err != nil {
+		h.log.Debug(req.Context(), "invalid pagination", "error", err)
+		writeResponse(w, http.StatusBadRequest, &app_errors.ErrorResponse{Code: app_errors.ErrInvalidFilter})
+		return nil
+	}
+
+	res, err := h.walletService.ListLedgerEntries(req.Context(), &dto.ListLedgerEntriesRequest{
+		UserID: user.ID,
+		Page:   page,
+		Limit:  limit,
+	})
+	if err != nil {
+		writeErrorResponse(w, err)
+		return nil
+	}
+
+	writeResponse(w, http.StatusOK, res)
+	return nil
+}
@@ -0,0 +1,158 @@
+package handlers
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	"github.com/vitthalaa/wager-app/app_errors"
+	"github.com/vitthalaa/wager-app/dto"
+	"github.com/vitthalaa/wager-app/internal/logger"
+	"github.com/vitthalaa/wager-app/money"
+)
+
+func TestWalletHandler_Handle_GetWallet_HappyPath(t *testing.T) {
+	now := time.Now()
+	walletResp := &dto.Wallet{Balance: money.MustParse("12.5"), UpdatedAt: &now}
+
+	request, err := http.NewRequest("GET", "http://domain.co/wallet", nil)
+	require.Nil(t, err)
+	request = withUser(request)
+
+	mockWalletService := new(MockWalletService)
+	mockWalletService.On("GetWallet", mock.Anything, testUser.ID).
+		Return(walletResp, nil)
+
+	resRecorder := httptest.NewRecorder()
+	handler := NewWalletHandler(mockWalletService, logger.Discard())
+	handler.Handle(resRecorder, request)
+
+	expected, err := json.Marshal(walletResp)
+	require.Nil(t, err)
+
+	require.Equal(t, http.StatusOK, resRecorder.Code)
+	assert.Equal(t, string(expected), resRecorder.Body.String())
+}
+
+func TestWalletHandler_Handle_DepositWithdraw(t *testing.T) {
+	for _, tc := range []struct {
+		name string
+		path string
+		body string
+
+		serviceError error
+
+		expectedCode int
+		expectedBody interface{}
+	}{
+		{
+			name:         "deposit",
+			path:         "/wallet/deposit",
+			body:         `{"amount":"10.50"}`,
+			expectedCode: http.StatusOK,
+			expectedBody: &dto.Wallet{Balance: money.MustParse("10.5")},
+		},
+		{
+			name:         "withdraw",
+			path:         "/wallet/withdraw",
+			body:         `{"amount":"10.50"}`,
+			expectedCode: http.StatusOK,
+			expectedBody: &dto.Wallet{Balance: money.MustParse("10.5")},
+		},
+		{
+			name:         "withdraw insufficient funds",
+			path:         "/wallet/withdraw",
+			body:         `{"amount":"10.50"}`,
+			serviceError: &app_errors.ErrorResponse{Status: http.StatusPaymentRequired, Code: app_errors.ErrInsufficientFunds},
+			expectedCode: http.StatusPaymentRequired,
+			expectedBody: app_errors.ErrorResponse{Code: app_errors.ErrInsufficientFunds},
+		},
+		{
+			name:         "invalid amount",
+			path:         "/wallet/deposit",
+			body:         `{"amount":"10.505"}`,
+			expectedCode: http.StatusBadRequest,
+			expectedBody: app_errors.ErrorResponse{Code: app_errors.ErrInvalidBody},
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			request, err := http.NewRequest("POST", "http://domain.co"+tc.path, bytes.NewReader([]byte(tc.body)))
+			require.Nil(t, err)
+			request = withUser(request)
+
+			var serviceResp *dto.Wallet
+			if tc.serviceError == nil {
+				serviceResp = &dto.Wallet{Balance: money.MustParse("10.5")}
+			}
+
+			mockWalletService := new(MockWalletService)
+			mockWalletService.On("Deposit", mock.Anything, &dto.DepositRequest{UserID: testUser.ID, Amount: money.MustParse("10.5")}).
+				Return(serviceResp, tc.serviceError)
+			mockWalletService.On("Withdraw", mock.Anything, &dto.WithdrawRequest{UserID: testUser.ID, Amount: money.MustParse("10.5")}).
+				Return(serviceResp, tc.serviceError)
+
+			resRecorder := httptest.NewRecorder()
+			handler := NewWalletHandler(mockWalletService, logger.Discard())
+			handler.Handle(resRecorder, request)
+
+			expected, err := json.Marshal(tc.expectedBody)
+			require.Nil(t, err)
+
+			require.Equal(t, tc.expectedCode, resRecorder.Code)
+			assert.JSONEq(t, string(expected), resRecorder.Body.String())
+		})
+	}
+}
+
+func TestWalletHandler_Handle_ListEntries_HappyPath(t *testing.T) {
+	now := time.Now()
+	entriesResp := &dto.LedgerEntryList{
+		Items: []dto.LedgerEntry{
+			{ID: 3, TransactionID: 2, Kind: "purchase", PurchaseID: 7, Amount: money.MustParse("-20"), CreatedAt: &now},
+		},
+		Page:  2,
+		Limit: 5,
+		Total: 6,
+	}
+
+	request, err := http.NewRequest("GET", "http://domain.co/wallet/entries?page=2&limit=5", nil)
+	require.Nil(t, err)
+	request = withUser(request)
+
+	mockWalletService := new(MockWalletService)
+	mockWalletService.On("ListLedgerEntries", mock.Anything, &dto.ListLedgerEntriesRequest{UserID: testUser.ID, Page: 2, Limit: 5}).
+		Return(entriesResp, nil)
+
+	resRecorder := httptest.NewRecorder()
+	handler := NewWalletHandler(mockWalletService, logger.Discard())
+	handler.Handle(resRecorder, request)
+
+	expected, err := json.Marshal(entriesResp)
+	require.Nil(t, err)
+
+	require.Equal(t, http.StatusOK, resRecorder.Code)
+	assert.Equal(t, string(expected), resRecorder.Body.String())
+}
+
+func TestWalletHandler_Handle_ListEntries_InvalidPagination(t *testing.T) {
+	request, err := http.NewRequest("GET", "http://domain.co/wallet/entries?limit=all", nil)
+	require.Nil(t, err)
+	request = withUser(request)
+
+	mockWalletService := new(MockWalletService)
+
+	resRecorder := httptest.NewRecorder()
+	handler := NewWalletHandler(mockWalletService, logger.Discard())
+	handler.Handle(resRecorder, request)
+
+	require.Equal(t, http.StatusBadRequest, resRecorder.Code)
+	assert.JSONEq(t, `{"error":"INVALID_FILTER"}`, resRecorder.Body.String())
+	mockWalletService.AssertExpectations(t)
+}
@@ -0,0 +1,84 @@
+// Package httpwriter wraps response writers, so that middlewares observe responses written by handlers.
+package httpwriter
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"net"
+	"net/http"
+)
+
+// StatusWriter writes response to wrapped writer and keeps its status code, recorder keeps copy of body too.
+// Flush and Hijack are passed to wrapped writer, so that event streams and WebSocket upgrades pass through.
+type StatusWriter struct {
+	http.ResponseWriter
+	status int
+	record bool
+	body   bytes.Buffer
+}
+
+// New returns writer keeping status code of response written to w
+func New(w http.ResponseWriter) *StatusWriter {
+	return &StatusWriter{ResponseWriter: w}
+}
+
+// NewRecorder returns writer keeping status code and body of response written to w
+func NewRecorder(w http.ResponseWriter) *StatusWriter {
+	return &StatusWriter{ResponseWriter: w, record: true}
+}
+
+// Status returns status code of response, 200 when handler did not write any
+func (w *StatusWriter) Status() int {
+	if w.status == 0 {
+		return http.StatusOK
+	}
+
+	return w.status
+}
+
+// Body returns body written so far, empty unless writer is recorder
+func (w *StatusWriter) Body() []byte {
+	return w.body.Bytes()
+}
+
+func (w *StatusWriter) WriteHeader(status int) {
+	if w.status == 0 {
+		w.status = status
+	}
+
+	w.ResponseWriter.WriteHeader(status)
+}
+
+func (w *StatusWriter) Write(b []byte) (int, error) {
+	if w.status == 0 {
+		w.status = http.StatusOK
+	}
+
+	if w.record {
+		w.body.Write(b)
+	}
+
+	return w.ResponseWriter.Write(b)
+}
+
+// Flush sends buffered data to client
+func (w *StatusWriter) Flush() {
+	if flusher, ok := w.ResponseWriter.(http.Flusher); ok {
+		flusher.Flush()
+	}
+}
+
+// Hijack takes over connection upgraded to WebSocket, response is recorded as switching protocols
+func (w *StatusWriter) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	hijacker, ok := w.ResponseWriter.(http.Hijacker)
+	if !ok {
+		return nil, nil, errors.New("response writer does not support hijacking")
+	}
+
+	if w.status == 0 {
+		w.status = http.StatusSwitchingProtocols
+	}
+
+	return hijacker.Hijack()
+}
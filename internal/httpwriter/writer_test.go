@@ -0,0 +1,80 @@
+package httpwriter
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestStatusWriter(t *testing.T) {
+	for _, tc := range []struct {
+		name   string
+		record bool
+		write  func(w http.ResponseWriter)
+
+		expectedStatus int
+		expectedBody   string
+	}{
+		{
+			name:           "nothing written",
+			write:          func(w http.ResponseWriter) {},
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name: "first status is kept",
+			write: func(w http.ResponseWriter) {
+				w.WriteHeader(http.StatusConflict)
+				w.WriteHeader(http.StatusOK)
+			},
+			expectedStatus: http.StatusConflict,
+		},
+		{
+			name: "body without status",
+			write: func(w http.ResponseWriter) {
+				_, _ = w.Write([]byte(`{"id":1}`))
+			},
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name:   "recorder keeps body",
+			record: true,
+			write: func(w http.ResponseWriter) {
+				w.WriteHeader(http.StatusCreated)
+				_, _ = w.Write([]byte(`{"id":`))
+				_, _ = w.Write([]byte(`1}`))
+			},
+			expectedStatus: http.StatusCreated,
+			expectedBody:   `{"id":1}`,
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			rr := httptest.NewRecorder()
+			sw := New(rr)
+			if tc.record {
+				sw = NewRecorder(rr)
+			}
+
+			tc.write(sw)
+
+			assert.Equal(t, tc.expectedStatus, sw.Status())
+			assert.Equal(t, tc.expectedBody, string(sw.Body()))
+			if tc.expectedStatus != http.StatusOK || tc.expectedBody != "" {
+				assert.Equal(t, tc.expectedStatus, rr.Code)
+			}
+		})
+	}
+}
+
+func TestStatusWriter_FlushHijack(t *testing.T) {
+	rr := httptest.NewRecorder()
+	sw := New(rr)
+
+	sw.Flush()
+	assert.True(t, rr.Flushed)
+
+	// recorder of httptest can not be hijacked
+	_, _, err := sw.Hijack()
+	assert.NotNil(t, err)
+}
@@ -0,0 +1,197 @@
+// Package logger writes leveled structured log lines, each line of request carries its request id.
+package logger
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Level is severity of log line, lines below logger level are dropped
+type Level int8
+
+// Supported levels
+const (
+	LevelDebug Level = iota
+	LevelInfo
+	LevelWarn
+	LevelError
+)
+
+// Supported output formats
+const (
+	FormatJSON = "json"
+	FormatText = "text"
+)
+
+var levelNames = map[Level]string{
+	LevelDebug: "debug",
+	LevelInfo:  "info",
+	LevelWarn:  "warn",
+	LevelError: "error",
+}
+
+// String returns lower case level name
+func (l Level) String() string {
+	return levelNames[l]
+}
+
+// ParseLevel returns level by its case-insensitive name
+func ParseLevel(name string) (Level, error) {
+	for level, levelName := range levelNames {
+		if strings.EqualFold(strings.TrimSpace(name), levelName) {
+			return level, nil
+		}
+	}
+
+	return LevelInfo, fmt.Errorf("unknown log level %q", name)
+}
+
+// New returns logger writing lines of level and above to out in format, json or text
+func New(out io.Writer, level Level, format string) *Logger {
+	return &Logger{
+		out:   out,
+		mu:    &sync.Mutex{},
+		level: level,
+		text:  format == FormatText,
+		now:   time.Now,
+	}
+}
+
+// Discard returns logger dropping all lines
+func Discard() *Logger {
+	return New(io.Discard, LevelError+1, FormatJSON)
+}
+
+// Logger writes one line per call. Key-value pairs passed to logging methods and With are
+// appended to line as fields, keys must be strings.
+type Logger struct {
+	out    io.Writer
+	mu     *sync.Mutex
+	level  Level
+	text   bool
+	fields []interface{}
+	now    func() time.Time
+}
+
+// With returns child logger adding key-value pairs to every line
+func (l *Logger) With(keyvals ...interface{}) *Logger {
+	child := *l
+	child.fields = append(append([]interface{}{}, l.fields...), keyvals...)
+	return &child
+}
+
+// Debug ...
+func (l *Logger) Debug(ctx context.Context, msg string, keyvals ...interface{}) {
+	l.log(ctx, LevelDebug, msg, keyvals)
+}
+
+// Info ...
+func (l *Logger) Info(ctx context.Context, msg string, keyvals ...interface{}) {
+	l.log(ctx, LevelInfo, msg, keyvals)
+}
+
+// Warn ...
+func (l *Logger) Warn(ctx context.Context, msg string, keyvals ...interface{}) {
+	l.log(ctx, LevelWarn, msg, keyvals)
+}
+
+// Error ...
+func (l *Logger) Error(ctx context.Context, msg string, keyvals ...interface{}) {
+	l.log(ctx, LevelError, msg, keyvals)
+}
+
+func (l *Logger) log(ctx context.Context, level Level, msg string, keyvals []interface{}) {
+	if level < l.level {
+		return
+	}
+
+	fields := []interface{}{
+		"time", l.now().UTC().Format(time.RFC3339Nano),
+		"level", level.String(),
+		"msg", msg,
+	}
+
+	if requestID := RequestID(ctx); requestID != "" {
+		fields = append(fields, "request_id", requestID)
+	}
+
+	fields = append(append(fields, l.fields...), keyvals...)
+
+	var line []byte
+	if l.text {
+		line = formatText(fields)
+	} else {
+		line = formatJSON(fields)
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	_, _ = l.out.Write(line)
+}
+
+// formatJSON returns fields as JSON object line keeping their order
+func formatJSON(fields []interface{}) []byte {
+	var buf bytes.Buffer
+	buf.WriteByte('{')
+	for i := 0; i < len(fields); i += 2 {
+		if i > 0 {
+			buf.WriteByte(',')
+		}
+
+		key, _ := json.Marshal(fmt.Sprint(fields[i]))
+		buf.Write(key)
+		buf.WriteByte(':')
+		buf.Write(jsonValue(fieldValue(fields, i+1)))
+	}
+
+	buf.WriteString("}\n")
+	return buf.Bytes()
+}
+
+// formatText returns fields as human readable line, time level msg key=value...
+func formatText(fields []interface{}) []byte {
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, "%s %s %s", fields[1], strings.ToUpper(fmt.Sprint(fields[3])), fields[5])
+	for i := 6; i < len(fields); i += 2 {
+		fmt.Fprintf(&buf, " %s=%s", fields[i], jsonValue(fieldValue(fields, i+1)))
+	}
+
+	buf.WriteByte('\n')
+	return buf.Bytes()
+}
+
+// fieldValue returns value of key at i, key without value gets "(MISSING)"
+func fieldValue(fields []interface{}, i int) interface{} {
+	if i >= len(fields) {
+		return "(MISSING)"
+	}
+
+	return fields[i]
+}
+
+func jsonValue(val interface{}) []byte {
+	switch v := val.(type) {
+	case time.Time:
+		// marshalled as RFC 3339
+	case error:
+		val = v.Error()
+	case time.Duration:
+		val = v.String()
+	case fmt.Stringer:
+		val = v.String()
+	}
+
+	b, err := json.Marshal(val)
+	if err != nil {
+		b, _ = json.Marshal(fmt.Sprint(val))
+	}
+
+	return b
+}
@@ -0,0 +1,70 @@
+package logger
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestLogger(out *bytes.Buffer, level Level, format string) *Logger {
+	log := New(out, level, format)
+	log.now = func() time.Time { return time.Date(2024, 5, 1, 10, 0, 0, 0, time.UTC) }
+	return log
+}
+
+func TestLogger_JSON(t *testing.T) {
+	var out bytes.Buffer
+	log := newTestLogger(&out, LevelInfo, FormatJSON).With("component", "test")
+
+	ctx := WithRequestID(context.Background(), "req-1")
+	log.Info(ctx, "wager placed", "wager_id", 5, "error", errors.New("some error"), "took", time.Second, "dangling")
+
+	assert.Equal(t,
+		`{"time":"2024-05-01T10:00:00Z","level":"info","msg":"wager placed","request_id":"req-1","component":"test",`+
+			`"wager_id":5,"error":"some error","took":"1s","dangling":"(MISSING)"}`+"\n",
+		out.String())
+}
+
+func TestLogger_Text(t *testing.T) {
+	var out bytes.Buffer
+	log := newTestLogger(&out, LevelDebug, FormatText)
+
+	log.Warn(context.Background(), "login failed", "user_id", 5, "username", "bob")
+
+	assert.Equal(t, `2024-05-01T10:00:00Z WARN login failed user_id=5 username="bob"`+"\n", out.String())
+}
+
+func TestLogger_Level(t *testing.T) {
+	var out bytes.Buffer
+	log := newTestLogger(&out, LevelWarn, FormatJSON)
+	ctx := context.Background()
+
+	log.Debug(ctx, "debug")
+	log.Info(ctx, "info")
+	assert.Empty(t, out.String())
+
+	log.Warn(ctx, "warn")
+	log.Error(ctx, "error")
+	assert.Equal(t, 2, bytes.Count(out.Bytes(), []byte("\n")))
+}
+
+func TestParseLevel(t *testing.T) {
+	for name, expected := range map[string]Level{
+		"debug":  LevelDebug,
+		"INFO":   LevelInfo,
+		" warn ": LevelWarn,
+		"Error":  LevelError,
+	} {
+		level, err := ParseLevel(name)
+		require.Nil(t, err)
+		assert.Equal(t, expected, level)
+	}
+
+	_, err := ParseLevel("verbose")
+	assert.NotNil(t, err)
+}
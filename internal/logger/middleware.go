@@ -0,0 +1,81 @@
+package logger
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"net/http"
+	"regexp"
+	"time"
+
+	"github.com/vitthalaa/wager-app/internal/httpwriter"
+)
+
+// RequestIDHeader is header carrying request id from client and back in response
+const RequestIDHeader = "X-Request-ID"
+
+// requestIDPattern limits accepted client request ids, others are replaced by generated id
+var requestIDPattern = regexp.MustCompile(`^[A-Za-z0-9._:-]{1,128}$`)
+
+type requestIDKey struct{}
+
+// WithRequestID returns copy of ctx carrying request id
+func WithRequestID(ctx context.Context, requestID string) context.Context {
+	return context.WithValue(ctx, requestIDKey{}, requestID)
+}
+
+// RequestID returns request id of ctx, empty outside of request
+func RequestID(ctx context.Context) string {
+	if ctx == nil {
+		return ""
+	}
+
+	requestID, _ := ctx.Value(requestIDKey{}).(string)
+	return requestID
+}
+
+// Detach returns context not cancelled with ctx but carrying its request id,
+// for work which must finish after request is done
+func Detach(ctx context.Context) context.Context {
+	detached := context.Background()
+	if requestID := RequestID(ctx); requestID != "" {
+		detached = WithRequestID(detached, requestID)
+	}
+
+	return detached
+}
+
+// Middleware assigns request id to every request and logs completed requests. Id sent by client
+// in X-Request-ID header is kept if it is valid, otherwise new id is generated. Id is put to request
+// context and returned in X-Request-ID response header.
+func Middleware(log *Logger, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		requestID := req.Header.Get(RequestIDHeader)
+		if !requestIDPattern.MatchString(requestID) {
+			requestID = newRequestID()
+		}
+
+		ctx := WithRequestID(req.Context(), requestID)
+		w.Header().Set(RequestIDHeader, requestID)
+
+		start := time.Now()
+		sw := httpwriter.New(w)
+		next.ServeHTTP(sw, req.WithContext(ctx))
+
+		log.Info(ctx, "request completed",
+			"method", req.Method,
+			"path", req.URL.Path,
+			"status", sw.Status(),
+			"duration_ms", time.Since(start).Milliseconds())
+	})
+}
+
+// newRequestID returns random 128 bit hex id
+func newRequestID() string {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return time.Now().UTC().Format("20060102150405.000000000")
+	}
+
+	return hex.EncodeToString(b)
+}
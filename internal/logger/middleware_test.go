@@ -0,0 +1,79 @@
+package logger
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMiddleware(t *testing.T) {
+	for _, tc := range []struct {
+		name      string
+		requestID string
+		keepID    bool
+	}{
+		{
+			name:      "keeps client request id",
+			requestID: "client-id-1",
+			keepID:    true,
+		},
+		{
+			name: "generates missing request id",
+		},
+		{
+			name:      "replaces invalid request id",
+			requestID: "bad id\n",
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			var out bytes.Buffer
+			log := New(&out, LevelInfo, FormatJSON)
+
+			var handlerRequestID string
+			next := http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+				handlerRequestID = RequestID(req.Context())
+				w.WriteHeader(http.StatusTeapot)
+			})
+
+			request, err := http.NewRequest("GET", "/wagers", nil)
+			require.Nil(t, err)
+			if tc.requestID != "" {
+				request.Header.Set(RequestIDHeader, tc.requestID)
+			}
+
+			resRecorder := httptest.NewRecorder()
+			Middleware(log, next).ServeHTTP(resRecorder, request)
+
+			requestID := resRecorder.Header().Get(RequestIDHeader)
+			require.NotEmpty(t, requestID)
+			assert.Equal(t, requestID, handlerRequestID)
+			if tc.keepID {
+				assert.Equal(t, tc.requestID, requestID)
+			} else {
+				assert.NotEqual(t, tc.requestID, requestID)
+			}
+
+			var line map[string]interface{}
+			require.Nil(t, json.Unmarshal(out.Bytes(), &line))
+			assert.Equal(t, "request completed", line["msg"])
+			assert.Equal(t, requestID, line["request_id"])
+			assert.Equal(t, float64(http.StatusTeapot), line["status"])
+			assert.Equal(t, "/wagers", line["path"])
+		})
+	}
+}
+
+func TestDetach(t *testing.T) {
+	ctx, cancel := context.WithCancel(WithRequestID(context.Background(), "req-1"))
+	cancel()
+
+	detached := Detach(ctx)
+	assert.Nil(t, detached.Err())
+	assert.Equal(t, "req-1", RequestID(detached))
+}
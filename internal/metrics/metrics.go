@@ -0,0 +1,67 @@
+package metrics
+
+import (
+	"database/sql"
+)
+
+// New returns application metrics registered to new registry
+func New() *Metrics {
+	registry := NewRegistry()
+	return &Metrics{
+		Registry: registry,
+		HTTPRequests: registry.NewCounterVec("http_requests_total",
+			"Count of HTTP requests by route, method and status.", "route", "method", "status"),
+		HTTPRequestDuration: registry.NewHistogramVec("http_request_duration_seconds",
+			"Latency of HTTP requests by route and method.", DefaultBuckets, "route", "method"),
+		WagersPlaced: registry.NewCounter("wagers_placed_total",
+			"Count of wagers placed."),
+		WagerPurchases: registry.NewCounter("wager_purchases_total",
+			"Count of wager purchases made."),
+		SoldOutRejections: registry.NewCounter("wager_sold_out_rejections_total",
+			"Count of purchases rejected because wager was sold out."),
+		AuctionsClosed: registry.NewCounter("wager_auctions_closed_total",
+			"Count of wager auctions closed."),
+		RollbackFailures: registry.NewCounter("db_transaction_rollback_failures_total",
+			"Count of failed transaction rollbacks, ex. of reverting failed purchase."),
+	}
+}
+
+// Metrics is set of application metrics exposed on /metrics
+type Metrics struct {
+	Registry *Registry
+
+	HTTPRequests        *CounterVec
+	HTTPRequestDuration *HistogramVec
+
+	WagersPlaced      *Counter
+	WagerPurchases    *Counter
+	SoldOutRejections *Counter
+	AuctionsClosed    *Counter
+	RollbackFailures  *Counter
+}
+
+// RegisterDBStats registers connection pool gauges of db read from db.Stats() on every scrape
+func (m *Metrics) RegisterDBStats(db *sql.DB) {
+	stats := func(fn func(s sql.DBStats) float64) func() float64 {
+		return func() float64 {
+			return fn(db.Stats())
+		}
+	}
+
+	m.Registry.NewGaugeFunc("db_max_open_connections", "Maximum number of open connections to DB.",
+		stats(func(s sql.DBStats) float64 { return float64(s.MaxOpenConnections) }))
+	m.Registry.NewGaugeFunc("db_open_connections", "Number of established connections to DB, in use and idle.",
+		stats(func(s sql.DBStats) float64 { return float64(s.OpenConnections) }))
+	m.Registry.NewGaugeFunc("db_in_use_connections", "Number of connections to DB currently in use.",
+		stats(func(s sql.DBStats) float64 { return float64(s.InUse) }))
+	m.Registry.NewGaugeFunc("db_idle_connections", "Number of idle connections to DB.",
+		stats(func(s sql.DBStats) float64 { return float64(s.Idle) }))
+	m.Registry.NewCounterFunc("db_wait_count_total", "Count of waits for connection to DB.",
+		stats(func(s sql.DBStats) float64 { return float64(s.WaitCount) }))
+	m.Registry.NewCounterFunc("db_wait_duration_seconds_total", "Total time blocked waiting for connection to DB.",
+		stats(func(s sql.DBStats) float64 { return s.WaitDuration.Seconds() }))
+	m.Registry.NewCounterFunc("db_max_idle_closed_total", "Count of connections closed due to max idle connections.",
+		stats(func(s sql.DBStats) float64 { return float64(s.MaxIdleClosed) }))
+	m.Registry.NewCounterFunc("db_max_lifetime_closed_total", "Count of connections closed due to max lifetime.",
+		stats(func(s sql.DBStats) float64 { return float64(s.MaxLifetimeClosed) }))
+}
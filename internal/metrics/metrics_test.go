@@ -0,0 +1,33 @@
+package metrics
+
+import (
+	"database/sql"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	_ "modernc.org/sqlite"
+)
+
+func TestMetrics_RegisterDBStats(t *testing.T) {
+	db, err := sql.Open("sqlite", ":memory:")
+	require.Nil(t, err)
+	defer db.Close()
+
+	db.SetMaxOpenConns(4)
+	require.Nil(t, db.Ping())
+
+	m := New()
+	m.RegisterDBStats(db)
+
+	resRecorder := httptest.NewRecorder()
+	m.Registry.ServeHTTP(resRecorder, httptest.NewRequest("GET", "/metrics", nil))
+
+	body := resRecorder.Body.String()
+	assert.Contains(t, body, "# TYPE db_max_open_connections gauge\ndb_max_open_connections 4\n")
+	assert.Contains(t, body, "db_open_connections 1\n")
+	assert.Contains(t, body, "db_idle_connections 1\n")
+	assert.Contains(t, body, "db_in_use_connections 0\n")
+	assert.Contains(t, body, "# TYPE db_wait_count_total counter\ndb_wait_count_total 0\n")
+}
@@ -0,0 +1,97 @@
+package metrics
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/vitthalaa/wager-app/internal/httpwriter"
+)
+
+// unmatchedRoute is route label of requests to paths not matching any route
+const unmatchedRoute = "unmatched"
+
+// otherMethod is method label of requests with method not in knownMethods
+const otherMethod = "other"
+
+// knownMethods are methods labelled as they are, clients can send any method so others share one label
+var knownMethods = map[string]bool{
+	http.MethodGet:     true,
+	http.MethodPost:    true,
+	http.MethodPut:     true,
+	http.MethodPatch:   true,
+	http.MethodDelete:  true,
+	http.MethodHead:    true,
+	http.MethodOptions: true,
+}
+
+// Middleware counts requests and observes their latency by route, method and status.
+// Route label is one of routes matching request path, ex. "/wagers/{id}/settle", so that
+// ids in path do not blow up number of series. Segments in braces match any path segment, methods
+// not in knownMethods are labelled "other" for the same reason.
+func Middleware(m *Metrics, routes []string, next http.Handler) http.Handler {
+	patterns := make([][]string, len(routes))
+	for i, route := range routes {
+		patterns[i] = pathSegments(route)
+	}
+
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		start := time.Now()
+		sw := httpwriter.New(w)
+		next.ServeHTTP(sw, req)
+
+		route := matchRoute(routes, patterns, req.URL.Path)
+		method := methodLabel(req.Method)
+		m.HTTPRequests.Inc(route, method, strconv.Itoa(sw.Status()))
+		m.HTTPRequestDuration.Observe(time.Since(start).Seconds(), route, method)
+	})
+}
+
+// methodLabel returns method label of request method
+func methodLabel(method string) string {
+	if knownMethods[method] {
+		return method
+	}
+
+	return otherMethod
+}
+
+// matchRoute returns first of routes matching path
+func matchRoute(routes []string, patterns [][]string, path string) string {
+	segments := pathSegments(path)
+	for i, pattern := range patterns {
+		if segmentsMatch(pattern, segments) {
+			return routes[i]
+		}
+	}
+
+	return unmatchedRoute
+}
+
+func segmentsMatch(pattern, segments []string) bool {
+	if len(pattern) != len(segments) {
+		return false
+	}
+
+	for i, p := range pattern {
+		if strings.HasPrefix(p, "{") && strings.HasSuffix(p, "}") {
+			continue
+		}
+
+		if p != segments[i] {
+			return false
+		}
+	}
+
+	return true
+}
+
+func pathSegments(path string) []string {
+	path = strings.Trim(path, "/")
+	if path == "" {
+		return nil
+	}
+
+	return strings.Split(path, "/")
+}
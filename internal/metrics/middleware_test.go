@@ -0,0 +1,88 @@
+package metrics
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMiddleware(t *testing.T) {
+	routes := []string{"/wagers", "/wagers/{id}", "/wagers/{id}/settle", "/buy/{id}"}
+	for _, tc := range []struct {
+		name   string
+		method string
+		path   string
+		status int
+
+		expectedRoute  string
+		expectedMethod string
+	}{
+		{
+			name:          "list wagers",
+			method:        "GET",
+			path:          "/wagers",
+			status:        http.StatusOK,
+			expectedRoute: "/wagers",
+		},
+		{
+			name:          "wager id",
+			method:        "GET",
+			path:          "/wagers/111",
+			status:        http.StatusOK,
+			expectedRoute: "/wagers/{id}",
+		},
+		{
+			name:          "wager action",
+			method:        "POST",
+			path:          "/wagers/111/settle/",
+			status:        http.StatusConflict,
+			expectedRoute: "/wagers/{id}/settle",
+		},
+		{
+			name:          "buy",
+			method:        "POST",
+			path:          "/buy/5",
+			status:        http.StatusNotAcceptable,
+			expectedRoute: "/buy/{id}",
+		},
+		{
+			name:          "unknown path",
+			method:        "GET",
+			path:          "/wagers/111/unknown",
+			status:        http.StatusNotFound,
+			expectedRoute: "unmatched",
+		},
+		{
+			name:           "unknown method",
+			method:         "PROPFIND",
+			path:           "/wagers",
+			status:         http.StatusNotFound,
+			expectedRoute:  "/wagers",
+			expectedMethod: "other",
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			m := New()
+			next := http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+				w.WriteHeader(tc.status)
+			})
+
+			Middleware(m, routes, next).ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(tc.method, tc.path, nil))
+
+			method := tc.method
+			if tc.expectedMethod != "" {
+				method = tc.expectedMethod
+			}
+
+			assert.Equal(t, float64(1), m.HTTPRequests.Value(tc.expectedRoute, method, strconv.Itoa(tc.status)))
+
+			resRecorder := httptest.NewRecorder()
+			m.Registry.ServeHTTP(resRecorder, httptest.NewRequest("GET", "/metrics", nil))
+			assert.Contains(t, resRecorder.Body.String(),
+				`http_request_duration_seconds_count{route="`+tc.expectedRoute+`",method="`+method+`"} 1`)
+		})
+	}
+}
@@ -0,0 +1,368 @@
+// Package metrics keeps application metrics and exposes them in Prometheus text exposition format.
+package metrics
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// contentType of Prometheus text exposition format
+const contentType = "text/plain; version=0.0.4; charset=utf-8"
+
+// DefaultBuckets are upper bounds of latency histograms, in seconds
+var DefaultBuckets = []float64{0.005, 0.01, 0.025, 0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10}
+
+// metric writes its samples in text exposition format
+type metric interface {
+	name() string
+	write(w io.Writer)
+}
+
+// NewRegistry returns empty registry
+func NewRegistry() *Registry {
+	return &Registry{}
+}
+
+// Registry is set of metrics exposed together, metrics are written in order of registration
+type Registry struct {
+	mu      sync.Mutex
+	metrics []metric
+}
+
+// register adds m to registry, metric names must be unique
+func (r *Registry) register(m metric) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for _, registered := range r.metrics {
+		if registered.name() == m.name() {
+			panic(fmt.Sprintf("metric %q registered twice", m.name()))
+		}
+	}
+
+	r.metrics = append(r.metrics, m)
+}
+
+// NewCounter registers counter without labels
+func (r *Registry) NewCounter(name, help string) *Counter {
+	return &Counter{vec: r.NewCounterVec(name, help)}
+}
+
+// NewCounterVec registers counter partitioned by labels
+func (r *Registry) NewCounterVec(name, help string, labels ...string) *CounterVec {
+	c := &CounterVec{
+		desc:   desc{metricName: name, help: help, kind: "counter", labels: labels},
+		values: map[string]*series{},
+	}
+
+	r.register(c)
+	return c
+}
+
+// NewHistogramVec registers histogram with bucket upper bounds partitioned by labels
+func (r *Registry) NewHistogramVec(name, help string, buckets []float64, labels ...string) *HistogramVec {
+	h := &HistogramVec{
+		desc:    desc{metricName: name, help: help, kind: "histogram", labels: labels},
+		buckets: append([]float64{}, buckets...),
+		values:  map[string]*series{},
+	}
+
+	sort.Float64s(h.buckets)
+	r.register(h)
+	return h
+}
+
+// NewGaugeFunc registers gauge which value is read by fn on every scrape
+func (r *Registry) NewGaugeFunc(name, help string, fn func() float64) {
+	r.register(&funcMetric{desc: desc{metricName: name, help: help, kind: "gauge"}, fn: fn})
+}
+
+// NewCounterFunc registers counter which value is read by fn on every scrape
+func (r *Registry) NewCounterFunc(name, help string, fn func() float64) {
+	r.register(&funcMetric{desc: desc{metricName: name, help: help, kind: "counter"}, fn: fn})
+}
+
+// WriteTo writes all metrics in text exposition format
+func (r *Registry) WriteTo(w io.Writer) (int64, error) {
+	r.mu.Lock()
+	metrics := append([]metric{}, r.metrics...)
+	r.mu.Unlock()
+
+	buf := bufio.NewWriter(w)
+	cw := &countingWriter{w: buf}
+	for _, m := range metrics {
+		m.write(cw)
+	}
+
+	if err := buf.Flush(); err != nil {
+		return cw.n, err
+	}
+
+	return cw.n, cw.err
+}
+
+// ServeHTTP serves metrics scrape
+func (r *Registry) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	if req.Method != http.MethodGet && req.Method != http.MethodHead {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+
+	w.Header().Set("Content-Type", contentType)
+	_, _ = r.WriteTo(w)
+}
+
+// desc is name, help and label names of metric
+type desc struct {
+	metricName string
+	help       string
+	kind       string
+	labels     []string
+}
+
+func (d *desc) name() string {
+	return d.metricName
+}
+
+func (d *desc) writeHeader(w io.Writer) {
+	fmt.Fprintf(w, "# HELP %s %s\n", d.metricName, escapeHelp(d.help))
+	fmt.Fprintf(w, "# TYPE %s %s\n", d.metricName, d.kind)
+}
+
+// key returns map key of label values, panics if count of values does not match labels
+func (d *desc) key(labelValues []string) string {
+	if len(labelValues) != len(d.labels) {
+		panic(fmt.Sprintf("metric %q expects %d label values, got %d", d.metricName, len(d.labels), len(labelValues)))
+	}
+
+	return strings.Join(labelValues, "\xff")
+}
+
+// series is value of metric for one set of label values
+type series struct {
+	labelValues []string
+	value       float64
+	// histogram only
+	counts []uint64
+	count  uint64
+}
+
+// Counter is monotonically increasing value
+type Counter struct {
+	vec *CounterVec
+}
+
+// Inc increments counter by 1
+func (c *Counter) Inc() {
+	c.vec.Add(1)
+}
+
+// Value returns current value of counter
+func (c *Counter) Value() float64 {
+	return c.vec.Value()
+}
+
+// CounterVec is counter partitioned by label values
+type CounterVec struct {
+	desc
+	mu     sync.Mutex
+	values map[string]*series
+}
+
+// Inc increments counter of label values by 1
+func (c *CounterVec) Inc(labelValues ...string) {
+	c.Add(1, labelValues...)
+}
+
+// Add adds v to counter of label values, negative v panics
+func (c *CounterVec) Add(v float64, labelValues ...string) {
+	if v < 0 {
+		panic(fmt.Sprintf("counter %q can not decrease", c.metricName))
+	}
+
+	key := c.key(labelValues)
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	s, ok := c.values[key]
+	if !ok {
+		s = &series{labelValues: append([]string{}, labelValues...)}
+		c.values[key] = s
+	}
+
+	s.value += v
+}
+
+// Value returns current value of counter of label values
+func (c *CounterVec) Value(labelValues ...string) float64 {
+	key := c.key(labelValues)
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if s, ok := c.values[key]; ok {
+		return s.value
+	}
+
+	return 0
+}
+
+func (c *CounterVec) write(w io.Writer) {
+	c.writeHeader(w)
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	// counter without labels is exposed before first increment
+	if len(c.labels) == 0 && len(c.values) == 0 {
+		fmt.Fprintf(w, "%s 0\n", c.metricName)
+		return
+	}
+
+	for _, s := range sortedSeries(c.values) {
+		fmt.Fprintf(w, "%s%s %s\n", c.metricName, formatLabels(c.labels, s.labelValues), formatFloat(s.value))
+	}
+}
+
+// HistogramVec counts observations in buckets, partitioned by label values
+type HistogramVec struct {
+	desc
+	buckets []float64
+	mu      sync.Mutex
+	values  map[string]*series
+}
+
+// Observe adds observation v to histogram of label values
+func (h *HistogramVec) Observe(v float64, labelValues ...string) {
+	key := h.key(labelValues)
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	s, ok := h.values[key]
+	if !ok {
+		s = &series{labelValues: append([]string{}, labelValues...), counts: make([]uint64, len(h.buckets))}
+		h.values[key] = s
+	}
+
+	for i, bound := range h.buckets {
+		if v <= bound {
+			s.counts[i]++
+		}
+	}
+
+	s.value += v
+	s.count++
+}
+
+func (h *HistogramVec) write(w io.Writer) {
+	h.writeHeader(w)
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	bucketLabels := append(append([]string{}, h.labels...), "le")
+	for _, s := range sortedSeries(h.values) {
+		for i, bound := range h.buckets {
+			labels := formatLabels(bucketLabels, append(append([]string{}, s.labelValues...), formatFloat(bound)))
+			fmt.Fprintf(w, "%s_bucket%s %d\n", h.metricName, labels, s.counts[i])
+		}
+
+		labels := formatLabels(bucketLabels, append(append([]string{}, s.labelValues...), "+Inf"))
+		fmt.Fprintf(w, "%s_bucket%s %d\n", h.metricName, labels, s.count)
+		fmt.Fprintf(w, "%s_sum%s %s\n", h.metricName, formatLabels(h.labels, s.labelValues), formatFloat(s.value))
+		fmt.Fprintf(w, "%s_count%s %d\n", h.metricName, formatLabels(h.labels, s.labelValues), s.count)
+	}
+}
+
+// funcMetric is metric without labels read on scrape
+type funcMetric struct {
+	desc
+	fn func() float64
+}
+
+func (f *funcMetric) write(w io.Writer) {
+	f.writeHeader(w)
+	fmt.Fprintf(w, "%s %s\n", f.metricName, formatFloat(f.fn()))
+}
+
+// sortedSeries returns series ordered by label values, so that output is stable
+func sortedSeries(values map[string]*series) []*series {
+	keys := make([]string, 0, len(values))
+	for key := range values {
+		keys = append(keys, key)
+	}
+
+	sort.Strings(keys)
+	res := make([]*series, 0, len(keys))
+	for _, key := range keys {
+		res = append(res, values[key])
+	}
+
+	return res
+}
+
+func formatLabels(names, values []string) string {
+	if len(names) == 0 {
+		return ""
+	}
+
+	pairs := make([]string, len(names))
+	for i, name := range names {
+		pairs[i] = name + `="` + escapeLabelValue(values[i]) + `"`
+	}
+
+	return "{" + strings.Join(pairs, ",") + "}"
+}
+
+func formatFloat(v float64) string {
+	switch {
+	case math.IsInf(v, 1):
+		return "+Inf"
+	case math.IsInf(v, -1):
+		return "-Inf"
+	case math.IsNaN(v):
+		return "NaN"
+	}
+
+	return strconv.FormatFloat(v, 'g', -1, 64)
+}
+
+var (
+	labelValueEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+	helpEscaper       = strings.NewReplacer(`\`, `\\`, "\n", `\n`)
+)
+
+func escapeLabelValue(v string) string {
+	return labelValueEscaper.Replace(v)
+}
+
+func escapeHelp(help string) string {
+	return helpEscaper.Replace(help)
+}
+
+// countingWriter counts written bytes and keeps first write error
+type countingWriter struct {
+	w   io.Writer
+	n   int64
+	err error
+}
+
+func (cw *countingWriter) Write(b []byte) (int, error) {
+	if cw.err != nil {
+		return 0, cw.err
+	}
+
+	n, err := cw.w.Write(b)
+	cw.n += int64(n)
+	cw.err = err
+	return n, err
+}
@@ -0,0 +1,87 @@
+package metrics
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRegistry_ServeHTTP(t *testing.T) {
+	registry := NewRegistry()
+	placed := registry.NewCounter("wagers_placed_total", "Count of wagers placed.")
+	requests := registry.NewCounterVec("http_requests_total", "Count of requests.", "route", "status")
+	duration := registry.NewHistogramVec("http_request_duration_seconds", "Latency.", []float64{0.5, 0.1}, "route")
+	registry.NewGaugeFunc("db_open_connections", "Open connections.", func() float64 { return 3 })
+
+	placed.Inc()
+	requests.Inc("/wagers", "200")
+	requests.Add(2, "/buy/{id}", "406")
+	requests.Inc("/wagers", "200")
+	duration.Observe(0.05, "/wagers")
+	duration.Observe(0.3, "/wagers")
+
+	request, err := http.NewRequest("GET", "/metrics", nil)
+	require.Nil(t, err)
+
+	resRecorder := httptest.NewRecorder()
+	registry.ServeHTTP(resRecorder, request)
+
+	require.Equal(t, http.StatusOK, resRecorder.Code)
+	assert.Equal(t, "text/plain; version=0.0.4; charset=utf-8", resRecorder.Header().Get("Content-Type"))
+	assert.Equal(t, `# HELP wagers_placed_total Count of wagers placed.
+# TYPE wagers_placed_total counter
+wagers_placed_total 1
+# HELP http_requests_total Count of requests.
+# TYPE http_requests_total counter
+http_requests_total{route="/buy/{id}",status="406"} 2
+http_requests_total{route="/wagers",status="200"} 2
+# HELP http_request_duration_seconds Latency.
+# TYPE http_request_duration_seconds histogram
+http_request_duration_seconds_bucket{route="/wagers",le="0.1"} 1
+http_request_duration_seconds_bucket{route="/wagers",le="0.5"} 2
+http_request_duration_seconds_bucket{route="/wagers",le="+Inf"} 2
+http_request_duration_seconds_sum{route="/wagers"} 0.35
+http_request_duration_seconds_count{route="/wagers"} 2
+# HELP db_open_connections Open connections.
+# TYPE db_open_connections gauge
+db_open_connections 3
+`, resRecorder.Body.String())
+}
+
+func TestRegistry_UnusedCounter(t *testing.T) {
+	registry := NewRegistry()
+	registry.NewCounter("wager_purchases_total", "Count of purchases.")
+	registry.NewCounterVec("http_requests_total", "Count of requests.", "route")
+
+	resRecorder := httptest.NewRecorder()
+	registry.ServeHTTP(resRecorder, httptest.NewRequest("GET", "/metrics", nil))
+
+	assert.Equal(t, `# HELP wager_purchases_total Count of purchases.
+# TYPE wager_purchases_total counter
+wager_purchases_total 0
+# HELP http_requests_total Count of requests.
+# TYPE http_requests_total counter
+`, resRecorder.Body.String())
+}
+
+func TestRegistry_EscapeLabelValue(t *testing.T) {
+	registry := NewRegistry()
+	registry.NewCounterVec("errors_total", "Count of errors.", "error").Inc("bad \"value\"\n\\")
+
+	resRecorder := httptest.NewRecorder()
+	registry.ServeHTTP(resRecorder, httptest.NewRequest("GET", "/metrics", nil))
+
+	assert.Contains(t, resRecorder.Body.String(), `errors_total{error="bad \"value\"\n\\"} 1`)
+}
+
+func TestRegistry_DuplicateName(t *testing.T) {
+	registry := NewRegistry()
+	registry.NewCounter("wagers_placed_total", "Count of wagers placed.")
+
+	assert.Panics(t, func() {
+		registry.NewCounter("wagers_placed_total", "Count of wagers placed.")
+	})
+}
@@ -0,0 +1,60 @@
+package migrate
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+)
+
+// Usage is help text of migrate command
+const Usage = `usage: migrate <command>
+commands:
+  up          apply all pending migrations
+  down [n]    revert n latest applied migrations (default 1)
+  status      print migrations and their state`
+
+// RunCommand runs migrate subcommand given by args and writes its output to out
+func RunCommand(ctx context.Context, m *Migrator, args []string, out io.Writer) error {
+	if len(args) == 0 {
+		return errors.New(Usage)
+	}
+
+	switch args[0] {
+	case "up":
+		return m.Up(ctx)
+
+	case "down":
+		steps := 1
+		if len(args) > 1 {
+			n, err := strconv.Atoi(args[1])
+			if err != nil || n < 1 {
+				return fmt.Errorf("invalid number of steps %q", args[1])
+			}
+
+			steps = n
+		}
+
+		return m.Down(ctx, steps)
+
+	case "status":
+		statuses, err := m.Status(ctx)
+		if err != nil {
+			return err
+		}
+
+		for _, st := range statuses {
+			state := "pending"
+			if st.Applied {
+				state = "applied at " + st.AppliedAt.Format("2006-01-02 15:04:05")
+			}
+
+			fmt.Fprintf(out, "%04d_%s\t%s\n", st.Version, st.Name, state)
+		}
+
+		return nil
+	}
+
+	return errors.New(Usage)
+}
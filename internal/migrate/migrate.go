@@ -0,0 +1,395 @@
+// Package migrate applies versioned schema migrations embedded into the app.
+//
+// Migrations are sql files named <version>_<name>.up.sql and <version>_<name>.down.sql.
+// Applied versions are tracked in schema_migrations table together with checksum of up script,
+// so that changed migration files are detected instead of silently diverging schema.
+package migrate
+
+import (
+	"context"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"fmt"
+	"io/fs"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/vitthalaa/wager-app/data"
+	"github.com/vitthalaa/wager-app/internal/logger"
+	"github.com/vitthalaa/wager-app/internal/repo"
+)
+
+// lockKey is postgres advisory lock key taken while migrating
+const lockKey = 7251908243
+
+const (
+	createMigrationsTableStmt = `create table if not exists schema_migrations (
+									version bigint not null constraint schema_migrations_pk primary key,
+									name text not null,
+									checksum text not null,
+									applied_at timestamp not null default current_timestamp
+								)`
+	listAppliedStmt    = "select version, name, checksum, applied_at from schema_migrations order by version"
+	isAppliedStmt      = "select count(*) from schema_migrations where version = $1"
+	insertAppliedStmt  = "insert into schema_migrations(version, name, checksum) values ($1, $2, $3)"
+	deleteAppliedStmt  = "delete from schema_migrations where version = $1"
+	advisoryLockStmt   = "select pg_advisory_lock($1)"
+	advisoryUnlockStmt = "select pg_advisory_unlock($1)"
+)
+
+const (
+	upSuffix   = ".up.sql"
+	downSuffix = ".down.sql"
+)
+
+// Migration is single schema version
+type Migration struct {
+	Version  uint32
+	Name     string
+	Up       string
+	Down     string
+	Checksum string
+}
+
+// Status is migration with its applied state
+type Status struct {
+	Migration
+	Applied   bool
+	AppliedAt *time.Time
+}
+
+type appliedMigration struct {
+	version   uint32
+	name      string
+	checksum  string
+	appliedAt time.Time
+}
+
+// Migrator runs migrations of a dialect against database
+type Migrator struct {
+	db         *sql.DB
+	dialect    repo.Dialect
+	migrations []Migration
+	log        *logger.Logger
+}
+
+// New returns migrator with migrations embedded in data package for dialect
+func New(db *sql.DB, dialect repo.Dialect, log *logger.Logger) (*Migrator, error) {
+	fsys, err := fs.Sub(data.Migrations, path.Join("migrations", string(dialect)))
+	if err != nil {
+		return nil, err
+	}
+
+	return NewFromFS(db, dialect, fsys, log)
+}
+
+// NewFromFS returns migrator with migrations read from root of fsys
+func NewFromFS(db *sql.DB, dialect repo.Dialect, fsys fs.FS, log *logger.Logger) (*Migrator, error) {
+	migrations, err := load(fsys)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Migrator{
+		db:         db,
+		dialect:    dialect,
+		migrations: migrations,
+		log:        log,
+	}, nil
+}
+
+// Up applies all pending migrations in version order
+func (m *Migrator) Up(ctx context.Context) error {
+	return m.withLock(ctx, func(conn *sql.Conn) error {
+		applied, err := m.verify(ctx, conn)
+		if err != nil {
+			return err
+		}
+
+		for _, mg := range m.migrations {
+			if _, ok := applied[mg.Version]; ok {
+				continue
+			}
+
+			if err = m.apply(ctx, conn, mg); err != nil {
+				return err
+			}
+		}
+
+		return nil
+	})
+}
+
+// Down reverts steps latest applied migrations
+func (m *Migrator) Down(ctx context.Context, steps int) error {
+	return m.withLock(ctx, func(conn *sql.Conn) error {
+		applied, err := m.verify(ctx, conn)
+		if err != nil {
+			return err
+		}
+
+		for i := len(m.migrations) - 1; i >= 0 && steps > 0; i-- {
+			mg := m.migrations[i]
+			if _, ok := applied[mg.Version]; !ok {
+				continue
+			}
+
+			if err = m.revert(ctx, conn, mg); err != nil {
+				return err
+			}
+
+			steps--
+		}
+
+		return nil
+	})
+}
+
+// Status returns all known migrations with applied state.
+// Returns error if applied migrations do not match known migrations.
+func (m *Migrator) Status(ctx context.Context) ([]Status, error) {
+	conn, err := m.db.Conn(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	defer conn.Close()
+
+	if _, err = conn.ExecContext(ctx, createMigrationsTableStmt); err != nil {
+		return nil, err
+	}
+
+	applied, err := m.verify(ctx, conn)
+	if err != nil {
+		return nil, err
+	}
+
+	res := make([]Status, 0, len(m.migrations))
+	for _, mg := range m.migrations {
+		st := Status{Migration: mg}
+		if a, ok := applied[mg.Version]; ok {
+			appliedAt := a.appliedAt
+			st.Applied = true
+			st.AppliedAt = &appliedAt
+		}
+
+		res = append(res, st)
+	}
+
+	return res, nil
+}
+
+// Pending returns migrations not applied yet
+func (m *Migrator) Pending(ctx context.Context) ([]Migration, error) {
+	statuses, err := m.Status(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var res []Migration
+	for _, st := range statuses {
+		if !st.Applied {
+			res = append(res, st.Migration)
+		}
+	}
+
+	return res, nil
+}
+
+// withLock runs fn on dedicated connection holding migration lock.
+// Postgres uses session advisory lock so that multiple app instances starting together do not race.
+// SQLite transactions lock whole database and apply re-checks version inside transaction.
+func (m *Migrator) withLock(ctx context.Context, fn func(conn *sql.Conn) error) (err error) {
+	conn, err := m.db.Conn(ctx)
+	if err != nil {
+		return err
+	}
+
+	defer conn.Close()
+
+	if m.dialect == repo.DialectPostgres {
+		if _, err = conn.ExecContext(ctx, advisoryLockStmt, lockKey); err != nil {
+			return fmt.Errorf("acquire migration lock: %w", err)
+		}
+
+		defer func() {
+			// unlock must run even if ctx is already cancelled
+			_, unlockErr := conn.ExecContext(context.Background(), advisoryUnlockStmt, lockKey)
+			if unlockErr != nil {
+				m.log.Error(ctx, "release migration lock failed", "error", unlockErr)
+			}
+		}()
+	}
+
+	if _, err = conn.ExecContext(ctx, createMigrationsTableStmt); err != nil {
+		return err
+	}
+
+	return fn(conn)
+}
+
+// verify returns applied migrations and checks each of them is known and unchanged
+func (m *Migrator) verify(ctx context.Context, conn *sql.Conn) (map[uint32]appliedMigration, error) {
+	rows, err := conn.QueryContext(ctx, listAppliedStmt)
+	if err != nil {
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	known := make(map[uint32]Migration, len(m.migrations))
+	for _, mg := range m.migrations {
+		known[mg.Version] = mg
+	}
+
+	applied := map[uint32]appliedMigration{}
+	for rows.Next() {
+		var a appliedMigration
+		if err = rows.Scan(&a.version, &a.name, &a.checksum, &a.appliedAt); err != nil {
+			return nil, err
+		}
+
+		mg, ok := known[a.version]
+		if !ok {
+			return nil, fmt.Errorf("applied migration %04d_%s is unknown to this app version", a.version, a.name)
+		}
+
+		if mg.Checksum != a.checksum {
+			return nil, fmt.Errorf("checksum mismatch of migration %04d_%s: file was changed after it was applied", a.version, a.name)
+		}
+
+		applied[a.version] = a
+	}
+
+	return applied, rows.Err()
+}
+
+func (m *Migrator) apply(ctx context.Context, conn *sql.Conn, mg Migration) error {
+	return inTx(ctx, conn, func(tx *sql.Tx) error {
+		var count int
+		if err := tx.QueryRowContext(ctx, isAppliedStmt, mg.Version).Scan(&count); err != nil {
+			return err
+		}
+
+		// applied concurrently by another instance
+		if count > 0 {
+			return nil
+		}
+
+		if _, err := tx.ExecContext(ctx, mg.Up); err != nil {
+			return fmt.Errorf("apply migration %04d_%s: %w", mg.Version, mg.Name, err)
+		}
+
+		if _, err := tx.ExecContext(ctx, insertAppliedStmt, mg.Version, mg.Name, mg.Checksum); err != nil {
+			return err
+		}
+
+		m.log.Info(ctx, "migration applied", "version", mg.Version, "name", mg.Name)
+		return nil
+	})
+}
+
+func (m *Migrator) revert(ctx context.Context, conn *sql.Conn, mg Migration) error {
+	if mg.Down == "" {
+		return fmt.Errorf("migration %04d_%s has no down script", mg.Version, mg.Name)
+	}
+
+	return inTx(ctx, conn, func(tx *sql.Tx) error {
+		if _, err := tx.ExecContext(ctx, mg.Down); err != nil {
+			return fmt.Errorf("revert migration %04d_%s: %w", mg.Version, mg.Name, err)
+		}
+
+		if _, err := tx.ExecContext(ctx, deleteAppliedStmt, mg.Version); err != nil {
+			return err
+		}
+
+		m.log.Info(ctx, "migration reverted", "version", mg.Version, "name", mg.Name)
+		return nil
+	})
+}
+
+func inTx(ctx context.Context, conn *sql.Conn, fn func(tx *sql.Tx) error) error {
+	tx, err := conn.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+
+	if err = fn(tx); err != nil {
+		_ = tx.Rollback()
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// load reads and validates migration files from root of fsys
+func load(fsys fs.FS) ([]Migration, error) {
+	entries, err := fs.ReadDir(fsys, ".")
+	if err != nil {
+		return nil, err
+	}
+
+	byVersion := map[uint32]*Migration{}
+	for _, entry := range entries {
+		fileName := entry.Name()
+		if entry.IsDir() || !strings.HasSuffix(fileName, ".sql") {
+			continue
+		}
+
+		isUp := strings.HasSuffix(fileName, upSuffix)
+		if !isUp && !strings.HasSuffix(fileName, downSuffix) {
+			return nil, fmt.Errorf("migration %s must end with %s or %s", fileName, upSuffix, downSuffix)
+		}
+
+		base := strings.TrimSuffix(strings.TrimSuffix(fileName, upSuffix), downSuffix)
+		versionStr, name, ok := strings.Cut(base, "_")
+		if !ok || name == "" {
+			return nil, fmt.Errorf("migration %s must be named <version>_<name>", fileName)
+		}
+
+		version, err := strconv.ParseUint(versionStr, 10, 32)
+		if err != nil || version == 0 {
+			return nil, fmt.Errorf("migration %s has invalid version", fileName)
+		}
+
+		content, err := fs.ReadFile(fsys, fileName)
+		if err != nil {
+			return nil, err
+		}
+
+		mg, ok := byVersion[uint32(version)]
+		if !ok {
+			mg = &Migration{Version: uint32(version), Name: name}
+			byVersion[mg.Version] = mg
+		}
+
+		if mg.Name != name {
+			return nil, fmt.Errorf("migration version %d is used by %s and %s", version, mg.Name, name)
+		}
+
+		if isUp {
+			sum := sha256.Sum256(content)
+			mg.Up = string(content)
+			mg.Checksum = hex.EncodeToString(sum[:])
+		} else {
+			mg.Down = string(content)
+		}
+	}
+
+	res := make([]Migration, 0, len(byVersion))
+	for _, mg := range byVersion {
+		if mg.Up == "" {
+			return nil, fmt.Errorf("migration %04d_%s has no up script", mg.Version, mg.Name)
+		}
+
+		res = append(res, *mg)
+	}
+
+	sort.Slice(res, func(i, j int) bool { return res[i].Version < res[j].Version })
+
+	return res, nil
+}
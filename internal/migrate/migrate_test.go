@@ -0,0 +1,217 @@
+package migrate
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"path/filepath"
+	"testing"
+	"testing/fstest"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/vitthalaa/wager-app/internal/config"
+	"github.com/vitthalaa/wager-app/internal/db"
+	"github.com/vitthalaa/wager-app/internal/logger"
+	"github.com/vitthalaa/wager-app/internal/repo"
+)
+
+func openSQLite(t *testing.T) *sql.DB {
+	conn, err := db.OpenConnection(&config.DataBaseConfig{
+		DBDriver: config.DriverSQLite,
+		DBPath:   filepath.Join(t.TempDir(), "migrate.db"),
+	}, logger.Discard())
+	require.Nil(t, err)
+	t.Cleanup(func() { conn.Close() })
+
+	return conn
+}
+
+func testFS() fstest.MapFS {
+	return fstest.MapFS{
+		"0001_first.up.sql":    {Data: []byte("create table first (id integer primary key);")},
+		"0001_first.down.sql":  {Data: []byte("drop table first;")},
+		"0002_second.up.sql":   {Data: []byte("create table second (id integer primary key);")},
+		"0002_second.down.sql": {Data: []byte("drop table second;")},
+	}
+}
+
+func tableExists(t *testing.T, conn *sql.DB, name string) bool {
+	var count int
+	err := conn.QueryRow("select count(*) from sqlite_master where type='table' and name=$1", name).Scan(&count)
+	require.Nil(t, err)
+
+	return count > 0
+}
+
+func TestMigrator_UpDown(t *testing.T) {
+	ctx := context.Background()
+	conn := openSQLite(t)
+
+	m, err := NewFromFS(conn, repo.DialectSQLite, testFS(), logger.Discard())
+	require.Nil(t, err)
+
+	pending, err := m.Pending(ctx)
+	require.Nil(t, err)
+	require.Len(t, pending, 2)
+
+	require.Nil(t, m.Up(ctx))
+	// up is idempotent
+	require.Nil(t, m.Up(ctx))
+
+	assert.True(t, tableExists(t, conn, "first"))
+	assert.True(t, tableExists(t, conn, "second"))
+
+	pending, err = m.Pending(ctx)
+	require.Nil(t, err)
+	assert.Empty(t, pending)
+
+	require.Nil(t, m.Down(ctx, 1))
+	assert.True(t, tableExists(t, conn, "first"))
+	assert.False(t, tableExists(t, conn, "second"))
+
+	statuses, err := m.Status(ctx)
+	require.Nil(t, err)
+	require.Len(t, statuses, 2)
+	assert.True(t, statuses[0].Applied)
+	assert.NotNil(t, statuses[0].AppliedAt)
+	assert.False(t, statuses[1].Applied)
+}
+
+func TestMigrator_FailedMigrationIsRolledBack(t *testing.T) {
+	ctx := context.Background()
+	conn := openSQLite(t)
+
+	fsys := testFS()
+	fsys["0002_second.up.sql"] = &fstest.MapFile{Data: []byte("create table second (id integer primary key); invalid sql;")}
+
+	m, err := NewFromFS(conn, repo.DialectSQLite, fsys, logger.Discard())
+	require.Nil(t, err)
+
+	assert.NotNil(t, m.Up(ctx))
+	assert.True(t, tableExists(t, conn, "first"))
+	assert.False(t, tableExists(t, conn, "second"))
+
+	pending, err := m.Pending(ctx)
+	require.Nil(t, err)
+	require.Len(t, pending, 1)
+	assert.Equal(t, uint32(2), pending[0].Version)
+}
+
+func TestMigrator_ChecksumMismatch(t *testing.T) {
+	ctx := context.Background()
+	conn := openSQLite(t)
+
+	m, err := NewFromFS(conn, repo.DialectSQLite, testFS(), logger.Discard())
+	require.Nil(t, err)
+	require.Nil(t, m.Up(ctx))
+
+	changed := testFS()
+	changed["0001_first.up.sql"] = &fstest.MapFile{Data: []byte("create table first (id integer primary key, name text);")}
+
+	m, err = NewFromFS(conn, repo.DialectSQLite, changed, logger.Discard())
+	require.Nil(t, err)
+
+	err = m.Up(ctx)
+	require.NotNil(t, err)
+	assert.Contains(t, err.Error(), "checksum mismatch of migration 0001_first")
+}
+
+func TestMigrator_UnknownAppliedMigration(t *testing.T) {
+	ctx := context.Background()
+	conn := openSQLite(t)
+
+	m, err := NewFromFS(conn, repo.DialectSQLite, testFS(), logger.Discard())
+	require.Nil(t, err)
+	require.Nil(t, m.Up(ctx))
+
+	older := testFS()
+	delete(older, "0002_second.up.sql")
+	delete(older, "0002_second.down.sql")
+
+	m, err = NewFromFS(conn, repo.DialectSQLite, older, logger.Discard())
+	require.Nil(t, err)
+
+	_, err = m.Status(ctx)
+	require.NotNil(t, err)
+	assert.Contains(t, err.Error(), "0002_second is unknown")
+}
+
+func Test_load_InvalidFiles(t *testing.T) {
+	for _, tc := range []struct {
+		name string
+		fsys fstest.MapFS
+	}{
+		{
+			name: "missing version",
+			fsys: fstest.MapFS{"first.up.sql": {Data: []byte("select 1;")}},
+		},
+		{
+			name: "missing direction",
+			fsys: fstest.MapFS{"0001_first.sql": {Data: []byte("select 1;")}},
+		},
+		{
+			name: "missing up script",
+			fsys: fstest.MapFS{"0001_first.down.sql": {Data: []byte("select 1;")}},
+		},
+		{
+			name: "duplicated version",
+			fsys: fstest.MapFS{
+				"0001_first.up.sql":  {Data: []byte("select 1;")},
+				"0001_second.up.sql": {Data: []byte("select 1;")},
+			},
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			_, err := load(tc.fsys)
+			assert.NotNil(t, err)
+		})
+	}
+}
+
+func TestNew_EmbeddedMigrations(t *testing.T) {
+	ctx := context.Background()
+	conn := openSQLite(t)
+
+	m, err := New(conn, repo.DialectSQLite, logger.Discard())
+	require.Nil(t, err)
+	require.Nil(t, m.Up(ctx))
+
+	assert.True(t, tableExists(t, conn, "wager"))
+	assert.True(t, tableExists(t, conn, "purchases"))
+	assert.True(t, tableExists(t, conn, "settlements"))
+
+	// down scripts must revert all migrations
+	require.Nil(t, m.Down(ctx, len(m.migrations)))
+	assert.False(t, tableExists(t, conn, "wager"))
+	assert.False(t, tableExists(t, conn, "settlements"))
+	require.Nil(t, m.Up(ctx))
+
+	// postgres migrations must load and match sqlite versions
+	pg, err := New(nil, repo.DialectPostgres, logger.Discard())
+	require.Nil(t, err)
+	require.Equal(t, len(m.migrations), len(pg.migrations))
+	for i := range m.migrations {
+		assert.Equal(t, m.migrations[i].Version, pg.migrations[i].Version)
+		assert.Equal(t, m.migrations[i].Name, pg.migrations[i].Name)
+	}
+}
+
+func TestRunCommand(t *testing.T) {
+	ctx := context.Background()
+	conn := openSQLite(t)
+
+	m, err := NewFromFS(conn, repo.DialectSQLite, testFS(), logger.Discard())
+	require.Nil(t, err)
+
+	var out bytes.Buffer
+	require.Nil(t, RunCommand(ctx, m, []string{"up"}, &out))
+	require.Nil(t, RunCommand(ctx, m, []string{"down", "2"}, &out))
+	require.Nil(t, RunCommand(ctx, m, []string{"status"}, &out))
+	assert.Equal(t, "0001_first\tpending\n0002_second\tpending\n", out.String())
+
+	assert.NotNil(t, RunCommand(ctx, m, []string{"down", "zero"}, &out))
+	assert.NotNil(t, RunCommand(ctx, m, []string{"sideways"}, &out))
+	assert.NotNil(t, RunCommand(ctx, m, nil, &out))
+}
@@ -0,0 +1,185 @@
+package openapi
+
+import (
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/vitthalaa/wager-app/internal/validation"
+	"github.com/vitthalaa/wager-app/money"
+)
+
+const schemaRefPrefix = "#/components/schemas/"
+
+var (
+	moneyType = reflect.TypeOf(money.Money(0))
+	timeType  = reflect.TypeOf(time.Time{})
+	zero      = float64(0)
+)
+
+// schemas collects component schemas of DTO types. Schemas are derived from json tags of struct fields,
+// so that spec can not drift from JSON encoded by handlers.
+type schemas struct {
+	components map[string]*Schema
+	// fields are descriptions and enums of DTO fields by "Type.json_name"
+	fields map[string]*Schema
+}
+
+// schemaOf returns schema of values of t, structs are added to components and referenced
+func (s *schemas) schemaOf(t reflect.Type) *Schema {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+
+	switch t {
+	case moneyType:
+		return &Schema{Type: "string", Format: "decimal", Description: "Amount with 2 decimal places", Example: "10.50"}
+	case timeType:
+		return &Schema{Type: "string", Format: "date-time"}
+	}
+
+	switch t.Kind() {
+	case reflect.Struct:
+		if _, ok := s.components[t.Name()]; !ok {
+			s.components[t.Name()] = s.object(t)
+		}
+
+		return &Schema{Ref: schemaRefPrefix + t.Name()}
+	case reflect.Slice, reflect.Array:
+		return &Schema{Type: "array", Items: s.schemaOf(t.Elem())}
+	case reflect.Map:
+		return &Schema{Type: "object", AdditionalProperties: s.schemaOf(t.Elem())}
+	case reflect.Interface:
+		return &Schema{}
+	case reflect.Bool:
+		return &Schema{Type: "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return &Schema{Type: "integer", Format: "int64"}
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return &Schema{Type: "integer", Format: "int64", Minimum: &zero}
+	case reflect.Float32:
+		return &Schema{Type: "number", Format: "float"}
+	case reflect.Float64:
+		return &Schema{Type: "number", Format: "double"}
+	}
+
+	return &Schema{Type: "string"}
+}
+
+// object returns object schema with json encoded fields of struct t
+func (s *schemas) object(t reflect.Type) *Schema {
+	schema := &Schema{Type: "object", Properties: map[string]*Schema{}}
+	s.addFields(schema, t, t.Name())
+	return schema
+}
+
+// addFields adds json encoded fields of t to schema, fields of embedded structs are promoted like encoding/json does
+func (s *schemas) addFields(schema *Schema, t reflect.Type, typeName string) {
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		name, opts, _ := strings.Cut(field.Tag.Get("json"), ",")
+		if name == "-" || !field.IsExported() {
+			continue
+		}
+
+		if field.Anonymous && name == "" && field.Type.Kind() == reflect.Struct {
+			s.addFields(schema, field.Type, typeName)
+			continue
+		}
+
+		if name == "" {
+			name = field.Name
+		}
+
+		property := s.schemaOf(field.Type)
+		if field.Type.Kind() == reflect.Ptr && property.Ref == "" {
+			property.Nullable = true
+		}
+
+		if extra, ok := s.fields[typeName+"."+name]; ok {
+			if extra.Description != "" {
+				property.Description = extra.Description
+			}
+
+			if extra.Enum != nil {
+				property.Enum = extra.Enum
+			}
+
+			if extra.Default != nil {
+				property.Default = extra.Default
+			}
+		}
+
+		applyRules(property, field.Tag.Get("validate"))
+
+		schema.Properties[name] = property
+		if !strings.Contains(opts, "omitempty") {
+			schema.Required = append(schema.Required, name)
+		}
+	}
+}
+
+// applyRules describes validation rules of field in its schema. Money is encoded as string,
+// so its limits are described in text.
+func applyRules(property *Schema, tag string) {
+	rules, err := validation.ParseTag(tag)
+	if err != nil || property.Ref != "" {
+		return
+	}
+
+	var limits []string
+	for _, rule := range rules {
+		if property.Format == "decimal" {
+			if rule.Name != "required" {
+				limits = append(limits, validation.Violation("", rule).Message)
+			}
+
+			continue
+		}
+
+		switch rule.Name {
+		case "min", "gt":
+			if n, err := strconv.ParseFloat(rule.Limit, 64); err == nil {
+				property.Minimum = &n
+				property.ExclusiveMinimum = rule.Name == "gt"
+			}
+		case "max":
+			if n, err := strconv.ParseFloat(rule.Limit, 64); err == nil {
+				property.Maximum = &n
+			}
+		case "minlen":
+			if n, err := strconv.Atoi(rule.Limit); err == nil {
+				property.MinLength = &n
+			}
+		case "maxlen":
+			if n, err := strconv.Atoi(rule.Limit); err == nil {
+				property.MaxLength = &n
+			}
+		case "oneof":
+			property.Enum = strings.Fields(rule.Limit)
+		case "pattern":
+			property.Pattern = rule.Limit
+		}
+	}
+
+	if len(limits) == 0 {
+		return
+	}
+
+	text := "Must be " + strings.Join(trimMust(limits), " and ") + "."
+	if property.Description != "" {
+		text = strings.TrimSuffix(property.Description, ".") + ". " + text
+	}
+
+	property.Description = text
+}
+
+func trimMust(messages []string) []string {
+	trimmed := make([]string, len(messages))
+	for i, message := range messages {
+		trimmed[i] = strings.TrimPrefix(message, "must be ")
+	}
+
+	return trimmed
+}
@@ -0,0 +1,704 @@
+package openapi
+
+import (
+	"net/http"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/vitthalaa/wager-app/app_errors"
+	"github.com/vitthalaa/wager-app/dto"
+	"github.com/vitthalaa/wager-app/internal/events"
+	"github.com/vitthalaa/wager-app/internal/repo"
+	"github.com/vitthalaa/wager-app/internal/services"
+)
+
+// Version is version of OpenAPI specification the document conforms to
+const Version = "3.0.3"
+
+const (
+	tagAuth       = "auth"
+	tagWallet     = "wallet"
+	tagWagers     = "wagers"
+	tagPurchases  = "purchases"
+	tagOrders     = "orders"
+	tagOperations = "operations"
+
+	bearerAuth             = "bearerAuth"
+	jsonContentType        = "application/json"
+	eventStreamContentType = "text/event-stream"
+
+	streamEvents      = "wager_created, price_changed and sold_out events with wager as data"
+	streamDescription = "Events replayed after Last-Event-ID are replaced by single reset event " +
+		"when they are not kept anymore, wagers have to be reloaded then. Comment frames are sent as heartbeat."
+)
+
+// errorCodes are all error codes of API, error of ErrorResponse is one of them
+var errorCodes = []struct {
+	code        app_errors.ErrorCode
+	description string
+}{
+	{app_errors.ErrInvalidBody, "Request body is not single JSON value of expected shape, unknown fields are rejected"},
+	{app_errors.ErrInternalError, "Unexpected server error, request can be retried"},
+	{app_errors.ErrNotImplemented, "Operation is not implemented yet"},
+	{app_errors.ErrNotFound, "Route or resource does not exist"},
+	{app_errors.ErrInvalidFilter, "Query filter or page value can not be parsed or is out of range"},
+	{app_errors.ErrInvalidSort, "Sort field is not supported"},
+	{app_errors.ErrInvalidCursor, "Cursor is not next_cursor returned by previous page of same sort"},
+	{app_errors.ErrUnauthorized, "Bearer token is missing, invalid or expired"},
+	{app_errors.ErrUnavailable, "Server is shutting down, request can be retried on other instance"},
+	{app_errors.ErrUpgradeRequired, "Request is not WebSocket handshake"},
+	{app_errors.ErrOriginNotAllowed, "Origin of WebSocket handshake is neither host of API nor in WS_ALLOWED_ORIGINS"},
+	{app_errors.ErrInvalidMessageType, "Type of WebSocket message must be one of subscribe, unsubscribe or buy"},
+	{app_errors.ErrInvalidTotalWagerValue, "Total wager value must be at least 1"},
+	{app_errors.ErrInvalidOdds, "Odds must be between 1 and 100"},
+	{app_errors.ErrInvalidSellingPercentage, "Selling percentage must be between 1 and 100"},
+	{app_errors.ErrInvalidSellingPrice, "Selling price must be greater than total wager value * selling percentage"},
+	{app_errors.ErrInvalidPricing, "Pricing must be one of last_trade, time_decay, demand_step_up or dutch_auction"},
+	{app_errors.ErrInvalidFloorPrice, "Floor price is required by decaying pricing and must not exceed selling price"},
+	{app_errors.ErrInvalidDecaySeconds, "Decay seconds are required by decaying pricing"},
+	{app_errors.ErrInvalidStepPercentage, "Step percentage is required by demand_step_up pricing, up to 100"},
+	{app_errors.ErrInvalidWagerID, "Wager id must be positive"},
+	{app_errors.ErrInvalidBuyingPrice, "Buying price must be at least 1.00 and not greater than current selling price"},
+	{app_errors.ErrWagerSoldOut, "Selling percentage of wager is sold"},
+	{app_errors.ErrTotalValueSold, "Total value of wager is sold"},
+	{app_errors.ErrSellOutLimitReached, "Wager sold most units allowed by sell out policy"},
+	{app_errors.ErrInvalidQuantity, "Quantity is over 1000000 or greater than units left to sell"},
+	{app_errors.ErrInvalidOrderSide, "Side must be one of bid or ask"},
+	{app_errors.ErrInvalidOrderPrice, "Price must be at least 1.00"},
+	{app_errors.ErrNotWagerSeller, "Only seller of wager can ask for its units"},
+	{app_errors.ErrOrderNotOpen, "Order is already filled or cancelled"},
+	{app_errors.ErrInvalidAuction, "Auction must be one of first_price or second_price, it is required by auction_ends_at"},
+	{app_errors.ErrInvalidAuctionEndsAt, "End of auction is required by auction and must be in the future"},
+	{app_errors.ErrInvalidBidPrice, "Bid price must be at least 1.00"},
+	{app_errors.ErrSellerCannotBid, "Seller of wager can not bid for its units"},
+	{app_errors.ErrAuctionOpen, "Units of wager are sold by auction until it closes"},
+	{app_errors.ErrAuctionClosed, "Wager is not auctioned or its auction ended"},
+	{app_errors.ErrInvalidOutcome, "Outcome must be one of won, lost or void"},
+	{app_errors.ErrWagerSettled, "Wager is already settled"},
+	{app_errors.ErrWagerNotSettled, "Wager is not settled yet"},
+	{app_errors.ErrNotOperator, "Only operators can settle wagers"},
+	{app_errors.ErrInvalidUsername, "Username must be 3 to 32 of letters, digits, _, . or -"},
+	{app_errors.ErrInvalidPassword, "Password must be 8 to 72 characters"},
+	{app_errors.ErrUsernameTaken, "Username is registered already"},
+	{app_errors.ErrInvalidCredentials, "Username or password is wrong"},
+	{app_errors.ErrInvalidAmount, "Amount must be positive"},
+	{app_errors.ErrInsufficientFunds, "Wallet balance is lower than amount"},
+	{app_errors.ErrInvalidIdempotencyKey, "Idempotency-Key header is longer than 255 characters"},
+	{app_errors.ErrIdempotencyKeyReused, "Idempotency-Key was used for different request"},
+	{app_errors.ErrIdempotencyKeyInProgress, "Request with same Idempotency-Key is still being processed"},
+}
+
+// fields are descriptions and enums of DTO fields by "Type.json_name"
+var fields = map[string]*Schema{
+	"PlaceWagerRequest.selling_percentage": {Description: "Percentage of total wager value to sell, 1 to 100"},
+	"PlaceWagerRequest.selling_price": {
+		Description: "Price of whole selling percentage, must be greater than total_wager_value * selling_percentage / 100",
+	},
+	"PlaceWagerRequest.pricing": {
+		Description: "Strategy of current selling price. last_trade prices at last buying price, " +
+			"time_decay at last buying price decaying to floor_price within decay_seconds, " +
+			"demand_step_up raises price by step_percentage for every unit bought up to 1000000000.00, " +
+			"dutch_auction decays selling_price to floor_price within decay_seconds after placing wager",
+		Enum:    pricings(),
+		Default: string(repo.WagerPricingLastTrade),
+	},
+	"PlaceWagerRequest.floor_price":     {Description: "Lowest price of time_decay and dutch_auction pricing"},
+	"PlaceWagerRequest.decay_seconds":   {Description: "Seconds price of time_decay and dutch_auction pricing takes to reach floor_price"},
+	"PlaceWagerRequest.step_percentage": {Description: "Percentage demand_step_up pricing raises price by for every unit bought"},
+	"PlaceWagerRequest.auction": {
+		Description: "Sells units by sealed bids until auction_ends_at, sale starts after auction closes when empty. " +
+			"Highest bids win, first_price winners pay their bid price, second_price winners pay price of highest " +
+			"losing bid or of lowest winning bid when all bids win",
+		Enum: auctions(),
+	},
+	"PlaceWagerRequest.auction_ends_at": {Description: "End of bidding, required by auction"},
+	"Wager.auction":                     {Enum: auctions()},
+	"Wager.auction_closed_at":           {Description: "Time units were awarded to bids, units left are sold by buying and orders then"},
+	"PlaceBidRequest.price":             {Description: "Price of one unit bidder pays at most"},
+	"PlaceBidRequest.quantity":          {Description: "Units to bid for, not greater than units left to sell"},
+	"AuctionBid.awarded":                {Description: "Units bought by bid when auction closed"},
+	"Wager.current_selling_price":       {Description: "Highest buying price of one unit at the moment, follows pricing of wager"},
+	"Wager.pricing":                     {Enum: pricings()},
+	"Wager.amount_sold": {
+		Description: "Units sold, wager is sold out when any rule of sell out policy is reached",
+	},
+	"BuyWagerRequest.buying_price": {Description: "Price of one unit, not greater than current selling price"},
+	"BuyWagerRequest.quantity":     {Description: "Units to buy, not greater than units left to sell", Default: 1},
+	"WagerPurchase.buying_Price":   {Description: "Price of one unit, key differs from buying_price of requests"},
+	"WagerPurchase.total_price":    {Description: "Price paid for all units, buying_price * quantity"},
+	"PlaceOrderRequest.side": {
+		Description: "bid buys units at price or lower, ask sells units at price or higher and is allowed to seller of wager only",
+		Enum:        orderSides(),
+	},
+	"PlaceOrderRequest.price":    {Description: "Limit price of one unit"},
+	"PlaceOrderRequest.quantity": {Description: "Units to trade, not greater than units left to sell"},
+	"Order.side":                 {Enum: orderSides()},
+	"Order.filled":               {Description: "Units traded so far"},
+	"Order.status": {Enum: []string{
+		string(repo.OrderStatusOpen), string(repo.OrderStatusFilled), string(repo.OrderStatusCancelled),
+	}},
+	"Order.purchases":         {Description: "Purchases of units traded when order was placed"},
+	"OrderBook.bids":          {Description: "Price levels of open bids, highest price first"},
+	"OrderBook.asks":          {Description: "Price levels of open asks, lowest price first"},
+	"OrderBookLevel.quantity": {Description: "Units left of open orders at price"},
+	"OrderBookLevel.orders":   {Description: "Number of open orders at price"},
+	"Wager.status": {Enum: []string{
+		string(repo.WagerStatusOpen), string(repo.WagerStatusSoldOut),
+		string(repo.WagerStatusSettled), string(repo.WagerStatusVoided),
+	}},
+	"Wager.outcome":              {Enum: outcomes()},
+	"Wager.seller_id":            {Description: "User who placed the wager"},
+	"WagerList.next_cursor":      {Description: "Cursor of next page, omitted on last page"},
+	"WagerSettlement.status":     {Enum: []string{string(repo.WagerStatusSettled), string(repo.WagerStatusVoided)}},
+	"WagerSettlement.outcome":    {Enum: outcomes()},
+	"PurchaseSettlement.outcome": {Enum: outcomes()},
+	"PurchaseSettlement.payout":  {Description: "Credited to buyer wallet, odds per unit if won, total price if void"},
+	"Token.token_type":           {Enum: []string{"Bearer"}},
+	"LedgerEntry.kind": {Enum: []string{
+		string(repo.LedgerTransactionDeposit), string(repo.LedgerTransactionWithdrawal),
+		string(repo.LedgerTransactionPurchase), string(repo.LedgerTransactionPayout), string(repo.LedgerTransactionRefund),
+	}},
+	"LedgerEntry.amount":  {Description: "Positive for credits, negative for debits"},
+	"Health.status":       {Enum: []string{dto.HealthOK, dto.HealthFailing}},
+	"Health.checks":       {Description: "Status of each check by name"},
+	"ClientMessage.type":  {Enum: []string{dto.MessageSubscribe, dto.MessageUnsubscribe, dto.MessageBuy}},
+	"ServerMessage.type":  {Enum: []string{dto.MessageAck, dto.MessageError, dto.MessageEvent}},
+	"ServerMessage.event": {Enum: []string{events.TypeWagerCreated, events.TypePriceChanged, events.TypeSoldOut, events.TypeReset}},
+	"ErrorResponse.error": {Description: "Error code, see descriptions of responses of each operation. Code of first violation when details are present"},
+}
+
+func outcomes() []string {
+	return []string{string(repo.WagerOutcomeWon), string(repo.WagerOutcomeLost), string(repo.WagerOutcomeVoid)}
+}
+
+func orderSides() []string {
+	return []string{string(repo.OrderSideBid), string(repo.OrderSideAsk)}
+}
+
+func auctions() []string {
+	return []string{string(repo.WagerAuctionFirstPrice), string(repo.WagerAuctionSecondPrice)}
+}
+
+func pricings() []string {
+	return []string{
+		string(repo.WagerPricingLastTrade), string(repo.WagerPricingTimeDecay),
+		string(repo.WagerPricingDemandStepUp), string(repo.WagerPricingDutchAuction),
+	}
+}
+
+// operation is single route of API, spec of common parameters and errors is derived from its flags
+type operation struct {
+	method      string
+	path        string
+	id          string
+	tag         string
+	summary     string
+	description string
+	// auth is true when operation requires bearer token
+	auth bool
+	// idempotent is true when operation accepts Idempotency-Key header
+	idempotent bool
+	query      []Parameter
+	request    interface{}
+	status     int
+	response   interface{}
+	// contentType of response, application/json when empty
+	contentType string
+	errors      map[int][]app_errors.ErrorCode
+	// alternates are non error responses other than status by status code
+	alternates map[int]interface{}
+	// messages are types of WebSocket messages, their schemas are added to components
+	messages []interface{}
+}
+
+var (
+	pageParams = []Parameter{
+		{Name: "page", In: "query", Description: "Page number starting from 1", Schema: &Schema{Type: "integer", Minimum: &one}},
+		{Name: "limit", In: "query", Description: "Page size", Schema: &Schema{Type: "integer", Minimum: &one, Maximum: &maxPageSize}},
+	}
+
+	streamParams = []Parameter{
+		{
+			Name: "Last-Event-ID", In: "header", Description: "Id of last received event, events after it are replayed",
+			Schema: &Schema{Type: "integer"},
+		},
+		{
+			Name: "last_event_id", In: "query", Description: "Same as Last-Event-ID header, for clients not able to set headers",
+			Schema: &Schema{Type: "integer"},
+		},
+	}
+
+	one              = float64(1)
+	maxWagerPageSize = float64(services.MaxWagerPageSize)
+	maxPageSize      = float64(services.MaxPageSize)
+)
+
+var operations = []operation{
+	{
+		method: http.MethodPost, path: "/auth/register", id: "register", tag: tagAuth,
+		summary: "Register user", description: "Creates user with empty wallet",
+		request: dto.RegisterRequest{}, status: http.StatusCreated, response: dto.User{},
+		errors: map[int][]app_errors.ErrorCode{
+			http.StatusBadRequest: {app_errors.ErrInvalidUsername, app_errors.ErrInvalidPassword},
+			http.StatusConflict:   {app_errors.ErrUsernameTaken},
+		},
+	},
+	{
+		method: http.MethodPost, path: "/auth/login", id: "login", tag: tagAuth,
+		summary: "Login", description: "Returns bearer token to be sent in Authorization header",
+		request: dto.LoginRequest{}, status: http.StatusOK, response: dto.Token{},
+		errors: map[int][]app_errors.ErrorCode{
+			http.StatusUnauthorized: {app_errors.ErrInvalidCredentials},
+		},
+	},
+	{
+		method: http.MethodGet, path: "/wallet", id: "getWallet", tag: tagWallet,
+		summary: "Get wallet balance", auth: true, status: http.StatusOK, response: dto.Wallet{},
+	},
+	{
+		method: http.MethodPost, path: "/wallet/deposit", id: "deposit", tag: tagWallet,
+		summary: "Deposit to wallet", auth: true,
+		request: dto.DepositRequest{}, status: http.StatusOK, response: dto.Wallet{},
+		errors: map[int][]app_errors.ErrorCode{
+			http.StatusBadRequest: {app_errors.ErrInvalidAmount},
+		},
+	},
+	{
+		method: http.MethodPost, path: "/wallet/withdraw", id: "withdraw", tag: tagWallet,
+		summary: "Withdraw from wallet", auth: true,
+		request: dto.WithdrawRequest{}, status: http.StatusOK, response: dto.Wallet{},
+		errors: map[int][]app_errors.ErrorCode{
+			http.StatusBadRequest:      {app_errors.ErrInvalidAmount},
+			http.StatusPaymentRequired: {app_errors.ErrInsufficientFunds},
+		},
+	},
+	{
+		method: http.MethodGet, path: "/wallet/entries", id: "listLedgerEntries", tag: tagWallet,
+		summary: "List wallet ledger entries", description: "Newest entries first", auth: true,
+		query: pageParams, status: http.StatusOK, response: dto.LedgerEntryList{},
+		errors: map[int][]app_errors.ErrorCode{
+			http.StatusBadRequest: {app_errors.ErrInvalidFilter},
+		},
+	},
+	{
+		method: http.MethodPost, path: "/wagers", id: "placeWager", tag: tagWagers,
+		summary: "Place wager", description: "Authenticated user becomes seller of the wager",
+		auth: true, idempotent: true,
+		request: dto.PlaceWagerRequest{}, status: http.StatusOK, response: dto.Wager{},
+		errors: map[int][]app_errors.ErrorCode{
+			http.StatusBadRequest: {
+				app_errors.ErrInvalidTotalWagerValue, app_errors.ErrInvalidOdds,
+				app_errors.ErrInvalidSellingPercentage, app_errors.ErrInvalidSellingPrice,
+				app_errors.ErrInvalidPricing, app_errors.ErrInvalidFloorPrice, app_errors.ErrInvalidDecaySeconds,
+				app_errors.ErrInvalidStepPercentage, app_errors.ErrInvalidAuction, app_errors.ErrInvalidAuctionEndsAt,
+			},
+		},
+	},
+	{
+		method: http.MethodGet, path: "/wagers", id: "listWagers", tag: tagWagers,
+		summary: "List wagers", description: "Filters are combined, latest wagers first by default",
+		query: []Parameter{
+			{Name: "min_odds", In: "query", Schema: &Schema{Type: "integer", Minimum: &one}},
+			{Name: "max_odds", In: "query", Schema: &Schema{Type: "integer", Minimum: &one}},
+			{
+				Name: "min_price", In: "query", Description: "Minimum current selling price, time priced wagers are left out",
+				Schema: &Schema{Type: "string", Format: "decimal"},
+			},
+			{
+				Name: "max_price", In: "query", Description: "Maximum current selling price, time priced wagers are left out",
+				Schema: &Schema{Type: "string", Format: "decimal"},
+			},
+			{
+				Name: "sold_out", In: "query", Description: "Only sold out wagers when true, only not sold out when false",
+				Schema: &Schema{Type: "boolean"},
+			},
+			{Name: "from", In: "query", Description: "Placed at or after", Schema: &Schema{Type: "string", Format: "date-time"}},
+			{Name: "to", In: "query", Description: "Placed at or before", Schema: &Schema{Type: "string", Format: "date-time"}},
+			{
+				Name: "sort", In: "query",
+				Description: "Sort field, prefixed with - for descending order, ties are broken by id. " +
+					"current_selling_price leaves out time_decay and dutch_auction wagers, their price moves with time",
+				Schema: &Schema{Type: "string", Default: "-id", Enum: []string{
+					"id", "-id", "current_selling_price", "-current_selling_price", "odds", "-odds",
+					"percentage_sold", "-percentage_sold", "placed_at", "-placed_at",
+				}},
+			},
+			{
+				Name: "cursor", In: "query", Description: "next_cursor of previous page with same sort, omitted for first page",
+				Schema: &Schema{Type: "string"},
+			},
+			{
+				Name: "limit", In: "query", Description: "Page size",
+				Schema: &Schema{Type: "integer", Minimum: &one, Maximum: &maxWagerPageSize},
+			},
+		},
+		status: http.StatusOK, response: dto.WagerList{},
+		errors: map[int][]app_errors.ErrorCode{
+			http.StatusBadRequest: {app_errors.ErrInvalidFilter, app_errors.ErrInvalidSort, app_errors.ErrInvalidCursor},
+		},
+	},
+	{
+		method: http.MethodGet, path: "/wagers/stream", id: "streamWagers", tag: tagWagers,
+		summary:     "Stream changes of wagers",
+		description: "Server-Sent Events of " + streamEvents + ". " + streamDescription,
+		query:       streamParams, status: http.StatusOK, contentType: eventStreamContentType,
+		errors: map[int][]app_errors.ErrorCode{
+			http.StatusServiceUnavailable: {app_errors.ErrUnavailable},
+		},
+	},
+	{
+		method: http.MethodGet, path: "/wagers/{id}", id: "getWager", tag: tagWagers,
+		summary: "Get wager with page of its purchases", query: pageParams,
+		status: http.StatusOK, response: dto.WagerDetails{},
+		errors: map[int][]app_errors.ErrorCode{
+			http.StatusBadRequest: {app_errors.ErrInvalidWagerID, app_errors.ErrInvalidFilter},
+		},
+	},
+	{
+		method: http.MethodGet, path: "/wagers/{id}/stream", id: "streamWager", tag: tagWagers,
+		summary:     "Stream changes of wager",
+		description: "Server-Sent Events of " + streamEvents + " of the wager. " + streamDescription,
+		query:       streamParams, status: http.StatusOK, contentType: eventStreamContentType,
+		errors: map[int][]app_errors.ErrorCode{
+			http.StatusBadRequest:         {app_errors.ErrInvalidWagerID},
+			http.StatusServiceUnavailable: {app_errors.ErrUnavailable},
+		},
+	},
+	{
+		method: http.MethodPost, path: "/wagers/{id}/bids", id: "placeBid", tag: tagWagers,
+		summary: "Bid for units of auctioned wager",
+		description: "Bids are sealed, they are not disclosed to other users. Bids do not reserve funds, bids not covered " +
+			"by wallet when auction closes are skipped. Units are awarded to highest bids, earliest first at same price, " +
+			"and paid from bidder wallet to seller wallet.",
+		auth: true, idempotent: true,
+		request: dto.PlaceBidRequest{}, status: http.StatusOK, response: dto.AuctionBid{},
+		errors: map[int][]app_errors.ErrorCode{
+			http.StatusBadRequest:      {app_errors.ErrInvalidWagerID, app_errors.ErrInvalidBidPrice, app_errors.ErrInvalidQuantity},
+			http.StatusPaymentRequired: {app_errors.ErrInsufficientFunds},
+			http.StatusForbidden:       {app_errors.ErrSellerCannotBid},
+			http.StatusNotAcceptable:   {app_errors.ErrWagerSoldOut, app_errors.ErrTotalValueSold, app_errors.ErrSellOutLimitReached},
+			http.StatusConflict:        {app_errors.ErrWagerSettled, app_errors.ErrAuctionClosed},
+		},
+	},
+	{
+		method: http.MethodGet, path: "/wagers/{id}/bids", id: "listBids", tag: tagWagers,
+		summary: "List own bids for auctioned wager", description: "Bids of other users are never disclosed", auth: true,
+		status: http.StatusOK, response: []dto.AuctionBid{},
+	},
+	{
+		method: http.MethodPost, path: "/wagers/{id}/settle", id: "settleWager", tag: tagWagers,
+		summary: "Settle wager", description: "Pays out every purchase of the wager by outcome, only operators can",
+		auth: true, request: dto.SettleWagerRequest{}, status: http.StatusOK, response: dto.WagerSettlement{},
+		errors: map[int][]app_errors.ErrorCode{
+			http.StatusBadRequest: {app_errors.ErrInvalidWagerID, app_errors.ErrInvalidOutcome},
+			http.StatusForbidden:  {app_errors.ErrNotOperator},
+			http.StatusConflict:   {app_errors.ErrWagerSettled},
+		},
+	},
+	{
+		method: http.MethodGet, path: "/wagers/{id}/settlement", id: "getSettlement", tag: tagWagers,
+		summary: "Get settlement of wager", status: http.StatusOK, response: dto.WagerSettlement{},
+		errors: map[int][]app_errors.ErrorCode{
+			http.StatusBadRequest: {app_errors.ErrInvalidWagerID},
+			http.StatusNotFound:   {app_errors.ErrWagerNotSettled},
+		},
+	},
+	{
+		method: http.MethodPost, path: "/buy/{id}", id: "buyWager", tag: tagPurchases,
+		summary: "Buy wager", description: "Moves total price of bought units from buyer wallet to seller wallet",
+		auth: true, idempotent: true,
+		request: dto.BuyWagerRequest{}, status: http.StatusOK, response: dto.WagerPurchase{},
+		errors: map[int][]app_errors.ErrorCode{
+			http.StatusBadRequest:      {app_errors.ErrInvalidWagerID, app_errors.ErrInvalidBuyingPrice, app_errors.ErrInvalidQuantity},
+			http.StatusPaymentRequired: {app_errors.ErrInsufficientFunds},
+			http.StatusNotAcceptable:   {app_errors.ErrWagerSoldOut, app_errors.ErrTotalValueSold, app_errors.ErrSellOutLimitReached},
+			http.StatusConflict:        {app_errors.ErrWagerSettled, app_errors.ErrAuctionOpen},
+		},
+	},
+	{
+		method: http.MethodGet, path: "/purchases", id: "listPurchases", tag: tagPurchases,
+		summary: "List purchases", description: "Filters are combined, newest purchases first by default",
+		query: append([]Parameter{
+			{Name: "wager_id", In: "query", Schema: &Schema{Type: "integer", Minimum: &one}},
+			{Name: "min_price", In: "query", Schema: &Schema{Type: "string", Format: "decimal"}},
+			{Name: "max_price", In: "query", Schema: &Schema{Type: "string", Format: "decimal"}},
+			{Name: "from", In: "query", Description: "Bought at or after", Schema: &Schema{Type: "string", Format: "date-time"}},
+			{Name: "to", In: "query", Description: "Bought at or before", Schema: &Schema{Type: "string", Format: "date-time"}},
+			{
+				Name: "sort", In: "query", Description: "Sort field, prefixed with - for descending order",
+				Schema: &Schema{Type: "string", Enum: []string{
+					"id", "-id", "buying_price", "-buying_price", "bought_at", "-bought_at",
+				}},
+			},
+		}, pageParams...),
+		status: http.StatusOK, response: dto.WagerPurchaseList{},
+		errors: map[int][]app_errors.ErrorCode{
+			http.StatusBadRequest: {app_errors.ErrInvalidFilter, app_errors.ErrInvalidSort},
+		},
+	},
+	{
+		method: http.MethodGet, path: "/purchases/{id}", id: "getPurchase", tag: tagPurchases,
+		summary: "Get purchase", status: http.StatusOK, response: dto.WagerPurchase{},
+	},
+	{
+		method: http.MethodPost, path: "/orders", id: "placeOrder", tag: tagOrders,
+		summary: "Place order",
+		description: "Matches order against open orders of other side of wager book, best price first and oldest first " +
+			"at same price. Crossed orders trade at price of the open order, every trade is purchase paid from bidder wallet " +
+			"to seller wallet. Units not traded stay open until wager sells out or is settled. Bids do not reserve funds " +
+			"but open bids of user together are limited by wallet balance, open bids not covered by wallet when crossed " +
+			"are cancelled.",
+		auth: true, idempotent: true,
+		request: dto.PlaceOrderRequest{}, status: http.StatusOK, response: dto.Order{},
+		errors: map[int][]app_errors.ErrorCode{
+			http.StatusBadRequest: {
+				app_errors.ErrInvalidWagerID, app_errors.ErrInvalidOrderSide, app_errors.ErrInvalidOrderPrice,
+				app_errors.ErrInvalidQuantity,
+			},
+			http.StatusPaymentRequired: {app_errors.ErrInsufficientFunds},
+			http.StatusForbidden:       {app_errors.ErrNotWagerSeller},
+			http.StatusNotFound:        {app_errors.ErrNotFound},
+			http.StatusNotAcceptable:   {app_errors.ErrWagerSoldOut, app_errors.ErrTotalValueSold, app_errors.ErrSellOutLimitReached},
+			http.StatusConflict:        {app_errors.ErrWagerSettled, app_errors.ErrAuctionOpen},
+		},
+	},
+	{
+		method: http.MethodGet, path: "/orders/{id}", id: "getOrder", tag: tagOrders,
+		summary: "Get order", description: "Orders of other users are not found", auth: true,
+		status: http.StatusOK, response: dto.Order{},
+	},
+	{
+		method: http.MethodDelete, path: "/orders/{id}", id: "cancelOrder", tag: tagOrders,
+		summary: "Cancel order", description: "Units not traded leave the book", auth: true,
+		status: http.StatusOK, response: dto.Order{},
+		errors: map[int][]app_errors.ErrorCode{
+			http.StatusConflict: {app_errors.ErrOrderNotOpen},
+		},
+	},
+	{
+		method: http.MethodGet, path: "/books/{id}", id: "getOrderBook", tag: tagOrders,
+		summary: "Get order book of wager", description: "Open orders grouped by price, book of sold out or settled wager is empty",
+		query: []Parameter{
+			{
+				Name: "levels", In: "query", Description: "Number of best prices of each side, at most 100",
+				Schema: &Schema{Type: "integer", Default: 10},
+			},
+		},
+		status: http.StatusOK, response: dto.OrderBook{},
+		errors: map[int][]app_errors.ErrorCode{
+			http.StatusBadRequest: {app_errors.ErrInvalidFilter},
+		},
+	},
+	{
+		method: http.MethodGet, path: "/ws", id: "socket", tag: tagWagers,
+		summary: "WebSocket API for live trading",
+		description: "Client sends ClientMessage to subscribe to events of wager (all wagers for wager_id 0), " +
+			"unsubscribe or buy wager. Server answers every message by ack or error ServerMessage with id of the message " +
+			"and sends subscribed events as event messages. Buying requires bearer token of handshake request. " +
+			"Clients not reading fast enough are closed with code 1008 and resubscribe with last_event_id. " +
+			"Handshakes of browser pages of other origins than API host are refused unless allowed by configuration.",
+		status: http.StatusSwitchingProtocols, messages: []interface{}{dto.ClientMessage{}, dto.ServerMessage{}},
+		errors: map[int][]app_errors.ErrorCode{
+			http.StatusForbidden:          {app_errors.ErrOriginNotAllowed},
+			http.StatusUpgradeRequired:    {app_errors.ErrUpgradeRequired},
+			http.StatusServiceUnavailable: {app_errors.ErrUnavailable},
+		},
+	},
+	{
+		method: http.MethodGet, path: "/healthz", id: "live", tag: tagOperations,
+		summary: "Liveness probe", status: http.StatusOK, response: dto.Health{},
+	},
+	{
+		method: http.MethodGet, path: "/readyz", id: "ready", tag: tagOperations,
+		summary:     "Readiness probe",
+		description: "Fails while database is unreachable, migrations are pending or server is shutting down",
+		status:      http.StatusOK, response: dto.Health{},
+		alternates: map[int]interface{}{http.StatusServiceUnavailable: dto.Health{}},
+	},
+	{
+		method: http.MethodGet, path: "/metrics", id: "metrics", tag: tagOperations,
+		summary: "Prometheus metrics", status: http.StatusOK, contentType: "text/plain; version=0.0.4",
+	},
+	{
+		method: http.MethodGet, path: "/openapi.json", id: "openapi", tag: tagOperations,
+		summary: "OpenAPI specification of API", status: http.StatusOK, response: map[string]interface{}{},
+	},
+	{
+		method: http.MethodGet, path: "/docs", id: "docs", tag: tagOperations,
+		summary: "Viewer of OpenAPI specification", status: http.StatusOK, contentType: "text/html",
+	},
+}
+
+// Spec returns OpenAPI document of all routes of API
+func Spec() *Document {
+	s := &schemas{components: map[string]*Schema{}, fields: fields}
+	doc := &Document{
+		OpenAPI: Version,
+		Info: Info{
+			Title:       "Wager App API",
+			Description: "Place, buy and settle wagers. Amounts are decimal strings with 2 decimal places.",
+			Version:     "1.0.0",
+		},
+		Tags: []Tag{
+			{Name: tagAuth, Description: "Registration and login"},
+			{Name: tagWallet, Description: "Wallet balance and ledger"},
+			{Name: tagWagers, Description: "Placing and settling wagers"},
+			{Name: tagPurchases, Description: "Buying wagers"},
+			{Name: tagOrders, Description: "Order books of bids and asks for units of wagers"},
+			{Name: tagOperations, Description: "Probes, metrics and docs"},
+		},
+		Paths: map[string]*PathItem{},
+		Components: Components{
+			Schemas: s.components,
+			SecuritySchemes: map[string]SecurityScheme{
+				bearerAuth: {
+					Type: "http", Scheme: "bearer", BearerFormat: "JWT",
+					Description: "Token of POST /auth/login. Invalid token is rejected on every route, even public ones.",
+				},
+			},
+		},
+	}
+
+	errorSchema := s.schemaOf(reflect.TypeOf(app_errors.ErrorResponse{}))
+	codes := make([]string, len(errorCodes))
+	for i, c := range errorCodes {
+		codes[i] = string(c.code)
+	}
+	s.components["ErrorResponse"].Properties["error"].Enum = codes
+
+	for _, op := range operations {
+		item, ok := doc.Paths[op.path]
+		if !ok {
+			item = &PathItem{}
+			doc.Paths[op.path] = item
+		}
+
+		(*item)[strings.ToLower(op.method)] = op.build(s, errorSchema)
+	}
+
+	return doc
+}
+
+// build returns spec of operation, adding parameters and errors common to routes with same flags
+func (op operation) build(s *schemas, errorSchema *Schema) *Operation {
+	spec := &Operation{
+		OperationID: op.id,
+		Summary:     op.summary,
+		Description: op.description,
+		Tags:        []string{op.tag},
+		Responses:   map[string]*Response{},
+	}
+
+	errors := map[int][]app_errors.ErrorCode{}
+	addError := func(status int, code app_errors.ErrorCode) {
+		errors[status] = append(errors[status], code)
+	}
+
+	if strings.Contains(op.path, "{id}") {
+		spec.Parameters = append(spec.Parameters, Parameter{
+			Name: "id", In: "path", Required: true, Schema: &Schema{Type: "integer", Minimum: &one},
+		})
+		addError(http.StatusNotFound, app_errors.ErrNotFound)
+	}
+
+	spec.Parameters = append(spec.Parameters, op.query...)
+	for _, message := range op.messages {
+		s.schemaOf(reflect.TypeOf(message))
+	}
+
+	if op.request != nil {
+		spec.RequestBody = &RequestBody{
+			Required: true,
+			Content:  map[string]MediaType{jsonContentType: {Schema: s.schemaOf(reflect.TypeOf(op.request))}},
+		}
+		addError(http.StatusBadRequest, app_errors.ErrInvalidBody)
+	}
+
+	if op.auth {
+		spec.Security = []SecurityRequirement{{bearerAuth: {}}}
+		addError(http.StatusUnauthorized, app_errors.ErrUnauthorized)
+	}
+
+	if op.idempotent {
+		spec.Parameters = append(spec.Parameters, Parameter{
+			Name: "Idempotency-Key", In: "header",
+			Description: "Retries with same key get response of first request replayed",
+			Schema:      &Schema{Type: "string"},
+		})
+		addError(http.StatusBadRequest, app_errors.ErrInvalidIdempotencyKey)
+		addError(http.StatusConflict, app_errors.ErrIdempotencyKeyInProgress)
+		addError(http.StatusUnprocessableEntity, app_errors.ErrIdempotencyKeyReused)
+	}
+
+	for status, codes := range op.errors {
+		for _, code := range codes {
+			addError(status, code)
+		}
+	}
+
+	if op.tag != tagOperations {
+		addError(http.StatusInternalServerError, app_errors.ErrInternalError)
+	}
+
+	spec.Responses[strconv.Itoa(op.status)] = op.success(s)
+	for status, response := range op.alternates {
+		spec.Responses[strconv.Itoa(status)] = &Response{
+			Description: http.StatusText(status),
+			Content:     map[string]MediaType{jsonContentType: {Schema: s.schemaOf(reflect.TypeOf(response))}},
+		}
+	}
+
+	for status, codes := range errors {
+		spec.Responses[strconv.Itoa(status)] = &Response{
+			Description: errorDescription(status, codes),
+			Content:     map[string]MediaType{jsonContentType: {Schema: errorSchema}},
+		}
+	}
+
+	if _, ok := errors[http.StatusUnauthorized]; ok {
+		spec.Responses[strconv.Itoa(http.StatusUnauthorized)].Headers = map[string]Header{
+			"WWW-Authenticate": {Schema: &Schema{Type: "string", Example: "Bearer"}},
+		}
+	}
+
+	return spec
+}
+
+// success returns response of operation with op.status
+func (op operation) success(s *schemas) *Response {
+	response := &Response{Description: http.StatusText(op.status)}
+	switch {
+	case op.contentType != "":
+		response.Content = map[string]MediaType{op.contentType: {Schema: &Schema{Type: "string"}}}
+	case op.response != nil:
+		response.Content = map[string]MediaType{jsonContentType: {Schema: s.schemaOf(reflect.TypeOf(op.response))}}
+	}
+
+	if op.idempotent {
+		response.Headers = map[string]Header{
+			"Idempotent-Replayed": {
+				Description: "Set to true on response replayed for retried Idempotency-Key",
+				Schema:      &Schema{Type: "boolean"},
+			},
+		}
+	}
+
+	return response
+}
+
+// errorDescription lists error codes of response with their meaning
+func errorDescription(status int, codes []app_errors.ErrorCode) string {
+	sort.Slice(codes, func(i, j int) bool { return codes[i] < codes[j] })
+	lines := []string{http.StatusText(status)}
+	for _, code := range codes {
+		for _, c := range errorCodes {
+			if c.code == code {
+				lines = append(lines, "- `"+string(code)+"`: "+c.description)
+			}
+		}
+	}
+
+	return strings.Join(lines, "\n")
+}
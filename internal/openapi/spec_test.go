@@ -0,0 +1,184 @@
+package openapi
+
+import (
+	"encoding/json"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"reflect"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// parsePackage returns files of package in dir, parsed from source so that new types are picked up without
+// being listed anywhere
+func parsePackage(t *testing.T, dir string) []*ast.File {
+	pkgs, err := parser.ParseDir(token.NewFileSet(), dir, nil, 0)
+	require.Nil(t, err)
+
+	var files []*ast.File
+	for name, pkg := range pkgs {
+		if strings.HasSuffix(name, "_test") {
+			continue
+		}
+
+		for _, file := range pkg.Files {
+			files = append(files, file)
+		}
+	}
+
+	return files
+}
+
+// jsonFields returns json names of fields of struct types by type name. Types without json tags are
+// built by handlers from query params and are not encoded.
+func jsonFields(files []*ast.File) map[string][]string {
+	types := map[string][]string{}
+	for _, file := range files {
+		ast.Inspect(file, func(node ast.Node) bool {
+			spec, ok := node.(*ast.TypeSpec)
+			if !ok {
+				return true
+			}
+
+			st, ok := spec.Type.(*ast.StructType)
+			if !ok {
+				return false
+			}
+
+			for _, field := range st.Fields.List {
+				if field.Tag == nil || len(field.Names) == 0 {
+					continue
+				}
+
+				tag, err := strconv.Unquote(field.Tag.Value)
+				if err != nil {
+					continue
+				}
+
+				name, _, _ := strings.Cut(reflect.StructTag(tag).Get("json"), ",")
+				if name == "-" || !field.Names[0].IsExported() {
+					continue
+				}
+
+				if name == "" {
+					name = field.Names[0].Name
+				}
+
+				types[spec.Name.Name] = append(types[spec.Name.Name], name)
+			}
+
+			return false
+		})
+	}
+
+	return types
+}
+
+func TestSpec_DescribesDTOFields(t *testing.T) {
+	doc := Spec()
+	types := jsonFields(append(parsePackage(t, "../../dto"), parsePackage(t, "../../app_errors")...))
+	require.NotEmpty(t, types)
+
+	for typeName, names := range types {
+		schema, ok := doc.Components.Schemas[typeName]
+		if !assert.True(t, ok, "schema of %s is missing", typeName) {
+			continue
+		}
+
+		for _, name := range names {
+			assert.Contains(t, schema.Properties, name, "field %s of %s is missing", name, typeName)
+		}
+	}
+
+	// embedded struct fields are promoted
+	assert.Contains(t, doc.Components.Schemas["WagerDetails"].Properties, "current_selling_price")
+	assert.Contains(t, doc.Components.Schemas["WagerPurchase"].Properties, "buying_Price")
+}
+
+func TestSpec_DescribesErrorCodes(t *testing.T) {
+	doc := Spec()
+	var codes []string
+	for _, file := range parsePackage(t, "../../app_errors") {
+		ast.Inspect(file, func(node ast.Node) bool {
+			spec, ok := node.(*ast.ValueSpec)
+			if !ok {
+				return true
+			}
+
+			if ident, ok := spec.Type.(*ast.Ident); ok && ident.Name == "ErrorCode" {
+				for _, value := range spec.Values {
+					code, err := strconv.Unquote(value.(*ast.BasicLit).Value)
+					require.Nil(t, err)
+					codes = append(codes, code)
+				}
+			}
+
+			return false
+		})
+	}
+
+	require.NotEmpty(t, codes)
+	enum := doc.Components.Schemas["ErrorResponse"].Properties["error"].Enum
+	assert.ElementsMatch(t, codes, enum)
+
+	for _, c := range errorCodes {
+		assert.NotEmpty(t, c.description, c.code)
+	}
+}
+
+func TestSpec_OperationErrors(t *testing.T) {
+	doc := Spec()
+
+	placeWager := (*doc.Paths["/wagers"])["post"]
+	require.NotNil(t, placeWager)
+	assert.Contains(t, placeWager.Responses["400"].Description, "`INVALID_ODDS`")
+	assert.Contains(t, placeWager.Responses["400"].Description, "`INVALID_BODY`")
+	assert.Contains(t, placeWager.Responses["401"].Description, "`UNAUTHORIZED`")
+	assert.Contains(t, placeWager.Responses["422"].Description, "`IDEMPOTENCY_KEY_REUSED`")
+	assert.Equal(t, "#/components/schemas/Wager", placeWager.Responses["200"].Content[jsonContentType].Schema.Ref)
+
+	buyWager := (*doc.Paths["/buy/{id}"])["post"]
+	require.NotNil(t, buyWager)
+	assert.Equal(t, "id", buyWager.Parameters[0].Name)
+	assert.Contains(t, buyWager.Responses["404"].Description, "`NOT_FOUND`")
+	assert.Contains(t, buyWager.Responses["406"].Description, "`WAGER_SOLD_OUT`")
+	assert.Contains(t, buyWager.Responses["402"].Description, "`INSUFFICIENT_FUNDS`")
+
+	listWagers := (*doc.Paths["/wagers"])["get"]
+	require.NotNil(t, listWagers)
+	assert.Empty(t, listWagers.Security)
+	assert.NotContains(t, listWagers.Responses, "401")
+}
+
+func TestSpec_RefsResolve(t *testing.T) {
+	doc := Spec()
+	body, err := json.Marshal(doc)
+	require.Nil(t, err)
+
+	var raw interface{}
+	require.Nil(t, json.Unmarshal(body, &raw))
+
+	var walk func(v interface{})
+	walk = func(v interface{}) {
+		switch v := v.(type) {
+		case map[string]interface{}:
+			if ref, ok := v["$ref"].(string); ok {
+				assert.Contains(t, doc.Components.Schemas, strings.TrimPrefix(ref, schemaRefPrefix), ref)
+			}
+
+			for _, child := range v {
+				walk(child)
+			}
+		case []interface{}:
+			for _, child := range v {
+				walk(child)
+			}
+		}
+	}
+	walk(raw)
+}
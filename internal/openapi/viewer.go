@@ -0,0 +1,10 @@
+package openapi
+
+import (
+	_ "embed"
+)
+
+// ViewerHTML is self-contained page rendering openapi.json served next to it, it needs no CDN assets
+//
+//go:embed viewer.html
+var ViewerHTML []byte
@@ -0,0 +1,123 @@
+package repo
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/vitthalaa/wager-app/money"
+)
+
+const (
+	insertAuctionBidStmt = `insert into auction_bids(wager_id, user_id, price, quantity)
+							values ($1, $2, $3, $4) returning *`
+	// bids in award order, highest price first and earliest first at same price
+	listAuctionBidsStmt     = `select * from auction_bids where wager_id = $1 order by price desc, id`
+	listUserAuctionBidsStmt = `select * from auction_bids where wager_id = $1 and user_id = $2 order by id`
+	updateAuctionBidStmt    = `update auction_bids set awarded = $1, updated_at = current_timestamp where id = $2`
+)
+
+// AuctionBid is sealed bid for units of auctioned wager at price of one unit
+type AuctionBid struct {
+	ID       uint32
+	WagerID  uint32
+	UserID   uint32
+	Price    money.Money
+	Quantity uint32
+	// Awarded is number of units bought by bid when auction was closed
+	Awarded   uint32
+	CreatedAt sql.NullTime
+	UpdatedAt sql.NullTime
+}
+
+// IAuctionBidRepo is repository interface for auction bid db operations
+type IAuctionBidRepo interface {
+	CreateAuctionBid(ctx context.Context, bid *AuctionBid) (*AuctionBid, error)
+	ListAuctionBids(ctx context.Context, wagerID uint32) ([]AuctionBid, error)
+	ListUserAuctionBids(ctx context.Context, wagerID, userID uint32) ([]AuctionBid, error)
+	UpdateAuctionBid(ctx context.Context, bid *AuctionBid) error
+}
+
+// NewAuctionBidRepo ...
+func NewAuctionBidRepo(db *sql.DB) *AuctionBidRepo {
+	return &AuctionBidRepo{
+		db: db,
+	}
+}
+
+// AuctionBidRepo is repository implementation for auction bid db operations
+type AuctionBidRepo struct {
+	db *sql.DB
+}
+
+// CreateAuctionBid creates new auction bid record in db
+func (br *AuctionBidRepo) CreateAuctionBid(ctx context.Context, bid *AuctionBid) (*AuctionBid, error) {
+	stmt, err := executor(ctx, br.db).PrepareContext(ctx, insertAuctionBidStmt)
+	if err != nil {
+		return nil, err
+	}
+
+	defer stmt.Close()
+
+	row := stmt.QueryRowContext(ctx, bid.WagerID, bid.UserID, bid.Price, bid.Quantity)
+	if err = scanAuctionBid(row, bid); err != nil {
+		return nil, err
+	}
+
+	return bid, nil
+}
+
+// ListAuctionBids returns all bids of wager, highest price first and earliest first at same price
+func (br *AuctionBidRepo) ListAuctionBids(ctx context.Context, wagerID uint32) ([]AuctionBid, error) {
+	return br.queryAuctionBids(ctx, listAuctionBidsStmt, wagerID)
+}
+
+// ListUserAuctionBids returns bids of user for wager, earliest first
+func (br *AuctionBidRepo) ListUserAuctionBids(ctx context.Context, wagerID, userID uint32) ([]AuctionBid, error) {
+	return br.queryAuctionBids(ctx, listUserAuctionBidsStmt, wagerID, userID)
+}
+
+// UpdateAuctionBid updates auction bid record for awarded units
+func (br *AuctionBidRepo) UpdateAuctionBid(ctx context.Context, bid *AuctionBid) error {
+	stmt, err := executor(ctx, br.db).PrepareContext(ctx, updateAuctionBidStmt)
+	if err != nil {
+		return err
+	}
+
+	defer stmt.Close()
+
+	_, err = stmt.ExecContext(ctx, bid.Awarded, bid.ID)
+	return err
+}
+
+func (br *AuctionBidRepo) queryAuctionBids(ctx context.Context, query string, args ...interface{}) ([]AuctionBid, error) {
+	rows, err := executor(ctx, br.db).QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	var res []AuctionBid
+	for rows.Next() {
+		var bid AuctionBid
+		if err = scanAuctionBid(rows, &bid); err != nil {
+			return nil, err
+		}
+
+		res = append(res, bid)
+	}
+
+	return res, rows.Err()
+}
+
+func scanAuctionBid(row rowScanner, bid *AuctionBid) error {
+	return row.Scan(
+		&bid.ID,
+		&bid.WagerID,
+		&bid.UserID,
+		&bid.Price,
+		&bid.Quantity,
+		&bid.Awarded,
+		&bid.CreatedAt,
+		&bid.UpdatedAt)
+}
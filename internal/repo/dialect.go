@@ -0,0 +1,20 @@
+package repo
+
+// Dialect is sql flavour of database used by sql repositories
+type Dialect string
+
+// Supported dialects
+const (
+	DialectPostgres Dialect = "postgres"
+	DialectSQLite   Dialect = "sqlite"
+)
+
+// forUpdate returns row locking clause for select statement.
+// SQLite has no row locks, its transactions lock whole database (see db.OpenConnection).
+func (d Dialect) forUpdate() string {
+	if d == DialectSQLite {
+		return ""
+	}
+
+	return " for update"
+}
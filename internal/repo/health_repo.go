@@ -0,0 +1,28 @@
+package repo
+
+import (
+	"context"
+	"database/sql"
+)
+
+// IHealthRepo is repository interface checking storage is reachable
+type IHealthRepo interface {
+	Ping(ctx context.Context) error
+}
+
+// NewHealthRepo ...
+func NewHealthRepo(db *sql.DB) *HealthRepo {
+	return &HealthRepo{
+		db: db,
+	}
+}
+
+// HealthRepo is repository implementation checking db connection
+type HealthRepo struct {
+	db *sql.DB
+}
+
+// Ping verifies connection to db is alive, establishing connection if necessary
+func (hr *HealthRepo) Ping(ctx context.Context) error {
+	return hr.db.PingContext(ctx)
+}
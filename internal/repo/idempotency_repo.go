@@ -0,0 +1,158 @@
+package repo
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+)
+
+const (
+	// insertIdempotencyKeyStmt takes over key of user only if it is expired
+	insertIdempotencyKeyStmt = `insert into idempotency_keys(user_id, idempotency_key, request_hash, expires_at)
+						values ($1, $2, $3, $4)
+						on conflict (user_id, idempotency_key) do update
+							set request_hash = excluded.request_hash, response_status = null, response_body = null,
+								created_at = current_timestamp, expires_at = excluded.expires_at
+							where idempotency_keys.expires_at <= $5
+						returning *`
+	getIdempotencyKeyStmt      = "select * from idempotency_keys where user_id = $1 and idempotency_key = $2 and expires_at > $3"
+	saveIdempotentResponseStmt = `update idempotency_keys set response_status = $1, response_body = $2
+						where user_id = $3 and idempotency_key = $4`
+	deleteIdempotencyKeyStmt         = "delete from idempotency_keys where user_id = $1 and idempotency_key = $2"
+	deleteExpiredIdempotencyKeysStmt = "delete from idempotency_keys where expires_at <= $1"
+)
+
+// ErrIdempotencyKeyExists is returned when user already used same key and it is not expired yet
+var ErrIdempotencyKeyExists = errors.New("idempotency key exists")
+
+// IdempotencyKey is Idempotency-Key of user request with fingerprint of request and its recorded response.
+// Response is empty while request is in progress.
+type IdempotencyKey struct {
+	ID             uint32
+	UserID         uint32
+	Key            string
+	RequestHash    string
+	ResponseStatus sql.NullInt32
+	ResponseBody   sql.NullString
+	CreatedAt      sql.NullTime
+	ExpiresAt      time.Time
+}
+
+// IIdempotencyRepo is repository interface for idempotency key db operations
+type IIdempotencyRepo interface {
+	CreateKey(ctx context.Context, key *IdempotencyKey, now time.Time) (*IdempotencyKey, error)
+	GetKey(ctx context.Context, userID uint32, key string, now time.Time) (*IdempotencyKey, error)
+	SaveResponse(ctx context.Context, userID uint32, key string, status int, body string) error
+	DeleteKey(ctx context.Context, userID uint32, key string) error
+	DeleteExpiredKeys(ctx context.Context, now time.Time) (int64, error)
+}
+
+// NewIdempotencyRepo ...
+func NewIdempotencyRepo(db *sql.DB) *IdempotencyRepo {
+	return &IdempotencyRepo{
+		db: db,
+	}
+}
+
+// IdempotencyRepo is repository implementation for idempotency key db operations
+type IdempotencyRepo struct {
+	db *sql.DB
+}
+
+// CreateKey creates new key record in db, key expired before now is replaced.
+// Returns ErrIdempotencyKeyExists if user has same key not expired yet.
+func (ir *IdempotencyRepo) CreateKey(ctx context.Context, key *IdempotencyKey, now time.Time) (*IdempotencyKey, error) {
+	stmt, err := executor(ctx, ir.db).PrepareContext(ctx, insertIdempotencyKeyStmt)
+	if err != nil {
+		return nil, err
+	}
+
+	defer stmt.Close()
+
+	row := stmt.QueryRowContext(ctx, key.UserID, key.Key, key.RequestHash, sqlTime(key.ExpiresAt), sqlTime(now))
+
+	err = scanIdempotencyKey(row, key)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, ErrIdempotencyKeyExists
+		}
+
+		return nil, err
+	}
+
+	return key, nil
+}
+
+// GetKey returns key record of user not expired at now
+func (ir *IdempotencyRepo) GetKey(ctx context.Context, userID uint32, key string, now time.Time) (*IdempotencyKey, error) {
+	stmt, err := executor(ctx, ir.db).PrepareContext(ctx, getIdempotencyKeyStmt)
+	if err != nil {
+		return nil, err
+	}
+
+	defer stmt.Close()
+
+	var res IdempotencyKey
+	err = scanIdempotencyKey(stmt.QueryRowContext(ctx, userID, key, sqlTime(now)), &res)
+	if err != nil {
+		return nil, err
+	}
+
+	return &res, nil
+}
+
+// SaveResponse records response of request with key
+func (ir *IdempotencyRepo) SaveResponse(ctx context.Context, userID uint32, key string, status int, body string) error {
+	stmt, err := executor(ctx, ir.db).PrepareContext(ctx, saveIdempotentResponseStmt)
+	if err != nil {
+		return err
+	}
+
+	defer stmt.Close()
+
+	_, err = stmt.ExecContext(ctx, status, body, userID, key)
+	return err
+}
+
+// DeleteKey deletes key record, so same key can be used again
+func (ir *IdempotencyRepo) DeleteKey(ctx context.Context, userID uint32, key string) error {
+	stmt, err := executor(ctx, ir.db).PrepareContext(ctx, deleteIdempotencyKeyStmt)
+	if err != nil {
+		return err
+	}
+
+	defer stmt.Close()
+
+	_, err = stmt.ExecContext(ctx, userID, key)
+	return err
+}
+
+// DeleteExpiredKeys deletes keys expired before now, returns number of deleted keys
+func (ir *IdempotencyRepo) DeleteExpiredKeys(ctx context.Context, now time.Time) (int64, error) {
+	stmt, err := executor(ctx, ir.db).PrepareContext(ctx, deleteExpiredIdempotencyKeysStmt)
+	if err != nil {
+		return 0, err
+	}
+
+	defer stmt.Close()
+
+	res, err := stmt.ExecContext(ctx, sqlTime(now))
+	if err != nil {
+		return 0, err
+	}
+
+	return res.RowsAffected()
+}
+
+func scanIdempotencyKey(row *sql.Row, key *IdempotencyKey) error {
+	return row.Scan(
+		&key.ID,
+		&key.UserID,
+		&key.Key,
+		&key.RequestHash,
+		&key.ResponseStatus,
+		&key.ResponseBody,
+		&key.CreatedAt,
+		&key.ExpiresAt)
+}
@@ -0,0 +1,128 @@
+package memory
+
+import (
+	"context"
+	"database/sql"
+	"sort"
+
+	"github.com/vitthalaa/wager-app/internal/repo"
+)
+
+// NewAuctionBidRepo ...
+func NewAuctionBidRepo(store *Store) *AuctionBidRepo {
+	return &AuctionBidRepo{
+		store: store,
+	}
+}
+
+// AuctionBidRepo is in-memory implementation of repo.IAuctionBidRepo
+type AuctionBidRepo struct {
+	store *Store
+}
+
+// CreateAuctionBid creates new auction bid record in store
+func (br *AuctionBidRepo) CreateAuctionBid(ctx context.Context, bid *repo.AuctionBid) (*repo.AuctionBid, error) {
+	s := br.store
+	err := s.run(ctx, func() error {
+		if _, ok := s.wagers[bid.WagerID]; !ok {
+			return ErrWagerNotExist
+		}
+
+		if _, ok := s.users[bid.UserID]; !ok {
+			return ErrUserNotExist
+		}
+
+		s.lastAuctionBidID++
+		bid.ID = s.lastAuctionBidID
+		bid.Awarded = 0
+		bid.CreatedAt = sql.NullTime{Time: now(), Valid: true}
+		bid.UpdatedAt = sql.NullTime{}
+		s.auctionBids[bid.ID] = *bid
+
+		id := bid.ID
+		s.onRollback(ctx, func() {
+			delete(s.auctionBids, id)
+		})
+
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return bid, nil
+}
+
+// ListAuctionBids returns all bids of wager, highest price first and earliest first at same price
+func (br *AuctionBidRepo) ListAuctionBids(ctx context.Context, wagerID uint32) ([]repo.AuctionBid, error) {
+	res, err := br.list(ctx, func(b repo.AuctionBid) bool {
+		return b.WagerID == wagerID
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	sort.Slice(res, func(i, j int) bool {
+		if res[i].Price != res[j].Price {
+			return res[i].Price > res[j].Price
+		}
+
+		return res[i].ID < res[j].ID
+	})
+
+	return res, nil
+}
+
+// ListUserAuctionBids returns bids of user for wager, earliest first
+func (br *AuctionBidRepo) ListUserAuctionBids(ctx context.Context, wagerID, userID uint32) ([]repo.AuctionBid, error) {
+	res, err := br.list(ctx, func(b repo.AuctionBid) bool {
+		return b.WagerID == wagerID && b.UserID == userID
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	sort.Slice(res, func(i, j int) bool {
+		return res[i].ID < res[j].ID
+	})
+
+	return res, nil
+}
+
+// UpdateAuctionBid updates auction bid record for awarded units
+func (br *AuctionBidRepo) UpdateAuctionBid(ctx context.Context, bid *repo.AuctionBid) error {
+	s := br.store
+	return s.run(ctx, func() error {
+		existing, ok := s.auctionBids[bid.ID]
+		if !ok {
+			return nil
+		}
+
+		updated := existing
+		updated.Awarded = bid.Awarded
+		updated.UpdatedAt = sql.NullTime{Time: now(), Valid: true}
+		s.auctionBids[bid.ID] = updated
+
+		s.onRollback(ctx, func() {
+			s.auctionBids[existing.ID] = existing
+		})
+
+		return nil
+	})
+}
+
+func (br *AuctionBidRepo) list(ctx context.Context, match func(b repo.AuctionBid) bool) ([]repo.AuctionBid, error) {
+	s := br.store
+	var res []repo.AuctionBid
+	err := s.run(ctx, func() error {
+		for _, b := range s.auctionBids {
+			if match(b) {
+				res = append(res, b)
+			}
+		}
+
+		return nil
+	})
+
+	return res, err
+}
@@ -0,0 +1,22 @@
+package memory
+
+import (
+	"context"
+)
+
+// NewHealthRepo ...
+func NewHealthRepo(store *Store) *HealthRepo {
+	return &HealthRepo{
+		store: store,
+	}
+}
+
+// HealthRepo is in-memory implementation of repo.IHealthRepo
+type HealthRepo struct {
+	store *Store
+}
+
+// Ping always succeeds unless ctx is done, store lives in process memory
+func (hr *HealthRepo) Ping(ctx context.Context) error {
+	return ctx.Err()
+}
@@ -0,0 +1,159 @@
+package memory
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/vitthalaa/wager-app/internal/repo"
+)
+
+// idempotencyKeyID identifies idempotency key of user, same as unique constraint of sql table
+type idempotencyKeyID struct {
+	userID uint32
+	key    string
+}
+
+// NewIdempotencyRepo ...
+func NewIdempotencyRepo(store *Store) *IdempotencyRepo {
+	return &IdempotencyRepo{
+		store: store,
+	}
+}
+
+// IdempotencyRepo is in-memory implementation of repo.IIdempotencyRepo
+type IdempotencyRepo struct {
+	store *Store
+}
+
+// CreateKey creates new key record in store, key expired before at is replaced.
+// Returns repo.ErrIdempotencyKeyExists if user has same key not expired yet.
+func (ir *IdempotencyRepo) CreateKey(ctx context.Context, key *repo.IdempotencyKey, at time.Time) (*repo.IdempotencyKey, error) {
+	s := ir.store
+	err := s.run(ctx, func() error {
+		if !s.userExists(sql.NullInt32{Int32: int32(key.UserID), Valid: true}) {
+			return ErrUserNotExist
+		}
+
+		id := idempotencyKeyID{userID: key.UserID, key: key.Key}
+		previous, exists := s.idempotencyKeys[id]
+		if exists && previous.ExpiresAt.After(at) {
+			return repo.ErrIdempotencyKeyExists
+		}
+
+		key.ID = previous.ID
+		if !exists {
+			s.lastIdempotencyKeyID++
+			key.ID = s.lastIdempotencyKeyID
+		}
+
+		key.ResponseStatus = sql.NullInt32{}
+		key.ResponseBody = sql.NullString{}
+		key.CreatedAt = sql.NullTime{Time: now(), Valid: true}
+		key.ExpiresAt = key.ExpiresAt.UTC().Truncate(time.Microsecond)
+		s.idempotencyKeys[id] = *key
+
+		s.onRollback(ctx, func() {
+			if exists {
+				s.idempotencyKeys[id] = previous
+				return
+			}
+
+			delete(s.idempotencyKeys, id)
+		})
+
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return key, nil
+}
+
+// GetKey returns key record of user not expired at given time or sql.ErrNoRows if not exists
+func (ir *IdempotencyRepo) GetKey(ctx context.Context, userID uint32, key string, at time.Time) (*repo.IdempotencyKey, error) {
+	s := ir.store
+	var res repo.IdempotencyKey
+	err := s.run(ctx, func() error {
+		existing, ok := s.idempotencyKeys[idempotencyKeyID{userID: userID, key: key}]
+		if !ok || !existing.ExpiresAt.After(at) {
+			return sql.ErrNoRows
+		}
+
+		res = existing
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &res, nil
+}
+
+// SaveResponse records response of request with key
+func (ir *IdempotencyRepo) SaveResponse(ctx context.Context, userID uint32, key string, status int, body string) error {
+	s := ir.store
+	return s.run(ctx, func() error {
+		id := idempotencyKeyID{userID: userID, key: key}
+		existing, ok := s.idempotencyKeys[id]
+		if !ok {
+			return nil
+		}
+
+		previous := existing
+		existing.ResponseStatus = sql.NullInt32{Int32: int32(status), Valid: true}
+		existing.ResponseBody = sql.NullString{String: body, Valid: true}
+		s.idempotencyKeys[id] = existing
+
+		s.onRollback(ctx, func() {
+			s.idempotencyKeys[id] = previous
+		})
+
+		return nil
+	})
+}
+
+// DeleteKey deletes key record, so same key can be used again
+func (ir *IdempotencyRepo) DeleteKey(ctx context.Context, userID uint32, key string) error {
+	s := ir.store
+	return s.run(ctx, func() error {
+		id := idempotencyKeyID{userID: userID, key: key}
+		existing, ok := s.idempotencyKeys[id]
+		if !ok {
+			return nil
+		}
+
+		delete(s.idempotencyKeys, id)
+		s.onRollback(ctx, func() {
+			s.idempotencyKeys[id] = existing
+		})
+
+		return nil
+	})
+}
+
+// DeleteExpiredKeys deletes keys expired before at, returns number of deleted keys
+func (ir *IdempotencyRepo) DeleteExpiredKeys(ctx context.Context, at time.Time) (int64, error) {
+	s := ir.store
+	var deleted int64
+	err := s.run(ctx, func() error {
+		for id, existing := range s.idempotencyKeys {
+			if existing.ExpiresAt.After(at) {
+				continue
+			}
+
+			delete(s.idempotencyKeys, id)
+			deleted++
+
+			id, existing := id, existing
+			s.onRollback(ctx, func() {
+				s.idempotencyKeys[id] = existing
+			})
+		}
+
+		return nil
+	})
+
+	return deleted, err
+}
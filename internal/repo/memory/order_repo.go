@@ -0,0 +1,228 @@
+package memory
+
+import (
+	"context"
+	"database/sql"
+	"sort"
+
+	"github.com/vitthalaa/wager-app/internal/repo"
+	"github.com/vitthalaa/wager-app/money"
+)
+
+// NewOrderRepo ...
+func NewOrderRepo(store *Store) *OrderRepo {
+	return &OrderRepo{
+		store: store,
+	}
+}
+
+// OrderRepo is in-memory implementation of repo.IOrderRepo
+type OrderRepo struct {
+	store *Store
+}
+
+// CreateOrder creates new open order record in store
+func (or *OrderRepo) CreateOrder(ctx context.Context, order *repo.Order) (*repo.Order, error) {
+	s := or.store
+	err := s.run(ctx, func() error {
+		if _, ok := s.wagers[order.WagerID]; !ok {
+			return ErrWagerNotExist
+		}
+
+		if _, ok := s.users[order.UserID]; !ok {
+			return ErrUserNotExist
+		}
+
+		s.lastOrderID++
+		order.ID = s.lastOrderID
+		order.Filled = 0
+		order.Status = repo.OrderStatusOpen
+		order.CreatedAt = sql.NullTime{Time: now(), Valid: true}
+		order.UpdatedAt = sql.NullTime{}
+		s.orders[order.ID] = *order
+
+		id := order.ID
+		s.onRollback(ctx, func() {
+			delete(s.orders, id)
+		})
+
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return order, nil
+}
+
+// GetOrderByID returns order record by id or sql.ErrNoRows if not exists
+func (or *OrderRepo) GetOrderByID(ctx context.Context, id uint32) (*repo.Order, error) {
+	s := or.store
+	var order repo.Order
+	err := s.run(ctx, func() error {
+		o, ok := s.orders[id]
+		if !ok {
+			return sql.ErrNoRows
+		}
+
+		order = o
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &order, nil
+}
+
+// ListOpenOrders returns open orders of wager side crossing price in price-time priority: asks at or below price
+// cheapest first, bids at or above price highest first, orders of same price oldest first
+func (or *OrderRepo) ListOpenOrders(
+	ctx context.Context, wagerID uint32, side repo.OrderSide, crossing money.Money,
+) ([]repo.Order, error) {
+	s := or.store
+	var res []repo.Order
+	err := s.run(ctx, func() error {
+		for _, o := range s.openOrders(wagerID, side) {
+			if (side == repo.OrderSideAsk && o.Price <= crossing) || (side == repo.OrderSideBid && o.Price >= crossing) {
+				res = append(res, o)
+			}
+		}
+
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	sort.Slice(res, func(i, j int) bool {
+		if res[i].Price != res[j].Price {
+			return (res[i].Price < res[j].Price) == (side == repo.OrderSideAsk)
+		}
+
+		return res[i].ID < res[j].ID
+	})
+
+	return res, nil
+}
+
+// UpdateOrder updates order record for filled units and status
+func (or *OrderRepo) UpdateOrder(ctx context.Context, order *repo.Order) error {
+	s := or.store
+	return s.run(ctx, func() error {
+		existing, ok := s.orders[order.ID]
+		if !ok {
+			return nil
+		}
+
+		updated := existing
+		updated.Filled = order.Filled
+		updated.Status = order.Status
+		updated.UpdatedAt = sql.NullTime{Time: now(), Valid: true}
+		s.orders[order.ID] = updated
+
+		s.onRollback(ctx, func() {
+			s.orders[existing.ID] = existing
+		})
+
+		return nil
+	})
+}
+
+// ListOrderBook returns best price levels of open orders of wager side, highest bids and lowest asks first
+func (or *OrderRepo) ListOrderBook(
+	ctx context.Context, wagerID uint32, side repo.OrderSide, levels uint32,
+) ([]repo.OrderBookLevel, error) {
+	s := or.store
+	byPrice := map[money.Money]*repo.OrderBookLevel{}
+	err := s.run(ctx, func() error {
+		for _, o := range s.openOrders(wagerID, side) {
+			level, ok := byPrice[o.Price]
+			if !ok {
+				level = &repo.OrderBookLevel{Price: o.Price}
+				byPrice[o.Price] = level
+			}
+
+			level.Quantity += o.Left()
+			level.Orders++
+		}
+
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	res := make([]repo.OrderBookLevel, 0, len(byPrice))
+	for _, level := range byPrice {
+		res = append(res, *level)
+	}
+
+	sort.Slice(res, func(i, j int) bool {
+		return (res[i].Price < res[j].Price) == (side == repo.OrderSideAsk)
+	})
+
+	if uint32(len(res)) > levels {
+		res = res[:levels]
+	}
+
+	return res, nil
+}
+
+// ListUserOpenOrders returns open orders of user side of all wagers, oldest first
+func (or *OrderRepo) ListUserOpenOrders(ctx context.Context, userID uint32, side repo.OrderSide) ([]repo.Order, error) {
+	s := or.store
+	var res []repo.Order
+	err := s.run(ctx, func() error {
+		for _, o := range s.orders {
+			if o.UserID == userID && o.Side == side && o.Status == repo.OrderStatusOpen {
+				res = append(res, o)
+			}
+		}
+
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	sort.Slice(res, func(i, j int) bool {
+		return res[i].ID < res[j].ID
+	})
+
+	return res, nil
+}
+
+// CancelOpenOrders cancels all open orders of wager
+func (or *OrderRepo) CancelOpenOrders(ctx context.Context, wagerID uint32) error {
+	s := or.store
+	return s.run(ctx, func() error {
+		for _, side := range []repo.OrderSide{repo.OrderSideBid, repo.OrderSideAsk} {
+			for _, existing := range s.openOrders(wagerID, side) {
+				updated := existing
+				updated.Status = repo.OrderStatusCancelled
+				updated.UpdatedAt = sql.NullTime{Time: now(), Valid: true}
+				s.orders[existing.ID] = updated
+
+				existing := existing
+				s.onRollback(ctx, func() {
+					s.orders[existing.ID] = existing
+				})
+			}
+		}
+
+		return nil
+	})
+}
+
+// openOrders returns unordered open orders of wager side, must be called holding store lock
+func (s *Store) openOrders(wagerID uint32, side repo.OrderSide) []repo.Order {
+	var res []repo.Order
+	for _, o := range s.orders {
+		if o.WagerID == wagerID && o.Side == side && o.Status == repo.OrderStatusOpen {
+			res = append(res, o)
+		}
+	}
+
+	return res
+}
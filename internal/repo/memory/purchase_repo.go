@@ -0,0 +1,157 @@
+package memory
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"sort"
+
+	"github.com/vitthalaa/wager-app/internal/repo"
+)
+
+// ErrWagerNotExist is returned when purchase references wager that does not exist (foreign key violation)
+var ErrWagerNotExist = errors.New("purchase wager does not exist")
+
+// NewPurchaseRepo ...
+func NewPurchaseRepo(store *Store) *PurchaseRepo {
+	return &PurchaseRepo{
+		store: store,
+	}
+}
+
+// PurchaseRepo is in-memory implementation of repo.IPurchaseRepo
+type PurchaseRepo struct {
+	store *Store
+}
+
+// CreatePurchase creates new purchase record in store
+func (pr *PurchaseRepo) CreatePurchase(ctx context.Context, purchase *repo.Purchase) (*repo.Purchase, error) {
+	s := pr.store
+	err := s.run(ctx, func() error {
+		if _, ok := s.wagers[purchase.WagerID]; !ok {
+			return ErrWagerNotExist
+		}
+
+		if !s.userExists(purchase.BuyerID) {
+			return ErrUserNotExist
+		}
+
+		s.lastPurchaseID++
+		purchase.ID = s.lastPurchaseID
+		purchase.CreatedAt = sql.NullTime{Time: now(), Valid: true}
+		purchase.UpdatedAt = sql.NullTime{}
+		s.purchases[purchase.ID] = *purchase
+
+		id := purchase.ID
+		s.onRollback(ctx, func() {
+			delete(s.purchases, id)
+		})
+
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return purchase, nil
+}
+
+// DeletePurchase deletes purchase record from store by id
+func (pr *PurchaseRepo) DeletePurchase(ctx context.Context, id uint32) error {
+	s := pr.store
+	return s.run(ctx, func() error {
+		existing, ok := s.purchases[id]
+		if !ok {
+			return nil
+		}
+
+		delete(s.purchases, id)
+		s.onRollback(ctx, func() {
+			s.purchases[existing.ID] = existing
+		})
+
+		return nil
+	})
+}
+
+// GetPurchaseByID returns purchase record by id or sql.ErrNoRows if not exists
+func (pr *PurchaseRepo) GetPurchaseByID(ctx context.Context, id uint32) (*repo.Purchase, error) {
+	s := pr.store
+	var purchase repo.Purchase
+	err := s.run(ctx, func() error {
+		p, ok := s.purchases[id]
+		if !ok {
+			return sql.ErrNoRows
+		}
+
+		purchase = p
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &purchase, nil
+}
+
+// ListPurchases returns purchases matching filter, sorted and paginated by filter
+func (pr *PurchaseRepo) ListPurchases(ctx context.Context, filter repo.PurchaseFilter) ([]repo.Purchase, error) {
+	sortBy := filter.SortBy
+	if sortBy == "" {
+		sortBy = repo.PurchaseSortID
+	}
+
+	if !sortBy.Valid() {
+		return nil, fmt.Errorf("unsupported purchase sort field %q", sortBy)
+	}
+
+	s := pr.store
+	res := make([]repo.Purchase, 0)
+	err := s.run(ctx, func() error {
+		purchases := s.filterPurchases(filter)
+		sort.Slice(purchases, func(i, j int) bool {
+			less, equal := comparePurchases(purchases[i], purchases[j], sortBy)
+			if equal {
+				less = purchases[i].ID < purchases[j].ID
+			}
+
+			return less != filter.SortDesc
+		})
+
+		for i := int(filter.Offset); i < len(purchases) && len(res) < int(filter.Limit); i++ {
+			res = append(res, purchases[i])
+		}
+
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return res, nil
+}
+
+// CountPurchases returns number of purchases matching filter, pagination is ignored
+func (pr *PurchaseRepo) CountPurchases(ctx context.Context, filter repo.PurchaseFilter) (uint32, error) {
+	s := pr.store
+	var count uint32
+	err := s.run(ctx, func() error {
+		count = uint32(len(s.filterPurchases(filter)))
+		return nil
+	})
+
+	return count, err
+}
+
+// comparePurchases compares a and b by field
+func comparePurchases(a, b repo.Purchase, field repo.PurchaseSortField) (less, equal bool) {
+	switch field {
+	case repo.PurchaseSortBuyingPrice:
+		return a.BuyingPrice < b.BuyingPrice, a.BuyingPrice == b.BuyingPrice
+	case repo.PurchaseSortCreatedAt:
+		return a.CreatedAt.Time.Before(b.CreatedAt.Time), a.CreatedAt.Time.Equal(b.CreatedAt.Time)
+	}
+
+	return a.ID < b.ID, a.ID == b.ID
+}
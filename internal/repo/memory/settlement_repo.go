@@ -0,0 +1,87 @@
+package memory
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"sort"
+
+	"github.com/vitthalaa/wager-app/internal/repo"
+)
+
+var (
+	// ErrPurchaseNotExist is returned when settlement references purchase that does not exist (foreign key violation)
+	ErrPurchaseNotExist = errors.New("settlement purchase does not exist")
+	// ErrPurchaseSettled is returned when purchase already has settlement (unique constraint violation)
+	ErrPurchaseSettled = errors.New("purchase is already settled")
+)
+
+// NewSettlementRepo ...
+func NewSettlementRepo(store *Store) *SettlementRepo {
+	return &SettlementRepo{
+		store: store,
+	}
+}
+
+// SettlementRepo is in-memory implementation of repo.ISettlementRepo
+type SettlementRepo struct {
+	store *Store
+}
+
+// CreateSettlement creates new settlement record in store
+func (sr *SettlementRepo) CreateSettlement(ctx context.Context, settlement *repo.Settlement) (*repo.Settlement, error) {
+	s := sr.store
+	err := s.run(ctx, func() error {
+		if _, ok := s.wagers[settlement.WagerID]; !ok {
+			return ErrWagerNotExist
+		}
+
+		if _, ok := s.purchases[settlement.PurchaseID]; !ok {
+			return ErrPurchaseNotExist
+		}
+
+		for _, existing := range s.settlements {
+			if existing.PurchaseID == settlement.PurchaseID {
+				return ErrPurchaseSettled
+			}
+		}
+
+		s.lastSettlementID++
+		settlement.ID = s.lastSettlementID
+		settlement.CreatedAt = sql.NullTime{Time: now(), Valid: true}
+		s.settlements[settlement.ID] = *settlement
+
+		id := settlement.ID
+		s.onRollback(ctx, func() {
+			delete(s.settlements, id)
+		})
+
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return settlement, nil
+}
+
+// ListSettlementsByWagerID returns all settlements of wager ordered by purchase id
+func (sr *SettlementRepo) ListSettlementsByWagerID(ctx context.Context, wagerID uint32) ([]repo.Settlement, error) {
+	s := sr.store
+	var res []repo.Settlement
+	err := s.run(ctx, func() error {
+		for _, settlement := range s.settlements {
+			if settlement.WagerID == wagerID {
+				res = append(res, settlement)
+			}
+		}
+
+		sort.Slice(res, func(i, j int) bool { return res[i].PurchaseID < res[j].PurchaseID })
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return res, nil
+}
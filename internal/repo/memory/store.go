@@ -0,0 +1,242 @@
+package memory
+
+import (
+	"context"
+	"database/sql"
+	"sync"
+	"time"
+
+	"github.com/vitthalaa/wager-app/internal/repo"
+)
+
+// Store is thread-safe in-memory storage shared by memory repositories.
+// All operations are serialized, so transactions behave as serializable.
+type Store struct {
+	mu sync.Mutex
+
+	wagers         map[uint32]repo.Wager
+	lastWagerID    uint32
+	purchases      map[uint32]repo.Purchase
+	lastPurchaseID uint32
+
+	settlements      map[uint32]repo.Settlement
+	lastSettlementID uint32
+
+	orders      map[uint32]repo.Order
+	lastOrderID uint32
+
+	auctionBids      map[uint32]repo.AuctionBid
+	lastAuctionBidID uint32
+
+	users      map[uint32]repo.User
+	lastUserID uint32
+
+	accounts                map[uint32]repo.Account
+	lastAccountID           uint32
+	externalAccountID       uint32
+	ledgerEntries           map[uint32]repo.LedgerEntry
+	lastLedgerEntryID       uint32
+	lastLedgerTransactionID uint32
+
+	idempotencyKeys      map[idempotencyKeyID]repo.IdempotencyKey
+	lastIdempotencyKeyID uint32
+
+	// undo holds rollback operations of running transaction
+	undo []func()
+}
+
+type txKey struct{}
+
+// NewStore ...
+func NewStore() *Store {
+	s := &Store{
+		wagers:        map[uint32]repo.Wager{},
+		purchases:     map[uint32]repo.Purchase{},
+		settlements:   map[uint32]repo.Settlement{},
+		orders:        map[uint32]repo.Order{},
+		auctionBids:   map[uint32]repo.AuctionBid{},
+		users:         map[uint32]repo.User{},
+		accounts:      map[uint32]repo.Account{},
+		ledgerEntries: map[uint32]repo.LedgerEntry{},
+
+		idempotencyKeys: map[idempotencyKeyID]repo.IdempotencyKey{},
+	}
+
+	// external account is created by migration in sql databases
+	s.externalAccountID = s.createAccount(sql.NullInt32{}, repo.AccountKindExternal).ID
+	return s
+}
+
+// NewTransactor ...
+func NewTransactor(store *Store) *Transactor {
+	return &Transactor{
+		store: store,
+	}
+}
+
+// Transactor is in-memory implementation of repo.ITransactor
+type Transactor struct {
+	store *Store
+}
+
+// WithinTransaction runs fn holding store lock and reverts all changes done by fn if it returns error or panics.
+// Nested calls reuse the transaction already present in ctx.
+func (t *Transactor) WithinTransaction(ctx context.Context, fn func(ctx context.Context) error) (err error) {
+	s := t.store
+	if s.inTx(ctx) {
+		return fn(ctx)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.undo = nil
+	defer func() {
+		if p := recover(); p != nil {
+			s.rollback()
+			panic(p)
+		}
+
+		if err != nil {
+			s.rollback()
+		}
+
+		s.undo = nil
+	}()
+
+	return fn(context.WithValue(ctx, txKey{}, s))
+}
+
+// run executes fn under store lock unless ctx already holds it through transaction
+func (s *Store) run(ctx context.Context, fn func() error) error {
+	if s.inTx(ctx) {
+		return fn()
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return fn()
+}
+
+func (s *Store) inTx(ctx context.Context) bool {
+	txStore, ok := ctx.Value(txKey{}).(*Store)
+	return ok && txStore == s
+}
+
+// onRollback registers undo operation, it is ignored outside of transaction
+func (s *Store) onRollback(ctx context.Context, fn func()) {
+	if s.inTx(ctx) {
+		s.undo = append(s.undo, fn)
+	}
+}
+
+func (s *Store) rollback() {
+	for i := len(s.undo) - 1; i >= 0; i-- {
+		s.undo[i]()
+	}
+}
+
+// userExists reports whether optional user reference is valid, must be called holding store lock
+func (s *Store) userExists(id sql.NullInt32) bool {
+	if !id.Valid {
+		return true
+	}
+
+	_, ok := s.users[uint32(id.Int32)]
+	return ok
+}
+
+// createAccount adds account with zero balance, must be called holding store lock
+func (s *Store) createAccount(userID sql.NullInt32, kind repo.AccountKind) repo.Account {
+	s.lastAccountID++
+	account := repo.Account{
+		ID:        s.lastAccountID,
+		UserID:    userID,
+		Kind:      kind,
+		CreatedAt: sql.NullTime{Time: now(), Valid: true},
+	}
+
+	s.accounts[account.ID] = account
+	return account
+}
+
+// accountByUserID returns wallet account of user, must be called holding store lock
+func (s *Store) accountByUserID(userID uint32) (repo.Account, bool) {
+	for _, account := range s.accounts {
+		if account.UserID.Valid && uint32(account.UserID.Int32) == userID {
+			return account, true
+		}
+	}
+
+	return repo.Account{}, false
+}
+
+// accountLedgerEntries returns unordered ledger entries of account, must be called holding store lock
+func (s *Store) accountLedgerEntries(accountID uint32) []repo.LedgerEntry {
+	var res []repo.LedgerEntry
+	for _, entry := range s.ledgerEntries {
+		if entry.AccountID == accountID {
+			res = append(res, entry)
+		}
+	}
+
+	return res
+}
+
+// hasPricing reports whether pricing is one of pricings
+func hasPricing(pricings []repo.WagerPricing, pricing repo.WagerPricing) bool {
+	for _, p := range pricings {
+		if p == pricing {
+			return true
+		}
+	}
+
+	return false
+}
+
+// filterWagers returns unordered wagers matching filter, must be called holding store lock
+func (s *Store) filterWagers(filter repo.WagerFilter) []repo.Wager {
+	var res []repo.Wager
+	for _, w := range s.wagers {
+		switch {
+		case filter.MinOdds > 0 && w.Odds < filter.MinOdds,
+			filter.MaxOdds > 0 && w.Odds > filter.MaxOdds,
+			filter.MinPrice > 0 && w.CurrentSellingPrice < filter.MinPrice,
+			filter.MaxPrice > 0 && w.CurrentSellingPrice > filter.MaxPrice,
+			hasPricing(filter.ExcludePricings, w.Pricing),
+			filter.SoldOut != nil && (w.Status == repo.WagerStatusSoldOut) != *filter.SoldOut,
+			!filter.From.IsZero() && w.CreatedAt.Time.Before(filter.From),
+			!filter.To.IsZero() && w.CreatedAt.Time.After(filter.To):
+			continue
+		}
+
+		res = append(res, w)
+	}
+
+	return res
+}
+
+// filterPurchases returns unordered purchases matching filter, must be called holding store lock
+func (s *Store) filterPurchases(filter repo.PurchaseFilter) []repo.Purchase {
+	var res []repo.Purchase
+	for _, p := range s.purchases {
+		switch {
+		case filter.WagerID > 0 && p.WagerID != filter.WagerID,
+			filter.MinPrice > 0 && p.BuyingPrice < filter.MinPrice,
+			filter.MaxPrice > 0 && p.BuyingPrice > filter.MaxPrice,
+			!filter.From.IsZero() && p.CreatedAt.Time.Before(filter.From),
+			!filter.To.IsZero() && p.CreatedAt.Time.After(filter.To):
+			continue
+		}
+
+		res = append(res, p)
+	}
+
+	return res
+}
+
+// now returns current time truncated to microseconds same as postgres timestamp precision
+func now() time.Time {
+	return time.Now().UTC().Truncate(time.Microsecond)
+}
@@ -0,0 +1,25 @@
+package memory
+
+import (
+	"testing"
+
+	"github.com/vitthalaa/wager-app/internal/repo/repotest"
+)
+
+func TestMemoryStore_Conformance(t *testing.T) {
+	repotest.RunConformance(t, func(t *testing.T) repotest.Repos {
+		store := NewStore()
+		return repotest.Repos{
+			Transactor:  NewTransactor(store),
+			Wager:       NewWagerRepo(store),
+			Purchase:    NewPurchaseRepo(store),
+			Settlement:  NewSettlementRepo(store),
+			User:        NewUserRepo(store),
+			Wallet:      NewWalletRepo(store),
+			Idempotency: NewIdempotencyRepo(store),
+			Health:      NewHealthRepo(store),
+			Order:       NewOrderRepo(store),
+			AuctionBid:  NewAuctionBidRepo(store),
+		}
+	})
+}
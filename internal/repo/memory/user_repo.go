@@ -0,0 +1,75 @@
+package memory
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+
+	"github.com/vitthalaa/wager-app/internal/repo"
+)
+
+// ErrUserNotExist is returned when record references user that does not exist (foreign key violation)
+var ErrUserNotExist = errors.New("user does not exist")
+
+// NewUserRepo ...
+func NewUserRepo(store *Store) *UserRepo {
+	return &UserRepo{
+		store: store,
+	}
+}
+
+// UserRepo is in-memory implementation of repo.IUserRepo
+type UserRepo struct {
+	store *Store
+}
+
+// CreateUser creates new user record in store, returns repo.ErrUsernameTaken if username exists
+func (ur *UserRepo) CreateUser(ctx context.Context, user *repo.User) (*repo.User, error) {
+	s := ur.store
+	err := s.run(ctx, func() error {
+		for _, existing := range s.users {
+			if existing.Username == user.Username {
+				return repo.ErrUsernameTaken
+			}
+		}
+
+		s.lastUserID++
+		user.ID = s.lastUserID
+		user.CreatedAt = sql.NullTime{Time: now(), Valid: true}
+		user.UpdatedAt = sql.NullTime{}
+		s.users[user.ID] = *user
+
+		id := user.ID
+		s.onRollback(ctx, func() {
+			delete(s.users, id)
+		})
+
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return user, nil
+}
+
+// GetUserByUsername returns user record by username or sql.ErrNoRows if not exists
+func (ur *UserRepo) GetUserByUsername(ctx context.Context, username string) (*repo.User, error) {
+	s := ur.store
+	var user repo.User
+	err := s.run(ctx, func() error {
+		for _, existing := range s.users {
+			if existing.Username == username {
+				user = existing
+				return nil
+			}
+		}
+
+		return sql.ErrNoRows
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &user, nil
+}
@@ -0,0 +1,246 @@
+package memory
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/vitthalaa/wager-app/internal/repo"
+)
+
+// NewWagerRepo ...
+func NewWagerRepo(store *Store) *WagerRepo {
+	return &WagerRepo{
+		store: store,
+	}
+}
+
+// WagerRepo is in-memory implementation of repo.IWagerRepo
+type WagerRepo struct {
+	store *Store
+}
+
+// CreateWager creates new wager record in store
+func (wr *WagerRepo) CreateWager(ctx context.Context, wager *repo.Wager) (*repo.Wager, error) {
+	s := wr.store
+	err := s.run(ctx, func() error {
+		if !s.userExists(wager.SellerID) {
+			return ErrUserNotExist
+		}
+
+		s.lastWagerID++
+		wager.ID = s.lastWagerID
+		wager.PercentageSold = sql.NullFloat64{}
+		wager.AmountSold = sql.NullInt32{}
+		wager.CreatedAt = sql.NullTime{Time: now(), Valid: true}
+		wager.UpdatedAt = sql.NullTime{}
+		wager.Status = repo.WagerStatusOpen
+		wager.Outcome = sql.NullString{}
+		wager.SettledAt = sql.NullTime{}
+		wager.PricedAt = sql.NullTime{}
+		wager.AuctionClosedAt = sql.NullTime{}
+		s.wagers[wager.ID] = *wager
+
+		id := wager.ID
+		s.onRollback(ctx, func() {
+			delete(s.wagers, id)
+		})
+
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return wager, nil
+}
+
+// ListWager returns wagers matching filter in sort order
+func (wr *WagerRepo) ListWager(ctx context.Context, filter repo.WagerFilter) ([]repo.Wager, error) {
+	sortBy := filter.SortBy
+	if sortBy == "" {
+		sortBy = repo.WagerSortID
+	}
+
+	if !sortBy.Valid() {
+		return nil, fmt.Errorf("unsupported wager sort field %q", sortBy)
+	}
+
+	s := wr.store
+	res := make([]repo.Wager, 0, filter.Limit)
+	err := s.run(ctx, func() error {
+		// less reports whether a is listed before b
+		less := func(a, b repo.Wager) bool {
+			less, equal := compareWagers(a, b, sortBy)
+			if equal {
+				if a.ID == b.ID {
+					return false
+				}
+
+				less = a.ID < b.ID
+			}
+
+			return less != filter.SortDesc
+		}
+
+		wagers := s.filterWagers(filter)
+		sort.Slice(wagers, func(i, j int) bool { return less(wagers[i], wagers[j]) })
+
+		var cursor repo.Wager
+		if filter.After != nil {
+			cursor = cursorWager(*filter.After)
+		}
+
+		for i := 0; i < len(wagers) && len(res) < int(filter.Limit); i++ {
+			if filter.After != nil && !less(cursor, wagers[i]) {
+				continue
+			}
+
+			res = append(res, wagers[i])
+		}
+
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return res, nil
+}
+
+// cursorWager returns wager with sort values of cursor, compared to listed wagers
+func cursorWager(cursor repo.WagerCursor) repo.Wager {
+	return repo.Wager{
+		ID:                  cursor.ID,
+		CurrentSellingPrice: cursor.CurrentSellingPrice,
+		Odds:                cursor.Odds,
+		PercentageSold:      sql.NullFloat64{Float64: cursor.PercentageSold, Valid: true},
+		CreatedAt:           sql.NullTime{Time: cursor.CreatedAt, Valid: true},
+	}
+}
+
+// compareWagers compares a and b by field
+func compareWagers(a, b repo.Wager, field repo.WagerSortField) (less, equal bool) {
+	switch field {
+	case repo.WagerSortCurrentSellingPrice:
+		return a.CurrentSellingPrice < b.CurrentSellingPrice, a.CurrentSellingPrice == b.CurrentSellingPrice
+	case repo.WagerSortOdds:
+		return a.Odds < b.Odds, a.Odds == b.Odds
+	case repo.WagerSortPercentageSold:
+		// null is sorted as 0 sold, Float64 of null is 0
+		return a.PercentageSold.Float64 < b.PercentageSold.Float64, a.PercentageSold.Float64 == b.PercentageSold.Float64
+	case repo.WagerSortCreatedAt:
+		return a.CreatedAt.Time.Before(b.CreatedAt.Time), a.CreatedAt.Time.Equal(b.CreatedAt.Time)
+	}
+
+	return a.ID < b.ID, a.ID == b.ID
+}
+
+// ListDueAuctions returns auctioned wagers not closed yet whose bidding ended at or before time, earliest end first
+func (wr *WagerRepo) ListDueAuctions(ctx context.Context, at time.Time) ([]repo.Wager, error) {
+	s := wr.store
+	res := make([]repo.Wager, 0)
+	err := s.run(ctx, func() error {
+		for _, w := range s.wagers {
+			if w.Auction != repo.WagerAuctionNone && !w.AuctionClosedAt.Valid && !w.AuctionEndsAt.Time.After(at) {
+				res = append(res, w)
+			}
+		}
+
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	sort.Slice(res, func(i, j int) bool {
+		if !res[i].AuctionEndsAt.Time.Equal(res[j].AuctionEndsAt.Time) {
+			return res[i].AuctionEndsAt.Time.Before(res[j].AuctionEndsAt.Time)
+		}
+
+		return res[i].ID < res[j].ID
+	})
+
+	return res, nil
+}
+
+// GetWagerByID returns wager record by id or sql.ErrNoRows if not exists
+func (wr *WagerRepo) GetWagerByID(ctx context.Context, wagerID uint32) (*repo.Wager, error) {
+	s := wr.store
+	var wager repo.Wager
+	err := s.run(ctx, func() error {
+		w, ok := s.wagers[wagerID]
+		if !ok {
+			return sql.ErrNoRows
+		}
+
+		wager = w
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &wager, nil
+}
+
+// GetWagerByIDForUpdate returns wager record by id.
+// Transaction already holds store lock, so no extra row lock is needed.
+func (wr *WagerRepo) GetWagerByIDForUpdate(ctx context.Context, wagerID uint32) (*repo.Wager, error) {
+	return wr.GetWagerByID(ctx, wagerID)
+}
+
+// UpdateWager updates wager record for current selling price and its pricing time, amount sold, percentage sold
+// and status
+func (wr *WagerRepo) UpdateWager(ctx context.Context, wager *repo.Wager) error {
+	s := wr.store
+	return s.run(ctx, func() error {
+		existing, ok := s.wagers[wager.ID]
+		if !ok {
+			return nil
+		}
+
+		updated := existing
+		updated.CurrentSellingPrice = wager.CurrentSellingPrice
+		updated.PercentageSold = wager.PercentageSold
+		updated.AmountSold = wager.AmountSold
+		updated.Status = wager.Status
+		updated.PricedAt = wager.PricedAt
+		updated.AuctionClosedAt = wager.AuctionClosedAt
+		updated.UpdatedAt = sql.NullTime{Time: now(), Valid: true}
+		s.wagers[wager.ID] = updated
+
+		s.onRollback(ctx, func() {
+			s.wagers[existing.ID] = existing
+		})
+
+		return nil
+	})
+}
+
+// SettleWager updates wager record for status and outcome, settlement time is set to current time
+func (wr *WagerRepo) SettleWager(ctx context.Context, wager *repo.Wager) error {
+	s := wr.store
+	return s.run(ctx, func() error {
+		existing, ok := s.wagers[wager.ID]
+		if !ok {
+			return nil
+		}
+
+		settledAt := sql.NullTime{Time: now(), Valid: true}
+		updated := existing
+		updated.Status = wager.Status
+		updated.Outcome = wager.Outcome
+		updated.SettledAt = settledAt
+		updated.UpdatedAt = settledAt
+		s.wagers[wager.ID] = updated
+
+		s.onRollback(ctx, func() {
+			s.wagers[existing.ID] = existing
+		})
+
+		return nil
+	})
+}
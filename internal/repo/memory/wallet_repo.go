@@ -0,0 +1,237 @@
+package memory
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"sort"
+
+	"github.com/vitthalaa/wager-app/internal/repo"
+)
+
+var (
+	// ErrAccountNotExist is returned when ledger entry references account that does not exist (foreign key violation)
+	ErrAccountNotExist = errors.New("account does not exist")
+	// ErrUserHasAccount is returned when user already has account (unique constraint violation)
+	ErrUserHasAccount = errors.New("user already has account")
+)
+
+// NewWalletRepo ...
+func NewWalletRepo(store *Store) *WalletRepo {
+	return &WalletRepo{
+		store: store,
+	}
+}
+
+// WalletRepo is in-memory implementation of repo.IWalletRepo
+type WalletRepo struct {
+	store *Store
+}
+
+// CreateAccount creates new account record in store
+func (wr *WalletRepo) CreateAccount(ctx context.Context, account *repo.Account) (*repo.Account, error) {
+	s := wr.store
+	err := s.run(ctx, func() error {
+		if !s.userExists(account.UserID) {
+			return ErrUserNotExist
+		}
+
+		if account.UserID.Valid {
+			if _, ok := s.accountByUserID(uint32(account.UserID.Int32)); ok {
+				return ErrUserHasAccount
+			}
+		}
+
+		*account = s.createAccount(account.UserID, account.Kind)
+
+		id := account.ID
+		s.onRollback(ctx, func() {
+			delete(s.accounts, id)
+		})
+
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return account, nil
+}
+
+// GetAccountByUserID returns wallet account of user or sql.ErrNoRows if not exists
+func (wr *WalletRepo) GetAccountByUserID(ctx context.Context, userID uint32) (*repo.Account, error) {
+	s := wr.store
+	var account repo.Account
+	err := s.run(ctx, func() error {
+		var ok bool
+		account, ok = s.accountByUserID(userID)
+		if !ok {
+			return sql.ErrNoRows
+		}
+
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &account, nil
+}
+
+// GetAccountByUserIDForUpdate returns wallet account of user. Transactions hold store lock, so no row lock is needed.
+func (wr *WalletRepo) GetAccountByUserIDForUpdate(ctx context.Context, userID uint32) (*repo.Account, error) {
+	return wr.GetAccountByUserID(ctx, userID)
+}
+
+// GetExternalAccount returns external account
+func (wr *WalletRepo) GetExternalAccount(ctx context.Context) (*repo.Account, error) {
+	s := wr.store
+	var account repo.Account
+	err := s.run(ctx, func() error {
+		account = s.accounts[s.externalAccountID]
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &account, nil
+}
+
+// CreateLedgerTransaction records transaction with its entries and applies entries to account balances atomically.
+// Returns repo.ErrUnbalancedTransaction unless entries sum to zero.
+func (wr *WalletRepo) CreateLedgerTransaction(ctx context.Context, transaction *repo.LedgerTransaction) (*repo.LedgerTransaction, error) {
+	if err := transaction.Validate(); err != nil {
+		return nil, err
+	}
+
+	s := wr.store
+	err := s.run(ctx, func() error {
+		if transaction.PurchaseID.Valid {
+			if _, ok := s.purchases[uint32(transaction.PurchaseID.Int32)]; !ok {
+				return ErrPurchaseNotExist
+			}
+		}
+
+		for _, entry := range transaction.Entries {
+			if _, ok := s.accounts[entry.AccountID]; !ok {
+				return ErrAccountNotExist
+			}
+		}
+
+		createdAt := sql.NullTime{Time: now(), Valid: true}
+		s.lastLedgerTransactionID++
+		transaction.ID = s.lastLedgerTransactionID
+		transaction.CreatedAt = createdAt
+
+		accounts := map[uint32]repo.Account{}
+		for i := range transaction.Entries {
+			entry := &transaction.Entries[i]
+			s.lastLedgerEntryID++
+			entry.ID = s.lastLedgerEntryID
+			entry.TransactionID = transaction.ID
+			entry.CreatedAt = createdAt
+			entry.Kind = transaction.Kind
+			entry.PurchaseID = transaction.PurchaseID
+			s.ledgerEntries[entry.ID] = *entry
+
+			account := s.accounts[entry.AccountID]
+			if _, ok := accounts[account.ID]; !ok {
+				accounts[account.ID] = account
+			}
+
+			account.Balance += entry.Amount
+			account.UpdatedAt = createdAt
+			s.accounts[account.ID] = account
+		}
+
+		entries := transaction.Entries
+		s.onRollback(ctx, func() {
+			for _, entry := range entries {
+				delete(s.ledgerEntries, entry.ID)
+			}
+
+			for _, account := range accounts {
+				s.accounts[account.ID] = account
+			}
+		})
+
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return transaction, nil
+}
+
+// ListLedgerEntries returns page of account ledger entries, latest first
+func (wr *WalletRepo) ListLedgerEntries(ctx context.Context, accountID, offset, limit uint32) ([]repo.LedgerEntry, error) {
+	s := wr.store
+	res := make([]repo.LedgerEntry, 0)
+	err := s.run(ctx, func() error {
+		entries := s.accountLedgerEntries(accountID)
+		sort.Slice(entries, func(i, j int) bool { return entries[i].ID > entries[j].ID })
+
+		for i := offset; i < uint32(len(entries)) && i-offset < limit; i++ {
+			res = append(res, entries[i])
+		}
+
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return res, nil
+}
+
+// CountLedgerEntries returns count of account ledger entries
+func (wr *WalletRepo) CountLedgerEntries(ctx context.Context, accountID uint32) (uint32, error) {
+	s := wr.store
+	var count uint32
+	err := s.run(ctx, func() error {
+		count = uint32(len(s.accountLedgerEntries(accountID)))
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	return count, nil
+}
+
+// CheckLedger verifies that ledger sums to zero, every transaction is balanced and
+// account balances match their entries
+func (wr *WalletRepo) CheckLedger(ctx context.Context) (*repo.LedgerCheck, error) {
+	s := wr.store
+	var check repo.LedgerCheck
+	err := s.run(ctx, func() error {
+		transactionSums := map[uint32]int64{}
+		accountSums := map[uint32]int64{}
+		for _, entry := range s.ledgerEntries {
+			check.Sum += entry.Amount
+			transactionSums[entry.TransactionID] += int64(entry.Amount)
+			accountSums[entry.AccountID] += int64(entry.Amount)
+		}
+
+		for _, sum := range transactionSums {
+			if sum != 0 {
+				check.UnbalancedTransactions++
+			}
+		}
+
+		for _, account := range s.accounts {
+			if int64(account.Balance) != accountSums[account.ID] {
+				check.MismatchedAccounts++
+			}
+		}
+
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &check, nil
+}
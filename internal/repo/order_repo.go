@@ -0,0 +1,238 @@
+package repo
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	"github.com/vitthalaa/wager-app/money"
+)
+
+const (
+	insertOrderStmt = `insert into orders(wager_id, user_id, side, price, quantity)
+						values ($1, $2, $3, $4, $5) returning *`
+	getOrderByIDStmt = "select * from orders where id = $1"
+	// open orders crossing price in price-time priority, cheapest asks and highest bids first
+	listOpenAsksStmt = `select * from orders where wager_id = $1 and side = 'ask' and status = 'open' and price <= $2
+						order by price, id`
+	listOpenBidsStmt = `select * from orders where wager_id = $1 and side = 'bid' and status = 'open' and price >= $2
+						order by price desc, id`
+	updateOrderStmt = `update orders set filled = $1, status = $2, updated_at = current_timestamp
+						where id = $3`
+	listUserOpenOrdersStmt = `select * from orders where user_id = $1 and side = $2 and status = 'open' order by id`
+	cancelOpenOrdersStmt   = `update orders set status = 'cancelled', updated_at = current_timestamp
+						where wager_id = $1 and status = 'open'`
+	orderBookStmt = `select price, sum(quantity - filled), count(*) from orders
+						where wager_id = $1 and side = $2 and status = 'open'
+						group by price order by price %s limit $3`
+)
+
+// OrderSide is side of order book, users bid for units of wager and its seller asks for them
+type OrderSide string
+
+// Order sides
+const (
+	OrderSideBid OrderSide = "bid"
+	OrderSideAsk OrderSide = "ask"
+)
+
+// OrderStatus is state of order, open -> filled/cancelled
+type OrderStatus string
+
+// Order statuses
+const (
+	OrderStatusOpen      OrderStatus = "open"
+	OrderStatusFilled    OrderStatus = "filled"
+	OrderStatusCancelled OrderStatus = "cancelled"
+)
+
+// Order is limit order of units of wager at price of one unit
+type Order struct {
+	ID       uint32
+	WagerID  uint32
+	UserID   uint32
+	Side     OrderSide
+	Price    money.Money
+	Quantity uint32
+	// Filled is number of units bought or sold by order so far
+	Filled    uint32
+	Status    OrderStatus
+	CreatedAt sql.NullTime
+	UpdatedAt sql.NullTime
+}
+
+// Left returns units of order not filled yet
+func (o Order) Left() uint32 {
+	return o.Quantity - o.Filled
+}
+
+// OrderBookLevel is open orders of one side of order book at same price
+type OrderBookLevel struct {
+	Price money.Money
+	// Quantity is sum of units left of orders
+	Quantity uint32
+	Orders   uint32
+}
+
+// IOrderRepo is repository interface for order db operations
+type IOrderRepo interface {
+	CreateOrder(ctx context.Context, order *Order) (*Order, error)
+	GetOrderByID(ctx context.Context, id uint32) (*Order, error)
+	ListOpenOrders(ctx context.Context, wagerID uint32, side OrderSide, crossing money.Money) ([]Order, error)
+	UpdateOrder(ctx context.Context, order *Order) error
+	ListOrderBook(ctx context.Context, wagerID uint32, side OrderSide, levels uint32) ([]OrderBookLevel, error)
+	ListUserOpenOrders(ctx context.Context, userID uint32, side OrderSide) ([]Order, error)
+	CancelOpenOrders(ctx context.Context, wagerID uint32) error
+}
+
+// NewOrderRepo ...
+func NewOrderRepo(db *sql.DB) *OrderRepo {
+	return &OrderRepo{
+		db: db,
+	}
+}
+
+// OrderRepo is repository implementation for order db operations
+type OrderRepo struct {
+	db *sql.DB
+}
+
+// CreateOrder creates new open order record in db
+func (or *OrderRepo) CreateOrder(ctx context.Context, order *Order) (*Order, error) {
+	stmt, err := executor(ctx, or.db).PrepareContext(ctx, insertOrderStmt)
+	if err != nil {
+		return nil, err
+	}
+
+	defer stmt.Close()
+
+	row := stmt.QueryRowContext(ctx, order.WagerID, order.UserID, order.Side, order.Price, order.Quantity)
+	if err = scanOrder(row, order); err != nil {
+		return nil, err
+	}
+
+	return order, nil
+}
+
+// GetOrderByID returns order record by id or sql.ErrNoRows if not exists
+func (or *OrderRepo) GetOrderByID(ctx context.Context, id uint32) (*Order, error) {
+	stmt, err := executor(ctx, or.db).PrepareContext(ctx, getOrderByIDStmt)
+	if err != nil {
+		return nil, err
+	}
+
+	defer stmt.Close()
+
+	var order Order
+	if err = scanOrder(stmt.QueryRowContext(ctx, id), &order); err != nil {
+		return nil, err
+	}
+
+	return &order, nil
+}
+
+// ListOpenOrders returns open orders of wager side crossing price in price-time priority: asks at or below price
+// cheapest first, bids at or above price highest first, orders of same price oldest first
+func (or *OrderRepo) ListOpenOrders(
+	ctx context.Context, wagerID uint32, side OrderSide, crossing money.Money,
+) ([]Order, error) {
+	query := listOpenAsksStmt
+	if side == OrderSideBid {
+		query = listOpenBidsStmt
+	}
+
+	return or.queryOrders(ctx, query, wagerID, crossing)
+}
+
+// ListUserOpenOrders returns open orders of user side of all wagers, oldest first
+func (or *OrderRepo) ListUserOpenOrders(ctx context.Context, userID uint32, side OrderSide) ([]Order, error) {
+	return or.queryOrders(ctx, listUserOpenOrdersStmt, userID, side)
+}
+
+// CancelOpenOrders cancels all open orders of wager
+func (or *OrderRepo) CancelOpenOrders(ctx context.Context, wagerID uint32) error {
+	_, err := executor(ctx, or.db).ExecContext(ctx, cancelOpenOrdersStmt, wagerID)
+	return err
+}
+
+func (or *OrderRepo) queryOrders(ctx context.Context, query string, args ...interface{}) ([]Order, error) {
+	rows, err := executor(ctx, or.db).QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	var res []Order
+	for rows.Next() {
+		var order Order
+		if err = scanOrder(rows, &order); err != nil {
+			return nil, err
+		}
+
+		res = append(res, order)
+	}
+
+	return res, rows.Err()
+}
+
+// UpdateOrder updates order record for filled units and status
+func (or *OrderRepo) UpdateOrder(ctx context.Context, order *Order) error {
+	stmt, err := executor(ctx, or.db).PrepareContext(ctx, updateOrderStmt)
+	if err != nil {
+		return err
+	}
+
+	defer stmt.Close()
+
+	_, err = stmt.ExecContext(ctx, order.Filled, order.Status, order.ID)
+	return err
+}
+
+// ListOrderBook returns best price levels of open orders of wager side, highest bids and lowest asks first
+func (or *OrderRepo) ListOrderBook(
+	ctx context.Context, wagerID uint32, side OrderSide, levels uint32,
+) ([]OrderBookLevel, error) {
+	direction := "asc"
+	if side == OrderSideBid {
+		direction = "desc"
+	}
+
+	rows, err := executor(ctx, or.db).QueryContext(ctx, fmt.Sprintf(orderBookStmt, direction), wagerID, side, levels)
+	if err != nil {
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	res := make([]OrderBookLevel, 0, levels)
+	for rows.Next() {
+		var level OrderBookLevel
+		if err = rows.Scan(&level.Price, &level.Quantity, &level.Orders); err != nil {
+			return nil, err
+		}
+
+		res = append(res, level)
+	}
+
+	return res, rows.Err()
+}
+
+// rowScanner is single row of sql.Row or sql.Rows
+type rowScanner interface {
+	Scan(dest ...interface{}) error
+}
+
+func scanOrder(row rowScanner, order *Order) error {
+	return row.Scan(
+		&order.ID,
+		&order.WagerID,
+		&order.UserID,
+		&order.Side,
+		&order.Price,
+		&order.Quantity,
+		&order.Filled,
+		&order.Status,
+		&order.CreatedAt,
+		&order.UpdatedAt)
+}
@@ -3,27 +3,80 @@ package repo
 import (
 	"context"
 	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/vitthalaa/wager-app/money"
 )
 
 const (
-	insertPurchaseStmt = `insert into purchases(wager_id, buying_price) values ($1, $2)
-						returning *`
-	deletePurchaseStmt = "delete from purchases where id = $1"
+	insertPurchaseStmt = `insert into purchases(wager_id, buying_price, buyer_id, quantity, total_price)
+						values ($1, $2, $3, $4, $5) returning *`
+	deletePurchaseStmt  = "delete from purchases where id = $1"
+	getPurchaseByIDStmt = "select * from purchases where id = $1"
+	listPurchasesStmt   = "select * from purchases"
+	countPurchasesStmt  = "select count(*) from purchases"
+)
+
+// PurchaseSortField is whitelisted field purchases can be sorted by
+type PurchaseSortField string
+
+// Purchase sort fields
+const (
+	PurchaseSortID          PurchaseSortField = "id"
+	PurchaseSortBuyingPrice PurchaseSortField = "buying_price"
+	PurchaseSortCreatedAt   PurchaseSortField = "created_at"
 )
 
 // Purchase ...
 type Purchase struct {
-	ID          uint32
-	WagerID     uint32
-	BuyingPrice float32
+	ID      uint32
+	WagerID uint32
+	// BuyingPrice is price of one unit of wager
+	BuyingPrice money.Money
 	CreatedAt   sql.NullTime
 	UpdatedAt   sql.NullTime
+	BuyerID     sql.NullInt32
+	// Quantity is number of units bought, TotalPrice is paid for all of them
+	Quantity   uint32
+	TotalPrice money.Money
+}
+
+var purchaseSortFields = map[PurchaseSortField]bool{
+	PurchaseSortID:          true,
+	PurchaseSortBuyingPrice: true,
+	PurchaseSortCreatedAt:   true,
+}
+
+// Valid reports whether field is whitelisted sort field
+func (f PurchaseSortField) Valid() bool {
+	return purchaseSortFields[f]
+}
+
+// PurchaseFilter is criteria for listing purchases, zero value fields are not applied
+type PurchaseFilter struct {
+	WagerID  uint32
+	MinPrice money.Money
+	MaxPrice money.Money
+	// From and To are inclusive range of purchase creation time
+	From time.Time
+	To   time.Time
+
+	// SortBy defaults to id, ties are broken by id
+	SortBy   PurchaseSortField
+	SortDesc bool
+
+	Offset uint32
+	Limit  uint32
 }
 
 // IPurchaseRepo is repository interface for purchase db operations
 type IPurchaseRepo interface {
 	CreatePurchase(ctx context.Context, purchase *Purchase) (*Purchase, error)
 	DeletePurchase(ctx context.Context, id uint32) error
+	GetPurchaseByID(ctx context.Context, id uint32) (*Purchase, error)
+	ListPurchases(ctx context.Context, filter PurchaseFilter) ([]Purchase, error)
+	CountPurchases(ctx context.Context, filter PurchaseFilter) (uint32, error)
 }
 
 // NewPurchaseRepo ...
@@ -40,20 +93,25 @@ type PurchaseRepo struct {
 
 // CreatePurchase creates new purchase record in db
 func (pr *PurchaseRepo) CreatePurchase(ctx context.Context, purchase *Purchase) (*Purchase, error) {
-	stmt, err := pr.db.Prepare(insertPurchaseStmt)
+	stmt, err := executor(ctx, pr.db).PrepareContext(ctx, insertPurchaseStmt)
 	if err != nil {
 		return nil, err
 	}
 
+	defer stmt.Close()
+
 	row := stmt.QueryRowContext(ctx,
-		purchase.WagerID, purchase.BuyingPrice)
+		purchase.WagerID, purchase.BuyingPrice, purchase.BuyerID, purchase.Quantity, purchase.TotalPrice)
 
 	err = row.Scan(
 		&purchase.ID,
 		&purchase.WagerID,
 		&purchase.BuyingPrice,
 		&purchase.CreatedAt,
-		&purchase.UpdatedAt)
+		&purchase.UpdatedAt,
+		&purchase.BuyerID,
+		&purchase.Quantity,
+		&purchase.TotalPrice)
 	if err != nil {
 		return nil, err
 	}
@@ -63,11 +121,13 @@ func (pr *PurchaseRepo) CreatePurchase(ctx context.Context, purchase *Purchase)
 
 // DeletePurchase deletes purchase record from db by id
 func (pr *PurchaseRepo) DeletePurchase(ctx context.Context, id uint32) error {
-	stmt, err := pr.db.Prepare(deletePurchaseStmt)
+	stmt, err := executor(ctx, pr.db).PrepareContext(ctx, deletePurchaseStmt)
 	if err != nil {
 		return err
 	}
 
+	defer stmt.Close()
+
 	_, err = stmt.ExecContext(ctx, id)
 	if err != nil {
 		return err
@@ -75,3 +135,118 @@ func (pr *PurchaseRepo) DeletePurchase(ctx context.Context, id uint32) error {
 
 	return nil
 }
+
+// GetPurchaseByID returns purchase record by id
+func (pr *PurchaseRepo) GetPurchaseByID(ctx context.Context, id uint32) (*Purchase, error) {
+	stmt, err := executor(ctx, pr.db).PrepareContext(ctx, getPurchaseByIDStmt)
+	if err != nil {
+		return nil, err
+	}
+
+	defer stmt.Close()
+
+	var purchase Purchase
+	err = stmt.QueryRowContext(ctx, id).Scan(
+		&purchase.ID,
+		&purchase.WagerID,
+		&purchase.BuyingPrice,
+		&purchase.CreatedAt,
+		&purchase.UpdatedAt,
+		&purchase.BuyerID,
+		&purchase.Quantity,
+		&purchase.TotalPrice)
+	if err != nil {
+		return nil, err
+	}
+
+	return &purchase, nil
+}
+
+// ListPurchases returns purchases matching filter, sorted and paginated by filter
+func (pr *PurchaseRepo) ListPurchases(ctx context.Context, filter PurchaseFilter) ([]Purchase, error) {
+	qb := purchaseFilterQuery(filter)
+
+	direction := " asc"
+	if filter.SortDesc {
+		direction = " desc"
+	}
+
+	sortBy := filter.SortBy
+	if sortBy == "" {
+		sortBy = PurchaseSortID
+	}
+
+	if !sortBy.Valid() {
+		return nil, fmt.Errorf("unsupported purchase sort field %q", sortBy)
+	}
+
+	query := listPurchasesStmt + qb.whereClause() +
+		" order by " + string(sortBy) + direction + ", id" + direction +
+		" limit " + qb.arg(filter.Limit) + " offset " + qb.arg(filter.Offset)
+
+	rows, err := executor(ctx, pr.db).QueryContext(ctx, query, qb.args...)
+	if err != nil {
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	res := make([]Purchase, 0)
+	for rows.Next() {
+		var purchase Purchase
+		err = rows.Scan(
+			&purchase.ID,
+			&purchase.WagerID,
+			&purchase.BuyingPrice,
+			&purchase.CreatedAt,
+			&purchase.UpdatedAt,
+			&purchase.BuyerID,
+			&purchase.Quantity,
+			&purchase.TotalPrice)
+		if err != nil {
+			return nil, err
+		}
+
+		res = append(res, purchase)
+	}
+
+	return res, rows.Err()
+}
+
+// CountPurchases returns number of purchases matching filter, pagination is ignored
+func (pr *PurchaseRepo) CountPurchases(ctx context.Context, filter PurchaseFilter) (uint32, error) {
+	qb := purchaseFilterQuery(filter)
+
+	var count uint32
+	err := executor(ctx, pr.db).QueryRowContext(ctx, countPurchasesStmt+qb.whereClause(), qb.args...).Scan(&count)
+	if err != nil {
+		return 0, err
+	}
+
+	return count, nil
+}
+
+func purchaseFilterQuery(filter PurchaseFilter) *queryBuilder {
+	qb := &queryBuilder{}
+	if filter.WagerID > 0 {
+		qb.where("wager_id = ?", filter.WagerID)
+	}
+
+	if filter.MinPrice > 0 {
+		qb.where("buying_price >= ?", filter.MinPrice)
+	}
+
+	if filter.MaxPrice > 0 {
+		qb.where("buying_price <= ?", filter.MaxPrice)
+	}
+
+	if !filter.From.IsZero() {
+		qb.where("created_at >= ?", sqlTime(filter.From))
+	}
+
+	if !filter.To.IsZero() {
+		qb.where("created_at <= ?", sqlTime(filter.To))
+	}
+
+	return qb
+}
@@ -0,0 +1,56 @@
+package repo
+
+import (
+	"database/sql"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// sqlTimeLayout is layout of time query args compared to timestamp columns
+const sqlTimeLayout = "2006-01-02 15:04:05.999999"
+
+// queryBuilder builds where clause with positional ($n) args for dynamic queries
+type queryBuilder struct {
+	conds []string
+	args  []interface{}
+}
+
+// where adds condition, every ? in cond is replaced by placeholder of next arg
+func (b *queryBuilder) where(cond string, args ...interface{}) {
+	for _, arg := range args {
+		cond = strings.Replace(cond, "?", b.arg(arg), 1)
+	}
+
+	b.conds = append(b.conds, cond)
+}
+
+// arg adds arg and returns its placeholder
+func (b *queryBuilder) arg(arg interface{}) string {
+	b.args = append(b.args, arg)
+	return "$" + strconv.Itoa(len(b.args))
+}
+
+// whereClause returns where clause of all conditions joined by and
+func (b *queryBuilder) whereClause() string {
+	if len(b.conds) == 0 {
+		return ""
+	}
+
+	return " where " + strings.Join(b.conds, " and ")
+}
+
+// sqlTime formats time arg compared to timestamp columns.
+// Timestamps are stored without time zone, so args are compared as UTC wall clock in every dialect.
+func sqlTime(t time.Time) string {
+	return t.UTC().Format(sqlTimeLayout)
+}
+
+// nullSQLTime is sqlTime of valid time, nil otherwise
+func nullSQLTime(t sql.NullTime) interface{} {
+	if !t.Valid {
+		return nil
+	}
+
+	return sqlTime(t.Time)
+}
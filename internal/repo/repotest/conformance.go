@@ -0,0 +1,1523 @@
+// Package repotest contains conformance test suite shared by all storage backends.
+// Every implementation of repo interfaces must pass it to keep identical semantics.
+package repotest
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/vitthalaa/wager-app/internal/repo"
+	"github.com/vitthalaa/wager-app/money"
+)
+
+// Repos is set of repositories of a storage backend under test
+type Repos struct {
+	Transactor  repo.ITransactor
+	Wager       repo.IWagerRepo
+	Purchase    repo.IPurchaseRepo
+	Settlement  repo.ISettlementRepo
+	User        repo.IUserRepo
+	Wallet      repo.IWalletRepo
+	Idempotency repo.IIdempotencyRepo
+	Health      repo.IHealthRepo
+	Order       repo.IOrderRepo
+	AuctionBid  repo.IAuctionBidRepo
+}
+
+// RunConformance runs all conformance tests against repos returned by newRepos.
+// newRepos is called once per test.
+func RunConformance(t *testing.T, newRepos func(t *testing.T) Repos) {
+	t.Run("CreateWager", func(t *testing.T) { testCreateWager(t, newRepos(t)) })
+	t.Run("GetWagerByID", func(t *testing.T) { testGetWagerByID(t, newRepos(t)) })
+	t.Run("ListWager", func(t *testing.T) { testListWager(t, newRepos(t)) })
+	t.Run("ListWagerFilter", func(t *testing.T) { testListWagerFilter(t, newRepos(t)) })
+	t.Run("ListWagerSort", func(t *testing.T) { testListWagerSort(t, newRepos(t)) })
+	t.Run("ListDueAuctions", func(t *testing.T) { testListDueAuctions(t, newRepos(t)) })
+	t.Run("UpdateWager", func(t *testing.T) { testUpdateWager(t, newRepos(t)) })
+	t.Run("SettleWager", func(t *testing.T) { testSettleWager(t, newRepos(t)) })
+	t.Run("CreatePurchase", func(t *testing.T) { testCreatePurchase(t, newRepos(t)) })
+	t.Run("DeletePurchase", func(t *testing.T) { testDeletePurchase(t, newRepos(t)) })
+	t.Run("GetPurchaseByID", func(t *testing.T) { testGetPurchaseByID(t, newRepos(t)) })
+	t.Run("ListPurchasesByWager", func(t *testing.T) { testListPurchasesByWager(t, newRepos(t)) })
+	t.Run("ListPurchasesFilterAndSort", func(t *testing.T) { testListPurchasesFilterAndSort(t, newRepos(t)) })
+	t.Run("CreateSettlement", func(t *testing.T) { testCreateSettlement(t, newRepos(t)) })
+	t.Run("ListSettlementsByWagerID", func(t *testing.T) { testListSettlementsByWagerID(t, newRepos(t)) })
+	t.Run("CreateUser", func(t *testing.T) { testCreateUser(t, newRepos(t)) })
+	t.Run("GetUserByUsername", func(t *testing.T) { testGetUserByUsername(t, newRepos(t)) })
+	t.Run("SellerAndBuyer", func(t *testing.T) { testSellerAndBuyer(t, newRepos(t)) })
+	t.Run("CreateAccount", func(t *testing.T) { testCreateAccount(t, newRepos(t)) })
+	t.Run("CreateLedgerTransaction", func(t *testing.T) { testCreateLedgerTransaction(t, newRepos(t)) })
+	t.Run("ListLedgerEntries", func(t *testing.T) { testListLedgerEntries(t, newRepos(t)) })
+	t.Run("LedgerTransactionRollback", func(t *testing.T) { testLedgerTransactionRollback(t, newRepos(t)) })
+	t.Run("CreateOrder", func(t *testing.T) { testCreateOrder(t, newRepos(t)) })
+	t.Run("ListOpenOrders", func(t *testing.T) { testListOpenOrders(t, newRepos(t)) })
+	t.Run("UpdateOrder", func(t *testing.T) { testUpdateOrder(t, newRepos(t)) })
+	t.Run("ListOrderBook", func(t *testing.T) { testListOrderBook(t, newRepos(t)) })
+	t.Run("ListUserOpenOrders", func(t *testing.T) { testListUserOpenOrders(t, newRepos(t)) })
+	t.Run("CancelOpenOrders", func(t *testing.T) { testCancelOpenOrders(t, newRepos(t)) })
+	t.Run("CreateAuctionBid", func(t *testing.T) { testCreateAuctionBid(t, newRepos(t)) })
+	t.Run("ListAuctionBids", func(t *testing.T) { testListAuctionBids(t, newRepos(t)) })
+	t.Run("UpdateAuctionBid", func(t *testing.T) { testUpdateAuctionBid(t, newRepos(t)) })
+	t.Run("CreateIdempotencyKey", func(t *testing.T) { testCreateIdempotencyKey(t, newRepos(t)) })
+	t.Run("DeleteIdempotencyKey", func(t *testing.T) { testDeleteIdempotencyKey(t, newRepos(t)) })
+	t.Run("Ping", func(t *testing.T) { testPing(t, newRepos(t)) })
+	t.Run("TransactionCommit", func(t *testing.T) { testTransactionCommit(t, newRepos(t)) })
+	t.Run("TransactionRollback", func(t *testing.T) { testTransactionRollback(t, newRepos(t)) })
+	t.Run("TransactionLockForUpdate", func(t *testing.T) { testTransactionLockForUpdate(t, newRepos(t)) })
+}
+
+func newWager() *repo.Wager {
+	return &repo.Wager{
+		TotalWagerValue:     100,
+		Odds:                2,
+		SellingPercentage:   20,
+		SellingPrice:        money.MustParse("25.5"),
+		CurrentSellingPrice: money.MustParse("25.5"),
+		Pricing:             repo.WagerPricingLastTrade,
+	}
+}
+
+func createWager(t *testing.T, r Repos) *repo.Wager {
+	wager, err := r.Wager.CreateWager(context.Background(), newWager())
+	require.Nil(t, err)
+
+	return wager
+}
+
+func testCreateWager(t *testing.T, r Repos) {
+	ctx := context.Background()
+
+	first, err := r.Wager.CreateWager(ctx, newWager())
+	require.Nil(t, err)
+	auction := newWager()
+	auction.Pricing = repo.WagerPricingDutchAuction
+	auction.FloorPrice = money.MustParse("10.25")
+	auction.DecaySeconds = 3600
+	auction.StepPercentage = 2.5
+	second, err := r.Wager.CreateWager(ctx, auction)
+	require.Nil(t, err)
+
+	assert.NotZero(t, first.ID)
+	assert.Greater(t, second.ID, first.ID)
+	assert.Equal(t, uint32(100), first.TotalWagerValue)
+	assert.Equal(t, uint32(2), first.Odds)
+	assert.Equal(t, float32(20), first.SellingPercentage)
+	assert.Equal(t, money.MustParse("25.5"), first.SellingPrice)
+	assert.Equal(t, money.MustParse("25.5"), first.CurrentSellingPrice)
+	assert.False(t, first.PercentageSold.Valid)
+	assert.False(t, first.AmountSold.Valid)
+	assert.True(t, first.CreatedAt.Valid)
+	assert.False(t, first.UpdatedAt.Valid)
+	assert.Equal(t, repo.WagerStatusOpen, first.Status)
+	assert.False(t, first.Outcome.Valid)
+	assert.False(t, first.SettledAt.Valid)
+	assert.Equal(t, repo.WagerPricingLastTrade, first.Pricing)
+	assert.False(t, first.PricedAt.Valid)
+	assert.Equal(t, repo.WagerAuctionNone, first.Auction)
+	assert.False(t, first.InAuction())
+
+	assert.Equal(t, repo.WagerPricingDutchAuction, second.Pricing)
+	assert.Equal(t, money.MustParse("10.25"), second.FloorPrice)
+	assert.Equal(t, uint32(3600), second.DecaySeconds)
+	assert.Equal(t, float32(2.5), second.StepPercentage)
+}
+
+func testGetWagerByID(t *testing.T, r Repos) {
+	ctx := context.Background()
+	created := createWager(t, r)
+
+	wager, err := r.Wager.GetWagerByID(ctx, created.ID)
+	require.Nil(t, err)
+	assert.Equal(t, created.ID, wager.ID)
+	assert.Equal(t, created.CurrentSellingPrice, wager.CurrentSellingPrice)
+	assert.True(t, created.CreatedAt.Time.Equal(wager.CreatedAt.Time))
+
+	_, err = r.Wager.GetWagerByID(ctx, created.ID+1000)
+	assert.Equal(t, sql.ErrNoRows, err)
+}
+
+func testListWager(t *testing.T, r Repos) {
+	ctx := context.Background()
+	first := createWager(t, r)
+	second := createWager(t, r)
+	third := createWager(t, r)
+
+	list, err := r.Wager.ListWager(ctx, repo.WagerFilter{SortDesc: true, Limit: 2})
+	require.Nil(t, err)
+	require.Len(t, list, 2)
+	assert.Equal(t, third.ID, list[0].ID)
+	assert.Equal(t, second.ID, list[1].ID)
+
+	// keyset continues after last listed wager
+	list, err = r.Wager.ListWager(ctx, repo.WagerFilter{SortDesc: true, After: cursorAt(list[1]), Limit: 2})
+	require.Nil(t, err)
+	require.Len(t, list, 1)
+	assert.Equal(t, first.ID, list[0].ID)
+
+	list, err = r.Wager.ListWager(ctx, repo.WagerFilter{SortDesc: true, After: cursorAt(*first), Limit: 2})
+	require.Nil(t, err)
+	assert.Empty(t, list)
+
+	list, err = r.Wager.ListWager(ctx, repo.WagerFilter{After: cursorAt(*first), Limit: 2})
+	require.Nil(t, err)
+	require.Len(t, list, 2)
+	assert.Equal(t, second.ID, list[0].ID)
+	assert.Equal(t, third.ID, list[1].ID)
+
+	_, err = r.Wager.ListWager(ctx, repo.WagerFilter{SortBy: "seller_id; drop table wager", Limit: 2})
+	assert.NotNil(t, err)
+}
+
+func testListWagerFilter(t *testing.T, r Repos) {
+	ctx := context.Background()
+
+	var wagers []*repo.Wager
+	for _, w := range []struct {
+		odds    uint32
+		price   money.Money
+		pricing repo.WagerPricing
+	}{
+		{odds: 2, price: money.MustParse("25.5"), pricing: repo.WagerPricingTimeDecay},
+		{odds: 5, price: money.MustParse("30")},
+		{odds: 10, price: money.MustParse("50"), pricing: repo.WagerPricingDutchAuction},
+		{odds: 5, price: money.MustParse("45")},
+	} {
+		wager := newWager()
+		wager.Odds = w.odds
+		// price filter compares current selling price
+		wager.SellingPrice = w.price + money.MustParse("100")
+		wager.CurrentSellingPrice = w.price
+		if w.pricing != "" {
+			wager.Pricing = w.pricing
+		}
+		created, err := r.Wager.CreateWager(ctx, wager)
+		require.Nil(t, err)
+		wagers = append(wagers, created)
+	}
+
+	wagers[1].Status = repo.WagerStatusSoldOut
+	require.Nil(t, r.Wager.UpdateWager(ctx, wagers[1]))
+
+	ids := func(filter repo.WagerFilter) []uint32 {
+		filter.SortDesc = true
+		filter.Limit = 10
+		list, err := r.Wager.ListWager(ctx, filter)
+		require.Nil(t, err)
+
+		res := make([]uint32, 0, len(list))
+		for _, w := range list {
+			res = append(res, w.ID)
+		}
+
+		return res
+	}
+
+	soldOut, notSoldOut := true, false
+	first, last := wagers[0].CreatedAt.Time, wagers[3].CreatedAt.Time
+
+	assert.Equal(t, []uint32{wagers[3].ID, wagers[1].ID}, ids(repo.WagerFilter{MinOdds: 5, MaxOdds: 5}))
+	assert.Equal(t, []uint32{wagers[2].ID}, ids(repo.WagerFilter{MinOdds: 6}))
+	assert.Equal(t, []uint32{wagers[3].ID, wagers[1].ID},
+		ids(repo.WagerFilter{MinPrice: money.MustParse("30"), MaxPrice: money.MustParse("45")}))
+	assert.Equal(t, []uint32{wagers[3].ID, wagers[1].ID}, ids(repo.WagerFilter{
+		ExcludePricings: []repo.WagerPricing{repo.WagerPricingTimeDecay, repo.WagerPricingDutchAuction},
+	}))
+	assert.Equal(t, []uint32{wagers[3].ID, wagers[1].ID, wagers[0].ID}, ids(repo.WagerFilter{
+		ExcludePricings: []repo.WagerPricing{repo.WagerPricingDutchAuction},
+	}))
+	assert.Equal(t, []uint32{wagers[1].ID}, ids(repo.WagerFilter{SoldOut: &soldOut}))
+	assert.Equal(t, []uint32{wagers[3].ID, wagers[2].ID, wagers[0].ID}, ids(repo.WagerFilter{SoldOut: &notSoldOut}))
+
+	// inclusive date range covering all wagers, in past and in future
+	assert.Len(t, ids(repo.WagerFilter{From: first, To: last}), 4)
+	assert.Empty(t, ids(repo.WagerFilter{To: first.Add(-time.Hour)}))
+	assert.Empty(t, ids(repo.WagerFilter{From: last.Add(time.Hour)}))
+
+	// filters combined with keyset
+	assert.Equal(t, []uint32{wagers[1].ID}, ids(repo.WagerFilter{MinOdds: 5, MaxOdds: 5, After: cursorAt(*wagers[3])}))
+}
+
+func testListWagerSort(t *testing.T, r Repos) {
+	ctx := context.Background()
+
+	var wagers []*repo.Wager
+	for _, w := range []struct {
+		odds  uint32
+		price money.Money
+		sold  float64
+	}{
+		{odds: 5, price: money.MustParse("30")},
+		{odds: 2, price: money.MustParse("25.5"), sold: 10},
+		{odds: 5, price: money.MustParse("20"), sold: 50.5},
+		{odds: 10, price: money.MustParse("25.5"), sold: 10},
+	} {
+		wager := newWager()
+		wager.Odds = w.odds
+		wager.SellingPrice = w.price
+		wager.CurrentSellingPrice = w.price
+		created, err := r.Wager.CreateWager(ctx, wager)
+		require.Nil(t, err)
+
+		if w.sold > 0 {
+			created.PercentageSold = sql.NullFloat64{Float64: w.sold, Valid: true}
+			require.Nil(t, r.Wager.UpdateWager(ctx, created))
+		}
+
+		wagers = append(wagers, created)
+	}
+
+	// pages lists all wagers page by page of size 1, so every page continues after cursor of previous one
+	pages := func(sortBy repo.WagerSortField, desc bool) []uint32 {
+		var res []uint32
+		filter := repo.WagerFilter{SortBy: sortBy, SortDesc: desc, Limit: 1}
+		for len(res) <= len(wagers) {
+			list, err := r.Wager.ListWager(ctx, filter)
+			require.Nil(t, err)
+			if len(list) == 0 {
+				break
+			}
+
+			res = append(res, list[0].ID)
+			filter.After = cursorAt(list[0])
+		}
+
+		return res
+	}
+
+	w := func(i int) uint32 { return wagers[i].ID }
+
+	// ties are broken by id in sort direction
+	assert.Equal(t, []uint32{w(2), w(1), w(3), w(0)}, pages(repo.WagerSortCurrentSellingPrice, false))
+	assert.Equal(t, []uint32{w(0), w(3), w(1), w(2)}, pages(repo.WagerSortCurrentSellingPrice, true))
+	assert.Equal(t, []uint32{w(3), w(2), w(0), w(1)}, pages(repo.WagerSortOdds, true))
+	// wager without purchases is sorted as 0 sold
+	assert.Equal(t, []uint32{w(2), w(3), w(1), w(0)}, pages(repo.WagerSortPercentageSold, true))
+	assert.Equal(t, []uint32{w(0), w(1), w(3), w(2)}, pages(repo.WagerSortPercentageSold, false))
+	assert.Equal(t, []uint32{w(3), w(2), w(1), w(0)}, pages(repo.WagerSortCreatedAt, true))
+
+	// cursor carries sort values, so repriced or missing cursor wager does not shift next page
+	next := func(cursor *repo.WagerCursor) []uint32 {
+		list, err := r.Wager.ListWager(ctx, repo.WagerFilter{SortBy: repo.WagerSortCurrentSellingPrice, After: cursor, Limit: 10})
+		require.Nil(t, err)
+
+		var res []uint32
+		for _, wager := range list {
+			res = append(res, wager.ID)
+		}
+
+		return res
+	}
+
+	cursor := cursorAt(*wagers[1])
+	wagers[1].CurrentSellingPrice = money.MustParse("100")
+	require.Nil(t, r.Wager.UpdateWager(ctx, wagers[1]))
+	assert.Equal(t, []uint32{w(3), w(0), w(1)}, next(cursor))
+
+	missing := &repo.WagerCursor{ID: w(3) + 1000, CurrentSellingPrice: money.MustParse("25.5")}
+	assert.Equal(t, []uint32{w(0), w(1)}, next(missing))
+}
+
+// cursorAt returns keyset cursor positioned at wager
+func cursorAt(wager repo.Wager) *repo.WagerCursor {
+	cursor := repo.NewWagerCursor(wager)
+	return &cursor
+}
+
+func testUpdateWager(t *testing.T, r Repos) {
+	ctx := context.Background()
+	wager := createWager(t, r)
+
+	wager.CurrentSellingPrice = money.MustParse("20")
+	wager.AmountSold = sql.NullInt32{Int32: 3, Valid: true}
+	wager.PercentageSold = sql.NullFloat64{Float64: 3, Valid: true}
+	wager.Status = repo.WagerStatusSoldOut
+	pricedAt := time.Now().UTC().Truncate(time.Microsecond)
+	wager.PricedAt = sql.NullTime{Time: pricedAt, Valid: true}
+	wager.AuctionClosedAt = sql.NullTime{Time: pricedAt, Valid: true}
+	// not updatable fields
+	wager.Odds = 10
+	wager.SellingPrice = money.MustParse("1")
+	wager.Outcome = sql.NullString{String: string(repo.WagerOutcomeWon), Valid: true}
+
+	err := r.Wager.UpdateWager(ctx, wager)
+	require.Nil(t, err)
+
+	updated, err := r.Wager.GetWagerByID(ctx, wager.ID)
+	require.Nil(t, err)
+	assert.Equal(t, money.MustParse("20"), updated.CurrentSellingPrice)
+	assert.Equal(t, sql.NullInt32{Int32: 3, Valid: true}, updated.AmountSold)
+	assert.Equal(t, sql.NullFloat64{Float64: 3, Valid: true}, updated.PercentageSold)
+	assert.Equal(t, uint32(2), updated.Odds)
+	assert.Equal(t, money.MustParse("25.5"), updated.SellingPrice)
+	assert.True(t, updated.UpdatedAt.Valid)
+	assert.Equal(t, repo.WagerStatusSoldOut, updated.Status)
+	assert.False(t, updated.Outcome.Valid)
+	assert.True(t, updated.PricedAt.Valid)
+	assert.True(t, pricedAt.Equal(updated.PricedAt.Time), "priced at %v, got %v", pricedAt, updated.PricedAt.Time)
+	assert.True(t, updated.AuctionClosedAt.Valid)
+}
+
+func createAuctionWager(t *testing.T, r Repos, auction repo.WagerAuction, endsAt time.Time) *repo.Wager {
+	wager := newWager()
+	wager.Auction = auction
+	wager.AuctionEndsAt = sql.NullTime{Time: endsAt, Valid: true}
+	created, err := r.Wager.CreateWager(context.Background(), wager)
+	require.Nil(t, err)
+
+	return created
+}
+
+func testListDueAuctions(t *testing.T, r Repos) {
+	ctx := context.Background()
+	now := time.Now().UTC().Truncate(time.Microsecond)
+
+	createWager(t, r)
+	later := createAuctionWager(t, r, repo.WagerAuctionSecondPrice, now.Add(-time.Minute))
+	earlier := createAuctionWager(t, r, repo.WagerAuctionFirstPrice, now.Add(-time.Hour))
+	createAuctionWager(t, r, repo.WagerAuctionFirstPrice, now.Add(time.Hour))
+	closed := createAuctionWager(t, r, repo.WagerAuctionFirstPrice, now.Add(-time.Hour))
+
+	assert.True(t, earlier.InAuction())
+	assert.False(t, closed.AuctionClosedAt.Valid)
+
+	closed.AuctionClosedAt = sql.NullTime{Time: now, Valid: true}
+	require.Nil(t, r.Wager.UpdateWager(ctx, closed))
+
+	due, err := r.Wager.ListDueAuctions(ctx, now)
+	require.Nil(t, err)
+	require.Len(t, due, 2)
+	assert.Equal(t, earlier.ID, due[0].ID)
+	assert.Equal(t, later.ID, due[1].ID)
+	assert.Equal(t, repo.WagerAuctionFirstPrice, due[0].Auction)
+	assert.Equal(t, repo.WagerAuctionSecondPrice, due[1].Auction)
+	assert.True(t, due[0].AuctionEndsAt.Valid)
+	assert.True(t, now.Add(-time.Hour).Equal(due[0].AuctionEndsAt.Time), "ends at %v, got %v",
+		now.Add(-time.Hour), due[0].AuctionEndsAt.Time)
+	assert.False(t, due[0].AuctionClosedAt.Valid)
+
+	stored, err := r.Wager.GetWagerByID(ctx, closed.ID)
+	require.Nil(t, err)
+	assert.False(t, stored.InAuction())
+
+	due, err = r.Wager.ListDueAuctions(ctx, now.Add(-2*time.Hour))
+	require.Nil(t, err)
+	assert.Empty(t, due)
+}
+
+func testSettleWager(t *testing.T, r Repos) {
+	ctx := context.Background()
+	wager := createWager(t, r)
+
+	wager.Status = repo.WagerStatusVoided
+	wager.Outcome = sql.NullString{String: string(repo.WagerOutcomeVoid), Valid: true}
+	// not updatable fields
+	wager.CurrentSellingPrice = money.MustParse("1")
+
+	err := r.Wager.SettleWager(ctx, wager)
+	require.Nil(t, err)
+
+	settled, err := r.Wager.GetWagerByID(ctx, wager.ID)
+	require.Nil(t, err)
+	assert.Equal(t, repo.WagerStatusVoided, settled.Status)
+	assert.Equal(t, sql.NullString{String: string(repo.WagerOutcomeVoid), Valid: true}, settled.Outcome)
+	assert.True(t, settled.SettledAt.Valid)
+	assert.True(t, settled.UpdatedAt.Valid)
+	assert.Equal(t, money.MustParse("25.5"), settled.CurrentSellingPrice)
+}
+
+func testCreatePurchase(t *testing.T, r Repos) {
+	ctx := context.Background()
+	wager := createWager(t, r)
+
+	first, err := r.Purchase.CreatePurchase(ctx, &repo.Purchase{WagerID: wager.ID, BuyingPrice: money.MustParse("20.5")})
+	require.Nil(t, err)
+	second, err := r.Purchase.CreatePurchase(ctx, &repo.Purchase{WagerID: wager.ID, BuyingPrice: money.MustParse("20")})
+	require.Nil(t, err)
+
+	assert.NotZero(t, first.ID)
+	assert.Greater(t, second.ID, first.ID)
+	assert.Equal(t, wager.ID, first.WagerID)
+	assert.Equal(t, money.MustParse("20.5"), first.BuyingPrice)
+	assert.True(t, first.CreatedAt.Valid)
+	assert.False(t, first.UpdatedAt.Valid)
+
+	// money must round trip exactly
+	exact, err := r.Purchase.CreatePurchase(ctx, &repo.Purchase{WagerID: wager.ID, BuyingPrice: money.MustParse("19.99")})
+	require.Nil(t, err)
+	assert.Equal(t, money.MustParse("19.99"), exact.BuyingPrice)
+
+	stored, err := r.Purchase.GetPurchaseByID(ctx, exact.ID)
+	require.Nil(t, err)
+	assert.Equal(t, money.MustParse("19.99"), stored.BuyingPrice)
+
+	// unit and total price are stored separately
+	several, err := r.Purchase.CreatePurchase(ctx, &repo.Purchase{
+		WagerID: wager.ID, BuyingPrice: money.MustParse("19.99"), Quantity: 3, TotalPrice: money.MustParse("59.97"),
+	})
+	require.Nil(t, err)
+
+	stored, err = r.Purchase.GetPurchaseByID(ctx, several.ID)
+	require.Nil(t, err)
+	assert.Equal(t, uint32(3), stored.Quantity)
+	assert.Equal(t, money.MustParse("19.99"), stored.BuyingPrice)
+	assert.Equal(t, money.MustParse("59.97"), stored.TotalPrice)
+
+	_, err = r.Purchase.CreatePurchase(ctx, &repo.Purchase{WagerID: wager.ID + 1000, BuyingPrice: money.MustParse("20")})
+	assert.NotNil(t, err, "purchase of not existing wager must fail")
+}
+
+func testDeletePurchase(t *testing.T, r Repos) {
+	ctx := context.Background()
+	wager := createWager(t, r)
+
+	purchase, err := r.Purchase.CreatePurchase(ctx, &repo.Purchase{WagerID: wager.ID, BuyingPrice: money.MustParse("20.5")})
+	require.Nil(t, err)
+
+	assert.Nil(t, r.Purchase.DeletePurchase(ctx, purchase.ID))
+	// deleting missing record is not an error
+	assert.Nil(t, r.Purchase.DeletePurchase(ctx, purchase.ID))
+}
+
+func testGetPurchaseByID(t *testing.T, r Repos) {
+	ctx := context.Background()
+	wager := createWager(t, r)
+
+	created, err := r.Purchase.CreatePurchase(ctx, &repo.Purchase{WagerID: wager.ID, BuyingPrice: money.MustParse("20.5")})
+	require.Nil(t, err)
+
+	purchase, err := r.Purchase.GetPurchaseByID(ctx, created.ID)
+	require.Nil(t, err)
+	assert.Equal(t, created.ID, purchase.ID)
+	assert.Equal(t, wager.ID, purchase.WagerID)
+	assert.Equal(t, money.MustParse("20.5"), purchase.BuyingPrice)
+	assert.True(t, created.CreatedAt.Time.Equal(purchase.CreatedAt.Time))
+
+	_, err = r.Purchase.GetPurchaseByID(ctx, created.ID+1000)
+	assert.Equal(t, sql.ErrNoRows, err)
+}
+
+func testListPurchasesByWager(t *testing.T, r Repos) {
+	ctx := context.Background()
+	wager := createWager(t, r)
+	other := createWager(t, r)
+
+	var ids []uint32
+	for _, price := range []money.Money{money.FromUnits(20), money.FromUnits(19), money.FromUnits(18)} {
+		purchase, err := r.Purchase.CreatePurchase(ctx, &repo.Purchase{WagerID: wager.ID, BuyingPrice: price})
+		require.Nil(t, err)
+		ids = append(ids, purchase.ID)
+	}
+
+	_, err := r.Purchase.CreatePurchase(ctx, &repo.Purchase{WagerID: other.ID, BuyingPrice: money.MustParse("20")})
+	require.Nil(t, err)
+
+	filter := repo.PurchaseFilter{WagerID: wager.ID, SortDesc: true, Limit: 2}
+	list, err := r.Purchase.ListPurchases(ctx, filter)
+	require.Nil(t, err)
+	require.Len(t, list, 2)
+	assert.Equal(t, ids[2], list[0].ID)
+	assert.Equal(t, ids[1], list[1].ID)
+	assert.Equal(t, money.MustParse("19"), list[1].BuyingPrice)
+	assert.True(t, list[1].CreatedAt.Valid)
+
+	filter.Offset = 2
+	list, err = r.Purchase.ListPurchases(ctx, filter)
+	require.Nil(t, err)
+	require.Len(t, list, 1)
+	assert.Equal(t, ids[0], list[0].ID)
+
+	count, err := r.Purchase.CountPurchases(ctx, filter)
+	require.Nil(t, err)
+	assert.Equal(t, uint32(3), count)
+
+	filter = repo.PurchaseFilter{WagerID: wager.ID + 1000, Limit: 2}
+	list, err = r.Purchase.ListPurchases(ctx, filter)
+	require.Nil(t, err)
+	assert.Empty(t, list)
+
+	count, err = r.Purchase.CountPurchases(ctx, filter)
+	require.Nil(t, err)
+	assert.Zero(t, count)
+}
+
+func testListPurchasesFilterAndSort(t *testing.T, r Repos) {
+	ctx := context.Background()
+	wager := createWager(t, r)
+
+	var purchases []*repo.Purchase
+	for _, price := range []money.Money{money.FromUnits(15), money.FromUnits(25), money.FromUnits(20), money.FromUnits(25)} {
+		purchase, err := r.Purchase.CreatePurchase(ctx, &repo.Purchase{WagerID: wager.ID, BuyingPrice: price})
+		require.Nil(t, err)
+		purchases = append(purchases, purchase)
+	}
+
+	ids := func(list []repo.Purchase) []uint32 {
+		res := make([]uint32, 0, len(list))
+		for _, p := range list {
+			res = append(res, p.ID)
+		}
+
+		return res
+	}
+
+	// price range, sorted by price desc with ties broken by id desc
+	filter := repo.PurchaseFilter{
+		WagerID:  wager.ID,
+		MinPrice: money.MustParse("20"),
+		MaxPrice: money.MustParse("25"),
+		SortBy:   repo.PurchaseSortBuyingPrice,
+		SortDesc: true,
+		Limit:    10,
+	}
+	list, err := r.Purchase.ListPurchases(ctx, filter)
+	require.Nil(t, err)
+	assert.Equal(t, []uint32{purchases[3].ID, purchases[1].ID, purchases[2].ID}, ids(list))
+
+	count, err := r.Purchase.CountPurchases(ctx, filter)
+	require.Nil(t, err)
+	assert.Equal(t, uint32(3), count)
+
+	// price asc
+	filter = repo.PurchaseFilter{WagerID: wager.ID, SortBy: repo.PurchaseSortBuyingPrice, Limit: 10}
+	list, err = r.Purchase.ListPurchases(ctx, filter)
+	require.Nil(t, err)
+	assert.Equal(t, []uint32{purchases[0].ID, purchases[2].ID, purchases[1].ID, purchases[3].ID}, ids(list))
+
+	// inclusive date range covering all purchases
+	first, last := purchases[0].CreatedAt.Time, purchases[3].CreatedAt.Time
+	filter = repo.PurchaseFilter{WagerID: wager.ID, From: first, To: last, SortBy: repo.PurchaseSortCreatedAt, Limit: 10}
+	list, err = r.Purchase.ListPurchases(ctx, filter)
+	require.Nil(t, err)
+	assert.Len(t, list, 4)
+
+	// date range in past and future
+	filter = repo.PurchaseFilter{WagerID: wager.ID, To: first.Add(-time.Hour), Limit: 10}
+	count, err = r.Purchase.CountPurchases(ctx, filter)
+	require.Nil(t, err)
+	assert.Zero(t, count)
+
+	filter = repo.PurchaseFilter{WagerID: wager.ID, From: last.Add(time.Hour), Limit: 10}
+	count, err = r.Purchase.CountPurchases(ctx, filter)
+	require.Nil(t, err)
+	assert.Zero(t, count)
+
+	// not whitelisted sort field
+	_, err = r.Purchase.ListPurchases(ctx, repo.PurchaseFilter{SortBy: "wager_id; drop table purchases", Limit: 10})
+	assert.NotNil(t, err)
+}
+
+func testCreateSettlement(t *testing.T, r Repos) {
+	ctx := context.Background()
+	wager := createWager(t, r)
+	purchase, err := r.Purchase.CreatePurchase(ctx, &repo.Purchase{WagerID: wager.ID, BuyingPrice: money.MustParse("20.5")})
+	require.Nil(t, err)
+
+	settlement, err := r.Settlement.CreateSettlement(ctx, &repo.Settlement{
+		WagerID:    wager.ID,
+		PurchaseID: purchase.ID,
+		Outcome:    repo.WagerOutcomeWon,
+		Payout:     money.MustParse("2"),
+	})
+	require.Nil(t, err)
+	assert.NotZero(t, settlement.ID)
+	assert.Equal(t, wager.ID, settlement.WagerID)
+	assert.Equal(t, purchase.ID, settlement.PurchaseID)
+	assert.Equal(t, repo.WagerOutcomeWon, settlement.Outcome)
+	assert.Equal(t, money.MustParse("2"), settlement.Payout)
+	assert.True(t, settlement.CreatedAt.Valid)
+
+	_, err = r.Settlement.CreateSettlement(ctx, &repo.Settlement{
+		WagerID:    wager.ID,
+		PurchaseID: purchase.ID,
+		Outcome:    repo.WagerOutcomeLost,
+	})
+	assert.NotNil(t, err, "purchase must not be settled twice")
+
+	_, err = r.Settlement.CreateSettlement(ctx, &repo.Settlement{
+		WagerID:    wager.ID,
+		PurchaseID: purchase.ID + 1000,
+		Outcome:    repo.WagerOutcomeLost,
+	})
+	assert.NotNil(t, err, "settlement of not existing purchase must fail")
+}
+
+func testListSettlementsByWagerID(t *testing.T, r Repos) {
+	ctx := context.Background()
+	wager := createWager(t, r)
+	other := createWager(t, r)
+
+	var purchaseIDs []uint32
+	for _, w := range []*repo.Wager{wager, wager, other} {
+		purchase, err := r.Purchase.CreatePurchase(ctx, &repo.Purchase{WagerID: w.ID, BuyingPrice: money.MustParse("20")})
+		require.Nil(t, err)
+		purchaseIDs = append(purchaseIDs, purchase.ID)
+	}
+
+	// settle in reverse order to verify ordering by purchase id
+	for i := len(purchaseIDs) - 1; i >= 0; i-- {
+		wagerID := wager.ID
+		if i == 2 {
+			wagerID = other.ID
+		}
+
+		_, err := r.Settlement.CreateSettlement(ctx, &repo.Settlement{
+			WagerID:    wagerID,
+			PurchaseID: purchaseIDs[i],
+			Outcome:    repo.WagerOutcomeVoid,
+			Payout:     money.MustParse("20"),
+		})
+		require.Nil(t, err)
+	}
+
+	list, err := r.Settlement.ListSettlementsByWagerID(ctx, wager.ID)
+	require.Nil(t, err)
+	require.Len(t, list, 2)
+	assert.Equal(t, purchaseIDs[0], list[0].PurchaseID)
+	assert.Equal(t, purchaseIDs[1], list[1].PurchaseID)
+
+	list, err = r.Settlement.ListSettlementsByWagerID(ctx, other.ID+1000)
+	require.Nil(t, err)
+	assert.Empty(t, list)
+}
+
+// createUser creates user with unique username starting with prefix, so tests can run against shared database
+func createUser(t *testing.T, r Repos, prefix string) *repo.User {
+	username := fmt.Sprintf("%s_%d", prefix, time.Now().UnixNano())
+	user, err := r.User.CreateUser(context.Background(), &repo.User{Username: username, PasswordHash: "hash-" + username})
+	require.Nil(t, err)
+
+	return user
+}
+
+func testCreateUser(t *testing.T, r Repos) {
+	ctx := context.Background()
+
+	first := createUser(t, r, "alice")
+	second := createUser(t, r, "bob")
+
+	assert.NotZero(t, first.ID)
+	assert.Greater(t, second.ID, first.ID)
+	assert.True(t, strings.HasPrefix(first.Username, "alice_"))
+	assert.Equal(t, "hash-"+first.Username, first.PasswordHash)
+	assert.True(t, first.CreatedAt.Valid)
+	assert.False(t, first.UpdatedAt.Valid)
+
+	_, err := r.User.CreateUser(ctx, &repo.User{Username: first.Username, PasswordHash: "other"})
+	assert.Equal(t, repo.ErrUsernameTaken, err)
+}
+
+func testGetUserByUsername(t *testing.T, r Repos) {
+	ctx := context.Background()
+	created := createUser(t, r, "alice")
+
+	user, err := r.User.GetUserByUsername(ctx, created.Username)
+	require.Nil(t, err)
+	assert.Equal(t, created.ID, user.ID)
+	assert.Equal(t, created.PasswordHash, user.PasswordHash)
+
+	_, err = r.User.GetUserByUsername(ctx, created.Username+"_missing")
+	assert.Equal(t, sql.ErrNoRows, err)
+}
+
+func testSellerAndBuyer(t *testing.T, r Repos) {
+	ctx := context.Background()
+	seller := createUser(t, r, "seller")
+	buyer := createUser(t, r, "buyer")
+
+	w := newWager()
+	w.SellerID = sql.NullInt32{Int32: int32(seller.ID), Valid: true}
+	wager, err := r.Wager.CreateWager(ctx, w)
+	require.Nil(t, err)
+
+	stored, err := r.Wager.GetWagerByID(ctx, wager.ID)
+	require.Nil(t, err)
+	assert.Equal(t, sql.NullInt32{Int32: int32(seller.ID), Valid: true}, stored.SellerID)
+
+	purchase, err := r.Purchase.CreatePurchase(ctx, &repo.Purchase{
+		WagerID:     wager.ID,
+		BuyingPrice: money.MustParse("20"),
+		BuyerID:     sql.NullInt32{Int32: int32(buyer.ID), Valid: true},
+	})
+	require.Nil(t, err)
+
+	storedPurchase, err := r.Purchase.GetPurchaseByID(ctx, purchase.ID)
+	require.Nil(t, err)
+	assert.Equal(t, sql.NullInt32{Int32: int32(buyer.ID), Valid: true}, storedPurchase.BuyerID)
+
+	// anonymous records of former versions
+	anonymous := createWager(t, r)
+	assert.False(t, anonymous.SellerID.Valid)
+
+	w = newWager()
+	w.SellerID = sql.NullInt32{Int32: int32(buyer.ID + 1000), Valid: true}
+	_, err = r.Wager.CreateWager(ctx, w)
+	assert.NotNil(t, err, "wager of not existing seller must fail")
+
+	_, err = r.Purchase.CreatePurchase(ctx, &repo.Purchase{
+		WagerID:     wager.ID,
+		BuyingPrice: money.MustParse("20"),
+		BuyerID:     sql.NullInt32{Int32: int32(buyer.ID + 1000), Valid: true},
+	})
+	assert.NotNil(t, err, "purchase of not existing buyer must fail")
+}
+
+func createAccount(t *testing.T, r Repos) *repo.Account {
+	user := createUser(t, r, "wallet")
+	account, err := r.Wallet.CreateAccount(context.Background(), &repo.Account{
+		UserID: sql.NullInt32{Int32: int32(user.ID), Valid: true},
+		Kind:   repo.AccountKindUser,
+	})
+	require.Nil(t, err)
+
+	return account
+}
+
+func deposit(t *testing.T, r Repos, account *repo.Account, amount money.Money) *repo.LedgerTransaction {
+	ctx := context.Background()
+	external, err := r.Wallet.GetExternalAccount(ctx)
+	require.Nil(t, err)
+
+	transaction, err := r.Wallet.CreateLedgerTransaction(ctx, &repo.LedgerTransaction{
+		Kind: repo.LedgerTransactionDeposit,
+		Entries: []repo.LedgerEntry{
+			{AccountID: account.ID, Amount: amount},
+			{AccountID: external.ID, Amount: -amount},
+		},
+	})
+	require.Nil(t, err)
+
+	return transaction
+}
+
+func testCreateAccount(t *testing.T, r Repos) {
+	ctx := context.Background()
+	user := createUser(t, r, "wallet")
+
+	account, err := r.Wallet.CreateAccount(ctx, &repo.Account{
+		UserID: sql.NullInt32{Int32: int32(user.ID), Valid: true},
+		Kind:   repo.AccountKindUser,
+	})
+	require.Nil(t, err)
+	assert.NotZero(t, account.ID)
+	assert.Equal(t, sql.NullInt32{Int32: int32(user.ID), Valid: true}, account.UserID)
+	assert.Equal(t, repo.AccountKindUser, account.Kind)
+	assert.Equal(t, money.Money(0), account.Balance)
+	assert.True(t, account.CreatedAt.Valid)
+
+	stored, err := r.Wallet.GetAccountByUserID(ctx, user.ID)
+	require.Nil(t, err)
+	assert.Equal(t, account.ID, stored.ID)
+
+	_, err = r.Wallet.CreateAccount(ctx, &repo.Account{
+		UserID: sql.NullInt32{Int32: int32(user.ID), Valid: true},
+		Kind:   repo.AccountKindUser,
+	})
+	assert.NotNil(t, err, "second account of user must fail")
+
+	_, err = r.Wallet.GetAccountByUserID(ctx, user.ID+1000)
+	assert.Equal(t, sql.ErrNoRows, err)
+
+	external, err := r.Wallet.GetExternalAccount(ctx)
+	require.Nil(t, err)
+	assert.Equal(t, repo.AccountKindExternal, external.Kind)
+	assert.False(t, external.UserID.Valid)
+}
+
+func testCreateLedgerTransaction(t *testing.T, r Repos) {
+	ctx := context.Background()
+	buyer := createAccount(t, r)
+	seller := createAccount(t, r)
+	externalBefore, err := r.Wallet.GetExternalAccount(ctx)
+	require.Nil(t, err)
+
+	deposited := deposit(t, r, buyer, money.MustParse("100.10"))
+	assert.NotZero(t, deposited.ID)
+	assert.True(t, deposited.CreatedAt.Valid)
+	require.Len(t, deposited.Entries, 2)
+	assert.NotZero(t, deposited.Entries[0].ID)
+	assert.Equal(t, deposited.ID, deposited.Entries[0].TransactionID)
+	assert.Equal(t, money.MustParse("-100.10"), deposited.Entries[1].Amount)
+
+	wager := createWager(t, r)
+	purchase, err := r.Purchase.CreatePurchase(ctx, &repo.Purchase{WagerID: wager.ID, BuyingPrice: money.MustParse("20.05")})
+	require.Nil(t, err)
+
+	paid, err := r.Wallet.CreateLedgerTransaction(ctx, &repo.LedgerTransaction{
+		Kind:       repo.LedgerTransactionPurchase,
+		PurchaseID: sql.NullInt32{Int32: int32(purchase.ID), Valid: true},
+		Entries: []repo.LedgerEntry{
+			{AccountID: buyer.ID, Amount: money.MustParse("-20.05")},
+			{AccountID: seller.ID, Amount: money.MustParse("20.05")},
+		},
+	})
+	require.Nil(t, err)
+	assert.Greater(t, paid.ID, deposited.ID)
+	assert.Equal(t, sql.NullInt32{Int32: int32(purchase.ID), Valid: true}, paid.PurchaseID)
+
+	buyerAccount, err := r.Wallet.GetAccountByUserID(ctx, uint32(buyer.UserID.Int32))
+	require.Nil(t, err)
+	assert.Equal(t, money.MustParse("80.05"), buyerAccount.Balance)
+	assert.True(t, buyerAccount.UpdatedAt.Valid)
+
+	sellerAccount, err := r.Wallet.GetAccountByUserID(ctx, uint32(seller.UserID.Int32))
+	require.Nil(t, err)
+	assert.Equal(t, money.MustParse("20.05"), sellerAccount.Balance)
+
+	externalAfter, err := r.Wallet.GetExternalAccount(ctx)
+	require.Nil(t, err)
+	assert.Equal(t, externalBefore.Balance-money.MustParse("100.10"), externalAfter.Balance)
+
+	_, err = r.Wallet.CreateLedgerTransaction(ctx, &repo.LedgerTransaction{
+		Kind: repo.LedgerTransactionDeposit,
+		Entries: []repo.LedgerEntry{
+			{AccountID: buyer.ID, Amount: money.MustParse("10")},
+			{AccountID: externalAfter.ID, Amount: money.MustParse("-9.99")},
+		},
+	})
+	assert.Equal(t, repo.ErrUnbalancedTransaction, err)
+
+	_, err = r.Wallet.CreateLedgerTransaction(ctx, &repo.LedgerTransaction{
+		Kind: repo.LedgerTransactionDeposit,
+		Entries: []repo.LedgerEntry{
+			{AccountID: buyer.ID, Amount: money.MustParse("10")},
+			{AccountID: buyer.ID + 1000, Amount: money.MustParse("-10")},
+		},
+	})
+	assert.NotNil(t, err, "entry of not existing account must fail")
+
+	check, err := r.Wallet.CheckLedger(ctx)
+	require.Nil(t, err)
+	assert.True(t, check.Balanced(), check)
+
+	buyerAccount, err = r.Wallet.GetAccountByUserID(ctx, uint32(buyer.UserID.Int32))
+	require.Nil(t, err)
+	assert.Equal(t, money.MustParse("80.05"), buyerAccount.Balance, "failed transaction must not change balance")
+}
+
+func testListLedgerEntries(t *testing.T, r Repos) {
+	ctx := context.Background()
+	account := createAccount(t, r)
+	other := createAccount(t, r)
+
+	first := deposit(t, r, account, money.MustParse("1"))
+	second := deposit(t, r, account, money.MustParse("2"))
+	third := deposit(t, r, account, money.MustParse("3"))
+	deposit(t, r, other, money.MustParse("4"))
+
+	entries, err := r.Wallet.ListLedgerEntries(ctx, account.ID, 0, 2)
+	require.Nil(t, err)
+	require.Len(t, entries, 2)
+	assert.Equal(t, third.Entries[0].ID, entries[0].ID)
+	assert.Equal(t, second.Entries[0].ID, entries[1].ID)
+	assert.Equal(t, repo.LedgerTransactionDeposit, entries[0].Kind)
+	assert.Equal(t, money.MustParse("3"), entries[0].Amount)
+	assert.Equal(t, third.ID, entries[0].TransactionID)
+	assert.False(t, entries[0].PurchaseID.Valid)
+
+	entries, err = r.Wallet.ListLedgerEntries(ctx, account.ID, 2, 2)
+	require.Nil(t, err)
+	require.Len(t, entries, 1)
+	assert.Equal(t, first.Entries[0].ID, entries[0].ID)
+
+	count, err := r.Wallet.CountLedgerEntries(ctx, account.ID)
+	require.Nil(t, err)
+	assert.Equal(t, uint32(3), count)
+}
+
+func testLedgerTransactionRollback(t *testing.T, r Repos) {
+	ctx := context.Background()
+	account := createAccount(t, r)
+	deposit(t, r, account, money.MustParse("10"))
+
+	errRollback := errors.New("rollback")
+	err := r.Transactor.WithinTransaction(ctx, func(ctx context.Context) error {
+		_, err := r.Wallet.GetAccountByUserIDForUpdate(ctx, uint32(account.UserID.Int32))
+		require.Nil(t, err)
+
+		external, err := r.Wallet.GetExternalAccount(ctx)
+		require.Nil(t, err)
+
+		_, err = r.Wallet.CreateLedgerTransaction(ctx, &repo.LedgerTransaction{
+			Kind: repo.LedgerTransactionWithdrawal,
+			Entries: []repo.LedgerEntry{
+				{AccountID: account.ID, Amount: money.MustParse("-4")},
+				{AccountID: external.ID, Amount: money.MustParse("4")},
+			},
+		})
+		require.Nil(t, err)
+
+		return errRollback
+	})
+	assert.Equal(t, errRollback, err)
+
+	stored, err := r.Wallet.GetAccountByUserID(ctx, uint32(account.UserID.Int32))
+	require.Nil(t, err)
+	assert.Equal(t, money.MustParse("10"), stored.Balance)
+
+	count, err := r.Wallet.CountLedgerEntries(ctx, account.ID)
+	require.Nil(t, err)
+	assert.Equal(t, uint32(1), count)
+
+	check, err := r.Wallet.CheckLedger(ctx)
+	require.Nil(t, err)
+	assert.True(t, check.Balanced(), check)
+}
+
+func createOrder(
+	t *testing.T, r Repos, wager *repo.Wager, user *repo.User, side repo.OrderSide, price string, quantity uint32,
+) *repo.Order {
+	order, err := r.Order.CreateOrder(context.Background(), &repo.Order{
+		WagerID:  wager.ID,
+		UserID:   user.ID,
+		Side:     side,
+		Price:    money.MustParse(price),
+		Quantity: quantity,
+	})
+	require.Nil(t, err)
+
+	return order
+}
+
+func testCreateOrder(t *testing.T, r Repos) {
+	ctx := context.Background()
+	wager := createWager(t, r)
+	user := createUser(t, r, "trader")
+
+	first := createOrder(t, r, wager, user, repo.OrderSideBid, "19.99", 3)
+	second := createOrder(t, r, wager, user, repo.OrderSideAsk, "25", 1)
+
+	assert.NotZero(t, first.ID)
+	assert.Greater(t, second.ID, first.ID)
+	assert.Equal(t, repo.OrderStatusOpen, first.Status)
+	assert.Zero(t, first.Filled)
+	assert.True(t, first.CreatedAt.Valid)
+	assert.False(t, first.UpdatedAt.Valid)
+
+	stored, err := r.Order.GetOrderByID(ctx, first.ID)
+	require.Nil(t, err)
+	assert.Equal(t, wager.ID, stored.WagerID)
+	assert.Equal(t, user.ID, stored.UserID)
+	assert.Equal(t, repo.OrderSideBid, stored.Side)
+	assert.Equal(t, money.MustParse("19.99"), stored.Price)
+	assert.Equal(t, uint32(3), stored.Quantity)
+	assert.Equal(t, uint32(3), stored.Left())
+
+	_, err = r.Order.GetOrderByID(ctx, second.ID+1000)
+	assert.Equal(t, sql.ErrNoRows, err)
+
+	_, err = r.Order.CreateOrder(ctx, &repo.Order{
+		WagerID: wager.ID + 1000, UserID: user.ID, Side: repo.OrderSideBid, Price: money.MustParse("20"), Quantity: 1,
+	})
+	assert.NotNil(t, err, "order of not existing wager must fail")
+
+	_, err = r.Order.CreateOrder(ctx, &repo.Order{
+		WagerID: wager.ID, UserID: user.ID + 1000, Side: repo.OrderSideBid, Price: money.MustParse("20"), Quantity: 1,
+	})
+	assert.NotNil(t, err, "order of not existing user must fail")
+}
+
+func testListOpenOrders(t *testing.T, r Repos) {
+	ctx := context.Background()
+	wager := createWager(t, r)
+	other := createWager(t, r)
+	user := createUser(t, r, "trader")
+
+	bid20 := createOrder(t, r, wager, user, repo.OrderSideBid, "20", 1)
+	bid22 := createOrder(t, r, wager, user, repo.OrderSideBid, "22", 1)
+	bid20Later := createOrder(t, r, wager, user, repo.OrderSideBid, "20", 1)
+	createOrder(t, r, wager, user, repo.OrderSideBid, "18", 1)
+	ask25 := createOrder(t, r, wager, user, repo.OrderSideAsk, "25", 1)
+	ask23 := createOrder(t, r, wager, user, repo.OrderSideAsk, "23", 1)
+	createOrder(t, r, wager, user, repo.OrderSideAsk, "30", 1)
+	createOrder(t, r, other, user, repo.OrderSideBid, "25", 1)
+
+	cancelled := createOrder(t, r, wager, user, repo.OrderSideBid, "24", 1)
+	cancelled.Status = repo.OrderStatusCancelled
+	require.Nil(t, r.Order.UpdateOrder(ctx, cancelled))
+
+	ids := func(orders []repo.Order) []uint32 {
+		res := make([]uint32, 0, len(orders))
+		for _, o := range orders {
+			res = append(res, o.ID)
+		}
+
+		return res
+	}
+
+	bids, err := r.Order.ListOpenOrders(ctx, wager.ID, repo.OrderSideBid, money.MustParse("20"))
+	require.Nil(t, err)
+	assert.Equal(t, []uint32{bid22.ID, bid20.ID, bid20Later.ID}, ids(bids))
+
+	asks, err := r.Order.ListOpenOrders(ctx, wager.ID, repo.OrderSideAsk, money.MustParse("25"))
+	require.Nil(t, err)
+	assert.Equal(t, []uint32{ask23.ID, ask25.ID}, ids(asks))
+
+	asks, err = r.Order.ListOpenOrders(ctx, wager.ID, repo.OrderSideAsk, money.MustParse("22.99"))
+	require.Nil(t, err)
+	assert.Empty(t, asks)
+}
+
+func testUpdateOrder(t *testing.T, r Repos) {
+	ctx := context.Background()
+	wager := createWager(t, r)
+	user := createUser(t, r, "trader")
+	order := createOrder(t, r, wager, user, repo.OrderSideAsk, "25", 3)
+
+	order.Filled = 2
+	require.Nil(t, r.Order.UpdateOrder(ctx, order))
+
+	stored, err := r.Order.GetOrderByID(ctx, order.ID)
+	require.Nil(t, err)
+	assert.Equal(t, uint32(2), stored.Filled)
+	assert.Equal(t, uint32(1), stored.Left())
+	assert.Equal(t, repo.OrderStatusOpen, stored.Status)
+	assert.True(t, stored.UpdatedAt.Valid)
+
+	order.Filled = 3
+	order.Status = repo.OrderStatusFilled
+	require.Nil(t, r.Order.UpdateOrder(ctx, order))
+
+	stored, err = r.Order.GetOrderByID(ctx, order.ID)
+	require.Nil(t, err)
+	assert.Equal(t, repo.OrderStatusFilled, stored.Status)
+
+	open, err := r.Order.ListOpenOrders(ctx, wager.ID, repo.OrderSideAsk, money.MustParse("100"))
+	require.Nil(t, err)
+	assert.Empty(t, open)
+}
+
+func testListOrderBook(t *testing.T, r Repos) {
+	ctx := context.Background()
+	wager := createWager(t, r)
+	user := createUser(t, r, "trader")
+
+	createOrder(t, r, wager, user, repo.OrderSideBid, "20", 2)
+	partly := createOrder(t, r, wager, user, repo.OrderSideBid, "20", 3)
+	createOrder(t, r, wager, user, repo.OrderSideBid, "22", 1)
+	createOrder(t, r, wager, user, repo.OrderSideBid, "18", 4)
+	createOrder(t, r, wager, user, repo.OrderSideAsk, "25", 1)
+	createOrder(t, r, wager, user, repo.OrderSideAsk, "23.5", 2)
+
+	partly.Filled = 1
+	require.Nil(t, r.Order.UpdateOrder(ctx, partly))
+
+	bids, err := r.Order.ListOrderBook(ctx, wager.ID, repo.OrderSideBid, 2)
+	require.Nil(t, err)
+	assert.Equal(t, []repo.OrderBookLevel{
+		{Price: money.MustParse("22"), Quantity: 1, Orders: 1},
+		{Price: money.MustParse("20"), Quantity: 4, Orders: 2},
+	}, bids)
+
+	asks, err := r.Order.ListOrderBook(ctx, wager.ID, repo.OrderSideAsk, 10)
+	require.Nil(t, err)
+	assert.Equal(t, []repo.OrderBookLevel{
+		{Price: money.MustParse("23.5"), Quantity: 2, Orders: 1},
+		{Price: money.MustParse("25"), Quantity: 1, Orders: 1},
+	}, asks)
+
+	empty, err := r.Order.ListOrderBook(ctx, wager.ID+1000, repo.OrderSideAsk, 10)
+	require.Nil(t, err)
+	assert.Empty(t, empty)
+}
+
+func testListUserOpenOrders(t *testing.T, r Repos) {
+	ctx := context.Background()
+	wager := createWager(t, r)
+	other := createWager(t, r)
+	user := createUser(t, r, "trader")
+	otherUser := createUser(t, r, "other")
+
+	first := createOrder(t, r, wager, user, repo.OrderSideBid, "20", 2)
+	second := createOrder(t, r, other, user, repo.OrderSideBid, "25", 1)
+	createOrder(t, r, wager, user, repo.OrderSideAsk, "30", 1)
+	createOrder(t, r, wager, otherUser, repo.OrderSideBid, "21", 1)
+
+	filled := createOrder(t, r, wager, user, repo.OrderSideBid, "22", 1)
+	filled.Filled = 1
+	filled.Status = repo.OrderStatusFilled
+	require.Nil(t, r.Order.UpdateOrder(ctx, filled))
+
+	bids, err := r.Order.ListUserOpenOrders(ctx, user.ID, repo.OrderSideBid)
+	require.Nil(t, err)
+	require.Len(t, bids, 2)
+	assert.Equal(t, first.ID, bids[0].ID)
+	assert.Equal(t, second.ID, bids[1].ID)
+
+	bids, err = r.Order.ListUserOpenOrders(ctx, user.ID+1000, repo.OrderSideBid)
+	require.Nil(t, err)
+	assert.Empty(t, bids)
+}
+
+func testCancelOpenOrders(t *testing.T, r Repos) {
+	ctx := context.Background()
+	wager := createWager(t, r)
+	other := createWager(t, r)
+	user := createUser(t, r, "trader")
+
+	bid := createOrder(t, r, wager, user, repo.OrderSideBid, "20", 2)
+	ask := createOrder(t, r, wager, user, repo.OrderSideAsk, "30", 1)
+	otherBid := createOrder(t, r, other, user, repo.OrderSideBid, "20", 1)
+
+	filled := createOrder(t, r, wager, user, repo.OrderSideBid, "22", 1)
+	filled.Filled = 1
+	filled.Status = repo.OrderStatusFilled
+	require.Nil(t, r.Order.UpdateOrder(ctx, filled))
+
+	require.Nil(t, r.Order.CancelOpenOrders(ctx, wager.ID))
+
+	for _, tc := range []struct {
+		order  *repo.Order
+		status repo.OrderStatus
+	}{
+		{bid, repo.OrderStatusCancelled},
+		{ask, repo.OrderStatusCancelled},
+		{filled, repo.OrderStatusFilled},
+		{otherBid, repo.OrderStatusOpen},
+	} {
+		stored, err := r.Order.GetOrderByID(ctx, tc.order.ID)
+		require.Nil(t, err)
+		assert.Equal(t, tc.status, stored.Status, "order %d", tc.order.ID)
+	}
+
+	book, err := r.Order.ListOrderBook(ctx, wager.ID, repo.OrderSideBid, 10)
+	require.Nil(t, err)
+	assert.Empty(t, book)
+}
+
+func createAuctionBid(t *testing.T, r Repos, wager *repo.Wager, user *repo.User, price string, quantity uint32) *repo.AuctionBid {
+	bid, err := r.AuctionBid.CreateAuctionBid(context.Background(), &repo.AuctionBid{
+		WagerID:  wager.ID,
+		UserID:   user.ID,
+		Price:    money.MustParse(price),
+		Quantity: quantity,
+	})
+	require.Nil(t, err)
+
+	return bid
+}
+
+func auctionBidIDs(bids []repo.AuctionBid) []uint32 {
+	res := make([]uint32, 0, len(bids))
+	for _, b := range bids {
+		res = append(res, b.ID)
+	}
+
+	return res
+}
+
+func testCreateAuctionBid(t *testing.T, r Repos) {
+	ctx := context.Background()
+	wager := createAuctionWager(t, r, repo.WagerAuctionFirstPrice, time.Now().Add(time.Hour))
+	user := createUser(t, r, "bidder")
+
+	first := createAuctionBid(t, r, wager, user, "19.99", 3)
+	second := createAuctionBid(t, r, wager, user, "25", 1)
+
+	assert.NotZero(t, first.ID)
+	assert.Greater(t, second.ID, first.ID)
+	assert.Zero(t, first.Awarded)
+	assert.True(t, first.CreatedAt.Valid)
+	assert.False(t, first.UpdatedAt.Valid)
+
+	bids, err := r.AuctionBid.ListUserAuctionBids(ctx, wager.ID, user.ID)
+	require.Nil(t, err)
+	require.Len(t, bids, 2)
+	assert.Equal(t, wager.ID, bids[0].WagerID)
+	assert.Equal(t, user.ID, bids[0].UserID)
+	assert.Equal(t, money.MustParse("19.99"), bids[0].Price)
+	assert.Equal(t, uint32(3), bids[0].Quantity)
+
+	_, err = r.AuctionBid.CreateAuctionBid(ctx, &repo.AuctionBid{
+		WagerID: wager.ID + 1000, UserID: user.ID, Price: money.MustParse("20"), Quantity: 1,
+	})
+	assert.NotNil(t, err, "bid for not existing wager must fail")
+
+	_, err = r.AuctionBid.CreateAuctionBid(ctx, &repo.AuctionBid{
+		WagerID: wager.ID, UserID: user.ID + 1000, Price: money.MustParse("20"), Quantity: 1,
+	})
+	assert.NotNil(t, err, "bid of not existing user must fail")
+}
+
+func testListAuctionBids(t *testing.T, r Repos) {
+	ctx := context.Background()
+	wager := createAuctionWager(t, r, repo.WagerAuctionSecondPrice, time.Now().Add(time.Hour))
+	other := createAuctionWager(t, r, repo.WagerAuctionSecondPrice, time.Now().Add(time.Hour))
+	alice := createUser(t, r, "alice")
+	bob := createUser(t, r, "bob")
+
+	bid20 := createAuctionBid(t, r, wager, alice, "20", 1)
+	bid22 := createAuctionBid(t, r, wager, bob, "22", 2)
+	bid20Later := createAuctionBid(t, r, wager, bob, "20", 1)
+	bid18 := createAuctionBid(t, r, wager, alice, "18", 4)
+	createAuctionBid(t, r, other, alice, "30", 1)
+
+	bids, err := r.AuctionBid.ListAuctionBids(ctx, wager.ID)
+	require.Nil(t, err)
+	assert.Equal(t, []uint32{bid22.ID, bid20.ID, bid20Later.ID, bid18.ID}, auctionBidIDs(bids))
+
+	bids, err = r.AuctionBid.ListUserAuctionBids(ctx, wager.ID, alice.ID)
+	require.Nil(t, err)
+	assert.Equal(t, []uint32{bid20.ID, bid18.ID}, auctionBidIDs(bids))
+
+	bids, err = r.AuctionBid.ListAuctionBids(ctx, wager.ID+1000)
+	require.Nil(t, err)
+	assert.Empty(t, bids)
+}
+
+func testUpdateAuctionBid(t *testing.T, r Repos) {
+	ctx := context.Background()
+	wager := createAuctionWager(t, r, repo.WagerAuctionFirstPrice, time.Now().Add(time.Hour))
+	user := createUser(t, r, "bidder")
+	bid := createAuctionBid(t, r, wager, user, "25", 3)
+
+	bid.Awarded = 2
+	// not updatable fields
+	bid.Price = money.MustParse("1")
+	bid.Quantity = 10
+	require.Nil(t, r.AuctionBid.UpdateAuctionBid(ctx, bid))
+
+	bids, err := r.AuctionBid.ListAuctionBids(ctx, wager.ID)
+	require.Nil(t, err)
+	require.Len(t, bids, 1)
+	assert.Equal(t, uint32(2), bids[0].Awarded)
+	assert.Equal(t, money.MustParse("25"), bids[0].Price)
+	assert.Equal(t, uint32(3), bids[0].Quantity)
+	assert.True(t, bids[0].UpdatedAt.Valid)
+}
+
+func testCreateIdempotencyKey(t *testing.T, r Repos) {
+	ctx := context.Background()
+	user := createUser(t, r, "idempotent")
+	now := time.Now().UTC().Truncate(time.Microsecond)
+
+	key, err := r.Idempotency.CreateKey(ctx, &repo.IdempotencyKey{
+		UserID:      user.ID,
+		Key:         "retry-1",
+		RequestHash: "hash-1",
+		ExpiresAt:   now.Add(time.Hour),
+	}, now)
+	require.Nil(t, err)
+	assert.NotEmpty(t, key.ID)
+	assert.True(t, key.CreatedAt.Valid)
+	assert.False(t, key.ResponseStatus.Valid)
+	assert.WithinDuration(t, now.Add(time.Hour), key.ExpiresAt, time.Millisecond)
+
+	// key is unique per user
+	_, err = r.Idempotency.CreateKey(ctx, &repo.IdempotencyKey{
+		UserID:      user.ID,
+		Key:         "retry-1",
+		RequestHash: "hash-2",
+		ExpiresAt:   now.Add(time.Hour),
+	}, now)
+	assert.Equal(t, repo.ErrIdempotencyKeyExists, err)
+
+	other := createUser(t, r, "idempotent")
+	_, err = r.Idempotency.CreateKey(ctx, &repo.IdempotencyKey{
+		UserID:      other.ID,
+		Key:         "retry-1",
+		RequestHash: "hash-2",
+		ExpiresAt:   now.Add(time.Hour),
+	}, now)
+	assert.Nil(t, err)
+
+	err = r.Idempotency.SaveResponse(ctx, user.ID, "retry-1", 200, `{"id":1}`)
+	require.Nil(t, err)
+
+	stored, err := r.Idempotency.GetKey(ctx, user.ID, "retry-1", now)
+	require.Nil(t, err)
+	assert.Equal(t, key.ID, stored.ID)
+	assert.Equal(t, "hash-1", stored.RequestHash)
+	assert.Equal(t, sql.NullInt32{Int32: 200, Valid: true}, stored.ResponseStatus)
+	assert.Equal(t, sql.NullString{String: `{"id":1}`, Valid: true}, stored.ResponseBody)
+
+	// expired key is not returned and can be taken over by new request
+	expiredAt := now.Add(2 * time.Hour)
+	_, err = r.Idempotency.GetKey(ctx, user.ID, "retry-1", expiredAt)
+	assert.Equal(t, sql.ErrNoRows, err)
+
+	replaced, err := r.Idempotency.CreateKey(ctx, &repo.IdempotencyKey{
+		UserID:      user.ID,
+		Key:         "retry-1",
+		RequestHash: "hash-3",
+		ExpiresAt:   expiredAt.Add(time.Hour),
+	}, expiredAt)
+	require.Nil(t, err)
+	assert.Equal(t, "hash-3", replaced.RequestHash)
+	assert.False(t, replaced.ResponseStatus.Valid)
+	assert.False(t, replaced.ResponseBody.Valid)
+}
+
+func testDeleteIdempotencyKey(t *testing.T, r Repos) {
+	ctx := context.Background()
+	user := createUser(t, r, "idempotent")
+	now := time.Now().UTC().Truncate(time.Microsecond)
+
+	for _, k := range []string{"short", "long"} {
+		ttl := time.Minute
+		if k == "long" {
+			ttl = time.Hour
+		}
+
+		_, err := r.Idempotency.CreateKey(ctx, &repo.IdempotencyKey{
+			UserID:      user.ID,
+			Key:         k,
+			RequestHash: "hash",
+			ExpiresAt:   now.Add(ttl),
+		}, now)
+		require.Nil(t, err)
+	}
+
+	deleted, err := r.Idempotency.DeleteExpiredKeys(ctx, now.Add(2*time.Minute))
+	require.Nil(t, err)
+	assert.GreaterOrEqual(t, deleted, int64(1))
+
+	_, err = r.Idempotency.GetKey(ctx, user.ID, "short", now)
+	assert.Equal(t, sql.ErrNoRows, err)
+
+	_, err = r.Idempotency.GetKey(ctx, user.ID, "long", now)
+	require.Nil(t, err)
+
+	err = r.Idempotency.DeleteKey(ctx, user.ID, "long")
+	require.Nil(t, err)
+
+	_, err = r.Idempotency.GetKey(ctx, user.ID, "long", now)
+	assert.Equal(t, sql.ErrNoRows, err)
+
+	// deleted key can be used again
+	_, err = r.Idempotency.CreateKey(ctx, &repo.IdempotencyKey{
+		UserID:      user.ID,
+		Key:         "long",
+		RequestHash: "hash",
+		ExpiresAt:   now.Add(time.Hour),
+	}, now)
+	assert.Nil(t, err)
+}
+
+func testPing(t *testing.T, r Repos) {
+	assert.Nil(t, r.Health.Ping(context.Background()))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	assert.NotNil(t, r.Health.Ping(ctx))
+}
+
+func testTransactionCommit(t *testing.T, r Repos) {
+	ctx := context.Background()
+	wager := createWager(t, r)
+
+	err := r.Transactor.WithinTransaction(ctx, func(ctx context.Context) error {
+		w, err := r.Wager.GetWagerByIDForUpdate(ctx, wager.ID)
+		if err != nil {
+			return err
+		}
+
+		if _, err = r.Purchase.CreatePurchase(ctx, &repo.Purchase{WagerID: w.ID, BuyingPrice: money.MustParse("20")}); err != nil {
+			return err
+		}
+
+		w.AmountSold = sql.NullInt32{Int32: 1, Valid: true}
+		return r.Wager.UpdateWager(ctx, w)
+	})
+	require.Nil(t, err)
+
+	updated, err := r.Wager.GetWagerByID(ctx, wager.ID)
+	require.Nil(t, err)
+	assert.Equal(t, int32(1), updated.AmountSold.Int32)
+}
+
+func testTransactionRollback(t *testing.T, r Repos) {
+	ctx := context.Background()
+	wager := createWager(t, r)
+	fnErr := errors.New("fn error")
+
+	var created *repo.Wager
+	err := r.Transactor.WithinTransaction(ctx, func(ctx context.Context) error {
+		w, err := r.Wager.GetWagerByIDForUpdate(ctx, wager.ID)
+		if err != nil {
+			return err
+		}
+
+		w.AmountSold = sql.NullInt32{Int32: 1, Valid: true}
+		if err = r.Wager.UpdateWager(ctx, w); err != nil {
+			return err
+		}
+
+		if created, err = r.Wager.CreateWager(ctx, newWager()); err != nil {
+			return err
+		}
+
+		purchase, err := r.Purchase.CreatePurchase(ctx, &repo.Purchase{WagerID: wager.ID, BuyingPrice: money.MustParse("20")})
+		if err != nil {
+			return err
+		}
+
+		_, err = r.Settlement.CreateSettlement(ctx, &repo.Settlement{
+			WagerID:    wager.ID,
+			PurchaseID: purchase.ID,
+			Outcome:    repo.WagerOutcomeLost,
+		})
+		if err != nil {
+			return err
+		}
+
+		return fnErr
+	})
+	require.Equal(t, fnErr, err)
+
+	updated, err := r.Wager.GetWagerByID(ctx, wager.ID)
+	require.Nil(t, err)
+	assert.False(t, updated.AmountSold.Valid)
+	assert.False(t, updated.UpdatedAt.Valid)
+
+	_, err = r.Wager.GetWagerByID(ctx, created.ID)
+	assert.Equal(t, sql.ErrNoRows, err)
+
+	settlements, err := r.Settlement.ListSettlementsByWagerID(ctx, wager.ID)
+	require.Nil(t, err)
+	assert.Empty(t, settlements)
+}
+
+// testTransactionLockForUpdate increments wager concurrently with read-modify-write in transactions
+// and expects no lost updates
+func testTransactionLockForUpdate(t *testing.T, r Repos) {
+	const workers = 20
+	ctx := context.Background()
+	wager := createWager(t, r)
+
+	var wg sync.WaitGroup
+	errs := make(chan error, workers)
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			errs <- r.Transactor.WithinTransaction(ctx, func(ctx context.Context) error {
+				w, err := r.Wager.GetWagerByIDForUpdate(ctx, wager.ID)
+				if err != nil {
+					return err
+				}
+
+				w.AmountSold = sql.NullInt32{Int32: w.AmountSold.Int32 + 1, Valid: true}
+				return r.Wager.UpdateWager(ctx, w)
+			})
+		}()
+	}
+
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		require.Nil(t, err)
+	}
+
+	updated, err := r.Wager.GetWagerByID(ctx, wager.ID)
+	require.Nil(t, err)
+	assert.Equal(t, int32(workers), updated.AmountSold.Int32)
+}
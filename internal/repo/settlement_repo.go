@@ -0,0 +1,104 @@
+package repo
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/vitthalaa/wager-app/money"
+)
+
+const (
+	insertSettlementStmt = `insert into settlements(wager_id, purchase_id, outcome, payout) values ($1, $2, $3, $4)
+							returning *`
+	listSettlementsByWagerIDStmt = "select * from settlements where wager_id = $1 order by purchase_id"
+)
+
+// Settlement is payout of single purchase on wager settlement
+type Settlement struct {
+	ID         uint32
+	WagerID    uint32
+	PurchaseID uint32
+	Outcome    WagerOutcome
+	Payout     money.Money
+	CreatedAt  sql.NullTime
+}
+
+// ISettlementRepo is repository interface for settlement db operations
+type ISettlementRepo interface {
+	CreateSettlement(ctx context.Context, settlement *Settlement) (*Settlement, error)
+	ListSettlementsByWagerID(ctx context.Context, wagerID uint32) ([]Settlement, error)
+}
+
+// NewSettlementRepo ...
+func NewSettlementRepo(db *sql.DB) *SettlementRepo {
+	return &SettlementRepo{
+		db: db,
+	}
+}
+
+// SettlementRepo is repository implementation for settlement db operations
+type SettlementRepo struct {
+	db *sql.DB
+}
+
+// CreateSettlement creates new settlement record in db
+func (sr *SettlementRepo) CreateSettlement(ctx context.Context, settlement *Settlement) (*Settlement, error) {
+	stmt, err := executor(ctx, sr.db).PrepareContext(ctx, insertSettlementStmt)
+	if err != nil {
+		return nil, err
+	}
+
+	defer stmt.Close()
+
+	row := stmt.QueryRowContext(ctx,
+		settlement.WagerID, settlement.PurchaseID, settlement.Outcome, settlement.Payout)
+
+	err = row.Scan(
+		&settlement.ID,
+		&settlement.WagerID,
+		&settlement.PurchaseID,
+		&settlement.Outcome,
+		&settlement.Payout,
+		&settlement.CreatedAt)
+	if err != nil {
+		return nil, err
+	}
+
+	return settlement, nil
+}
+
+// ListSettlementsByWagerID returns all settlements of wager ordered by purchase id
+func (sr *SettlementRepo) ListSettlementsByWagerID(ctx context.Context, wagerID uint32) ([]Settlement, error) {
+	stmt, err := executor(ctx, sr.db).PrepareContext(ctx, listSettlementsByWagerIDStmt)
+	if err != nil {
+		return nil, err
+	}
+
+	defer stmt.Close()
+
+	rows, err := stmt.QueryContext(ctx, wagerID)
+	if err != nil {
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	var res []Settlement
+	for rows.Next() {
+		var settlement Settlement
+		err = rows.Scan(
+			&settlement.ID,
+			&settlement.WagerID,
+			&settlement.PurchaseID,
+			&settlement.Outcome,
+			&settlement.Payout,
+			&settlement.CreatedAt)
+		if err != nil {
+			return nil, err
+		}
+
+		res = append(res, settlement)
+	}
+
+	return res, rows.Err()
+}
@@ -0,0 +1,46 @@
+package repo_test
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/vitthalaa/wager-app/internal/config"
+	"github.com/vitthalaa/wager-app/internal/db"
+	"github.com/vitthalaa/wager-app/internal/logger"
+	"github.com/vitthalaa/wager-app/internal/metrics"
+	"github.com/vitthalaa/wager-app/internal/migrate"
+	"github.com/vitthalaa/wager-app/internal/repo"
+	"github.com/vitthalaa/wager-app/internal/repo/repotest"
+)
+
+func TestSQLiteRepo_Conformance(t *testing.T) {
+	repotest.RunConformance(t, func(t *testing.T) repotest.Repos {
+		conn, err := db.OpenConnection(&config.DataBaseConfig{
+			DBDriver: config.DriverSQLite,
+			DBPath:   filepath.Join(t.TempDir(), "wager_app.db"),
+		}, logger.Discard())
+		require.Nil(t, err)
+		t.Cleanup(func() { conn.Close() })
+
+		migrator, err := migrate.New(conn, repo.DialectSQLite, logger.Discard())
+		require.Nil(t, err)
+		require.Nil(t, migrator.Up(context.Background()))
+
+		transactor := repo.NewTransactor(conn, logger.Discard(), metrics.New())
+		return repotest.Repos{
+			Transactor:  transactor,
+			Wager:       repo.NewWagerRepo(conn, repo.DialectSQLite),
+			Purchase:    repo.NewPurchaseRepo(conn),
+			Settlement:  repo.NewSettlementRepo(conn),
+			User:        repo.NewUserRepo(conn),
+			Wallet:      repo.NewWalletRepo(conn, repo.DialectSQLite, transactor),
+			Idempotency: repo.NewIdempotencyRepo(conn),
+			Health:      repo.NewHealthRepo(conn),
+			Order:       repo.NewOrderRepo(conn),
+			AuctionBid:  repo.NewAuctionBidRepo(conn),
+		}
+	})
+}
@@ -0,0 +1,85 @@
+package repo
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/vitthalaa/wager-app/internal/logger"
+	"github.com/vitthalaa/wager-app/internal/metrics"
+)
+
+type txKey struct{}
+
+// ITransactor is unit of work interface to run multiple repo operations atomically.
+// Repos called with the ctx passed to fn are executed within the same transaction.
+type ITransactor interface {
+	WithinTransaction(ctx context.Context, fn func(ctx context.Context) error) error
+}
+
+// dbExecutor is common interface for *sql.DB and *sql.Tx
+type dbExecutor interface {
+	PrepareContext(ctx context.Context, query string) (*sql.Stmt, error)
+	ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error)
+	QueryContext(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error)
+	QueryRowContext(ctx context.Context, query string, args ...interface{}) *sql.Row
+}
+
+// NewTransactor ...
+func NewTransactor(db *sql.DB, log *logger.Logger, metrics *metrics.Metrics) *Transactor {
+	return &Transactor{
+		db:      db,
+		log:     log,
+		metrics: metrics,
+	}
+}
+
+// Transactor is sql transaction implementation of ITransactor
+type Transactor struct {
+	db      *sql.DB
+	log     *logger.Logger
+	metrics *metrics.Metrics
+}
+
+// WithinTransaction begins transaction, runs fn and commits it.
+// Transaction is rolled back if fn returns error or panics.
+// Nested calls reuse the transaction already present in ctx.
+func (t *Transactor) WithinTransaction(ctx context.Context, fn func(ctx context.Context) error) (err error) {
+	if _, ok := ctx.Value(txKey{}).(*sql.Tx); ok {
+		return fn(ctx)
+	}
+
+	tx, err := t.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+
+	defer func() {
+		if p := recover(); p != nil {
+			_ = tx.Rollback()
+			panic(p)
+		}
+
+		if err != nil {
+			if rbErr := tx.Rollback(); rbErr != nil {
+				t.metrics.RollbackFailures.Inc()
+				t.log.Error(ctx, "transaction rollback failed", "error", rbErr)
+			}
+		}
+	}()
+
+	err = fn(context.WithValue(ctx, txKey{}, tx))
+	if err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// executor returns transaction from ctx if any, otherwise db
+func executor(ctx context.Context, db *sql.DB) dbExecutor {
+	if tx, ok := ctx.Value(txKey{}).(*sql.Tx); ok {
+		return tx
+	}
+
+	return db
+}
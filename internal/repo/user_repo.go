@@ -0,0 +1,95 @@
+package repo
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+)
+
+const (
+	insertUserStmt = `insert into users(username, password_hash) values ($1, $2)
+						on conflict (username) do nothing
+						returning *`
+	getUserByUsernameStmt = "select * from users where username = $1"
+)
+
+// ErrUsernameTaken is returned when user with same username already exists
+var ErrUsernameTaken = errors.New("username is taken")
+
+// User ...
+type User struct {
+	ID           uint32
+	Username     string
+	PasswordHash string
+	CreatedAt    sql.NullTime
+	UpdatedAt    sql.NullTime
+}
+
+// IUserRepo is repository interface for user db operations
+type IUserRepo interface {
+	CreateUser(ctx context.Context, user *User) (*User, error)
+	GetUserByUsername(ctx context.Context, username string) (*User, error)
+}
+
+// NewUserRepo ...
+func NewUserRepo(db *sql.DB) *UserRepo {
+	return &UserRepo{
+		db: db,
+	}
+}
+
+// UserRepo is repository implementation for user db operations
+type UserRepo struct {
+	db *sql.DB
+}
+
+// CreateUser creates new user record in db, returns ErrUsernameTaken if username exists
+func (ur *UserRepo) CreateUser(ctx context.Context, user *User) (*User, error) {
+	stmt, err := executor(ctx, ur.db).PrepareContext(ctx, insertUserStmt)
+	if err != nil {
+		return nil, err
+	}
+
+	defer stmt.Close()
+
+	row := stmt.QueryRowContext(ctx, user.Username, user.PasswordHash)
+
+	err = row.Scan(
+		&user.ID,
+		&user.Username,
+		&user.PasswordHash,
+		&user.CreatedAt,
+		&user.UpdatedAt)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, ErrUsernameTaken
+		}
+
+		return nil, err
+	}
+
+	return user, nil
+}
+
+// GetUserByUsername returns user record by username
+func (ur *UserRepo) GetUserByUsername(ctx context.Context, username string) (*User, error) {
+	stmt, err := executor(ctx, ur.db).PrepareContext(ctx, getUserByUsernameStmt)
+	if err != nil {
+		return nil, err
+	}
+
+	defer stmt.Close()
+
+	var user User
+	err = stmt.QueryRowContext(ctx, username).Scan(
+		&user.ID,
+		&user.Username,
+		&user.PasswordHash,
+		&user.CreatedAt,
+		&user.UpdatedAt)
+	if err != nil {
+		return nil, err
+	}
+
+	return &user, nil
+}
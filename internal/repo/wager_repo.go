@@ -3,16 +3,90 @@ package repo
 import (
 	"context"
 	"database/sql"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/vitthalaa/wager-app/money"
 )
 
 const (
-	insertWagerStmt = `insert into wager(total_wager_value, odds, selling_percentage, selling_price, current_selling_price)
-						values ($1, $2, $3, $4, $5)
+	insertWagerStmt = `insert into wager(total_wager_value, odds, selling_percentage, selling_price, current_selling_price, seller_id,
+							pricing, floor_price, decay_seconds, step_percentage, auction, auction_ends_at)
+						values ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12)
 						returning *`
-	listWagerStmt    = "select * from wager order by id desc limit $1 offset $2"
+	listWagerStmt    = "select * from wager"
 	getWagerByIDStmt = "select * from wager where id=$1"
-	updateWagerStmt  = `update wager set current_selling_price=$1, percentage_sold=$2, amount_sold=$3, updated_at=now() 
-						where id = $4;`
+	updateWagerStmt  = `update wager set current_selling_price=$1, percentage_sold=$2, amount_sold=$3, status=$4,
+						priced_at=$5, auction_closed_at=$6, updated_at=current_timestamp
+						where id = $7;`
+	listDueAuctionsStmt = `select * from wager where auction <> '' and auction_closed_at is null and auction_ends_at <= $1
+						order by auction_ends_at, id`
+	settleWagerStmt = `update wager set status=$1, outcome=$2, settled_at=current_timestamp, updated_at=current_timestamp
+						where id = $3;`
+)
+
+// WagerStatus is state of wager, open -> sold_out -> settled/voided
+type WagerStatus string
+
+// Wager statuses
+const (
+	WagerStatusOpen    WagerStatus = "open"
+	WagerStatusSoldOut WagerStatus = "sold_out"
+	WagerStatusSettled WagerStatus = "settled"
+	WagerStatusVoided  WagerStatus = "voided"
+)
+
+// Closed reports whether wager is settled or voided and accepts no more purchases
+func (s WagerStatus) Closed() bool {
+	return s == WagerStatusSettled || s == WagerStatusVoided
+}
+
+// WagerOutcome is result of wager recorded on settlement
+type WagerOutcome string
+
+// Wager outcomes
+const (
+	WagerOutcomeWon  WagerOutcome = "won"
+	WagerOutcomeLost WagerOutcome = "lost"
+	WagerOutcomeVoid WagerOutcome = "void"
+)
+
+// Valid reports whether outcome is known
+func (o WagerOutcome) Valid() bool {
+	return o == WagerOutcomeWon || o == WagerOutcomeLost || o == WagerOutcomeVoid
+}
+
+// Status returns wager status after settlement with outcome
+func (o WagerOutcome) Status() WagerStatus {
+	if o == WagerOutcomeVoid {
+		return WagerStatusVoided
+	}
+
+	return WagerStatusSettled
+}
+
+// WagerPricing is strategy pricing current selling price of wager
+type WagerPricing string
+
+// Wager pricing strategies
+const (
+	WagerPricingLastTrade    WagerPricing = "last_trade"
+	WagerPricingTimeDecay    WagerPricing = "time_decay"
+	WagerPricingDemandStepUp WagerPricing = "demand_step_up"
+	WagerPricingDutchAuction WagerPricing = "dutch_auction"
+)
+
+// WagerAuction is sealed-bid auction mode of wager, wagers without auction are sold first-come-first-served
+type WagerAuction string
+
+// Wager auction modes
+const (
+	WagerAuctionNone WagerAuction = ""
+	// WagerAuctionFirstPrice winners pay their bid prices
+	WagerAuctionFirstPrice WagerAuction = "first_price"
+	// WagerAuctionSecondPrice winners pay price of highest losing bid
+	WagerAuctionSecondPrice WagerAuction = "second_price"
 )
 
 // Wager ...
@@ -21,43 +95,161 @@ type Wager struct {
 	TotalWagerValue     uint32
 	Odds                uint32
 	SellingPercentage   float32
-	SellingPrice        float32
-	CurrentSellingPrice float32
+	SellingPrice        money.Money
+	CurrentSellingPrice money.Money
 	PercentageSold      sql.NullFloat64
 	AmountSold          sql.NullInt32
 	CreatedAt           sql.NullTime
 	UpdatedAt           sql.NullTime
+	Status              WagerStatus
+	Outcome             sql.NullString
+	SettledAt           sql.NullTime
+	SellerID            sql.NullInt32
+	Pricing             WagerPricing
+	// FloorPrice and DecaySeconds parameterize decaying pricing strategies, StepPercentage demand based one
+	FloorPrice     money.Money
+	DecaySeconds   uint32
+	StepPercentage float32
+	// PricedAt is time current selling price was last set by purchase
+	PricedAt sql.NullTime
+	Auction  WagerAuction
+	// AuctionEndsAt is end of bidding window, AuctionClosedAt is time bids were awarded
+	AuctionEndsAt   sql.NullTime
+	AuctionClosedAt sql.NullTime
+}
+
+// InAuction reports whether wager is auctioned and its bids are not awarded yet
+func (w Wager) InAuction() bool {
+	return w.Auction != WagerAuctionNone && !w.AuctionClosedAt.Valid
+}
+
+// WagerSortField is whitelisted field wagers can be sorted by
+type WagerSortField string
+
+// Wager sort fields
+const (
+	WagerSortID                  WagerSortField = "id"
+	WagerSortCurrentSellingPrice WagerSortField = "current_selling_price"
+	WagerSortOdds                WagerSortField = "odds"
+	WagerSortPercentageSold      WagerSortField = "percentage_sold"
+	WagerSortCreatedAt           WagerSortField = "created_at"
+)
+
+// wagerSortExprs are sql expressions of sort fields, matching expressions of wager indexes
+var wagerSortExprs = map[WagerSortField]string{
+	WagerSortID:                  "id",
+	WagerSortCurrentSellingPrice: "current_selling_price",
+	WagerSortOdds:                "odds",
+	// wagers without purchases are sorted as 0 sold, null ordering differs between dialects
+	WagerSortPercentageSold: "coalesce(percentage_sold, 0)",
+	WagerSortCreatedAt:      "created_at",
+}
+
+// Valid reports whether field is whitelisted sort field
+func (f WagerSortField) Valid() bool {
+	_, ok := wagerSortExprs[f]
+	return ok
+}
+
+// WagerFilter is criteria for listing wagers, zero value fields are not applied
+type WagerFilter struct {
+	MinOdds uint32
+	MaxOdds uint32
+	// MinPrice and MaxPrice are inclusive range of wager current selling price
+	MinPrice money.Money
+	MaxPrice money.Money
+	// ExcludePricings leaves out wagers priced by any of the pricings
+	ExcludePricings []WagerPricing
+	// SoldOut lists only sold out wagers when true and only not sold out wagers when false
+	SoldOut *bool
+	// From and To are inclusive range of wager creation time
+	From time.Time
+	To   time.Time
+
+	// SortBy defaults to id, ties are broken by id
+	SortBy   WagerSortField
+	SortDesc bool
+
+	// After is keyset cursor, only wagers after it in sort order are listed, nil lists from first wager
+	After *WagerCursor
+	Limit uint32
+}
+
+// WagerCursor is position in wager listing, sort values and id of last wager of previous page. It carries the values,
+// so position stays valid when that wager changes.
+type WagerCursor struct {
+	ID                  uint32
+	CurrentSellingPrice money.Money
+	Odds                uint32
+	PercentageSold      float64
+	CreatedAt           time.Time
+}
+
+// NewWagerCursor returns cursor positioned at wager
+func NewWagerCursor(wager Wager) WagerCursor {
+	return WagerCursor{
+		ID:                  wager.ID,
+		CurrentSellingPrice: wager.CurrentSellingPrice,
+		Odds:                wager.Odds,
+		// null is sorted as 0 sold, Float64 of null is 0
+		PercentageSold: wager.PercentageSold.Float64,
+		CreatedAt:      wager.CreatedAt.Time,
+	}
+}
+
+// sortArg returns cursor value of sort field as query arg
+func (c WagerCursor) sortArg(field WagerSortField) interface{} {
+	switch field {
+	case WagerSortCurrentSellingPrice:
+		return c.CurrentSellingPrice
+	case WagerSortOdds:
+		return c.Odds
+	case WagerSortPercentageSold:
+		return c.PercentageSold
+	case WagerSortCreatedAt:
+		return sqlTime(c.CreatedAt)
+	}
+
+	return c.ID
 }
 
 // IWagerRepo is repository interface for wager db operations
 type IWagerRepo interface {
 	CreateWager(ctx context.Context, wager *Wager) (*Wager, error)
-	ListWager(ctx context.Context, offset, limit uint32) ([]Wager, error)
+	ListWager(ctx context.Context, filter WagerFilter) ([]Wager, error)
+	ListDueAuctions(ctx context.Context, at time.Time) ([]Wager, error)
 	GetWagerByID(ctx context.Context, wagerID uint32) (*Wager, error)
+	GetWagerByIDForUpdate(ctx context.Context, wagerID uint32) (*Wager, error)
 	UpdateWager(ctx context.Context, wager *Wager) error
+	SettleWager(ctx context.Context, wager *Wager) error
 }
 
 // NewWagerRepo ...
-func NewWagerRepo(db *sql.DB) *WagerRepo {
+func NewWagerRepo(db *sql.DB, dialect Dialect) *WagerRepo {
 	return &WagerRepo{
-		db: db,
+		db:      db,
+		dialect: dialect,
 	}
 }
 
 // WagerRepo is repository implementation for wager db operations
 type WagerRepo struct {
-	db *sql.DB
+	db      *sql.DB
+	dialect Dialect
 }
 
 // CreateWager creates new wager record in db
 func (wr *WagerRepo) CreateWager(ctx context.Context, wager *Wager) (*Wager, error) {
-	stmt, err := wr.db.Prepare(insertWagerStmt)
+	stmt, err := executor(ctx, wr.db).PrepareContext(ctx, insertWagerStmt)
 	if err != nil {
 		return nil, err
 	}
 
+	defer stmt.Close()
+
 	row := stmt.QueryRowContext(ctx,
-		wager.TotalWagerValue, wager.Odds, wager.SellingPercentage, wager.SellingPrice, wager.CurrentSellingPrice)
+		wager.TotalWagerValue, wager.Odds, wager.SellingPercentage, wager.SellingPrice, wager.CurrentSellingPrice, wager.SellerID,
+		wager.Pricing, wager.FloorPrice, wager.DecaySeconds, wager.StepPercentage, wager.Auction, nullSQLTime(wager.AuctionEndsAt))
 
 	err = row.Scan(
 		&wager.ID,
@@ -69,7 +261,19 @@ func (wr *WagerRepo) CreateWager(ctx context.Context, wager *Wager) (*Wager, err
 		&wager.PercentageSold,
 		&wager.AmountSold,
 		&wager.CreatedAt,
-		&wager.UpdatedAt)
+		&wager.UpdatedAt,
+		&wager.Status,
+		&wager.Outcome,
+		&wager.SettledAt,
+		&wager.SellerID,
+		&wager.Pricing,
+		&wager.FloorPrice,
+		&wager.DecaySeconds,
+		&wager.StepPercentage,
+		&wager.PricedAt,
+		&wager.Auction,
+		&wager.AuctionEndsAt,
+		&wager.AuctionClosedAt)
 	if err != nil {
 		return nil, err
 	}
@@ -77,23 +281,49 @@ func (wr *WagerRepo) CreateWager(ctx context.Context, wager *Wager) (*Wager, err
 	return wager, nil
 }
 
-// ListWager returns list of wagers from offset to limit
-func (wr *WagerRepo) ListWager(ctx context.Context, offset, limit uint32) ([]Wager, error) {
-	stmt, err := wr.db.Prepare(listWagerStmt)
-	if err != nil {
-		return nil, err
+// ListWager returns wagers matching filter in sort order
+func (wr *WagerRepo) ListWager(ctx context.Context, filter WagerFilter) ([]Wager, error) {
+	sortBy := filter.SortBy
+	if sortBy == "" {
+		sortBy = WagerSortID
 	}
 
-	defer stmt.Close()
+	if !sortBy.Valid() {
+		return nil, fmt.Errorf("unsupported wager sort field %q", sortBy)
+	}
+
+	expr := wagerSortExprs[sortBy]
+	direction, after := " asc", ">"
+	if filter.SortDesc {
+		direction, after = " desc", "<"
+	}
+
+	qb := wagerFilterQuery(filter)
+	if filter.After != nil {
+		qb.where("("+expr+", id) "+after+" (?, ?)", filter.After.sortArg(sortBy), filter.After.ID)
+	}
+
+	query := listWagerStmt + qb.whereClause() +
+		" order by " + expr + direction + ", id" + direction +
+		" limit " + qb.arg(filter.Limit)
+
+	return wr.queryWagers(ctx, query, qb.args...)
+}
+
+// ListDueAuctions returns auctioned wagers not closed yet whose bidding ended at or before time, earliest end first
+func (wr *WagerRepo) ListDueAuctions(ctx context.Context, at time.Time) ([]Wager, error) {
+	return wr.queryWagers(ctx, listDueAuctionsStmt, sqlTime(at))
+}
 
-	rows, err := stmt.QueryContext(ctx, limit, offset)
+func (wr *WagerRepo) queryWagers(ctx context.Context, query string, args ...interface{}) ([]Wager, error) {
+	rows, err := executor(ctx, wr.db).QueryContext(ctx, query, args...)
 	if err != nil {
 		return nil, err
 	}
 
 	defer rows.Close()
 
-	res := make([]Wager, 0, limit)
+	res := make([]Wager, 0)
 	for rows.Next() {
 		var wager Wager
 		err = rows.Scan(
@@ -106,7 +336,19 @@ func (wr *WagerRepo) ListWager(ctx context.Context, offset, limit uint32) ([]Wag
 			&wager.PercentageSold,
 			&wager.AmountSold,
 			&wager.CreatedAt,
-			&wager.UpdatedAt)
+			&wager.UpdatedAt,
+			&wager.Status,
+			&wager.Outcome,
+			&wager.SettledAt,
+			&wager.SellerID,
+			&wager.Pricing,
+			&wager.FloorPrice,
+			&wager.DecaySeconds,
+			&wager.StepPercentage,
+			&wager.PricedAt,
+			&wager.Auction,
+			&wager.AuctionEndsAt,
+			&wager.AuctionClosedAt)
 		if err != nil {
 			return nil, err
 		}
@@ -114,12 +356,69 @@ func (wr *WagerRepo) ListWager(ctx context.Context, offset, limit uint32) ([]Wag
 		res = append(res, wager)
 	}
 
-	return res, nil
+	return res, rows.Err()
+}
+
+func wagerFilterQuery(filter WagerFilter) *queryBuilder {
+	qb := &queryBuilder{}
+	if filter.MinOdds > 0 {
+		qb.where("odds >= ?", filter.MinOdds)
+	}
+
+	if filter.MaxOdds > 0 {
+		qb.where("odds <= ?", filter.MaxOdds)
+	}
+
+	if filter.MinPrice > 0 {
+		qb.where("current_selling_price >= ?", filter.MinPrice)
+	}
+
+	if filter.MaxPrice > 0 {
+		qb.where("current_selling_price <= ?", filter.MaxPrice)
+	}
+
+	if len(filter.ExcludePricings) > 0 {
+		placeholders := make([]string, 0, len(filter.ExcludePricings))
+		for _, pricing := range filter.ExcludePricings {
+			placeholders = append(placeholders, qb.arg(string(pricing)))
+		}
+
+		qb.where("pricing not in (" + strings.Join(placeholders, ", ") + ")")
+	}
+
+	if filter.SoldOut != nil {
+		op := "<>"
+		if *filter.SoldOut {
+			op = "="
+		}
+
+		qb.where("status "+op+" ?", string(WagerStatusSoldOut))
+	}
+
+	if !filter.From.IsZero() {
+		qb.where("created_at >= ?", sqlTime(filter.From))
+	}
+
+	if !filter.To.IsZero() {
+		qb.where("created_at <= ?", sqlTime(filter.To))
+	}
+
+	return qb
 }
 
 // GetWagerByID returns wager record by ids
 func (wr *WagerRepo) GetWagerByID(ctx context.Context, wagerID uint32) (*Wager, error) {
-	stmt, err := wr.db.Prepare(getWagerByIDStmt)
+	return wr.getWager(ctx, getWagerByIDStmt, wagerID)
+}
+
+// GetWagerByIDForUpdate returns wager record by id and locks the row until transaction ends.
+// Must be called within ITransactor.WithinTransaction to hold the lock.
+func (wr *WagerRepo) GetWagerByIDForUpdate(ctx context.Context, wagerID uint32) (*Wager, error) {
+	return wr.getWager(ctx, getWagerByIDStmt+wr.dialect.forUpdate(), wagerID)
+}
+
+func (wr *WagerRepo) getWager(ctx context.Context, query string, wagerID uint32) (*Wager, error) {
+	stmt, err := executor(ctx, wr.db).PrepareContext(ctx, query)
 	if err != nil {
 		return nil, err
 	}
@@ -139,7 +438,19 @@ func (wr *WagerRepo) GetWagerByID(ctx context.Context, wagerID uint32) (*Wager,
 		&wager.PercentageSold,
 		&wager.AmountSold,
 		&wager.CreatedAt,
-		&wager.UpdatedAt)
+		&wager.UpdatedAt,
+		&wager.Status,
+		&wager.Outcome,
+		&wager.SettledAt,
+		&wager.SellerID,
+		&wager.Pricing,
+		&wager.FloorPrice,
+		&wager.DecaySeconds,
+		&wager.StepPercentage,
+		&wager.PricedAt,
+		&wager.Auction,
+		&wager.AuctionEndsAt,
+		&wager.AuctionClosedAt)
 	if err != nil {
 		return nil, err
 	}
@@ -147,16 +458,36 @@ func (wr *WagerRepo) GetWagerByID(ctx context.Context, wagerID uint32) (*Wager,
 	return &wager, nil
 }
 
-// UpdateWager updates wager record for current selling price, amount sold and percentage sold
+// UpdateWager updates wager record for current selling price and its pricing time, amount sold, percentage sold,
+// status and auction closing time
 func (wr *WagerRepo) UpdateWager(ctx context.Context, wager *Wager) error {
-	stmt, err := wr.db.Prepare(updateWagerStmt)
+	stmt, err := executor(ctx, wr.db).PrepareContext(ctx, updateWagerStmt)
+	if err != nil {
+		return err
+	}
+
+	defer stmt.Close()
+
+	_, err = stmt.ExecContext(ctx,
+		wager.CurrentSellingPrice, wager.PercentageSold, wager.AmountSold, wager.Status, nullSQLTime(wager.PricedAt),
+		nullSQLTime(wager.AuctionClosedAt), wager.ID)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// SettleWager updates wager record for status and outcome, settlement time is set to current time
+func (wr *WagerRepo) SettleWager(ctx context.Context, wager *Wager) error {
+	stmt, err := executor(ctx, wr.db).PrepareContext(ctx, settleWagerStmt)
 	if err != nil {
 		return err
 	}
 
 	defer stmt.Close()
 
-	_, err = stmt.ExecContext(ctx, wager.CurrentSellingPrice, wager.PercentageSold, wager.AmountSold, wager.ID)
+	_, err = stmt.ExecContext(ctx, wager.Status, wager.Outcome, wager.ID)
 	if err != nil {
 		return err
 	}
@@ -0,0 +1,317 @@
+package repo
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+
+	"github.com/vitthalaa/wager-app/money"
+)
+
+const (
+	insertAccountStmt           = "insert into accounts(user_id, kind) values ($1, $2) returning *"
+	getAccountByUserIDStmt      = "select * from accounts where user_id = $1"
+	getAccountByKindStmt        = "select * from accounts where kind = $1 order by id limit 1"
+	addAccountBalanceStmt       = "update accounts set balance = balance + $1, updated_at = current_timestamp where id = $2"
+	insertLedgerTransactionStmt = "insert into ledger_transactions(kind, purchase_id) values ($1, $2) returning *"
+	insertLedgerEntryStmt       = `insert into ledger_entries(transaction_id, account_id, amount) values ($1, $2, $3)
+								returning *`
+	listLedgerEntriesStmt = `select e.id, e.transaction_id, e.account_id, e.amount, e.created_at, t.kind, t.purchase_id
+							from ledger_entries e join ledger_transactions t on t.id = e.transaction_id
+							where e.account_id = $1 order by e.id desc limit $2 offset $3`
+	countLedgerEntriesStmt = "select count(*) from ledger_entries where account_id = $1"
+	sumLedgerStmt          = "select coalesce(sum(amount), 0) from ledger_entries"
+	// amounts are rounded as SQLite sums them as floats
+	countUnbalancedTransactionsStmt = `select count(*) from (
+										select transaction_id from ledger_entries
+										group by transaction_id having round(sum(amount), 2) <> 0
+									) t`
+	countMismatchedAccountsStmt = `select count(*) from accounts a
+									where round(a.balance, 2) <> round(coalesce(
+										(select sum(e.amount) from ledger_entries e where e.account_id = a.id), 0), 2)`
+)
+
+// ErrUnbalancedTransaction is returned when entries of ledger transaction do not sum to zero
+var ErrUnbalancedTransaction = errors.New("ledger transaction entries do not sum to zero")
+
+// AccountKind is owner type of ledger account
+type AccountKind string
+
+// Account kinds
+const (
+	// AccountKindUser is wallet of user
+	AccountKindUser AccountKind = "user"
+	// AccountKindExternal is counterparty of money entering and leaving wallets, its balance is negative of deposits
+	AccountKindExternal AccountKind = "external"
+)
+
+// LedgerTransactionKind is business operation recorded by ledger transaction
+type LedgerTransactionKind string
+
+// Ledger transaction kinds
+const (
+	LedgerTransactionDeposit    LedgerTransactionKind = "deposit"
+	LedgerTransactionWithdrawal LedgerTransactionKind = "withdrawal"
+	LedgerTransactionPurchase   LedgerTransactionKind = "purchase"
+	LedgerTransactionPayout     LedgerTransactionKind = "payout"
+	LedgerTransactionRefund     LedgerTransactionKind = "refund"
+)
+
+// Account is ledger account, balance is sum of its ledger entries
+type Account struct {
+	ID        uint32
+	UserID    sql.NullInt32
+	Kind      AccountKind
+	Balance   money.Money
+	CreatedAt sql.NullTime
+	UpdatedAt sql.NullTime
+}
+
+// LedgerTransaction is set of ledger entries moving money between accounts
+type LedgerTransaction struct {
+	ID         uint32
+	Kind       LedgerTransactionKind
+	PurchaseID sql.NullInt32
+	CreatedAt  sql.NullTime
+	Entries    []LedgerEntry
+}
+
+// LedgerEntry is signed amount credited(positive) or debited(negative) to account
+type LedgerEntry struct {
+	ID            uint32
+	TransactionID uint32
+	AccountID     uint32
+	Amount        money.Money
+	CreatedAt     sql.NullTime
+
+	// Kind and PurchaseID are of entry transaction, filled by ListLedgerEntries
+	Kind       LedgerTransactionKind
+	PurchaseID sql.NullInt32
+}
+
+// LedgerCheck is result of ledger invariant check
+type LedgerCheck struct {
+	// Sum of all ledger entries
+	Sum money.Money
+	// UnbalancedTransactions is count of transactions which entries do not sum to zero
+	UnbalancedTransactions uint32
+	// MismatchedAccounts is count of accounts which balance differs from sum of their entries
+	MismatchedAccounts uint32
+}
+
+// Balanced reports whether ledger satisfies all invariants
+func (c LedgerCheck) Balanced() bool {
+	return c.Sum == 0 && c.UnbalancedTransactions == 0 && c.MismatchedAccounts == 0
+}
+
+// Validate returns ErrUnbalancedTransaction unless transaction has entries summing to zero
+func (t *LedgerTransaction) Validate() error {
+	if len(t.Entries) < 2 {
+		return ErrUnbalancedTransaction
+	}
+
+	var sum money.Money
+	for _, entry := range t.Entries {
+		sum += entry.Amount
+	}
+
+	if sum != 0 {
+		return ErrUnbalancedTransaction
+	}
+
+	return nil
+}
+
+// IWalletRepo is repository interface for wallet accounts and ledger db operations
+type IWalletRepo interface {
+	CreateAccount(ctx context.Context, account *Account) (*Account, error)
+	GetAccountByUserID(ctx context.Context, userID uint32) (*Account, error)
+	GetAccountByUserIDForUpdate(ctx context.Context, userID uint32) (*Account, error)
+	GetExternalAccount(ctx context.Context) (*Account, error)
+	CreateLedgerTransaction(ctx context.Context, transaction *LedgerTransaction) (*LedgerTransaction, error)
+	ListLedgerEntries(ctx context.Context, accountID, offset, limit uint32) ([]LedgerEntry, error)
+	CountLedgerEntries(ctx context.Context, accountID uint32) (uint32, error)
+	CheckLedger(ctx context.Context) (*LedgerCheck, error)
+}
+
+// NewWalletRepo ...
+func NewWalletRepo(db *sql.DB, dialect Dialect, transactor *Transactor) *WalletRepo {
+	return &WalletRepo{
+		db:         db,
+		dialect:    dialect,
+		transactor: transactor,
+	}
+}
+
+// WalletRepo is repository implementation for wallet accounts and ledger db operations
+type WalletRepo struct {
+	db      *sql.DB
+	dialect Dialect
+	// transactor keeps ledger transaction atomic when it is not created within transaction already
+	transactor *Transactor
+}
+
+// CreateAccount creates new account record in db
+func (wr *WalletRepo) CreateAccount(ctx context.Context, account *Account) (*Account, error) {
+	return wr.getAccount(ctx, insertAccountStmt, account.UserID, account.Kind)
+}
+
+// GetAccountByUserID returns wallet account of user
+func (wr *WalletRepo) GetAccountByUserID(ctx context.Context, userID uint32) (*Account, error) {
+	return wr.getAccount(ctx, getAccountByUserIDStmt, userID)
+}
+
+// GetAccountByUserIDForUpdate returns wallet account of user and locks the row until transaction ends.
+// Must be called within ITransactor.WithinTransaction to hold the lock.
+func (wr *WalletRepo) GetAccountByUserIDForUpdate(ctx context.Context, userID uint32) (*Account, error) {
+	return wr.getAccount(ctx, getAccountByUserIDStmt+wr.dialect.forUpdate(), userID)
+}
+
+// GetExternalAccount returns external account
+func (wr *WalletRepo) GetExternalAccount(ctx context.Context) (*Account, error) {
+	return wr.getAccount(ctx, getAccountByKindStmt, AccountKindExternal)
+}
+
+func (wr *WalletRepo) getAccount(ctx context.Context, query string, args ...interface{}) (*Account, error) {
+	stmt, err := executor(ctx, wr.db).PrepareContext(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+
+	defer stmt.Close()
+
+	var account Account
+	err = stmt.QueryRowContext(ctx, args...).Scan(
+		&account.ID,
+		&account.UserID,
+		&account.Kind,
+		&account.Balance,
+		&account.CreatedAt,
+		&account.UpdatedAt)
+	if err != nil {
+		return nil, err
+	}
+
+	return &account, nil
+}
+
+// CreateLedgerTransaction records transaction with its entries and applies entries to account balances atomically.
+// Returns ErrUnbalancedTransaction unless entries sum to zero.
+func (wr *WalletRepo) CreateLedgerTransaction(ctx context.Context, transaction *LedgerTransaction) (*LedgerTransaction, error) {
+	if err := transaction.Validate(); err != nil {
+		return nil, err
+	}
+
+	err := wr.transactor.WithinTransaction(ctx, func(ctx context.Context) error {
+		db := executor(ctx, wr.db)
+		err := db.QueryRowContext(ctx, insertLedgerTransactionStmt, transaction.Kind, transaction.PurchaseID).Scan(
+			&transaction.ID,
+			&transaction.Kind,
+			&transaction.PurchaseID,
+			&transaction.CreatedAt)
+		if err != nil {
+			return err
+		}
+
+		for i := range transaction.Entries {
+			entry := &transaction.Entries[i]
+			err = db.QueryRowContext(ctx, insertLedgerEntryStmt, transaction.ID, entry.AccountID, entry.Amount).Scan(
+				&entry.ID,
+				&entry.TransactionID,
+				&entry.AccountID,
+				&entry.Amount,
+				&entry.CreatedAt)
+			if err != nil {
+				return err
+			}
+
+			entry.Kind = transaction.Kind
+			entry.PurchaseID = transaction.PurchaseID
+
+			res, err := db.ExecContext(ctx, addAccountBalanceStmt, entry.Amount, entry.AccountID)
+			if err != nil {
+				return err
+			}
+
+			if n, err := res.RowsAffected(); err != nil || n == 0 {
+				return sql.ErrNoRows
+			}
+		}
+
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return transaction, nil
+}
+
+// ListLedgerEntries returns page of account ledger entries, latest first
+func (wr *WalletRepo) ListLedgerEntries(ctx context.Context, accountID, offset, limit uint32) ([]LedgerEntry, error) {
+	stmt, err := executor(ctx, wr.db).PrepareContext(ctx, listLedgerEntriesStmt)
+	if err != nil {
+		return nil, err
+	}
+
+	defer stmt.Close()
+
+	rows, err := stmt.QueryContext(ctx, accountID, limit, offset)
+	if err != nil {
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	res := make([]LedgerEntry, 0)
+	for rows.Next() {
+		var entry LedgerEntry
+		err = rows.Scan(
+			&entry.ID,
+			&entry.TransactionID,
+			&entry.AccountID,
+			&entry.Amount,
+			&entry.CreatedAt,
+			&entry.Kind,
+			&entry.PurchaseID)
+		if err != nil {
+			return nil, err
+		}
+
+		res = append(res, entry)
+	}
+
+	return res, rows.Err()
+}
+
+// CountLedgerEntries returns count of account ledger entries
+func (wr *WalletRepo) CountLedgerEntries(ctx context.Context, accountID uint32) (uint32, error) {
+	var count uint32
+	err := executor(ctx, wr.db).QueryRowContext(ctx, countLedgerEntriesStmt, accountID).Scan(&count)
+	if err != nil {
+		return 0, err
+	}
+
+	return count, nil
+}
+
+// CheckLedger verifies that ledger sums to zero, every transaction is balanced and
+// account balances match their entries
+func (wr *WalletRepo) CheckLedger(ctx context.Context) (*LedgerCheck, error) {
+	db := executor(ctx, wr.db)
+
+	var check LedgerCheck
+	if err := db.QueryRowContext(ctx, sumLedgerStmt).Scan(&check.Sum); err != nil {
+		return nil, err
+	}
+
+	if err := db.QueryRowContext(ctx, countUnbalancedTransactionsStmt).Scan(&check.UnbalancedTransactions); err != nil {
+		return nil, err
+	}
+
+	if err := db.QueryRowContext(ctx, countMismatchedAccountsStmt).Scan(&check.MismatchedAccounts); err != nil {
+		return nil, err
+	}
+
+	return &check, nil
+}
@@ -0,0 +1,375 @@
+package services
+
+import (
+	"context"
+	"database/sql"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/vitthalaa/wager-app/app_errors"
+	"github.com/vitthalaa/wager-app/dto"
+	"github.com/vitthalaa/wager-app/internal/events"
+	"github.com/vitthalaa/wager-app/internal/logger"
+	"github.com/vitthalaa/wager-app/internal/metrics"
+	"github.com/vitthalaa/wager-app/internal/repo"
+	"github.com/vitthalaa/wager-app/internal/validation"
+	"github.com/vitthalaa/wager-app/money"
+)
+
+// IAuctionService ...
+type IAuctionService interface {
+	PlaceBid(ctx context.Context, req *dto.PlaceBidRequest) (*dto.AuctionBid, error)
+	ListBids(ctx context.Context, req *dto.AuctionBidsRequest) ([]dto.AuctionBid, error)
+	CloseDueAuctions(ctx context.Context) (int, error)
+}
+
+// NewAuctionService ...
+func NewAuctionService(
+	transactor repo.ITransactor, auctionBidRepo repo.IAuctionBidRepo, purchaseRepo repo.IPurchaseRepo,
+	wagerRepo repo.IWagerRepo, walletRepo repo.IWalletRepo, log *logger.Logger, metrics *metrics.Metrics,
+	broker *events.Broker, sellOut *SellOutPolicy,
+) *AuctionService {
+	return &AuctionService{
+		transactor:     transactor,
+		auctionBidRepo: auctionBidRepo,
+		purchaseRepo:   purchaseRepo,
+		wagerRepo:      wagerRepo,
+		walletRepo:     walletRepo,
+		log:            log,
+		metrics:        metrics,
+		events:         broker,
+		sellOut:        sellOut,
+		now:            time.Now,
+	}
+}
+
+// AuctionService collects sealed bids of auctioned wagers and awards their units when auctions close
+type AuctionService struct {
+	transactor     repo.ITransactor
+	auctionBidRepo repo.IAuctionBidRepo
+	purchaseRepo   repo.IPurchaseRepo
+	wagerRepo      repo.IWagerRepo
+	walletRepo     repo.IWalletRepo
+	log            *logger.Logger
+	metrics        *metrics.Metrics
+	events         *events.Broker
+	sellOut        *SellOutPolicy
+	now            func() time.Time
+}
+
+// PlaceBid places sealed bid of user for units of wager until its auction ends. Funds are not reserved,
+// bidder must be able to pay whole bid when it is placed and when auction is closed.
+func (s *AuctionService) PlaceBid(ctx context.Context, req *dto.PlaceBidRequest) (*dto.AuctionBid, error) {
+	if req == nil || req.UserID == 0 {
+		return nil, &app_errors.ErrorResponse{Status: http.StatusUnauthorized, Code: app_errors.ErrUnauthorized}
+	}
+
+	if req.WagerID == 0 {
+		return nil, &app_errors.ErrorResponse{Status: http.StatusBadRequest, Code: app_errors.ErrInvalidWagerID}
+	}
+
+	if errRes := validation.Struct(req).Err(); errRes != nil {
+		return nil, errRes
+	}
+
+	var bid *repo.AuctionBid
+	err := s.transactor.WithinTransaction(ctx, func(ctx context.Context) error {
+		var err error
+		bid, err = s.placeBid(ctx, req)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	s.log.Info(ctx, "auction bid placed",
+		"bid_id", bid.ID, "wager_id", bid.WagerID, "user_id", bid.UserID, "quantity", bid.Quantity)
+
+	bidDTO := toAuctionBidDTO(*bid)
+	return &bidDTO, nil
+}
+
+// ListBids returns bids of user for wager, earliest first. Bids of other users are never disclosed.
+func (s *AuctionService) ListBids(ctx context.Context, req *dto.AuctionBidsRequest) ([]dto.AuctionBid, error) {
+	if req == nil || req.UserID == 0 {
+		return nil, &app_errors.ErrorResponse{Status: http.StatusUnauthorized, Code: app_errors.ErrUnauthorized}
+	}
+
+	if _, err := s.wagerRepo.GetWagerByID(ctx, req.WagerID); err != nil {
+		if err == sql.ErrNoRows {
+			return nil, &app_errors.ErrorResponse{Status: http.StatusNotFound, Code: app_errors.ErrNotFound}
+		}
+
+		return nil, err
+	}
+
+	bids, err := s.auctionBidRepo.ListUserAuctionBids(ctx, req.WagerID, req.UserID)
+	if err != nil {
+		return nil, err
+	}
+
+	res := make([]dto.AuctionBid, 0, len(bids))
+	for _, b := range bids {
+		res = append(res, toAuctionBidDTO(b))
+	}
+
+	return res, nil
+}
+
+// CloseDueAuctions closes auctions whose bidding ended, each in its own transaction so that failing auction
+// does not hold others back. Returns number of closed auctions and first error.
+func (s *AuctionService) CloseDueAuctions(ctx context.Context) (int, error) {
+	due, err := s.wagerRepo.ListDueAuctions(ctx, s.now())
+	if err != nil {
+		return 0, err
+	}
+
+	closed := 0
+	var firstErr error
+	for _, w := range due {
+		ok, err := s.closeAuction(ctx, w.ID)
+		if err != nil {
+			s.log.Error(ctx, "close auction failed", "wager_id", w.ID, "error", err)
+			if firstErr == nil {
+				firstErr = err
+			}
+
+			continue
+		}
+
+		if ok {
+			closed++
+		}
+	}
+
+	return closed, firstErr
+}
+
+// closeAuction awards units of auctioned wager to its bids and closes auction, false when it was closed meanwhile
+func (s *AuctionService) closeAuction(ctx context.Context, wagerID uint32) (bool, error) {
+	var wager *repo.Wager
+	var purchases []repo.Purchase
+	err := s.transactor.WithinTransaction(ctx, func(ctx context.Context) error {
+		var err error
+		wager, purchases, err = s.awardAuction(ctx, wagerID)
+		return err
+	})
+	if err != nil || wager == nil {
+		return false, err
+	}
+
+	s.metrics.AuctionsClosed.Inc()
+	s.log.Info(ctx, "auction closed",
+		"wager_id", wager.ID, "auction", wager.Auction, "purchases", len(purchases))
+
+	// published after commit, so subscribers never see changes of rolled back awards
+	if len(purchases) > 0 {
+		for range purchases {
+			s.metrics.WagerPurchases.Inc()
+		}
+
+		wagerDTO := toWagerDTO(*wager, wager.PricedAt.Time)
+		s.events.Publish(events.TypePriceChanged, wagerDTO)
+		if wager.Status == repo.WagerStatusSoldOut {
+			s.events.Publish(events.TypeSoldOut, wagerDTO)
+		}
+	}
+
+	return true, nil
+}
+
+// placeBid locks wager row, validates bid against it and records bid. Must be called within transaction,
+// wager lock keeps bids from being placed while auction is being closed.
+func (s *AuctionService) placeBid(ctx context.Context, req *dto.PlaceBidRequest) (*repo.AuctionBid, error) {
+	wager, err := s.wagerRepo.GetWagerByIDForUpdate(ctx, req.WagerID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, &app_errors.ErrorResponse{Status: http.StatusNotFound, Code: app_errors.ErrNotFound}
+		}
+
+		return nil, err
+	}
+
+	if wager.Status.Closed() {
+		return nil, &app_errors.ErrorResponse{Status: http.StatusConflict, Code: app_errors.ErrWagerSettled}
+	}
+
+	if !wager.InAuction() || !s.now().Before(wager.AuctionEndsAt.Time) {
+		return nil, &app_errors.ErrorResponse{Status: http.StatusConflict, Code: app_errors.ErrAuctionClosed}
+	}
+
+	if wager.SellerID.Valid && uint32(wager.SellerID.Int32) == req.UserID {
+		return nil, &app_errors.ErrorResponse{Status: http.StatusForbidden, Code: app_errors.ErrSellerCannotBid}
+	}
+
+	remaining, rule := s.sellOut.Remaining(*wager)
+	if remaining == 0 {
+		s.metrics.SoldOutRejections.Inc()
+		return nil, &app_errors.ErrorResponse{Status: http.StatusNotAcceptable, Code: rule.Code()}
+	}
+
+	if req.Quantity > remaining {
+		errs := &validation.Errors{}
+		errs.Add(app_errors.ErrInvalidQuantity,
+			validation.Violation("quantity", validation.Rule{Name: "max", Limit: strconv.FormatUint(uint64(remaining), 10)}))
+		return nil, errs.Err()
+	}
+
+	account, err := s.walletRepo.GetAccountByUserID(ctx, req.UserID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, &app_errors.ErrorResponse{Status: http.StatusNotFound, Code: app_errors.ErrNotFound}
+		}
+
+		return nil, err
+	}
+
+	total, err := totalPrice(req.Price, req.Quantity)
+	if err != nil {
+		return nil, err
+	}
+
+	if account.Balance < total {
+		return nil, &app_errors.ErrorResponse{Status: http.StatusPaymentRequired, Code: app_errors.ErrInsufficientFunds}
+	}
+
+	return s.auctionBidRepo.CreateAuctionBid(ctx, &repo.AuctionBid{
+		WagerID:  wager.ID,
+		UserID:   req.UserID,
+		Price:    req.Price,
+		Quantity: req.Quantity,
+	})
+}
+
+// awardAuction locks wager row, awards its units to bids and marks auction closed. Returns updated wager and
+// purchases of awards, nil wager when auction was closed meanwhile. Settled wagers are closed without awards.
+// Must be called within transaction.
+func (s *AuctionService) awardAuction(ctx context.Context, wagerID uint32) (*repo.Wager, []repo.Purchase, error) {
+	wager, err := s.wagerRepo.GetWagerByIDForUpdate(ctx, wagerID)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	now := s.now()
+	if !wager.InAuction() || wager.AuctionEndsAt.Time.After(now) {
+		return nil, nil, nil
+	}
+
+	var purchases []repo.Purchase
+	if !wager.Status.Closed() {
+		bids, err := s.auctionBidRepo.ListAuctionBids(ctx, wager.ID)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		purchases, err = s.award(ctx, wager, bids, now)
+		if err != nil {
+			return nil, nil, err
+		}
+	}
+
+	wager.AuctionClosedAt = sql.NullTime{Time: now, Valid: true}
+	if err = s.wagerRepo.UpdateWager(ctx, wager); err != nil {
+		return nil, nil, err
+	}
+
+	return wager, purchases, nil
+}
+
+// award sells units left by sell out policy to bids in their order, highest price first and earliest first at same
+// price. Bids are not reserved, so bids which bidder can not pay in full anymore are skipped. Winners of first price
+// auction pay their bid price, winners of second price auction pay price of highest losing bid, or of lowest
+// winning bid when all bids win. Updates bids and wager in place.
+func (s *AuctionService) award(
+	ctx context.Context, wager *repo.Wager, bids []repo.AuctionBid, now time.Time,
+) ([]repo.Purchase, error) {
+	if len(bids) == 0 {
+		return nil, nil
+	}
+
+	// all accounts which may take part in awards are locked at once in user id order
+	sellerID := uint32(wager.SellerID.Int32)
+	userIDs := []uint32{sellerID}
+	for _, b := range bids {
+		userIDs = append(userIDs, b.UserID)
+	}
+
+	accounts, err := lockUserAccounts(ctx, s.walletRepo, userIDs...)
+	if err != nil {
+		return nil, err
+	}
+
+	remaining, _ := s.sellOut.Remaining(*wager)
+	committed := map[uint32]money.Money{}
+	var winners []*repo.AuctionBid
+	var clearing money.Money
+	for i := range bids {
+		b := &bids[i]
+		total, err := totalPrice(b.Price, b.Quantity)
+		if err != nil || accounts[b.UserID].Balance-committed[b.UserID] < total {
+			continue
+		}
+
+		// first bid left without units is highest losing bid
+		if remaining == 0 {
+			clearing = b.Price
+			break
+		}
+
+		b.Awarded = b.Quantity
+		if remaining < b.Awarded {
+			b.Awarded = remaining
+		}
+
+		remaining -= b.Awarded
+		// awarded units do not overflow, they are not more than checked quantity
+		committed[b.UserID] += b.Price * money.Money(b.Awarded)
+		winners = append(winners, b)
+
+		// units of partly awarded bid lose too
+		if b.Awarded < b.Quantity {
+			clearing = b.Price
+			break
+		}
+	}
+
+	if clearing == 0 && len(winners) > 0 {
+		clearing = winners[len(winners)-1].Price
+	}
+
+	pricing := pricingOf(*wager)
+	purchases := make([]repo.Purchase, 0, len(winners))
+	for _, b := range winners {
+		price := b.Price
+		if wager.Auction == repo.WagerAuctionSecondPrice {
+			price = clearing
+		}
+
+		total, err := totalPrice(price, b.Awarded)
+		if err != nil {
+			return nil, err
+		}
+
+		purchase, err := recordPurchase(ctx, s.purchaseRepo, s.walletRepo, &repo.Purchase{
+			WagerID:     wager.ID,
+			BuyingPrice: price,
+			BuyerID:     toNullID(b.UserID),
+			Quantity:    b.Awarded,
+			TotalPrice:  total,
+		}, accounts[b.UserID], accounts[sellerID])
+		if err != nil {
+			return nil, err
+		}
+
+		markSold(wager, s.sellOut, pricing, price, b.Awarded, now)
+
+		if err = s.auctionBidRepo.UpdateAuctionBid(ctx, b); err != nil {
+			return nil, err
+		}
+
+		purchases = append(purchases, *purchase)
+	}
+
+	return purchases, nil
+}
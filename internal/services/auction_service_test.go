@@ -0,0 +1,459 @@
+package services
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+
+	"github.com/vitthalaa/wager-app/app_errors"
+	"github.com/vitthalaa/wager-app/dto"
+	"github.com/vitthalaa/wager-app/internal/events"
+	"github.com/vitthalaa/wager-app/internal/logger"
+	"github.com/vitthalaa/wager-app/internal/metrics"
+	"github.com/vitthalaa/wager-app/internal/repo"
+	"github.com/vitthalaa/wager-app/money"
+)
+
+// auctionWager returns wager of 3 units auctioned by seller 9 until ends at
+func auctionWager(auction repo.WagerAuction, endsAt time.Time) *repo.Wager {
+	return &repo.Wager{
+		ID:                  111,
+		TotalWagerValue:     10,
+		Odds:                2,
+		SellingPercentage:   30,
+		SellingPrice:        money.MustParse("26"),
+		CurrentSellingPrice: money.MustParse("26"),
+		SellerID:            sql.NullInt32{Int32: 9, Valid: true},
+		Status:              repo.WagerStatusOpen,
+		Pricing:             repo.WagerPricingLastTrade,
+		Auction:             auction,
+		AuctionEndsAt:       sql.NullTime{Time: endsAt, Valid: true},
+	}
+}
+
+func TestAuctionService_PlaceBid(t *testing.T) {
+	now := time.Now()
+	open := auctionWager(repo.WagerAuctionFirstPrice, now.Add(time.Hour))
+
+	for _, tc := range []struct {
+		name  string
+		input *dto.PlaceBidRequest
+
+		wagerRepoResp  *repo.Wager
+		wagerRepoError error
+		balance        money.Money
+
+		expectedBid   *repo.AuctionBid
+		expectedRes   *dto.AuctionBid
+		expectedError error
+	}{
+		{
+			name:          "happy path",
+			input:         &dto.PlaceBidRequest{UserID: 5, WagerID: 111, Price: money.MustParse("30"), Quantity: 2},
+			wagerRepoResp: open,
+			balance:       money.MustParse("60"),
+			expectedBid:   &repo.AuctionBid{WagerID: 111, UserID: 5, Price: money.MustParse("30"), Quantity: 2},
+			expectedRes: &dto.AuctionBid{
+				ID: 1, WagerID: 111, Price: money.MustParse("30"), Quantity: 2, PlacedAt: &now,
+			},
+		},
+		{
+			name:          "unauthorized",
+			input:         &dto.PlaceBidRequest{WagerID: 111, Price: money.MustParse("30"), Quantity: 1},
+			expectedError: &app_errors.ErrorResponse{Status: http.StatusUnauthorized, Code: app_errors.ErrUnauthorized},
+		},
+		{
+			name:  "invalid price",
+			input: &dto.PlaceBidRequest{UserID: 5, WagerID: 111, Price: money.MustParse("0.5"), Quantity: 1},
+			expectedError: &app_errors.ErrorResponse{
+				Status: http.StatusBadRequest,
+				Code:   app_errors.ErrInvalidBidPrice,
+				Details: []app_errors.FieldError{
+					{Field: "price", Rule: "min", Message: "must be at least 1.00", Limits: map[string]string{"min": "1.00"}},
+				},
+			},
+		},
+		{
+			name:           "wager not found",
+			input:          &dto.PlaceBidRequest{UserID: 5, WagerID: 111, Price: money.MustParse("30"), Quantity: 1},
+			wagerRepoError: sql.ErrNoRows,
+			expectedError:  &app_errors.ErrorResponse{Status: http.StatusNotFound, Code: app_errors.ErrNotFound},
+		},
+		{
+			name:          "wager not auctioned",
+			input:         &dto.PlaceBidRequest{UserID: 5, WagerID: 111, Price: money.MustParse("30"), Quantity: 1},
+			wagerRepoResp: auctionWager(repo.WagerAuctionNone, time.Time{}),
+			expectedError: &app_errors.ErrorResponse{Status: http.StatusConflict, Code: app_errors.ErrAuctionClosed},
+		},
+		{
+			name:          "auction ended",
+			input:         &dto.PlaceBidRequest{UserID: 5, WagerID: 111, Price: money.MustParse("30"), Quantity: 1},
+			wagerRepoResp: auctionWager(repo.WagerAuctionSecondPrice, now),
+			expectedError: &app_errors.ErrorResponse{Status: http.StatusConflict, Code: app_errors.ErrAuctionClosed},
+		},
+		{
+			name:          "seller bids",
+			input:         &dto.PlaceBidRequest{UserID: 9, WagerID: 111, Price: money.MustParse("30"), Quantity: 1},
+			wagerRepoResp: open,
+			expectedError: &app_errors.ErrorResponse{Status: http.StatusForbidden, Code: app_errors.ErrSellerCannotBid},
+		},
+		{
+			name:          "quantity above units to sell",
+			input:         &dto.PlaceBidRequest{UserID: 5, WagerID: 111, Price: money.MustParse("30"), Quantity: 4},
+			wagerRepoResp: open,
+			expectedError: &app_errors.ErrorResponse{
+				Status: http.StatusBadRequest,
+				Code:   app_errors.ErrInvalidQuantity,
+				Details: []app_errors.FieldError{
+					{Field: "quantity", Rule: "max", Message: "must be at most 3", Limits: map[string]string{"max": "3"}},
+				},
+			},
+		},
+		{
+			name:          "insufficient funds",
+			input:         &dto.PlaceBidRequest{UserID: 5, WagerID: 111, Price: money.MustParse("30"), Quantity: 2},
+			wagerRepoResp: open,
+			balance:       money.MustParse("59.99"),
+			expectedError: &app_errors.ErrorResponse{Status: http.StatusPaymentRequired, Code: app_errors.ErrInsufficientFunds},
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			ctx := context.Background()
+			mockWagerRepo := new(MockWagerRepo)
+			mockWagerRepo.On("GetWagerByIDForUpdate", ctx, tc.input.WagerID).
+				Return(tc.wagerRepoResp, tc.wagerRepoError)
+
+			mockWalletRepo := new(MockWalletRepo)
+			mockWalletRepo.On("GetAccountByUserID", ctx, tc.input.UserID).
+				Return(&repo.Account{ID: 50, Kind: repo.AccountKindUser, Balance: tc.balance}, nil)
+
+			mockAuctionBidRepo := new(MockAuctionBidRepo)
+			mockAuctionBidRepo.On("CreateAuctionBid", ctx, mock.Anything).
+				Return(func(_ context.Context, b *repo.AuctionBid) *repo.AuctionBid {
+					created := *b
+					created.ID = 1
+					created.CreatedAt = sql.NullTime{Time: now, Valid: true}
+					return &created
+				}, nil)
+
+			mockTransactor := new(MockTransactor)
+			mockTransactor.On("WithinTransaction", ctx, mock.Anything).
+				Return(func(ctx context.Context, fn func(context.Context) error) error {
+					return fn(ctx)
+				})
+
+			service := NewAuctionService(mockTransactor, mockAuctionBidRepo, new(MockPurchaseRepo), mockWagerRepo,
+				mockWalletRepo, logger.Discard(), metrics.New(), events.NewBroker(1), NewSellOutPolicy(SellingPercentageRule{}))
+			service.now = func() time.Time { return now }
+
+			bid, err := service.PlaceBid(ctx, tc.input)
+
+			assert.Equal(t, tc.expectedError, err)
+			assert.Equal(t, tc.expectedRes, bid)
+			if tc.expectedBid != nil {
+				mockAuctionBidRepo.AssertCalled(t, "CreateAuctionBid", ctx, tc.expectedBid)
+			} else {
+				mockAuctionBidRepo.AssertNotCalled(t, "CreateAuctionBid", ctx, mock.Anything)
+			}
+		})
+	}
+}
+
+func TestAuctionService_ListBids(t *testing.T) {
+	now := time.Now()
+	repoErr := errors.New("some error")
+	bids := []repo.AuctionBid{
+		{ID: 1, WagerID: 111, UserID: 5, Price: money.MustParse("30"), Quantity: 2, Awarded: 1, CreatedAt: sql.NullTime{Time: now, Valid: true}},
+	}
+
+	for _, tc := range []struct {
+		name           string
+		wagerRepoError error
+		bidRepoError   error
+
+		expectedRes   []dto.AuctionBid
+		expectedError error
+	}{
+		{
+			name: "happy path",
+			expectedRes: []dto.AuctionBid{
+				{ID: 1, WagerID: 111, Price: money.MustParse("30"), Quantity: 2, Awarded: 1, PlacedAt: &now},
+			},
+		},
+		{
+			name:           "wager not found",
+			wagerRepoError: sql.ErrNoRows,
+			expectedError:  &app_errors.ErrorResponse{Status: http.StatusNotFound, Code: app_errors.ErrNotFound},
+		},
+		{
+			name:          "repo error",
+			bidRepoError:  repoErr,
+			expectedError: repoErr,
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			ctx := context.Background()
+			mockWagerRepo := new(MockWagerRepo)
+			mockWagerRepo.On("GetWagerByID", ctx, uint32(111)).
+				Return(auctionWager(repo.WagerAuctionFirstPrice, now), tc.wagerRepoError)
+
+			var repoBids []repo.AuctionBid
+			if tc.bidRepoError == nil {
+				repoBids = bids
+			}
+
+			mockAuctionBidRepo := new(MockAuctionBidRepo)
+			mockAuctionBidRepo.On("ListUserAuctionBids", ctx, uint32(111), uint32(5)).Return(repoBids, tc.bidRepoError)
+
+			service := NewAuctionService(new(MockTransactor), mockAuctionBidRepo, new(MockPurchaseRepo), mockWagerRepo,
+				new(MockWalletRepo), logger.Discard(), metrics.New(), events.NewBroker(1), NewSellOutPolicy(SellingPercentageRule{}))
+
+			res, err := service.ListBids(ctx, &dto.AuctionBidsRequest{UserID: 5, WagerID: 111})
+
+			assert.Equal(t, tc.expectedError, err)
+			assert.Equal(t, tc.expectedRes, res)
+		})
+	}
+}
+
+func TestAuctionService_CloseDueAuctions(t *testing.T) {
+	now := time.Now()
+	endsAt := now.Add(-time.Minute)
+
+	// closed returns wager auctioned by auction after amount units were awarded at last price
+	closed := func(auction repo.WagerAuction, amount uint32, price string, status repo.WagerStatus) *repo.Wager {
+		w := auctionWager(auction, endsAt)
+		w.AuctionClosedAt = sql.NullTime{Time: now, Valid: true}
+		if amount > 0 {
+			w.AmountSold = sql.NullInt32{Int32: int32(amount), Valid: true}
+			w.PercentageSold = sql.NullFloat64{Float64: float64(amount) * 10, Valid: true}
+			w.CurrentSellingPrice = money.MustParse(price)
+			w.PricedAt = sql.NullTime{Time: now, Valid: true}
+		}
+
+		w.Status = status
+		return w
+	}
+
+	bid := func(id, userID uint32, price string, quantity uint32) repo.AuctionBid {
+		return repo.AuctionBid{ID: id, WagerID: 111, UserID: userID, Price: money.MustParse(price), Quantity: quantity}
+	}
+
+	awarded := func(b repo.AuctionBid, awarded uint32) repo.AuctionBid {
+		b.Awarded = awarded
+		return b
+	}
+
+	purchase := func(userID uint32, price string, quantity uint32) repo.Purchase {
+		p := money.MustParse(price)
+		return repo.Purchase{WagerID: 111, BuyingPrice: p, BuyerID: toNullID(userID), Quantity: quantity, TotalPrice: p * money.Money(quantity)}
+	}
+
+	for _, tc := range []struct {
+		name string
+
+		wagerRepoResp *repo.Wager
+		// bids of wager in award order
+		bids []repo.AuctionBid
+		// balances of user accounts, account id is user id * 10
+		balances map[uint32]money.Money
+
+		expectedPurchases  []repo.Purchase
+		expectedBidUpdates []repo.AuctionBid
+		updateWagerRepoReq *repo.Wager
+		expectedClosed     int
+	}{
+		{
+			name:          "first price winners pay their bids",
+			wagerRepoResp: auctionWager(repo.WagerAuctionFirstPrice, endsAt),
+			bids:          []repo.AuctionBid{bid(1, 5, "40", 1), bid(2, 6, "35", 3), bid(3, 7, "30", 1)},
+			balances:      map[uint32]money.Money{5: money.MustParse("40"), 6: money.MustParse("105"), 7: money.MustParse("30"), 9: 0},
+			expectedPurchases: []repo.Purchase{
+				purchase(5, "40", 1),
+				purchase(6, "35", 2),
+			},
+			expectedBidUpdates: []repo.AuctionBid{awarded(bid(1, 5, "40", 1), 1), awarded(bid(2, 6, "35", 3), 2)},
+			updateWagerRepoReq: closed(repo.WagerAuctionFirstPrice, 3, "35", repo.WagerStatusSoldOut),
+			expectedClosed:     1,
+		},
+		{
+			name:          "second price winners pay highest losing bid",
+			wagerRepoResp: auctionWager(repo.WagerAuctionSecondPrice, endsAt),
+			bids:          []repo.AuctionBid{bid(1, 5, "40", 1), bid(2, 6, "35", 2), bid(3, 7, "30", 1)},
+			balances:      map[uint32]money.Money{5: money.MustParse("40"), 6: money.MustParse("70"), 7: money.MustParse("30"), 9: 0},
+			expectedPurchases: []repo.Purchase{
+				purchase(5, "30", 1),
+				purchase(6, "30", 2),
+			},
+			expectedBidUpdates: []repo.AuctionBid{awarded(bid(1, 5, "40", 1), 1), awarded(bid(2, 6, "35", 2), 2)},
+			updateWagerRepoReq: closed(repo.WagerAuctionSecondPrice, 3, "30", repo.WagerStatusSoldOut),
+			expectedClosed:     1,
+		},
+		{
+			name:          "second price winners pay partly awarded bid",
+			wagerRepoResp: auctionWager(repo.WagerAuctionSecondPrice, endsAt),
+			bids:          []repo.AuctionBid{bid(1, 5, "40", 2), bid(2, 6, "35", 2), bid(3, 7, "30", 1)},
+			balances:      map[uint32]money.Money{5: money.MustParse("80"), 6: money.MustParse("70"), 7: money.MustParse("30"), 9: 0},
+			expectedPurchases: []repo.Purchase{
+				purchase(5, "35", 2),
+				purchase(6, "35", 1),
+			},
+			expectedBidUpdates: []repo.AuctionBid{awarded(bid(1, 5, "40", 2), 2), awarded(bid(2, 6, "35", 2), 1)},
+			updateWagerRepoReq: closed(repo.WagerAuctionSecondPrice, 3, "35", repo.WagerStatusSoldOut),
+			expectedClosed:     1,
+		},
+		{
+			name:          "second price winners pay lowest winning bid when all bids win",
+			wagerRepoResp: auctionWager(repo.WagerAuctionSecondPrice, endsAt),
+			bids:          []repo.AuctionBid{bid(1, 5, "40", 1), bid(2, 6, "35", 1)},
+			balances:      map[uint32]money.Money{5: money.MustParse("40"), 6: money.MustParse("35"), 9: 0},
+			expectedPurchases: []repo.Purchase{
+				purchase(5, "35", 1),
+				purchase(6, "35", 1),
+			},
+			expectedBidUpdates: []repo.AuctionBid{awarded(bid(1, 5, "40", 1), 1), awarded(bid(2, 6, "35", 1), 1)},
+			updateWagerRepoReq: closed(repo.WagerAuctionSecondPrice, 2, "35", repo.WagerStatusOpen),
+			expectedClosed:     1,
+		},
+		{
+			name:          "unpaid bids are skipped",
+			wagerRepoResp: auctionWager(repo.WagerAuctionSecondPrice, endsAt),
+			// second bid of user 5 exceeds balance left by the first one
+			bids:     []repo.AuctionBid{bid(1, 6, "50", 1), bid(2, 5, "40", 2), bid(3, 5, "35", 1), bid(4, 7, "30", 2)},
+			balances: map[uint32]money.Money{5: money.MustParse("100"), 6: money.MustParse("49.99"), 7: money.MustParse("60"), 9: 0},
+			expectedPurchases: []repo.Purchase{
+				purchase(5, "30", 2),
+				purchase(7, "30", 1),
+			},
+			expectedBidUpdates: []repo.AuctionBid{awarded(bid(2, 5, "40", 2), 2), awarded(bid(4, 7, "30", 2), 1)},
+			updateWagerRepoReq: closed(repo.WagerAuctionSecondPrice, 3, "30", repo.WagerStatusSoldOut),
+			expectedClosed:     1,
+		},
+		{
+			name:               "auction without bids",
+			wagerRepoResp:      auctionWager(repo.WagerAuctionFirstPrice, endsAt),
+			updateWagerRepoReq: closed(repo.WagerAuctionFirstPrice, 0, "", repo.WagerStatusOpen),
+			expectedClosed:     1,
+		},
+		{
+			name: "settled wager is closed without awards",
+			wagerRepoResp: func() *repo.Wager {
+				w := auctionWager(repo.WagerAuctionFirstPrice, endsAt)
+				w.Status = repo.WagerStatusVoided
+				return w
+			}(),
+			bids:               []repo.AuctionBid{bid(1, 5, "40", 1)},
+			updateWagerRepoReq: closed(repo.WagerAuctionFirstPrice, 0, "", repo.WagerStatusVoided),
+			expectedClosed:     1,
+		},
+		{
+			name: "auction closed meanwhile",
+			wagerRepoResp: func() *repo.Wager {
+				w := auctionWager(repo.WagerAuctionFirstPrice, endsAt)
+				w.AuctionClosedAt = sql.NullTime{Time: endsAt, Valid: true}
+				return w
+			}(),
+			bids: []repo.AuctionBid{bid(1, 5, "40", 1)},
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			ctx := context.Background()
+			mockWagerRepo := new(MockWagerRepo)
+			mockWagerRepo.On("ListDueAuctions", ctx, now).
+				Return([]repo.Wager{*auctionWager(tc.wagerRepoResp.Auction, endsAt)}, nil)
+			mockWagerRepo.On("GetWagerByIDForUpdate", ctx, uint32(111)).Return(tc.wagerRepoResp, nil)
+			mockWagerRepo.On("UpdateWager", ctx, mock.Anything).Return(nil)
+
+			var bidUpdates []repo.AuctionBid
+			mockAuctionBidRepo := new(MockAuctionBidRepo)
+			mockAuctionBidRepo.On("ListAuctionBids", ctx, uint32(111)).Return(tc.bids, nil)
+			mockAuctionBidRepo.On("UpdateAuctionBid", ctx, mock.Anything).
+				Run(func(args mock.Arguments) { bidUpdates = append(bidUpdates, *args.Get(1).(*repo.AuctionBid)) }).
+				Return(nil)
+
+			var purchases []repo.Purchase
+			mockPurchaseRepo := new(MockPurchaseRepo)
+			mockPurchaseRepo.On("CreatePurchase", ctx, mock.Anything).
+				Return(func(_ context.Context, p *repo.Purchase) *repo.Purchase {
+					purchases = append(purchases, *p)
+					created := *p
+					created.ID = uint32(len(purchases))
+					return &created
+				}, nil)
+
+			mockWalletRepo := new(MockWalletRepo)
+			for userID, balance := range tc.balances {
+				mockWalletRepo.On("GetAccountByUserIDForUpdate", ctx, userID).
+					Return(&repo.Account{ID: userID * 10, Kind: repo.AccountKindUser, Balance: balance}, nil)
+			}
+
+			mockWalletRepo.On("CreateLedgerTransaction", ctx, mock.Anything).Return(&repo.LedgerTransaction{}, nil)
+
+			mockTransactor := new(MockTransactor)
+			mockTransactor.On("WithinTransaction", ctx, mock.Anything).
+				Return(func(ctx context.Context, fn func(context.Context) error) error {
+					return fn(ctx)
+				})
+
+			appMetrics := metrics.New()
+			service := NewAuctionService(mockTransactor, mockAuctionBidRepo, mockPurchaseRepo, mockWagerRepo,
+				mockWalletRepo, logger.Discard(), appMetrics, events.NewBroker(1), NewSellOutPolicy(SellingPercentageRule{}))
+			service.now = func() time.Time { return now }
+
+			count, err := service.CloseDueAuctions(ctx)
+
+			assert.Nil(t, err)
+			assert.Equal(t, tc.expectedClosed, count)
+			assert.Equal(t, tc.expectedPurchases, purchases)
+			assert.Equal(t, tc.expectedBidUpdates, bidUpdates)
+			assert.Equal(t, float64(tc.expectedClosed), appMetrics.AuctionsClosed.Value())
+			assert.Equal(t, float64(len(tc.expectedPurchases)), appMetrics.WagerPurchases.Value())
+			if tc.updateWagerRepoReq != nil {
+				mockWagerRepo.AssertCalled(t, "UpdateWager", ctx, tc.updateWagerRepoReq)
+			} else {
+				mockWagerRepo.AssertNotCalled(t, "UpdateWager", ctx, mock.Anything)
+			}
+		})
+	}
+}
+
+func TestAuctionService_CloseDueAuctions_Error(t *testing.T) {
+	now := time.Now()
+	repoErr := errors.New("some error")
+	ctx := context.Background()
+
+	mockWagerRepo := new(MockWagerRepo)
+	mockWagerRepo.On("ListDueAuctions", ctx, now).Return([]repo.Wager{
+		{ID: 111, Auction: repo.WagerAuctionFirstPrice},
+		{ID: 112, Auction: repo.WagerAuctionFirstPrice},
+	}, nil)
+	mockWagerRepo.On("GetWagerByIDForUpdate", ctx, uint32(111)).Return(nil, repoErr)
+	mockWagerRepo.On("GetWagerByIDForUpdate", ctx, uint32(112)).
+		Return(auctionWager(repo.WagerAuctionFirstPrice, now), nil)
+	mockWagerRepo.On("UpdateWager", ctx, mock.Anything).Return(nil)
+
+	mockAuctionBidRepo := new(MockAuctionBidRepo)
+	mockAuctionBidRepo.On("ListAuctionBids", ctx, mock.Anything).Return(nil, nil)
+
+	mockTransactor := new(MockTransactor)
+	mockTransactor.On("WithinTransaction", ctx, mock.Anything).
+		Return(func(ctx context.Context, fn func(context.Context) error) error {
+			return fn(ctx)
+		})
+
+	service := NewAuctionService(mockTransactor, mockAuctionBidRepo, new(MockPurchaseRepo), mockWagerRepo,
+		new(MockWalletRepo), logger.Discard(), metrics.New(), events.NewBroker(1), NewSellOutPolicy(SellingPercentageRule{}))
+	service.now = func() time.Time { return now }
+
+	// failing auction does not hold others back
+	count, err := service.CloseDueAuctions(ctx)
+
+	assert.Equal(t, repoErr, err)
+	assert.Equal(t, 1, count)
+}
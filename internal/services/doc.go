@@ -3,3 +3,12 @@ package services
 // Generate dependencies mocks for services
 //go:generate mockery --name=IWagerRepo --structname=MockWagerRepo --dir ../repo --filename generated_mock_wager_repo_test.go --testonly --output . --outpkg services
 //go:generate mockery --name=IPurchaseRepo --structname=MockPurchaseRepo --dir ../repo --filename generated_mock_purchase_repo_test.go --testonly --output . --outpkg services
+//go:generate mockery --name=ITransactor --structname=MockTransactor --dir ../repo --filename generated_mock_transactor_test.go --testonly --output . --outpkg services
+//go:generate mockery --name=ISettlementRepo --structname=MockSettlementRepo --dir ../repo --filename generated_mock_settlement_repo_test.go --testonly --output . --outpkg services
+//go:generate mockery --name=IUserRepo --structname=MockUserRepo --dir ../repo --filename generated_mock_user_repo_test.go --testonly --output . --outpkg services
+//go:generate mockery --name=IWalletRepo --structname=MockWalletRepo --dir ../repo --filename generated_mock_wallet_repo_test.go --testonly --output . --outpkg services
+//go:generate mockery --name=IIdempotencyRepo --structname=MockIdempotencyRepo --dir ../repo --filename generated_mock_idempotency_repo_test.go --testonly --output . --outpkg services
+//go:generate mockery --name=IHealthRepo --structname=MockHealthRepo --dir ../repo --filename generated_mock_health_repo_test.go --testonly --output . --outpkg services
+//go:generate mockery --name=IOrderRepo --structname=MockOrderRepo --dir ../repo --filename generated_mock_order_repo_test.go --testonly --output . --outpkg services
+//go:generate mockery --name=IAuctionBidRepo --structname=MockAuctionBidRepo --dir ../repo --filename generated_mock_auction_bid_repo_test.go --testonly --output . --outpkg services
+//go:generate mockery --name=IMigrator --structname=MockMigrator --dir . --filename generated_mock_migrator_test.go --testonly --output . --outpkg services
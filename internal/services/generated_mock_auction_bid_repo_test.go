@@ -0,0 +1,111 @@
+// Code generated by mockery v2.12.2. DO NOT EDIT.
+
+package services
+
+import (
+	context "context"
+
+	mock "github.com/stretchr/testify/mock"
+
+	repo "github.com/vitthalaa/wager-app/internal/repo"
+
+	testing "testing"
+)
+
+// MockAuctionBidRepo is an autogenerated mock type for the IAuctionBidRepo type
+type MockAuctionBidRepo struct {
+	mock.Mock
+}
+
+// CreateAuctionBid provides a mock function with given fields: ctx, bid
+func (_m *MockAuctionBidRepo) CreateAuctionBid(ctx context.Context, bid *repo.AuctionBid) (*repo.AuctionBid, error) {
+	ret := _m.Called(ctx, bid)
+
+	var r0 *repo.AuctionBid
+	if rf, ok := ret.Get(0).(func(context.Context, *repo.AuctionBid) *repo.AuctionBid); ok {
+		r0 = rf(ctx, bid)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*repo.AuctionBid)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, *repo.AuctionBid) error); ok {
+		r1 = rf(ctx, bid)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// ListAuctionBids provides a mock function with given fields: ctx, wagerID
+func (_m *MockAuctionBidRepo) ListAuctionBids(ctx context.Context, wagerID uint32) ([]repo.AuctionBid, error) {
+	ret := _m.Called(ctx, wagerID)
+
+	var r0 []repo.AuctionBid
+	if rf, ok := ret.Get(0).(func(context.Context, uint32) []repo.AuctionBid); ok {
+		r0 = rf(ctx, wagerID)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]repo.AuctionBid)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, uint32) error); ok {
+		r1 = rf(ctx, wagerID)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// ListUserAuctionBids provides a mock function with given fields: ctx, wagerID, userID
+func (_m *MockAuctionBidRepo) ListUserAuctionBids(ctx context.Context, wagerID uint32, userID uint32) ([]repo.AuctionBid, error) {
+	ret := _m.Called(ctx, wagerID, userID)
+
+	var r0 []repo.AuctionBid
+	if rf, ok := ret.Get(0).(func(context.Context, uint32, uint32) []repo.AuctionBid); ok {
+		r0 = rf(ctx, wagerID, userID)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]repo.AuctionBid)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, uint32, uint32) error); ok {
+		r1 = rf(ctx, wagerID, userID)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// UpdateAuctionBid provides a mock function with given fields: ctx, bid
+func (_m *MockAuctionBidRepo) UpdateAuctionBid(ctx context.Context, bid *repo.AuctionBid) error {
+	ret := _m.Called(ctx, bid)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, *repo.AuctionBid) error); ok {
+		r0 = rf(ctx, bid)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// NewMockAuctionBidRepo creates a new instance of MockAuctionBidRepo. It also registers the testing.TB interface on the mock and a cleanup function to assert the mocks expectations.
+func NewMockAuctionBidRepo(t testing.TB) *MockAuctionBidRepo {
+	mock := &MockAuctionBidRepo{}
+	mock.Mock.Test(t)
+
+	t.Cleanup(func() { mock.AssertExpectations(t) })
+
+	return mock
+}
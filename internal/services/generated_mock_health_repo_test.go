@@ -0,0 +1,40 @@
+// Code generated by mockery v2.12.2. DO NOT EDIT.
+
+package services
+
+import (
+	context "context"
+
+	mock "github.com/stretchr/testify/mock"
+
+	testing "testing"
+)
+
+// MockHealthRepo is an autogenerated mock type for the IHealthRepo type
+type MockHealthRepo struct {
+	mock.Mock
+}
+
+// Ping provides a mock function with given fields: ctx
+func (_m *MockHealthRepo) Ping(ctx context.Context) error {
+	ret := _m.Called(ctx)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context) error); ok {
+		r0 = rf(ctx)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// NewMockHealthRepo creates a new instance of MockHealthRepo. It also registers the testing.TB interface on the mock and a cleanup function to assert the mocks expectations.
+func NewMockHealthRepo(t testing.TB) *MockHealthRepo {
+	mock := &MockHealthRepo{}
+	mock.Mock.Test(t)
+
+	t.Cleanup(func() { mock.AssertExpectations(t) })
+
+	return mock
+}
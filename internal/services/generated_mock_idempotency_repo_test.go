@@ -0,0 +1,125 @@
+// Code generated by mockery v2.12.2. DO NOT EDIT.
+
+package services
+
+import (
+	context "context"
+
+	mock "github.com/stretchr/testify/mock"
+
+	repo "github.com/vitthalaa/wager-app/internal/repo"
+
+	testing "testing"
+
+	time "time"
+)
+
+// MockIdempotencyRepo is an autogenerated mock type for the IIdempotencyRepo type
+type MockIdempotencyRepo struct {
+	mock.Mock
+}
+
+// CreateKey provides a mock function with given fields: ctx, key, now
+func (_m *MockIdempotencyRepo) CreateKey(ctx context.Context, key *repo.IdempotencyKey, now time.Time) (*repo.IdempotencyKey, error) {
+	ret := _m.Called(ctx, key, now)
+
+	var r0 *repo.IdempotencyKey
+	if rf, ok := ret.Get(0).(func(context.Context, *repo.IdempotencyKey, time.Time) *repo.IdempotencyKey); ok {
+		r0 = rf(ctx, key, now)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*repo.IdempotencyKey)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, *repo.IdempotencyKey, time.Time) error); ok {
+		r1 = rf(ctx, key, now)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// DeleteExpiredKeys provides a mock function with given fields: ctx, now
+func (_m *MockIdempotencyRepo) DeleteExpiredKeys(ctx context.Context, now time.Time) (int64, error) {
+	ret := _m.Called(ctx, now)
+
+	var r0 int64
+	if rf, ok := ret.Get(0).(func(context.Context, time.Time) int64); ok {
+		r0 = rf(ctx, now)
+	} else {
+		r0 = ret.Get(0).(int64)
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, time.Time) error); ok {
+		r1 = rf(ctx, now)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// DeleteKey provides a mock function with given fields: ctx, userID, key
+func (_m *MockIdempotencyRepo) DeleteKey(ctx context.Context, userID uint32, key string) error {
+	ret := _m.Called(ctx, userID, key)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, uint32, string) error); ok {
+		r0 = rf(ctx, userID, key)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// GetKey provides a mock function with given fields: ctx, userID, key, now
+func (_m *MockIdempotencyRepo) GetKey(ctx context.Context, userID uint32, key string, now time.Time) (*repo.IdempotencyKey, error) {
+	ret := _m.Called(ctx, userID, key, now)
+
+	var r0 *repo.IdempotencyKey
+	if rf, ok := ret.Get(0).(func(context.Context, uint32, string, time.Time) *repo.IdempotencyKey); ok {
+		r0 = rf(ctx, userID, key, now)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*repo.IdempotencyKey)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, uint32, string, time.Time) error); ok {
+		r1 = rf(ctx, userID, key, now)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// SaveResponse provides a mock function with given fields: ctx, userID, key, status, body
+func (_m *MockIdempotencyRepo) SaveResponse(ctx context.Context, userID uint32, key string, status int, body string) error {
+	ret := _m.Called(ctx, userID, key, status, body)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, uint32, string, int, string) error); ok {
+		r0 = rf(ctx, userID, key, status, body)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// NewMockIdempotencyRepo creates a new instance of MockIdempotencyRepo. It also registers the testing.TB interface on the mock and a cleanup function to assert the mocks expectations.
+func NewMockIdempotencyRepo(t testing.TB) *MockIdempotencyRepo {
+	mock := &MockIdempotencyRepo{}
+	mock.Mock.Test(t)
+
+	t.Cleanup(func() { mock.AssertExpectations(t) })
+
+	return mock
+}
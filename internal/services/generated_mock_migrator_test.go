@@ -0,0 +1,51 @@
+// Code generated by mockery v2.12.2. DO NOT EDIT.
+
+package services
+
+import (
+	context "context"
+
+	mock "github.com/stretchr/testify/mock"
+
+	migrate "github.com/vitthalaa/wager-app/internal/migrate"
+
+	testing "testing"
+)
+
+// MockMigrator is an autogenerated mock type for the IMigrator type
+type MockMigrator struct {
+	mock.Mock
+}
+
+// Pending provides a mock function with given fields: ctx
+func (_m *MockMigrator) Pending(ctx context.Context) ([]migrate.Migration, error) {
+	ret := _m.Called(ctx)
+
+	var r0 []migrate.Migration
+	if rf, ok := ret.Get(0).(func(context.Context) []migrate.Migration); ok {
+		r0 = rf(ctx)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]migrate.Migration)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context) error); ok {
+		r1 = rf(ctx)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// NewMockMigrator creates a new instance of MockMigrator. It also registers the testing.TB interface on the mock and a cleanup function to assert the mocks expectations.
+func NewMockMigrator(t testing.TB) *MockMigrator {
+	mock := &MockMigrator{}
+	mock.Mock.Test(t)
+
+	t.Cleanup(func() { mock.AssertExpectations(t) })
+
+	return mock
+}
@@ -0,0 +1,173 @@
+// Code generated by mockery v2.12.2. DO NOT EDIT.
+
+package services
+
+import (
+	context "context"
+
+	mock "github.com/stretchr/testify/mock"
+
+	repo "github.com/vitthalaa/wager-app/internal/repo"
+
+	money "github.com/vitthalaa/wager-app/money"
+
+	testing "testing"
+)
+
+// MockOrderRepo is an autogenerated mock type for the IOrderRepo type
+type MockOrderRepo struct {
+	mock.Mock
+}
+
+// CancelOpenOrders provides a mock function with given fields: ctx, wagerID
+func (_m *MockOrderRepo) CancelOpenOrders(ctx context.Context, wagerID uint32) error {
+	ret := _m.Called(ctx, wagerID)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, uint32) error); ok {
+		r0 = rf(ctx, wagerID)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// CreateOrder provides a mock function with given fields: ctx, order
+func (_m *MockOrderRepo) CreateOrder(ctx context.Context, order *repo.Order) (*repo.Order, error) {
+	ret := _m.Called(ctx, order)
+
+	var r0 *repo.Order
+	if rf, ok := ret.Get(0).(func(context.Context, *repo.Order) *repo.Order); ok {
+		r0 = rf(ctx, order)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*repo.Order)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, *repo.Order) error); ok {
+		r1 = rf(ctx, order)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetOrderByID provides a mock function with given fields: ctx, id
+func (_m *MockOrderRepo) GetOrderByID(ctx context.Context, id uint32) (*repo.Order, error) {
+	ret := _m.Called(ctx, id)
+
+	var r0 *repo.Order
+	if rf, ok := ret.Get(0).(func(context.Context, uint32) *repo.Order); ok {
+		r0 = rf(ctx, id)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*repo.Order)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, uint32) error); ok {
+		r1 = rf(ctx, id)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// ListOpenOrders provides a mock function with given fields: ctx, wagerID, side, crossing
+func (_m *MockOrderRepo) ListOpenOrders(ctx context.Context, wagerID uint32, side repo.OrderSide, crossing money.Money) ([]repo.Order, error) {
+	ret := _m.Called(ctx, wagerID, side, crossing)
+
+	var r0 []repo.Order
+	if rf, ok := ret.Get(0).(func(context.Context, uint32, repo.OrderSide, money.Money) []repo.Order); ok {
+		r0 = rf(ctx, wagerID, side, crossing)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]repo.Order)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, uint32, repo.OrderSide, money.Money) error); ok {
+		r1 = rf(ctx, wagerID, side, crossing)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// ListOrderBook provides a mock function with given fields: ctx, wagerID, side, levels
+func (_m *MockOrderRepo) ListOrderBook(ctx context.Context, wagerID uint32, side repo.OrderSide, levels uint32) ([]repo.OrderBookLevel, error) {
+	ret := _m.Called(ctx, wagerID, side, levels)
+
+	var r0 []repo.OrderBookLevel
+	if rf, ok := ret.Get(0).(func(context.Context, uint32, repo.OrderSide, uint32) []repo.OrderBookLevel); ok {
+		r0 = rf(ctx, wagerID, side, levels)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]repo.OrderBookLevel)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, uint32, repo.OrderSide, uint32) error); ok {
+		r1 = rf(ctx, wagerID, side, levels)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// ListUserOpenOrders provides a mock function with given fields: ctx, userID, side
+func (_m *MockOrderRepo) ListUserOpenOrders(ctx context.Context, userID uint32, side repo.OrderSide) ([]repo.Order, error) {
+	ret := _m.Called(ctx, userID, side)
+
+	var r0 []repo.Order
+	if rf, ok := ret.Get(0).(func(context.Context, uint32, repo.OrderSide) []repo.Order); ok {
+		r0 = rf(ctx, userID, side)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]repo.Order)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, uint32, repo.OrderSide) error); ok {
+		r1 = rf(ctx, userID, side)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// UpdateOrder provides a mock function with given fields: ctx, order
+func (_m *MockOrderRepo) UpdateOrder(ctx context.Context, order *repo.Order) error {
+	ret := _m.Called(ctx, order)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, *repo.Order) error); ok {
+		r0 = rf(ctx, order)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// NewMockOrderRepo creates a new instance of MockOrderRepo. It also registers the testing.TB interface on the mock and a cleanup function to assert the mocks expectations.
+func NewMockOrderRepo(t testing.TB) *MockOrderRepo {
+	mock := &MockOrderRepo{}
+	mock.Mock.Test(t)
+
+	t.Cleanup(func() { mock.AssertExpectations(t) })
+
+	return mock
+}
@@ -17,6 +17,27 @@ type MockPurchaseRepo struct {
 	mock.Mock
 }
 
+// CountPurchases provides a mock function with given fields: ctx, filter
+func (_m *MockPurchaseRepo) CountPurchases(ctx context.Context, filter repo.PurchaseFilter) (uint32, error) {
+	ret := _m.Called(ctx, filter)
+
+	var r0 uint32
+	if rf, ok := ret.Get(0).(func(context.Context, repo.PurchaseFilter) uint32); ok {
+		r0 = rf(ctx, filter)
+	} else {
+		r0 = ret.Get(0).(uint32)
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, repo.PurchaseFilter) error); ok {
+		r1 = rf(ctx, filter)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
 // CreatePurchase provides a mock function with given fields: ctx, purchase
 func (_m *MockPurchaseRepo) CreatePurchase(ctx context.Context, purchase *repo.Purchase) (*repo.Purchase, error) {
 	ret := _m.Called(ctx, purchase)
@@ -54,6 +75,52 @@ func (_m *MockPurchaseRepo) DeletePurchase(ctx context.Context, id uint32) error
 	return r0
 }
 
+// GetPurchaseByID provides a mock function with given fields: ctx, id
+func (_m *MockPurchaseRepo) GetPurchaseByID(ctx context.Context, id uint32) (*repo.Purchase, error) {
+	ret := _m.Called(ctx, id)
+
+	var r0 *repo.Purchase
+	if rf, ok := ret.Get(0).(func(context.Context, uint32) *repo.Purchase); ok {
+		r0 = rf(ctx, id)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*repo.Purchase)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, uint32) error); ok {
+		r1 = rf(ctx, id)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// ListPurchases provides a mock function with given fields: ctx, filter
+func (_m *MockPurchaseRepo) ListPurchases(ctx context.Context, filter repo.PurchaseFilter) ([]repo.Purchase, error) {
+	ret := _m.Called(ctx, filter)
+
+	var r0 []repo.Purchase
+	if rf, ok := ret.Get(0).(func(context.Context, repo.PurchaseFilter) []repo.Purchase); ok {
+		r0 = rf(ctx, filter)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]repo.Purchase)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, repo.PurchaseFilter) error); ok {
+		r1 = rf(ctx, filter)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
 // NewMockPurchaseRepo creates a new instance of MockPurchaseRepo. It also registers the testing.TB interface on the mock and a cleanup function to assert the mocks expectations.
 func NewMockPurchaseRepo(t testing.TB) *MockPurchaseRepo {
 	mock := &MockPurchaseRepo{}
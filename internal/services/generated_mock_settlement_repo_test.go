@@ -0,0 +1,74 @@
+// Code generated by mockery v2.12.2. DO NOT EDIT.
+
+package services
+
+import (
+	context "context"
+
+	mock "github.com/stretchr/testify/mock"
+
+	repo "github.com/vitthalaa/wager-app/internal/repo"
+
+	testing "testing"
+)
+
+// MockSettlementRepo is an autogenerated mock type for the ISettlementRepo type
+type MockSettlementRepo struct {
+	mock.Mock
+}
+
+// CreateSettlement provides a mock function with given fields: ctx, settlement
+func (_m *MockSettlementRepo) CreateSettlement(ctx context.Context, settlement *repo.Settlement) (*repo.Settlement, error) {
+	ret := _m.Called(ctx, settlement)
+
+	var r0 *repo.Settlement
+	if rf, ok := ret.Get(0).(func(context.Context, *repo.Settlement) *repo.Settlement); ok {
+		r0 = rf(ctx, settlement)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*repo.Settlement)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, *repo.Settlement) error); ok {
+		r1 = rf(ctx, settlement)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// ListSettlementsByWagerID provides a mock function with given fields: ctx, wagerID
+func (_m *MockSettlementRepo) ListSettlementsByWagerID(ctx context.Context, wagerID uint32) ([]repo.Settlement, error) {
+	ret := _m.Called(ctx, wagerID)
+
+	var r0 []repo.Settlement
+	if rf, ok := ret.Get(0).(func(context.Context, uint32) []repo.Settlement); ok {
+		r0 = rf(ctx, wagerID)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]repo.Settlement)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, uint32) error); ok {
+		r1 = rf(ctx, wagerID)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// NewMockSettlementRepo creates a new instance of MockSettlementRepo. It also registers the testing.TB interface on the mock and a cleanup function to assert the mocks expectations.
+func NewMockSettlementRepo(t testing.TB) *MockSettlementRepo {
+	mock := &MockSettlementRepo{}
+	mock.Mock.Test(t)
+
+	t.Cleanup(func() { mock.AssertExpectations(t) })
+
+	return mock
+}
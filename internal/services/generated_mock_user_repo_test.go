@@ -0,0 +1,74 @@
+// Code generated by mockery v2.12.2. DO NOT EDIT.
+
+package services
+
+import (
+	context "context"
+
+	mock "github.com/stretchr/testify/mock"
+
+	repo "github.com/vitthalaa/wager-app/internal/repo"
+
+	testing "testing"
+)
+
+// MockUserRepo is an autogenerated mock type for the IUserRepo type
+type MockUserRepo struct {
+	mock.Mock
+}
+
+// CreateUser provides a mock function with given fields: ctx, user
+func (_m *MockUserRepo) CreateUser(ctx context.Context, user *repo.User) (*repo.User, error) {
+	ret := _m.Called(ctx, user)
+
+	var r0 *repo.User
+	if rf, ok := ret.Get(0).(func(context.Context, *repo.User) *repo.User); ok {
+		r0 = rf(ctx, user)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*repo.User)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, *repo.User) error); ok {
+		r1 = rf(ctx, user)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetUserByUsername provides a mock function with given fields: ctx, username
+func (_m *MockUserRepo) GetUserByUsername(ctx context.Context, username string) (*repo.User, error) {
+	ret := _m.Called(ctx, username)
+
+	var r0 *repo.User
+	if rf, ok := ret.Get(0).(func(context.Context, string) *repo.User); ok {
+		r0 = rf(ctx, username)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*repo.User)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, string) error); ok {
+		r1 = rf(ctx, username)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// NewMockUserRepo creates a new instance of MockUserRepo. It also registers the testing.TB interface on the mock and a cleanup function to assert the mocks expectations.
+func NewMockUserRepo(t testing.TB) *MockUserRepo {
+	mock := &MockUserRepo{}
+	mock.Mock.Test(t)
+
+	t.Cleanup(func() { mock.AssertExpectations(t) })
+
+	return mock
+}
@@ -10,6 +10,8 @@ import (
 	repo "github.com/vitthalaa/wager-app/internal/repo"
 
 	testing "testing"
+
+	time "time"
 )
 
 // MockWagerRepo is an autogenerated mock type for the IWagerRepo type
@@ -63,13 +65,59 @@ func (_m *MockWagerRepo) GetWagerByID(ctx context.Context, wagerID uint32) (*rep
 	return r0, r1
 }
 
-// ListWager provides a mock function with given fields: ctx, offset, limit
-func (_m *MockWagerRepo) ListWager(ctx context.Context, offset uint32, limit uint32) ([]repo.Wager, error) {
-	ret := _m.Called(ctx, offset, limit)
+// GetWagerByIDForUpdate provides a mock function with given fields: ctx, wagerID
+func (_m *MockWagerRepo) GetWagerByIDForUpdate(ctx context.Context, wagerID uint32) (*repo.Wager, error) {
+	ret := _m.Called(ctx, wagerID)
+
+	var r0 *repo.Wager
+	if rf, ok := ret.Get(0).(func(context.Context, uint32) *repo.Wager); ok {
+		r0 = rf(ctx, wagerID)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*repo.Wager)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, uint32) error); ok {
+		r1 = rf(ctx, wagerID)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// ListDueAuctions provides a mock function with given fields: ctx, at
+func (_m *MockWagerRepo) ListDueAuctions(ctx context.Context, at time.Time) ([]repo.Wager, error) {
+	ret := _m.Called(ctx, at)
+
+	var r0 []repo.Wager
+	if rf, ok := ret.Get(0).(func(context.Context, time.Time) []repo.Wager); ok {
+		r0 = rf(ctx, at)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]repo.Wager)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, time.Time) error); ok {
+		r1 = rf(ctx, at)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// ListWager provides a mock function with given fields: ctx, filter
+func (_m *MockWagerRepo) ListWager(ctx context.Context, filter repo.WagerFilter) ([]repo.Wager, error) {
+	ret := _m.Called(ctx, filter)
 
 	var r0 []repo.Wager
-	if rf, ok := ret.Get(0).(func(context.Context, uint32, uint32) []repo.Wager); ok {
-		r0 = rf(ctx, offset, limit)
+	if rf, ok := ret.Get(0).(func(context.Context, repo.WagerFilter) []repo.Wager); ok {
+		r0 = rf(ctx, filter)
 	} else {
 		if ret.Get(0) != nil {
 			r0 = ret.Get(0).([]repo.Wager)
@@ -77,8 +125,8 @@ func (_m *MockWagerRepo) ListWager(ctx context.Context, offset uint32, limit uin
 	}
 
 	var r1 error
-	if rf, ok := ret.Get(1).(func(context.Context, uint32, uint32) error); ok {
-		r1 = rf(ctx, offset, limit)
+	if rf, ok := ret.Get(1).(func(context.Context, repo.WagerFilter) error); ok {
+		r1 = rf(ctx, filter)
 	} else {
 		r1 = ret.Error(1)
 	}
@@ -86,6 +134,20 @@ func (_m *MockWagerRepo) ListWager(ctx context.Context, offset uint32, limit uin
 	return r0, r1
 }
 
+// SettleWager provides a mock function with given fields: ctx, wager
+func (_m *MockWagerRepo) SettleWager(ctx context.Context, wager *repo.Wager) error {
+	ret := _m.Called(ctx, wager)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, *repo.Wager) error); ok {
+		r0 = rf(ctx, wager)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
 // UpdateWager provides a mock function with given fields: ctx, wager
 func (_m *MockWagerRepo) UpdateWager(ctx context.Context, wager *repo.Wager) error {
 	ret := _m.Called(ctx, wager)
@@ -0,0 +1,210 @@
+// Code generated by mockery v2.12.2. DO NOT EDIT.
+
+package services
+
+import (
+	context "context"
+
+	mock "github.com/stretchr/testify/mock"
+
+	repo "github.com/vitthalaa/wager-app/internal/repo"
+
+	testing "testing"
+)
+
+// MockWalletRepo is an autogenerated mock type for the IWalletRepo type
+type MockWalletRepo struct {
+	mock.Mock
+}
+
+// CheckLedger provides a mock function with given fields: ctx
+func (_m *MockWalletRepo) CheckLedger(ctx context.Context) (*repo.LedgerCheck, error) {
+	ret := _m.Called(ctx)
+
+	var r0 *repo.LedgerCheck
+	if rf, ok := ret.Get(0).(func(context.Context) *repo.LedgerCheck); ok {
+		r0 = rf(ctx)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*repo.LedgerCheck)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context) error); ok {
+		r1 = rf(ctx)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// CountLedgerEntries provides a mock function with given fields: ctx, accountID
+func (_m *MockWalletRepo) CountLedgerEntries(ctx context.Context, accountID uint32) (uint32, error) {
+	ret := _m.Called(ctx, accountID)
+
+	var r0 uint32
+	if rf, ok := ret.Get(0).(func(context.Context, uint32) uint32); ok {
+		r0 = rf(ctx, accountID)
+	} else {
+		r0 = ret.Get(0).(uint32)
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, uint32) error); ok {
+		r1 = rf(ctx, accountID)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// CreateAccount provides a mock function with given fields: ctx, account
+func (_m *MockWalletRepo) CreateAccount(ctx context.Context, account *repo.Account) (*repo.Account, error) {
+	ret := _m.Called(ctx, account)
+
+	var r0 *repo.Account
+	if rf, ok := ret.Get(0).(func(context.Context, *repo.Account) *repo.Account); ok {
+		r0 = rf(ctx, account)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*repo.Account)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, *repo.Account) error); ok {
+		r1 = rf(ctx, account)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// CreateLedgerTransaction provides a mock function with given fields: ctx, transaction
+func (_m *MockWalletRepo) CreateLedgerTransaction(ctx context.Context, transaction *repo.LedgerTransaction) (*repo.LedgerTransaction, error) {
+	ret := _m.Called(ctx, transaction)
+
+	var r0 *repo.LedgerTransaction
+	if rf, ok := ret.Get(0).(func(context.Context, *repo.LedgerTransaction) *repo.LedgerTransaction); ok {
+		r0 = rf(ctx, transaction)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*repo.LedgerTransaction)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, *repo.LedgerTransaction) error); ok {
+		r1 = rf(ctx, transaction)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetAccountByUserID provides a mock function with given fields: ctx, userID
+func (_m *MockWalletRepo) GetAccountByUserID(ctx context.Context, userID uint32) (*repo.Account, error) {
+	ret := _m.Called(ctx, userID)
+
+	var r0 *repo.Account
+	if rf, ok := ret.Get(0).(func(context.Context, uint32) *repo.Account); ok {
+		r0 = rf(ctx, userID)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*repo.Account)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, uint32) error); ok {
+		r1 = rf(ctx, userID)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetAccountByUserIDForUpdate provides a mock function with given fields: ctx, userID
+func (_m *MockWalletRepo) GetAccountByUserIDForUpdate(ctx context.Context, userID uint32) (*repo.Account, error) {
+	ret := _m.Called(ctx, userID)
+
+	var r0 *repo.Account
+	if rf, ok := ret.Get(0).(func(context.Context, uint32) *repo.Account); ok {
+		r0 = rf(ctx, userID)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*repo.Account)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, uint32) error); ok {
+		r1 = rf(ctx, userID)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetExternalAccount provides a mock function with given fields: ctx
+func (_m *MockWalletRepo) GetExternalAccount(ctx context.Context) (*repo.Account, error) {
+	ret := _m.Called(ctx)
+
+	var r0 *repo.Account
+	if rf, ok := ret.Get(0).(func(context.Context) *repo.Account); ok {
+		r0 = rf(ctx)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*repo.Account)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context) error); ok {
+		r1 = rf(ctx)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// ListLedgerEntries provides a mock function with given fields: ctx, accountID, offset, limit
+func (_m *MockWalletRepo) ListLedgerEntries(ctx context.Context, accountID uint32, offset uint32, limit uint32) ([]repo.LedgerEntry, error) {
+	ret := _m.Called(ctx, accountID, offset, limit)
+
+	var r0 []repo.LedgerEntry
+	if rf, ok := ret.Get(0).(func(context.Context, uint32, uint32, uint32) []repo.LedgerEntry); ok {
+		r0 = rf(ctx, accountID, offset, limit)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]repo.LedgerEntry)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, uint32, uint32, uint32) error); ok {
+		r1 = rf(ctx, accountID, offset, limit)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// NewMockWalletRepo creates a new instance of MockWalletRepo. It also registers the testing.TB interface on the mock and a cleanup function to assert the mocks expectations.
+func NewMockWalletRepo(t testing.TB) *MockWalletRepo {
+	mock := &MockWalletRepo{}
+	mock.Mock.Test(t)
+
+	t.Cleanup(func() { mock.AssertExpectations(t) })
+
+	return mock
+}
@@ -0,0 +1,110 @@
+package services
+
+import (
+	"context"
+	"sync/atomic"
+	"time"
+
+	"github.com/vitthalaa/wager-app/dto"
+	"github.com/vitthalaa/wager-app/internal/logger"
+	"github.com/vitthalaa/wager-app/internal/migrate"
+	"github.com/vitthalaa/wager-app/internal/repo"
+)
+
+// Readiness checks
+const (
+	checkDatabase   = "database"
+	checkMigrations = "migrations"
+	checkShutdown   = "shutdown"
+)
+
+// IHealthService ...
+type IHealthService interface {
+	Live(ctx context.Context) *dto.Health
+	Ready(ctx context.Context) *dto.Health
+	ShutDown()
+}
+
+// IMigrator returns schema migrations not applied yet, implemented by *migrate.Migrator
+type IMigrator interface {
+	Pending(ctx context.Context) ([]migrate.Migration, error)
+}
+
+// NewHealthService ...
+// migrator is nil for storage without schema, timeout limits each readiness check.
+func NewHealthService(
+	healthRepo repo.IHealthRepo, migrator IMigrator, timeout time.Duration, log *logger.Logger,
+) *HealthService {
+	return &HealthService{
+		healthRepo: healthRepo,
+		migrator:   migrator,
+		timeout:    timeout,
+		log:        log,
+	}
+}
+
+// HealthService ...
+type HealthService struct {
+	healthRepo repo.IHealthRepo
+	migrator   IMigrator
+	timeout    time.Duration
+	log        *logger.Logger
+	// shuttingDown is set to 1 once shutdown started
+	shuttingDown int32
+}
+
+// Live reports process is alive and serving requests
+func (s *HealthService) Live(ctx context.Context) *dto.Health {
+	return &dto.Health{Status: dto.HealthOK}
+}
+
+// Ready reports app can take traffic: db is reachable, schema is migrated and shutdown has not started
+func (s *HealthService) Ready(ctx context.Context) *dto.Health {
+	ctx, cancel := context.WithTimeout(ctx, s.timeout)
+	defer cancel()
+
+	health := &dto.Health{
+		Status: dto.HealthOK,
+		Checks: map[string]string{
+			checkDatabase:   dto.HealthOK,
+			checkMigrations: dto.HealthOK,
+			checkShutdown:   dto.HealthOK,
+		},
+	}
+
+	fail := func(check string) {
+		health.Status = dto.HealthFailing
+		health.Checks[check] = dto.HealthFailing
+	}
+
+	if atomic.LoadInt32(&s.shuttingDown) == 1 {
+		fail(checkShutdown)
+	}
+
+	if err := s.healthRepo.Ping(ctx); err != nil {
+		s.log.Warn(ctx, "readiness check failed", "check", checkDatabase, "error", err)
+		fail(checkDatabase)
+		// migrations can not be checked without db
+		fail(checkMigrations)
+		return health
+	}
+
+	if s.migrator != nil {
+		pending, err := s.migrator.Pending(ctx)
+		switch {
+		case err != nil:
+			s.log.Warn(ctx, "readiness check failed", "check", checkMigrations, "error", err)
+			fail(checkMigrations)
+		case len(pending) > 0:
+			s.log.Warn(ctx, "readiness check failed", "check", checkMigrations, "pending", len(pending))
+			fail(checkMigrations)
+		}
+	}
+
+	return health
+}
+
+// ShutDown makes app not ready, so that load balancers stop sending traffic before server shuts down
+func (s *HealthService) ShutDown() {
+	atomic.StoreInt32(&s.shuttingDown, 1)
+}
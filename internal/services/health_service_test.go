@@ -0,0 +1,102 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+
+	"github.com/vitthalaa/wager-app/dto"
+	"github.com/vitthalaa/wager-app/internal/logger"
+	"github.com/vitthalaa/wager-app/internal/migrate"
+)
+
+func TestHealthService_Ready(t *testing.T) {
+	for _, tc := range []struct {
+		name string
+
+		pingError      error
+		pending        []migrate.Migration
+		pendingError   error
+		withoutSchema  bool
+		shuttingDown   bool
+		expectedHealth *dto.Health
+	}{
+		{
+			name: "ready",
+			expectedHealth: &dto.Health{Status: "ok", Checks: map[string]string{
+				"database": "ok", "migrations": "ok", "shutdown": "ok",
+			}},
+		},
+		{
+			name:          "storage without schema",
+			withoutSchema: true,
+			expectedHealth: &dto.Health{Status: "ok", Checks: map[string]string{
+				"database": "ok", "migrations": "ok", "shutdown": "ok",
+			}},
+		},
+		{
+			name:      "db unreachable",
+			pingError: errors.New("connection refused"),
+			expectedHealth: &dto.Health{Status: "failing", Checks: map[string]string{
+				"database": "failing", "migrations": "failing", "shutdown": "ok",
+			}},
+		},
+		{
+			name:    "pending migrations",
+			pending: []migrate.Migration{{Version: 7, Name: "new_table"}},
+			expectedHealth: &dto.Health{Status: "failing", Checks: map[string]string{
+				"database": "ok", "migrations": "failing", "shutdown": "ok",
+			}},
+		},
+		{
+			name:         "migration status error",
+			pendingError: errors.New("some migrate error"),
+			expectedHealth: &dto.Health{Status: "failing", Checks: map[string]string{
+				"database": "ok", "migrations": "failing", "shutdown": "ok",
+			}},
+		},
+		{
+			name:         "shutting down",
+			shuttingDown: true,
+			expectedHealth: &dto.Health{Status: "failing", Checks: map[string]string{
+				"database": "ok", "migrations": "ok", "shutdown": "failing",
+			}},
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			mockHealthRepo := new(MockHealthRepo)
+			mockHealthRepo.On("Ping", mock.Anything).Return(tc.pingError)
+
+			var migrator IMigrator
+			if !tc.withoutSchema {
+				mockMigrator := new(MockMigrator)
+				mockMigrator.On("Pending", mock.Anything).Return(tc.pending, tc.pendingError)
+				migrator = mockMigrator
+			}
+
+			service := NewHealthService(mockHealthRepo, migrator, time.Second, logger.Discard())
+			if tc.shuttingDown {
+				service.ShutDown()
+			}
+
+			assert.Equal(t, tc.expectedHealth, service.Ready(context.Background()))
+			assert.Equal(t, &dto.Health{Status: "ok"}, service.Live(context.Background()))
+		})
+	}
+}
+
+func TestHealthService_Ready_Timeout(t *testing.T) {
+	mockHealthRepo := new(MockHealthRepo)
+	mockHealthRepo.On("Ping", mock.Anything).Return(func(ctx context.Context) error {
+		<-ctx.Done()
+		return ctx.Err()
+	})
+
+	service := NewHealthService(mockHealthRepo, nil, 10*time.Millisecond, logger.Discard())
+
+	assert.False(t, service.Ready(context.Background()).OK())
+}
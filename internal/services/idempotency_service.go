@@ -0,0 +1,104 @@
+package services
+
+import (
+	"context"
+	"database/sql"
+	"net/http"
+	"time"
+
+	"github.com/vitthalaa/wager-app/app_errors"
+	"github.com/vitthalaa/wager-app/dto"
+	"github.com/vitthalaa/wager-app/internal/logger"
+	"github.com/vitthalaa/wager-app/internal/repo"
+)
+
+// maxIdempotencyKeyLength is length of idempotency_key column
+const maxIdempotencyKeyLength = 255
+
+// IIdempotencyService ...
+type IIdempotencyService interface {
+	Begin(ctx context.Context, req *dto.IdempotentRequest) (*dto.IdempotentResponse, error)
+	Complete(ctx context.Context, req *dto.IdempotentRequest, res *dto.IdempotentResponse) error
+	Release(ctx context.Context, req *dto.IdempotentRequest) error
+	DeleteExpired(ctx context.Context) (int64, error)
+}
+
+// NewIdempotencyService ...
+func NewIdempotencyService(idempotencyRepo repo.IIdempotencyRepo, ttl time.Duration, log *logger.Logger) *IdempotencyService {
+	return &IdempotencyService{
+		idempotencyRepo: idempotencyRepo,
+		ttl:             ttl,
+		log:             log,
+		now:             time.Now,
+	}
+}
+
+// IdempotencyService records responses of requests by Idempotency-Key, so retried requests are not executed twice
+type IdempotencyService struct {
+	idempotencyRepo repo.IIdempotencyRepo
+	ttl             time.Duration
+	log             *logger.Logger
+	now             func() time.Time
+}
+
+// Begin reserves key of user for request. It returns recorded response if key was already used by same request,
+// the request must be executed only when both response and error are nil.
+func (s *IdempotencyService) Begin(ctx context.Context, req *dto.IdempotentRequest) (*dto.IdempotentResponse, error) {
+	if req.Key == "" || len(req.Key) > maxIdempotencyKeyLength {
+		return nil, &app_errors.ErrorResponse{Status: http.StatusBadRequest, Code: app_errors.ErrInvalidIdempotencyKey}
+	}
+
+	now := s.now()
+	_, err := s.idempotencyRepo.CreateKey(ctx, &repo.IdempotencyKey{
+		UserID:      req.UserID,
+		Key:         req.Key,
+		RequestHash: req.RequestHash,
+		ExpiresAt:   now.Add(s.ttl),
+	}, now)
+	if err == nil {
+		return nil, nil
+	}
+
+	if err != repo.ErrIdempotencyKeyExists {
+		return nil, err
+	}
+
+	key, err := s.idempotencyRepo.GetKey(ctx, req.UserID, req.Key, now)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			// key was released or expired meanwhile, client retries
+			return nil, &app_errors.ErrorResponse{Status: http.StatusConflict, Code: app_errors.ErrIdempotencyKeyInProgress}
+		}
+
+		return nil, err
+	}
+
+	if key.RequestHash != req.RequestHash {
+		return nil, &app_errors.ErrorResponse{Status: http.StatusUnprocessableEntity, Code: app_errors.ErrIdempotencyKeyReused}
+	}
+
+	if !key.ResponseStatus.Valid {
+		return nil, &app_errors.ErrorResponse{Status: http.StatusConflict, Code: app_errors.ErrIdempotencyKeyInProgress}
+	}
+
+	s.log.Info(ctx, "idempotent response replayed", "user_id", req.UserID, "idempotency_key", req.Key)
+	return &dto.IdempotentResponse{
+		Status: int(key.ResponseStatus.Int32),
+		Body:   []byte(key.ResponseBody.String),
+	}, nil
+}
+
+// Complete records response of request reserved by Begin
+func (s *IdempotencyService) Complete(ctx context.Context, req *dto.IdempotentRequest, res *dto.IdempotentResponse) error {
+	return s.idempotencyRepo.SaveResponse(ctx, req.UserID, req.Key, res.Status, string(res.Body))
+}
+
+// Release frees key reserved by Begin without response, so request can be retried with same key
+func (s *IdempotencyService) Release(ctx context.Context, req *dto.IdempotentRequest) error {
+	return s.idempotencyRepo.DeleteKey(ctx, req.UserID, req.Key)
+}
+
+// DeleteExpired deletes expired keys, returns number of deleted keys
+func (s *IdempotencyService) DeleteExpired(ctx context.Context) (int64, error) {
+	return s.idempotencyRepo.DeleteExpiredKeys(ctx, s.now())
+}
@@ -0,0 +1,119 @@
+package services
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/vitthalaa/wager-app/app_errors"
+	"github.com/vitthalaa/wager-app/dto"
+	"github.com/vitthalaa/wager-app/internal/logger"
+	"github.com/vitthalaa/wager-app/internal/repo"
+)
+
+func TestIdempotencyService_Begin(t *testing.T) {
+	now := time.Date(2024, 5, 1, 10, 0, 0, 0, time.UTC)
+	for _, tc := range []struct {
+		name string
+		key  string
+
+		createError error
+		storedKey   *repo.IdempotencyKey
+		getError    error
+
+		expectedRes   *dto.IdempotentResponse
+		expectedError error
+	}{
+		{
+			name: "new key",
+			key:  "key-1",
+		},
+		{
+			name:        "replay recorded response",
+			key:         "key-1",
+			createError: repo.ErrIdempotencyKeyExists,
+			storedKey: &repo.IdempotencyKey{
+				RequestHash:    "hash-1",
+				ResponseStatus: sql.NullInt32{Int32: 200, Valid: true},
+				ResponseBody:   sql.NullString{String: `{"id":1}`, Valid: true},
+			},
+			expectedRes: &dto.IdempotentResponse{Status: 200, Body: []byte(`{"id":1}`)},
+		},
+		{
+			name:          "key reused by different request",
+			key:           "key-1",
+			createError:   repo.ErrIdempotencyKeyExists,
+			storedKey:     &repo.IdempotencyKey{RequestHash: "hash-2"},
+			expectedError: &app_errors.ErrorResponse{Status: http.StatusUnprocessableEntity, Code: app_errors.ErrIdempotencyKeyReused},
+		},
+		{
+			name:          "first request in progress",
+			key:           "key-1",
+			createError:   repo.ErrIdempotencyKeyExists,
+			storedKey:     &repo.IdempotencyKey{RequestHash: "hash-1"},
+			expectedError: &app_errors.ErrorResponse{Status: http.StatusConflict, Code: app_errors.ErrIdempotencyKeyInProgress},
+		},
+		{
+			name:          "key released meanwhile",
+			key:           "key-1",
+			createError:   repo.ErrIdempotencyKeyExists,
+			getError:      sql.ErrNoRows,
+			expectedError: &app_errors.ErrorResponse{Status: http.StatusConflict, Code: app_errors.ErrIdempotencyKeyInProgress},
+		},
+		{
+			name:          "too long key",
+			key:           strings.Repeat("k", 256),
+			expectedError: &app_errors.ErrorResponse{Status: http.StatusBadRequest, Code: app_errors.ErrInvalidIdempotencyKey},
+		},
+		{
+			name:          "repo error",
+			key:           "key-1",
+			createError:   errors.New("some repo error"),
+			expectedError: errors.New("some repo error"),
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			ctx := context.Background()
+			mockIdempotencyRepo := new(MockIdempotencyRepo)
+			mockIdempotencyRepo.On("CreateKey", ctx, &repo.IdempotencyKey{
+				UserID:      5,
+				Key:         tc.key,
+				RequestHash: "hash-1",
+				ExpiresAt:   now.Add(time.Hour),
+			}, now).Return(nil, tc.createError)
+			mockIdempotencyRepo.On("GetKey", ctx, uint32(5), tc.key, now).
+				Return(tc.storedKey, tc.getError)
+
+			service := NewIdempotencyService(mockIdempotencyRepo, time.Hour, logger.Discard())
+			service.now = func() time.Time { return now }
+
+			res, err := service.Begin(ctx, &dto.IdempotentRequest{UserID: 5, Key: tc.key, RequestHash: "hash-1"})
+
+			assert.Equal(t, tc.expectedError, err)
+			assert.Equal(t, tc.expectedRes, res)
+		})
+	}
+}
+
+func TestIdempotencyService_CompleteRelease(t *testing.T) {
+	ctx := context.Background()
+	req := &dto.IdempotentRequest{UserID: 5, Key: "key-1", RequestHash: "hash-1"}
+
+	mockIdempotencyRepo := new(MockIdempotencyRepo)
+	mockIdempotencyRepo.On("SaveResponse", ctx, uint32(5), "key-1", 406, `{"error":"WAGER_SOLD_OUT"}`).
+		Return(nil)
+	mockIdempotencyRepo.On("DeleteKey", ctx, uint32(5), "key-1").
+		Return(nil)
+
+	service := NewIdempotencyService(mockIdempotencyRepo, time.Hour, logger.Discard())
+
+	assert.Nil(t, service.Complete(ctx, req, &dto.IdempotentResponse{Status: 406, Body: []byte(`{"error":"WAGER_SOLD_OUT"}`)}))
+	assert.Nil(t, service.Release(ctx, req))
+	mockIdempotencyRepo.AssertExpectations(t)
+}
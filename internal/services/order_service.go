@@ -0,0 +1,459 @@
+package services
+
+import (
+	"context"
+	"database/sql"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/vitthalaa/wager-app/app_errors"
+	"github.com/vitthalaa/wager-app/dto"
+	"github.com/vitthalaa/wager-app/internal/events"
+	"github.com/vitthalaa/wager-app/internal/logger"
+	"github.com/vitthalaa/wager-app/internal/metrics"
+	"github.com/vitthalaa/wager-app/internal/repo"
+	"github.com/vitthalaa/wager-app/internal/validation"
+	"github.com/vitthalaa/wager-app/money"
+)
+
+const (
+	defaultOrderBookLevels = 10
+	maxOrderBookLevels     = 100
+)
+
+// IOrderService ...
+type IOrderService interface {
+	PlaceOrder(ctx context.Context, req *dto.PlaceOrderRequest) (*dto.Order, error)
+	GetOrder(ctx context.Context, req *dto.OrderRequest) (*dto.Order, error)
+	CancelOrder(ctx context.Context, req *dto.OrderRequest) (*dto.Order, error)
+	GetOrderBook(ctx context.Context, req *dto.GetOrderBookRequest) (*dto.OrderBook, error)
+}
+
+// NewOrderService ...
+func NewOrderService(
+	transactor repo.ITransactor, orderRepo repo.IOrderRepo, purchaseRepo repo.IPurchaseRepo, wagerRepo repo.IWagerRepo,
+	walletRepo repo.IWalletRepo, log *logger.Logger, metrics *metrics.Metrics, broker *events.Broker,
+	sellOut *SellOutPolicy,
+) *OrderService {
+	return &OrderService{
+		transactor:   transactor,
+		orderRepo:    orderRepo,
+		purchaseRepo: purchaseRepo,
+		wagerRepo:    wagerRepo,
+		walletRepo:   walletRepo,
+		log:          log,
+		metrics:      metrics,
+		events:       broker,
+		sellOut:      sellOut,
+		now:          time.Now,
+	}
+}
+
+// OrderService keeps order books of wagers and matches their orders
+type OrderService struct {
+	transactor   repo.ITransactor
+	orderRepo    repo.IOrderRepo
+	purchaseRepo repo.IPurchaseRepo
+	wagerRepo    repo.IWagerRepo
+	walletRepo   repo.IWalletRepo
+	log          *logger.Logger
+	metrics      *metrics.Metrics
+	events       *events.Broker
+	sellOut      *SellOutPolicy
+	now          func() time.Time
+}
+
+// PlaceOrder places limit order and matches it against open orders of other side of wager book in price-time
+// priority. Crossed orders trade at price of resting order, every fill is purchase paid from bidder to seller.
+// Unfilled units stay open in the book until wager sells out. Open bids of user together may not exceed user balance.
+func (s *OrderService) PlaceOrder(ctx context.Context, req *dto.PlaceOrderRequest) (*dto.Order, error) {
+	if req == nil || req.UserID == 0 {
+		return nil, &app_errors.ErrorResponse{Status: http.StatusUnauthorized, Code: app_errors.ErrUnauthorized}
+	}
+
+	if errRes := validation.Struct(req).Err(); errRes != nil {
+		return nil, errRes
+	}
+
+	var order *repo.Order
+	var purchases []repo.Purchase
+	var wager *repo.Wager
+	err := s.transactor.WithinTransaction(ctx, func(ctx context.Context) error {
+		var err error
+		order, purchases, wager, err = s.placeOrder(ctx, req)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	s.log.Info(ctx, "order placed",
+		"order_id", order.ID, "wager_id", order.WagerID, "user_id", order.UserID, "side", order.Side,
+		"price", order.Price, "quantity", order.Quantity, "filled", order.Filled)
+
+	// published after commit, so subscribers never see changes of rolled back fills
+	if len(purchases) > 0 {
+		for range purchases {
+			s.metrics.WagerPurchases.Inc()
+		}
+
+		wagerDTO := toWagerDTO(*wager, wager.PricedAt.Time)
+		s.events.Publish(events.TypePriceChanged, wagerDTO)
+		if wager.Status == repo.WagerStatusSoldOut {
+			s.events.Publish(events.TypeSoldOut, wagerDTO)
+		}
+	}
+
+	orderDTO := toOrderDTO(*order, purchases)
+	return &orderDTO, nil
+}
+
+// GetOrder returns order of user by id, orders of other users are not found
+func (s *OrderService) GetOrder(ctx context.Context, req *dto.OrderRequest) (*dto.Order, error) {
+	order, err := s.getUserOrder(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+
+	orderDTO := toOrderDTO(*order, nil)
+	return &orderDTO, nil
+}
+
+// CancelOrder cancels open order of user, its unfilled units leave the book
+func (s *OrderService) CancelOrder(ctx context.Context, req *dto.OrderRequest) (*dto.Order, error) {
+	var order *repo.Order
+	err := s.transactor.WithinTransaction(ctx, func(ctx context.Context) error {
+		var err error
+		if order, err = s.getUserOrder(ctx, req); err != nil {
+			return err
+		}
+
+		// wager lock serializes cancel with matching of its book, order is read again under the lock
+		if _, err = s.wagerRepo.GetWagerByIDForUpdate(ctx, order.WagerID); err != nil {
+			return err
+		}
+
+		if order, err = s.orderRepo.GetOrderByID(ctx, order.ID); err != nil {
+			return err
+		}
+
+		if order.Status != repo.OrderStatusOpen {
+			return &app_errors.ErrorResponse{Status: http.StatusConflict, Code: app_errors.ErrOrderNotOpen}
+		}
+
+		order.Status = repo.OrderStatusCancelled
+		return s.orderRepo.UpdateOrder(ctx, order)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	s.log.Info(ctx, "order cancelled", "order_id", order.ID, "wager_id", order.WagerID, "user_id", order.UserID)
+
+	orderDTO := toOrderDTO(*order, nil)
+	return &orderDTO, nil
+}
+
+// GetOrderBook returns best price levels of open bids and asks of wager, book of wager which sells no more units
+// is empty
+func (s *OrderService) GetOrderBook(ctx context.Context, req *dto.GetOrderBookRequest) (*dto.OrderBook, error) {
+	if req.WagerID == 0 {
+		return nil, &app_errors.ErrorResponse{Status: http.StatusNotFound, Code: app_errors.ErrNotFound}
+	}
+
+	wager, err := s.wagerRepo.GetWagerByID(ctx, req.WagerID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, &app_errors.ErrorResponse{Status: http.StatusNotFound, Code: app_errors.ErrNotFound}
+		}
+
+		return nil, err
+	}
+
+	// orders left open by former versions can not be filled anymore
+	if !sellsUnits(*wager) {
+		return &dto.OrderBook{WagerID: req.WagerID, Bids: []dto.OrderBookLevel{}, Asks: []dto.OrderBookLevel{}}, nil
+	}
+
+	levels := req.Levels
+	if levels == 0 {
+		levels = defaultOrderBookLevels
+	}
+
+	if levels > maxOrderBookLevels {
+		levels = maxOrderBookLevels
+	}
+
+	bids, err := s.orderRepo.ListOrderBook(ctx, req.WagerID, repo.OrderSideBid, levels)
+	if err != nil {
+		return nil, err
+	}
+
+	asks, err := s.orderRepo.ListOrderBook(ctx, req.WagerID, repo.OrderSideAsk, levels)
+	if err != nil {
+		return nil, err
+	}
+
+	return &dto.OrderBook{
+		WagerID: req.WagerID,
+		Bids:    toOrderBookLevelDTOs(bids),
+		Asks:    toOrderBookLevelDTOs(asks),
+	}, nil
+}
+
+// getUserOrder returns order by id when it belongs to user
+func (s *OrderService) getUserOrder(ctx context.Context, req *dto.OrderRequest) (*repo.Order, error) {
+	if req.OrderID == 0 {
+		return nil, &app_errors.ErrorResponse{Status: http.StatusNotFound, Code: app_errors.ErrNotFound}
+	}
+
+	order, err := s.orderRepo.GetOrderByID(ctx, req.OrderID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, &app_errors.ErrorResponse{Status: http.StatusNotFound, Code: app_errors.ErrNotFound}
+		}
+
+		return nil, err
+	}
+
+	if order.UserID != req.UserID {
+		return nil, &app_errors.ErrorResponse{Status: http.StatusNotFound, Code: app_errors.ErrNotFound}
+	}
+
+	return order, nil
+}
+
+// placeOrder locks wager row, validates order against it, records order and fills it from crossing resting orders.
+// Returns order, purchases of its fills and updated wager. Must be called within transaction, wager lock serializes
+// matching of the book.
+func (s *OrderService) placeOrder(
+	ctx context.Context, req *dto.PlaceOrderRequest,
+) (*repo.Order, []repo.Purchase, *repo.Wager, error) {
+	wager, err := s.wagerRepo.GetWagerByIDForUpdate(ctx, req.WagerID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil, nil, &app_errors.ErrorResponse{Status: http.StatusNotFound, Code: app_errors.ErrNotFound}
+		}
+
+		return nil, nil, nil, err
+	}
+
+	if wager.Status.Closed() {
+		return nil, nil, nil, &app_errors.ErrorResponse{Status: http.StatusConflict, Code: app_errors.ErrWagerSettled}
+	}
+
+	if wager.InAuction() {
+		return nil, nil, nil, &app_errors.ErrorResponse{Status: http.StatusConflict, Code: app_errors.ErrAuctionOpen}
+	}
+
+	side := repo.OrderSide(req.Side)
+	if side == repo.OrderSideAsk && (!wager.SellerID.Valid || uint32(wager.SellerID.Int32) != req.UserID) {
+		return nil, nil, nil, &app_errors.ErrorResponse{Status: http.StatusForbidden, Code: app_errors.ErrNotWagerSeller}
+	}
+
+	remaining, rule := s.sellOut.Remaining(*wager)
+	if remaining == 0 {
+		s.metrics.SoldOutRejections.Inc()
+		return nil, nil, nil, &app_errors.ErrorResponse{Status: http.StatusNotAcceptable, Code: rule.Code()}
+	}
+
+	if req.Quantity > remaining {
+		errs := &validation.Errors{}
+		errs.Add(app_errors.ErrInvalidQuantity,
+			validation.Violation("quantity", validation.Rule{Name: "max", Limit: strconv.FormatUint(uint64(remaining), 10)}))
+		return nil, nil, nil, errs.Err()
+	}
+
+	opposite := repo.OrderSideAsk
+	if side == repo.OrderSideAsk {
+		opposite = repo.OrderSideBid
+	}
+
+	resting, err := s.orderRepo.ListOpenOrders(ctx, wager.ID, opposite, req.Price)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+
+	// all accounts which may take part in fills are locked at once in user id order
+	userIDs := []uint32{req.UserID}
+	if wager.SellerID.Valid {
+		userIDs = append(userIDs, uint32(wager.SellerID.Int32))
+	}
+
+	for _, r := range resting {
+		userIDs = append(userIDs, r.UserID)
+	}
+
+	accounts, err := lockUserAccounts(ctx, s.walletRepo, userIDs...)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+
+	total, err := totalPrice(req.Price, req.Quantity)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+
+	if side == repo.OrderSideBid {
+		committed, err := openBidsTotal(ctx, s.orderRepo, req.UserID)
+		if err != nil {
+			return nil, nil, nil, err
+		}
+
+		if accounts[req.UserID].Balance-committed < total {
+			return nil, nil, nil, &app_errors.ErrorResponse{Status: http.StatusPaymentRequired, Code: app_errors.ErrInsufficientFunds}
+		}
+	}
+
+	order, err := s.orderRepo.CreateOrder(ctx, &repo.Order{
+		WagerID:  wager.ID,
+		UserID:   req.UserID,
+		Side:     side,
+		Price:    req.Price,
+		Quantity: req.Quantity,
+	})
+	if err != nil {
+		return nil, nil, nil, err
+	}
+
+	purchases, err := s.match(ctx, wager, order, resting, accounts)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+
+	if order.Filled > 0 {
+		if order.Left() == 0 {
+			order.Status = repo.OrderStatusFilled
+		}
+
+		if err = s.orderRepo.UpdateOrder(ctx, order); err != nil {
+			return nil, nil, nil, err
+		}
+
+		if err = s.wagerRepo.UpdateWager(ctx, wager); err != nil {
+			return nil, nil, nil, err
+		}
+
+		if err = closeOrderBook(ctx, s.orderRepo, *wager); err != nil {
+			return nil, nil, nil, err
+		}
+	}
+
+	return order, purchases, wager, nil
+}
+
+// openBidsTotal returns total price of units left in open bids of user over all wagers
+func openBidsTotal(ctx context.Context, orderRepo repo.IOrderRepo, userID uint32) (money.Money, error) {
+	bids, err := orderRepo.ListUserOpenOrders(ctx, userID, repo.OrderSideBid)
+	if err != nil {
+		return 0, err
+	}
+
+	var committed money.Money
+	for _, bid := range bids {
+		total, err := totalPrice(bid.Price, bid.Left())
+		if err != nil {
+			return 0, err
+		}
+
+		committed += total
+	}
+
+	return committed, nil
+}
+
+// closeOrderBook cancels open orders of wager which sells no more units
+func closeOrderBook(ctx context.Context, orderRepo repo.IOrderRepo, wager repo.Wager) error {
+	if sellsUnits(wager) {
+		return nil
+	}
+
+	return orderRepo.CancelOpenOrders(ctx, wager.ID)
+}
+
+// sellsUnits reports whether wager still sells units, it is neither sold out nor settled
+func sellsUnits(wager repo.Wager) bool {
+	return wager.Status != repo.WagerStatusSoldOut && !wager.Status.Closed()
+}
+
+// match fills order from resting orders of other side in their price-time priority at their prices, until order
+// is filled or wager is sold out by policy. Orders of same user do not trade with each other. Bids are checked
+// against balance when placed but do not reserve funds, so resting bids which can not be paid anymore after
+// purchases or withdrawals of bidder are cancelled. Updates order and wager in place.
+func (s *OrderService) match(
+	ctx context.Context, wager *repo.Wager, order *repo.Order, resting []repo.Order, accounts map[uint32]*repo.Account,
+) ([]repo.Purchase, error) {
+	now := s.now()
+	pricing := pricingOf(*wager)
+	seller := accounts[uint32(wager.SellerID.Int32)]
+
+	var purchases []repo.Purchase
+	for i := range resting {
+		r := &resting[i]
+		remaining, _ := s.sellOut.Remaining(*wager)
+		if order.Left() == 0 || remaining == 0 {
+			break
+		}
+
+		if r.UserID == order.UserID {
+			continue
+		}
+
+		quantity := order.Left()
+		if r.Left() < quantity {
+			quantity = r.Left()
+		}
+
+		if remaining < quantity {
+			quantity = remaining
+		}
+
+		bid := order
+		if order.Side == repo.OrderSideAsk {
+			bid = r
+		}
+
+		total, err := totalPrice(r.Price, quantity)
+		if err != nil {
+			return nil, err
+		}
+
+		buyer := accounts[bid.UserID]
+		if buyer.Balance < total {
+			r.Status = repo.OrderStatusCancelled
+			if err := s.orderRepo.UpdateOrder(ctx, r); err != nil {
+				return nil, err
+			}
+
+			continue
+		}
+
+		purchase, err := recordPurchase(ctx, s.purchaseRepo, s.walletRepo, &repo.Purchase{
+			WagerID:     wager.ID,
+			BuyingPrice: r.Price,
+			BuyerID:     toNullID(bid.UserID),
+			Quantity:    quantity,
+			TotalPrice:  total,
+		}, buyer, seller)
+		if err != nil {
+			return nil, err
+		}
+
+		markSold(wager, s.sellOut, pricing, r.Price, quantity, now)
+
+		order.Filled += quantity
+		r.Filled += quantity
+		if r.Left() == 0 {
+			r.Status = repo.OrderStatusFilled
+		}
+
+		if err = s.orderRepo.UpdateOrder(ctx, r); err != nil {
+			return nil, err
+		}
+
+		purchases = append(purchases, *purchase)
+	}
+
+	return purchases, nil
+}
@@ -0,0 +1,527 @@
+package services
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+
+	"github.com/vitthalaa/wager-app/app_errors"
+	"github.com/vitthalaa/wager-app/dto"
+	"github.com/vitthalaa/wager-app/internal/events"
+	"github.com/vitthalaa/wager-app/internal/logger"
+	"github.com/vitthalaa/wager-app/internal/metrics"
+	"github.com/vitthalaa/wager-app/internal/repo"
+	"github.com/vitthalaa/wager-app/money"
+)
+
+func TestOrderService_PlaceOrder(t *testing.T) {
+	now := time.Now()
+	wager := func() *repo.Wager {
+		return &repo.Wager{
+			ID:                  111,
+			TotalWagerValue:     100,
+			Odds:                2,
+			SellingPercentage:   20,
+			SellingPrice:        money.MustParse("26"),
+			CurrentSellingPrice: money.MustParse("26"),
+			SellerID:            sql.NullInt32{Int32: 9, Valid: true},
+			CreatedAt:           sql.NullTime{Time: now, Valid: true},
+		}
+	}
+
+	sold := func(amount uint32, price string) *repo.Wager {
+		w := wager()
+		w.AmountSold = sql.NullInt32{Int32: int32(amount), Valid: true}
+		w.PercentageSold = sql.NullFloat64{Float64: float64(amount), Valid: true}
+		w.CurrentSellingPrice = money.MustParse(price)
+		w.PricedAt = sql.NullTime{Time: now, Valid: true}
+		return w
+	}
+
+	for _, tc := range []struct {
+		name  string
+		input *dto.PlaceOrderRequest
+
+		wagerRepoResp  *repo.Wager
+		wagerRepoError error
+
+		// resting are open orders of other side crossing order price
+		resting []repo.Order
+		// balances of user accounts, account id is user id * 10
+		balances map[uint32]money.Money
+		// openBids are open bids of user placing order
+		openBids []repo.Order
+
+		expectedPurchases    []repo.Purchase
+		expectedOrderUpdates []repo.Order
+		updateWagerRepoReq   *repo.Wager
+		closesBook           bool
+
+		expectedRes   *dto.Order
+		expectedError error
+	}{
+		{
+			name:          "bid rests without crossing asks",
+			input:         &dto.PlaceOrderRequest{UserID: 5, WagerID: 111, Side: "bid", Price: money.MustParse("25"), Quantity: 2},
+			wagerRepoResp: wager(),
+			balances:      map[uint32]money.Money{5: money.MustParse("50"), 9: 0},
+			expectedRes: &dto.Order{
+				ID: 1, WagerID: 111, UserID: 5, Side: "bid", Price: money.MustParse("25"), Quantity: 2,
+				Status: "open", PlacedAt: &now,
+			},
+		},
+		{
+			name:          "bid fills asks in price-time priority at their prices",
+			input:         &dto.PlaceOrderRequest{UserID: 5, WagerID: 111, Side: "bid", Price: money.MustParse("25"), Quantity: 3},
+			wagerRepoResp: wager(),
+			resting: []repo.Order{
+				{ID: 2, WagerID: 111, UserID: 9, Side: repo.OrderSideAsk, Price: money.MustParse("20"), Quantity: 2, Status: repo.OrderStatusOpen},
+				{ID: 3, WagerID: 111, UserID: 9, Side: repo.OrderSideAsk, Price: money.MustParse("24"), Quantity: 2, Status: repo.OrderStatusOpen},
+			},
+			balances: map[uint32]money.Money{5: money.MustParse("100"), 9: 0},
+			expectedPurchases: []repo.Purchase{
+				{WagerID: 111, BuyingPrice: money.MustParse("20"), BuyerID: toNullID(5), Quantity: 2, TotalPrice: money.MustParse("40")},
+				{WagerID: 111, BuyingPrice: money.MustParse("24"), BuyerID: toNullID(5), Quantity: 1, TotalPrice: money.MustParse("24")},
+			},
+			expectedOrderUpdates: []repo.Order{
+				{ID: 2, WagerID: 111, UserID: 9, Side: repo.OrderSideAsk, Price: money.MustParse("20"), Quantity: 2, Filled: 2, Status: repo.OrderStatusFilled},
+				{ID: 3, WagerID: 111, UserID: 9, Side: repo.OrderSideAsk, Price: money.MustParse("24"), Quantity: 2, Filled: 1, Status: repo.OrderStatusOpen},
+				{
+					ID: 1, WagerID: 111, UserID: 5, Side: repo.OrderSideBid, Price: money.MustParse("25"), Quantity: 3, Filled: 3,
+					Status: repo.OrderStatusFilled, CreatedAt: sql.NullTime{Time: now, Valid: true},
+				},
+			},
+			updateWagerRepoReq: sold(3, "24"),
+			expectedRes: &dto.Order{
+				ID: 1, WagerID: 111, UserID: 5, Side: "bid", Price: money.MustParse("25"), Quantity: 3, Filled: 3,
+				Status: "filled", PlacedAt: &now,
+				Purchases: []dto.WagerPurchase{
+					{ID: 1, WagerID: 111, BuyingPrice: money.MustParse("20"), Quantity: 2, TotalPrice: money.MustParse("40"), BoughtAt: &now, BuyerID: 5},
+					{ID: 2, WagerID: 111, BuyingPrice: money.MustParse("24"), Quantity: 1, TotalPrice: money.MustParse("24"), BoughtAt: &now, BuyerID: 5},
+				},
+			},
+		},
+		{
+			name:          "ask cancels unpaid bids and skips own bids",
+			input:         &dto.PlaceOrderRequest{UserID: 9, WagerID: 111, Side: "ask", Price: money.MustParse("20"), Quantity: 2},
+			wagerRepoResp: wager(),
+			resting: []repo.Order{
+				{ID: 2, WagerID: 111, UserID: 5, Side: repo.OrderSideBid, Price: money.MustParse("23"), Quantity: 1, Status: repo.OrderStatusOpen},
+				{ID: 3, WagerID: 111, UserID: 9, Side: repo.OrderSideBid, Price: money.MustParse("22"), Quantity: 1, Status: repo.OrderStatusOpen},
+				{ID: 4, WagerID: 111, UserID: 7, Side: repo.OrderSideBid, Price: money.MustParse("21"), Quantity: 3, Status: repo.OrderStatusOpen},
+			},
+			balances: map[uint32]money.Money{5: money.MustParse("10"), 7: money.MustParse("100"), 9: 0},
+			expectedPurchases: []repo.Purchase{
+				{WagerID: 111, BuyingPrice: money.MustParse("21"), BuyerID: toNullID(7), Quantity: 2, TotalPrice: money.MustParse("42")},
+			},
+			expectedOrderUpdates: []repo.Order{
+				{ID: 2, WagerID: 111, UserID: 5, Side: repo.OrderSideBid, Price: money.MustParse("23"), Quantity: 1, Status: repo.OrderStatusCancelled},
+				{ID: 4, WagerID: 111, UserID: 7, Side: repo.OrderSideBid, Price: money.MustParse("21"), Quantity: 3, Filled: 2, Status: repo.OrderStatusOpen},
+				{
+					ID: 1, WagerID: 111, UserID: 9, Side: repo.OrderSideAsk, Price: money.MustParse("20"), Quantity: 2, Filled: 2,
+					Status: repo.OrderStatusFilled, CreatedAt: sql.NullTime{Time: now, Valid: true},
+				},
+			},
+			updateWagerRepoReq: sold(2, "21"),
+			expectedRes: &dto.Order{
+				ID: 1, WagerID: 111, UserID: 9, Side: "ask", Price: money.MustParse("20"), Quantity: 2, Filled: 2,
+				Status: "filled", PlacedAt: &now,
+				Purchases: []dto.WagerPurchase{
+					{ID: 1, WagerID: 111, BuyingPrice: money.MustParse("21"), Quantity: 2, TotalPrice: money.MustParse("42"), BoughtAt: &now, BuyerID: 7},
+				},
+			},
+		},
+		{
+			name:  "quantity above units left to sell",
+			input: &dto.PlaceOrderRequest{UserID: 5, WagerID: 111, Side: "bid", Price: money.MustParse("25"), Quantity: 2},
+			wagerRepoResp: func() *repo.Wager {
+				w := wager()
+				w.AmountSold = sql.NullInt32{Int32: 19, Valid: true}
+				return w
+			}(),
+			resting: []repo.Order{
+				{ID: 2, WagerID: 111, UserID: 9, Side: repo.OrderSideAsk, Price: money.MustParse("20"), Quantity: 5, Status: repo.OrderStatusOpen},
+			},
+			expectedError: &app_errors.ErrorResponse{
+				Status: http.StatusBadRequest, Code: app_errors.ErrInvalidQuantity,
+				Details: []app_errors.FieldError{
+					{Field: "quantity", Rule: "max", Message: "must be at most 1", Limits: map[string]string{"max": "1"}},
+				},
+			},
+		},
+		{
+			name:          "unauthorized",
+			input:         &dto.PlaceOrderRequest{WagerID: 111, Side: "bid", Price: money.MustParse("25"), Quantity: 1},
+			expectedError: &app_errors.ErrorResponse{Status: http.StatusUnauthorized, Code: app_errors.ErrUnauthorized},
+		},
+		{
+			name:  "invalid side",
+			input: &dto.PlaceOrderRequest{UserID: 5, WagerID: 111, Side: "buy", Price: money.MustParse("25"), Quantity: 1},
+			expectedError: &app_errors.ErrorResponse{
+				Status: http.StatusBadRequest, Code: app_errors.ErrInvalidOrderSide,
+				Details: []app_errors.FieldError{
+					{Field: "side", Rule: "oneof", Message: "must be one of bid, ask", Limits: map[string]string{"oneof": "bid ask"}},
+				},
+			},
+		},
+		{
+			name:           "wager not found",
+			input:          &dto.PlaceOrderRequest{UserID: 5, WagerID: 111, Side: "bid", Price: money.MustParse("25"), Quantity: 1},
+			wagerRepoError: sql.ErrNoRows,
+			expectedError:  &app_errors.ErrorResponse{Status: http.StatusNotFound, Code: app_errors.ErrNotFound},
+		},
+		{
+			name:  "settled wager",
+			input: &dto.PlaceOrderRequest{UserID: 5, WagerID: 111, Side: "bid", Price: money.MustParse("25"), Quantity: 1},
+			wagerRepoResp: func() *repo.Wager {
+				w := wager()
+				w.Status = repo.WagerStatusSettled
+				return w
+			}(),
+			expectedError: &app_errors.ErrorResponse{Status: http.StatusConflict, Code: app_errors.ErrWagerSettled},
+		},
+		{
+			name:  "open auction",
+			input: &dto.PlaceOrderRequest{UserID: 5, WagerID: 111, Side: "bid", Price: money.MustParse("25"), Quantity: 1},
+			wagerRepoResp: func() *repo.Wager {
+				w := wager()
+				w.Auction = repo.WagerAuctionFirstPrice
+				w.AuctionEndsAt = sql.NullTime{Time: now.Add(time.Hour), Valid: true}
+				return w
+			}(),
+			expectedError: &app_errors.ErrorResponse{Status: http.StatusConflict, Code: app_errors.ErrAuctionOpen},
+		},
+		{
+			name:          "ask of other than seller",
+			input:         &dto.PlaceOrderRequest{UserID: 5, WagerID: 111, Side: "ask", Price: money.MustParse("25"), Quantity: 1},
+			wagerRepoResp: wager(),
+			expectedError: &app_errors.ErrorResponse{Status: http.StatusForbidden, Code: app_errors.ErrNotWagerSeller},
+		},
+		{
+			name:  "sold out",
+			input: &dto.PlaceOrderRequest{UserID: 5, WagerID: 111, Side: "bid", Price: money.MustParse("25"), Quantity: 1},
+			wagerRepoResp: func() *repo.Wager {
+				w := wager()
+				w.AmountSold = sql.NullInt32{Int32: 20, Valid: true}
+				return w
+			}(),
+			expectedError: &app_errors.ErrorResponse{Status: http.StatusNotAcceptable, Code: app_errors.ErrWagerSoldOut},
+		},
+		{
+			name:          "insufficient funds for bid",
+			input:         &dto.PlaceOrderRequest{UserID: 5, WagerID: 111, Side: "bid", Price: money.MustParse("25"), Quantity: 2},
+			wagerRepoResp: wager(),
+			balances:      map[uint32]money.Money{5: money.MustParse("49.99"), 9: 0},
+			expectedError: &app_errors.ErrorResponse{Status: http.StatusPaymentRequired, Code: app_errors.ErrInsufficientFunds},
+		},
+		{
+			name:          "bid over balance left by open bids",
+			input:         &dto.PlaceOrderRequest{UserID: 5, WagerID: 111, Side: "bid", Price: money.MustParse("25"), Quantity: 2},
+			wagerRepoResp: wager(),
+			balances:      map[uint32]money.Money{5: money.MustParse("99.99"), 9: 0},
+			openBids: []repo.Order{
+				{ID: 2, WagerID: 112, UserID: 5, Side: repo.OrderSideBid, Price: money.MustParse("30"), Quantity: 1, Status: repo.OrderStatusOpen},
+				{ID: 3, WagerID: 111, UserID: 5, Side: repo.OrderSideBid, Price: money.MustParse("10"), Quantity: 4, Filled: 2, Status: repo.OrderStatusOpen},
+			},
+			expectedError: &app_errors.ErrorResponse{Status: http.StatusPaymentRequired, Code: app_errors.ErrInsufficientFunds},
+		},
+		{
+			name:          "bid within balance left by open bids",
+			input:         &dto.PlaceOrderRequest{UserID: 5, WagerID: 111, Side: "bid", Price: money.MustParse("25"), Quantity: 2},
+			wagerRepoResp: wager(),
+			balances:      map[uint32]money.Money{5: money.MustParse("100"), 9: 0},
+			openBids: []repo.Order{
+				{ID: 2, WagerID: 112, UserID: 5, Side: repo.OrderSideBid, Price: money.MustParse("30"), Quantity: 1, Status: repo.OrderStatusOpen},
+				{ID: 3, WagerID: 111, UserID: 5, Side: repo.OrderSideBid, Price: money.MustParse("10"), Quantity: 4, Filled: 2, Status: repo.OrderStatusOpen},
+			},
+			expectedRes: &dto.Order{
+				ID: 1, WagerID: 111, UserID: 5, Side: "bid", Price: money.MustParse("25"), Quantity: 2,
+				Status: "open", PlacedAt: &now,
+			},
+		},
+		{
+			name:  "bid selling out wager closes its book",
+			input: &dto.PlaceOrderRequest{UserID: 5, WagerID: 111, Side: "bid", Price: money.MustParse("25"), Quantity: 2},
+			wagerRepoResp: func() *repo.Wager {
+				w := wager()
+				w.AmountSold = sql.NullInt32{Int32: 18, Valid: true}
+				return w
+			}(),
+			resting: []repo.Order{
+				{ID: 2, WagerID: 111, UserID: 9, Side: repo.OrderSideAsk, Price: money.MustParse("20"), Quantity: 5, Status: repo.OrderStatusOpen},
+			},
+			balances: map[uint32]money.Money{5: money.MustParse("100"), 9: 0},
+			expectedPurchases: []repo.Purchase{
+				{WagerID: 111, BuyingPrice: money.MustParse("20"), BuyerID: toNullID(5), Quantity: 2, TotalPrice: money.MustParse("40")},
+			},
+			expectedOrderUpdates: []repo.Order{
+				{ID: 2, WagerID: 111, UserID: 9, Side: repo.OrderSideAsk, Price: money.MustParse("20"), Quantity: 5, Filled: 2, Status: repo.OrderStatusOpen},
+				{
+					ID: 1, WagerID: 111, UserID: 5, Side: repo.OrderSideBid, Price: money.MustParse("25"), Quantity: 2, Filled: 2,
+					Status: repo.OrderStatusFilled, CreatedAt: sql.NullTime{Time: now, Valid: true},
+				},
+			},
+			updateWagerRepoReq: func() *repo.Wager {
+				w := sold(20, "20")
+				w.Status = repo.WagerStatusSoldOut
+				return w
+			}(),
+			closesBook: true,
+			expectedRes: &dto.Order{
+				ID: 1, WagerID: 111, UserID: 5, Side: "bid", Price: money.MustParse("25"), Quantity: 2, Filled: 2,
+				Status: "filled", PlacedAt: &now,
+				Purchases: []dto.WagerPurchase{
+					{ID: 1, WagerID: 111, BuyingPrice: money.MustParse("20"), Quantity: 2, TotalPrice: money.MustParse("40"), BoughtAt: &now, BuyerID: 5},
+				},
+			},
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			ctx := context.Background()
+			mockWagerRepo := new(MockWagerRepo)
+			mockWagerRepo.On("GetWagerByIDForUpdate", ctx, tc.input.WagerID).
+				Return(tc.wagerRepoResp, tc.wagerRepoError)
+			mockWagerRepo.On("UpdateWager", ctx, mock.Anything).Return(nil)
+
+			mockOrderRepo := new(MockOrderRepo)
+			mockOrderRepo.On("ListOpenOrders", ctx, tc.input.WagerID, mock.Anything, tc.input.Price).
+				Return(tc.resting, nil)
+			mockOrderRepo.On("ListUserOpenOrders", ctx, tc.input.UserID, repo.OrderSideBid).
+				Return(tc.openBids, nil)
+			mockOrderRepo.On("CancelOpenOrders", ctx, tc.input.WagerID).Return(nil)
+			mockOrderRepo.On("CreateOrder", ctx, mock.Anything).
+				Return(func(_ context.Context, o *repo.Order) *repo.Order {
+					o.ID = 1
+					o.Status = repo.OrderStatusOpen
+					o.CreatedAt = sql.NullTime{Time: now, Valid: true}
+					return o
+				}, nil)
+
+			var orderUpdates []repo.Order
+			mockOrderRepo.On("UpdateOrder", ctx, mock.Anything).
+				Run(func(args mock.Arguments) { orderUpdates = append(orderUpdates, *args.Get(1).(*repo.Order)) }).
+				Return(nil)
+
+			var purchases []repo.Purchase
+			mockPurchaseRepo := new(MockPurchaseRepo)
+			mockPurchaseRepo.On("CreatePurchase", ctx, mock.Anything).
+				Return(func(_ context.Context, p *repo.Purchase) *repo.Purchase {
+					purchases = append(purchases, *p)
+					created := *p
+					created.ID = uint32(len(purchases))
+					created.CreatedAt = sql.NullTime{Time: now, Valid: true}
+					return &created
+				}, nil)
+
+			mockWalletRepo := new(MockWalletRepo)
+			for userID, balance := range tc.balances {
+				mockWalletRepo.On("GetAccountByUserIDForUpdate", ctx, userID).
+					Return(&repo.Account{ID: userID * 10, Kind: repo.AccountKindUser, Balance: balance}, nil)
+			}
+
+			mockWalletRepo.On("CreateLedgerTransaction", ctx, mock.Anything).Return(&repo.LedgerTransaction{}, nil)
+
+			mockTransactor := new(MockTransactor)
+			mockTransactor.On("WithinTransaction", ctx, mock.Anything).
+				Return(func(ctx context.Context, fn func(context.Context) error) error {
+					return fn(ctx)
+				})
+
+			appMetrics := metrics.New()
+			service := NewOrderService(mockTransactor, mockOrderRepo, mockPurchaseRepo, mockWagerRepo, mockWalletRepo,
+				logger.Discard(), appMetrics, events.NewBroker(1), NewSellOutPolicy(SellingPercentageRule{}))
+			service.now = func() time.Time { return now }
+
+			order, err := service.PlaceOrder(ctx, tc.input)
+
+			assert.Equal(t, tc.expectedError, err)
+			assert.Equal(t, tc.expectedRes, order)
+			assert.Equal(t, tc.expectedPurchases, purchases)
+			assert.Equal(t, tc.expectedOrderUpdates, orderUpdates)
+			assert.Equal(t, float64(len(tc.expectedPurchases)), appMetrics.WagerPurchases.Value())
+			if tc.updateWagerRepoReq != nil {
+				mockWagerRepo.AssertCalled(t, "UpdateWager", ctx, tc.updateWagerRepoReq)
+			} else {
+				mockWagerRepo.AssertNotCalled(t, "UpdateWager", ctx, mock.Anything)
+			}
+
+			if tc.closesBook {
+				mockOrderRepo.AssertCalled(t, "CancelOpenOrders", ctx, tc.input.WagerID)
+			} else {
+				mockOrderRepo.AssertNotCalled(t, "CancelOpenOrders", ctx, mock.Anything)
+			}
+		})
+	}
+}
+
+func TestOrderService_CancelOrder(t *testing.T) {
+	repoErr := errors.New("some error")
+	open := &repo.Order{ID: 1, WagerID: 111, UserID: 5, Side: repo.OrderSideBid, Price: money.MustParse("25"), Quantity: 2, Status: repo.OrderStatusOpen}
+	filled := &repo.Order{ID: 1, WagerID: 111, UserID: 5, Side: repo.OrderSideBid, Price: money.MustParse("25"), Quantity: 2, Filled: 2, Status: repo.OrderStatusFilled}
+
+	for _, tc := range []struct {
+		name  string
+		input *dto.OrderRequest
+
+		orderRepoResp  *repo.Order
+		orderRepoError error
+
+		expectedUpdate *repo.Order
+		expectedRes    *dto.Order
+		expectedError  error
+	}{
+		{
+			name:           "happy path",
+			input:          &dto.OrderRequest{UserID: 5, OrderID: 1},
+			orderRepoResp:  open,
+			expectedUpdate: &repo.Order{ID: 1, WagerID: 111, UserID: 5, Side: repo.OrderSideBid, Price: money.MustParse("25"), Quantity: 2, Status: repo.OrderStatusCancelled},
+			expectedRes:    &dto.Order{ID: 1, WagerID: 111, UserID: 5, Side: "bid", Price: money.MustParse("25"), Quantity: 2, Status: "cancelled"},
+		},
+		{
+			name:          "order of other user",
+			input:         &dto.OrderRequest{UserID: 6, OrderID: 1},
+			orderRepoResp: open,
+			expectedError: &app_errors.ErrorResponse{Status: http.StatusNotFound, Code: app_errors.ErrNotFound},
+		},
+		{
+			name:           "order not found",
+			input:          &dto.OrderRequest{UserID: 5, OrderID: 1},
+			orderRepoError: sql.ErrNoRows,
+			expectedError:  &app_errors.ErrorResponse{Status: http.StatusNotFound, Code: app_errors.ErrNotFound},
+		},
+		{
+			name:          "filled order",
+			input:         &dto.OrderRequest{UserID: 5, OrderID: 1},
+			orderRepoResp: filled,
+			expectedError: &app_errors.ErrorResponse{Status: http.StatusConflict, Code: app_errors.ErrOrderNotOpen},
+		},
+		{
+			name:           "repo error",
+			input:          &dto.OrderRequest{UserID: 5, OrderID: 1},
+			orderRepoError: repoErr,
+			expectedError:  repoErr,
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			ctx := context.Background()
+			mockOrderRepo := new(MockOrderRepo)
+			mockOrderRepo.On("GetOrderByID", ctx, tc.input.OrderID).
+				Return(func(context.Context, uint32) *repo.Order {
+					if tc.orderRepoResp == nil {
+						return nil
+					}
+
+					order := *tc.orderRepoResp
+					return &order
+				}, tc.orderRepoError)
+			mockOrderRepo.On("UpdateOrder", ctx, mock.Anything).Return(nil)
+
+			mockWagerRepo := new(MockWagerRepo)
+			mockWagerRepo.On("GetWagerByIDForUpdate", ctx, uint32(111)).Return(&repo.Wager{ID: 111}, nil)
+
+			mockTransactor := new(MockTransactor)
+			mockTransactor.On("WithinTransaction", ctx, mock.Anything).
+				Return(func(ctx context.Context, fn func(context.Context) error) error {
+					return fn(ctx)
+				})
+
+			service := NewOrderService(mockTransactor, mockOrderRepo, new(MockPurchaseRepo), mockWagerRepo, new(MockWalletRepo),
+				logger.Discard(), metrics.New(), events.NewBroker(1), NewSellOutPolicy(SellingPercentageRule{}))
+
+			order, err := service.CancelOrder(ctx, tc.input)
+
+			assert.Equal(t, tc.expectedError, err)
+			assert.Equal(t, tc.expectedRes, order)
+			if tc.expectedUpdate != nil {
+				mockOrderRepo.AssertCalled(t, "UpdateOrder", ctx, tc.expectedUpdate)
+			} else {
+				mockOrderRepo.AssertNotCalled(t, "UpdateOrder", ctx, mock.Anything)
+			}
+		})
+	}
+}
+
+func TestOrderService_GetOrderBook(t *testing.T) {
+	for _, tc := range []struct {
+		name  string
+		input *dto.GetOrderBookRequest
+
+		wagerRepoResp  *repo.Wager
+		wagerRepoError error
+		// expectedLevels is levels requested from repo
+		expectedLevels uint32
+
+		expectedRes   *dto.OrderBook
+		expectedError error
+	}{
+		{
+			name:           "default levels",
+			input:          &dto.GetOrderBookRequest{WagerID: 111},
+			expectedLevels: 10,
+			expectedRes: &dto.OrderBook{
+				WagerID: 111,
+				Bids:    []dto.OrderBookLevel{{Price: money.MustParse("24"), Quantity: 3, Orders: 2}},
+				Asks:    []dto.OrderBookLevel{},
+			},
+		},
+		{
+			name:           "levels limited",
+			input:          &dto.GetOrderBookRequest{WagerID: 111, Levels: 1000},
+			expectedLevels: 100,
+			expectedRes: &dto.OrderBook{
+				WagerID: 111,
+				Bids:    []dto.OrderBookLevel{{Price: money.MustParse("24"), Quantity: 3, Orders: 2}},
+				Asks:    []dto.OrderBookLevel{},
+			},
+		},
+		{
+			name:          "sold out wager has empty book",
+			input:         &dto.GetOrderBookRequest{WagerID: 111},
+			wagerRepoResp: &repo.Wager{ID: 111, Status: repo.WagerStatusSoldOut},
+			expectedRes:   &dto.OrderBook{WagerID: 111, Bids: []dto.OrderBookLevel{}, Asks: []dto.OrderBookLevel{}},
+		},
+		{
+			name:          "settled wager has empty book",
+			input:         &dto.GetOrderBookRequest{WagerID: 111},
+			wagerRepoResp: &repo.Wager{ID: 111, Status: repo.WagerStatusSettled},
+			expectedRes:   &dto.OrderBook{WagerID: 111, Bids: []dto.OrderBookLevel{}, Asks: []dto.OrderBookLevel{}},
+		},
+		{
+			name:           "wager not found",
+			input:          &dto.GetOrderBookRequest{WagerID: 111},
+			wagerRepoError: sql.ErrNoRows,
+			expectedError:  &app_errors.ErrorResponse{Status: http.StatusNotFound, Code: app_errors.ErrNotFound},
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			ctx := context.Background()
+			mockWagerRepo := new(MockWagerRepo)
+			wagerRepoResp := tc.wagerRepoResp
+			if wagerRepoResp == nil {
+				wagerRepoResp = &repo.Wager{ID: 111, Status: repo.WagerStatusOpen}
+			}
+
+			mockWagerRepo.On("GetWagerByID", ctx, tc.input.WagerID).Return(wagerRepoResp, tc.wagerRepoError)
+
+			mockOrderRepo := new(MockOrderRepo)
+			mockOrderRepo.On("ListOrderBook", ctx, tc.input.WagerID, repo.OrderSideBid, tc.expectedLevels).
+				Return([]repo.OrderBookLevel{{Price: money.MustParse("24"), Quantity: 3, Orders: 2}}, nil)
+			mockOrderRepo.On("ListOrderBook", ctx, tc.input.WagerID, repo.OrderSideAsk, tc.expectedLevels).
+				Return(nil, nil)
+
+			service := NewOrderService(new(MockTransactor), mockOrderRepo, new(MockPurchaseRepo), mockWagerRepo, new(MockWalletRepo),
+				logger.Discard(), metrics.New(), events.NewBroker(1), NewSellOutPolicy(SellingPercentageRule{}))
+
+			book, err := service.GetOrderBook(ctx, tc.input)
+
+			assert.Equal(t, tc.expectedError, err)
+			assert.Equal(t, tc.expectedRes, book)
+		})
+	}
+}
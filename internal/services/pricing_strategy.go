@@ -0,0 +1,122 @@
+package services
+
+import (
+	"math"
+	"time"
+
+	"github.com/vitthalaa/wager-app/internal/repo"
+	"github.com/vitthalaa/wager-app/money"
+)
+
+// PricingStrategy prices units of wager on sale. Implement it for custom strategies.
+type PricingStrategy interface {
+	// Price returns current selling price of wager at time
+	Price(wager repo.Wager, at time.Time) money.Money
+	// Traded returns current selling price of wager after quantity units are bought at buying price at time
+	Traded(wager repo.Wager, buyingPrice money.Money, quantity uint32, at time.Time) money.Money
+}
+
+// maxPrice is highest price of one unit, as validation of selling and buying prices allows
+const maxPrice money.Money = 1000000000_00
+
+// pricingStrategies are strategies of wager pricing names
+var pricingStrategies = map[repo.WagerPricing]PricingStrategy{
+	repo.WagerPricingLastTrade:    LastTradePricing{},
+	repo.WagerPricingTimeDecay:    TimeDecayPricing{},
+	repo.WagerPricingDemandStepUp: DemandStepUpPricing{},
+	repo.WagerPricingDutchAuction: DutchAuctionPricing{},
+}
+
+// timePricings are pricings whose current selling price is computed at read time, stored current selling price of
+// their wagers is not the price they sell at
+var timePricings = []repo.WagerPricing{repo.WagerPricingTimeDecay, repo.WagerPricingDutchAuction}
+
+// pricingOf returns pricing strategy of wager, wagers of unknown pricing are priced by last trade
+func pricingOf(wager repo.Wager) PricingStrategy {
+	if strategy, ok := pricingStrategies[wager.Pricing]; ok {
+		return strategy
+	}
+
+	return LastTradePricing{}
+}
+
+// LastTradePricing prices wager at buying price of last purchase
+type LastTradePricing struct{}
+
+// Price ...
+func (LastTradePricing) Price(wager repo.Wager, _ time.Time) money.Money {
+	return wager.CurrentSellingPrice
+}
+
+// Traded ...
+func (LastTradePricing) Traded(_ repo.Wager, buyingPrice money.Money, _ uint32, _ time.Time) money.Money {
+	return buyingPrice
+}
+
+// TimeDecayPricing prices wager at buying price of last purchase, decaying linearly to floor price
+// within decay seconds after the purchase, or after placing wager until first purchase
+type TimeDecayPricing struct{}
+
+// Price ...
+func (TimeDecayPricing) Price(wager repo.Wager, at time.Time) money.Money {
+	since := wager.CreatedAt.Time
+	if wager.PricedAt.Valid {
+		since = wager.PricedAt.Time
+	}
+
+	return decay(wager.CurrentSellingPrice, wager.FloorPrice, at.Sub(since), wager.DecaySeconds)
+}
+
+// Traded ...
+func (TimeDecayPricing) Traded(_ repo.Wager, buyingPrice money.Money, _ uint32, _ time.Time) money.Money {
+	return buyingPrice
+}
+
+// DemandStepUpPricing raises current selling price by step percentage for every unit bought,
+// regardless of buying price, up to maxPrice
+type DemandStepUpPricing struct{}
+
+// Price ...
+func (DemandStepUpPricing) Price(wager repo.Wager, _ time.Time) money.Money {
+	return wager.CurrentSellingPrice
+}
+
+// Traded ...
+func (DemandStepUpPricing) Traded(wager repo.Wager, _ money.Money, quantity uint32, _ time.Time) money.Money {
+	percent := 100 + float64(wager.StepPercentage)*float64(quantity)
+	// compared before rounding to money, so raised price can not overflow
+	if float64(wager.CurrentSellingPrice)*percent/100 >= float64(maxPrice) {
+		return maxPrice
+	}
+
+	return wager.CurrentSellingPrice.Percent(percent)
+}
+
+// DutchAuctionPricing decays selling price linearly to floor price within decay seconds after placing wager,
+// purchases do not change the price
+type DutchAuctionPricing struct{}
+
+// Price ...
+func (DutchAuctionPricing) Price(wager repo.Wager, at time.Time) money.Money {
+	return decay(wager.SellingPrice, wager.FloorPrice, at.Sub(wager.CreatedAt.Time), wager.DecaySeconds)
+}
+
+// Traded keeps price of purchase time as current selling price, so listings sort by recent price
+func (p DutchAuctionPricing) Traded(wager repo.Wager, _ money.Money, _ uint32, at time.Time) money.Money {
+	return p.Price(wager, at)
+}
+
+// decay returns price decayed linearly from start to floor price within decay seconds, rounded to minor unit
+func decay(start, floor money.Money, elapsed time.Duration, decaySeconds uint32) money.Money {
+	if start <= floor || elapsed <= 0 {
+		return start
+	}
+
+	period := time.Duration(decaySeconds) * time.Second
+	if elapsed >= period {
+		return floor
+	}
+
+	decayed := math.Round(float64(start-floor) * float64(elapsed) / float64(period))
+	return start - money.Money(decayed)
+}
@@ -0,0 +1,171 @@
+package services
+
+import (
+	"database/sql"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/vitthalaa/wager-app/internal/repo"
+	"github.com/vitthalaa/wager-app/money"
+)
+
+func TestPricingStrategy_Price(t *testing.T) {
+	placedAt := time.Date(2024, 5, 1, 10, 0, 0, 0, time.UTC)
+	wager := func(pricing repo.WagerPricing, pricedAt time.Time) repo.Wager {
+		return repo.Wager{
+			SellingPrice:        money.MustParse("100"),
+			CurrentSellingPrice: money.MustParse("80"),
+			CreatedAt:           sql.NullTime{Time: placedAt, Valid: true},
+			Pricing:             pricing,
+			FloorPrice:          money.MustParse("40"),
+			DecaySeconds:        3600,
+			StepPercentage:      10,
+			PricedAt:            sql.NullTime{Time: pricedAt, Valid: !pricedAt.IsZero()},
+		}
+	}
+
+	for _, tc := range []struct {
+		name  string
+		wager repo.Wager
+		at    time.Time
+
+		expected money.Money
+	}{
+		{
+			name:     "last trade",
+			wager:    wager(repo.WagerPricingLastTrade, placedAt),
+			at:       placedAt.Add(time.Hour),
+			expected: money.MustParse("80"),
+		},
+		{
+			name:     "unknown pricing is priced by last trade",
+			wager:    wager("", placedAt),
+			at:       placedAt.Add(time.Hour),
+			expected: money.MustParse("80"),
+		},
+		{
+			name:     "time decay from placing until first purchase",
+			wager:    wager(repo.WagerPricingTimeDecay, time.Time{}),
+			at:       placedAt.Add(15 * time.Minute),
+			expected: money.MustParse("70"),
+		},
+		{
+			name:     "time decay from last purchase",
+			wager:    wager(repo.WagerPricingTimeDecay, placedAt.Add(time.Hour)),
+			at:       placedAt.Add(90 * time.Minute),
+			expected: money.MustParse("60"),
+		},
+		{
+			name:     "time decay rounded to minor unit",
+			wager:    wager(repo.WagerPricingTimeDecay, placedAt),
+			at:       placedAt.Add(time.Second),
+			expected: money.MustParse("79.99"),
+		},
+		{
+			name:     "time decay stops at floor price",
+			wager:    wager(repo.WagerPricingTimeDecay, placedAt),
+			at:       placedAt.Add(2 * time.Hour),
+			expected: money.MustParse("40"),
+		},
+		{
+			name:     "demand step up",
+			wager:    wager(repo.WagerPricingDemandStepUp, placedAt),
+			at:       placedAt.Add(2 * time.Hour),
+			expected: money.MustParse("80"),
+		},
+		{
+			name:     "dutch auction decays selling price from placing",
+			wager:    wager(repo.WagerPricingDutchAuction, placedAt.Add(30*time.Minute)),
+			at:       placedAt.Add(45 * time.Minute),
+			expected: money.MustParse("55"),
+		},
+		{
+			name:     "dutch auction before placing",
+			wager:    wager(repo.WagerPricingDutchAuction, time.Time{}),
+			at:       placedAt.Add(-time.Minute),
+			expected: money.MustParse("100"),
+		},
+		{
+			name:     "dutch auction stops at floor price",
+			wager:    wager(repo.WagerPricingDutchAuction, time.Time{}),
+			at:       placedAt.Add(time.Hour),
+			expected: money.MustParse("40"),
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			assert.Equal(t, tc.expected, pricingOf(tc.wager).Price(tc.wager, tc.at))
+		})
+	}
+}
+
+func TestPricingStrategy_Traded(t *testing.T) {
+	placedAt := time.Date(2024, 5, 1, 10, 0, 0, 0, time.UTC)
+	wager := func(pricing repo.WagerPricing) repo.Wager {
+		return repo.Wager{
+			SellingPrice:        money.MustParse("100"),
+			CurrentSellingPrice: money.MustParse("80"),
+			CreatedAt:           sql.NullTime{Time: placedAt, Valid: true},
+			Pricing:             pricing,
+			FloorPrice:          money.MustParse("40"),
+			DecaySeconds:        3600,
+			StepPercentage:      2.5,
+		}
+	}
+
+	for _, tc := range []struct {
+		name        string
+		wager       repo.Wager
+		buyingPrice money.Money
+		quantity    uint32
+
+		expected money.Money
+	}{
+		{
+			name:        "last trade prices at buying price",
+			wager:       wager(repo.WagerPricingLastTrade),
+			buyingPrice: money.MustParse("75"),
+			quantity:    1,
+			expected:    money.MustParse("75"),
+		},
+		{
+			name:        "time decay restarts from buying price",
+			wager:       wager(repo.WagerPricingTimeDecay),
+			buyingPrice: money.MustParse("65"),
+			quantity:    2,
+			expected:    money.MustParse("65"),
+		},
+		{
+			name:        "demand step up for every unit",
+			wager:       wager(repo.WagerPricingDemandStepUp),
+			buyingPrice: money.MustParse("75"),
+			quantity:    2,
+			expected:    money.MustParse("84"),
+		},
+		{
+			name: "demand step up up to max price",
+			wager: func() repo.Wager {
+				w := wager(repo.WagerPricingDemandStepUp)
+				w.CurrentSellingPrice = money.MustParse("999999999")
+				w.StepPercentage = 100
+				return w
+			}(),
+			buyingPrice: money.MustParse("999999999"),
+			quantity:    1000000,
+			expected:    money.MustParse("1000000000"),
+		},
+		{
+			name:        "dutch auction keeps price of purchase time",
+			wager:       wager(repo.WagerPricingDutchAuction),
+			buyingPrice: money.MustParse("50"),
+			quantity:    1,
+			expected:    money.MustParse("70"),
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			at := placedAt.Add(30 * time.Minute)
+			assert.Equal(t, tc.expected, pricingOf(tc.wager).Traded(tc.wager, tc.buyingPrice, tc.quantity, at))
+		})
+	}
+}
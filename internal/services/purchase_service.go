@@ -3,46 +3,147 @@ package services
 import (
 	"context"
 	"database/sql"
-	"log"
 	"net/http"
+	"strconv"
+	"strings"
 	"time"
 
 	"github.com/vitthalaa/wager-app/app_errors"
 	"github.com/vitthalaa/wager-app/dto"
+	"github.com/vitthalaa/wager-app/internal/events"
+	"github.com/vitthalaa/wager-app/internal/logger"
+	"github.com/vitthalaa/wager-app/internal/metrics"
 	"github.com/vitthalaa/wager-app/internal/repo"
+	"github.com/vitthalaa/wager-app/internal/validation"
+	"github.com/vitthalaa/wager-app/money"
 )
 
 // IPurchaseService ...
 type IPurchaseService interface {
 	PurchaseWager(ctx context.Context, req *dto.BuyWagerRequest) (*dto.WagerPurchase, error)
+	ListPurchases(ctx context.Context, req *dto.ListPurchaseRequest) (*dto.WagerPurchaseList, error)
+	GetPurchase(ctx context.Context, id uint32) (*dto.WagerPurchase, error)
+}
+
+// purchaseSortFields maps sort fields of API to repo sort fields
+var purchaseSortFields = map[string]repo.PurchaseSortField{
+	"id":           repo.PurchaseSortID,
+	"buying_price": repo.PurchaseSortBuyingPrice,
+	"bought_at":    repo.PurchaseSortCreatedAt,
 }
 
 // NewPurchaseService ...
-func NewPurchaseService(purchaseRepo repo.IPurchaseRepo, wagerRepo repo.IWagerRepo) *PurchaseService {
+func NewPurchaseService(
+	transactor repo.ITransactor, purchaseRepo repo.IPurchaseRepo, wagerRepo repo.IWagerRepo, walletRepo repo.IWalletRepo,
+	orderRepo repo.IOrderRepo, log *logger.Logger, metrics *metrics.Metrics, broker *events.Broker,
+	sellOut *SellOutPolicy,
+) *PurchaseService {
 	return &PurchaseService{
+		transactor:   transactor,
 		purchaseRepo: purchaseRepo,
 		wagerRepo:    wagerRepo,
+		walletRepo:   walletRepo,
+		orderRepo:    orderRepo,
+		log:          log,
+		metrics:      metrics,
+		events:       broker,
+		sellOut:      sellOut,
+		now:          time.Now,
 	}
 }
 
 // PurchaseService ...
 type PurchaseService struct {
+	transactor   repo.ITransactor
 	purchaseRepo repo.IPurchaseRepo
 	wagerRepo    repo.IWagerRepo
+	walletRepo   repo.IWalletRepo
+	orderRepo    repo.IOrderRepo
+	log          *logger.Logger
+	metrics      *metrics.Metrics
+	events       *events.Broker
+	sellOut      *SellOutPolicy
+	now          func() time.Time
 }
 
-// PurchaseWager ...
+// PurchaseWager records purchase of requested units and pays their total price from wallet of buyer to seller
 func (s *PurchaseService) PurchaseWager(ctx context.Context, req *dto.BuyWagerRequest) (*dto.WagerPurchase, error) {
 	if req == nil || req.WagerID == 0 {
 		return nil, &app_errors.ErrorResponse{Status: http.StatusBadRequest, Code: app_errors.ErrInvalidWagerID}
 	}
 
-	if req.BuyingPrice < 1 {
-		return nil, &app_errors.ErrorResponse{Status: http.StatusBadRequest, Code: app_errors.ErrInvalidBuyingPrice}
+	if req.BuyerID == 0 {
+		return nil, &app_errors.ErrorResponse{Status: http.StatusUnauthorized, Code: app_errors.ErrUnauthorized}
+	}
+
+	if errRes := validation.Struct(req).Err(); errRes != nil {
+		return nil, errRes
+	}
+
+	var purchase *repo.Purchase
+	var wager *repo.Wager
+	err := s.transactor.WithinTransaction(ctx, func(ctx context.Context) error {
+		var err error
+		purchase, wager, err = s.purchaseWager(ctx, req)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	s.metrics.WagerPurchases.Inc()
+	s.log.Info(ctx, "wager purchased",
+		"purchase_id", purchase.ID, "wager_id", purchase.WagerID, "buyer_id", req.BuyerID,
+		"buying_price", purchase.BuyingPrice, "quantity", purchase.Quantity)
+
+	// published after commit, so subscribers never see changes of rolled back purchase
+	wagerDTO := toWagerDTO(*wager, wager.PricedAt.Time)
+	s.events.Publish(events.TypePriceChanged, wagerDTO)
+	if wager.Status == repo.WagerStatusSoldOut {
+		s.events.Publish(events.TypeSoldOut, wagerDTO)
+	}
+
+	purchaseDTO := toWagerPurchaseDTO(*purchase)
+	return &purchaseDTO, nil
+}
+
+// ListPurchases returns page of purchases matching request filters, latest first by default
+func (s *PurchaseService) ListPurchases(ctx context.Context, req *dto.ListPurchaseRequest) (*dto.WagerPurchaseList, error) {
+	filter, errRes := toPurchaseFilter(req)
+	if errRes != nil {
+		return nil, errRes
 	}
 
-	// TODO: Should do all wager operations with lock and transaction
-	wager, err := s.wagerRepo.GetWagerByID(ctx, req.WagerID)
+	purchases, err := s.purchaseRepo.ListPurchases(ctx, filter)
+	if err != nil {
+		return nil, err
+	}
+
+	total, err := s.purchaseRepo.CountPurchases(ctx, filter)
+	if err != nil {
+		return nil, err
+	}
+
+	items := make([]dto.WagerPurchase, 0, len(purchases))
+	for _, p := range purchases {
+		items = append(items, toWagerPurchaseDTO(p))
+	}
+
+	return &dto.WagerPurchaseList{
+		Items: items,
+		Page:  filter.Offset/filter.Limit + 1,
+		Limit: filter.Limit,
+		Total: total,
+	}, nil
+}
+
+// GetPurchase returns purchase by id
+func (s *PurchaseService) GetPurchase(ctx context.Context, id uint32) (*dto.WagerPurchase, error) {
+	if id == 0 {
+		return nil, &app_errors.ErrorResponse{Status: http.StatusNotFound, Code: app_errors.ErrNotFound}
+	}
+
+	purchase, err := s.purchaseRepo.GetPurchaseByID(ctx, id)
 	if err != nil {
 		if err == sql.ErrNoRows {
 			return nil, &app_errors.ErrorResponse{Status: http.StatusNotFound, Code: app_errors.ErrNotFound}
@@ -51,69 +152,227 @@ func (s *PurchaseService) PurchaseWager(ctx context.Context, req *dto.BuyWagerRe
 		return nil, err
 	}
 
-	if req.BuyingPrice > wager.CurrentSellingPrice {
-		return nil, &app_errors.ErrorResponse{Status: http.StatusBadRequest, Code: app_errors.ErrInvalidBuyingPrice}
+	purchaseDTO := toWagerPurchaseDTO(*purchase)
+	return &purchaseDTO, nil
+}
+
+// toPurchaseFilter validates list request and converts it to repo filter
+func toPurchaseFilter(req *dto.ListPurchaseRequest) (repo.PurchaseFilter, *app_errors.ErrorResponse) {
+	filter := repo.PurchaseFilter{
+		WagerID:  req.WagerID,
+		MinPrice: req.MinPrice,
+		MaxPrice: req.MaxPrice,
+		SortBy:   repo.PurchaseSortID,
+		SortDesc: true,
+	}
+
+	var errRes *app_errors.ErrorResponse
+	filter.Offset, filter.Limit, errRes = toOffsetLimit(req.Page, req.Limit)
+	if errRes != nil {
+		return filter, errRes
+	}
+
+	if req.MinPrice < 0 || req.MaxPrice < 0 || (req.MaxPrice > 0 && req.MinPrice > req.MaxPrice) {
+		return filter, &app_errors.ErrorResponse{Status: http.StatusBadRequest, Code: app_errors.ErrInvalidFilter}
+	}
+
+	if req.From != nil {
+		filter.From = *req.From
+	}
+
+	if req.To != nil {
+		filter.To = *req.To
+	}
+
+	if req.From != nil && req.To != nil && req.From.After(*req.To) {
+		return filter, &app_errors.ErrorResponse{Status: http.StatusBadRequest, Code: app_errors.ErrInvalidFilter}
+	}
+
+	if req.Sort != "" {
+		field := strings.TrimPrefix(req.Sort, "-")
+		sortBy, ok := purchaseSortFields[field]
+		if !ok {
+			return filter, &app_errors.ErrorResponse{Status: http.StatusBadRequest, Code: app_errors.ErrInvalidSort}
+		}
+
+		filter.SortBy = sortBy
+		filter.SortDesc = strings.HasPrefix(req.Sort, "-")
+	}
+
+	return filter, nil
+}
+
+// purchaseWager locks wager row, validates it against request, records purchase and pays it. Returns updated wager.
+// Must be called within transaction so that concurrent purchases can not oversell or overdraw buyer wallet.
+func (s *PurchaseService) purchaseWager(ctx context.Context, req *dto.BuyWagerRequest) (*repo.Purchase, *repo.Wager, error) {
+	wager, err := s.wagerRepo.GetWagerByIDForUpdate(ctx, req.WagerID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil, &app_errors.ErrorResponse{Status: http.StatusNotFound, Code: app_errors.ErrNotFound}
+		}
+
+		return nil, nil, err
+	}
+
+	if wager.Status.Closed() {
+		return nil, nil, &app_errors.ErrorResponse{Status: http.StatusConflict, Code: app_errors.ErrWagerSettled}
+	}
+
+	if wager.InAuction() {
+		return nil, nil, &app_errors.ErrorResponse{Status: http.StatusConflict, Code: app_errors.ErrAuctionOpen}
+	}
+
+	now := s.now()
+	pricing := pricingOf(*wager)
+	if price := pricing.Price(*wager, now); req.BuyingPrice > price {
+		errs := &validation.Errors{}
+		errs.Add(app_errors.ErrInvalidBuyingPrice,
+			validation.Violation("buying_price", validation.Rule{Name: "max", Limit: price.String()}))
+		return nil, nil, errs.Err()
+	}
+
+	quantity := req.Quantity
+	if quantity == 0 {
+		quantity = 1
+	}
+
+	remaining, rule := s.sellOut.Remaining(*wager)
+	if remaining == 0 {
+		s.metrics.SoldOutRejections.Inc()
+		return nil, nil, &app_errors.ErrorResponse{Status: http.StatusNotAcceptable, Code: rule.Code()}
+	}
+
+	if quantity > remaining {
+		errs := &validation.Errors{}
+		errs.Add(app_errors.ErrInvalidQuantity,
+			validation.Violation("quantity", validation.Rule{Name: "max", Limit: strconv.FormatUint(uint64(remaining), 10)}))
+		return nil, nil, errs.Err()
+	}
+
+	buyer, seller, err := s.lockPurchaseAccounts(ctx, req.BuyerID, wager)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	total, err := totalPrice(req.BuyingPrice, quantity)
+	if err != nil {
+		return nil, nil, err
 	}
 
-	// TODO: Clarify whether need to return error if amount sold is reaches to total wager value
-	// Or percent sold reaches to selling percent
-	if wager.TotalWagerValue <= uint32(wager.AmountSold.Int32) {
-		return nil, &app_errors.ErrorResponse{Status: http.StatusNotAcceptable, Code: app_errors.ErrWagerSoldOut}
+	if buyer.Balance < total {
+		return nil, nil, &app_errors.ErrorResponse{Status: http.StatusPaymentRequired, Code: app_errors.ErrInsufficientFunds}
 	}
 
-	// insert purchase
-	purchaseReq := &repo.Purchase{
+	purchase, err := recordPurchase(ctx, s.purchaseRepo, s.walletRepo, &repo.Purchase{
 		WagerID:     wager.ID,
 		BuyingPrice: req.BuyingPrice,
+		BuyerID:     toNullID(req.BuyerID),
+		Quantity:    quantity,
+		TotalPrice:  total,
+	}, buyer, seller)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	markSold(wager, s.sellOut, pricing, req.BuyingPrice, quantity, now)
+
+	err = s.wagerRepo.UpdateWager(ctx, wager)
+	if err != nil {
+		return nil, nil, err
 	}
 
-	purchase, err := s.purchaseRepo.CreatePurchase(ctx, purchaseReq)
+	err = closeOrderBook(ctx, s.orderRepo, *wager)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return purchase, wager, nil
+}
+
+// totalPrice returns price of quantity units. Totals overflowing money or not positive are rejected, a transfer of
+// such total would pay seller from buyer's wallet in reverse. So are totals over amount storage can keep.
+func totalPrice(price money.Money, quantity uint32) (money.Money, error) {
+	total, err := price.Mul(int64(quantity))
+	if err != nil || total <= 0 || total > money.MaxAmount {
+		return 0, &app_errors.ErrorResponse{Status: http.StatusBadRequest, Code: app_errors.ErrInvalidQuantity}
+	}
+
+	return total, nil
+}
+
+// recordPurchase inserts purchase and pays its total price from buyer to seller account.
+// Accounts must be locked by lockUserAccounts.
+func recordPurchase(
+	ctx context.Context, purchaseRepo repo.IPurchaseRepo, walletRepo repo.IWalletRepo, req *repo.Purchase,
+	buyer, seller *repo.Account,
+) (*repo.Purchase, error) {
+	purchase, err := purchaseRepo.CreatePurchase(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+
+	purchaseID := sql.NullInt32{Int32: int32(purchase.ID), Valid: true}
+	err = transfer(ctx, walletRepo, repo.LedgerTransactionPurchase, purchaseID, buyer, seller, req.TotalPrice)
 	if err != nil {
 		return nil, err
 	}
 
+	return purchase, nil
+}
+
+// markSold adds quantity units bought at buying price to sold amount of wager, reprices it by its pricing strategy
+// and sets its status by sell out policy. Caller updates wager record.
+func markSold(
+	wager *repo.Wager, sellOut *SellOutPolicy, pricing PricingStrategy, buyingPrice money.Money, quantity uint32,
+	now time.Time,
+) {
 	// increase amount sold
+	amountSold := uint32(wager.AmountSold.Int32) + quantity
 	wager.AmountSold = sql.NullInt32{
-		Int32: wager.AmountSold.Int32 + 1,
+		Int32: int32(amountSold),
 		Valid: true,
 	}
 
-	// buying price will be assigned to current selling price
-	wager.CurrentSellingPrice = req.BuyingPrice
+	// current selling price follows pricing strategy of wager
+	wager.CurrentSellingPrice = pricing.Traded(*wager, buyingPrice, quantity, now)
+	wager.PricedAt = sql.NullTime{Time: now, Valid: true}
 	wager.PercentageSold = sql.NullFloat64{
-		Float64: float64(wager.AmountSold.Int32*100) / float64(wager.TotalWagerValue),
+		Float64: float64(amountSold) * 100 / float64(wager.TotalWagerValue),
 		Valid:   true,
 	}
 
-	err = s.wagerRepo.UpdateWager(ctx, wager)
-	if err != nil {
-		ctxBg, cancel := context.WithTimeout(context.Background(), 3*time.Second)
-		go s.revertPurchase(ctxBg, cancel, purchase.ID)
-		return nil, err
+	// status follows policy, wagers sold out by former policy reopen
+	if left, _ := sellOut.Remaining(*wager); left == 0 {
+		wager.Status = repo.WagerStatusSoldOut
+	} else if wager.Status == repo.WagerStatusSoldOut {
+		wager.Status = repo.WagerStatusOpen
 	}
+}
 
-	// return purchase
-	purchaseDTO := &dto.WagerPurchase{
-		ID:          purchase.ID,
-		WagerID:     purchase.WagerID,
-		BuyingPrice: purchase.BuyingPrice,
-	}
+// lockPurchaseAccounts locks wallets of buyer and seller. Proceeds of anonymous wagers placed by former versions
+// go to external account.
+func (s *PurchaseService) lockPurchaseAccounts(
+	ctx context.Context, buyerID uint32, wager *repo.Wager,
+) (buyer, seller *repo.Account, err error) {
+	if !wager.SellerID.Valid {
+		accounts, err := lockUserAccounts(ctx, s.walletRepo, buyerID)
+		if err != nil {
+			return nil, nil, err
+		}
 
-	if purchase.CreatedAt.Valid {
-		boughtAt := purchase.CreatedAt.Time
-		purchaseDTO.BoughtAt = &boughtAt
-	}
+		seller, err = s.walletRepo.GetExternalAccount(ctx)
+		if err != nil {
+			return nil, nil, err
+		}
 
-	return purchaseDTO, nil
-}
+		return accounts[buyerID], seller, nil
+	}
 
-func (s *PurchaseService) revertPurchase(ctx context.Context, cancel context.CancelFunc, id uint32) {
-	err := s.purchaseRepo.DeletePurchase(ctx, id)
+	sellerID := uint32(wager.SellerID.Int32)
+	accounts, err := lockUserAccounts(ctx, s.walletRepo, buyerID, sellerID)
 	if err != nil {
-		log.Printf("delete purchase error %s", err)
-		return
+		return nil, nil, err
 	}
 
-	log.Printf("purchase record %d deleted", id)
-	cancel()
+	return accounts[buyerID], accounts[sellerID], nil
 }
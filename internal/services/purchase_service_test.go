@@ -4,16 +4,22 @@ import (
 	"context"
 	"database/sql"
 	"errors"
+	"math"
 	"net/http"
 	"testing"
 	"time"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
 
 	"github.com/vitthalaa/wager-app/app_errors"
 	"github.com/vitthalaa/wager-app/dto"
+	"github.com/vitthalaa/wager-app/internal/events"
+	"github.com/vitthalaa/wager-app/internal/logger"
+	"github.com/vitthalaa/wager-app/internal/metrics"
 	"github.com/vitthalaa/wager-app/internal/repo"
+	"github.com/vitthalaa/wager-app/money"
 )
 
 func TestPurchaseService_PurchaseWager(t *testing.T) {
@@ -25,12 +31,20 @@ func TestPurchaseService_PurchaseWager(t *testing.T) {
 		wagerRepoResp  *repo.Wager
 		wagerRepoError error
 
+		// purchaseRepoReq is expected purchase to create when set
+		purchaseRepoReq   *repo.Purchase
 		purchaseRepoResp  *repo.Purchase
 		purchaseRepoError error
 
 		updateWagerRepoReq   *repo.Wager
 		updateWagerRepoError error
 
+		// sellOut defaults to selling percentage policy
+		sellOut *SellOutPolicy
+		// buyerBalance defaults to 100
+		buyerBalance        money.Money
+		expectedTransaction *repo.LedgerTransaction
+
 		expectedRes   *dto.WagerPurchase
 		expectedError error
 	}{
@@ -38,25 +52,35 @@ func TestPurchaseService_PurchaseWager(t *testing.T) {
 			name: "happy path",
 			input: &dto.BuyWagerRequest{
 				WagerID:     111,
-				BuyingPrice: 25.5,
+				BuyerID:     5,
+				BuyingPrice: money.MustParse("25.5"),
 			},
 			wagerRepoResp: &repo.Wager{
 				ID:                  111,
 				TotalWagerValue:     100,
 				Odds:                2,
 				SellingPercentage:   20,
-				SellingPrice:        26,
-				CurrentSellingPrice: 26,
+				SellingPrice:        money.MustParse("26"),
+				CurrentSellingPrice: money.MustParse("26"),
 				CreatedAt: sql.NullTime{
 					Time:  now,
 					Valid: true,
 				},
 			},
 			wagerRepoError: nil,
+			purchaseRepoReq: &repo.Purchase{
+				WagerID:     111,
+				BuyingPrice: money.MustParse("25.5"),
+				BuyerID:     sql.NullInt32{Int32: 5, Valid: true},
+				Quantity:    1,
+				TotalPrice:  money.MustParse("25.5"),
+			},
 			purchaseRepoResp: &repo.Purchase{
 				ID:          1,
 				WagerID:     111,
-				BuyingPrice: 25.5,
+				BuyingPrice: money.MustParse("25.5"),
+				Quantity:    1,
+				TotalPrice:  money.MustParse("25.5"),
 				CreatedAt: sql.NullTime{
 					Time:  now,
 					Valid: true,
@@ -68,8 +92,8 @@ func TestPurchaseService_PurchaseWager(t *testing.T) {
 				TotalWagerValue:     100,
 				Odds:                2,
 				SellingPercentage:   20,
-				SellingPrice:        26,
-				CurrentSellingPrice: 25.5,
+				SellingPrice:        money.MustParse("26"),
+				CurrentSellingPrice: money.MustParse("25.5"),
 				PercentageSold: sql.NullFloat64{
 					Float64: 1,
 					Valid:   true,
@@ -82,16 +106,204 @@ func TestPurchaseService_PurchaseWager(t *testing.T) {
 					Time:  now,
 					Valid: true,
 				},
+				PricedAt: sql.NullTime{
+					Time:  now,
+					Valid: true,
+				},
 			},
 			updateWagerRepoError: nil,
+			expectedTransaction: &repo.LedgerTransaction{
+				Kind:       repo.LedgerTransactionPurchase,
+				PurchaseID: sql.NullInt32{Int32: 1, Valid: true},
+				Entries: []repo.LedgerEntry{
+					{AccountID: 50, Amount: money.MustParse("-25.5")},
+					{AccountID: 1, Amount: money.MustParse("25.5")},
+				},
+			},
 			expectedRes: &dto.WagerPurchase{
 				ID:          1,
 				WagerID:     111,
-				BuyingPrice: 25.5,
+				BuyingPrice: money.MustParse("25.5"),
+				Quantity:    1,
+				TotalPrice:  money.MustParse("25.5"),
 				BoughtAt:    &now,
 			},
 			expectedError: nil,
 		},
+		{
+			name: "several units",
+			input: &dto.BuyWagerRequest{
+				WagerID:     111,
+				BuyerID:     5,
+				BuyingPrice: money.MustParse("25.5"),
+				Quantity:    3,
+			},
+			wagerRepoResp: &repo.Wager{
+				ID:                  111,
+				TotalWagerValue:     100,
+				SellingPercentage:   20,
+				SellingPrice:        money.MustParse("26"),
+				CurrentSellingPrice: money.MustParse("26"),
+				PercentageSold:      sql.NullFloat64{Float64: 2, Valid: true},
+				AmountSold:          sql.NullInt32{Int32: 2, Valid: true},
+			},
+			purchaseRepoReq: &repo.Purchase{
+				WagerID:     111,
+				BuyingPrice: money.MustParse("25.5"),
+				BuyerID:     sql.NullInt32{Int32: 5, Valid: true},
+				Quantity:    3,
+				TotalPrice:  money.MustParse("76.5"),
+			},
+			purchaseRepoResp: &repo.Purchase{
+				ID:          1,
+				WagerID:     111,
+				BuyingPrice: money.MustParse("25.5"),
+				Quantity:    3,
+				TotalPrice:  money.MustParse("76.5"),
+			},
+			updateWagerRepoReq: &repo.Wager{
+				ID:                  111,
+				TotalWagerValue:     100,
+				SellingPercentage:   20,
+				SellingPrice:        money.MustParse("26"),
+				CurrentSellingPrice: money.MustParse("25.5"),
+				PercentageSold:      sql.NullFloat64{Float64: 5, Valid: true},
+				AmountSold:          sql.NullInt32{Int32: 5, Valid: true},
+				PricedAt:            sql.NullTime{Time: now, Valid: true},
+			},
+			expectedTransaction: &repo.LedgerTransaction{
+				Kind:       repo.LedgerTransactionPurchase,
+				PurchaseID: sql.NullInt32{Int32: 1, Valid: true},
+				Entries: []repo.LedgerEntry{
+					{AccountID: 50, Amount: money.MustParse("-76.5")},
+					{AccountID: 1, Amount: money.MustParse("76.5")},
+				},
+			},
+			expectedRes: &dto.WagerPurchase{
+				ID:          1,
+				WagerID:     111,
+				BuyingPrice: money.MustParse("25.5"),
+				Quantity:    3,
+				TotalPrice:  money.MustParse("76.5"),
+			},
+		},
+		{
+			name: "quantity over remaining amount",
+			input: &dto.BuyWagerRequest{
+				WagerID:     111,
+				BuyerID:     5,
+				BuyingPrice: money.MustParse("25.5"),
+				Quantity:    2,
+			},
+			wagerRepoResp: &repo.Wager{
+				ID:                  111,
+				TotalWagerValue:     10,
+				SellingPercentage:   20,
+				SellingPrice:        money.MustParse("26"),
+				CurrentSellingPrice: money.MustParse("26"),
+				AmountSold:          sql.NullInt32{Int32: 1, Valid: true},
+			},
+			expectedError: &app_errors.ErrorResponse{
+				Status: http.StatusBadRequest, Code: app_errors.ErrInvalidQuantity,
+				Details: []app_errors.FieldError{
+					{Field: "quantity", Rule: "max", Message: "must be at most 1", Limits: map[string]string{"max": "1"}},
+				},
+			},
+		},
+		{
+			name: "insufficient funds for all units",
+			input: &dto.BuyWagerRequest{
+				WagerID:     111,
+				BuyerID:     5,
+				BuyingPrice: money.MustParse("25.5"),
+				Quantity:    2,
+			},
+			wagerRepoResp: &repo.Wager{
+				ID:                  111,
+				TotalWagerValue:     100,
+				SellingPercentage:   20,
+				SellingPrice:        money.MustParse("26"),
+				CurrentSellingPrice: money.MustParse("26"),
+			},
+			buyerBalance:  money.MustParse("50.99"),
+			expectedError: &app_errors.ErrorResponse{Status: http.StatusPaymentRequired, Code: app_errors.ErrInsufficientFunds},
+		},
+		{
+			name: "seller gets paid",
+			input: &dto.BuyWagerRequest{
+				WagerID:     111,
+				BuyerID:     5,
+				BuyingPrice: money.MustParse("25.5"),
+			},
+			wagerRepoResp: &repo.Wager{
+				ID:                  111,
+				TotalWagerValue:     100,
+				SellingPercentage:   20,
+				SellingPrice:        money.MustParse("26"),
+				CurrentSellingPrice: money.MustParse("26"),
+				SellerID:            sql.NullInt32{Int32: 9, Valid: true},
+			},
+			purchaseRepoResp: &repo.Purchase{
+				ID:          1,
+				WagerID:     111,
+				BuyingPrice: money.MustParse("25.5"),
+			},
+			updateWagerRepoReq: &repo.Wager{
+				ID:                  111,
+				TotalWagerValue:     100,
+				SellingPercentage:   20,
+				SellingPrice:        money.MustParse("26"),
+				CurrentSellingPrice: money.MustParse("25.5"),
+				PercentageSold: sql.NullFloat64{
+					Float64: 1,
+					Valid:   true,
+				},
+				AmountSold: sql.NullInt32{
+					Int32: 1,
+					Valid: true,
+				},
+				SellerID: sql.NullInt32{Int32: 9, Valid: true},
+				PricedAt: sql.NullTime{Time: now, Valid: true},
+			},
+			expectedTransaction: &repo.LedgerTransaction{
+				Kind:       repo.LedgerTransactionPurchase,
+				PurchaseID: sql.NullInt32{Int32: 1, Valid: true},
+				Entries: []repo.LedgerEntry{
+					{AccountID: 50, Amount: money.MustParse("-25.5")},
+					{AccountID: 90, Amount: money.MustParse("25.5")},
+				},
+			},
+			expectedRes: &dto.WagerPurchase{
+				ID:          1,
+				WagerID:     111,
+				BuyingPrice: money.MustParse("25.5"),
+			},
+		},
+		{
+			name: "insufficient funds",
+			input: &dto.BuyWagerRequest{
+				WagerID:     111,
+				BuyerID:     5,
+				BuyingPrice: money.MustParse("25.5"),
+			},
+			wagerRepoResp: &repo.Wager{
+				ID:                  111,
+				TotalWagerValue:     100,
+				SellingPercentage:   20,
+				SellingPrice:        money.MustParse("26"),
+				CurrentSellingPrice: money.MustParse("26"),
+			},
+			buyerBalance:  money.MustParse("25.49"),
+			expectedError: &app_errors.ErrorResponse{Status: http.StatusPaymentRequired, Code: app_errors.ErrInsufficientFunds},
+		},
+		{
+			name: "anonymous buyer",
+			input: &dto.BuyWagerRequest{
+				WagerID:     111,
+				BuyingPrice: money.MustParse("25.5"),
+			},
+			expectedError: &app_errors.ErrorResponse{Status: http.StatusUnauthorized, Code: app_errors.ErrUnauthorized},
+		},
 		{
 			name:                 "invalid request",
 			input:                nil,
@@ -120,6 +332,7 @@ func TestPurchaseService_PurchaseWager(t *testing.T) {
 			name: "invalid buying price",
 			input: &dto.BuyWagerRequest{
 				WagerID:     111,
+				BuyerID:     5,
 				BuyingPrice: 0,
 			},
 			wagerRepoResp:        nil,
@@ -129,13 +342,36 @@ func TestPurchaseService_PurchaseWager(t *testing.T) {
 			updateWagerRepoReq:   nil,
 			updateWagerRepoError: nil,
 			expectedRes:          nil,
-			expectedError:        &app_errors.ErrorResponse{Status: http.StatusBadRequest, Code: app_errors.ErrInvalidBuyingPrice},
+			expectedError: &app_errors.ErrorResponse{
+				Status: http.StatusBadRequest, Code: app_errors.ErrInvalidBuyingPrice,
+				Details: []app_errors.FieldError{
+					{Field: "buying_price", Rule: "min", Message: "must be at least 1.00", Limits: map[string]string{"min": "1.00"}},
+				},
+			},
+		},
+		{
+			// product of these overflowed into negative total paying seller from buyer's wallet in reverse
+			name: "buying price and quantity over limits",
+			input: &dto.BuyWagerRequest{
+				WagerID:     111,
+				BuyerID:     5,
+				BuyingPrice: money.MustParse("100000000000.00"),
+				Quantity:    1000000 + 1,
+			},
+			expectedError: &app_errors.ErrorResponse{
+				Status: http.StatusBadRequest, Code: app_errors.ErrInvalidBuyingPrice,
+				Details: []app_errors.FieldError{
+					{Field: "buying_price", Rule: "max", Message: "must be at most 1000000000.00", Limits: map[string]string{"max": "1000000000.00"}},
+					{Field: "quantity", Rule: "max", Message: "must be at most 1000000", Limits: map[string]string{"max": "1000000"}},
+				},
+			},
 		},
 		{
 			name: "get wager repo not found error",
 			input: &dto.BuyWagerRequest{
 				WagerID:     111,
-				BuyingPrice: 22,
+				BuyerID:     5,
+				BuyingPrice: money.MustParse("22"),
 			},
 			wagerRepoResp:        nil,
 			wagerRepoError:       sql.ErrNoRows,
@@ -150,7 +386,8 @@ func TestPurchaseService_PurchaseWager(t *testing.T) {
 			name: "get wager repo unknown error",
 			input: &dto.BuyWagerRequest{
 				WagerID:     111,
-				BuyingPrice: 22,
+				BuyerID:     5,
+				BuyingPrice: money.MustParse("22"),
 			},
 			wagerRepoResp:        nil,
 			wagerRepoError:       errors.New("some repo error"),
@@ -165,15 +402,16 @@ func TestPurchaseService_PurchaseWager(t *testing.T) {
 			name: "request buying price is greater than current selling error",
 			input: &dto.BuyWagerRequest{
 				WagerID:     111,
-				BuyingPrice: 27,
+				BuyerID:     5,
+				BuyingPrice: money.MustParse("27"),
 			},
 			wagerRepoResp: &repo.Wager{
 				ID:                  111,
 				TotalWagerValue:     100,
 				Odds:                2,
 				SellingPercentage:   20,
-				SellingPrice:        26,
-				CurrentSellingPrice: 26,
+				SellingPrice:        money.MustParse("26"),
+				CurrentSellingPrice: money.MustParse("26"),
 				CreatedAt: sql.NullTime{
 					Time:  now,
 					Valid: true,
@@ -185,21 +423,277 @@ func TestPurchaseService_PurchaseWager(t *testing.T) {
 			updateWagerRepoReq:   nil,
 			updateWagerRepoError: nil,
 			expectedRes:          nil,
-			expectedError:        &app_errors.ErrorResponse{Status: http.StatusBadRequest, Code: app_errors.ErrInvalidBuyingPrice},
+			expectedError: &app_errors.ErrorResponse{
+				Status: http.StatusBadRequest, Code: app_errors.ErrInvalidBuyingPrice,
+				Details: []app_errors.FieldError{
+					{Field: "buying_price", Rule: "max", Message: "must be at most 26.00", Limits: map[string]string{"max": "26.00"}},
+				},
+			},
+		},
+		{
+			name: "request buying price is greater than decayed selling price error",
+			input: &dto.BuyWagerRequest{
+				WagerID:     111,
+				BuyerID:     5,
+				BuyingPrice: money.MustParse("25.5"),
+			},
+			// half of decay seconds passed since placing
+			wagerRepoResp: &repo.Wager{
+				ID:                  111,
+				TotalWagerValue:     100,
+				Odds:                2,
+				SellingPercentage:   20,
+				SellingPrice:        money.MustParse("26"),
+				CurrentSellingPrice: money.MustParse("26"),
+				CreatedAt:           sql.NullTime{Time: now.Add(-30 * time.Minute), Valid: true},
+				Pricing:             repo.WagerPricingDutchAuction,
+				FloorPrice:          money.MustParse("16"),
+				DecaySeconds:        3600,
+			},
+			expectedError: &app_errors.ErrorResponse{
+				Status: http.StatusBadRequest, Code: app_errors.ErrInvalidBuyingPrice,
+				Details: []app_errors.FieldError{
+					{Field: "buying_price", Rule: "max", Message: "must be at most 21.00", Limits: map[string]string{"max": "21.00"}},
+				},
+			},
+		},
+		{
+			name: "demand step up raises current selling price",
+			input: &dto.BuyWagerRequest{
+				WagerID:     111,
+				BuyerID:     5,
+				BuyingPrice: money.MustParse("25.5"),
+				Quantity:    2,
+			},
+			wagerRepoResp: &repo.Wager{
+				ID:                  111,
+				TotalWagerValue:     100,
+				SellingPercentage:   20,
+				SellingPrice:        money.MustParse("26"),
+				CurrentSellingPrice: money.MustParse("26"),
+				Pricing:             repo.WagerPricingDemandStepUp,
+				StepPercentage:      5,
+			},
+			purchaseRepoResp: &repo.Purchase{ID: 4, WagerID: 111, BuyingPrice: money.MustParse("25.5"), Quantity: 2},
+			updateWagerRepoReq: &repo.Wager{
+				ID:                  111,
+				TotalWagerValue:     100,
+				SellingPercentage:   20,
+				SellingPrice:        money.MustParse("26"),
+				CurrentSellingPrice: money.MustParse("28.6"),
+				PercentageSold:      sql.NullFloat64{Float64: 2, Valid: true},
+				AmountSold:          sql.NullInt32{Int32: 2, Valid: true},
+				Pricing:             repo.WagerPricingDemandStepUp,
+				StepPercentage:      5,
+				PricedAt:            sql.NullTime{Time: now, Valid: true},
+			},
+			expectedRes: &dto.WagerPurchase{ID: 4, WagerID: 111, BuyingPrice: money.MustParse("25.5"), Quantity: 2},
+		},
+		{
+			name: "settled wager error",
+			input: &dto.BuyWagerRequest{
+				WagerID:     111,
+				BuyerID:     5,
+				BuyingPrice: money.MustParse("25.5"),
+			},
+			wagerRepoResp: &repo.Wager{
+				ID:                  111,
+				TotalWagerValue:     100,
+				Odds:                2,
+				SellingPercentage:   20,
+				SellingPrice:        money.MustParse("26"),
+				CurrentSellingPrice: money.MustParse("26"),
+				Status:              repo.WagerStatusSettled,
+			},
+			wagerRepoError:       nil,
+			purchaseRepoResp:     nil,
+			purchaseRepoError:    nil,
+			updateWagerRepoReq:   nil,
+			updateWagerRepoError: nil,
+			expectedRes:          nil,
+			expectedError:        &app_errors.ErrorResponse{Status: http.StatusConflict, Code: app_errors.ErrWagerSettled},
+		},
+		{
+			name: "open auction error",
+			input: &dto.BuyWagerRequest{
+				WagerID:     111,
+				BuyerID:     5,
+				BuyingPrice: money.MustParse("25.5"),
+			},
+			wagerRepoResp: &repo.Wager{
+				ID:                  111,
+				TotalWagerValue:     100,
+				Odds:                2,
+				SellingPercentage:   20,
+				SellingPrice:        money.MustParse("26"),
+				CurrentSellingPrice: money.MustParse("26"),
+				Status:              repo.WagerStatusOpen,
+				Auction:             repo.WagerAuctionSecondPrice,
+				AuctionEndsAt:       sql.NullTime{Time: now.Add(time.Hour), Valid: true},
+			},
+			expectedError: &app_errors.ErrorResponse{Status: http.StatusConflict, Code: app_errors.ErrAuctionOpen},
+		},
+		{
+			name: "sold out wager error",
+			input: &dto.BuyWagerRequest{
+				WagerID:     111,
+				BuyerID:     5,
+				BuyingPrice: money.MustParse("25.5"),
+			},
+			// selling percentage of total wager value is sold
+			wagerRepoResp: &repo.Wager{
+				ID:                  111,
+				TotalWagerValue:     10,
+				Odds:                2,
+				SellingPercentage:   20,
+				SellingPrice:        money.MustParse("26"),
+				CurrentSellingPrice: money.MustParse("26"),
+				AmountSold:          sql.NullInt32{Int32: 2, Valid: true},
+				Status:              repo.WagerStatusOpen,
+			},
+			expectedError: &app_errors.ErrorResponse{Status: http.StatusNotAcceptable, Code: app_errors.ErrWagerSoldOut},
+		},
+		{
+			name: "last unit marks wager sold out",
+			input: &dto.BuyWagerRequest{
+				WagerID:     111,
+				BuyerID:     5,
+				BuyingPrice: money.MustParse("25.5"),
+			},
+			wagerRepoResp: &repo.Wager{
+				ID:                  111,
+				TotalWagerValue:     10,
+				Odds:                2,
+				SellingPercentage:   20,
+				SellingPrice:        money.MustParse("26"),
+				CurrentSellingPrice: money.MustParse("26"),
+				PercentageSold: sql.NullFloat64{
+					Float64: 10,
+					Valid:   true,
+				},
+				AmountSold: sql.NullInt32{
+					Int32: 1,
+					Valid: true,
+				},
+				Status: repo.WagerStatusOpen,
+			},
+			wagerRepoError: nil,
+			purchaseRepoResp: &repo.Purchase{
+				ID:          2,
+				WagerID:     111,
+				BuyingPrice: money.MustParse("25.5"),
+			},
+			purchaseRepoError: nil,
+			updateWagerRepoReq: &repo.Wager{
+				ID:                  111,
+				TotalWagerValue:     10,
+				Odds:                2,
+				SellingPercentage:   20,
+				SellingPrice:        money.MustParse("26"),
+				CurrentSellingPrice: money.MustParse("25.5"),
+				PercentageSold: sql.NullFloat64{
+					Float64: 20,
+					Valid:   true,
+				},
+				AmountSold: sql.NullInt32{
+					Int32: 2,
+					Valid: true,
+				},
+				Status:   repo.WagerStatusSoldOut,
+				PricedAt: sql.NullTime{Time: now, Valid: true},
+			},
+			updateWagerRepoError: nil,
+			expectedRes: &dto.WagerPurchase{
+				ID:          2,
+				WagerID:     111,
+				BuyingPrice: money.MustParse("25.5"),
+			},
+			expectedError: nil,
+		},
+		{
+			name: "total value policy sells over selling percentage",
+			input: &dto.BuyWagerRequest{
+				WagerID:     111,
+				BuyerID:     5,
+				BuyingPrice: money.MustParse("25.5"),
+			},
+			sellOut: NewSellOutPolicy(TotalValueRule{}),
+			// sold out by former policy
+			wagerRepoResp: &repo.Wager{
+				ID:                  111,
+				TotalWagerValue:     10,
+				SellingPercentage:   20,
+				SellingPrice:        money.MustParse("26"),
+				CurrentSellingPrice: money.MustParse("26"),
+				AmountSold:          sql.NullInt32{Int32: 2, Valid: true},
+				Status:              repo.WagerStatusSoldOut,
+			},
+			purchaseRepoResp: &repo.Purchase{ID: 3, WagerID: 111, BuyingPrice: money.MustParse("25.5"), Quantity: 1},
+			updateWagerRepoReq: &repo.Wager{
+				ID:                  111,
+				TotalWagerValue:     10,
+				SellingPercentage:   20,
+				SellingPrice:        money.MustParse("26"),
+				CurrentSellingPrice: money.MustParse("25.5"),
+				PercentageSold:      sql.NullFloat64{Float64: 30, Valid: true},
+				AmountSold:          sql.NullInt32{Int32: 3, Valid: true},
+				Status:              repo.WagerStatusOpen,
+				PricedAt:            sql.NullTime{Time: now, Valid: true},
+			},
+			expectedRes: &dto.WagerPurchase{ID: 3, WagerID: 111, BuyingPrice: money.MustParse("25.5"), Quantity: 1},
+		},
+		{
+			name: "total value sold error",
+			input: &dto.BuyWagerRequest{
+				WagerID:     111,
+				BuyerID:     5,
+				BuyingPrice: money.MustParse("25.5"),
+			},
+			sellOut: NewSellOutPolicy(SellingPercentageRule{}, TotalValueRule{}),
+			wagerRepoResp: &repo.Wager{
+				ID:                  111,
+				TotalWagerValue:     10,
+				SellingPercentage:   100,
+				SellingPrice:        money.MustParse("26"),
+				CurrentSellingPrice: money.MustParse("26"),
+				AmountSold:          sql.NullInt32{Int32: 10, Valid: true},
+				Status:              repo.WagerStatusSoldOut,
+			},
+			// first of rules reached at once rejects
+			expectedError: &app_errors.ErrorResponse{Status: http.StatusNotAcceptable, Code: app_errors.ErrWagerSoldOut},
+		},
+		{
+			name: "max units reached error",
+			input: &dto.BuyWagerRequest{
+				WagerID:     111,
+				BuyerID:     5,
+				BuyingPrice: money.MustParse("25.5"),
+			},
+			sellOut: NewSellOutPolicy(SellingPercentageRule{}, MaxUnitsRule{Units: 2}),
+			wagerRepoResp: &repo.Wager{
+				ID:                  111,
+				TotalWagerValue:     100,
+				SellingPercentage:   20,
+				SellingPrice:        money.MustParse("26"),
+				CurrentSellingPrice: money.MustParse("26"),
+				AmountSold:          sql.NullInt32{Int32: 2, Valid: true},
+			},
+			expectedError: &app_errors.ErrorResponse{Status: http.StatusNotAcceptable, Code: app_errors.ErrSellOutLimitReached},
 		},
 		{
 			name: "purchase repo error",
 			input: &dto.BuyWagerRequest{
 				WagerID:     111,
-				BuyingPrice: 25.5,
+				BuyerID:     5,
+				BuyingPrice: money.MustParse("25.5"),
 			},
 			wagerRepoResp: &repo.Wager{
 				ID:                  111,
 				TotalWagerValue:     100,
 				Odds:                2,
 				SellingPercentage:   20,
-				SellingPrice:        26,
-				CurrentSellingPrice: 26,
+				SellingPrice:        money.MustParse("26"),
+				CurrentSellingPrice: money.MustParse("26"),
 				CreatedAt: sql.NullTime{
 					Time:  now,
 					Valid: true,
@@ -217,15 +711,16 @@ func TestPurchaseService_PurchaseWager(t *testing.T) {
 			name: "update wager error",
 			input: &dto.BuyWagerRequest{
 				WagerID:     111,
-				BuyingPrice: 25.5,
+				BuyerID:     5,
+				BuyingPrice: money.MustParse("25.5"),
 			},
 			wagerRepoResp: &repo.Wager{
 				ID:                  111,
 				TotalWagerValue:     100,
 				Odds:                2,
 				SellingPercentage:   20,
-				SellingPrice:        26,
-				CurrentSellingPrice: 26,
+				SellingPrice:        money.MustParse("26"),
+				CurrentSellingPrice: money.MustParse("26"),
 				CreatedAt: sql.NullTime{
 					Time:  now,
 					Valid: true,
@@ -235,7 +730,7 @@ func TestPurchaseService_PurchaseWager(t *testing.T) {
 			purchaseRepoResp: &repo.Purchase{
 				ID:          1,
 				WagerID:     111,
-				BuyingPrice: 25.5,
+				BuyingPrice: money.MustParse("25.5"),
 				CreatedAt: sql.NullTime{
 					Time:  now,
 					Valid: true,
@@ -247,8 +742,8 @@ func TestPurchaseService_PurchaseWager(t *testing.T) {
 				TotalWagerValue:     100,
 				Odds:                2,
 				SellingPercentage:   20,
-				SellingPrice:        26,
-				CurrentSellingPrice: 25.5,
+				SellingPrice:        money.MustParse("26"),
+				CurrentSellingPrice: money.MustParse("25.5"),
 				PercentageSold: sql.NullFloat64{
 					Float64: 1,
 					Valid:   true,
@@ -261,6 +756,10 @@ func TestPurchaseService_PurchaseWager(t *testing.T) {
 					Time:  now,
 					Valid: true,
 				},
+				PricedAt: sql.NullTime{
+					Time:  now,
+					Valid: true,
+				},
 			},
 			updateWagerRepoError: errors.New("some update wager repo error"),
 			expectedRes:          nil,
@@ -271,29 +770,389 @@ func TestPurchaseService_PurchaseWager(t *testing.T) {
 			ctx := context.Background()
 			mockWagerRepo := new(MockWagerRepo)
 			if tc.input != nil {
-				mockWagerRepo.On("GetWagerByID", ctx, tc.input.WagerID).
+				mockWagerRepo.On("GetWagerByIDForUpdate", ctx, tc.input.WagerID).
 					Return(tc.wagerRepoResp, tc.wagerRepoError)
 			}
 
+			var expectedPurchase interface{} = mock.Anything
+			if tc.purchaseRepoReq != nil {
+				expectedPurchase = tc.purchaseRepoReq
+			}
+
 			mockPurchaseRepo := new(MockPurchaseRepo)
-			mockPurchaseRepo.On("CreatePurchase", ctx, mock.Anything).
+			mockPurchaseRepo.On("CreatePurchase", ctx, expectedPurchase).
 				Return(tc.purchaseRepoResp, tc.purchaseRepoError)
 
-			if tc.input != nil && tc.input.WagerID != 0 {
-				mockPurchaseRepo.On("DeletePurchase", mock.Anything, mock.Anything).
-					Return(nil)
-			}
-
 			mockWagerRepo.On("UpdateWager", ctx, tc.updateWagerRepoReq).
 				Return(tc.updateWagerRepoError)
 
-			service := NewPurchaseService(mockPurchaseRepo, mockWagerRepo)
+			buyerBalance := money.MustParse("100")
+			if tc.buyerBalance > 0 {
+				buyerBalance = tc.buyerBalance
+			}
+
+			mockWalletRepo := new(MockWalletRepo)
+			mockWalletRepo.On("GetAccountByUserIDForUpdate", ctx, uint32(5)).
+				Return(&repo.Account{ID: 50, Kind: repo.AccountKindUser, Balance: buyerBalance}, nil)
+			mockWalletRepo.On("GetAccountByUserIDForUpdate", ctx, uint32(9)).
+				Return(&repo.Account{ID: 90, Kind: repo.AccountKindUser}, nil)
+			mockWalletRepo.On("GetExternalAccount", ctx).
+				Return(&repo.Account{ID: 1, Kind: repo.AccountKindExternal}, nil)
+
+			var expectedTransaction interface{} = mock.Anything
+			if tc.expectedTransaction != nil {
+				expectedTransaction = tc.expectedTransaction
+			}
+
+			mockWalletRepo.On("CreateLedgerTransaction", ctx, expectedTransaction).
+				Return(tc.expectedTransaction, nil)
+
+			mockTransactor := new(MockTransactor)
+			mockTransactor.On("WithinTransaction", ctx, mock.Anything).
+				Return(func(ctx context.Context, fn func(context.Context) error) error {
+					return fn(ctx)
+				})
+
+			appMetrics := metrics.New()
+			broker := events.NewBroker(1)
+			sub, _, err := broker.Subscribe(0, 0)
+			require.Nil(t, err)
+
+			sellOut := tc.sellOut
+			if sellOut == nil {
+				sellOut = NewSellOutPolicy(SellingPercentageRule{})
+			}
+
+			mockOrderRepo := new(MockOrderRepo)
+			mockOrderRepo.On("CancelOpenOrders", ctx, mock.Anything).Return(nil)
+
+			service := NewPurchaseService(mockTransactor, mockPurchaseRepo, mockWagerRepo, mockWalletRepo, mockOrderRepo, logger.Discard(), appMetrics, broker, sellOut)
+			service.now = func() time.Time { return now }
 
 			wagerPurchase, err := service.PurchaseWager(ctx, tc.input)
 
 			assert.Equal(t, tc.expectedError, err)
 			assert.EqualValues(t, tc.expectedRes, wagerPurchase)
+			if tc.expectedTransaction != nil {
+				mockWalletRepo.AssertCalled(t, "CreateLedgerTransaction", ctx, tc.expectedTransaction)
+			}
+
+			if errRes, ok := tc.expectedError.(*app_errors.ErrorResponse); ok && errRes.Status == http.StatusBadRequest {
+				mockWalletRepo.AssertNotCalled(t, "CreateLedgerTransaction", ctx, mock.Anything)
+			}
+
+			var purchases, soldOutRejections float64
+			if tc.expectedError == nil {
+				purchases = 1
+			}
 
+			if errRes, ok := tc.expectedError.(*app_errors.ErrorResponse); ok && errRes.Status == http.StatusNotAcceptable {
+				soldOutRejections = 1
+			}
+
+			assert.Equal(t, purchases, appMetrics.WagerPurchases.Value())
+			assert.Equal(t, soldOutRejections, appMetrics.SoldOutRejections.Value())
+
+			// events are published only for committed purchases, open orders of wager sold out are cancelled
+			var expectedEvents, published []string
+			if tc.expectedError == nil {
+				expectedEvents = append(expectedEvents, events.TypePriceChanged)
+				if tc.updateWagerRepoReq.Status == repo.WagerStatusSoldOut {
+					expectedEvents = append(expectedEvents, events.TypeSoldOut)
+					mockOrderRepo.AssertCalled(t, "CancelOpenOrders", ctx, tc.input.WagerID)
+				}
+			} else {
+				mockOrderRepo.AssertNotCalled(t, "CancelOpenOrders", ctx, mock.Anything)
+			}
+
+			for len(sub.Events()) > 0 {
+				event := <-sub.Events()
+				assert.Equal(t, tc.input.WagerID, event.Wager.ID)
+				assert.Equal(t, tc.updateWagerRepoReq.CurrentSellingPrice, event.Wager.CurrentSellingPrice)
+				published = append(published, event.Type)
+			}
+
+			assert.Equal(t, expectedEvents, published)
+		})
+	}
+}
+
+func Test_totalPrice(t *testing.T) {
+	for _, tc := range []struct {
+		name     string
+		price    money.Money
+		quantity uint32
+
+		expected      money.Money
+		expectedError error
+	}{
+		{
+			name:     "several units",
+			price:    money.MustParse("25.50"),
+			quantity: 3,
+			expected: money.MustParse("76.50"),
+		},
+		{
+			name:          "overflow",
+			price:         money.MustParse("100000000000.00"),
+			quantity:      1000000,
+			expectedError: &app_errors.ErrorResponse{Status: http.StatusBadRequest, Code: app_errors.ErrInvalidQuantity},
+		},
+		{
+			name:          "over max amount",
+			price:         money.MustParse("1000000000.00"),
+			quantity:      1000000,
+			expectedError: &app_errors.ErrorResponse{Status: http.StatusBadRequest, Code: app_errors.ErrInvalidQuantity},
+		},
+		{
+			name:     "max amount",
+			price:    money.MustParse("999999999.99"),
+			quantity: 1000,
+			expected: money.MustParse("999999999990.00"),
+		},
+		{
+			name:          "zero quantity",
+			price:         money.MustParse("25.50"),
+			expectedError: &app_errors.ErrorResponse{Status: http.StatusBadRequest, Code: app_errors.ErrInvalidQuantity},
+		},
+		{
+			name:          "negative price",
+			price:         money.MustParse("-1.00"),
+			quantity:      1,
+			expectedError: &app_errors.ErrorResponse{Status: http.StatusBadRequest, Code: app_errors.ErrInvalidQuantity},
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			total, err := totalPrice(tc.price, tc.quantity)
+			assert.Equal(t, tc.expectedError, err)
+			assert.Equal(t, tc.expected, total)
+		})
+	}
+}
+
+func TestPurchaseService_ListPurchases(t *testing.T) {
+	now := time.Now()
+	from := now.Add(-time.Hour)
+	repoPurchases := []repo.Purchase{
+		{
+			ID:          2,
+			WagerID:     111,
+			BuyingPrice: money.MustParse("20.5"),
+			CreatedAt: sql.NullTime{
+				Time:  now,
+				Valid: true,
+			},
+		},
+	}
+
+	for _, tc := range []struct {
+		name string
+		req  *dto.ListPurchaseRequest
+
+		expectedFilter     repo.PurchaseFilter
+		purchasesRepoResp  []repo.Purchase
+		purchasesRepoError error
+		countRepoResp      uint32
+		countRepoError     error
+
+		expectedRes   *dto.WagerPurchaseList
+		expectedError error
+	}{
+		{
+			name: "happy path with defaults",
+			req:  &dto.ListPurchaseRequest{},
+			expectedFilter: repo.PurchaseFilter{
+				SortBy:   repo.PurchaseSortID,
+				SortDesc: true,
+				Limit:    10,
+			},
+			purchasesRepoResp: repoPurchases,
+			countRepoResp:     1,
+			expectedRes: &dto.WagerPurchaseList{
+				Items: []dto.WagerPurchase{
+					{
+						ID:          2,
+						WagerID:     111,
+						BuyingPrice: money.MustParse("20.5"),
+						BoughtAt:    &now,
+					},
+				},
+				Page:  1,
+				Limit: 10,
+				Total: 1,
+			},
+		},
+		{
+			name:          "limit over max page size",
+			req:           &dto.ListPurchaseRequest{Limit: MaxPageSize + 1},
+			expectedError: &app_errors.ErrorResponse{Status: http.StatusBadRequest, Code: app_errors.ErrInvalidFilter},
+		},
+		{
+			name: "page past range of offset",
+			req:  &dto.ListPurchaseRequest{Page: math.MaxUint32, Limit: MaxPageSize},
+			expectedFilter: repo.PurchaseFilter{
+				SortBy:   repo.PurchaseSortID,
+				SortDesc: true,
+				Offset:   4294967200,
+				Limit:    MaxPageSize,
+			},
+			purchasesRepoResp: []repo.Purchase{},
+			countRepoResp:     1,
+			expectedRes: &dto.WagerPurchaseList{
+				Items: []dto.WagerPurchase{},
+				Page:  42949673,
+				Limit: MaxPageSize,
+				Total: 1,
+			},
+		},
+		{
+			name: "happy path with filters",
+			req: &dto.ListPurchaseRequest{
+				WagerID:  111,
+				MinPrice: money.MustParse("10"),
+				MaxPrice: money.MustParse("30"),
+				From:     &from,
+				To:       &now,
+				Sort:     "bought_at",
+				Page:     2,
+				Limit:    5,
+			},
+			expectedFilter: repo.PurchaseFilter{
+				WagerID:  111,
+				MinPrice: money.MustParse("10"),
+				MaxPrice: money.MustParse("30"),
+				From:     from,
+				To:       now,
+				SortBy:   repo.PurchaseSortCreatedAt,
+				Offset:   5,
+				Limit:    5,
+			},
+			purchasesRepoResp: []repo.Purchase{},
+			countRepoResp:     3,
+			expectedRes: &dto.WagerPurchaseList{
+				Items: []dto.WagerPurchase{},
+				Page:  2,
+				Limit: 5,
+				Total: 3,
+			},
+		},
+		{
+			name:          "min price greater than max price",
+			req:           &dto.ListPurchaseRequest{MinPrice: money.MustParse("30"), MaxPrice: money.MustParse("10")},
+			expectedError: &app_errors.ErrorResponse{Status: http.StatusBadRequest, Code: app_errors.ErrInvalidFilter},
+		},
+		{
+			name:          "from after to",
+			req:           &dto.ListPurchaseRequest{From: &now, To: &from},
+			expectedError: &app_errors.ErrorResponse{Status: http.StatusBadRequest, Code: app_errors.ErrInvalidFilter},
+		},
+		{
+			name:          "unknown sort field",
+			req:           &dto.ListPurchaseRequest{Sort: "-wager_id"},
+			expectedError: &app_errors.ErrorResponse{Status: http.StatusBadRequest, Code: app_errors.ErrInvalidSort},
+		},
+		{
+			name: "purchase repo error",
+			req:  &dto.ListPurchaseRequest{Sort: "-buying_price"},
+			expectedFilter: repo.PurchaseFilter{
+				SortBy:   repo.PurchaseSortBuyingPrice,
+				SortDesc: true,
+				Limit:    10,
+			},
+			purchasesRepoError: errors.New("some purchase repo error"),
+			expectedError:      errors.New("some purchase repo error"),
+		},
+		{
+			name: "count repo error",
+			req:  &dto.ListPurchaseRequest{},
+			expectedFilter: repo.PurchaseFilter{
+				SortBy:   repo.PurchaseSortID,
+				SortDesc: true,
+				Limit:    10,
+			},
+			purchasesRepoResp: repoPurchases,
+			countRepoError:    errors.New("some count repo error"),
+			expectedError:     errors.New("some count repo error"),
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			ctx := context.Background()
+			mockPurchaseRepo := new(MockPurchaseRepo)
+			mockPurchaseRepo.On("ListPurchases", ctx, tc.expectedFilter).
+				Return(tc.purchasesRepoResp, tc.purchasesRepoError)
+			mockPurchaseRepo.On("CountPurchases", ctx, tc.expectedFilter).
+				Return(tc.countRepoResp, tc.countRepoError)
+
+			service := NewPurchaseService(new(MockTransactor), mockPurchaseRepo, new(MockWagerRepo), new(MockWalletRepo), new(MockOrderRepo), logger.Discard(), metrics.New(), events.NewBroker(1), NewSellOutPolicy())
+
+			res, err := service.ListPurchases(ctx, tc.req)
+
+			assert.Equal(t, tc.expectedError, err)
+			assert.Equal(t, tc.expectedRes, res)
+		})
+	}
+}
+
+func TestPurchaseService_GetPurchase(t *testing.T) {
+	now := time.Now()
+	for _, tc := range []struct {
+		name string
+		id   uint32
+
+		repoResp  *repo.Purchase
+		repoError error
+
+		expectedRes   *dto.WagerPurchase
+		expectedError error
+	}{
+		{
+			name: "happy path",
+			id:   1,
+			repoResp: &repo.Purchase{
+				ID:          1,
+				WagerID:     111,
+				BuyingPrice: money.MustParse("20.5"),
+				CreatedAt: sql.NullTime{
+					Time:  now,
+					Valid: true,
+				},
+			},
+			expectedRes: &dto.WagerPurchase{
+				ID:          1,
+				WagerID:     111,
+				BuyingPrice: money.MustParse("20.5"),
+				BoughtAt:    &now,
+			},
+		},
+		{
+			name:          "zero id",
+			expectedError: &app_errors.ErrorResponse{Status: http.StatusNotFound, Code: app_errors.ErrNotFound},
+		},
+		{
+			name:          "purchase not found",
+			id:            1,
+			repoError:     sql.ErrNoRows,
+			expectedError: &app_errors.ErrorResponse{Status: http.StatusNotFound, Code: app_errors.ErrNotFound},
+		},
+		{
+			name:          "repo error",
+			id:            1,
+			repoError:     errors.New("some repo error"),
+			expectedError: errors.New("some repo error"),
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			ctx := context.Background()
+			mockPurchaseRepo := new(MockPurchaseRepo)
+			mockPurchaseRepo.On("GetPurchaseByID", ctx, tc.id).
+				Return(tc.repoResp, tc.repoError)
+
+			service := NewPurchaseService(new(MockTransactor), mockPurchaseRepo, new(MockWagerRepo), new(MockWalletRepo), new(MockOrderRepo), logger.Discard(), metrics.New(), events.NewBroker(1), NewSellOutPolicy())
+
+			res, err := service.GetPurchase(ctx, tc.id)
+
+			assert.Equal(t, tc.expectedError, err)
+			assert.Equal(t, tc.expectedRes, res)
 		})
 	}
 }
@@ -0,0 +1,138 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+
+	"github.com/vitthalaa/wager-app/app_errors"
+	"github.com/vitthalaa/wager-app/internal/repo"
+)
+
+// Sell out rule names of ParseSellOutPolicy, max units rule is configured as max_units:<n>
+const (
+	RuleSellingPercentage = "percentage"
+	RuleTotalValue        = "total"
+	RuleMaxUnits          = "max_units"
+)
+
+// SellOutRule limits units of wager on sale. Implement it for custom rules.
+type SellOutRule interface {
+	// Limit returns units of wager on sale
+	Limit(wager repo.Wager) uint32
+	// Code is error code of purchases rejected once limit is sold
+	Code() app_errors.ErrorCode
+}
+
+// SellingPercentageRule sells selling percentage of total wager value rounded down, at least one unit
+type SellingPercentageRule struct{}
+
+// Limit ...
+func (SellingPercentageRule) Limit(wager repo.Wager) uint32 {
+	// tolerates float32 representation error of selling percentage
+	limit := uint32(math.Floor(float64(wager.TotalWagerValue)*float64(wager.SellingPercentage)/100 + 1e-6))
+	if limit == 0 {
+		return 1
+	}
+
+	return limit
+}
+
+// Code ...
+func (SellingPercentageRule) Code() app_errors.ErrorCode {
+	return app_errors.ErrWagerSoldOut
+}
+
+// TotalValueRule sells whole total wager value regardless of selling percentage
+type TotalValueRule struct{}
+
+// Limit ...
+func (TotalValueRule) Limit(wager repo.Wager) uint32 {
+	return wager.TotalWagerValue
+}
+
+// Code ...
+func (TotalValueRule) Code() app_errors.ErrorCode {
+	return app_errors.ErrTotalValueSold
+}
+
+// MaxUnitsRule sells at most Units of every wager
+type MaxUnitsRule struct {
+	Units uint32
+}
+
+// Limit ...
+func (r MaxUnitsRule) Limit(repo.Wager) uint32 {
+	return r.Units
+}
+
+// Code ...
+func (MaxUnitsRule) Code() app_errors.ErrorCode {
+	return app_errors.ErrSellOutLimitReached
+}
+
+// SellOutPolicy sells units of wager until any of its rules is reached
+type SellOutPolicy struct {
+	rules []SellOutRule
+}
+
+// NewSellOutPolicy returns policy of rules, in order of precedence when several are reached at once.
+// Policy without rules sells whole total wager value.
+func NewSellOutPolicy(rules ...SellOutRule) *SellOutPolicy {
+	if len(rules) == 0 {
+		rules = []SellOutRule{TotalValueRule{}}
+	}
+
+	return &SellOutPolicy{rules: rules}
+}
+
+// ParseSellOutPolicy returns policy of rules by name, ex. ["percentage", "max_units:50"]
+func ParseSellOutPolicy(names []string) (*SellOutPolicy, error) {
+	if len(names) == 0 {
+		return nil, errors.New("no sell out rules")
+	}
+
+	rules := make([]SellOutRule, 0, len(names))
+	for _, name := range names {
+		rule, arg, hasArg := strings.Cut(name, ":")
+		switch {
+		case rule == RuleSellingPercentage && !hasArg:
+			rules = append(rules, SellingPercentageRule{})
+		case rule == RuleTotalValue && !hasArg:
+			rules = append(rules, TotalValueRule{})
+		case rule == RuleMaxUnits && hasArg:
+			units, err := strconv.ParseUint(arg, 10, 32)
+			if err != nil || units == 0 {
+				return nil, fmt.Errorf("invalid units of sell out rule %q", name)
+			}
+
+			rules = append(rules, MaxUnitsRule{Units: uint32(units)})
+		default:
+			return nil, fmt.Errorf("unknown sell out rule %q", name)
+		}
+	}
+
+	return NewSellOutPolicy(rules...), nil
+}
+
+// Remaining returns units of wager left to sell and rule limiting them
+func (p *SellOutPolicy) Remaining(wager repo.Wager) (uint32, SellOutRule) {
+	sold := uint32(wager.AmountSold.Int32)
+
+	var remaining uint32
+	var limiting SellOutRule
+	for _, rule := range p.rules {
+		var left uint32
+		if limit := rule.Limit(wager); limit > sold {
+			left = limit - sold
+		}
+
+		if limiting == nil || left < remaining {
+			remaining, limiting = left, rule
+		}
+	}
+
+	return remaining, limiting
+}
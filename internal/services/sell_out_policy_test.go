@@ -0,0 +1,147 @@
+package services
+
+import (
+	"database/sql"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/vitthalaa/wager-app/app_errors"
+	"github.com/vitthalaa/wager-app/internal/repo"
+)
+
+func TestParseSellOutPolicy(t *testing.T) {
+	for _, tc := range []struct {
+		name  string
+		rules []string
+
+		expected      *SellOutPolicy
+		expectedError error
+	}{
+		{
+			name:     "single rule",
+			rules:    []string{"percentage"},
+			expected: NewSellOutPolicy(SellingPercentageRule{}),
+		},
+		{
+			name:     "rules in order",
+			rules:    []string{"max_units:50", "total"},
+			expected: NewSellOutPolicy(MaxUnitsRule{Units: 50}, TotalValueRule{}),
+		},
+		{
+			name:          "no rules",
+			expectedError: errors.New("no sell out rules"),
+		},
+		{
+			name:          "unknown rule",
+			rules:         []string{"percentage", "odds"},
+			expectedError: errors.New(`unknown sell out rule "odds"`),
+		},
+		{
+			name:          "argument of rule without arguments",
+			rules:         []string{"total:5"},
+			expectedError: errors.New(`unknown sell out rule "total:5"`),
+		},
+		{
+			name:          "max units without units",
+			rules:         []string{"max_units"},
+			expectedError: errors.New(`unknown sell out rule "max_units"`),
+		},
+		{
+			name:          "zero max units",
+			rules:         []string{"max_units:0"},
+			expectedError: errors.New(`invalid units of sell out rule "max_units:0"`),
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			policy, err := ParseSellOutPolicy(tc.rules)
+			assert.Equal(t, tc.expectedError, err)
+			assert.Equal(t, tc.expected, policy)
+		})
+	}
+}
+
+func TestSellOutPolicy_Remaining(t *testing.T) {
+	wager := func(total uint32, percentage float32, sold int32) repo.Wager {
+		return repo.Wager{
+			TotalWagerValue:   total,
+			SellingPercentage: percentage,
+			AmountSold:        sql.NullInt32{Int32: sold, Valid: sold > 0},
+		}
+	}
+
+	for _, tc := range []struct {
+		name   string
+		policy *SellOutPolicy
+		wager  repo.Wager
+
+		expectedRemaining uint32
+		expectedCode      app_errors.ErrorCode
+	}{
+		{
+			name:              "percentage of total value",
+			policy:            NewSellOutPolicy(SellingPercentageRule{}),
+			wager:             wager(10, 20, 1),
+			expectedRemaining: 1,
+			expectedCode:      app_errors.ErrWagerSoldOut,
+		},
+		{
+			name:              "percentage rounded down",
+			policy:            NewSellOutPolicy(SellingPercentageRule{}),
+			wager:             wager(10, 33.3, 0),
+			expectedRemaining: 3,
+			expectedCode:      app_errors.ErrWagerSoldOut,
+		},
+		{
+			name:              "percentage sells at least one unit",
+			policy:            NewSellOutPolicy(SellingPercentageRule{}),
+			wager:             wager(1, 20, 0),
+			expectedRemaining: 1,
+			expectedCode:      app_errors.ErrWagerSoldOut,
+		},
+		{
+			name:              "total value",
+			policy:            NewSellOutPolicy(TotalValueRule{}),
+			wager:             wager(10, 20, 2),
+			expectedRemaining: 8,
+			expectedCode:      app_errors.ErrTotalValueSold,
+		},
+		{
+			name:              "policy without rules sells total value",
+			policy:            NewSellOutPolicy(),
+			wager:             wager(10, 20, 4),
+			expectedRemaining: 6,
+			expectedCode:      app_errors.ErrTotalValueSold,
+		},
+		{
+			name:              "lowest rule limits",
+			policy:            NewSellOutPolicy(TotalValueRule{}, MaxUnitsRule{Units: 5}),
+			wager:             wager(10, 20, 4),
+			expectedRemaining: 1,
+			expectedCode:      app_errors.ErrSellOutLimitReached,
+		},
+		{
+			name:              "first of reached rules limits",
+			policy:            NewSellOutPolicy(MaxUnitsRule{Units: 2}, SellingPercentageRule{}),
+			wager:             wager(10, 20, 2),
+			expectedRemaining: 0,
+			expectedCode:      app_errors.ErrSellOutLimitReached,
+		},
+		{
+			name:              "oversold by former policy",
+			policy:            NewSellOutPolicy(SellingPercentageRule{}),
+			wager:             wager(10, 20, 5),
+			expectedRemaining: 0,
+			expectedCode:      app_errors.ErrWagerSoldOut,
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			remaining, rule := tc.policy.Remaining(tc.wager)
+			assert.Equal(t, tc.expectedRemaining, remaining)
+			require.NotNil(t, rule)
+			assert.Equal(t, tc.expectedCode, rule.Code())
+		})
+	}
+}
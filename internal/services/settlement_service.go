@@ -0,0 +1,263 @@
+package services
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"net/http"
+
+	"github.com/vitthalaa/wager-app/app_errors"
+	"github.com/vitthalaa/wager-app/dto"
+	"github.com/vitthalaa/wager-app/internal/logger"
+	"github.com/vitthalaa/wager-app/internal/repo"
+	"github.com/vitthalaa/wager-app/internal/validation"
+	"github.com/vitthalaa/wager-app/money"
+)
+
+// ISettlementService ...
+type ISettlementService interface {
+	SettleWager(ctx context.Context, req *dto.SettleWagerRequest) (*dto.WagerSettlement, error)
+	GetSettlement(ctx context.Context, wagerID uint32) (*dto.WagerSettlement, error)
+}
+
+// NewSettlementService ...
+func NewSettlementService(
+	transactor repo.ITransactor,
+	wagerRepo repo.IWagerRepo,
+	purchaseRepo repo.IPurchaseRepo,
+	settlementRepo repo.ISettlementRepo,
+	walletRepo repo.IWalletRepo,
+	orderRepo repo.IOrderRepo,
+	log *logger.Logger,
+) *SettlementService {
+	return &SettlementService{
+		transactor:     transactor,
+		wagerRepo:      wagerRepo,
+		purchaseRepo:   purchaseRepo,
+		settlementRepo: settlementRepo,
+		walletRepo:     walletRepo,
+		orderRepo:      orderRepo,
+		log:            log,
+	}
+}
+
+// SettlementService ...
+type SettlementService struct {
+	transactor     repo.ITransactor
+	wagerRepo      repo.IWagerRepo
+	purchaseRepo   repo.IPurchaseRepo
+	settlementRepo repo.ISettlementRepo
+	walletRepo     repo.IWalletRepo
+	orderRepo      repo.IOrderRepo
+	log            *logger.Logger
+}
+
+// SettleWager records wager outcome and settles all its purchases with payouts credited to buyer wallets, its open
+// orders are cancelled. Only operators can settle it.
+func (s *SettlementService) SettleWager(ctx context.Context, req *dto.SettleWagerRequest) (*dto.WagerSettlement, error) {
+	if req == nil || req.WagerID == 0 {
+		return nil, &app_errors.ErrorResponse{Status: http.StatusBadRequest, Code: app_errors.ErrInvalidWagerID}
+	}
+
+	if errRes := validation.Struct(req).Err(); errRes != nil {
+		return nil, errRes
+	}
+
+	if !req.Operator {
+		return nil, &app_errors.ErrorResponse{Status: http.StatusForbidden, Code: app_errors.ErrNotOperator}
+	}
+
+	outcome := repo.WagerOutcome(req.Outcome)
+
+	var (
+		wager       *repo.Wager
+		settlements []repo.Settlement
+	)
+	err := s.transactor.WithinTransaction(ctx, func(ctx context.Context) error {
+		var err error
+		wager, settlements, err = s.settleWager(ctx, req, outcome)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	s.log.Info(ctx, "wager settled", "wager_id", wager.ID, "outcome", outcome, "settlements", len(settlements))
+
+	settlementDTO := toWagerSettlementDTO(*wager, settlements)
+	return &settlementDTO, nil
+}
+
+// GetSettlement returns settlement of settled or voided wager
+func (s *SettlementService) GetSettlement(ctx context.Context, wagerID uint32) (*dto.WagerSettlement, error) {
+	if wagerID == 0 {
+		return nil, &app_errors.ErrorResponse{Status: http.StatusBadRequest, Code: app_errors.ErrInvalidWagerID}
+	}
+
+	wager, err := s.wagerRepo.GetWagerByID(ctx, wagerID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, &app_errors.ErrorResponse{Status: http.StatusNotFound, Code: app_errors.ErrNotFound}
+		}
+
+		return nil, err
+	}
+
+	if !wager.Status.Closed() {
+		return nil, &app_errors.ErrorResponse{Status: http.StatusNotFound, Code: app_errors.ErrWagerNotSettled}
+	}
+
+	settlements, err := s.settlementRepo.ListSettlementsByWagerID(ctx, wagerID)
+	if err != nil {
+		return nil, err
+	}
+
+	settlementDTO := toWagerSettlementDTO(*wager, settlements)
+	return &settlementDTO, nil
+}
+
+// settleWager locks wager row, so no purchase can happen while it is being settled. Must run within transaction.
+func (s *SettlementService) settleWager(
+	ctx context.Context, req *dto.SettleWagerRequest, outcome repo.WagerOutcome,
+) (*repo.Wager, []repo.Settlement, error) {
+	wager, err := s.wagerRepo.GetWagerByIDForUpdate(ctx, req.WagerID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil, &app_errors.ErrorResponse{Status: http.StatusNotFound, Code: app_errors.ErrNotFound}
+		}
+
+		return nil, nil, err
+	}
+
+	if wager.Status.Closed() {
+		return nil, nil, &app_errors.ErrorResponse{Status: http.StatusConflict, Code: app_errors.ErrWagerSettled}
+	}
+
+	// every purchase holds at least one unit, so amount sold bounds number of purchases
+	var purchases []repo.Purchase
+	if wager.AmountSold.Int32 > 0 {
+		purchases, err = s.purchaseRepo.ListPurchases(ctx, repo.PurchaseFilter{
+			WagerID: wager.ID,
+			SortBy:  repo.PurchaseSortID,
+			Limit:   uint32(wager.AmountSold.Int32),
+		})
+		if err != nil {
+			return nil, nil, err
+		}
+	}
+
+	accounts, err := lockBuyerAccounts(ctx, s.walletRepo, purchases)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	external, err := s.walletRepo.GetExternalAccount(ctx)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	settlements := make([]repo.Settlement, 0, len(purchases))
+	for _, purchase := range purchases {
+		amount, err := payout(*wager, purchase, outcome)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		settlement, err := s.settlementRepo.CreateSettlement(ctx, &repo.Settlement{
+			WagerID:    wager.ID,
+			PurchaseID: purchase.ID,
+			Outcome:    outcome,
+			Payout:     amount,
+		})
+		if err != nil {
+			return nil, nil, err
+		}
+
+		err = s.pay(ctx, purchase, outcome, amount, accounts, external)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		settlements = append(settlements, *settlement)
+	}
+
+	wager.Status = outcome.Status()
+	wager.Outcome = sql.NullString{String: string(outcome), Valid: true}
+	err = s.wagerRepo.SettleWager(ctx, wager)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	err = closeOrderBook(ctx, s.orderRepo, *wager)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	// reload for settlement time set by storage
+	wager, err = s.wagerRepo.GetWagerByID(ctx, wager.ID)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return wager, settlements, nil
+}
+
+// lockBuyerAccounts locks wallets of buyers of purchases
+func lockBuyerAccounts(
+	ctx context.Context, walletRepo repo.IWalletRepo, purchases []repo.Purchase,
+) (map[uint32]*repo.Account, error) {
+	userIDs := make([]uint32, 0, len(purchases))
+	for _, purchase := range purchases {
+		if purchase.BuyerID.Valid {
+			userIDs = append(userIDs, uint32(purchase.BuyerID.Int32))
+		}
+	}
+
+	return lockUserAccounts(ctx, walletRepo, userIDs...)
+}
+
+// pay credits payout to buyer wallet from external account, both winnings and refunds of void wager. Seller keeps
+// proceeds of purchases, so refunds do not depend on seller wallet which may be withdrawn already. Anonymous purchases
+// made by former versions have no wallet to credit, their settlement only records payout.
+func (s *SettlementService) pay(
+	ctx context.Context, purchase repo.Purchase, outcome repo.WagerOutcome, amount money.Money,
+	accounts map[uint32]*repo.Account, external *repo.Account,
+) error {
+	if amount == 0 || !purchase.BuyerID.Valid {
+		return nil
+	}
+
+	kind := repo.LedgerTransactionPayout
+	if outcome == repo.WagerOutcomeVoid {
+		kind = repo.LedgerTransactionRefund
+	}
+
+	buyer := accounts[uint32(purchase.BuyerID.Int32)]
+	return transfer(ctx, s.walletRepo, kind, toNullID(purchase.ID), external, buyer, amount)
+}
+
+// payout returns amount paid to holder of purchase. Won wager pays out total wager value at odds, purchase gets share
+// of it by units it holds out of total wager value, that is odds per unit. Void wager refunds total price.
+func payout(wager repo.Wager, purchase repo.Purchase, outcome repo.WagerOutcome) (money.Money, error) {
+	switch outcome {
+	case repo.WagerOutcomeWon:
+		if purchase.Quantity > wager.TotalWagerValue {
+			return 0, fmt.Errorf("purchase %d holds more units than total wager value", purchase.ID)
+		}
+
+		// TotalWagerValue * Odds * Quantity / TotalWagerValue, without overflowing product of the values
+		amount, err := money.FromUnits(int64(wager.Odds)).Mul(int64(purchase.Quantity))
+		if err == nil && amount > money.MaxAmount {
+			err = money.ErrOverflow
+		}
+		if err != nil {
+			return 0, fmt.Errorf("payout of purchase %d: %w", purchase.ID, err)
+		}
+
+		return amount, nil
+	case repo.WagerOutcomeVoid:
+		return purchase.TotalPrice, nil
+	}
+
+	return 0, nil
+}
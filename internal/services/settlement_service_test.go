@@ -0,0 +1,415 @@
+package services
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+
+	"github.com/vitthalaa/wager-app/app_errors"
+	"github.com/vitthalaa/wager-app/dto"
+	"github.com/vitthalaa/wager-app/internal/logger"
+	"github.com/vitthalaa/wager-app/internal/repo"
+	"github.com/vitthalaa/wager-app/money"
+)
+
+func TestSettlementService_SettleWager(t *testing.T) {
+	now := time.Now()
+	openWager := func() *repo.Wager {
+		return &repo.Wager{
+			ID:                  111,
+			TotalWagerValue:     10,
+			Odds:                3,
+			SellingPercentage:   20,
+			SellingPrice:        money.MustParse("26"),
+			CurrentSellingPrice: money.MustParse("25"),
+			AmountSold: sql.NullInt32{
+				Int32: 3,
+				Valid: true,
+			},
+			Status:   repo.WagerStatusOpen,
+			SellerID: sql.NullInt32{Int32: 9, Valid: true},
+		}
+	}
+
+	settledWager := func(status repo.WagerStatus, outcome repo.WagerOutcome) *repo.Wager {
+		w := openWager()
+		w.Status = status
+		w.Outcome = sql.NullString{String: string(outcome), Valid: true}
+		w.SettledAt = sql.NullTime{Time: now, Valid: true}
+		return w
+	}
+
+	purchases := []repo.Purchase{
+		{ID: 1, WagerID: 111, BuyingPrice: money.MustParse("26"), Quantity: 1, TotalPrice: money.MustParse("26"),
+			BuyerID: sql.NullInt32{Int32: 7, Valid: true}},
+		{ID: 2, WagerID: 111, BuyingPrice: money.MustParse("25"), Quantity: 2, TotalPrice: money.MustParse("50"),
+			BuyerID: sql.NullInt32{Int32: 8, Valid: true}},
+	}
+
+	ledgerTransaction := func(kind repo.LedgerTransactionKind, purchaseID int32, from, to uint32, amount string) *repo.LedgerTransaction {
+		return &repo.LedgerTransaction{
+			Kind:       kind,
+			PurchaseID: sql.NullInt32{Int32: purchaseID, Valid: true},
+			Entries: []repo.LedgerEntry{
+				{AccountID: from, Amount: -money.MustParse(amount)},
+				{AccountID: to, Amount: money.MustParse(amount)},
+			},
+		}
+	}
+
+	for _, tc := range []struct {
+		name string
+		req  *dto.SettleWagerRequest
+
+		wagerRepoResp      *repo.Wager
+		wagerRepoError     error
+		purchasesRepoError error
+		settlementRepoErr  error
+		settleRepoError    error
+		reloadedWager      *repo.Wager
+
+		expectedPayouts      []money.Money
+		expectedTransactions []*repo.LedgerTransaction
+		expectedRes          *dto.WagerSettlement
+		expectedError        error
+	}{
+		{
+			name:            "won pays share of total wager value at odds from external account",
+			req:             &dto.SettleWagerRequest{WagerID: 111, UserID: 5, Operator: true, Outcome: "won"},
+			wagerRepoResp:   openWager(),
+			reloadedWager:   settledWager(repo.WagerStatusSettled, repo.WagerOutcomeWon),
+			expectedPayouts: []money.Money{money.FromUnits(3), money.FromUnits(6)},
+			expectedTransactions: []*repo.LedgerTransaction{
+				ledgerTransaction(repo.LedgerTransactionPayout, 1, 1, 70, "3"),
+				ledgerTransaction(repo.LedgerTransactionPayout, 2, 1, 80, "6"),
+			},
+			expectedRes: &dto.WagerSettlement{
+				WagerID:     111,
+				Status:      "settled",
+				Outcome:     "won",
+				SettledAt:   &now,
+				TotalPayout: money.MustParse("9"),
+				Settlements: []dto.PurchaseSettlement{
+					{ID: 1, PurchaseID: 1, Outcome: "won", Payout: money.MustParse("3")},
+					{ID: 2, PurchaseID: 2, Outcome: "won", Payout: money.MustParse("6")},
+				},
+			},
+		},
+		{
+			name:            "lost pays nothing",
+			req:             &dto.SettleWagerRequest{WagerID: 111, UserID: 5, Operator: true, Outcome: "lost"},
+			wagerRepoResp:   openWager(),
+			reloadedWager:   settledWager(repo.WagerStatusSettled, repo.WagerOutcomeLost),
+			expectedPayouts: []money.Money{0, 0},
+			expectedRes: &dto.WagerSettlement{
+				WagerID:     111,
+				Status:      "settled",
+				Outcome:     "lost",
+				SettledAt:   &now,
+				TotalPayout: 0,
+				Settlements: []dto.PurchaseSettlement{
+					{ID: 1, PurchaseID: 1, Outcome: "lost", Payout: 0},
+					{ID: 2, PurchaseID: 2, Outcome: "lost", Payout: 0},
+				},
+			},
+		},
+		{
+			name:            "void refunds total price from external account",
+			req:             &dto.SettleWagerRequest{WagerID: 111, UserID: 5, Operator: true, Outcome: "void"},
+			wagerRepoResp:   openWager(),
+			reloadedWager:   settledWager(repo.WagerStatusVoided, repo.WagerOutcomeVoid),
+			expectedPayouts: []money.Money{money.FromUnits(26), money.FromUnits(50)},
+			expectedTransactions: []*repo.LedgerTransaction{
+				ledgerTransaction(repo.LedgerTransactionRefund, 1, 1, 70, "26"),
+				ledgerTransaction(repo.LedgerTransactionRefund, 2, 1, 80, "50"),
+			},
+			expectedRes: &dto.WagerSettlement{
+				WagerID:     111,
+				Status:      "voided",
+				Outcome:     "void",
+				SettledAt:   &now,
+				TotalPayout: money.MustParse("76"),
+				Settlements: []dto.PurchaseSettlement{
+					{ID: 1, PurchaseID: 1, Outcome: "void", Payout: money.MustParse("26")},
+					{ID: 2, PurchaseID: 2, Outcome: "void", Payout: money.MustParse("50")},
+				},
+			},
+		},
+		{
+			name: "void of anonymous wager",
+			req:  &dto.SettleWagerRequest{WagerID: 111, UserID: 5, Operator: true, Outcome: "void"},
+			wagerRepoResp: func() *repo.Wager {
+				w := openWager()
+				w.SellerID = sql.NullInt32{}
+				return w
+			}(),
+			reloadedWager:   settledWager(repo.WagerStatusVoided, repo.WagerOutcomeVoid),
+			expectedPayouts: []money.Money{money.FromUnits(26), money.FromUnits(50)},
+			expectedTransactions: []*repo.LedgerTransaction{
+				ledgerTransaction(repo.LedgerTransactionRefund, 1, 1, 70, "26"),
+				ledgerTransaction(repo.LedgerTransactionRefund, 2, 1, 80, "50"),
+			},
+			expectedRes: &dto.WagerSettlement{
+				WagerID:     111,
+				Status:      "voided",
+				Outcome:     "void",
+				SettledAt:   &now,
+				TotalPayout: money.MustParse("76"),
+				Settlements: []dto.PurchaseSettlement{
+					{ID: 1, PurchaseID: 1, Outcome: "void", Payout: money.MustParse("26")},
+					{ID: 2, PurchaseID: 2, Outcome: "void", Payout: money.MustParse("50")},
+				},
+			},
+		},
+		{
+			name:            "void after seller withdrew proceeds",
+			req:             &dto.SettleWagerRequest{WagerID: 111, UserID: 5, Operator: true, Outcome: "void"},
+			wagerRepoResp:   openWager(),
+			reloadedWager:   settledWager(repo.WagerStatusVoided, repo.WagerOutcomeVoid),
+			expectedPayouts: []money.Money{money.FromUnits(26), money.FromUnits(50)},
+			expectedTransactions: []*repo.LedgerTransaction{
+				ledgerTransaction(repo.LedgerTransactionRefund, 1, 1, 70, "26"),
+				ledgerTransaction(repo.LedgerTransactionRefund, 2, 1, 80, "50"),
+			},
+			expectedRes: &dto.WagerSettlement{
+				WagerID:     111,
+				Status:      "voided",
+				Outcome:     "void",
+				SettledAt:   &now,
+				TotalPayout: money.MustParse("76"),
+				Settlements: []dto.PurchaseSettlement{
+					{ID: 1, PurchaseID: 1, Outcome: "void", Payout: money.MustParse("26")},
+					{ID: 2, PurchaseID: 2, Outcome: "void", Payout: money.MustParse("50")},
+				},
+			},
+		},
+		{
+			name:          "invalid wager id",
+			req:           &dto.SettleWagerRequest{Outcome: "won"},
+			expectedError: &app_errors.ErrorResponse{Status: http.StatusBadRequest, Code: app_errors.ErrInvalidWagerID},
+		},
+		{
+			name: "invalid outcome",
+			req:  &dto.SettleWagerRequest{WagerID: 111, UserID: 5, Operator: true, Outcome: "draw"},
+			expectedError: &app_errors.ErrorResponse{
+				Status: http.StatusBadRequest, Code: app_errors.ErrInvalidOutcome,
+				Details: []app_errors.FieldError{
+					{Field: "outcome", Rule: "oneof", Message: "must be one of won, lost, void", Limits: map[string]string{"oneof": "won lost void"}},
+				},
+			},
+		},
+		{
+			name:           "wager not found",
+			req:            &dto.SettleWagerRequest{WagerID: 111, UserID: 5, Operator: true, Outcome: "won"},
+			wagerRepoError: sql.ErrNoRows,
+			expectedError:  &app_errors.ErrorResponse{Status: http.StatusNotFound, Code: app_errors.ErrNotFound},
+		},
+		{
+			name:          "seller is not operator",
+			req:           &dto.SettleWagerRequest{WagerID: 111, UserID: 9, Outcome: "won"},
+			wagerRepoResp: openWager(),
+			expectedError: &app_errors.ErrorResponse{Status: http.StatusForbidden, Code: app_errors.ErrNotOperator},
+		},
+		{
+			name:          "user is not operator",
+			req:           &dto.SettleWagerRequest{WagerID: 111, UserID: 5, Outcome: "won"},
+			wagerRepoResp: openWager(),
+			expectedError: &app_errors.ErrorResponse{Status: http.StatusForbidden, Code: app_errors.ErrNotOperator},
+		},
+		{
+			name:          "already settled",
+			req:           &dto.SettleWagerRequest{WagerID: 111, UserID: 5, Operator: true, Outcome: "won"},
+			wagerRepoResp: settledWager(repo.WagerStatusVoided, repo.WagerOutcomeVoid),
+			expectedError: &app_errors.ErrorResponse{Status: http.StatusConflict, Code: app_errors.ErrWagerSettled},
+		},
+		{
+			name:               "purchase repo error",
+			req:                &dto.SettleWagerRequest{WagerID: 111, UserID: 5, Operator: true, Outcome: "won"},
+			wagerRepoResp:      openWager(),
+			purchasesRepoError: errors.New("some purchase repo error"),
+			expectedError:      errors.New("some purchase repo error"),
+		},
+		{
+			name:              "settlement repo error",
+			req:               &dto.SettleWagerRequest{WagerID: 111, UserID: 5, Operator: true, Outcome: "won"},
+			wagerRepoResp:     openWager(),
+			expectedPayouts:   []money.Money{money.FromUnits(3)},
+			settlementRepoErr: errors.New("some settlement repo error"),
+			expectedError:     errors.New("some settlement repo error"),
+		},
+		{
+			name:            "settle wager repo error",
+			req:             &dto.SettleWagerRequest{WagerID: 111, UserID: 5, Operator: true, Outcome: "won"},
+			wagerRepoResp:   openWager(),
+			expectedPayouts: []money.Money{money.FromUnits(3), money.FromUnits(6)},
+			expectedTransactions: []*repo.LedgerTransaction{
+				ledgerTransaction(repo.LedgerTransactionPayout, 1, 1, 70, "3"),
+				ledgerTransaction(repo.LedgerTransactionPayout, 2, 1, 80, "6"),
+			},
+			settleRepoError: errors.New("some settle repo error"),
+			expectedError:   errors.New("some settle repo error"),
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			ctx := context.Background()
+			mockWagerRepo := new(MockWagerRepo)
+			mockWagerRepo.On("GetWagerByIDForUpdate", ctx, uint32(111)).
+				Return(tc.wagerRepoResp, tc.wagerRepoError)
+			mockWagerRepo.On("SettleWager", ctx, mock.Anything).
+				Return(tc.settleRepoError)
+			mockWagerRepo.On("GetWagerByID", ctx, uint32(111)).
+				Return(tc.reloadedWager, nil)
+
+			mockPurchaseRepo := new(MockPurchaseRepo)
+			mockPurchaseRepo.On("ListPurchases", ctx, repo.PurchaseFilter{
+				WagerID: 111,
+				SortBy:  repo.PurchaseSortID,
+				Limit:   3,
+			}).Return(purchases, tc.purchasesRepoError)
+
+			mockSettlementRepo := new(MockSettlementRepo)
+			for i, payout := range tc.expectedPayouts {
+				expected := &repo.Settlement{
+					WagerID:    111,
+					PurchaseID: purchases[i].ID,
+					Outcome:    repo.WagerOutcome(tc.req.Outcome),
+					Payout:     payout,
+				}
+				created := *expected
+				created.ID = purchases[i].ID
+				mockSettlementRepo.On("CreateSettlement", ctx, expected).
+					Return(&created, tc.settlementRepoErr)
+			}
+
+			mockWalletRepo := new(MockWalletRepo)
+			mockWalletRepo.On("GetAccountByUserIDForUpdate", ctx, uint32(7)).
+				Return(&repo.Account{ID: 70, Kind: repo.AccountKindUser}, nil)
+			mockWalletRepo.On("GetAccountByUserIDForUpdate", ctx, uint32(8)).
+				Return(&repo.Account{ID: 80, Kind: repo.AccountKindUser}, nil)
+			mockWalletRepo.On("GetExternalAccount", ctx).
+				Return(&repo.Account{ID: 1, Kind: repo.AccountKindExternal}, nil)
+			mockWalletRepo.On("CreateLedgerTransaction", ctx, mock.Anything).
+				Return(&repo.LedgerTransaction{}, nil)
+
+			mockTransactor := new(MockTransactor)
+			mockTransactor.On("WithinTransaction", ctx, mock.Anything).
+				Return(func(ctx context.Context, fn func(context.Context) error) error {
+					return fn(ctx)
+				})
+
+			mockOrderRepo := new(MockOrderRepo)
+			mockOrderRepo.On("CancelOpenOrders", ctx, uint32(111)).Return(nil)
+
+			service := NewSettlementService(
+				mockTransactor, mockWagerRepo, mockPurchaseRepo, mockSettlementRepo, mockWalletRepo, mockOrderRepo, logger.Discard())
+
+			res, err := service.SettleWager(ctx, tc.req)
+
+			assert.Equal(t, tc.expectedError, err)
+			assert.Equal(t, tc.expectedRes, res)
+			mockWalletRepo.AssertNumberOfCalls(t, "CreateLedgerTransaction", len(tc.expectedTransactions))
+			// open orders of settled wager are cancelled
+			if tc.expectedError == nil {
+				mockOrderRepo.AssertCalled(t, "CancelOpenOrders", ctx, uint32(111))
+			} else {
+				mockOrderRepo.AssertNotCalled(t, "CancelOpenOrders", ctx, mock.Anything)
+			}
+
+			// seller keeps proceeds, its wallet is not touched
+			mockWalletRepo.AssertNotCalled(t, "GetAccountByUserIDForUpdate", ctx, uint32(9))
+			for _, transaction := range tc.expectedTransactions {
+				mockWalletRepo.AssertCalled(t, "CreateLedgerTransaction", ctx, transaction)
+			}
+		})
+	}
+}
+
+func TestSettlementService_GetSettlement(t *testing.T) {
+	now := time.Now()
+	settled := &repo.Wager{
+		ID:        111,
+		Status:    repo.WagerStatusSettled,
+		Outcome:   sql.NullString{String: "won", Valid: true},
+		SettledAt: sql.NullTime{Time: now, Valid: true},
+	}
+
+	for _, tc := range []struct {
+		name    string
+		wagerID uint32
+
+		wagerRepoResp       *repo.Wager
+		wagerRepoError      error
+		settlementsRepoResp []repo.Settlement
+		settlementsRepoErr  error
+
+		expectedRes   *dto.WagerSettlement
+		expectedError error
+	}{
+		{
+			name:          "happy path",
+			wagerID:       111,
+			wagerRepoResp: settled,
+			settlementsRepoResp: []repo.Settlement{
+				{ID: 5, WagerID: 111, PurchaseID: 1, Outcome: repo.WagerOutcomeWon, Payout: money.MustParse("2")},
+			},
+			expectedRes: &dto.WagerSettlement{
+				WagerID:     111,
+				Status:      "settled",
+				Outcome:     "won",
+				SettledAt:   &now,
+				TotalPayout: money.MustParse("2"),
+				Settlements: []dto.PurchaseSettlement{
+					{ID: 5, PurchaseID: 1, Outcome: "won", Payout: money.MustParse("2")},
+				},
+			},
+		},
+		{
+			name:          "invalid wager id",
+			expectedError: &app_errors.ErrorResponse{Status: http.StatusBadRequest, Code: app_errors.ErrInvalidWagerID},
+		},
+		{
+			name:           "wager not found",
+			wagerID:        111,
+			wagerRepoError: sql.ErrNoRows,
+			expectedError:  &app_errors.ErrorResponse{Status: http.StatusNotFound, Code: app_errors.ErrNotFound},
+		},
+		{
+			name:          "wager not settled",
+			wagerID:       111,
+			wagerRepoResp: &repo.Wager{ID: 111, Status: repo.WagerStatusSoldOut},
+			expectedError: &app_errors.ErrorResponse{Status: http.StatusNotFound, Code: app_errors.ErrWagerNotSettled},
+		},
+		{
+			name:               "settlement repo error",
+			wagerID:            111,
+			wagerRepoResp:      settled,
+			settlementsRepoErr: errors.New("some settlement repo error"),
+			expectedError:      errors.New("some settlement repo error"),
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			ctx := context.Background()
+			mockWagerRepo := new(MockWagerRepo)
+			mockWagerRepo.On("GetWagerByID", ctx, tc.wagerID).
+				Return(tc.wagerRepoResp, tc.wagerRepoError)
+
+			mockSettlementRepo := new(MockSettlementRepo)
+			mockSettlementRepo.On("ListSettlementsByWagerID", ctx, tc.wagerID).
+				Return(tc.settlementsRepoResp, tc.settlementsRepoErr)
+
+			service := NewSettlementService(
+				new(MockTransactor), mockWagerRepo, new(MockPurchaseRepo), mockSettlementRepo, new(MockWalletRepo), new(MockOrderRepo), logger.Discard())
+
+			res, err := service.GetSettlement(ctx, tc.wagerID)
+
+			assert.Equal(t, tc.expectedError, err)
+			assert.Equal(t, tc.expectedRes, res)
+		})
+	}
+}
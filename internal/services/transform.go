@@ -1,30 +1,59 @@
 package services
 
 import (
+	"database/sql"
+	"time"
+
 	"github.com/vitthalaa/wager-app/dto"
 	"github.com/vitthalaa/wager-app/internal/repo"
 )
 
 func toWagerEntity(req dto.PlaceWagerRequest) *repo.Wager {
-	return &repo.Wager{
+	pricing := repo.WagerPricing(req.Pricing)
+	if pricing == "" {
+		pricing = repo.WagerPricingLastTrade
+	}
+
+	wager := &repo.Wager{
 		TotalWagerValue:     req.TotalWagerValue,
 		Odds:                req.Odds,
 		SellingPercentage:   req.SellingPercentage,
 		SellingPrice:        req.SellingPrice,
 		CurrentSellingPrice: req.SellingPrice,
+		SellerID:            toNullID(req.SellerID),
+		Pricing:             pricing,
+		FloorPrice:          req.FloorPrice,
+		DecaySeconds:        req.DecaySeconds,
+		StepPercentage:      req.StepPercentage,
+		Auction:             repo.WagerAuction(req.Auction),
+	}
+
+	if req.AuctionEndsAt != nil {
+		wager.AuctionEndsAt = sql.NullTime{Time: req.AuctionEndsAt.UTC(), Valid: true}
 	}
+
+	return wager
 }
 
-func toWagerDTO(w repo.Wager) dto.Wager {
+// toWagerDTO converts wager to DTO with current selling price of its pricing strategy at time
+func toWagerDTO(w repo.Wager, at time.Time) dto.Wager {
 	wDto := dto.Wager{
 		ID:                  w.ID,
 		TotalWagerValue:     w.TotalWagerValue,
 		Odds:                w.Odds,
 		SellingPercentage:   w.SellingPercentage,
 		SellingPrice:        w.SellingPrice,
-		CurrentSellingPrice: w.CurrentSellingPrice,
+		CurrentSellingPrice: pricingOf(w).Price(w, at),
 		PercentageSold:      float32(w.PercentageSold.Float64),
 		AmountSold:          uint32(w.AmountSold.Int32),
+		Status:              string(w.Status),
+		Outcome:             w.Outcome.String,
+		SellerID:            uint32(w.SellerID.Int32),
+		Pricing:             string(w.Pricing),
+		FloorPrice:          w.FloorPrice,
+		DecaySeconds:        w.DecaySeconds,
+		StepPercentage:      w.StepPercentage,
+		Auction:             string(w.Auction),
 	}
 
 	if w.CreatedAt.Valid {
@@ -32,5 +61,163 @@ func toWagerDTO(w repo.Wager) dto.Wager {
 		wDto.PlacedAt = &t
 	}
 
+	if w.AuctionEndsAt.Valid {
+		t := w.AuctionEndsAt.Time
+		wDto.AuctionEndsAt = &t
+	}
+
+	if w.AuctionClosedAt.Valid {
+		t := w.AuctionClosedAt.Time
+		wDto.AuctionClosedAt = &t
+	}
+
+	if w.SettledAt.Valid {
+		t := w.SettledAt.Time
+		wDto.SettledAt = &t
+	}
+
 	return wDto
 }
+
+func toWagerPurchaseDTO(p repo.Purchase) dto.WagerPurchase {
+	pDto := dto.WagerPurchase{
+		ID:          p.ID,
+		WagerID:     p.WagerID,
+		BuyingPrice: p.BuyingPrice,
+		Quantity:    p.Quantity,
+		TotalPrice:  p.TotalPrice,
+		BuyerID:     uint32(p.BuyerID.Int32),
+	}
+
+	if p.CreatedAt.Valid {
+		boughtAt := p.CreatedAt.Time
+		pDto.BoughtAt = &boughtAt
+	}
+
+	return pDto
+}
+
+func toOrderDTO(o repo.Order, purchases []repo.Purchase) dto.Order {
+	oDto := dto.Order{
+		ID:       o.ID,
+		WagerID:  o.WagerID,
+		UserID:   o.UserID,
+		Side:     string(o.Side),
+		Price:    o.Price,
+		Quantity: o.Quantity,
+		Filled:   o.Filled,
+		Status:   string(o.Status),
+	}
+
+	if o.CreatedAt.Valid {
+		placedAt := o.CreatedAt.Time
+		oDto.PlacedAt = &placedAt
+	}
+
+	for _, p := range purchases {
+		oDto.Purchases = append(oDto.Purchases, toWagerPurchaseDTO(p))
+	}
+
+	return oDto
+}
+
+func toOrderBookLevelDTOs(levels []repo.OrderBookLevel) []dto.OrderBookLevel {
+	res := make([]dto.OrderBookLevel, 0, len(levels))
+	for _, l := range levels {
+		res = append(res, dto.OrderBookLevel{Price: l.Price, Quantity: l.Quantity, Orders: l.Orders})
+	}
+
+	return res
+}
+
+func toAuctionBidDTO(b repo.AuctionBid) dto.AuctionBid {
+	bDto := dto.AuctionBid{
+		ID:       b.ID,
+		WagerID:  b.WagerID,
+		Price:    b.Price,
+		Quantity: b.Quantity,
+		Awarded:  b.Awarded,
+	}
+
+	if b.CreatedAt.Valid {
+		placedAt := b.CreatedAt.Time
+		bDto.PlacedAt = &placedAt
+	}
+
+	return bDto
+}
+
+func toWagerSettlementDTO(w repo.Wager, settlements []repo.Settlement) dto.WagerSettlement {
+	sDto := dto.WagerSettlement{
+		WagerID:     w.ID,
+		Status:      string(w.Status),
+		Outcome:     w.Outcome.String,
+		Settlements: make([]dto.PurchaseSettlement, 0, len(settlements)),
+	}
+
+	if w.SettledAt.Valid {
+		settledAt := w.SettledAt.Time
+		sDto.SettledAt = &settledAt
+	}
+
+	for _, s := range settlements {
+		sDto.TotalPayout += s.Payout
+		sDto.Settlements = append(sDto.Settlements, dto.PurchaseSettlement{
+			ID:         s.ID,
+			PurchaseID: s.PurchaseID,
+			Outcome:    string(s.Outcome),
+			Payout:     s.Payout,
+		})
+	}
+
+	return sDto
+}
+
+func toUserDTO(u repo.User) dto.User {
+	uDto := dto.User{
+		ID:       u.ID,
+		Username: u.Username,
+	}
+
+	if u.CreatedAt.Valid {
+		registeredAt := u.CreatedAt.Time
+		uDto.RegisteredAt = &registeredAt
+	}
+
+	return uDto
+}
+
+func toWalletDTO(a repo.Account) dto.Wallet {
+	wDto := dto.Wallet{
+		Balance: a.Balance,
+	}
+
+	if a.UpdatedAt.Valid {
+		updatedAt := a.UpdatedAt.Time
+		wDto.UpdatedAt = &updatedAt
+	}
+
+	return wDto
+}
+
+func toLedgerEntryDTO(e repo.LedgerEntry) dto.LedgerEntry {
+	eDto := dto.LedgerEntry{
+		ID:            e.ID,
+		TransactionID: e.TransactionID,
+		Kind:          string(e.Kind),
+		PurchaseID:    uint32(e.PurchaseID.Int32),
+		Amount:        e.Amount,
+	}
+
+	if e.CreatedAt.Valid {
+		createdAt := e.CreatedAt.Time
+		eDto.CreatedAt = &createdAt
+	}
+
+	return eDto
+}
+
+// toNullID converts optional id to nullable column value, zero is null
+func toNullID(id uint32) sql.NullInt32 {
+	return sql.NullInt32{Int32: int32(id), Valid: id > 0}
+}
@@ -0,0 +1,138 @@
+package services
+
+import (
+	"context"
+	"database/sql"
+	"net/http"
+	"strings"
+
+	"github.com/vitthalaa/wager-app/app_errors"
+	"github.com/vitthalaa/wager-app/dto"
+	"github.com/vitthalaa/wager-app/internal/auth"
+	"github.com/vitthalaa/wager-app/internal/logger"
+	"github.com/vitthalaa/wager-app/internal/repo"
+	"github.com/vitthalaa/wager-app/internal/validation"
+)
+
+// dummyPasswordHash is compared on login of unknown user, so response time does not reveal existing usernames
+var dummyPasswordHash, _ = auth.HashPassword("dummy password")
+
+// IUserService ...
+type IUserService interface {
+	Register(ctx context.Context, req *dto.RegisterRequest) (*dto.User, error)
+	Login(ctx context.Context, req *dto.LoginRequest) (*dto.Token, error)
+}
+
+// NewUserService ...
+func NewUserService(
+	transactor repo.ITransactor, userRepo repo.IUserRepo, walletRepo repo.IWalletRepo, tokens *auth.Tokens,
+	operatorUsernames []string, log *logger.Logger,
+) *UserService {
+	operators := make(map[string]bool, len(operatorUsernames))
+	for _, username := range operatorUsernames {
+		operators[normalizeUsername(username)] = true
+	}
+
+	return &UserService{
+		transactor: transactor,
+		userRepo:   userRepo,
+		walletRepo: walletRepo,
+		tokens:     tokens,
+		operators:  operators,
+		log:        log,
+	}
+}
+
+// UserService ...
+type UserService struct {
+	transactor repo.ITransactor
+	userRepo   repo.IUserRepo
+	walletRepo repo.IWalletRepo
+	tokens     *auth.Tokens
+	// operators are usernames given operator role
+	operators map[string]bool
+	log       *logger.Logger
+}
+
+// Register creates user with hashed password and empty wallet. Usernames are case-insensitive.
+func (s *UserService) Register(ctx context.Context, req *dto.RegisterRequest) (*dto.User, error) {
+	username := normalizeUsername(req.Username)
+	errRes := validation.Struct(&dto.RegisterRequest{Username: username, Password: req.Password}).Err()
+	if errRes != nil {
+		return nil, errRes
+	}
+
+	hash, err := auth.HashPassword(req.Password)
+	if err != nil {
+		return nil, err
+	}
+
+	var user *repo.User
+	err = s.transactor.WithinTransaction(ctx, func(ctx context.Context) error {
+		var err error
+		user, err = s.userRepo.CreateUser(ctx, &repo.User{
+			Username:     username,
+			PasswordHash: hash,
+		})
+		if err != nil {
+			return err
+		}
+
+		_, err = s.walletRepo.CreateAccount(ctx, &repo.Account{
+			UserID: toNullID(user.ID),
+			Kind:   repo.AccountKindUser,
+		})
+		return err
+	})
+	if err != nil {
+		if err == repo.ErrUsernameTaken {
+			return nil, &app_errors.ErrorResponse{Status: http.StatusConflict, Code: app_errors.ErrUsernameTaken}
+		}
+
+		return nil, err
+	}
+
+	userDTO := toUserDTO(*user)
+	return &userDTO, nil
+}
+
+// Login verifies user credentials and issues bearer token, tokens of operators carry operator role
+func (s *UserService) Login(ctx context.Context, req *dto.LoginRequest) (*dto.Token, error) {
+	invalidCredentials := &app_errors.ErrorResponse{Status: http.StatusUnauthorized, Code: app_errors.ErrInvalidCredentials}
+
+	user, err := s.userRepo.GetUserByUsername(ctx, normalizeUsername(req.Username))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			auth.CheckPassword(dummyPasswordHash, req.Password)
+			s.log.Info(ctx, "login failed, unknown user")
+			return nil, invalidCredentials
+		}
+
+		return nil, err
+	}
+
+	if !auth.CheckPassword(user.PasswordHash, req.Password) {
+		s.log.Info(ctx, "login failed, wrong password", "user_id", user.ID)
+		return nil, invalidCredentials
+	}
+
+	tokenUser := auth.User{ID: user.ID, Username: user.Username}
+	if s.operators[user.Username] {
+		tokenUser.Role = auth.RoleOperator
+	}
+
+	token, expiresAt, err := s.tokens.Issue(tokenUser)
+	if err != nil {
+		return nil, err
+	}
+
+	return &dto.Token{
+		AccessToken: token,
+		TokenType:   "Bearer",
+		ExpiresAt:   expiresAt,
+	}, nil
+}
+
+func normalizeUsername(username string) string {
+	return strings.ToLower(strings.TrimSpace(username))
+}
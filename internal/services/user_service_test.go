@@ -0,0 +1,203 @@
+package services
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	"github.com/vitthalaa/wager-app/app_errors"
+	"github.com/vitthalaa/wager-app/dto"
+	"github.com/vitthalaa/wager-app/internal/auth"
+	"github.com/vitthalaa/wager-app/internal/logger"
+	"github.com/vitthalaa/wager-app/internal/repo"
+)
+
+func TestUserService_Register(t *testing.T) {
+	now := time.Now()
+	for _, tc := range []struct {
+		name string
+		req  *dto.RegisterRequest
+
+		repoResp        *repo.User
+		repoError       error
+		walletRepoError error
+
+		expectedUsername string
+		expectedRes      *dto.User
+		expectedError    error
+	}{
+		{
+			name:             "happy path",
+			req:              &dto.RegisterRequest{Username: " Alice ", Password: "secret123"},
+			repoResp:         &repo.User{ID: 7, Username: "alice", CreatedAt: sql.NullTime{Time: now, Valid: true}},
+			expectedUsername: "alice",
+			expectedRes:      &dto.User{ID: 7, Username: "alice", RegisteredAt: &now},
+		},
+		{
+			name:             "wallet repo error",
+			req:              &dto.RegisterRequest{Username: "alice", Password: "secret123"},
+			repoResp:         &repo.User{ID: 7, Username: "alice"},
+			walletRepoError:  errors.New("some wallet repo error"),
+			expectedUsername: "alice",
+			expectedError:    errors.New("some wallet repo error"),
+		},
+		{
+			name: "short username",
+			req:  &dto.RegisterRequest{Username: "al", Password: "secret123"},
+			expectedError: &app_errors.ErrorResponse{
+				Status: http.StatusBadRequest, Code: app_errors.ErrInvalidUsername,
+				Details: []app_errors.FieldError{
+					{Field: "username", Rule: "minlen", Message: "must be at least 3 characters long", Limits: map[string]string{"minlen": "3"}},
+				},
+			},
+		},
+		{
+			name: "invalid username chars",
+			req:  &dto.RegisterRequest{Username: "al ice", Password: "secret123"},
+			expectedError: &app_errors.ErrorResponse{
+				Status: http.StatusBadRequest, Code: app_errors.ErrInvalidUsername,
+				Details: []app_errors.FieldError{
+					{Field: "username", Rule: "pattern", Message: "must match ^[a-z0-9_.-]*$", Limits: map[string]string{"pattern": "^[a-z0-9_.-]*$"}},
+				},
+			},
+		},
+		{
+			name: "short password",
+			req:  &dto.RegisterRequest{Username: "alice", Password: "secret"},
+			expectedError: &app_errors.ErrorResponse{
+				Status: http.StatusBadRequest, Code: app_errors.ErrInvalidPassword,
+				Details: []app_errors.FieldError{
+					{Field: "password", Rule: "minlen", Message: "must be at least 8 characters long", Limits: map[string]string{"minlen": "8"}},
+				},
+			},
+		},
+		{
+			name:             "username taken",
+			req:              &dto.RegisterRequest{Username: "alice", Password: "secret123"},
+			repoError:        repo.ErrUsernameTaken,
+			expectedUsername: "alice",
+			expectedError:    &app_errors.ErrorResponse{Status: http.StatusConflict, Code: app_errors.ErrUsernameTaken},
+		},
+		{
+			name:             "repo error",
+			req:              &dto.RegisterRequest{Username: "alice", Password: "secret123"},
+			repoError:        errors.New("some repo error"),
+			expectedUsername: "alice",
+			expectedError:    errors.New("some repo error"),
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			ctx := context.Background()
+			mockUserRepo := new(MockUserRepo)
+			mockUserRepo.On("CreateUser", ctx, mock.MatchedBy(func(user *repo.User) bool {
+				return user.Username == tc.expectedUsername && auth.CheckPassword(user.PasswordHash, tc.req.Password)
+			})).Return(tc.repoResp, tc.repoError)
+
+			mockWalletRepo := new(MockWalletRepo)
+			mockWalletRepo.On("CreateAccount", ctx, &repo.Account{
+				UserID: sql.NullInt32{Int32: 7, Valid: true},
+				Kind:   repo.AccountKindUser,
+			}).Return(&repo.Account{ID: 70}, tc.walletRepoError)
+
+			mockTransactor := new(MockTransactor)
+			mockTransactor.On("WithinTransaction", ctx, mock.Anything).
+				Return(func(ctx context.Context, fn func(context.Context) error) error {
+					return fn(ctx)
+				})
+
+			service := NewUserService(mockTransactor, mockUserRepo, mockWalletRepo, auth.NewTokens([]byte("secret"), time.Hour), nil, logger.Discard())
+
+			res, err := service.Register(ctx, tc.req)
+
+			assert.Equal(t, tc.expectedError, err)
+			assert.Equal(t, tc.expectedRes, res)
+			if tc.repoResp != nil {
+				mockWalletRepo.AssertNumberOfCalls(t, "CreateAccount", 1)
+			}
+		})
+	}
+}
+
+func TestUserService_Login(t *testing.T) {
+	hash, err := auth.HashPassword("secret123")
+	require.Nil(t, err)
+
+	tokens := auth.NewTokens([]byte("secret"), time.Hour)
+	invalidCredentials := &app_errors.ErrorResponse{Status: http.StatusUnauthorized, Code: app_errors.ErrInvalidCredentials}
+
+	for _, tc := range []struct {
+		name string
+		req  *dto.LoginRequest
+
+		repoResp  *repo.User
+		repoError error
+		operators []string
+
+		expectedUser  auth.User
+		expectedError error
+	}{
+		{
+			name:         "happy path",
+			req:          &dto.LoginRequest{Username: "Alice", Password: "secret123"},
+			repoResp:     &repo.User{ID: 7, Username: "alice", PasswordHash: hash},
+			operators:    []string{"bob"},
+			expectedUser: auth.User{ID: 7, Username: "alice"},
+		},
+		{
+			name:         "operator",
+			req:          &dto.LoginRequest{Username: "alice", Password: "secret123"},
+			repoResp:     &repo.User{ID: 7, Username: "alice", PasswordHash: hash},
+			operators:    []string{"bob", " Alice "},
+			expectedUser: auth.User{ID: 7, Username: "alice", Role: auth.RoleOperator},
+		},
+		{
+			name:          "wrong password",
+			req:           &dto.LoginRequest{Username: "alice", Password: "secret1234"},
+			repoResp:      &repo.User{ID: 7, Username: "alice", PasswordHash: hash},
+			expectedError: invalidCredentials,
+		},
+		{
+			name:          "unknown user",
+			req:           &dto.LoginRequest{Username: "alice", Password: "secret123"},
+			repoError:     sql.ErrNoRows,
+			expectedError: invalidCredentials,
+		},
+		{
+			name:          "repo error",
+			req:           &dto.LoginRequest{Username: "alice", Password: "secret123"},
+			repoError:     errors.New("some repo error"),
+			expectedError: errors.New("some repo error"),
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			ctx := context.Background()
+			mockUserRepo := new(MockUserRepo)
+			mockUserRepo.On("GetUserByUsername", ctx, "alice").
+				Return(tc.repoResp, tc.repoError)
+
+			service := NewUserService(new(MockTransactor), mockUserRepo, new(MockWalletRepo), tokens, tc.operators, logger.Discard())
+
+			res, err := service.Login(ctx, tc.req)
+
+			assert.Equal(t, tc.expectedError, err)
+			if tc.expectedError != nil {
+				assert.Nil(t, res)
+				return
+			}
+
+			require.NotNil(t, res)
+			assert.Equal(t, "Bearer", res.TokenType)
+
+			user, err := tokens.Verify(res.AccessToken)
+			require.Nil(t, err)
+			assert.Equal(t, tc.expectedUser, user)
+		})
+	}
+}
@@ -2,33 +2,83 @@ package services
 
 import (
 	"context"
+	"database/sql"
+	"encoding/base64"
+	"encoding/json"
+	"math"
+	"net/http"
+	"strings"
+	"time"
 
 	"github.com/vitthalaa/wager-app/app_errors"
 	"github.com/vitthalaa/wager-app/dto"
+	"github.com/vitthalaa/wager-app/internal/events"
+	"github.com/vitthalaa/wager-app/internal/logger"
+	"github.com/vitthalaa/wager-app/internal/metrics"
 	"github.com/vitthalaa/wager-app/internal/repo"
+	"github.com/vitthalaa/wager-app/internal/validation"
+	"github.com/vitthalaa/wager-app/money"
 )
 
+// Wager listing page sizes
+const (
+	DefaultWagerPageSize = 10
+	MaxWagerPageSize     = 100
+)
+
+// Page sizes of purchases and ledger entries
+const (
+	DefaultPageSize = 10
+	MaxPageSize     = 100
+)
+
+// defaultWagerSort lists latest wagers first
+const defaultWagerSort = "-id"
+
+// wagerSortFields maps sort fields of API to repo sort fields
+var wagerSortFields = map[string]repo.WagerSortField{
+	"id":                    repo.WagerSortID,
+	"current_selling_price": repo.WagerSortCurrentSellingPrice,
+	"odds":                  repo.WagerSortOdds,
+	"percentage_sold":       repo.WagerSortPercentageSold,
+	"placed_at":             repo.WagerSortCreatedAt,
+}
+
 // IWagerService ...
 type IWagerService interface {
 	PlaceWager(ctx context.Context, req *dto.PlaceWagerRequest) (*dto.Wager, error)
-	ListWager(ctx context.Context, req *dto.ListWagerRequest) ([]dto.Wager, error)
+	ListWager(ctx context.Context, req *dto.ListWagerRequest) (*dto.WagerList, error)
+	GetWager(ctx context.Context, req *dto.GetWagerRequest) (*dto.WagerDetails, error)
 }
 
 // NewWagerService ...
-func NewWagerService(wagerRepo repo.IWagerRepo) *WagerService {
+func NewWagerService(
+	wagerRepo repo.IWagerRepo, purchaseRepo repo.IPurchaseRepo, log *logger.Logger, metrics *metrics.Metrics,
+	broker *events.Broker,
+) *WagerService {
 	return &WagerService{
-		wagerRepo: wagerRepo,
+		wagerRepo:    wagerRepo,
+		purchaseRepo: purchaseRepo,
+		log:          log,
+		metrics:      metrics,
+		events:       broker,
+		now:          time.Now,
 	}
 }
 
 // WagerService ...
 type WagerService struct {
-	wagerRepo repo.IWagerRepo
+	wagerRepo    repo.IWagerRepo
+	purchaseRepo repo.IPurchaseRepo
+	log          *logger.Logger
+	metrics      *metrics.Metrics
+	events       *events.Broker
+	now          func() time.Time
 }
 
 // PlaceWager ...
 func (s *WagerService) PlaceWager(ctx context.Context, req *dto.PlaceWagerRequest) (*dto.Wager, error) {
-	errRes := validatePlaceWagerRequest(req)
+	errRes := validatePlaceWagerRequest(req, s.now())
 	if errRes != nil {
 		return nil, errRes
 	}
@@ -38,56 +88,313 @@ func (s *WagerService) PlaceWager(ctx context.Context, req *dto.PlaceWagerReques
 		return nil, err
 	}
 
-	wagerDto := toWagerDTO(*wager)
+	s.metrics.WagersPlaced.Inc()
+	s.log.Info(ctx, "wager placed", "wager_id", wager.ID, "seller_id", req.SellerID)
+
+	wagerDto := toWagerDTO(*wager, s.now())
+	s.events.Publish(events.TypeWagerCreated, wagerDto)
 	return &wagerDto, nil
 }
 
-// ListWager ...
-func (s *WagerService) ListWager(ctx context.Context, req *dto.ListWagerRequest) ([]dto.Wager, error) {
-	offset := uint32(0)
-	limit := uint32(10)
-	if req.Limit > 0 {
-		limit = req.Limit
+// ListWager returns page of wagers matching request filter in requested sort order, latest first by default.
+// Page continues after sort values of last wager encoded in request cursor, so wagers placed or changed meanwhile
+// do not shift it.
+func (s *WagerService) ListWager(ctx context.Context, req *dto.ListWagerRequest) (*dto.WagerList, error) {
+	filter, sort, errRes := toWagerFilter(req)
+	if errRes != nil {
+		return nil, errRes
 	}
 
-	if req.Page > 0 {
-		offset = (req.Page - 1) * limit
-	}
+	limit := filter.Limit
+	// one more wager tells whether there is next page
+	filter.Limit++
 
-	res, err := s.wagerRepo.ListWager(ctx, offset, limit)
+	res, err := s.wagerRepo.ListWager(ctx, filter)
 	if err != nil {
 		return nil, err
 	}
 
-	dtoList := make([]dto.Wager, 0, len(res))
+	list := &dto.WagerList{Items: make([]dto.Wager, 0, len(res))}
+	if uint32(len(res)) > limit {
+		res = res[:limit]
+		list.HasMore = true
+		list.NextCursor = encodeWagerCursor(newWagerCursor(res[len(res)-1], sort))
+	}
+
+	now := s.now()
 	for _, rs := range res {
-		dtoList = append(dtoList, toWagerDTO(rs))
+		list.Items = append(list.Items, toWagerDTO(rs, now))
+	}
+
+	return list, nil
+}
+
+// GetWager returns wager by id with requested page of its purchases
+func (s *WagerService) GetWager(ctx context.Context, req *dto.GetWagerRequest) (*dto.WagerDetails, error) {
+	if req == nil || req.WagerID == 0 {
+		return nil, &app_errors.ErrorResponse{Status: http.StatusBadRequest, Code: app_errors.ErrInvalidWagerID}
+	}
+
+	offset, limit, errRes := toOffsetLimit(req.Page, req.Limit)
+	if errRes != nil {
+		return nil, errRes
+	}
+
+	wager, err := s.wagerRepo.GetWagerByID(ctx, req.WagerID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, &app_errors.ErrorResponse{Status: http.StatusNotFound, Code: app_errors.ErrNotFound}
+		}
+
+		return nil, err
+	}
+
+	filter := repo.PurchaseFilter{
+		WagerID:  wager.ID,
+		SortDesc: true,
+		Offset:   offset,
+		Limit:    limit,
+	}
+
+	purchases, err := s.purchaseRepo.ListPurchases(ctx, filter)
+	if err != nil {
+		return nil, err
+	}
+
+	total, err := s.purchaseRepo.CountPurchases(ctx, filter)
+	if err != nil {
+		return nil, err
+	}
+
+	items := make([]dto.WagerPurchase, 0, len(purchases))
+	for _, p := range purchases {
+		items = append(items, toWagerPurchaseDTO(p))
+	}
+
+	return &dto.WagerDetails{
+		Wager: toWagerDTO(*wager, s.now()),
+		Purchases: dto.WagerPurchaseList{
+			Items: items,
+			Page:  offset/limit + 1,
+			Limit: limit,
+			Total: total,
+		},
+	}, nil
+}
+
+// toOffsetLimit converts 1 based page number and page size to offset and limit. Page size defaults to
+// DefaultPageSize and over MaxPageSize is rejected like on wager listing, pages past range of offset are clamped
+// to the last one in it.
+func toOffsetLimit(page, pageSize uint32) (offset, limit uint32, errRes *app_errors.ErrorResponse) {
+	if pageSize > MaxPageSize {
+		return 0, 0, &app_errors.ErrorResponse{Status: http.StatusBadRequest, Code: app_errors.ErrInvalidFilter}
+	}
+
+	limit = DefaultPageSize
+	if pageSize > 0 {
+		limit = pageSize
+	}
+
+	if page > 0 {
+		pages := page - 1
+		if pages > math.MaxUint32/limit {
+			pages = math.MaxUint32 / limit
+		}
+
+		offset = pages * limit
+	}
+
+	return offset, limit, nil
+}
+
+// validatePlaceWagerRequest returns all violations of request. Selling price is checked against
+// total wager value * selling percentage once they are valid, parameters of pricing against its strategy
+// and end of auction against time now.
+func validatePlaceWagerRequest(req *dto.PlaceWagerRequest, now time.Time) *app_errors.ErrorResponse {
+	errs := validation.Struct(req)
+	if !errs.Has("total_wager_value") && !errs.Has("selling_percentage") && !errs.Has("selling_price") {
+		minPrice := money.FromUnits(int64(req.TotalWagerValue)).Percent(float64(req.SellingPercentage))
+		if req.SellingPrice <= minPrice {
+			errs.Add(app_errors.ErrInvalidSellingPrice,
+				validation.Violation("selling_price", validation.Rule{Name: "gt", Limit: minPrice.String()}))
+		}
+	}
+
+	validatePricing(req, errs)
+	validateAuction(req, now, errs)
+	return errs.Err()
+}
+
+// validateAuction adds violation of unknown auction of request, of end of auction without auction
+// or of missing end or end not after time now
+func validateAuction(req *dto.PlaceWagerRequest, now time.Time, errs *validation.Errors) {
+	switch repo.WagerAuction(req.Auction) {
+	case repo.WagerAuctionNone:
+		if req.AuctionEndsAt != nil {
+			errs.Add(app_errors.ErrInvalidAuction, validation.Violation("auction", validation.Rule{Name: "required"}))
+		}
+	case repo.WagerAuctionFirstPrice, repo.WagerAuctionSecondPrice:
+		if req.AuctionEndsAt == nil {
+			errs.Add(app_errors.ErrInvalidAuctionEndsAt,
+				validation.Violation("auction_ends_at", validation.Rule{Name: "required"}))
+		} else if !req.AuctionEndsAt.After(now) {
+			errs.Add(app_errors.ErrInvalidAuctionEndsAt,
+				validation.Violation("auction_ends_at", validation.Rule{Name: "gt", Limit: now.UTC().Format(time.RFC3339)}))
+		}
+	default:
+		errs.Add(app_errors.ErrInvalidAuction, validation.Violation("auction", validation.Rule{
+			Name:  "oneof",
+			Limit: "first_price second_price",
+		}))
+	}
+}
+
+// validatePricing adds violation of unknown pricing strategy of request or of parameters it requires
+func validatePricing(req *dto.PlaceWagerRequest, errs *validation.Errors) {
+	switch repo.WagerPricing(req.Pricing) {
+	case "", repo.WagerPricingLastTrade:
+	case repo.WagerPricingTimeDecay, repo.WagerPricingDutchAuction:
+		if req.FloorPrice == 0 {
+			errs.Add(app_errors.ErrInvalidFloorPrice, validation.Violation("floor_price", validation.Rule{Name: "required"}))
+		} else if !errs.Has("floor_price") && !errs.Has("selling_price") && req.FloorPrice > req.SellingPrice {
+			errs.Add(app_errors.ErrInvalidFloorPrice,
+				validation.Violation("floor_price", validation.Rule{Name: "max", Limit: req.SellingPrice.String()}))
+		}
+
+		if req.DecaySeconds == 0 {
+			errs.Add(app_errors.ErrInvalidDecaySeconds,
+				validation.Violation("decay_seconds", validation.Rule{Name: "required"}))
+		}
+	case repo.WagerPricingDemandStepUp:
+		if req.StepPercentage == 0 {
+			errs.Add(app_errors.ErrInvalidStepPercentage,
+				validation.Violation("step_percentage", validation.Rule{Name: "required"}))
+		}
+	default:
+		errs.Add(app_errors.ErrInvalidPricing, validation.Violation("pricing", validation.Rule{
+			Name:  "oneof",
+			Limit: "last_trade time_decay demand_step_up dutch_auction",
+		}))
+	}
+}
+
+// wagerCursor is position in wager listing, encoded opaquely so clients do not depend on its content
+type wagerCursor struct {
+	// ID is id of last wager of previous page
+	ID uint32 `json:"id"`
+	// Sort is sort of listing the cursor belongs to, cursor is not valid for other sort
+	Sort string `json:"sort"`
+	// Price, Odds, PercentageSold and PlacedAt are sort values of last wager of previous page
+	Price          money.Money `json:"price"`
+	Odds           uint32      `json:"odds"`
+	PercentageSold float64     `json:"percentage_sold"`
+	PlacedAt       time.Time   `json:"placed_at"`
+}
+
+func newWagerCursor(wager repo.Wager, sort string) wagerCursor {
+	cursor := repo.NewWagerCursor(wager)
+	return wagerCursor{
+		ID:             cursor.ID,
+		Sort:           sort,
+		Price:          cursor.CurrentSellingPrice,
+		Odds:           cursor.Odds,
+		PercentageSold: cursor.PercentageSold,
+		PlacedAt:       cursor.CreatedAt,
+	}
+}
+
+// after returns repo keyset cursor of position
+func (c wagerCursor) after() *repo.WagerCursor {
+	return &repo.WagerCursor{
+		ID:                  c.ID,
+		CurrentSellingPrice: c.Price,
+		Odds:                c.Odds,
+		PercentageSold:      c.PercentageSold,
+		CreatedAt:           c.PlacedAt,
+	}
+}
+
+func encodeWagerCursor(cursor wagerCursor) string {
+	b, _ := json.Marshal(cursor)
+	return base64.RawURLEncoding.EncodeToString(b)
+}
+
+func decodeWagerCursor(s string) (wagerCursor, bool) {
+	var cursor wagerCursor
+	b, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil || json.Unmarshal(b, &cursor) != nil || cursor.ID == 0 {
+		return wagerCursor{}, false
 	}
 
-	return dtoList, nil
+	return cursor, true
 }
 
-func validatePlaceWagerRequest(req *dto.PlaceWagerRequest) *app_errors.ErrorResponse {
-	err := &app_errors.ErrorResponse{
-		Status: 400,
+// toWagerFilter validates list request and converts it to repo filter, sort is request sort or default one
+func toWagerFilter(req *dto.ListWagerRequest) (filter repo.WagerFilter, sort string, errRes *app_errors.ErrorResponse) {
+	filter = repo.WagerFilter{
+		MinOdds:  req.MinOdds,
+		MaxOdds:  req.MaxOdds,
+		MinPrice: req.MinPrice,
+		MaxPrice: req.MaxPrice,
+		SoldOut:  req.SoldOut,
+		Limit:    req.Limit,
+	}
+
+	if filter.Limit == 0 {
+		filter.Limit = DefaultWagerPageSize
+	}
+
+	invalidFilter := &app_errors.ErrorResponse{Status: http.StatusBadRequest, Code: app_errors.ErrInvalidFilter}
+	if filter.Limit > MaxWagerPageSize {
+		return filter, "", invalidFilter
+	}
+
+	if req.MaxOdds > 0 && req.MinOdds > req.MaxOdds {
+		return filter, "", invalidFilter
 	}
-	switch true {
-	case req.TotalWagerValue < 1:
-		err.Code = app_errors.ErrInvalidTotalWagerValue
-		return err
 
-	case req.Odds < 1:
-		err.Code = app_errors.ErrInvalidOdds
-		return err
+	if req.MinPrice < 0 || req.MaxPrice < 0 || (req.MaxPrice > 0 && req.MinPrice > req.MaxPrice) {
+		return filter, "", invalidFilter
+	}
+
+	if req.From != nil {
+		filter.From = *req.From
+	}
+
+	if req.To != nil {
+		filter.To = *req.To
+	}
+
+	if req.From != nil && req.To != nil && req.From.After(*req.To) {
+		return filter, "", invalidFilter
+	}
+
+	sort = req.Sort
+	if sort == "" {
+		sort = defaultWagerSort
+	}
+
+	sortBy, ok := wagerSortFields[strings.TrimPrefix(sort, "-")]
+	if !ok {
+		return filter, "", &app_errors.ErrorResponse{Status: http.StatusBadRequest, Code: app_errors.ErrInvalidSort}
+	}
+
+	filter.SortBy = sortBy
+	filter.SortDesc = strings.HasPrefix(sort, "-")
+
+	// stored price of time priced wagers is stale, they are left out of listings by price
+	if sortBy == repo.WagerSortCurrentSellingPrice || req.MinPrice > 0 || req.MaxPrice > 0 {
+		filter.ExcludePricings = timePricings
+	}
 
-	case req.SellingPercentage < 1 || req.SellingPercentage > 100:
-		err.Code = app_errors.ErrInvalidSellingPercentage
-		return err
+	if req.Cursor != "" {
+		cursor, ok := decodeWagerCursor(req.Cursor)
+		if !ok || cursor.Sort != sort {
+			return filter, "", &app_errors.ErrorResponse{Status: http.StatusBadRequest, Code: app_errors.ErrInvalidCursor}
+		}
 
-	case req.SellingPrice <= float32(req.TotalWagerValue)*(req.SellingPercentage/100):
-		err.Code = app_errors.ErrInvalidSellingPrice
-		return err
+		filter.After = cursor.after()
 	}
 
-	return nil
+	return filter, sort, nil
 }
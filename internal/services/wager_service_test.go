@@ -4,15 +4,22 @@ import (
 	"context"
 	"database/sql"
 	"errors"
+	"math"
+	"net/http"
 	"testing"
 	"time"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
 
 	"github.com/vitthalaa/wager-app/app_errors"
 	"github.com/vitthalaa/wager-app/dto"
+	"github.com/vitthalaa/wager-app/internal/events"
+	"github.com/vitthalaa/wager-app/internal/logger"
+	"github.com/vitthalaa/wager-app/internal/metrics"
 	"github.com/vitthalaa/wager-app/internal/repo"
+	"github.com/vitthalaa/wager-app/money"
 )
 
 func TestWagerService_PlaceWager(t *testing.T) {
@@ -33,19 +40,20 @@ func TestWagerService_PlaceWager(t *testing.T) {
 				TotalWagerValue:   1000,
 				Odds:              2,
 				SellingPercentage: 20,
-				SellingPrice:      201,
+				SellingPrice:      money.MustParse("201"),
 			},
 			repoResp: &repo.Wager{
 				ID:                  111,
 				TotalWagerValue:     1000,
 				Odds:                2,
 				SellingPercentage:   20,
-				SellingPrice:        201,
-				CurrentSellingPrice: 201,
+				SellingPrice:        money.MustParse("201"),
+				CurrentSellingPrice: money.MustParse("201"),
 				CreatedAt: sql.NullTime{
 					Time:  now,
 					Valid: true,
 				},
+				Pricing: repo.WagerPricingLastTrade,
 			},
 			repoError: nil,
 			expectedRes: &dto.Wager{
@@ -53,11 +61,12 @@ func TestWagerService_PlaceWager(t *testing.T) {
 				TotalWagerValue:     1000,
 				Odds:                2,
 				SellingPercentage:   20,
-				SellingPrice:        201,
-				CurrentSellingPrice: 201,
+				SellingPrice:        money.MustParse("201"),
+				CurrentSellingPrice: money.MustParse("201"),
 				PercentageSold:      0,
 				AmountSold:          0,
 				PlacedAt:            &now,
+				Pricing:             string(repo.WagerPricingLastTrade),
 			},
 			expectedError: nil,
 		},
@@ -67,7 +76,7 @@ func TestWagerService_PlaceWager(t *testing.T) {
 				TotalWagerValue:   0,
 				Odds:              2,
 				SellingPercentage: 20,
-				SellingPrice:      201,
+				SellingPrice:      money.MustParse("201"),
 			},
 			repoResp:    nil,
 			repoError:   nil,
@@ -75,6 +84,9 @@ func TestWagerService_PlaceWager(t *testing.T) {
 			expectedError: &app_errors.ErrorResponse{
 				Status: 400,
 				Code:   app_errors.ErrInvalidTotalWagerValue,
+				Details: []app_errors.FieldError{
+					{Field: "total_wager_value", Rule: "min", Message: "must be at least 1", Limits: map[string]string{"min": "1"}},
+				},
 			},
 		},
 		{
@@ -83,7 +95,7 @@ func TestWagerService_PlaceWager(t *testing.T) {
 				TotalWagerValue:   1000,
 				Odds:              2,
 				SellingPercentage: 20,
-				SellingPrice:      201,
+				SellingPrice:      money.MustParse("201"),
 			},
 			repoResp:      nil,
 			repoError:     errors.New("some repo error"),
@@ -97,17 +109,36 @@ func TestWagerService_PlaceWager(t *testing.T) {
 			mockRepo.On("CreateWager", ctx, mock.Anything).
 				Return(tc.repoResp, tc.repoError)
 
-			service := NewWagerService(mockRepo)
+			appMetrics := metrics.New()
+			broker := events.NewBroker(1)
+			sub, _, err := broker.Subscribe(0, 0)
+			require.Nil(t, err)
+
+			service := NewWagerService(mockRepo, new(MockPurchaseRepo), logger.Discard(), appMetrics, broker)
 
 			wager, err := service.PlaceWager(ctx, tc.input)
 
 			assert.Equal(t, tc.expectedRes, wager)
 			assert.Equal(t, err, tc.expectedError)
+
+			var placed float64
+			if tc.expectedError == nil {
+				placed = 1
+				event := <-sub.Events()
+				assert.Equal(t, events.TypeWagerCreated, event.Type)
+				assert.Equal(t, *tc.expectedRes, event.Wager)
+			}
+
+			assert.Equal(t, placed, appMetrics.WagersPlaced.Value())
+			assert.Empty(t, sub.Events())
 		})
 	}
 }
 
 func Test_validatePlaceWagerRequest(t *testing.T) {
+	now := time.Date(2026, 10, 18, 12, 0, 0, 0, time.UTC)
+	auctionEndsAt := now.Add(time.Hour)
+
 	for _, tc := range []struct {
 		name          string
 		req           *dto.PlaceWagerRequest
@@ -119,7 +150,7 @@ func Test_validatePlaceWagerRequest(t *testing.T) {
 				TotalWagerValue:   1000,
 				Odds:              2,
 				SellingPercentage: 20,
-				SellingPrice:      201,
+				SellingPrice:      money.MustParse("201"),
 			},
 			expectedError: nil,
 		},
@@ -129,11 +160,14 @@ func Test_validatePlaceWagerRequest(t *testing.T) {
 				TotalWagerValue:   0,
 				Odds:              2,
 				SellingPercentage: 20,
-				SellingPrice:      201,
+				SellingPrice:      money.MustParse("201"),
 			},
 			expectedError: &app_errors.ErrorResponse{
 				Status: 400,
 				Code:   app_errors.ErrInvalidTotalWagerValue,
+				Details: []app_errors.FieldError{
+					{Field: "total_wager_value", Rule: "min", Message: "must be at least 1", Limits: map[string]string{"min": "1"}},
+				},
 			},
 		},
 		{
@@ -142,11 +176,46 @@ func Test_validatePlaceWagerRequest(t *testing.T) {
 				TotalWagerValue:   1000,
 				Odds:              0,
 				SellingPercentage: 20,
-				SellingPrice:      201,
+				SellingPrice:      money.MustParse("201"),
+			},
+			expectedError: &app_errors.ErrorResponse{
+				Status: 400,
+				Code:   app_errors.ErrInvalidOdds,
+				Details: []app_errors.FieldError{
+					{Field: "odds", Rule: "min", Message: "must be at least 1", Limits: map[string]string{"min": "1"}},
+				},
+			},
+		},
+		{
+			name: "odds over limit",
+			req: &dto.PlaceWagerRequest{
+				TotalWagerValue:   1000,
+				Odds:              101,
+				SellingPercentage: 20,
+				SellingPrice:      money.MustParse("201"),
 			},
 			expectedError: &app_errors.ErrorResponse{
 				Status: 400,
 				Code:   app_errors.ErrInvalidOdds,
+				Details: []app_errors.FieldError{
+					{Field: "odds", Rule: "max", Message: "must be at most 100", Limits: map[string]string{"max": "100"}},
+				},
+			},
+		},
+		{
+			name: "selling price over limit",
+			req: &dto.PlaceWagerRequest{
+				TotalWagerValue:   1000,
+				Odds:              2,
+				SellingPercentage: 20,
+				SellingPrice:      money.MustParse("100000000000.00"),
+			},
+			expectedError: &app_errors.ErrorResponse{
+				Status: 400,
+				Code:   app_errors.ErrInvalidSellingPrice,
+				Details: []app_errors.FieldError{
+					{Field: "selling_price", Rule: "max", Message: "must be at most 1000000000.00", Limits: map[string]string{"max": "1000000000.00"}},
+				},
 			},
 		},
 		{
@@ -155,11 +224,14 @@ func Test_validatePlaceWagerRequest(t *testing.T) {
 				TotalWagerValue:   1000,
 				Odds:              2,
 				SellingPercentage: 101,
-				SellingPrice:      201,
+				SellingPrice:      money.MustParse("201"),
 			},
 			expectedError: &app_errors.ErrorResponse{
 				Status: 400,
 				Code:   app_errors.ErrInvalidSellingPercentage,
+				Details: []app_errors.FieldError{
+					{Field: "selling_percentage", Rule: "max", Message: "must be at most 100", Limits: map[string]string{"max": "100"}},
+				},
 			},
 		},
 		{
@@ -168,16 +240,282 @@ func Test_validatePlaceWagerRequest(t *testing.T) {
 				TotalWagerValue:   1000,
 				Odds:              2,
 				SellingPercentage: 20,
-				SellingPrice:      100,
+				SellingPrice:      money.MustParse("100"),
+			},
+			expectedError: &app_errors.ErrorResponse{
+				Status: 400,
+				Code:   app_errors.ErrInvalidSellingPrice,
+				Details: []app_errors.FieldError{
+					{Field: "selling_price", Rule: "gt", Message: "must be greater than 200.00", Limits: map[string]string{"gt": "200.00"}},
+				},
+			},
+		},
+		{
+			name: "selling price equal to selling percentage of total value",
+			req: &dto.PlaceWagerRequest{
+				TotalWagerValue:   1000,
+				Odds:              2,
+				SellingPercentage: 20,
+				SellingPrice:      money.MustParse("200.00"),
 			},
 			expectedError: &app_errors.ErrorResponse{
 				Status: 400,
 				Code:   app_errors.ErrInvalidSellingPrice,
+				Details: []app_errors.FieldError{
+					{Field: "selling_price", Rule: "gt", Message: "must be greater than 200.00", Limits: map[string]string{"gt": "200.00"}},
+				},
+			},
+		},
+		{
+			name: "all violations",
+			req: &dto.PlaceWagerRequest{
+				TotalWagerValue:   0,
+				Odds:              0,
+				SellingPercentage: 0,
+				SellingPrice:      money.MustParse("201"),
+			},
+			expectedError: &app_errors.ErrorResponse{
+				Status: 400,
+				Code:   app_errors.ErrInvalidTotalWagerValue,
+				Details: []app_errors.FieldError{
+					{Field: "total_wager_value", Rule: "min", Message: "must be at least 1", Limits: map[string]string{"min": "1"}},
+					{Field: "odds", Rule: "min", Message: "must be at least 1", Limits: map[string]string{"min": "1"}},
+					{Field: "selling_percentage", Rule: "min", Message: "must be at least 1", Limits: map[string]string{"min": "1"}},
+				},
+			},
+		},
+		{
+			name: "selling price cent above selling percentage of total value",
+			req: &dto.PlaceWagerRequest{
+				TotalWagerValue:   1000,
+				Odds:              2,
+				SellingPercentage: 20,
+				SellingPrice:      money.MustParse("200.01"),
+			},
+			expectedError: nil,
+		},
+		{
+			name: "dutch auction pricing",
+			req: &dto.PlaceWagerRequest{
+				TotalWagerValue:   1000,
+				Odds:              2,
+				SellingPercentage: 20,
+				SellingPrice:      money.MustParse("201"),
+				Pricing:           "dutch_auction",
+				FloorPrice:        money.MustParse("201"),
+				DecaySeconds:      3600,
+			},
+			expectedError: nil,
+		},
+		{
+			name: "unknown pricing",
+			req: &dto.PlaceWagerRequest{
+				TotalWagerValue:   1000,
+				Odds:              2,
+				SellingPercentage: 20,
+				SellingPrice:      money.MustParse("201"),
+				Pricing:           "auction",
+			},
+			expectedError: &app_errors.ErrorResponse{
+				Status: 400,
+				Code:   app_errors.ErrInvalidPricing,
+				Details: []app_errors.FieldError{
+					{
+						Field:   "pricing",
+						Rule:    "oneof",
+						Message: "must be one of last_trade, time_decay, demand_step_up, dutch_auction",
+						Limits:  map[string]string{"oneof": "last_trade time_decay demand_step_up dutch_auction"},
+					},
+				},
+			},
+		},
+		{
+			name: "time decay pricing without parameters",
+			req: &dto.PlaceWagerRequest{
+				TotalWagerValue:   1000,
+				Odds:              2,
+				SellingPercentage: 20,
+				SellingPrice:      money.MustParse("201"),
+				Pricing:           "time_decay",
+				StepPercentage:    5,
+			},
+			expectedError: &app_errors.ErrorResponse{
+				Status: 400,
+				Code:   app_errors.ErrInvalidFloorPrice,
+				Details: []app_errors.FieldError{
+					{Field: "floor_price", Rule: "required", Message: "is required"},
+					{Field: "decay_seconds", Rule: "required", Message: "is required"},
+				},
+			},
+		},
+		{
+			name: "floor price above selling price",
+			req: &dto.PlaceWagerRequest{
+				TotalWagerValue:   1000,
+				Odds:              2,
+				SellingPercentage: 20,
+				SellingPrice:      money.MustParse("201"),
+				Pricing:           "dutch_auction",
+				FloorPrice:        money.MustParse("201.01"),
+				DecaySeconds:      3600,
+			},
+			expectedError: &app_errors.ErrorResponse{
+				Status: 400,
+				Code:   app_errors.ErrInvalidFloorPrice,
+				Details: []app_errors.FieldError{
+					{Field: "floor_price", Rule: "max", Message: "must be at most 201.00", Limits: map[string]string{"max": "201.00"}},
+				},
+			},
+		},
+		{
+			name: "negative floor price",
+			req: &dto.PlaceWagerRequest{
+				TotalWagerValue:   1000,
+				Odds:              2,
+				SellingPercentage: 20,
+				SellingPrice:      money.MustParse("201"),
+				Pricing:           "time_decay",
+				FloorPrice:        money.MustParse("-1"),
+				DecaySeconds:      3600,
+			},
+			expectedError: &app_errors.ErrorResponse{
+				Status: 400,
+				Code:   app_errors.ErrInvalidFloorPrice,
+				Details: []app_errors.FieldError{
+					{Field: "floor_price", Rule: "min", Message: "must be at least 0.00", Limits: map[string]string{"min": "0.00"}},
+				},
+			},
+		},
+		{
+			name: "demand step up pricing without step percentage",
+			req: &dto.PlaceWagerRequest{
+				TotalWagerValue:   1000,
+				Odds:              2,
+				SellingPercentage: 20,
+				SellingPrice:      money.MustParse("201"),
+				Pricing:           "demand_step_up",
+			},
+			expectedError: &app_errors.ErrorResponse{
+				Status: 400,
+				Code:   app_errors.ErrInvalidStepPercentage,
+				Details: []app_errors.FieldError{
+					{Field: "step_percentage", Rule: "required", Message: "is required"},
+				},
+			},
+		},
+		{
+			name: "step percentage above 100",
+			req: &dto.PlaceWagerRequest{
+				TotalWagerValue:   1000,
+				Odds:              2,
+				SellingPercentage: 20,
+				SellingPrice:      money.MustParse("201"),
+				Pricing:           "demand_step_up",
+				StepPercentage:    101,
+			},
+			expectedError: &app_errors.ErrorResponse{
+				Status: 400,
+				Code:   app_errors.ErrInvalidStepPercentage,
+				Details: []app_errors.FieldError{
+					{Field: "step_percentage", Rule: "max", Message: "must be at most 100", Limits: map[string]string{"max": "100"}},
+				},
+			},
+		},
+		{
+			name: "second price auction",
+			req: &dto.PlaceWagerRequest{
+				TotalWagerValue:   1000,
+				Odds:              2,
+				SellingPercentage: 20,
+				SellingPrice:      money.MustParse("201"),
+				Auction:           "second_price",
+				AuctionEndsAt:     &auctionEndsAt,
+			},
+			expectedError: nil,
+		},
+		{
+			name: "unknown auction",
+			req: &dto.PlaceWagerRequest{
+				TotalWagerValue:   1000,
+				Odds:              2,
+				SellingPercentage: 20,
+				SellingPrice:      money.MustParse("201"),
+				Auction:           "english",
+				AuctionEndsAt:     &auctionEndsAt,
+			},
+			expectedError: &app_errors.ErrorResponse{
+				Status: 400,
+				Code:   app_errors.ErrInvalidAuction,
+				Details: []app_errors.FieldError{
+					{
+						Field:   "auction",
+						Rule:    "oneof",
+						Message: "must be one of first_price, second_price",
+						Limits:  map[string]string{"oneof": "first_price second_price"},
+					},
+				},
+			},
+		},
+		{
+			name: "auction end without auction",
+			req: &dto.PlaceWagerRequest{
+				TotalWagerValue:   1000,
+				Odds:              2,
+				SellingPercentage: 20,
+				SellingPrice:      money.MustParse("201"),
+				AuctionEndsAt:     &auctionEndsAt,
+			},
+			expectedError: &app_errors.ErrorResponse{
+				Status: 400,
+				Code:   app_errors.ErrInvalidAuction,
+				Details: []app_errors.FieldError{
+					{Field: "auction", Rule: "required", Message: "is required"},
+				},
+			},
+		},
+		{
+			name: "auction without end",
+			req: &dto.PlaceWagerRequest{
+				TotalWagerValue:   1000,
+				Odds:              2,
+				SellingPercentage: 20,
+				SellingPrice:      money.MustParse("201"),
+				Auction:           "first_price",
+			},
+			expectedError: &app_errors.ErrorResponse{
+				Status: 400,
+				Code:   app_errors.ErrInvalidAuctionEndsAt,
+				Details: []app_errors.FieldError{
+					{Field: "auction_ends_at", Rule: "required", Message: "is required"},
+				},
+			},
+		},
+		{
+			name: "auction ended",
+			req: &dto.PlaceWagerRequest{
+				TotalWagerValue:   1000,
+				Odds:              2,
+				SellingPercentage: 20,
+				SellingPrice:      money.MustParse("201"),
+				Auction:           "first_price",
+				AuctionEndsAt:     &now,
+			},
+			expectedError: &app_errors.ErrorResponse{
+				Status: 400,
+				Code:   app_errors.ErrInvalidAuctionEndsAt,
+				Details: []app_errors.FieldError{
+					{
+						Field:   "auction_ends_at",
+						Rule:    "gt",
+						Message: "must be greater than 2026-10-18T12:00:00Z",
+						Limits:  map[string]string{"gt": "2026-10-18T12:00:00Z"},
+					},
+				},
 			},
 		},
 	} {
 		t.Run(tc.name, func(t *testing.T) {
-			err := validatePlaceWagerRequest(tc.req)
+			err := validatePlaceWagerRequest(tc.req, now)
 
 			assert.Equal(t, tc.expectedError, err)
 		})
@@ -186,115 +524,413 @@ func Test_validatePlaceWagerRequest(t *testing.T) {
 
 func TestWagerService_ListWager(t *testing.T) {
 	now := time.Now()
+	repoWager := func(id uint32) repo.Wager {
+		return repo.Wager{
+			ID:                  id,
+			TotalWagerValue:     100,
+			Odds:                2,
+			SellingPercentage:   20.5,
+			SellingPrice:        money.MustParse("20.6"),
+			CurrentSellingPrice: money.MustParse("40.6"),
+			PercentageSold:      sql.NullFloat64{Float64: 50.1, Valid: true},
+			AmountSold:          sql.NullInt32{Int32: 50, Valid: true},
+			CreatedAt:           sql.NullTime{Time: now, Valid: true},
+			Status:              repo.WagerStatusOpen,
+		}
+	}
+	dtoWager := func(id uint32) dto.Wager {
+		return dto.Wager{
+			ID:                  id,
+			TotalWagerValue:     100,
+			Odds:                2,
+			SellingPercentage:   20.5,
+			SellingPrice:        money.MustParse("20.6"),
+			CurrentSellingPrice: money.MustParse("40.6"),
+			PercentageSold:      50.1,
+			AmountSold:          50,
+			PlacedAt:            &now,
+			Status:              string(repo.WagerStatusOpen),
+		}
+	}
+
+	soldOut := true
+	from := now.Add(-time.Hour)
 	for _, tc := range []struct {
 		name           string
 		req            *dto.ListWagerRequest
-		expectedOffset uint32
-		expectedLimit  uint32
+		expectedFilter repo.WagerFilter
 		repoResp       []repo.Wager
 		repoError      error
-		expectedResp   []dto.Wager
+		expectedResp   *dto.WagerList
 		expectedError  error
 	}{
 		{
-			name: "happy path",
+			name:           "first page with more wagers",
+			req:            &dto.ListWagerRequest{Limit: 2},
+			expectedFilter: repo.WagerFilter{SortBy: repo.WagerSortID, SortDesc: true, Limit: 3},
+			repoResp:       []repo.Wager{repoWager(333), repoWager(222), repoWager(111)},
+			expectedResp: &dto.WagerList{
+				Items:      []dto.Wager{dtoWager(333), dtoWager(222)},
+				NextCursor: encodeWagerCursor(newWagerCursor(repoWager(222), "-id")),
+				HasMore:    true,
+			},
+		},
+		{
+			name: "last page after cursor with default limit",
+			req:  &dto.ListWagerRequest{Cursor: encodeWagerCursor(wagerCursor{ID: 222, Sort: "-id"})},
+			expectedFilter: repo.WagerFilter{
+				SortBy: repo.WagerSortID, SortDesc: true, After: &repo.WagerCursor{ID: 222}, Limit: DefaultWagerPageSize + 1,
+			},
+			repoResp:     []repo.Wager{repoWager(111)},
+			expectedResp: &dto.WagerList{Items: []dto.Wager{dtoWager(111)}},
+		},
+		{
+			name: "filters",
 			req: &dto.ListWagerRequest{
-				Page:  1,
-				Limit: 20,
+				MinOdds:  2,
+				MaxOdds:  2,
+				MinPrice: money.MustParse("10"),
+				MaxPrice: money.MustParse("30"),
+				SoldOut:  &soldOut,
+				From:     &from,
+				To:       &now,
+				Sort:     "-percentage_sold",
+				Limit:    MaxWagerPageSize,
 			},
-			expectedOffset: 0,
-			expectedLimit:  20,
-			repoResp: []repo.Wager{
+			expectedFilter: repo.WagerFilter{
+				MinOdds:  2,
+				MaxOdds:  2,
+				MinPrice: money.MustParse("10"),
+				MaxPrice: money.MustParse("30"),
+				SoldOut:  &soldOut,
+				From:     from,
+				To:       now,
+				SortBy:   repo.WagerSortPercentageSold,
+				SortDesc: true,
+				Limit:    MaxWagerPageSize + 1,
+				// price filter leaves out time priced wagers
+				ExcludePricings: []repo.WagerPricing{repo.WagerPricingTimeDecay, repo.WagerPricingDutchAuction},
+			},
+			repoResp:     []repo.Wager{},
+			expectedResp: &dto.WagerList{Items: []dto.Wager{}},
+		},
+		{
+			name: "limit above max page size",
+			req:  &dto.ListWagerRequest{Limit: MaxWagerPageSize + 1},
+			expectedError: &app_errors.ErrorResponse{
+				Status: http.StatusBadRequest, Code: app_errors.ErrInvalidFilter,
+			},
+		},
+		{
+			name: "odds range reversed",
+			req:  &dto.ListWagerRequest{MinOdds: 5, MaxOdds: 2},
+			expectedError: &app_errors.ErrorResponse{
+				Status: http.StatusBadRequest, Code: app_errors.ErrInvalidFilter,
+			},
+		},
+		{
+			name: "price range reversed",
+			req:  &dto.ListWagerRequest{MinPrice: money.MustParse("30"), MaxPrice: money.MustParse("10")},
+			expectedError: &app_errors.ErrorResponse{
+				Status: http.StatusBadRequest, Code: app_errors.ErrInvalidFilter,
+			},
+		},
+		{
+			name: "date range reversed",
+			req:  &dto.ListWagerRequest{From: &now, To: &from},
+			expectedError: &app_errors.ErrorResponse{
+				Status: http.StatusBadRequest, Code: app_errors.ErrInvalidFilter,
+			},
+		},
+		{
+			name: "malformed cursor",
+			req:  &dto.ListWagerRequest{Cursor: "not a cursor"},
+			expectedError: &app_errors.ErrorResponse{
+				Status: http.StatusBadRequest, Code: app_errors.ErrInvalidCursor,
+			},
+		},
+		{
+			name: "cursor without wager id",
+			req:  &dto.ListWagerRequest{Cursor: encodeWagerCursor(wagerCursor{Sort: "-id"})},
+			expectedError: &app_errors.ErrorResponse{
+				Status: http.StatusBadRequest, Code: app_errors.ErrInvalidCursor,
+			},
+		},
+		{
+			name: "cursor of other sort",
+			req: &dto.ListWagerRequest{
+				Sort:   "current_selling_price",
+				Cursor: encodeWagerCursor(wagerCursor{ID: 222, Sort: "-id"}),
+			},
+			expectedError: &app_errors.ErrorResponse{
+				Status: http.StatusBadRequest, Code: app_errors.ErrInvalidCursor,
+			},
+		},
+		{
+			name: "sort ascending with cursor",
+			req: &dto.ListWagerRequest{
+				Sort:   "current_selling_price",
+				Cursor: encodeWagerCursor(wagerCursor{ID: 222, Sort: "current_selling_price", Price: money.MustParse("40.6")}),
+				Limit:  1,
+			},
+			expectedFilter: repo.WagerFilter{
+				SortBy:          repo.WagerSortCurrentSellingPrice,
+				After:           &repo.WagerCursor{ID: 222, CurrentSellingPrice: money.MustParse("40.6")},
+				Limit:           2,
+				ExcludePricings: []repo.WagerPricing{repo.WagerPricingTimeDecay, repo.WagerPricingDutchAuction},
+			},
+			repoResp: []repo.Wager{repoWager(111), repoWager(333)},
+			expectedResp: &dto.WagerList{
+				Items:      []dto.Wager{dtoWager(111)},
+				NextCursor: encodeWagerCursor(newWagerCursor(repoWager(111), "current_selling_price")),
+				HasMore:    true,
+			},
+		},
+		{
+			name: "unsupported sort",
+			req:  &dto.ListWagerRequest{Sort: "-seller_id"},
+			expectedError: &app_errors.ErrorResponse{
+				Status: http.StatusBadRequest, Code: app_errors.ErrInvalidSort,
+			},
+		},
+		{
+			name:           "repo error",
+			req:            &dto.ListWagerRequest{},
+			expectedFilter: repo.WagerFilter{SortBy: repo.WagerSortID, SortDesc: true, Limit: DefaultWagerPageSize + 1},
+			repoError:      errors.New("some repo error"),
+			expectedError:  errors.New("some repo error"),
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			ctx := context.Background()
+			mockRepo := new(MockWagerRepo)
+			if tc.expectedFilter.Limit > 0 {
+				mockRepo.On("ListWager", ctx, tc.expectedFilter).
+					Return(tc.repoResp, tc.repoError)
+			}
+
+			service := NewWagerService(mockRepo, new(MockPurchaseRepo), logger.Discard(), metrics.New(), events.NewBroker(1))
+
+			wagerList, err := service.ListWager(ctx, tc.req)
+
+			assert.Equal(t, tc.expectedResp, wagerList)
+			assert.Equal(t, tc.expectedError, err)
+			mockRepo.AssertExpectations(t)
+		})
+	}
+}
+
+func Test_toOffsetLimit(t *testing.T) {
+	for _, tc := range []struct {
+		name     string
+		page     uint32
+		pageSize uint32
+
+		expectedOffset uint32
+		expectedLimit  uint32
+		expectedError  *app_errors.ErrorResponse
+	}{
+		{name: "defaults", expectedOffset: 0, expectedLimit: DefaultPageSize},
+		{name: "page", page: 3, pageSize: 20, expectedOffset: 40, expectedLimit: 20},
+		{name: "max page size", page: 2, pageSize: MaxPageSize, expectedOffset: MaxPageSize, expectedLimit: MaxPageSize},
+		{
+			name: "page size over max", page: 2, pageSize: MaxPageSize + 1,
+			expectedError: &app_errors.ErrorResponse{Status: http.StatusBadRequest, Code: app_errors.ErrInvalidFilter},
+		},
+		{name: "last page", page: math.MaxUint32, pageSize: 1, expectedOffset: math.MaxUint32 - 1, expectedLimit: 1},
+		{name: "offset over max of page size", page: math.MaxUint32, pageSize: 100, expectedOffset: 4294967200, expectedLimit: 100},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			offset, limit, errRes := toOffsetLimit(tc.page, tc.pageSize)
+			assert.Equal(t, tc.expectedOffset, offset)
+			assert.Equal(t, tc.expectedLimit, limit)
+			assert.Equal(t, tc.expectedError, errRes)
+		})
+	}
+}
+
+func TestWagerService_GetWager(t *testing.T) {
+	now := time.Now()
+	repoWager := &repo.Wager{
+		ID:                  111,
+		TotalWagerValue:     100,
+		Odds:                2,
+		SellingPercentage:   20,
+		SellingPrice:        money.MustParse("21"),
+		CurrentSellingPrice: money.MustParse("20.5"),
+		PercentageSold: sql.NullFloat64{
+			Float64: 1,
+			Valid:   true,
+		},
+		AmountSold: sql.NullInt32{
+			Int32: 1,
+			Valid: true,
+		},
+		CreatedAt: sql.NullTime{
+			Time:  now,
+			Valid: true,
+		},
+	}
+
+	for _, tc := range []struct {
+		name string
+		req  *dto.GetWagerRequest
+
+		wagerRepoResp  *repo.Wager
+		wagerRepoError error
+
+		expectedOffset     uint32
+		expectedLimit      uint32
+		purchasesRepoResp  []repo.Purchase
+		purchasesRepoError error
+		countRepoResp      uint32
+		countRepoError     error
+
+		expectedRes   *dto.WagerDetails
+		expectedError error
+	}{
+		{
+			name: "happy path",
+			req: &dto.GetWagerRequest{
+				WagerID: 111,
+				Page:    2,
+				Limit:   1,
+			},
+			wagerRepoResp:  repoWager,
+			expectedOffset: 1,
+			expectedLimit:  1,
+			purchasesRepoResp: []repo.Purchase{
 				{
-					ID:                  111,
-					TotalWagerValue:     1000,
-					Odds:                2,
-					SellingPercentage:   20,
-					SellingPrice:        201,
-					CurrentSellingPrice: 201,
+					ID:          1,
+					WagerID:     111,
+					BuyingPrice: money.MustParse("20.5"),
 					CreatedAt: sql.NullTime{
 						Time:  now,
 						Valid: true,
 					},
 				},
-				{
-					ID:                  222,
+			},
+			countRepoResp: 2,
+			expectedRes: &dto.WagerDetails{
+				Wager: dto.Wager{
+					ID:                  111,
 					TotalWagerValue:     100,
 					Odds:                2,
-					SellingPercentage:   20.5,
-					SellingPrice:        20.6,
-					CurrentSellingPrice: 40.6,
-					PercentageSold: sql.NullFloat64{
-						Float64: 50.1,
-						Valid:   true,
-					},
-					AmountSold: sql.NullInt32{
-						Int32: 50,
-						Valid: true,
-					},
-					CreatedAt: sql.NullTime{
-						Time:  now,
-						Valid: true,
-					},
-					UpdatedAt: sql.NullTime{
-						Time:  now,
-						Valid: true,
+					SellingPercentage:   20,
+					SellingPrice:        money.MustParse("21"),
+					CurrentSellingPrice: money.MustParse("20.5"),
+					PercentageSold:      1,
+					AmountSold:          1,
+					PlacedAt:            &now,
+				},
+				Purchases: dto.WagerPurchaseList{
+					Items: []dto.WagerPurchase{
+						{
+							ID:          1,
+							WagerID:     111,
+							BuyingPrice: money.MustParse("20.5"),
+							BoughtAt:    &now,
+						},
 					},
+					Page:  2,
+					Limit: 1,
+					Total: 2,
 				},
 			},
-			repoError: nil,
-			expectedResp: []dto.Wager{
-				{
+		},
+		{
+			name:          "limit over max page size",
+			req:           &dto.GetWagerRequest{WagerID: 111, Limit: MaxPageSize + 1},
+			expectedError: &app_errors.ErrorResponse{Status: http.StatusBadRequest, Code: app_errors.ErrInvalidFilter},
+		},
+		{
+			name:              "max page size",
+			req:               &dto.GetWagerRequest{WagerID: 111, Limit: MaxPageSize},
+			wagerRepoResp:     repoWager,
+			expectedOffset:    0,
+			expectedLimit:     MaxPageSize,
+			purchasesRepoResp: []repo.Purchase{},
+			countRepoResp:     1,
+			expectedRes: &dto.WagerDetails{
+				Wager: dto.Wager{
 					ID:                  111,
-					TotalWagerValue:     1000,
+					TotalWagerValue:     100,
 					Odds:                2,
 					SellingPercentage:   20,
-					SellingPrice:        201,
-					CurrentSellingPrice: 201,
-					PercentageSold:      0,
-					AmountSold:          0,
+					SellingPrice:        money.MustParse("21"),
+					CurrentSellingPrice: money.MustParse("20.5"),
+					PercentageSold:      1,
+					AmountSold:          1,
 					PlacedAt:            &now,
 				},
-				{
-					ID:                  222,
-					TotalWagerValue:     100,
-					Odds:                2,
-					SellingPercentage:   20.5,
-					SellingPrice:        20.6,
-					CurrentSellingPrice: 40.6,
-					PercentageSold:      50.1,
-					AmountSold:          50,
-					PlacedAt:            &now,
+				Purchases: dto.WagerPurchaseList{
+					Items: []dto.WagerPurchase{},
+					Page:  1,
+					Limit: MaxPageSize,
+					Total: 1,
 				},
 			},
-			expectedError: nil,
 		},
 		{
-			name: "repo error",
-			req: &dto.ListWagerRequest{
-				Page:  0,
-				Limit: 0,
-			},
+			name:          "invalid wager id",
+			req:           &dto.GetWagerRequest{},
+			expectedError: &app_errors.ErrorResponse{Status: http.StatusBadRequest, Code: app_errors.ErrInvalidWagerID},
+		},
+		{
+			name:           "wager not found",
+			req:            &dto.GetWagerRequest{WagerID: 111},
+			wagerRepoError: sql.ErrNoRows,
+			expectedError:  &app_errors.ErrorResponse{Status: http.StatusNotFound, Code: app_errors.ErrNotFound},
+		},
+		{
+			name:           "wager repo error",
+			req:            &dto.GetWagerRequest{WagerID: 111},
+			wagerRepoError: errors.New("some repo error"),
+			expectedError:  errors.New("some repo error"),
+		},
+		{
+			name:               "purchase repo error",
+			req:                &dto.GetWagerRequest{WagerID: 111},
+			wagerRepoResp:      repoWager,
+			expectedOffset:     0,
+			expectedLimit:      10,
+			purchasesRepoError: errors.New("some purchase repo error"),
+			expectedError:      errors.New("some purchase repo error"),
+		},
+		{
+			name:           "count repo error",
+			req:            &dto.GetWagerRequest{WagerID: 111},
+			wagerRepoResp:  repoWager,
 			expectedOffset: 0,
 			expectedLimit:  10,
-			repoResp:       nil,
-			repoError:      errors.New("some repo error"),
-			expectedResp:   nil,
-			expectedError:  errors.New("some repo error"),
+			countRepoError: errors.New("some count repo error"),
+			expectedError:  errors.New("some count repo error"),
 		},
 	} {
 		t.Run(tc.name, func(t *testing.T) {
 			ctx := context.Background()
-			mockRepo := new(MockWagerRepo)
-			mockRepo.On("ListWager", ctx, tc.expectedOffset, tc.expectedLimit).
-				Return(tc.repoResp, tc.repoError)
+			mockWagerRepo := new(MockWagerRepo)
+			mockWagerRepo.On("GetWagerByID", ctx, tc.req.WagerID).
+				Return(tc.wagerRepoResp, tc.wagerRepoError)
 
-			service := NewWagerService(mockRepo)
+			mockPurchaseRepo := new(MockPurchaseRepo)
+			expectedFilter := repo.PurchaseFilter{
+				WagerID:  tc.req.WagerID,
+				SortDesc: true,
+				Offset:   tc.expectedOffset,
+				Limit:    tc.expectedLimit,
+			}
+			mockPurchaseRepo.On("ListPurchases", ctx, expectedFilter).
+				Return(tc.purchasesRepoResp, tc.purchasesRepoError)
+			mockPurchaseRepo.On("CountPurchases", ctx, expectedFilter).
+				Return(tc.countRepoResp, tc.countRepoError)
 
-			wagerList, err := service.ListWager(ctx, tc.req)
+			service := NewWagerService(mockWagerRepo, mockPurchaseRepo, logger.Discard(), metrics.New(), events.NewBroker(1))
 
-			assert.ElementsMatch(t, tc.expectedResp, wagerList)
-			assert.Equal(t, err, tc.expectedError)
+			wager, err := service.GetWager(ctx, tc.req)
+
+			assert.Equal(t, tc.expectedError, err)
+			assert.Equal(t, tc.expectedRes, wager)
 		})
 	}
 }
@@ -0,0 +1,225 @@
+package services
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"net/http"
+	"sort"
+
+	"github.com/vitthalaa/wager-app/app_errors"
+	"github.com/vitthalaa/wager-app/dto"
+	"github.com/vitthalaa/wager-app/internal/logger"
+	"github.com/vitthalaa/wager-app/internal/repo"
+	"github.com/vitthalaa/wager-app/internal/validation"
+	"github.com/vitthalaa/wager-app/money"
+)
+
+// IWalletService ...
+type IWalletService interface {
+	GetWallet(ctx context.Context, userID uint32) (*dto.Wallet, error)
+	Deposit(ctx context.Context, req *dto.DepositRequest) (*dto.Wallet, error)
+	Withdraw(ctx context.Context, req *dto.WithdrawRequest) (*dto.Wallet, error)
+	ListLedgerEntries(ctx context.Context, req *dto.ListLedgerEntriesRequest) (*dto.LedgerEntryList, error)
+	CheckLedger(ctx context.Context) error
+}
+
+// NewWalletService ...
+func NewWalletService(transactor repo.ITransactor, walletRepo repo.IWalletRepo, log *logger.Logger) *WalletService {
+	return &WalletService{
+		transactor: transactor,
+		walletRepo: walletRepo,
+		log:        log,
+	}
+}
+
+// WalletService ...
+type WalletService struct {
+	transactor repo.ITransactor
+	walletRepo repo.IWalletRepo
+	log        *logger.Logger
+}
+
+// GetWallet returns wallet balance of user
+func (s *WalletService) GetWallet(ctx context.Context, userID uint32) (*dto.Wallet, error) {
+	account, err := s.walletRepo.GetAccountByUserID(ctx, userID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, &app_errors.ErrorResponse{Status: http.StatusNotFound, Code: app_errors.ErrNotFound}
+		}
+
+		return nil, err
+	}
+
+	wallet := toWalletDTO(*account)
+	return &wallet, nil
+}
+
+// Deposit credits amount to wallet of user from external account
+func (s *WalletService) Deposit(ctx context.Context, req *dto.DepositRequest) (*dto.Wallet, error) {
+	if errRes := validation.Struct(req).Err(); errRes != nil {
+		return nil, errRes
+	}
+
+	return s.moveExternal(ctx, req.UserID, req.Amount, repo.LedgerTransactionDeposit)
+}
+
+// Withdraw debits amount from wallet of user to external account, balance can not go below zero
+func (s *WalletService) Withdraw(ctx context.Context, req *dto.WithdrawRequest) (*dto.Wallet, error) {
+	if errRes := validation.Struct(req).Err(); errRes != nil {
+		return nil, errRes
+	}
+
+	return s.moveExternal(ctx, req.UserID, req.Amount, repo.LedgerTransactionWithdrawal)
+}
+
+// ListLedgerEntries returns page of wallet ledger entries of user, latest first
+func (s *WalletService) ListLedgerEntries(ctx context.Context, req *dto.ListLedgerEntriesRequest) (*dto.LedgerEntryList, error) {
+	offset, limit, errRes := toOffsetLimit(req.Page, req.Limit)
+	if errRes != nil {
+		return nil, errRes
+	}
+
+	account, err := s.walletRepo.GetAccountByUserID(ctx, req.UserID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, &app_errors.ErrorResponse{Status: http.StatusNotFound, Code: app_errors.ErrNotFound}
+		}
+
+		return nil, err
+	}
+
+	entries, err := s.walletRepo.ListLedgerEntries(ctx, account.ID, offset, limit)
+	if err != nil {
+		return nil, err
+	}
+
+	total, err := s.walletRepo.CountLedgerEntries(ctx, account.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	items := make([]dto.LedgerEntry, 0, len(entries))
+	for _, e := range entries {
+		items = append(items, toLedgerEntryDTO(e))
+	}
+
+	return &dto.LedgerEntryList{
+		Items: items,
+		Page:  offset/limit + 1,
+		Limit: limit,
+		Total: total,
+	}, nil
+}
+
+// CheckLedger returns error unless ledger sums to zero, every transaction is balanced and
+// account balances match their entries
+func (s *WalletService) CheckLedger(ctx context.Context) error {
+	check, err := s.walletRepo.CheckLedger(ctx)
+	if err != nil {
+		return err
+	}
+
+	if !check.Balanced() {
+		return fmt.Errorf("ledger is unbalanced: sum %s, unbalanced transactions %d, mismatched accounts %d",
+			check.Sum, check.UnbalancedTransactions, check.MismatchedAccounts)
+	}
+
+	return nil
+}
+
+// moveExternal moves amount between wallet of user and external account, deposits credit the wallet
+func (s *WalletService) moveExternal(
+	ctx context.Context, userID uint32, amount money.Money, kind repo.LedgerTransactionKind,
+) (*dto.Wallet, error) {
+	var account *repo.Account
+	err := s.transactor.WithinTransaction(ctx, func(ctx context.Context) error {
+		accounts, err := lockUserAccounts(ctx, s.walletRepo, userID)
+		if err != nil {
+			return err
+		}
+
+		external, err := s.walletRepo.GetExternalAccount(ctx)
+		if err != nil {
+			return err
+		}
+
+		from, to := accounts[userID], external
+		if kind == repo.LedgerTransactionDeposit {
+			from, to = external, accounts[userID]
+		}
+
+		if err = transfer(ctx, s.walletRepo, kind, sql.NullInt32{}, from, to, amount); err != nil {
+			return err
+		}
+
+		account, err = s.walletRepo.GetAccountByUserID(ctx, userID)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	s.log.Info(ctx, "wallet external transfer", "kind", kind, "user_id", userID, "amount", amount)
+
+	wallet := toWalletDTO(*account)
+	return &wallet, nil
+}
+
+// lockUserAccounts locks wallet accounts of users in user id order, so that concurrent transactions
+// locking same accounts can not deadlock. Must be called within transaction.
+func lockUserAccounts(ctx context.Context, walletRepo repo.IWalletRepo, userIDs ...uint32) (map[uint32]*repo.Account, error) {
+	sorted := append([]uint32(nil), userIDs...)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i] < sorted[j] })
+
+	accounts := make(map[uint32]*repo.Account, len(sorted))
+	for _, userID := range sorted {
+		if _, ok := accounts[userID]; ok {
+			continue
+		}
+
+		account, err := walletRepo.GetAccountByUserIDForUpdate(ctx, userID)
+		if err != nil {
+			if err == sql.ErrNoRows {
+				return nil, &app_errors.ErrorResponse{Status: http.StatusNotFound, Code: app_errors.ErrNotFound}
+			}
+
+			return nil, err
+		}
+
+		accounts[userID] = account
+	}
+
+	return accounts, nil
+}
+
+// transfer records ledger transaction moving positive amount between accounts. Wallets of users can not go below zero,
+// external account is not limited. Accounts of users must be locked by lockUserAccounts.
+func transfer(
+	ctx context.Context, walletRepo repo.IWalletRepo, kind repo.LedgerTransactionKind, purchaseID sql.NullInt32,
+	from, to *repo.Account, amount money.Money,
+) error {
+	if amount <= 0 {
+		return fmt.Errorf("transfer of not positive amount %s", amount)
+	}
+
+	if from.Kind == repo.AccountKindUser && from.Balance < amount {
+		return &app_errors.ErrorResponse{Status: http.StatusPaymentRequired, Code: app_errors.ErrInsufficientFunds}
+	}
+
+	_, err := walletRepo.CreateLedgerTransaction(ctx, &repo.LedgerTransaction{
+		Kind:       kind,
+		PurchaseID: purchaseID,
+		Entries: []repo.LedgerEntry{
+			{AccountID: from.ID, Amount: -amount},
+			{AccountID: to.ID, Amount: amount},
+		},
+	})
+	if err != nil {
+		return err
+	}
+
+	from.Balance -= amount
+	to.Balance += amount
+	return nil
+}
@@ -0,0 +1,273 @@
+package services
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+
+	"github.com/vitthalaa/wager-app/app_errors"
+	"github.com/vitthalaa/wager-app/dto"
+	"github.com/vitthalaa/wager-app/internal/logger"
+	"github.com/vitthalaa/wager-app/internal/repo"
+	"github.com/vitthalaa/wager-app/money"
+)
+
+func TestWalletService_DepositWithdraw(t *testing.T) {
+	now := time.Now()
+	for _, tc := range []struct {
+		name     string
+		withdraw bool
+		amount   money.Money
+
+		accountRepoError error
+		ledgerRepoError  error
+
+		expectedTransaction *repo.LedgerTransaction
+		expectedRes         *dto.Wallet
+		expectedError       error
+	}{
+		{
+			name:   "deposit",
+			amount: money.MustParse("10.5"),
+			expectedTransaction: &repo.LedgerTransaction{
+				Kind: repo.LedgerTransactionDeposit,
+				Entries: []repo.LedgerEntry{
+					{AccountID: 1, Amount: money.MustParse("-10.5")},
+					{AccountID: 50, Amount: money.MustParse("10.5")},
+				},
+			},
+			expectedRes: &dto.Wallet{Balance: money.MustParse("30.5"), UpdatedAt: &now},
+		},
+		{
+			name:     "withdraw",
+			withdraw: true,
+			amount:   money.MustParse("20"),
+			expectedTransaction: &repo.LedgerTransaction{
+				Kind: repo.LedgerTransactionWithdrawal,
+				Entries: []repo.LedgerEntry{
+					{AccountID: 50, Amount: money.MustParse("-20")},
+					{AccountID: 1, Amount: money.MustParse("20")},
+				},
+			},
+			expectedRes: &dto.Wallet{Balance: money.MustParse("30.5"), UpdatedAt: &now},
+		},
+		{
+			name:          "withdraw more than balance",
+			withdraw:      true,
+			amount:        money.MustParse("20.01"),
+			expectedError: &app_errors.ErrorResponse{Status: http.StatusPaymentRequired, Code: app_errors.ErrInsufficientFunds},
+		},
+		{
+			name:   "zero amount",
+			amount: 0,
+			expectedError: &app_errors.ErrorResponse{
+				Status: http.StatusBadRequest, Code: app_errors.ErrInvalidAmount,
+				Details: []app_errors.FieldError{
+					{Field: "amount", Rule: "gt", Message: "must be greater than 0.00", Limits: map[string]string{"gt": "0.00"}},
+				},
+			},
+		},
+		{
+			name:     "negative amount",
+			withdraw: true,
+			amount:   money.MustParse("-1"),
+			expectedError: &app_errors.ErrorResponse{
+				Status: http.StatusBadRequest, Code: app_errors.ErrInvalidAmount,
+				Details: []app_errors.FieldError{
+					{Field: "amount", Rule: "gt", Message: "must be greater than 0.00", Limits: map[string]string{"gt": "0.00"}},
+				},
+			},
+		},
+		{
+			name:             "account not found",
+			amount:           money.MustParse("1"),
+			accountRepoError: sql.ErrNoRows,
+			expectedError:    &app_errors.ErrorResponse{Status: http.StatusNotFound, Code: app_errors.ErrNotFound},
+		},
+		{
+			name:            "ledger repo error",
+			amount:          money.MustParse("1"),
+			ledgerRepoError: errors.New("some ledger repo error"),
+			expectedError:   errors.New("some ledger repo error"),
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			ctx := context.Background()
+			mockWalletRepo := new(MockWalletRepo)
+			mockWalletRepo.On("GetAccountByUserIDForUpdate", ctx, uint32(5)).
+				Return(&repo.Account{ID: 50, Kind: repo.AccountKindUser, Balance: money.MustParse("20")}, tc.accountRepoError)
+			mockWalletRepo.On("GetExternalAccount", ctx).
+				Return(&repo.Account{ID: 1, Kind: repo.AccountKindExternal}, nil)
+			mockWalletRepo.On("GetAccountByUserID", ctx, uint32(5)).
+				Return(&repo.Account{
+					ID:        50,
+					Kind:      repo.AccountKindUser,
+					Balance:   money.MustParse("30.5"),
+					UpdatedAt: sql.NullTime{Time: now, Valid: true},
+				}, nil)
+
+			var expectedTransaction interface{} = mock.Anything
+			if tc.expectedTransaction != nil {
+				expectedTransaction = tc.expectedTransaction
+			}
+
+			mockWalletRepo.On("CreateLedgerTransaction", ctx, expectedTransaction).
+				Return(tc.expectedTransaction, tc.ledgerRepoError)
+
+			mockTransactor := new(MockTransactor)
+			mockTransactor.On("WithinTransaction", ctx, mock.Anything).
+				Return(func(ctx context.Context, fn func(context.Context) error) error {
+					return fn(ctx)
+				})
+
+			service := NewWalletService(mockTransactor, mockWalletRepo, logger.Discard())
+
+			var res *dto.Wallet
+			var err error
+			if tc.withdraw {
+				res, err = service.Withdraw(ctx, &dto.WithdrawRequest{UserID: 5, Amount: tc.amount})
+			} else {
+				res, err = service.Deposit(ctx, &dto.DepositRequest{UserID: 5, Amount: tc.amount})
+			}
+
+			assert.Equal(t, tc.expectedError, err)
+			assert.Equal(t, tc.expectedRes, res)
+			if tc.expectedTransaction != nil {
+				mockWalletRepo.AssertCalled(t, "CreateLedgerTransaction", ctx, tc.expectedTransaction)
+			}
+		})
+	}
+}
+
+func TestWalletService_GetWallet(t *testing.T) {
+	for _, tc := range []struct {
+		name string
+
+		repoResp  *repo.Account
+		repoError error
+
+		expectedRes   *dto.Wallet
+		expectedError error
+	}{
+		{
+			name:        "happy path",
+			repoResp:    &repo.Account{ID: 50, Balance: money.MustParse("12.34")},
+			expectedRes: &dto.Wallet{Balance: money.MustParse("12.34")},
+		},
+		{
+			name:          "account not found",
+			repoError:     sql.ErrNoRows,
+			expectedError: &app_errors.ErrorResponse{Status: http.StatusNotFound, Code: app_errors.ErrNotFound},
+		},
+		{
+			name:          "repo error",
+			repoError:     errors.New("some repo error"),
+			expectedError: errors.New("some repo error"),
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			ctx := context.Background()
+			mockWalletRepo := new(MockWalletRepo)
+			mockWalletRepo.On("GetAccountByUserID", ctx, uint32(5)).
+				Return(tc.repoResp, tc.repoError)
+
+			service := NewWalletService(new(MockTransactor), mockWalletRepo, logger.Discard())
+
+			res, err := service.GetWallet(ctx, 5)
+
+			assert.Equal(t, tc.expectedError, err)
+			assert.Equal(t, tc.expectedRes, res)
+		})
+	}
+}
+
+func TestWalletService_ListLedgerEntries(t *testing.T) {
+	now := time.Now()
+	ctx := context.Background()
+	mockWalletRepo := new(MockWalletRepo)
+	mockWalletRepo.On("GetAccountByUserID", ctx, uint32(5)).
+		Return(&repo.Account{ID: 50}, nil)
+	mockWalletRepo.On("ListLedgerEntries", ctx, uint32(50), uint32(5), uint32(5)).
+		Return([]repo.LedgerEntry{
+			{
+				ID:            3,
+				TransactionID: 2,
+				AccountID:     50,
+				Amount:        money.MustParse("-20"),
+				CreatedAt:     sql.NullTime{Time: now, Valid: true},
+				Kind:          repo.LedgerTransactionPurchase,
+				PurchaseID:    sql.NullInt32{Int32: 7, Valid: true},
+			},
+		}, nil)
+	mockWalletRepo.On("CountLedgerEntries", ctx, uint32(50)).
+		Return(uint32(6), nil)
+
+	service := NewWalletService(new(MockTransactor), mockWalletRepo, logger.Discard())
+
+	res, err := service.ListLedgerEntries(ctx, &dto.ListLedgerEntriesRequest{UserID: 5, Page: 2, Limit: 5})
+
+	assert.Nil(t, err)
+	assert.Equal(t, &dto.LedgerEntryList{
+		Items: []dto.LedgerEntry{
+			{ID: 3, TransactionID: 2, Kind: "purchase", PurchaseID: 7, Amount: money.MustParse("-20"), CreatedAt: &now},
+		},
+		Page:  2,
+		Limit: 5,
+		Total: 6,
+	}, res)
+}
+
+func TestWalletService_ListLedgerEntries_LimitOverMax(t *testing.T) {
+	ctx := context.Background()
+	mockWalletRepo := new(MockWalletRepo)
+
+	service := NewWalletService(new(MockTransactor), mockWalletRepo, logger.Discard())
+
+	res, err := service.ListLedgerEntries(ctx, &dto.ListLedgerEntriesRequest{UserID: 5, Limit: MaxPageSize + 1})
+
+	assert.Equal(t, &app_errors.ErrorResponse{Status: http.StatusBadRequest, Code: app_errors.ErrInvalidFilter}, err)
+	assert.Nil(t, res)
+	mockWalletRepo.AssertExpectations(t)
+}
+
+func TestWalletService_CheckLedger(t *testing.T) {
+	for _, tc := range []struct {
+		name      string
+		check     *repo.LedgerCheck
+		repoError error
+
+		expectedError error
+	}{
+		{
+			name:  "balanced",
+			check: &repo.LedgerCheck{},
+		},
+		{
+			name:          "unbalanced",
+			check:         &repo.LedgerCheck{Sum: money.MustParse("0.01"), UnbalancedTransactions: 1, MismatchedAccounts: 2},
+			expectedError: errors.New("ledger is unbalanced: sum 0.01, unbalanced transactions 1, mismatched accounts 2"),
+		},
+		{
+			name:          "repo error",
+			repoError:     errors.New("some repo error"),
+			expectedError: errors.New("some repo error"),
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			ctx := context.Background()
+			mockWalletRepo := new(MockWalletRepo)
+			mockWalletRepo.On("CheckLedger", ctx).
+				Return(tc.check, tc.repoError)
+
+			service := NewWalletService(new(MockTransactor), mockWalletRepo, logger.Discard())
+
+			assert.Equal(t, tc.expectedError, service.CheckLedger(ctx))
+		})
+	}
+}
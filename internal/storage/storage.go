@@ -0,0 +1,90 @@
+package storage
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/vitthalaa/wager-app/internal/config"
+	"github.com/vitthalaa/wager-app/internal/db"
+	"github.com/vitthalaa/wager-app/internal/logger"
+	"github.com/vitthalaa/wager-app/internal/metrics"
+	"github.com/vitthalaa/wager-app/internal/migrate"
+	"github.com/vitthalaa/wager-app/internal/repo"
+	"github.com/vitthalaa/wager-app/internal/repo/memory"
+)
+
+// Repositories is set of repos of configured storage backend
+type Repositories struct {
+	Transactor repo.ITransactor
+	Wager      repo.IWagerRepo
+	Purchase   repo.IPurchaseRepo
+	Settlement repo.ISettlementRepo
+	User       repo.IUserRepo
+	Wallet     repo.IWalletRepo
+	// Idempotency stores Idempotency-Key of POST requests with their responses
+	Idempotency repo.IIdempotencyRepo
+	Health      repo.IHealthRepo
+	// Order stores limit orders of wager order books
+	Order repo.IOrderRepo
+	// AuctionBid stores sealed bids of auctioned wagers
+	AuctionBid repo.IAuctionBidRepo
+
+	// Migrator migrates schema of sql databases, it is nil for in-memory storage
+	Migrator *migrate.Migrator
+}
+
+// Open initializes repositories of storage backend selected by conf.DBDriver.
+// Connection pool stats of sql databases are registered to metrics.
+func Open(conf *config.DataBaseConfig, log *logger.Logger, metrics *metrics.Metrics) (*Repositories, error) {
+	switch conf.DBDriver {
+	case config.DriverMemory:
+		log.Warn(context.Background(), "using in-memory storage, data will be lost on exit")
+		store := memory.NewStore()
+		return &Repositories{
+			Transactor:  memory.NewTransactor(store),
+			Wager:       memory.NewWagerRepo(store),
+			Purchase:    memory.NewPurchaseRepo(store),
+			Settlement:  memory.NewSettlementRepo(store),
+			User:        memory.NewUserRepo(store),
+			Wallet:      memory.NewWalletRepo(store),
+			Idempotency: memory.NewIdempotencyRepo(store),
+			Health:      memory.NewHealthRepo(store),
+			Order:       memory.NewOrderRepo(store),
+			AuctionBid:  memory.NewAuctionBidRepo(store),
+		}, nil
+
+	case config.DriverPostgres, config.DriverSQLite:
+		conn, err := db.OpenConnection(conf, log)
+		if err != nil {
+			return nil, fmt.Errorf("DB connection error: %w", err)
+		}
+
+		dialect := repo.DialectPostgres
+		if conf.DBDriver == config.DriverSQLite {
+			dialect = repo.DialectSQLite
+		}
+
+		migrator, err := migrate.New(conn, dialect, log)
+		if err != nil {
+			return nil, fmt.Errorf("load migrations error: %w", err)
+		}
+
+		metrics.RegisterDBStats(conn)
+		transactor := repo.NewTransactor(conn, log, metrics)
+		return &Repositories{
+			Transactor:  transactor,
+			Wager:       repo.NewWagerRepo(conn, dialect),
+			Purchase:    repo.NewPurchaseRepo(conn),
+			Settlement:  repo.NewSettlementRepo(conn),
+			User:        repo.NewUserRepo(conn),
+			Wallet:      repo.NewWalletRepo(conn, dialect, transactor),
+			Idempotency: repo.NewIdempotencyRepo(conn),
+			Health:      repo.NewHealthRepo(conn),
+			Order:       repo.NewOrderRepo(conn),
+			AuctionBid:  repo.NewAuctionBidRepo(conn),
+			Migrator:    migrator,
+		}, nil
+	}
+
+	return nil, fmt.Errorf("unsupported DB driver %q", conf.DBDriver)
+}
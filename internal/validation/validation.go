@@ -0,0 +1,295 @@
+// Package validation validates request DTOs by rules declared in `validate` struct tags and reports
+// all violations at once as details of app_errors.ErrorResponse.
+//
+// Rules of field are separated by comma and checked in order, only first violated rule of field is reported:
+//   - required: value is not zero
+//   - min=N, max=N: number or money is at least, at most N
+//   - gt=N: number or money is greater than N
+//   - minlen=N, maxlen=N: length of string in bytes is at least, at most N
+//   - oneof=a b c: string is one of space separated values
+//   - pattern=re: string matches regular expression, it must not contain comma
+//
+// Error code of field is declared in `error` tag. Ex. `json:"odds" validate:"min=1" error:"INVALID_ODDS"`
+package validation
+
+import (
+	"fmt"
+	"net/http"
+	"reflect"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/vitthalaa/wager-app/app_errors"
+	"github.com/vitthalaa/wager-app/money"
+)
+
+var moneyType = reflect.TypeOf(money.Money(0))
+
+// Rule is rule of validate tag, Limit is empty for rules without parameter
+type Rule struct {
+	Name  string
+	Limit string
+}
+
+// Errors collects violations of request validation
+type Errors struct {
+	code    app_errors.ErrorCode
+	details []app_errors.FieldError
+}
+
+// Add records violation of field rule, code of first violation becomes code of error response
+func (e *Errors) Add(code app_errors.ErrorCode, violation app_errors.FieldError) {
+	if len(e.details) == 0 {
+		e.code = code
+	}
+
+	e.details = append(e.details, violation)
+}
+
+// Has returns true when field has violation
+func (e *Errors) Has(field string) bool {
+	for _, detail := range e.details {
+		if detail.Field == field {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Err returns nil when there are no violations, otherwise bad request error response listing all of them
+func (e *Errors) Err() *app_errors.ErrorResponse {
+	if len(e.details) == 0 {
+		return nil
+	}
+
+	return &app_errors.ErrorResponse{Status: http.StatusBadRequest, Code: e.code, Details: e.details}
+}
+
+// Struct validates fields of struct v, or struct pointed by v, by their validate tags.
+// It panics on malformed tags, they are programming errors.
+func Struct(v interface{}) *Errors {
+	value := reflect.Indirect(reflect.ValueOf(v))
+	errs := &Errors{}
+	for _, field := range fieldsOf(value.Type()) {
+		fieldValue := value.Field(field.index)
+		for _, c := range field.checks {
+			if !c.valid(fieldValue) {
+				errs.Add(field.code, c.violation(field.name))
+				break
+			}
+		}
+	}
+
+	return errs
+}
+
+// Violation returns field error of rule, for rules checked outside of validate tags
+func Violation(field string, rule Rule) app_errors.FieldError {
+	violation := app_errors.FieldError{Field: field, Rule: rule.Name, Message: message(rule)}
+	if rule.Limit != "" {
+		violation.Limits = map[string]string{rule.Name: rule.Limit}
+	}
+
+	return violation
+}
+
+// ParseTag returns rules of validate tag
+func ParseTag(tag string) ([]Rule, error) {
+	if tag == "" {
+		return nil, nil
+	}
+
+	var rules []Rule
+	for _, r := range strings.Split(tag, ",") {
+		name, limit, _ := strings.Cut(r, "=")
+		switch name {
+		case "required":
+		case "min", "max", "gt", "minlen", "maxlen", "oneof", "pattern":
+			if limit == "" {
+				return nil, fmt.Errorf("rule %s needs value", name)
+			}
+		default:
+			return nil, fmt.Errorf("unknown rule %q", name)
+		}
+
+		rules = append(rules, Rule{Name: name, Limit: limit})
+	}
+
+	return rules, nil
+}
+
+func message(rule Rule) string {
+	switch rule.Name {
+	case "required":
+		return "is required"
+	case "min":
+		return "must be at least " + rule.Limit
+	case "max":
+		return "must be at most " + rule.Limit
+	case "gt":
+		return "must be greater than " + rule.Limit
+	case "minlen":
+		return "must be at least " + rule.Limit + " characters long"
+	case "maxlen":
+		return "must be at most " + rule.Limit + " characters long"
+	case "oneof":
+		return "must be one of " + strings.Join(strings.Fields(rule.Limit), ", ")
+	case "pattern":
+		return "must match " + rule.Limit
+	}
+
+	return "is invalid"
+}
+
+// field is validated struct field
+type field struct {
+	index  int
+	name   string
+	code   app_errors.ErrorCode
+	checks []check
+}
+
+// check is rule compiled for type of field
+type check struct {
+	Rule
+	valid func(v reflect.Value) bool
+}
+
+func (c check) violation(field string) app_errors.FieldError {
+	return Violation(field, c.Rule)
+}
+
+// fields caches validated fields by struct type
+var fields sync.Map
+
+func fieldsOf(t reflect.Type) []field {
+	if cached, ok := fields.Load(t); ok {
+		return cached.([]field)
+	}
+
+	var result []field
+	for i := 0; i < t.NumField(); i++ {
+		structField := t.Field(i)
+		rules, err := ParseTag(structField.Tag.Get("validate"))
+		if err != nil {
+			panic(fmt.Sprintf("validation: %s.%s: %s", t.Name(), structField.Name, err))
+		}
+
+		if len(rules) == 0 {
+			continue
+		}
+
+		name, _, _ := strings.Cut(structField.Tag.Get("json"), ",")
+		if name == "" || name == "-" {
+			name = structField.Name
+		}
+
+		f := field{index: i, name: name, code: app_errors.ErrorCode(structField.Tag.Get("error"))}
+		for _, rule := range rules {
+			valid, err := compile(rule, structField.Type)
+			if err != nil {
+				panic(fmt.Sprintf("validation: %s.%s: %s", t.Name(), structField.Name, err))
+			}
+
+			f.checks = append(f.checks, check{Rule: rule, valid: valid})
+		}
+
+		result = append(result, f)
+	}
+
+	fields.Store(t, result)
+	return result
+}
+
+// compile returns check of rule for values of type t
+func compile(rule Rule, t reflect.Type) (func(v reflect.Value) bool, error) {
+	switch rule.Name {
+	case "required":
+		return func(v reflect.Value) bool { return !v.IsZero() }, nil
+	case "min", "max", "gt":
+		limit, err := parseNumber(rule.Limit, t)
+		if err != nil {
+			return nil, err
+		}
+
+		return func(v reflect.Value) bool {
+			n := number(v)
+			switch rule.Name {
+			case "min":
+				return n >= limit
+			case "max":
+				return n <= limit
+			}
+
+			return n > limit
+		}, nil
+	}
+
+	if t.Kind() != reflect.String {
+		return nil, fmt.Errorf("rule %s needs string field, got %s", rule.Name, t)
+	}
+
+	switch rule.Name {
+	case "minlen", "maxlen":
+		limit, err := strconv.Atoi(rule.Limit)
+		if err != nil {
+			return nil, err
+		}
+
+		if rule.Name == "minlen" {
+			return func(v reflect.Value) bool { return len(v.String()) >= limit }, nil
+		}
+
+		return func(v reflect.Value) bool { return len(v.String()) <= limit }, nil
+	case "oneof":
+		values := strings.Fields(rule.Limit)
+		return func(v reflect.Value) bool {
+			for _, value := range values {
+				if v.String() == value {
+					return true
+				}
+			}
+
+			return false
+		}, nil
+	}
+
+	re, err := regexp.Compile(rule.Limit)
+	if err != nil {
+		return nil, err
+	}
+
+	return func(v reflect.Value) bool { return re.MatchString(v.String()) }, nil
+}
+
+// parseNumber parses limit of numeric rule as number comparable with number of values of type t
+func parseNumber(limit string, t reflect.Type) (float64, error) {
+	if t == moneyType {
+		m, err := money.Parse(limit)
+		return float64(m), err
+	}
+
+	switch t.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64,
+		reflect.Float32, reflect.Float64:
+		return strconv.ParseFloat(limit, 64)
+	}
+
+	return 0, fmt.Errorf("numeric rule on non numeric field of %s", t)
+}
+
+// number returns value of numeric field, money as minor units
+func number(v reflect.Value) float64 {
+	switch v.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return float64(v.Int())
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return float64(v.Uint())
+	}
+
+	return v.Float()
+}
@@ -0,0 +1,194 @@
+package validation
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/vitthalaa/wager-app/app_errors"
+	"github.com/vitthalaa/wager-app/dto"
+	"github.com/vitthalaa/wager-app/money"
+)
+
+type testRequest struct {
+	Name    string      `json:"name" validate:"required,minlen=2,maxlen=4" error:"INVALID_NAME"`
+	Kind    string      `json:"kind,omitempty" validate:"oneof=a b" error:"INVALID_KIND"`
+	Code    string      `json:"code" validate:"pattern=^[A-Z]+$" error:"INVALID_CODE"`
+	Count   uint32      `json:"count" validate:"min=1,max=10" error:"INVALID_COUNT"`
+	Ratio   float32     `json:"ratio" validate:"gt=0.5" error:"INVALID_RATIO"`
+	Price   money.Money `json:"price" validate:"min=1.50" error:"INVALID_PRICE"`
+	Ignored int         `json:"-"`
+	NoTag   int         `validate:"max=0" error:"INVALID_NO_TAG"`
+}
+
+func validRequest() testRequest {
+	return testRequest{Name: "abc", Kind: "a", Code: "AB", Count: 1, Ratio: 0.6, Price: money.MustParse("1.50")}
+}
+
+func TestStruct(t *testing.T) {
+	for _, tc := range []struct {
+		name   string
+		modify func(req *testRequest)
+
+		expectedError *app_errors.ErrorResponse
+	}{
+		{
+			name:   "valid",
+			modify: func(req *testRequest) {},
+		},
+		{
+			name:   "required",
+			modify: func(req *testRequest) { req.Name = "" },
+			expectedError: &app_errors.ErrorResponse{
+				Status: http.StatusBadRequest, Code: "INVALID_NAME",
+				Details: []app_errors.FieldError{{Field: "name", Rule: "required", Message: "is required"}},
+			},
+		},
+		{
+			name:   "maxlen",
+			modify: func(req *testRequest) { req.Name = "abcde" },
+			expectedError: &app_errors.ErrorResponse{
+				Status: http.StatusBadRequest, Code: "INVALID_NAME",
+				Details: []app_errors.FieldError{{
+					Field: "name", Rule: "maxlen", Message: "must be at most 4 characters long",
+					Limits: map[string]string{"maxlen": "4"},
+				}},
+			},
+		},
+		{
+			name:   "oneof",
+			modify: func(req *testRequest) { req.Kind = "c" },
+			expectedError: &app_errors.ErrorResponse{
+				Status: http.StatusBadRequest, Code: "INVALID_KIND",
+				Details: []app_errors.FieldError{{
+					Field: "kind", Rule: "oneof", Message: "must be one of a, b", Limits: map[string]string{"oneof": "a b"},
+				}},
+			},
+		},
+		{
+			name:   "pattern",
+			modify: func(req *testRequest) { req.Code = "ab" },
+			expectedError: &app_errors.ErrorResponse{
+				Status: http.StatusBadRequest, Code: "INVALID_CODE",
+				Details: []app_errors.FieldError{{
+					Field: "code", Rule: "pattern", Message: "must match ^[A-Z]+$", Limits: map[string]string{"pattern": "^[A-Z]+$"},
+				}},
+			},
+		},
+		{
+			name:   "gt equal to limit",
+			modify: func(req *testRequest) { req.Ratio = 0.5 },
+			expectedError: &app_errors.ErrorResponse{
+				Status: http.StatusBadRequest, Code: "INVALID_RATIO",
+				Details: []app_errors.FieldError{{
+					Field: "ratio", Rule: "gt", Message: "must be greater than 0.5", Limits: map[string]string{"gt": "0.5"},
+				}},
+			},
+		},
+		{
+			name:   "money below min",
+			modify: func(req *testRequest) { req.Price = money.MustParse("1.49") },
+			expectedError: &app_errors.ErrorResponse{
+				Status: http.StatusBadRequest, Code: "INVALID_PRICE",
+				Details: []app_errors.FieldError{{
+					Field: "price", Rule: "min", Message: "must be at least 1.50", Limits: map[string]string{"min": "1.50"},
+				}},
+			},
+		},
+		{
+			name:   "field without json name",
+			modify: func(req *testRequest) { req.NoTag = 1 },
+			expectedError: &app_errors.ErrorResponse{
+				Status: http.StatusBadRequest, Code: "INVALID_NO_TAG",
+				Details: []app_errors.FieldError{{
+					Field: "NoTag", Rule: "max", Message: "must be at most 0", Limits: map[string]string{"max": "0"},
+				}},
+			},
+		},
+		{
+			name: "all violations with first rule of each field",
+			modify: func(req *testRequest) {
+				req.Name = ""
+				req.Count = 11
+				req.Price = 0
+			},
+			expectedError: &app_errors.ErrorResponse{
+				Status: http.StatusBadRequest, Code: "INVALID_NAME",
+				Details: []app_errors.FieldError{
+					{Field: "name", Rule: "required", Message: "is required"},
+					{Field: "count", Rule: "max", Message: "must be at most 10", Limits: map[string]string{"max": "10"}},
+					{Field: "price", Rule: "min", Message: "must be at least 1.50", Limits: map[string]string{"min": "1.50"}},
+				},
+			},
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			req := validRequest()
+			tc.modify(&req)
+
+			assert.Equal(t, tc.expectedError, Struct(&req).Err())
+		})
+	}
+}
+
+func TestErrors_Add(t *testing.T) {
+	errs := &Errors{}
+	require.Nil(t, errs.Err())
+
+	errs.Add(app_errors.ErrInvalidBuyingPrice, Violation("buying_price", Rule{Name: "max", Limit: "26.00"}))
+	errs.Add(app_errors.ErrInvalidOdds, Violation("odds", Rule{Name: "required"}))
+
+	assert.True(t, errs.Has("odds"))
+	assert.False(t, errs.Has("selling_price"))
+	assert.Equal(t, &app_errors.ErrorResponse{
+		Status: http.StatusBadRequest,
+		Code:   app_errors.ErrInvalidBuyingPrice,
+		Details: []app_errors.FieldError{
+			{Field: "buying_price", Rule: "max", Message: "must be at most 26.00", Limits: map[string]string{"max": "26.00"}},
+			{Field: "odds", Rule: "required", Message: "is required"},
+		},
+	}, errs.Err())
+}
+
+func TestParseTag(t *testing.T) {
+	rules, err := ParseTag("required,min=1,oneof=a b")
+	require.Nil(t, err)
+	assert.Equal(t, []Rule{{Name: "required"}, {Name: "min", Limit: "1"}, {Name: "oneof", Limit: "a b"}}, rules)
+
+	_, err = ParseTag("min")
+	assert.NotNil(t, err)
+
+	_, err = ParseTag("between=1")
+	assert.NotNil(t, err)
+}
+
+func TestStruct_MalformedTagPanics(t *testing.T) {
+	assert.Panics(t, func() {
+		Struct(struct {
+			Name string `validate:"min=1"`
+		}{})
+	})
+
+	assert.Panics(t, func() {
+		Struct(struct {
+			Price money.Money `validate:"min=abc"`
+		}{})
+	})
+}
+
+// TestStruct_DTOs compiles rules of every validated request DTO, malformed tags panic
+func TestStruct_DTOs(t *testing.T) {
+	for _, req := range []interface{}{
+		&dto.PlaceWagerRequest{},
+		&dto.BuyWagerRequest{},
+		&dto.SettleWagerRequest{},
+		&dto.RegisterRequest{},
+		&dto.LoginRequest{},
+		&dto.DepositRequest{},
+		&dto.WithdrawRequest{},
+	} {
+		assert.NotPanics(t, func() { Struct(req) })
+	}
+}
@@ -0,0 +1,346 @@
+// Package websocket implements WebSocket protocol (RFC 6455) over hijacked HTTP connections,
+// and client side of it used by tests. Extensions and subprotocols are not supported.
+package websocket
+
+import (
+	"bufio"
+	"crypto/rand"
+	"crypto/sha1"
+	"encoding/base64"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"sync"
+	"time"
+	"unicode/utf8"
+)
+
+// Message types
+const (
+	TextMessage   = 1
+	BinaryMessage = 2
+
+	opContinuation = 0
+	opClose        = 8
+	opPing         = 9
+	opPong         = 10
+)
+
+// Close codes
+const (
+	CloseNormal          = 1000
+	CloseGoingAway       = 1001
+	CloseProtocolError   = 1002
+	CloseNoStatus        = 1005
+	CloseInvalidData     = 1007
+	ClosePolicyViolation = 1008
+	CloseMessageTooBig   = 1009
+	CloseInternalError   = 1011
+)
+
+// maxControlPayload is max payload length of ping, pong and close frames
+const maxControlPayload = 125
+
+var (
+	// ErrProtocol is returned by ReadMessage for frames violating protocol, connection is being closed then
+	ErrProtocol = errors.New("websocket: protocol error")
+	// ErrMessageTooBig is returned by ReadMessage for messages longer than read limit
+	ErrMessageTooBig = errors.New("websocket: message too big")
+	// ErrCloseSent is returned by writes after close frame was sent
+	ErrCloseSent = errors.New("websocket: close sent")
+)
+
+// CloseError is returned by ReadMessage once peer closed connection
+type CloseError struct {
+	Code   int
+	Reason string
+}
+
+// Error returns close code and reason
+func (e *CloseError) Error() string {
+	return fmt.Sprintf("websocket: closed by peer with %d %s", e.Code, e.Reason)
+}
+
+// Conn is WebSocket connection. ReadMessage must be called from single goroutine,
+// writes are safe for concurrent use.
+type Conn struct {
+	conn net.Conn
+	br   *bufio.Reader
+	// client masks frames it writes and expects unmasked frames
+	client      bool
+	readLimit   int64
+	pongHandler func()
+
+	writeMu   sync.Mutex
+	closeSent bool
+}
+
+func newConn(conn net.Conn, br *bufio.Reader, client bool) *Conn {
+	return &Conn{conn: conn, br: br, client: client}
+}
+
+// SetReadLimit sets max length of messages in bytes, longer messages close connection with CloseMessageTooBig
+func (c *Conn) SetReadLimit(limit int64) {
+	c.readLimit = limit
+}
+
+// SetPongHandler sets handler called by ReadMessage for every pong received, ex. to extend read deadline
+func (c *Conn) SetPongHandler(h func()) {
+	c.pongHandler = h
+}
+
+// SetReadDeadline sets deadline of reads of underlying connection
+func (c *Conn) SetReadDeadline(t time.Time) error {
+	return c.conn.SetReadDeadline(t)
+}
+
+// SetWriteDeadline sets deadline of writes of underlying connection
+func (c *Conn) SetWriteDeadline(t time.Time) error {
+	return c.conn.SetWriteDeadline(t)
+}
+
+// ReadMessage returns type and payload of next text or binary message, joining its fragments.
+// Pings are answered by pongs and close frame is echoed before CloseError is returned.
+func (c *Conn) ReadMessage() (int, []byte, error) {
+	messageType := 0
+	var message []byte
+	for {
+		fin, op, payload, err := c.readFrame()
+		if err != nil {
+			return 0, nil, err
+		}
+
+		switch op {
+		case opPing:
+			if err := c.writeControl(opPong, payload); err != nil && err != ErrCloseSent {
+				return 0, nil, err
+			}
+
+			continue
+		case opPong:
+			if c.pongHandler != nil {
+				c.pongHandler()
+			}
+
+			continue
+		case opClose:
+			closeErr := &CloseError{Code: CloseNoStatus}
+			if len(payload) >= 2 {
+				closeErr.Code = int(binary.BigEndian.Uint16(payload))
+				closeErr.Reason = string(payload[2:])
+			}
+
+			// close frame without status is echoed without status too
+			_ = c.WriteClose(closeErr.Code, "")
+			return 0, nil, closeErr
+		case opContinuation:
+			if messageType == 0 {
+				return 0, nil, c.fail(CloseProtocolError, ErrProtocol)
+			}
+		case TextMessage, BinaryMessage:
+			if messageType != 0 {
+				return 0, nil, c.fail(CloseProtocolError, ErrProtocol)
+			}
+
+			messageType = int(op)
+		default:
+			return 0, nil, c.fail(CloseProtocolError, ErrProtocol)
+		}
+
+		message = append(message, payload...)
+		if c.readLimit > 0 && int64(len(message)) > c.readLimit {
+			return 0, nil, c.fail(CloseMessageTooBig, ErrMessageTooBig)
+		}
+
+		if !fin {
+			continue
+		}
+
+		if messageType == TextMessage && !utf8.Valid(message) {
+			return 0, nil, c.fail(CloseInvalidData, ErrProtocol)
+		}
+
+		return messageType, message, nil
+	}
+}
+
+// readFrame reads single frame, payload of masked frame is unmasked
+func (c *Conn) readFrame() (fin bool, op byte, payload []byte, err error) {
+	var header [2]byte
+	if _, err = io.ReadFull(c.br, header[:]); err != nil {
+		return false, 0, nil, err
+	}
+
+	fin = header[0]&0x80 != 0
+	op = header[0] & 0x0f
+	masked := header[1]&0x80 != 0
+	// reserved bits are used only by extensions and frames of client must be masked, frames of server must not
+	if header[0]&0x70 != 0 || masked == c.client {
+		return false, 0, nil, c.fail(CloseProtocolError, ErrProtocol)
+	}
+
+	length := uint64(header[1] & 0x7f)
+	switch length {
+	case 126:
+		var b [2]byte
+		if _, err = io.ReadFull(c.br, b[:]); err != nil {
+			return false, 0, nil, err
+		}
+
+		length = uint64(binary.BigEndian.Uint16(b[:]))
+	case 127:
+		var b [8]byte
+		if _, err = io.ReadFull(c.br, b[:]); err != nil {
+			return false, 0, nil, err
+		}
+
+		length = binary.BigEndian.Uint64(b[:])
+	}
+
+	if op >= opClose && (!fin || length > maxControlPayload) {
+		return false, 0, nil, c.fail(CloseProtocolError, ErrProtocol)
+	}
+
+	// checked before reading payload, so that peer can not make us allocate more than limit
+	if c.readLimit > 0 && length > uint64(c.readLimit) {
+		return false, 0, nil, c.fail(CloseMessageTooBig, ErrMessageTooBig)
+	}
+
+	var key [4]byte
+	if masked {
+		if _, err = io.ReadFull(c.br, key[:]); err != nil {
+			return false, 0, nil, err
+		}
+	}
+
+	payload = make([]byte, length)
+	if _, err = io.ReadFull(c.br, payload); err != nil {
+		return false, 0, nil, err
+	}
+
+	if masked {
+		mask(key, payload)
+	}
+
+	return fin, op, payload, nil
+}
+
+// fail sends close frame with code and returns err
+func (c *Conn) fail(code int, err error) error {
+	_ = c.WriteClose(code, "")
+	return err
+}
+
+// WriteMessage writes message of messageType as single frame
+func (c *Conn) WriteMessage(messageType int, data []byte) error {
+	c.writeMu.Lock()
+	defer c.writeMu.Unlock()
+
+	if c.closeSent {
+		return ErrCloseSent
+	}
+
+	return c.writeFrame(byte(messageType), data)
+}
+
+// WritePing writes ping frame, peer answers it with pong
+func (c *Conn) WritePing() error {
+	return c.writeControl(opPing, nil)
+}
+
+// WriteClose starts closing handshake by sending close frame with code and reason, it is sent only once.
+// Peer answers it with close frame, then connection has to be closed by Close.
+func (c *Conn) WriteClose(code int, reason string) error {
+	var payload []byte
+	if code != CloseNoStatus {
+		payload = make([]byte, 2, 2+len(reason))
+		binary.BigEndian.PutUint16(payload, uint16(code))
+		payload = append(payload, reason...)
+		if len(payload) > maxControlPayload {
+			payload = payload[:maxControlPayload]
+		}
+	}
+
+	c.writeMu.Lock()
+	defer c.writeMu.Unlock()
+
+	if c.closeSent {
+		return nil
+	}
+
+	c.closeSent = true
+	return c.writeFrame(opClose, payload)
+}
+
+// writeControl writes ping or pong frame
+func (c *Conn) writeControl(op byte, payload []byte) error {
+	c.writeMu.Lock()
+	defer c.writeMu.Unlock()
+
+	if c.closeSent {
+		return ErrCloseSent
+	}
+
+	return c.writeFrame(op, payload)
+}
+
+// writeFrame writes final frame with payload in single write, must be called holding write lock
+func (c *Conn) writeFrame(op byte, payload []byte) error {
+	frame := make([]byte, 0, 14+len(payload))
+	frame = append(frame, 0x80|op)
+
+	maskBit := byte(0)
+	if c.client {
+		maskBit = 0x80
+	}
+
+	switch length := len(payload); {
+	case length <= 125:
+		frame = append(frame, maskBit|byte(length))
+	case length <= 0xffff:
+		frame = append(frame, maskBit|126, 0, 0)
+		binary.BigEndian.PutUint16(frame[len(frame)-2:], uint16(length))
+	default:
+		frame = append(frame, maskBit|127, 0, 0, 0, 0, 0, 0, 0, 0)
+		binary.BigEndian.PutUint64(frame[len(frame)-8:], uint64(length))
+	}
+
+	if !c.client {
+		_, err := c.conn.Write(append(frame, payload...))
+		return err
+	}
+
+	var key [4]byte
+	if _, err := rand.Read(key[:]); err != nil {
+		return err
+	}
+
+	frame = append(frame, key[:]...)
+	start := len(frame)
+	frame = append(frame, payload...)
+	mask(key, frame[start:])
+
+	_, err := c.conn.Write(frame)
+	return err
+}
+
+// Close closes underlying connection without closing handshake
+func (c *Conn) Close() error {
+	return c.conn.Close()
+}
+
+// mask masks or unmasks payload by key
+func mask(key [4]byte, payload []byte) {
+	for i := range payload {
+		payload[i] ^= key[i%4]
+	}
+}
+
+// acceptKey returns Sec-WebSocket-Accept of Sec-WebSocket-Key
+func acceptKey(key string) string {
+	h := sha1.New()
+	h.Write([]byte(key + "258EAFA5-E914-47DA-95CA-C5AB0DC85B11"))
+	return base64.StdEncoding.EncodeToString(h.Sum(nil))
+}
@@ -0,0 +1,248 @@
+package websocket
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// serverResult is result of ReadMessage on server side
+type serverResult struct {
+	messageType int
+	data        []byte
+	err         error
+}
+
+// newServer returns server upgrading requests and reporting what it reads, messages are echoed back
+func newServer(t *testing.T, readLimit int64) (string, chan serverResult) {
+	results := make(chan serverResult, 10)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		conn, err := Upgrade(w, req)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		defer conn.Close()
+		conn.SetReadLimit(readLimit)
+		for {
+			messageType, data, err := conn.ReadMessage()
+			results <- serverResult{messageType: messageType, data: data, err: err}
+			if err != nil {
+				return
+			}
+
+			if err = conn.WriteMessage(messageType, data); err != nil {
+				return
+			}
+		}
+	}))
+	t.Cleanup(server.Close)
+
+	return "ws" + strings.TrimPrefix(server.URL, "http"), results
+}
+
+func dial(t *testing.T, url string) *Conn {
+	conn, res, err := Dial(context.Background(), url, nil)
+	require.Nil(t, err)
+	require.Equal(t, http.StatusSwitchingProtocols, res.StatusCode)
+	t.Cleanup(func() { conn.Close() })
+
+	return conn
+}
+
+func TestConn_Echo(t *testing.T) {
+	url, results := newServer(t, 0)
+	conn := dial(t, url)
+
+	for _, tc := range []struct {
+		name        string
+		messageType int
+		data        []byte
+	}{
+		{name: "short text", messageType: TextMessage, data: []byte(`{"type":"subscribe"}`)},
+		{name: "16 bit length", messageType: BinaryMessage, data: make([]byte, 300)},
+		{name: "64 bit length", messageType: TextMessage, data: []byte(strings.Repeat("a", 70000))},
+		{name: "empty", messageType: TextMessage},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			require.Nil(t, conn.WriteMessage(tc.messageType, tc.data))
+
+			received := <-results
+			require.Nil(t, received.err)
+			assert.Equal(t, tc.messageType, received.messageType)
+			assert.Equal(t, tc.data, received.data)
+
+			messageType, data, err := conn.ReadMessage()
+			require.Nil(t, err)
+			assert.Equal(t, tc.messageType, messageType)
+			assert.Equal(t, tc.data, data)
+		})
+	}
+}
+
+func TestConn_Fragments(t *testing.T) {
+	url, results := newServer(t, 0)
+	conn := dial(t, url)
+
+	writeRawFrame(t, conn, false, TextMessage, []byte("hel"))
+	// control frames may be interleaved with fragments
+	writeRawFrame(t, conn, true, opPing, []byte("p"))
+	writeRawFrame(t, conn, true, opContinuation, []byte("lo"))
+
+	received := <-results
+	require.Nil(t, received.err)
+	assert.Equal(t, "hello", string(received.data))
+
+	pongs := 0
+	conn.SetPongHandler(func() { pongs++ })
+	_, data, err := conn.ReadMessage()
+	require.Nil(t, err)
+	assert.Equal(t, "hello", string(data))
+	assert.Equal(t, 1, pongs)
+}
+
+func TestConn_Close(t *testing.T) {
+	url, results := newServer(t, 0)
+	conn := dial(t, url)
+
+	require.Nil(t, conn.WriteClose(CloseGoingAway, "bye"))
+	assert.Equal(t, ErrCloseSent, conn.WriteMessage(TextMessage, []byte("late")))
+
+	received := <-results
+	assert.Equal(t, &CloseError{Code: CloseGoingAway, Reason: "bye"}, received.err)
+
+	_, _, err := conn.ReadMessage()
+	assert.Equal(t, &CloseError{Code: CloseGoingAway}, err)
+}
+
+func TestConn_Errors(t *testing.T) {
+	for _, tc := range []struct {
+		name  string
+		write func(t *testing.T, conn *Conn)
+
+		expectedErr   error
+		expectedClose int
+	}{
+		{
+			name: "message over read limit",
+			write: func(t *testing.T, conn *Conn) {
+				require.Nil(t, conn.WriteMessage(TextMessage, []byte("12345678901")))
+			},
+			expectedErr:   ErrMessageTooBig,
+			expectedClose: CloseMessageTooBig,
+		},
+		{
+			name: "fragments over read limit",
+			write: func(t *testing.T, conn *Conn) {
+				writeRawFrame(t, conn, false, TextMessage, []byte("123456"))
+				writeRawFrame(t, conn, true, opContinuation, []byte("789012"))
+			},
+			expectedErr:   ErrMessageTooBig,
+			expectedClose: CloseMessageTooBig,
+		},
+		{
+			name: "continuation without message",
+			write: func(t *testing.T, conn *Conn) {
+				writeRawFrame(t, conn, true, opContinuation, []byte("1"))
+			},
+			expectedErr:   ErrProtocol,
+			expectedClose: CloseProtocolError,
+		},
+		{
+			name: "unmasked frame of client",
+			write: func(t *testing.T, conn *Conn) {
+				_, err := conn.conn.Write([]byte{0x81, 1, 'a'})
+				require.Nil(t, err)
+			},
+			expectedErr:   ErrProtocol,
+			expectedClose: CloseProtocolError,
+		},
+		{
+			name: "invalid utf-8 text",
+			write: func(t *testing.T, conn *Conn) {
+				require.Nil(t, conn.WriteMessage(TextMessage, []byte{0xff}))
+			},
+			expectedErr:   ErrProtocol,
+			expectedClose: CloseInvalidData,
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			url, results := newServer(t, 10)
+			conn := dial(t, url)
+
+			tc.write(t, conn)
+
+			received := <-results
+			assert.Equal(t, tc.expectedErr, received.err)
+
+			_, _, err := conn.ReadMessage()
+			closeErr, ok := err.(*CloseError)
+			require.True(t, ok, "expected close, got %v", err)
+			assert.Equal(t, tc.expectedClose, closeErr.Code)
+		})
+	}
+}
+
+func TestUpgrade_BadHandshake(t *testing.T) {
+	url, _ := newServer(t, 0)
+
+	res, err := http.Get("http" + strings.TrimPrefix(url, "ws"))
+	require.Nil(t, err)
+	defer res.Body.Close()
+	assert.Equal(t, http.StatusBadRequest, res.StatusCode)
+
+	res, err = http.DefaultClient.Do(&http.Request{
+		Method: http.MethodGet,
+		URL:    res.Request.URL,
+		Header: http.Header{
+			"Connection":            {"Upgrade"},
+			"Upgrade":               {"websocket"},
+			"Sec-WebSocket-Version": {"13"},
+			"Sec-WebSocket-Key":     {"not 16 bytes"},
+		},
+	})
+	require.Nil(t, err)
+	defer res.Body.Close()
+	assert.Equal(t, http.StatusBadRequest, res.StatusCode)
+}
+
+func TestDial_Refused(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		http.Error(w, "not here", http.StatusNotFound)
+	}))
+	defer server.Close()
+
+	_, res, err := Dial(context.Background(), "ws"+strings.TrimPrefix(server.URL, "http"), nil)
+	assert.Equal(t, ErrBadHandshake, err)
+	require.NotNil(t, res)
+	assert.Equal(t, http.StatusNotFound, res.StatusCode)
+
+	body, err := io.ReadAll(res.Body)
+	require.Nil(t, err)
+	assert.Equal(t, "not here\n", string(body))
+}
+
+// writeRawFrame writes masked frame of client, fin is false for all but last fragment of message
+func writeRawFrame(t *testing.T, conn *Conn, fin bool, op byte, payload []byte) {
+	var b0 byte = op
+	if fin {
+		b0 |= 0x80
+	}
+
+	key := [4]byte{1, 2, 3, 4}
+	masked := append([]byte(nil), payload...)
+	mask(key, masked)
+
+	frame := []byte{b0, 0x80 | byte(len(payload))}
+	frame = append(frame, key[:]...)
+	frame = append(frame, masked...)
+	_, err := conn.conn.Write(frame)
+	require.Nil(t, err)
+}
@@ -12,79 +12,330 @@ import (
 
 	env "github.com/joho/godotenv"
 
+	"github.com/vitthalaa/wager-app/internal/auth"
 	"github.com/vitthalaa/wager-app/internal/config"
-	"github.com/vitthalaa/wager-app/internal/db"
+	"github.com/vitthalaa/wager-app/internal/events"
 	"github.com/vitthalaa/wager-app/internal/handlers"
-	"github.com/vitthalaa/wager-app/internal/repo"
+	"github.com/vitthalaa/wager-app/internal/logger"
+	"github.com/vitthalaa/wager-app/internal/metrics"
+	"github.com/vitthalaa/wager-app/internal/migrate"
+	"github.com/vitthalaa/wager-app/internal/openapi"
 	"github.com/vitthalaa/wager-app/internal/services"
+	"github.com/vitthalaa/wager-app/internal/storage"
 )
 
 const envFile = ".env"
 
-// Changing to overload to override from .env file(Should be load from env in prod)
-var loadEnv = env.Overload
+// Variables set in environment take precedence over .env file, secrets are set there in prod
+var loadEnv = env.Load
 
-func run() (s *http.Server) {
+// routes are label values of HTTP metrics and paths of OpenAPI spec, {id} matches any path segment
+var routes = []string{
+	"/auth/register",
+	"/auth/login",
+	"/wallet",
+	"/wallet/deposit",
+	"/wallet/withdraw",
+	"/wallet/entries",
+	"/wagers",
+	"/wagers/stream",
+	"/wagers/{id}",
+	"/wagers/{id}/bids",
+	"/wagers/{id}/settle",
+	"/wagers/{id}/settlement",
+	"/wagers/{id}/stream",
+	"/buy/{id}",
+	"/purchases",
+	"/purchases/{id}",
+	"/orders",
+	"/orders/{id}",
+	"/books/{id}",
+	"/ws",
+	"/metrics",
+	"/healthz",
+	"/readyz",
+	"/openapi.json",
+	"/docs",
+}
+
+// appHandlers are handlers of all routes
+type appHandlers struct {
+	auth     *handlers.AuthHandler
+	wallet   *handlers.WalletHandler
+	wagers   *handlers.WagersHandler
+	purchase *handlers.PurchaseHandler
+	orders   *handlers.OrderHandler
+	socket   *handlers.SocketHandler
+	health   *handlers.HealthHandler
+	docs     *handlers.DocsHandler
+	metrics  http.Handler
+}
+
+// muxPatterns returns handlers by mux pattern, patterns ending with / serve subtree of {id} paths
+func muxPatterns(h appHandlers) map[string]http.Handler {
+	return map[string]http.Handler{
+		"/auth/":        http.HandlerFunc(h.auth.Handle),
+		"/wallet":       http.HandlerFunc(h.wallet.Handle),
+		"/wallet/":      http.HandlerFunc(h.wallet.Handle),
+		"/wagers":       http.HandlerFunc(h.wagers.Handle),
+		"/wagers/":      http.HandlerFunc(h.wagers.Handle),
+		"/buy/":         http.HandlerFunc(h.purchase.Handle),
+		"/purchases":    http.HandlerFunc(h.purchase.Handle),
+		"/purchases/":   http.HandlerFunc(h.purchase.Handle),
+		"/orders":       http.HandlerFunc(h.orders.Handle),
+		"/orders/":      http.HandlerFunc(h.orders.Handle),
+		"/books/":       http.HandlerFunc(h.orders.Handle),
+		"/ws":           http.HandlerFunc(h.socket.Handle),
+		"/metrics":      h.metrics,
+		"/healthz":      http.HandlerFunc(h.health.Handle),
+		"/readyz":       http.HandlerFunc(h.health.Handle),
+		"/openapi.json": http.HandlerFunc(h.docs.Handle),
+		"/docs":         http.HandlerFunc(h.docs.Handle),
+	}
+}
+
+func newMux(h appHandlers) *http.ServeMux {
+	mux := http.NewServeMux()
+	for pattern, handler := range muxPatterns(h) {
+		mux.Handle(pattern, handler)
+	}
+
+	return mux
+}
+
+// app is running application with dependencies needed for graceful shutdown
+type app struct {
+	server     *http.Server
+	socket     *handlers.SocketHandler
+	log        *logger.Logger
+	health     *services.HealthService
+	drainDelay time.Duration
+}
+
+func loadConfig() config.AppConfig {
 	err := loadEnv(envFile)
 	if err != nil {
 		log.Fatal(err)
 	}
 
+	return config.GetAppConfig()
+}
+
+// newLogger returns application logger writing to stdout as configured
+func newLogger(conf config.LogConfig) *logger.Logger {
+	level, err := logger.ParseLevel(conf.Level)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	if conf.Format != logger.FormatJSON && conf.Format != logger.FormatText {
+		log.Fatalf("unknown log format %q", conf.Format)
+	}
+
+	return logger.New(os.Stdout, level, conf.Format)
+}
+
+func run() *app {
 	// Load config
-	conf := config.GetAppConfig()
+	conf := loadConfig()
+	appLog := newLogger(conf.LogConfig)
 	if conf.Port == 0 {
 		log.Fatal("no port specified")
 	}
 
-	// Connect DB
-	conn, err := db.OpenConnection(&conf.DataBaseConfig)
-	if err != nil {
-		log.Fatalf("DB connection error: %s\n", err)
+	if err := conf.AuthConfig.Validate(); err != nil {
+		log.Fatal(err)
 	}
 
 	// Init Repos
-	wagerRepo := repo.NewWagerRepo(conn)
-	purchaseRepo := repo.NewPurchaseRepo(conn)
+	appMetrics := metrics.New()
+	repos, err := storage.Open(&conf.DataBaseConfig, appLog, appMetrics)
+	if err != nil {
+		log.Fatalf("init repos error: %s\n", err)
+	}
+
+	// Migrate DB
+	if conf.MigrateOnStart && repos.Migrator != nil {
+		if err = repos.Migrator.Up(context.Background()); err != nil {
+			log.Fatalf("DB migration error: %s\n", err)
+		}
+	}
+
+	sellOut, err := services.ParseSellOutPolicy(conf.SellOutRules)
+	if err != nil {
+		log.Fatal(err)
+	}
 
 	// Init Services
-	wagerService := services.NewWagerService(wagerRepo)
-	purchaseService := services.NewPurchaseService(purchaseRepo, wagerRepo)
+	broker := events.NewBroker(conf.StreamConfig.ReplayBuffer)
+	tokens := auth.NewTokens([]byte(conf.AuthConfig.TokenSecret), conf.AuthConfig.TokenTTL)
+	userService := services.NewUserService(
+		repos.Transactor, repos.User, repos.Wallet, tokens, conf.AuthConfig.OperatorUsernames, appLog)
+	walletService := services.NewWalletService(repos.Transactor, repos.Wallet, appLog)
+	wagerService := services.NewWagerService(repos.Wager, repos.Purchase, appLog, appMetrics, broker)
+	purchaseService := services.NewPurchaseService(
+		repos.Transactor, repos.Purchase, repos.Wager, repos.Wallet, repos.Order, appLog, appMetrics, broker, sellOut)
+	orderService := services.NewOrderService(
+		repos.Transactor, repos.Order, repos.Purchase, repos.Wager, repos.Wallet, appLog, appMetrics, broker, sellOut)
+	auctionService := services.NewAuctionService(repos.Transactor, repos.AuctionBid, repos.Purchase, repos.Wager,
+		repos.Wallet, appLog, appMetrics, broker, sellOut)
+	settlementService := services.NewSettlementService(
+		repos.Transactor, repos.Wager, repos.Purchase, repos.Settlement, repos.Wallet, repos.Order, appLog)
+	idempotencyService := services.NewIdempotencyService(repos.Idempotency, conf.IdempotencyKeyTTL, appLog)
 
-	// Init handlers
-	wagerHandler := handlers.NewWagersHandler(wagerService)
-	purchaseHandler := handlers.NewPurchasesHandler(purchaseService)
+	// migrator of in-memory storage is nil, interface must stay nil too
+	var migrator services.IMigrator
+	if repos.Migrator != nil {
+		migrator = repos.Migrator
+	}
 
-	mux := http.NewServeMux()
-	mux.HandleFunc("/wagers", wagerHandler.Handle)
-	mux.HandleFunc("/buy/", purchaseHandler.Handle)
+	healthService := services.NewHealthService(repos.Health, migrator, conf.ReadinessTimeout, appLog)
+
+	// Init handlers
+	stream := handlers.NewWagerStreamHandler(broker, conf.StreamConfig.HeartbeatInterval, appLog)
+	socket := handlers.NewSocketHandler(wagerService, purchaseService, broker,
+		conf.SocketConfig.SendBuffer, conf.SocketConfig.PingInterval, conf.SocketConfig.AllowedOrigins, appLog)
+	mux := newMux(appHandlers{
+		auth:     handlers.NewAuthHandler(userService, appLog),
+		wallet:   handlers.NewWalletHandler(walletService, appLog),
+		wagers:   handlers.NewWagersHandler(wagerService, settlementService, auctionService, idempotencyService, appLog, stream),
+		purchase: handlers.NewPurchasesHandler(purchaseService, idempotencyService, appLog),
+		orders:   handlers.NewOrderHandler(orderService, idempotencyService, appLog),
+		socket:   socket,
+		health:   handlers.NewHealthHandler(healthService, appLog),
+		docs:     handlers.NewDocsHandler(openapi.Spec(), appLog),
+		metrics:  appMetrics.Registry,
+	})
 
 	address := fmt.Sprintf(":%d", conf.Port)
-	s = &http.Server{
-		Addr:         address,
-		ReadTimeout:  10 * time.Second,
-		WriteTimeout: 10 * time.Second,
-		Handler:      mux,
+	// no write timeout, it would end event streams
+	s := &http.Server{
+		Addr:        address,
+		ReadTimeout: 10 * time.Second,
+		Handler: logger.Middleware(appLog, metrics.Middleware(appMetrics, routes,
+			auth.Middleware(tokens, appLog, mux))),
 	}
 
+	// streams never end on their own, so they are ended when shutdown starts to let it complete
+	s.RegisterOnShutdown(broker.Close)
+
+	go purgeIdempotencyKeys(idempotencyService, conf.IdempotencyKeyTTL, appLog)
+	go closeDueAuctions(auctionService, time.Tick(conf.AuctionCloseInterval), appLog)
+
 	go func() {
-		log.Printf("Starting HTTP listener on: %s", address)
+		appLog.Info(context.Background(), "starting HTTP listener", "address", address)
 		if err := s.ListenAndServe(); err != nil && err != http.ErrServerClosed {
 			log.Fatalf("error listening on port: %s\n", err)
 		}
 	}()
 
-	return
+	return &app{
+		server:     s,
+		socket:     socket,
+		log:        appLog,
+		health:     healthService,
+		drainDelay: conf.ShutdownDrainDelay,
+	}
+}
+
+// purgeIdempotencyKeys deletes expired idempotency keys periodically, expired keys are not replayed anyway
+func purgeIdempotencyKeys(idempotencyService services.IIdempotencyService, ttl time.Duration, appLog *logger.Logger) {
+	interval := time.Hour
+	if ttl < interval {
+		interval = ttl
+	}
+
+	ctx := context.Background()
+	for range time.Tick(interval) {
+		deleted, err := idempotencyService.DeleteExpired(ctx)
+		if err != nil {
+			appLog.Error(ctx, "purge idempotency keys failed", "error", err)
+			continue
+		}
+
+		if deleted > 0 {
+			appLog.Info(ctx, "purged expired idempotency keys", "deleted", deleted)
+		}
+	}
+}
+
+// closeDueAuctions closes auctions whose bidding ended on every tick, units of wager are awarded when it closes.
+// Auctions failing to close are retried on next tick.
+func closeDueAuctions(auctionService services.IAuctionService, ticks <-chan time.Time, appLog *logger.Logger) {
+	ctx := context.Background()
+	for range ticks {
+		closed, err := auctionService.CloseDueAuctions(ctx)
+		if err != nil {
+			// other due auctions are closed even if one fails
+			appLog.Error(ctx, "close due auctions failed", "closed", closed, "error", err)
+			continue
+		}
+
+		if closed > 0 {
+			appLog.Info(ctx, "closed due auctions", "closed", closed)
+		}
+	}
+}
+
+// runMigrate runs migrate subcommand, ex. `./wager-app migrate up`
+func runMigrate(args []string) {
+	conf := loadConfig()
+	repos, err := storage.Open(&conf.DataBaseConfig, newLogger(conf.LogConfig), metrics.New())
+	if err != nil {
+		log.Fatalf("init repos error: %s\n", err)
+	}
+
+	if repos.Migrator == nil {
+		log.Printf("nothing to migrate for %s storage", conf.DataBaseConfig.DBDriver)
+		return
+	}
+
+	if err = migrate.RunCommand(context.Background(), repos.Migrator, args, os.Stdout); err != nil {
+		log.Fatal(err)
+	}
+}
+
+// runCheckLedger runs check-ledger subcommand verifying wallet ledger invariants, ex. `./wager-app check-ledger`
+func runCheckLedger() {
+	conf := loadConfig()
+	appLog := newLogger(conf.LogConfig)
+	repos, err := storage.Open(&conf.DataBaseConfig, appLog, metrics.New())
+	if err != nil {
+		log.Fatalf("init repos error: %s\n", err)
+	}
+
+	walletService := services.NewWalletService(repos.Transactor, repos.Wallet, appLog)
+	if err = walletService.CheckLedger(context.Background()); err != nil {
+		log.Fatal(err)
+	}
+
+	log.Println("ledger is balanced")
 }
 
 func main() {
-	s := run()
-	quit := make(chan os.Signal)
+	if len(os.Args) > 1 && os.Args[1] == "migrate" {
+		runMigrate(os.Args[2:])
+		return
+	}
+
+	if len(os.Args) > 1 && os.Args[1] == "check-ledger" {
+		runCheckLedger()
+		return
+	}
+
+	a := run()
+	quit := make(chan os.Signal, 1)
 	// kill (no param) default send syscall.SIGTERM
 	// kill -2 is syscall.SIGINT
 	// kill -9 is syscall.SIGKILL but can't be catch, so don't need add it
 	signal.Notify(quit, syscall.SIGINT, syscall.SIGTERM)
 	<-quit
-	log.Println("shutting down server...")
+
+	// fail readiness first, so that load balancers stop sending requests before server stops accepting them
+	a.health.ShutDown()
+	a.log.Info(context.Background(), "draining before shutdown", "delay", a.drainDelay)
+	time.Sleep(a.drainDelay)
+	a.log.Info(context.Background(), "shutting down server")
 
 	// The context is used to inform the server it has 5 seconds to finish
 	// the request it is currently handling
@@ -92,8 +343,13 @@ func main() {
 	defer func() {
 		cancel()
 	}()
-	if err := s.Shutdown(ctx); err != nil {
+	if err := a.server.Shutdown(ctx); err != nil {
 		log.Fatal("server forced to shut down")
 	}
-	log.Println("server exiting")
+
+	// WebSocket connections are hijacked, server does not wait for them
+	if err := a.socket.Shutdown(ctx); err != nil {
+		log.Fatal("websocket connections forced to close")
+	}
+	a.log.Info(context.Background(), "server exiting")
 }
@@ -0,0 +1,105 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/vitthalaa/wager-app/internal/handlers"
+	"github.com/vitthalaa/wager-app/internal/logger"
+	"github.com/vitthalaa/wager-app/internal/metrics"
+	"github.com/vitthalaa/wager-app/internal/openapi"
+	"github.com/vitthalaa/wager-app/internal/services"
+)
+
+func TestRoutes_DescribedInSpec(t *testing.T) {
+	doc := openapi.Spec()
+	h := appHandlers{
+		auth:     &handlers.AuthHandler{},
+		wallet:   &handlers.WalletHandler{},
+		wagers:   &handlers.WagersHandler{},
+		purchase: &handlers.PurchaseHandler{},
+		socket:   &handlers.SocketHandler{},
+		health:   &handlers.HealthHandler{},
+		docs:     &handlers.DocsHandler{},
+		metrics:  metrics.New().Registry,
+	}
+	mux := newMux(h)
+
+	var paths []string
+	served := map[string]bool{}
+	for path, item := range doc.Paths {
+		paths = append(paths, path)
+		assert.NotEmpty(t, *item, path)
+		for method := range *item {
+			req := httptest.NewRequest(strings.ToUpper(method), strings.ReplaceAll(path, "{id}", "1"), nil)
+			_, pattern := mux.Handler(req)
+			if assert.NotEmpty(t, pattern, "%s %s is not registered on mux", method, path) {
+				served[pattern] = true
+			}
+		}
+	}
+
+	// metrics are labelled by routes, so they must be the paths of spec
+	assert.ElementsMatch(t, routes, paths)
+
+	for pattern := range muxPatterns(h) {
+		assert.True(t, served[pattern], "mux pattern %s is not described in spec", pattern)
+	}
+
+	_, pattern := mux.Handler(httptest.NewRequest(http.MethodGet, "/unknown", nil))
+	assert.Empty(t, pattern)
+}
+
+// dueAuctionsService returns result of next tick on every call
+type dueAuctionsService struct {
+	services.IAuctionService
+	closed []int
+	errs   []error
+}
+
+func (s *dueAuctionsService) CloseDueAuctions(context.Context) (int, error) {
+	closed, err := s.closed[0], s.errs[0]
+	s.closed, s.errs = s.closed[1:], s.errs[1:]
+	return closed, err
+}
+
+func Test_closeDueAuctions(t *testing.T) {
+	// first of two due auctions fails to close on first tick, it is closed on second tick
+	service := &dueAuctionsService{
+		closed: []int{1, 1},
+		errs:   []error{errors.New("some close error"), nil},
+	}
+
+	ticks := make(chan time.Time, 2)
+	ticks <- time.Now()
+	ticks <- time.Now()
+	close(ticks)
+
+	var out bytes.Buffer
+	closeDueAuctions(service, ticks, logger.New(&out, logger.LevelInfo, logger.FormatJSON))
+
+	var lines []map[string]interface{}
+	decoder := json.NewDecoder(&out)
+	for decoder.More() {
+		var line map[string]interface{}
+		require.Nil(t, decoder.Decode(&line))
+		lines = append(lines, line)
+	}
+
+	require.Len(t, lines, 2)
+	assert.Equal(t, "close due auctions failed", lines[0]["msg"])
+	assert.Equal(t, float64(1), lines[0]["closed"])
+	assert.Equal(t, "some close error", lines[0]["error"])
+	assert.Equal(t, "closed due auctions", lines[1]["msg"])
+	assert.Equal(t, float64(1), lines[1]["closed"])
+}
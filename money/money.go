@@ -0,0 +1,179 @@
+// Package money provides exact decimal amount of money stored as integer minor units.
+package money
+
+import (
+	"bytes"
+	"database/sql/driver"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// Scale is number of minor units in one unit, amounts have 2 decimal places
+const Scale = 100
+
+// Money is amount of money in minor units (cents). It is encoded as decimal string "12.50" in JSON and sql.
+type Money int64
+
+// MaxAmount is largest amount stored by numeric(14, 2) columns of amounts
+const MaxAmount Money = 999999999999_99
+
+// ErrOverflow is returned by arithmetic whose result does not fit into Money
+var ErrOverflow = errors.New("money amount overflow")
+
+// FromUnits returns money of whole units
+func FromUnits(units int64) Money {
+	return Money(units * Scale)
+}
+
+// Parse parses decimal string with at most 2 decimal places. Ex. "12", "12.5", "-0.25"
+func Parse(s string) (Money, error) {
+	str := s
+	negative := strings.HasPrefix(str, "-")
+	if negative {
+		str = str[1:]
+	}
+
+	units, cents, hasPoint := strings.Cut(str, ".")
+	if units == "" || (hasPoint && cents == "") || len(cents) > 2 || !isDigits(units) || !isDigits(cents) {
+		return 0, fmt.Errorf("invalid money amount %q", s)
+	}
+
+	for len(cents) < 2 {
+		cents += "0"
+	}
+
+	minor, err := strconv.ParseInt(units+cents, 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("invalid money amount %q: %w", s, err)
+	}
+
+	if negative {
+		minor = -minor
+	}
+
+	return Money(minor), nil
+}
+
+// MustParse is like Parse but panics on invalid amount. It is meant for constants and tests.
+func MustParse(s string) Money {
+	m, err := Parse(s)
+	if err != nil {
+		panic(err)
+	}
+
+	return m
+}
+
+func isDigits(s string) bool {
+	for _, r := range s {
+		if r < '0' || r > '9' {
+			return false
+		}
+	}
+
+	return true
+}
+
+// String returns amount as decimal string with 2 decimal places
+func (m Money) String() string {
+	sign := ""
+	minor := int64(m)
+	if minor < 0 {
+		sign = "-"
+	}
+
+	// avoid overflow of math.MinInt64 negation
+	units := minor / Scale
+	cents := minor % Scale
+	if units < 0 {
+		units = -units
+	}
+
+	if cents < 0 {
+		cents = -cents
+	}
+
+	return fmt.Sprintf("%s%d.%02d", sign, units, cents)
+}
+
+// Percent returns p percent of m rounded half away from zero to minor unit
+func (m Money) Percent(p float64) Money {
+	return Money(math.Round(float64(m) * p / 100))
+}
+
+// Mul returns m multiplied by n, or ErrOverflow when product does not fit into Money
+func (m Money) Mul(n int64) (Money, error) {
+	product := int64(m) * n
+	if m != 0 && (product/int64(m) != n || (m == -1 && n == math.MinInt64)) {
+		return 0, ErrOverflow
+	}
+
+	return Money(product), nil
+}
+
+// MarshalJSON encodes money as decimal string
+func (m Money) MarshalJSON() ([]byte, error) {
+	return json.Marshal(m.String())
+}
+
+// UnmarshalJSON decodes money from decimal string or JSON number. Numbers are parsed exactly, not as floats.
+func (m *Money) UnmarshalJSON(data []byte) error {
+	if bytes.Equal(data, []byte("null")) {
+		return nil
+	}
+
+	s := string(data)
+	if strings.HasPrefix(s, `"`) {
+		if err := json.Unmarshal(data, &s); err != nil {
+			return err
+		}
+	}
+
+	parsed, err := Parse(s)
+	if err != nil {
+		return err
+	}
+
+	*m = parsed
+	return nil
+}
+
+// Value implements driver.Valuer, money is stored as decimal string into numeric column
+func (m Money) Value() (driver.Value, error) {
+	return m.String(), nil
+}
+
+// Scan implements sql.Scanner for numeric columns
+func (m *Money) Scan(src interface{}) error {
+	switch v := src.(type) {
+	case nil:
+		*m = 0
+	case int64:
+		*m = FromUnits(v)
+	case float64:
+		// sqlite keeps numeric values as floats, amounts of 2 decimal places are restored exactly by rounding
+		*m = Money(math.Round(v * Scale))
+	case []byte:
+		return m.scanString(string(v))
+	case string:
+		return m.scanString(v)
+	default:
+		return fmt.Errorf("unsupported money source type %T", src)
+	}
+
+	return nil
+}
+
+func (m *Money) scanString(s string) error {
+	parsed, err := Parse(s)
+	if err != nil {
+		return err
+	}
+
+	*m = parsed
+	return nil
+}
@@ -0,0 +1,137 @@
+package money
+
+import (
+	"encoding/json"
+	"math"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParse(t *testing.T) {
+	for _, tc := range []struct {
+		input    string
+		expected Money
+		invalid  bool
+	}{
+		{input: "12", expected: 1200},
+		{input: "12.5", expected: 1250},
+		{input: "12.05", expected: 1205},
+		{input: "0.1", expected: 10},
+		{input: "-0.25", expected: -25},
+		{input: "007.10", expected: 710},
+		{input: "", invalid: true},
+		{input: "-", invalid: true},
+		{input: "12.", invalid: true},
+		{input: ".5", invalid: true},
+		{input: "12.345", invalid: true},
+		{input: "1e3", invalid: true},
+		{input: "+12", invalid: true},
+		{input: " 12", invalid: true},
+		{input: "99999999999999999999", invalid: true},
+	} {
+		t.Run(tc.input, func(t *testing.T) {
+			m, err := Parse(tc.input)
+			if tc.invalid {
+				assert.NotNil(t, err)
+				return
+			}
+
+			require.Nil(t, err)
+			assert.Equal(t, tc.expected, m)
+		})
+	}
+}
+
+func TestMoney_String(t *testing.T) {
+	assert.Equal(t, "12.50", Money(1250).String())
+	assert.Equal(t, "0.05", Money(5).String())
+	assert.Equal(t, "-0.05", Money(-5).String())
+	assert.Equal(t, "-12.00", FromUnits(-12).String())
+	assert.Equal(t, "-92233720368547758.08", Money(math.MinInt64).String())
+}
+
+func TestMoney_Percent(t *testing.T) {
+	assert.Equal(t, MustParse("200"), FromUnits(1000).Percent(20))
+	assert.Equal(t, MustParse("0.02"), MustParse("0.03").Percent(50))
+	assert.Equal(t, MustParse("-0.02"), MustParse("-0.03").Percent(50))
+}
+
+func TestMoney_Mul(t *testing.T) {
+	for _, tc := range []struct {
+		name     string
+		m        Money
+		n        int64
+		expected Money
+		overflow bool
+	}{
+		{name: "units", m: MustParse("12.50"), n: 3, expected: MustParse("37.50")},
+		{name: "zero", m: 0, n: math.MaxInt64, expected: 0},
+		{name: "negative", m: MustParse("-0.25"), n: 4, expected: MustParse("-1.00")},
+		{name: "max", m: math.MaxInt64, n: 1, expected: math.MaxInt64},
+		{name: "wraps to negative", m: MustParse("100000000000"), n: 1000000, overflow: true},
+		{name: "min negated", m: -1, n: math.MinInt64, overflow: true},
+		{name: "min times minus one", m: math.MinInt64, n: -1, overflow: true},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			product, err := tc.m.Mul(tc.n)
+			if tc.overflow {
+				assert.Equal(t, ErrOverflow, err)
+				return
+			}
+
+			require.Nil(t, err)
+			assert.Equal(t, tc.expected, product)
+		})
+	}
+}
+
+func TestMoney_JSON(t *testing.T) {
+	var req struct {
+		Price  Money `json:"price"`
+		Legacy Money `json:"legacy"`
+		Null   Money `json:"null"`
+	}
+
+	err := json.Unmarshal([]byte(`{"price":"0.10","legacy":20.5,"null":null}`), &req)
+	require.Nil(t, err)
+	assert.Equal(t, Money(10), req.Price)
+	assert.Equal(t, Money(2050), req.Legacy)
+	assert.Equal(t, Money(0), req.Null)
+
+	data, err := json.Marshal(req)
+	require.Nil(t, err)
+	assert.JSONEq(t, `{"price":"0.10","legacy":"20.50","null":"0.00"}`, string(data))
+
+	assert.NotNil(t, json.Unmarshal([]byte(`{"price":"0.001"}`), &req))
+	assert.NotNil(t, json.Unmarshal([]byte(`{"price":true}`), &req))
+}
+
+func TestMoney_Scan(t *testing.T) {
+	for _, tc := range []struct {
+		name     string
+		src      interface{}
+		expected Money
+	}{
+		{name: "numeric bytes", src: []byte("20.50"), expected: 2050},
+		{name: "string", src: "0.10", expected: 10},
+		{name: "integer", src: int64(20), expected: 2000},
+		{name: "float", src: 0.1 + 0.2, expected: 30},
+		{name: "null", src: nil, expected: 0},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			m := Money(1)
+			require.Nil(t, m.Scan(tc.src))
+			assert.Equal(t, tc.expected, m)
+		})
+	}
+
+	var m Money
+	assert.NotNil(t, m.Scan(true))
+	assert.NotNil(t, m.Scan([]byte("abc")))
+
+	v, err := Money(2050).Value()
+	require.Nil(t, err)
+	assert.Equal(t, "20.50", v)
+}